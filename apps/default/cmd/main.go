@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 
@@ -11,9 +13,23 @@ import (
 	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
 	profileV1 "github.com/antinvestor/apis/go/profile/v1"
 	"github.com/antinvestor/service-payments/config"
+	"github.com/antinvestor/service-payments/service/authorization"
 	"github.com/antinvestor/service-payments/service/events"
 	"github.com/antinvestor/service-payments/service/handlers"
+	"github.com/antinvestor/service-payments/service/limits"
 	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/msisdn"
+	"github.com/antinvestor/service-payments/service/provider"
+	"github.com/antinvestor/service-payments/service/provider/daraja"
+	"github.com/antinvestor/service-payments/service/provider/jenga"
+	"github.com/antinvestor/service-payments/service/reference"
+	"github.com/antinvestor/service-payments/service/repository"
+	"github.com/antinvestor/service-payments/service/router"
+	"github.com/antinvestor/service-payments/service/scheduler"
+	"github.com/antinvestor/service-payments/service/screening"
+	"github.com/antinvestor/service-payments/service/tenancy"
+	"github.com/antinvestor/service-payments/service/utility"
+	"github.com/shopspring/decimal"
 	"google.golang.org/grpc"
 	_ "gorm.io/driver/postgres"
 
@@ -35,7 +51,9 @@ func main() {
 	if !paymentConfig.DoMigration {
 		err = service.MigrateDatastore(ctx, paymentConfig.GetDatabaseMigrationPath(),
 			&models.Route{}, &models.Payment{}, &models.Status{}, &models.Prompt{},
-			&models.Cost{}, &models.PaymentLink{})
+			&models.Cost{}, &models.PaymentLink{}, &models.Account{}, &models.ReferenceSequence{},
+			&models.TransactionReference{}, &models.ApprovalPolicy{}, &models.Approval{}, &models.ApprovalDecision{}, &models.LimitCounter{},
+			&models.LimitConsumption{}, &models.Screening{})
 		if err != nil {
 			logger.WithError(err).Fatal("could not migrate successfully")
 		}
@@ -50,11 +68,18 @@ func main() {
 			Fatal("Database connection is nil - check DATABASE_URL and database availability")
 		return
 	}
-	if migrateErr := db.AutoMigrate(&models.Route{}, &models.Payment{}, &models.Cost{}, &models.Status{}, &models.Prompt{}, &models.PaymentLink{}); migrateErr != nil {
+	if migrateErr := db.AutoMigrate(&models.Route{}, &models.Payment{}, &models.Cost{}, &models.Status{},
+		&models.Prompt{}, &models.PaymentLink{}, &models.Account{}, &models.ReferenceSequence{},
+		&models.TransactionReference{}, &models.ApprovalPolicy{}, &models.Approval{},
+		&models.ApprovalDecision{}, &models.LimitCounter{}, &models.LimitConsumption{},
+		&models.Screening{}); migrateErr != nil {
 		logger.WithError(migrateErr).Fatal("Failed to auto-migrate database tables - cannot continue")
 		return
 	}
 
+	// Reads and writes are confined to the tenant and partition of the caller's claims
+	tenancy.SetPolicy(tenancy.NewPolicy(paymentConfig.TenancyPrivilegedServices))
+
 	// OAuth2 and service clients
 	oauth2ServiceHost := paymentConfig.GetOauth2ServiceURI()
 	oauth2ServiceURL := fmt.Sprintf("%s/oauth2/token", oauth2ServiceHost)
@@ -104,16 +129,23 @@ func main() {
 	unaryInterceptors := []grpc.UnaryServerInterceptor{}
 	streamInterceptors := []grpc.StreamServerInterceptor{}
 
+	var authorizer *authorization.Authorizer
 	if paymentConfig.SecurelyRunService {
 		logger.Info("Running service securely with TLS")
+		// Callers are authenticated first and then checked for the permission each method requires
+		authorizer = authorization.NewAuthorizer(
+			&authorization.PartitionRoles{Client: partitionCli, TTL: paymentConfig.PartitionRoleCacheTTL},
+			paymentConfig.IntegrationServices)
 		unaryInterceptors = append(
 			[]grpc.UnaryServerInterceptor{
 				service.UnaryAuthInterceptor(jwtAudience, paymentConfig.Oauth2JwtVerifyIssuer),
+				authorizer.UnaryInterceptor(),
 			},
 			unaryInterceptors...)
 		streamInterceptors = append(
 			[]grpc.StreamServerInterceptor{
 				service.StreamAuthInterceptor(jwtAudience, paymentConfig.Oauth2JwtVerifyIssuer),
+				authorizer.StreamInterceptor(),
 			},
 			streamInterceptors...)
 	} else {
@@ -125,24 +157,99 @@ func main() {
 		grpc.ChainStreamInterceptor(streamInterceptors...),
 	)
 
+	// Use NATS for pub/sub messaging
+	natsURL := paymentConfig.NatsURL
+	promptTopic := paymentConfig.PromptTopic
+	paymentLinkTopic := paymentConfig.PaymentLinkTopic
+	promptStatusQueryTopic := paymentConfig.PromptStatusQueryTopic
+	paymentLinkUpdateTopic := paymentConfig.PaymentLinkUpdateTopic
+	paymentLinkPollTopic := paymentConfig.PaymentLinkPollTopic
+	jengaPayoutTopic := paymentConfig.JengaPayoutTopic
+
+	// Credentials for the integration endpoints the payment service calls
+	credentials := utility.NewServiceCredentials(ctx, oauth2ServiceURL, service.JwtClientID(),
+		oauth2ServiceSecret, audienceList...)
+
+	// Payment service providers, keyed by the counter id of the routes they serve
+	providers := provider.NewRegistry()
+	providers.Register(paymentConfig.JengaCounterID, &jenga.Provider{
+		Transport:              service,
+		PromptTopic:            promptTopic,
+		StatusQueryTopic:       promptStatusQueryTopic,
+		PayoutTopic:            jengaPayoutTopic,
+		PaymentLinkTopic:       paymentLinkTopic,
+		PaymentLinkUpdateTopic: paymentLinkUpdateTopic,
+		BalanceURI:             paymentConfig.JengaBalanceURI,
+		Credentials:            credentials,
+	})
+	if paymentConfig.DarajaCounterID != "" {
+		providers.Register(paymentConfig.DarajaCounterID, &daraja.Provider{
+			Transport:        service,
+			PromptTopic:      paymentConfig.DarajaPromptTopic,
+			StatusQueryTopic: paymentConfig.DarajaPromptStatusQueryTopic,
+			PayoutTopic:      paymentConfig.DarajaPayoutTopic,
+		})
+	}
+
+	numbers, err := newNumberResolver(&paymentConfig)
+	if err != nil {
+		logger.WithError(err).Fatal("could not load ported numbers")
+	}
+
+	references, err := reference.ParseFormats(paymentConfig.TransactionReferenceFormats,
+		reference.MustParseFormat(reference.DefaultFormat))
+	if err != nil {
+		logger.WithError(err).Fatal("could not parse transaction reference formats")
+	}
+
+	transactionLimits, err := limits.ParseRules(paymentConfig.TransactionLimits)
+	if err != nil {
+		logger.WithError(err).Fatal("could not parse transaction limits")
+	}
+
+	releaseThresholds, err := utility.ParseThresholds(paymentConfig.ReleaseCheckerThresholds)
+	if err != nil {
+		logger.WithError(err).Fatal("could not parse release checker thresholds")
+	}
+
+	screener, err := newScreener(ctx, service, &paymentConfig)
+	if err != nil {
+		logger.WithError(err).Fatal("could not set up payment screening")
+	}
+
 	implementation := &handlers.PaymentServer{
 		Service:      service,
 		ProfileCli:   profileCli,
 		PartitionCli: partitionCli,
 		LedgerCli:    ledgerCli,
+		Providers:    providers,
+		Numbers:      numbers,
+		References:   references,
+		Authorizer:   authorizer,
+		Limits:       transactionLimits,
+
+		ReleaseThresholds: releaseThresholds,
+		Credentials:       credentials,
 	}
 
 	paymentV1.RegisterPaymentServiceServer(grpcServer, implementation)
 
+	var httpHandler http.Handler = router.NewRouter(implementation)
+	if paymentConfig.SecurelyRunService {
+		httpHandler = service.AuthenticationMiddleware(httpHandler, jwtAudience, paymentConfig.Oauth2JwtVerifyIssuer)
+	}
+
 	serviceOptions := []frame.Option{
 		frame.WithDatastore(),
 		frame.WithGRPCServer(grpcServer),
+		frame.WithHTTPHandler(httpHandler),
 		frame.WithEnableGRPCServerReflection(),
 		frame.WithRegisterEvents(
 			&events.PaymentSave{Service: service},
 			&events.PaymentInQueue{Service: service},
-			&events.PaymentOutQueue{Service: service},
+			&events.PaymentOutQueue{Service: service, Providers: providers},
 			&events.PaymentInRoute{Service: service},
+			&events.PaymentScreen{Service: service, Screener: screener},
 			&events.PaymentOutRoute{Service: service, ProfileCli: profileCli},
 			&events.PromptSave{Service: service},
 			&events.PaymentLinkSave{Service: service},
@@ -150,15 +257,53 @@ func main() {
 		),
 	}
 
-	// Use NATS for pub/sub messaging
-	natsURL := paymentConfig.NatsURL
-	promptTopic := paymentConfig.PromptTopic
-	paymentLinkTopic := paymentConfig.PaymentLinkTopic
+	// Background jobs, every replica schedules them and each run happens in one replica only
+	jobs := scheduler.NewScheduler(service,
+		scheduler.Exclusive(service, &scheduler.PromptSweeper{
+			Service:    service,
+			Providers:  providers,
+			QueryAfter: paymentConfig.PromptStatusQueryAfter,
+			Timeout:    paymentConfig.PromptTimeout,
+			Every:      paymentConfig.PromptSweepInterval,
+		}),
+		scheduler.Exclusive(service, &scheduler.PaymentLinkExpirer{
+			Service: service,
+			Every:   paymentConfig.PaymentLinkExpiryInterval,
+		}),
+		scheduler.Exclusive(service, &scheduler.PaymentLinkPoller{
+			Service:   service,
+			PollTopic: paymentLinkPollTopic,
+			Every:     paymentConfig.PaymentLinkPollInterval,
+		}),
+		scheduler.Exclusive(service, &scheduler.LimitCounterPurger{
+			Service: service,
+			Every:   paymentConfig.LimitCounterPurgeInterval,
+		}),
+	)
 
 	serviceOptions = append(serviceOptions,
 		frame.WithRegisterPublisher(promptTopic, natsURL+promptTopic),
 		frame.WithRegisterPublisher(paymentLinkTopic, natsURL+paymentLinkTopic),
+		frame.WithRegisterPublisher(promptStatusQueryTopic, natsURL+promptStatusQueryTopic),
+		frame.WithRegisterPublisher(paymentLinkUpdateTopic, natsURL+paymentLinkUpdateTopic),
+		frame.WithRegisterPublisher(paymentLinkPollTopic, natsURL+paymentLinkPollTopic),
+		frame.WithBackgroundConsumer(jobs.Run),
 	)
+	if jengaPayoutTopic != "" {
+		serviceOptions = append(serviceOptions,
+			frame.WithRegisterPublisher(jengaPayoutTopic, natsURL+jengaPayoutTopic))
+	}
+	if paymentConfig.DarajaCounterID != "" {
+		for _, topic := range []string{
+			paymentConfig.DarajaPromptTopic,
+			paymentConfig.DarajaPromptStatusQueryTopic,
+			paymentConfig.DarajaPayoutTopic,
+		} {
+			if topic != "" {
+				serviceOptions = append(serviceOptions, frame.WithRegisterPublisher(topic, natsURL+topic))
+			}
+		}
+	}
 
 	service.Init(ctx, serviceOptions...)
 
@@ -170,3 +315,59 @@ func main() {
 		logger.WithError(runErr).Fatal("could not run Server")
 	}
 }
+
+// newNumberResolver sets up prompt number validation, overriding the operator prefix tables
+// with the ported numbers file when one is configured.
+func newNumberResolver(paymentConfig *config.PaymentConfig) (*msisdn.Resolver, error) {
+	if paymentConfig.PortedNumbersFile == "" {
+		return msisdn.NewResolver(paymentConfig.PromptDefaultCountry, nil), nil
+	}
+
+	file, err := os.Open(paymentConfig.PortedNumbersFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	ported, err := msisdn.LoadPortedNumbers(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", paymentConfig.PortedNumbersFile, err)
+	}
+	return msisdn.NewResolver(paymentConfig.PromptDefaultCountry, ported), nil
+}
+
+// newScreener builds the rules screener released outbound payments go through.
+func newScreener(
+	ctx context.Context,
+	service *frame.Service,
+	paymentConfig *config.PaymentConfig,
+) (screening.Screener, error) {
+	thresholds, err := utility.ParseThresholds(paymentConfig.ScreeningNewBeneficiaryThresholds)
+	if err != nil {
+		return nil, err
+	}
+	rules := &screening.Rules{
+		History:                  repository.NewPaymentRepository(ctx, service),
+		ReviewScore:              paymentConfig.ScreeningReviewScore,
+		RejectScore:              paymentConfig.ScreeningRejectScore,
+		UnusualFactor:            decimal.NewFromFloat(paymentConfig.ScreeningUnusualAmountFactor),
+		MinHistory:               paymentConfig.ScreeningMinHistory,
+		HistoryWindow:            paymentConfig.ScreeningHistoryWindow,
+		NewBeneficiaryThresholds: thresholds,
+	}
+	if paymentConfig.ScreeningWatchlistFile == "" {
+		return rules, nil
+	}
+
+	file, err := os.Open(paymentConfig.ScreeningWatchlistFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rules.Watchlist, err = screening.LoadWatchlist(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", paymentConfig.ScreeningWatchlistFile, err)
+	}
+	return rules, nil
+}
@@ -7,10 +7,12 @@ require (
 	github.com/antinvestor/apis/go/partition v1.40.2
 	github.com/antinvestor/apis/go/payment v1.40.2
 	github.com/antinvestor/apis/go/profile v1.40.4
+	github.com/gorilla/mux v1.8.1
 	github.com/pitabwire/frame v1.43.7
 	github.com/shopspring/decimal v1.4.0
 	github.com/testcontainers/testcontainers-go v0.32.0
 	go.uber.org/mock v0.6.0
+	golang.org/x/oauth2 v0.30.0
 	google.golang.org/genproto v0.0.0-20250811230008-5f3141c8851a
 	google.golang.org/grpc v1.75.0
 	gorm.io/datatypes v1.2.5
@@ -102,7 +104,6 @@ require (
 	gocloud.dev v0.41.0 // indirect
 	golang.org/x/crypto v0.41.0 // indirect
 	golang.org/x/net v0.43.0 // indirect
-	golang.org/x/oauth2 v0.30.0 // indirect
 	golang.org/x/sync v0.16.0 // indirect
 	golang.org/x/sys v0.35.0 // indirect
 	golang.org/x/text v0.28.0 // indirect
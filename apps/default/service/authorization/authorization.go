@@ -0,0 +1,211 @@
+// Package authorization decides which payment operations a caller may perform. Permissions
+// are granted by the roles in the caller's claims and the roles the caller holds in its
+// partition, integration services are granted the operations they report provider outcomes
+// with.
+package authorization
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/pitabwire/frame"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrUnauthenticated = status.Error(codes.Unauthenticated, "Request carries no authenticated claims")
+
+	ErrPermissionDenied = status.Error(codes.PermissionDenied, "Caller is not permitted to perform this operation")
+
+	ErrRolesUnavailable = status.Error(codes.Unavailable, "Could not resolve the caller's partition roles")
+)
+
+// Permissions on payment operations.
+const (
+	PermissionSend         = "payments.send"
+	PermissionReceive      = "payments.receive"
+	PermissionRelease      = "payments.release"
+	PermissionStatus       = "payments.status"
+	PermissionStatusUpdate = "payments.status_update"
+	PermissionSearch       = "payments.search"
+	PermissionReconcile    = "payments.reconcile"
+	PermissionPrompt       = "payments.prompt"
+	PermissionPaymentLink  = "payments.payment_link"
+	PermissionAccounts     = "payments.accounts"
+	PermissionApprove      = "payments.approve"
+	PermissionPolicies     = "payments.approval_policies"
+	PermissionScreening    = "payments.screening_review"
+)
+
+// Roles with built in permissions. Partition roles may grant further permissions through a
+// comma separated "permissions" property.
+const (
+	RoleOwner          = "owner"
+	RoleAdmin          = "admin"
+	RoleMaker          = "maker"
+	RoleChecker        = "checker"
+	RoleViewer         = "viewer"
+	RoleSystemInternal = "system_internal"
+)
+
+// PermissionsProperty is the partition role property listing the permissions the role grants.
+const PermissionsProperty = "permissions"
+
+var roleGrants = map[string][]string{
+	RoleOwner: {
+		PermissionSend, PermissionReceive, PermissionRelease, PermissionStatus, PermissionSearch,
+		PermissionReconcile, PermissionPrompt, PermissionPaymentLink, PermissionAccounts, PermissionApprove,
+		PermissionPolicies, PermissionScreening,
+	},
+	RoleAdmin: {
+		PermissionSend, PermissionReceive, PermissionRelease, PermissionStatus, PermissionSearch,
+		PermissionReconcile, PermissionPrompt, PermissionPaymentLink, PermissionAccounts, PermissionApprove,
+		PermissionPolicies, PermissionScreening,
+	},
+	RoleMaker: {
+		PermissionSend, PermissionReceive, PermissionStatus, PermissionSearch, PermissionPrompt,
+		PermissionPaymentLink,
+	},
+	RoleChecker: {PermissionRelease, PermissionApprove, PermissionScreening, PermissionStatus, PermissionSearch},
+	RoleViewer:  {PermissionStatus, PermissionSearch},
+	RoleSystemInternal: {
+		PermissionSend, PermissionReceive, PermissionStatus, PermissionSearch, PermissionPrompt,
+		PermissionPaymentLink,
+	},
+}
+
+// integrationGrants are held by the integration services that report what providers did
+// with payments.
+var integrationGrants = []string{PermissionStatusUpdate, PermissionReceive, PermissionStatus, PermissionSearch}
+
+// reservedPermissions are only held by integration services, partition roles listing them do
+// not grant them.
+var reservedPermissions = []string{PermissionStatusUpdate}
+
+// Role is a role a caller holds and the permissions it grants beyond the built in ones.
+type Role struct {
+	Name        string
+	Permissions []string
+}
+
+// RoleSource resolves the roles a caller holds in its partition.
+type RoleSource interface {
+	Roles(ctx context.Context, claims *frame.AuthenticationClaims) ([]Role, error)
+}
+
+// Authorizer checks callers hold the permission an operation requires.
+type Authorizer struct {
+	partitionRoles      RoleSource
+	integrationServices []string
+}
+
+// NewAuthorizer returns an authorizer resolving partition roles from the source, which may be
+// nil to only consider the roles in claims. Integration services are internal service
+// accounts named in the comma separated list.
+func NewAuthorizer(partitionRoles RoleSource, integrationServices string) *Authorizer {
+	authorizer := &Authorizer{partitionRoles: partitionRoles}
+	for _, name := range strings.Split(integrationServices, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			authorizer.integrationServices = append(authorizer.integrationServices, name)
+		}
+	}
+	return authorizer
+}
+
+// Authorize returns nil when the caller of the context holds the permission.
+func (a *Authorizer) Authorize(ctx context.Context, permission string) error {
+	claims := frame.ClaimsFromContext(ctx)
+	if claims == nil {
+		return ErrUnauthenticated
+	}
+
+	permissions, err := a.Permissions(ctx, claims)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(permissions, permission) {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// Permissions returns every permission the claims grant.
+func (a *Authorizer) Permissions(ctx context.Context, claims *frame.AuthenticationClaims) ([]string, error) {
+	var permissions []string
+	if a.isIntegration(claims) {
+		permissions = append(permissions, integrationGrants...)
+	}
+
+	roles, err := a.roles(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		permissions = append(permissions, roleGrants[roleName(role.Name)]...)
+		for _, permission := range role.Permissions {
+			if !slices.Contains(reservedPermissions, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	return permissions, nil
+}
+
+// Roles returns the names of the roles the caller of the context holds, in its claims and
+// in its partition. A nil authorizer only reads the claims.
+func (a *Authorizer) Roles(ctx context.Context) ([]string, error) {
+	claims := frame.ClaimsFromContext(ctx)
+	if claims == nil {
+		return nil, ErrUnauthenticated
+	}
+	if a == nil {
+		return claims.GetRoles(), nil
+	}
+
+	roles, err := a.roles(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	return names, nil
+}
+
+func (a *Authorizer) roles(ctx context.Context, claims *frame.AuthenticationClaims) ([]Role, error) {
+	roles := make([]Role, 0, len(claims.GetRoles()))
+	for _, name := range claims.GetRoles() {
+		roles = append(roles, Role{Name: name})
+	}
+	if a.partitionRoles != nil && !isInternal(claims) {
+		partitionRoles, err := a.partitionRoles.Roles(ctx, claims)
+		if err != nil {
+			return nil, ErrRolesUnavailable
+		}
+		roles = append(roles, partitionRoles...)
+	}
+	return roles, nil
+}
+
+func (a *Authorizer) isIntegration(claims *frame.AuthenticationClaims) bool {
+	return isInternal(claims) && slices.Contains(a.integrationServices, claims.GetServiceName())
+}
+
+// isInternal reports claims of an internal service account, which hold a single system role.
+func isInternal(claims *frame.AuthenticationClaims) bool {
+	roles := claims.GetRoles()
+	return len(roles) == 1 && strings.HasPrefix(roles[0], RoleSystemInternal)
+}
+
+// roleName folds role names so "Checker" and "checker" grant the same permissions, and system
+// roles such as "system_internal_payments" grant the internal permissions.
+func roleName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if strings.HasPrefix(name, RoleSystemInternal) {
+		return RoleSystemInternal
+	}
+	return name
+}
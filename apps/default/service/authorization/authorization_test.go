@@ -0,0 +1,136 @@
+package authorization
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/pitabwire/frame"
+	"google.golang.org/grpc"
+)
+
+type staticRoles struct {
+	roles []Role
+	err   error
+}
+
+func (s *staticRoles) Roles(context.Context, *frame.AuthenticationClaims) ([]Role, error) {
+	return s.roles, s.err
+}
+
+func claimsContext(serviceName string, roles ...string) context.Context {
+	claims := &frame.AuthenticationClaims{
+		TenantID:    "tenant",
+		PartitionID: "partition",
+		ServiceName: serviceName,
+		Roles:       roles,
+	}
+	claims.Subject = "profile"
+	return claims.ClaimsToContext(context.Background())
+}
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name           string
+		ctx            context.Context
+		partitionRoles []Role
+		permission     string
+		wantErr        error
+	}{
+		{name: "no claims", ctx: context.Background(), permission: PermissionStatus, wantErr: ErrUnauthenticated},
+		{name: "caller without roles", ctx: claimsContext(""), permission: PermissionSend,
+			wantErr: ErrPermissionDenied},
+		{name: "maker sends", ctx: claimsContext("", "Maker"), permission: PermissionSend},
+		{name: "maker cannot release", ctx: claimsContext("", "maker"), permission: PermissionRelease,
+			wantErr: ErrPermissionDenied},
+		{name: "partition checker releases", ctx: claimsContext(""), permission: PermissionRelease,
+			partitionRoles: []Role{{Name: RoleChecker}}},
+		{name: "partition role grants listed permissions", ctx: claimsContext(""), permission: PermissionAccounts,
+			partitionRoles: []Role{{Name: "treasury", Permissions: []string{PermissionAccounts}}}},
+		{name: "partition roles cannot grant status updates", ctx: claimsContext(""),
+			permission: PermissionStatusUpdate, wantErr: ErrPermissionDenied,
+			partitionRoles: []Role{{Name: "integrations", Permissions: []string{PermissionStatusUpdate}}}},
+		{name: "owner cannot update statuses", ctx: claimsContext("", RoleOwner), permission: PermissionStatusUpdate,
+			wantErr: ErrPermissionDenied},
+		{name: "integration updates statuses", ctx: claimsContext("service_jenga_api", RoleSystemInternal),
+			permission: PermissionStatusUpdate},
+		{name: "other internal services cannot update statuses",
+			ctx: claimsContext("service_notification", RoleSystemInternal), permission: PermissionStatusUpdate,
+			wantErr: ErrPermissionDenied},
+		{name: "internal services send", ctx: claimsContext("service_notification", RoleSystemInternal),
+			permission: PermissionSend},
+		{name: "internal services ignore partition roles",
+			ctx: claimsContext("service_notification", RoleSystemInternal), permission: PermissionRelease,
+			partitionRoles: []Role{{Name: RoleOwner}}, wantErr: ErrPermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorizer := NewAuthorizer(&staticRoles{roles: tt.partitionRoles}, "service_jenga_api, service_daraja_api")
+			if err := authorizer.Authorize(tt.ctx, tt.permission); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authorize(%s) error = %v, want %v", tt.permission, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthorizeWithoutPartitionRoles(t *testing.T) {
+	authorizer := NewAuthorizer(&staticRoles{err: errors.New("partition service is down")}, "")
+	if err := authorizer.Authorize(claimsContext("", RoleAdmin), PermissionSend); !errors.Is(err, ErrRolesUnavailable) {
+		t.Fatalf("Authorize() error = %v, want ErrRolesUnavailable", err)
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	authorizer := NewAuthorizer(nil, "service_jenga_api")
+	interceptor := authorizer.UnaryInterceptor()
+	handler := func(context.Context, any) (any, error) { return "handled", nil }
+
+	tests := []struct {
+		method  string
+		ctx     context.Context
+		wantErr error
+	}{
+		{method: paymentV1.PaymentService_Send_FullMethodName, ctx: claimsContext("", RoleMaker)},
+		{method: paymentV1.PaymentService_Release_FullMethodName, ctx: claimsContext("", RoleMaker),
+			wantErr: ErrPermissionDenied},
+		{method: paymentV1.PaymentService_StatusUpdate_FullMethodName,
+			ctx: claimsContext("service_jenga_api", RoleSystemInternal)},
+		{method: paymentV1.PaymentService_StatusUpdate_FullMethodName, ctx: claimsContext("", RoleAdmin),
+			wantErr: ErrPermissionDenied},
+		{method: "/payment.v1.PaymentService/Unknown", ctx: claimsContext("", RoleOwner),
+			wantErr: ErrPermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			response, err := interceptor(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("interceptor error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && response != "handled" {
+				t.Fatalf("interceptor did not call the handler")
+			}
+		})
+	}
+}
+
+func TestPartitionRolesAreCached(t *testing.T) {
+	roles := &PartitionRoles{TTL: time.Minute}
+	roles.store("access", []Role{{Name: RoleChecker}})
+
+	cached, ok := roles.cached("access")
+	if !ok || len(cached) != 1 || cached[0].Name != RoleChecker {
+		t.Fatalf("cached() = %v, %v", cached, ok)
+	}
+	if _, ok = roles.cached("other"); ok {
+		t.Fatalf("cached() found roles for an access that was never resolved")
+	}
+
+	roles.cache["access"] = cachedRoles{roles: cached, expiresAt: time.Now().Add(-time.Second)}
+	if _, ok = roles.cached("access"); ok {
+		t.Fatalf("cached() returned expired roles")
+	}
+}
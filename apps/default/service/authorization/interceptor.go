@@ -0,0 +1,50 @@
+package authorization
+
+import (
+	"context"
+
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"google.golang.org/grpc"
+)
+
+// MethodPermissions are the permissions the payment service RPCs require. Methods missing
+// from the list are denied.
+var MethodPermissions = map[string]string{
+	paymentV1.PaymentService_Send_FullMethodName:              PermissionSend,
+	paymentV1.PaymentService_Receive_FullMethodName:           PermissionReceive,
+	paymentV1.PaymentService_InitiatePrompt_FullMethodName:    PermissionPrompt,
+	paymentV1.PaymentService_CreatePaymentLink_FullMethodName: PermissionPaymentLink,
+	paymentV1.PaymentService_Status_FullMethodName:            PermissionStatus,
+	paymentV1.PaymentService_StatusUpdate_FullMethodName:      PermissionStatusUpdate,
+	paymentV1.PaymentService_Release_FullMethodName:           PermissionRelease,
+	paymentV1.PaymentService_Search_FullMethodName:            PermissionSearch,
+	paymentV1.PaymentService_Reconcile_FullMethodName:         PermissionReconcile,
+}
+
+func (a *Authorizer) authorizeMethod(ctx context.Context, fullMethod string) error {
+	permission, ok := MethodPermissions[fullMethod]
+	if !ok {
+		return ErrPermissionDenied
+	}
+	return a.Authorize(ctx, permission)
+}
+
+// UnaryInterceptor authorizes unary calls, it runs after the interceptor that authenticates them.
+func (a *Authorizer) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := a.authorizeMethod(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor authorizes streaming calls, it runs after the interceptor that authenticates them.
+func (a *Authorizer) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := a.authorizeMethod(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,105 @@
+package authorization
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	partitionv1 "github.com/antinvestor/apis/go/partition/v1"
+	"github.com/pitabwire/frame"
+)
+
+// PartitionRoles resolves the roles a caller holds in its partition from the partition
+// service. Roles are cached per access for a while, so authorizing a request does not cost a
+// call to the partition service each time.
+type PartitionRoles struct {
+	Client *partitionv1.PartitionClient
+	TTL    time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedRoles
+}
+
+type cachedRoles struct {
+	roles     []Role
+	expiresAt time.Time
+}
+
+// Roles returns the partition roles of the access the claims were issued for, looking the
+// access up by profile when the claims do not name it.
+func (pr *PartitionRoles) Roles(ctx context.Context, claims *frame.AuthenticationClaims) ([]Role, error) {
+	key := claims.GetAccessID()
+	if key == "" {
+		key = claims.GetPartitionID() + "/" + claims.Subject
+	}
+	if roles, ok := pr.cached(key); ok {
+		return roles, nil
+	}
+
+	accessID := claims.GetAccessID()
+	if accessID == "" {
+		if claims.GetPartitionID() == "" || claims.Subject == "" {
+			return nil, nil
+		}
+		access, err := pr.Client.GetAccessByPartitionIdProfileId(ctx, claims.GetPartitionID(), claims.Subject)
+		if err != nil {
+			return nil, err
+		}
+		accessID = access.GetAccessId()
+		if accessID == "" {
+			return nil, errors.New("partition service returned no access")
+		}
+	}
+
+	accessRoles, err := pr.Client.ListAccessRole(ctx, accessID)
+	if err != nil {
+		return nil, err
+	}
+	var roles []Role
+	for accessRole := range accessRoles {
+		partitionRole := accessRole.GetRole()
+		if partitionRole == nil {
+			continue
+		}
+		role := Role{Name: partitionRole.GetName()}
+		for _, permission := range strings.Split(partitionRole.GetProperties()[PermissionsProperty], ",") {
+			if permission = strings.TrimSpace(permission); permission != "" {
+				role.Permissions = append(role.Permissions, permission)
+			}
+		}
+		roles = append(roles, role)
+	}
+
+	pr.store(key, roles)
+	return roles, nil
+}
+
+func (pr *PartitionRoles) cached(key string) ([]Role, bool) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	entry, ok := pr.cache[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.roles, true
+}
+
+func (pr *PartitionRoles) store(key string, roles []Role) {
+	if pr.TTL <= 0 {
+		return
+	}
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	if pr.cache == nil {
+		pr.cache = make(map[string]cachedRoles)
+	}
+	now := time.Now()
+	for cachedKey, entry := range pr.cache {
+		if now.After(entry.expiresAt) {
+			delete(pr.cache, cachedKey)
+		}
+	}
+	pr.cache[key] = cachedRoles{roles: roles, expiresAt: time.Now().Add(pr.TTL)}
+}
@@ -0,0 +1,266 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/service-payments/service/events"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/provider"
+	"github.com/antinvestor/service-payments/service/repository"
+	"github.com/pitabwire/frame"
+	"gorm.io/gorm"
+)
+
+// partitionFromContext returns the partition of the authenticated caller, accounts are
+// registered and looked up within it.
+func partitionFromContext(ctx context.Context) string {
+	if claims := frame.ClaimsFromContext(ctx); claims != nil {
+		return claims.GetPartitionID()
+	}
+	return ""
+}
+
+// RegisterAccount registers a merchant collection account in the caller's partition once the
+// provider it is held with has validated it.
+func (pb *paymentBusiness) RegisterAccount(ctx context.Context, req *models.AccountRequest) (*models.Account, error) {
+	logger := pb.service.Log(ctx).WithField("accountNumber", req.AccountNumber)
+
+	account := &models.Account{
+		AccountNumber: strings.TrimSpace(req.AccountNumber),
+		CountryCode:   strings.ToUpper(strings.TrimSpace(req.CountryCode)),
+		Name:          strings.TrimSpace(req.Name),
+		Currency:      strings.ToUpper(strings.TrimSpace(req.Currency)),
+		CounterID:     strings.TrimSpace(req.CounterID),
+		RouteID:       strings.TrimSpace(req.RouteID),
+		State:         int32(commonv1.STATE_ACTIVE),
+	}
+	if account.AccountNumber == "" || len(account.CountryCode) != 2 ||
+		(account.Currency != "" && len(account.Currency) != 3) {
+		return nil, ErrInvalidAccountRequest
+	}
+
+	if err := pb.validateAccount(ctx, account); err != nil {
+		return nil, err
+	}
+
+	accountRepo := repository.NewAccountRepository(ctx, pb.service)
+	existing, err := accountRepo.GetRegistered(ctx, partitionFromContext(ctx), account.CounterID, account.AccountNumber)
+	switch {
+	case err == nil:
+		return pb.reactivateAccount(ctx, existing, account)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	account.GenID(ctx)
+	registered, err := accountRepo.Register(ctx, account)
+	if err != nil {
+		logger.WithError(err).Warn("could not save account")
+		return nil, err
+	}
+	if !registered {
+		return nil, ErrAccountAlreadyRegistered
+	}
+	logger.WithField("accountId", account.ID).Info("collection account registered")
+	return account, nil
+}
+
+// reactivateAccount registers a deactivated account again with the details just validated,
+// keeping its ID so links and prompts made against it stay with it.
+func (pb *paymentBusiness) reactivateAccount(
+	ctx context.Context,
+	existing, account *models.Account,
+) (*models.Account, error) {
+	if existing.IsRegistered() {
+		return nil, ErrAccountAlreadyRegistered
+	}
+
+	existing.CountryCode = account.CountryCode
+	existing.Name = account.Name
+	existing.Currency = account.Currency
+	existing.RouteID = account.RouteID
+	existing.ValidatedAt = account.ValidatedAt
+	existing.State = account.State
+	if err := repository.NewAccountRepository(ctx, pb.service).Save(ctx, existing); err != nil {
+		pb.service.Log(ctx).WithError(err).WithField("accountId", existing.ID).Warn("could not save account")
+		return nil, err
+	}
+	pb.service.Log(ctx).WithField("accountId", existing.ID).Info("collection account registered again")
+	return existing, nil
+}
+
+// ListAccounts returns the collection accounts registered in the caller's partition.
+func (pb *paymentBusiness) ListAccounts(ctx context.Context) ([]*models.Account, error) {
+	return repository.NewAccountRepository(ctx, pb.service).ListByPartition(ctx, partitionFromContext(ctx))
+}
+
+// GetAccount returns an account of the caller's partition.
+func (pb *paymentBusiness) GetAccount(ctx context.Context, id string) (*models.Account, error) {
+	account, err := repository.NewAccountRepository(ctx, pb.service).
+		GetByPartitionAndID(ctx, partitionFromContext(ctx), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccountDoesNotExist
+		}
+		return nil, err
+	}
+	return account, nil
+}
+
+// UpdateAccount changes the editable details of a registered account.
+func (pb *paymentBusiness) UpdateAccount(
+	ctx context.Context,
+	id string,
+	req *models.AccountUpdateRequest,
+) (*models.Account, error) {
+	account, err := pb.collectionAccount(ctx, id, "")
+	if err != nil {
+		return nil, err
+	}
+
+	revalidate := false
+	if req.Name != nil {
+		account.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Currency != nil {
+		currency := strings.ToUpper(strings.TrimSpace(*req.Currency))
+		if len(currency) != 3 {
+			return nil, ErrInvalidAccountRequest
+		}
+		revalidate = revalidate || currency != account.Currency
+		account.Currency = currency
+	}
+	if req.RouteID != nil {
+		routeID := strings.TrimSpace(*req.RouteID)
+		revalidate = revalidate || routeID != account.RouteID
+		account.RouteID = routeID
+	}
+
+	if revalidate {
+		if err = pb.validateAccount(ctx, account); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = repository.NewAccountRepository(ctx, pb.service).Save(ctx, account); err != nil {
+		pb.service.Log(ctx).WithError(err).WithField("accountId", id).Warn("could not save account")
+		return nil, err
+	}
+	return account, nil
+}
+
+// DeactivateAccount stops prompts and payment links from collecting into an account.
+func (pb *paymentBusiness) DeactivateAccount(ctx context.Context, id string) (*models.Account, error) {
+	account, err := pb.collectionAccount(ctx, id, "")
+	if err != nil {
+		return nil, err
+	}
+
+	account.State = int32(commonv1.STATE_INACTIVE)
+	if err = repository.NewAccountRepository(ctx, pb.service).Save(ctx, account); err != nil {
+		pb.service.Log(ctx).WithError(err).WithField("accountId", id).Warn("could not save account")
+		return nil, err
+	}
+	return account, nil
+}
+
+// collectionAccount returns an active registered account of the caller's partition, checking
+// it collects in the given currency when one is given.
+func (pb *paymentBusiness) collectionAccount(ctx context.Context, id, currency string) (*models.Account, error) {
+	account, err := pb.GetAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !account.IsRegistered() {
+		return nil, ErrAccountInactive
+	}
+	if currency != "" && account.Currency != "" && !strings.EqualFold(currency, account.Currency) {
+		return nil, ErrAccountCurrencyMismatch
+	}
+	return account, nil
+}
+
+// validateAccount settles the provider an account is held with, from its counter or the
+// counter of its default route, and asks that provider to confirm the account exists.
+// Providers that cannot look accounts up leave the account unvalidated.
+func (pb *paymentBusiness) validateAccount(ctx context.Context, account *models.Account) error {
+	logger := pb.service.Log(ctx).WithField("accountNumber", account.AccountNumber)
+
+	if account.RouteID != "" {
+		route, err := repository.NewRouteRepository(ctx, pb.service).GetByID(ctx, account.RouteID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidAccountRequest
+			}
+			return err
+		}
+		if account.CounterID == "" {
+			account.CounterID = route.CounterID
+		}
+		if route.CounterID != account.CounterID {
+			logger.WithField("routeId", route.ID).Warn("account route is served by another provider")
+			return ErrInvalidAccountRequest
+		}
+	}
+
+	psp, err := pb.providers.Lookup(account.CounterID)
+	if err != nil {
+		logger.WithError(err).Warn("account names no known provider")
+		return ErrInvalidAccountRequest
+	}
+
+	balance, err := psp.Balance(ctx, account)
+	switch {
+	case errors.Is(err, provider.ErrNotSupported):
+		logger.WithField("provider", psp.Name()).Info("provider cannot validate accounts, registering it unvalidated")
+		account.ValidatedAt = nil
+		return nil
+	case err != nil:
+		logger.WithError(err).WithField("provider", psp.Name()).Warn("provider did not validate account")
+		return ErrAccountValidationFailed
+	}
+
+	if balance.Currency != "" {
+		if account.Currency == "" {
+			account.Currency = strings.ToUpper(balance.Currency)
+		}
+		if !strings.EqualFold(balance.Currency, account.Currency) {
+			logger.WithField("providerCurrency", balance.Currency).Warn("account currency differs from the provider's")
+			return ErrAccountValidationFailed
+		}
+	}
+
+	validatedAt := time.Now()
+	account.ValidatedAt = &validatedAt
+	return nil
+}
+
+// recordPromptAccount returns the account of the caller's partition with the raw account
+// details of a prompt, recording it when it is not known yet.
+func (pb *paymentBusiness) recordPromptAccount(ctx context.Context, req *paymentV1.Account) (*models.Account, error) {
+	account, err := repository.NewAccountRepository(ctx, pb.service).
+		GetByAccountNumber(ctx, partitionFromContext(ctx), req.GetAccountNumber())
+	if err == nil {
+		return account, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	account = &models.Account{
+		AccountNumber: req.GetAccountNumber(),
+		CountryCode:   req.GetCountryCode(),
+		Name:          req.GetName(),
+	}
+	account.GenID(ctx)
+	event := events.AccountSave{Service: pb.service}
+	if err = pb.service.Emit(ctx, event.Name(), account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
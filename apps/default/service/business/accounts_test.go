@@ -0,0 +1,114 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/provider"
+	"github.com/antinvestor/service-payments/service/repository/repositorytest"
+
+	"github.com/pitabwire/frame"
+)
+
+// unvalidatingProvider is a provider that cannot look accounts up.
+type unvalidatingProvider struct {
+	provider.Provider
+}
+
+func (p *unvalidatingProvider) Name() string {
+	return "unvalidating"
+}
+
+func (p *unvalidatingProvider) Balance(_ context.Context, _ *models.Account) (*provider.Balance, error) {
+	return nil, provider.ErrNotSupported
+}
+
+// newAccountBusiness returns a payment business on a service with a datastore and a context of a
+// caller in a partition.
+func newAccountBusiness(t *testing.T) (context.Context, *paymentBusiness) {
+	t.Helper()
+	ctx, service := repositorytest.NewService(t, nil)
+
+	providers := provider.NewRegistry()
+	providers.Register("jenga", &unvalidatingProvider{})
+	pb, err := NewPaymentBusiness(ctx, service, nil, nil, nil, providers, nil, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := &frame.AuthenticationClaims{TenantID: "tenant", PartitionID: "partition"}
+	return claims.ClaimsToContext(ctx), pb.(*paymentBusiness)
+}
+
+func accountRequest() *models.AccountRequest {
+	return &models.AccountRequest{
+		AccountNumber: "1100161816677", CountryCode: "ke", Name: "Duka", Currency: "kes", CounterID: "jenga",
+	}
+}
+
+func TestRegisterAccount(t *testing.T) {
+	ctx, pb := newAccountBusiness(t)
+
+	account, err := pb.RegisterAccount(ctx, accountRequest())
+	if err != nil {
+		t.Fatalf("RegisterAccount() error = %v", err)
+	}
+	if !account.IsRegistered() || account.PartitionID != "partition" || account.Currency != "KES" {
+		t.Errorf("RegisterAccount() = %+v, want an active KES account in the caller's partition", account)
+	}
+
+	if _, err = pb.RegisterAccount(ctx, accountRequest()); !errors.Is(err, ErrAccountAlreadyRegistered) {
+		t.Errorf("RegisterAccount() again error = %v, want ErrAccountAlreadyRegistered", err)
+	}
+
+	// A deactivated account can be registered again, it keeps its ID
+	if _, err = pb.DeactivateAccount(ctx, account.ID); err != nil {
+		t.Fatal(err)
+	}
+	request := accountRequest()
+	request.Name = "Duka Kuu"
+	again, err := pb.RegisterAccount(ctx, request)
+	if err != nil {
+		t.Fatalf("RegisterAccount() after deactivation error = %v", err)
+	}
+	if again.ID != account.ID || !again.IsRegistered() || again.Name != "Duka Kuu" {
+		t.Errorf("RegisterAccount() after deactivation = %+v, want %s active again and renamed", again, account.ID)
+	}
+}
+
+func TestRegisterAccountConcurrently(t *testing.T) {
+	ctx, pb := newAccountBusiness(t)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	registered, refused := 0, 0
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := pb.RegisterAccount(ctx, accountRequest())
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				registered++
+			case errors.Is(err, ErrAccountAlreadyRegistered):
+				refused++
+			default:
+				t.Errorf("RegisterAccount() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if registered != 1 || refused != 4 {
+		t.Errorf("concurrent registrations: %d registered and %d refused, want 1 and 4", registered, refused)
+	}
+	accounts, err := pb.ListAccounts(ctx)
+	if err != nil || len(accounts) != 1 {
+		t.Errorf("ListAccounts() = %d accounts, %v, want one", len(accounts), err)
+	}
+}
@@ -0,0 +1,307 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/service/events"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/repository"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// CreateApprovalPolicy adds an approval policy to the caller's partition.
+func (pb *paymentBusiness) CreateApprovalPolicy(
+	ctx context.Context,
+	req *models.ApprovalPolicyRequest,
+) (*models.ApprovalPolicy, error) {
+	policy := &models.ApprovalPolicy{
+		Name:              strings.TrimSpace(req.Name),
+		Currency:          strings.ToUpper(strings.TrimSpace(req.Currency)),
+		Threshold:         req.Threshold,
+		RequiredApprovals: req.RequiredApprovals,
+		State:             int32(commonv1.STATE_ACTIVE),
+	}
+	for _, role := range req.AllowedRoles {
+		if role = strings.TrimSpace(role); role != "" {
+			policy.AllowedRoles = append(policy.AllowedRoles, role)
+		}
+	}
+	if policy.Name == "" || policy.RequiredApprovals < 1 || policy.Threshold.IsNegative() ||
+		(policy.Currency != "" && len(policy.Currency) != 3) {
+		return nil, ErrInvalidApprovalPolicy
+	}
+
+	policy.GenID(ctx)
+	if err := repository.NewApprovalRepository(ctx, pb.service).SavePolicy(ctx, policy); err != nil {
+		pb.service.Log(ctx).WithError(err).Warn("could not save approval policy")
+		return nil, err
+	}
+	return policy, nil
+}
+
+// ListApprovalPolicies returns the approval policies in force in the caller's partition.
+func (pb *paymentBusiness) ListApprovalPolicies(ctx context.Context) ([]*models.ApprovalPolicy, error) {
+	return repository.NewApprovalRepository(ctx, pb.service).ListActivePolicies(ctx)
+}
+
+// DeactivateApprovalPolicy stops a policy from covering new payments, approvals it already
+// opened still have to be satisfied.
+func (pb *paymentBusiness) DeactivateApprovalPolicy(ctx context.Context, id string) (*models.ApprovalPolicy, error) {
+	approvalRepo := repository.NewApprovalRepository(ctx, pb.service)
+	policy, err := approvalRepo.GetPolicyByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrApprovalPolicyDoesNotExist
+		}
+		return nil, err
+	}
+
+	policy.State = int32(commonv1.STATE_INACTIVE)
+	if err = approvalRepo.SavePolicy(ctx, policy); err != nil {
+		pb.service.Log(ctx).WithError(err).WithField("policyId", id).Warn("could not save approval policy")
+		return nil, err
+	}
+	return policy, nil
+}
+
+// ListPendingApprovals returns the payments of the caller's partition waiting on approvers.
+func (pb *paymentBusiness) ListPendingApprovals(ctx context.Context) ([]*models.Approval, error) {
+	return repository.NewApprovalRepository(ctx, pb.service).ListPending(ctx)
+}
+
+// GetPaymentApproval returns the approval of a payment with the decisions taken on it.
+func (pb *paymentBusiness) GetPaymentApproval(ctx context.Context, paymentID string) (*models.Approval, error) {
+	approvalRepo := repository.NewApprovalRepository(ctx, pb.service)
+	approval, err := approvalRepo.GetByPaymentID(ctx, paymentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrApprovalNotRequired
+		}
+		return nil, err
+	}
+
+	approval.Decisions, err = approvalRepo.ListDecisions(ctx, approval.GetID())
+	if err != nil {
+		return nil, err
+	}
+	return approval, nil
+}
+
+// ApprovePayment records the caller's approval of a payment, the payment may be released once
+// as many approvers as its policy requires approved it.
+func (pb *paymentBusiness) ApprovePayment(
+	ctx context.Context,
+	paymentID string,
+	req *models.ApprovalDecisionRequest,
+) (*models.Approval, error) {
+	return pb.decidePayment(ctx, paymentID, models.DecisionApprove, req.Comment)
+}
+
+// RejectPayment records the caller's rejection of a payment, a rejected payment is never released.
+func (pb *paymentBusiness) RejectPayment(
+	ctx context.Context,
+	paymentID string,
+	req *models.ApprovalDecisionRequest,
+) (*models.Approval, error) {
+	return pb.decidePayment(ctx, paymentID, models.DecisionReject, req.Comment)
+}
+
+func (pb *paymentBusiness) decidePayment(
+	ctx context.Context,
+	paymentID, decision, comment string,
+) (*models.Approval, error) {
+	logger := pb.service.Log(ctx).WithField("paymentId", paymentID).WithField("decision", decision)
+
+	p, err := repository.NewPaymentRepository(ctx, pb.service).GetByID(ctx, paymentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentDoesNotExist
+		}
+		return nil, err
+	}
+	if p.IsReleased() {
+		return nil, ErrPaymentAlreadyReleased
+	}
+
+	approval, err := pb.openApproval(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if approval == nil {
+		return nil, ErrApprovalNotRequired
+	}
+
+	approver := subjectFromContext(ctx)
+	if approver == "" {
+		return nil, ErrApproverNotPermitted
+	}
+	if approver == p.InitiatedBy {
+		return nil, ErrApproverIsSender
+	}
+	roles, err := pb.authorizer.Roles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	approval, err = repository.NewApprovalRepository(ctx, pb.service).Decide(ctx, approval.GetID(),
+		func(approval *models.Approval, decisions []*models.ApprovalDecision) (*models.ApprovalDecision, error) {
+			if approval.State != models.ApprovalPending {
+				return nil, ErrApprovalAlreadyDecided
+			}
+			if !approval.AllowsRole(roles) {
+				return nil, ErrApproverNotPermitted
+			}
+			for _, taken := range decisions {
+				if taken.DecidedBy == approver {
+					return nil, ErrApproverAlreadyDecided
+				}
+			}
+
+			now := time.Now()
+			switch decision {
+			case models.DecisionApprove:
+				approval.Approvals++
+				if approval.Approvals >= approval.RequiredApprovals {
+					approval.State = models.ApprovalApproved
+					approval.DecidedAt = &now
+				}
+			default:
+				approval.State = models.ApprovalRejected
+				approval.DecidedAt = &now
+			}
+
+			taken := &models.ApprovalDecision{
+				ApprovalID: approval.GetID(),
+				PaymentID:  approval.PaymentID,
+				DecidedBy:  approver,
+				Decision:   decision,
+				Comment:    strings.TrimSpace(comment),
+			}
+			taken.GenID(ctx)
+			return taken, nil
+		})
+	if err != nil {
+		logger.WithError(err).Info("approval decision was not recorded")
+		return nil, err
+	}
+	logger.WithField("state", approval.State).WithField("approvals", approval.Approvals).Info("approval decision recorded")
+
+	if approval.State == models.ApprovalRejected {
+		if err = pb.emitRejection(ctx, p, approver, comment); err != nil {
+			return nil, err
+		}
+	}
+	return approval, nil
+}
+
+// emitRejection fails a payment an approver rejected.
+func (pb *paymentBusiness) emitRejection(ctx context.Context, p *models.Payment, approver, comment string) error {
+	status := &models.Status{
+		EntityID:   p.GetID(),
+		EntityType: "payment",
+		State:      int32(commonv1.STATE_INACTIVE.Number()),
+		Status:     int32(commonv1.STATUS_FAILED.Number()),
+		Extra: datatypes.JSONMap{
+			"approval":    models.ApprovalRejected,
+			"rejected_by": approver,
+			"comment":     comment,
+		},
+	}
+	status.GenID(ctx)
+	event := events.StatusSave{Service: pb.service}
+	if err := pb.service.Emit(ctx, event.Name(), status); err != nil {
+		pb.service.Log(ctx).WithError(err).WithField("paymentId", p.GetID()).Warn("could not emit rejection status")
+		return err
+	}
+	return nil
+}
+
+// checkApproval refuses to release a payment an approval policy covers until enough approvers
+// approved it.
+func (pb *paymentBusiness) checkApproval(ctx context.Context, p *models.Payment) error {
+	approval, err := pb.openApproval(ctx, p)
+	if err != nil || approval == nil {
+		return err
+	}
+	switch approval.State {
+	case models.ApprovalApproved:
+		return nil
+	case models.ApprovalRejected:
+		return ErrPaymentRejected
+	default:
+		return ErrPaymentAwaitingApproval
+	}
+}
+
+// openApproval returns the approval of an outbound payment, opening it when an approval
+// policy of the payment's partition covers the payment. Payments no policy covers need no
+// approval and nil is returned for them.
+func (pb *paymentBusiness) openApproval(ctx context.Context, p *models.Payment) (*models.Approval, error) {
+	approvalRepo := repository.NewApprovalRepository(ctx, pb.service)
+	approval, err := approvalRepo.GetByPaymentID(ctx, p.GetID())
+	if err == nil {
+		return approval, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	policy, err := pb.approvalPolicyFor(ctx, p)
+	if err != nil || policy == nil {
+		return nil, err
+	}
+
+	approval = &models.Approval{
+		PaymentID:         p.GetID(),
+		PolicyID:          policy.GetID(),
+		RequiredApprovals: policy.RequiredApprovals,
+		AllowedRoles:      policy.AllowedRoles,
+		State:             models.ApprovalPending,
+	}
+	approval.GenID(ctx)
+	if p.PartitionID != "" {
+		approval.TenantID, approval.PartitionID = p.TenantID, p.PartitionID
+	}
+	return approvalRepo.Open(ctx, approval)
+}
+
+// approvalPolicyFor returns the strictest active policy of the payment's partition covering
+// it, the one requiring the most approvals.
+func (pb *paymentBusiness) approvalPolicyFor(ctx context.Context, p *models.Payment) (*models.ApprovalPolicy, error) {
+	policies, err := repository.NewApprovalRepository(ctx, pb.service).ListActivePolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	partitionID := p.PartitionID
+	if partitionID == "" {
+		partitionID = partitionFromContext(ctx)
+	}
+
+	var strictest *models.ApprovalPolicy
+	for _, policy := range policies {
+		if policy.PartitionID != partitionID || !policy.Covers(p) {
+			continue
+		}
+		if strictest == nil || policy.RequiredApprovals > strictest.RequiredApprovals {
+			strictest = policy
+		}
+	}
+	return strictest, nil
+}
+
+// approvalExtras describes a payment's approval on its status.
+func approvalExtras(approval *models.Approval) datatypes.JSONMap {
+	extras := make(datatypes.JSONMap)
+	if approval != nil {
+		extras["approval"] = approval.State
+		extras["approvals"] = approval.Approvals
+		extras["required_approvals"] = approval.RequiredApprovals
+	}
+	return extras
+}
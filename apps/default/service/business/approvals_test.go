@@ -0,0 +1,201 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/service-payments/service/events"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/repository"
+	"github.com/antinvestor/service-payments/service/repository/repositorytest"
+	"github.com/shopspring/decimal"
+
+	"github.com/pitabwire/frame"
+)
+
+// newApprovalBusiness returns a payment business on a service with a datastore along with a
+// payment above the threshold of a policy requiring two checkers, and the statuses emitted.
+func newApprovalBusiness(t *testing.T) (context.Context, *paymentBusiness, *models.Payment, *repositorytest.Recorder) {
+	t.Helper()
+	ctx, service := repositorytest.NewService(t, nil)
+	saved := repositorytest.NewRecorder((&events.PaymentSave{}).Name(), func() any { return &models.Payment{} })
+	statuses := repositorytest.NewRecorder((&events.StatusSave{}).Name(), func() any { return &models.Status{} })
+	repositorytest.Start(t, ctx, service, saved, statuses)
+
+	pb, err := NewPaymentBusiness(ctx, service, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pb.CreateApprovalPolicy(ctx, &models.ApprovalPolicyRequest{
+		Name:              "large payments",
+		Currency:          "KES",
+		Threshold:         decimal.NewFromInt(1000),
+		RequiredApprovals: 2,
+		AllowedRoles:      []string{"checker"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payment := &models.Payment{
+		OutBound:    true,
+		Currency:    "KES",
+		Amount:      decimal.NewNullDecimal(decimal.NewFromInt(5000)),
+		InitiatedBy: "maker",
+	}
+	payment.GenID(ctx)
+	if err = repository.NewPaymentRepository(ctx, service).Save(ctx, payment); err != nil {
+		t.Fatal(err)
+	}
+	return ctx, pb.(*paymentBusiness), payment, statuses
+}
+
+// asCaller returns a context acting as the profile holding the roles in the test partition.
+func asCaller(profileID string, roles ...string) context.Context {
+	claims := &frame.AuthenticationClaims{
+		TenantID:    repositorytest.TenantID,
+		PartitionID: repositorytest.PartitionID,
+		Roles:       roles,
+	}
+	claims.Subject = profileID
+	return claims.ClaimsToContext(context.Background())
+}
+
+func TestApprovePayment(t *testing.T) {
+	_, pb, payment, _ := newApprovalBusiness(t)
+	id := payment.GetID()
+	release := func(releaser string) error {
+		_, err := pb.Release(asCaller(releaser, "checker"), &paymentV1.ReleaseRequest{Id: id})
+		return err
+	}
+
+	for _, tt := range []struct {
+		name    string
+		ctx     context.Context
+		wantErr error
+	}{
+		{name: "sender", ctx: asCaller("maker", "checker"), wantErr: ErrApproverIsSender},
+		{name: "role the policy does not allow", ctx: asCaller("teller", "teller"), wantErr: ErrApproverNotPermitted},
+	} {
+		if _, err := pb.ApprovePayment(tt.ctx, id, &models.ApprovalDecisionRequest{}); !errors.Is(err, tt.wantErr) {
+			t.Errorf("ApprovePayment(%s) error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	approval, err := pb.ApprovePayment(asCaller("checker-1", "checker"), id, &models.ApprovalDecisionRequest{})
+	if err != nil || approval.State != models.ApprovalPending || approval.Approvals != 1 {
+		t.Fatalf("ApprovePayment(checker-1) = %+v, %v, want one of two approvals", approval, err)
+	}
+	if _, err = pb.ApprovePayment(asCaller("checker-1", "checker"), id, &models.ApprovalDecisionRequest{}); !errors.Is(
+		err, ErrApproverAlreadyDecided) {
+		t.Errorf("ApprovePayment(checker-1) again error = %v, want %v", err, ErrApproverAlreadyDecided)
+	}
+	if err = release("checker-1"); !errors.Is(err, ErrPaymentAwaitingApproval) {
+		t.Fatalf("Release() with one approval error = %v, want %v", err, ErrPaymentAwaitingApproval)
+	}
+
+	approval, err = pb.ApprovePayment(asCaller("checker-2", "checker"), id,
+		&models.ApprovalDecisionRequest{Comment: " checked the invoice "})
+	if err != nil || !approval.IsSatisfied() || approval.Approvals != 2 || approval.DecidedAt == nil {
+		t.Fatalf("ApprovePayment(checker-2) = %+v, %v, want the payment approved", approval, err)
+	}
+	if _, err = pb.RejectPayment(asCaller("checker-3", "checker"), id, &models.ApprovalDecisionRequest{}); !errors.Is(
+		err, ErrApprovalAlreadyDecided) {
+		t.Errorf("RejectPayment() once approved error = %v, want %v", err, ErrApprovalAlreadyDecided)
+	}
+
+	approval, err = pb.GetPaymentApproval(asCaller("checker-1"), id)
+	if err != nil || len(approval.Decisions) != 2 {
+		t.Fatalf("GetPaymentApproval() = %+v, %v, want both decisions", approval, err)
+	}
+	for _, decision := range approval.Decisions {
+		if decision.DecidedBy == "checker-2" && decision.Comment != "checked the invoice" {
+			t.Errorf("decision comment = %q, want it trimmed", decision.Comment)
+		}
+	}
+
+	if err = release("checker-1"); err != nil {
+		t.Errorf("Release() once approved error = %v", err)
+	}
+}
+
+func TestRejectPayment(t *testing.T) {
+	_, pb, payment, statuses := newApprovalBusiness(t)
+	id := payment.GetID()
+
+	approval, err := pb.RejectPayment(asCaller("checker-1", "checker"), id,
+		&models.ApprovalDecisionRequest{Comment: "unknown beneficiary"})
+	if err != nil || approval.State != models.ApprovalRejected || approval.DecidedAt == nil {
+		t.Fatalf("RejectPayment() = %+v, %v, want the payment rejected", approval, err)
+	}
+	status := statuses.Next(t).(*models.Status)
+	if status.EntityID != id || status.Status != int32(commonv1.STATUS_FAILED) || status.Extra["rejected_by"] != "checker-1" {
+		t.Errorf("status = %+v, want the payment failed by checker-1", status)
+	}
+
+	if _, err = pb.ApprovePayment(asCaller("checker-2", "checker"), id, &models.ApprovalDecisionRequest{}); !errors.Is(
+		err, ErrApprovalAlreadyDecided) {
+		t.Errorf("ApprovePayment() once rejected error = %v, want %v", err, ErrApprovalAlreadyDecided)
+	}
+	if _, err = pb.Release(asCaller("checker-2", "checker"), &paymentV1.ReleaseRequest{Id: id}); !errors.Is(
+		err, ErrPaymentRejected) {
+		t.Errorf("Release() once rejected error = %v, want %v", err, ErrPaymentRejected)
+	}
+}
+
+func TestApprovalNotRequired(t *testing.T) {
+	ctx, pb, _, _ := newApprovalBusiness(t)
+
+	small := &models.Payment{OutBound: true, Currency: "KES", Amount: decimal.NewNullDecimal(decimal.NewFromInt(500))}
+	small.GenID(ctx)
+	if err := repository.NewPaymentRepository(ctx, pb.service).Save(ctx, small); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pb.ApprovePayment(asCaller("checker-1", "checker"), small.GetID(),
+		&models.ApprovalDecisionRequest{}); !errors.Is(err, ErrApprovalNotRequired) {
+		t.Errorf("ApprovePayment() error = %v, want %v", err, ErrApprovalNotRequired)
+	}
+	if err := pb.checkApproval(ctx, small); err != nil {
+		t.Errorf("checkApproval() error = %v, want payments no policy covers released", err)
+	}
+}
+
+func TestCheckReleaser(t *testing.T) {
+	_, service := frame.NewService("payment_release_test", frame.WithNoopDriver())
+	pb := &paymentBusiness{service: service, releaseThresholds: map[string]decimal.Decimal{"KES": decimal.NewFromInt(1000)}}
+	payment := func(currency string, amount int64) *models.Payment {
+		return &models.Payment{
+			Currency:    currency,
+			Amount:      decimal.NewNullDecimal(decimal.NewFromInt(amount)),
+			InitiatedBy: "maker",
+		}
+	}
+
+	tests := []struct {
+		name     string
+		releaser string
+		payment  *models.Payment
+		wantErr  error
+	}{
+		{name: "below the threshold", releaser: "maker", payment: payment("KES", 1000)},
+		{name: "currency without a threshold", releaser: "maker", payment: payment("USD", 5000)},
+		{name: "checker", releaser: "checker", payment: payment("kes", 5000)},
+		{name: "sender", releaser: "maker", payment: payment("KES", 5000), wantErr: ErrReleaseRequiresChecker},
+		{name: "anonymous", payment: payment("KES", 5000), wantErr: ErrReleaseRequiresChecker},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.releaser != "" {
+				ctx = asCaller(tt.releaser)
+			}
+			if err := pb.checkReleaser(ctx, tt.payment); !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkReleaser() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package business
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/antinvestor/service-payments/config"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/utility"
+
+	"github.com/pitabwire/frame"
+)
+
+const defaultNameMatchThreshold = 0.8
+
+// beneficiaryLookupResponse mirrors the integration service's KYC lookup response.
+type beneficiaryLookupResponse struct {
+	Status    string `json:"status"`
+	Name      string `json:"name"`
+	Reference string `json:"reference"`
+}
+
+func (pb *paymentBusiness) ValidateBeneficiary(
+	ctx context.Context,
+	req *models.BeneficiaryValidationRequest,
+) (*models.BeneficiaryValidation, error) {
+	logger := pb.service.Log(ctx).WithField("profile_id", req.ProfileID)
+	logger.Debug("handling beneficiary validation request")
+
+	if req.ProfileID == "" || req.CountryCode == "" {
+		return nil, ErrInvalidPaymentRequest
+	}
+	if req.LookupType == "" {
+		req.LookupType = models.BeneficiaryLookupMobile
+		if req.AccountNumber != "" {
+			req.LookupType = models.BeneficiaryLookupAccount
+		}
+	}
+	if (req.LookupType == models.BeneficiaryLookupAccount && req.AccountNumber == "") ||
+		(req.LookupType == models.BeneficiaryLookupMobile && req.MobileNumber == "") {
+		return nil, ErrInvalidPaymentRequest
+	}
+
+	if pb.profileCli == nil {
+		return nil, errors.New("profile client is not configured")
+	}
+	profile, err := pb.profileCli.GetProfileByID(ctx, req.ProfileID)
+	if err != nil {
+		logger.WithError(err).Warn("could not get beneficiary profile")
+		return nil, err
+	}
+
+	providerName, reference, err := pb.lookupBeneficiaryName(ctx, req)
+	if err != nil {
+		logger.WithError(err).Warn("could not look up beneficiary name")
+		return nil, ErrBeneficiaryLookupFailed
+	}
+
+	threshold := req.Threshold
+	if threshold <= 0 {
+		threshold = pb.nameMatchThreshold()
+	}
+
+	profileName := profile.GetProperties()["name"]
+	score := utility.NameMatchScore(profileName, providerName)
+
+	return &models.BeneficiaryValidation{
+		ProfileID:    req.ProfileID,
+		ProfileName:  profileName,
+		ProviderName: providerName,
+		Reference:    reference,
+		Score:        score,
+		Threshold:    threshold,
+		Matched:      score >= threshold,
+	}, nil
+}
+
+// lookupBeneficiaryName asks the integration service for the name registered with the provider.
+// An unknown account or wallet is not an error, it simply yields an empty name.
+func (pb *paymentBusiness) lookupBeneficiaryName(
+	ctx context.Context,
+	req *models.BeneficiaryValidationRequest,
+) (string, string, error) {
+	cfg, ok := pb.service.Config().(*config.PaymentConfig)
+	if !ok || cfg.BeneficiaryLookupURI == "" {
+		return "", "", errors.New("beneficiary lookup uri is not configured")
+	}
+
+	payload := map[string]any{
+		"type":          req.LookupType,
+		"countryCode":   req.CountryCode,
+		"accountNumber": req.AccountNumber,
+		"mobileNumber":  req.MobileNumber,
+		"wallet":        req.Wallet,
+	}
+
+	headers, err := pb.credentials.Headers()
+	if err != nil {
+		return "", "", err
+	}
+	statusCode, body, err := pb.service.InvokeRestService(ctx, http.MethodPost, cfg.BeneficiaryLookupURI, payload, headers)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch statusCode {
+	case http.StatusOK:
+		var response beneficiaryLookupResponse
+		if err = json.Unmarshal(body, &response); err != nil {
+			return "", "", err
+		}
+		return response.Name, response.Reference, nil
+	case http.StatusNotFound:
+		return "", "", nil
+	default:
+		return "", "", errors.New("beneficiary lookup failed with status " + strconv.Itoa(statusCode))
+	}
+}
+
+func (pb *paymentBusiness) nameMatchThreshold() float64 {
+	if cfg, ok := pb.service.Config().(*config.PaymentConfig); ok && cfg.NameMatchThreshold > 0 {
+		return cfg.NameMatchThreshold
+	}
+	return defaultNameMatchThreshold
+}
+
+// checkBeneficiary blocks the release of outbound payments that asked for beneficiary
+// verification when the provider registered name does not match the recipient's profile.
+func (pb *paymentBusiness) checkBeneficiary(ctx context.Context, p *models.Payment) error {
+	extra := frame.DBPropertiesToMap(p.Extra)
+	if extra["verify_beneficiary"] != "true" {
+		return nil
+	}
+
+	req := &models.BeneficiaryValidationRequest{
+		ProfileID:     p.RecipientProfileID,
+		CountryCode:   extra["beneficiary_country"],
+		AccountNumber: extra["beneficiary_account"],
+		MobileNumber:  extra["beneficiary_msisdn"],
+		Wallet:        extra["beneficiary_wallet"],
+	}
+	if threshold, err := strconv.ParseFloat(extra["name_match_threshold"], 64); err == nil {
+		req.Threshold = threshold
+	}
+
+	result, err := pb.ValidateBeneficiary(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if !result.Matched {
+		pb.service.Log(ctx).
+			WithField("payment_id", p.GetID()).
+			WithField("score", result.Score).
+			WithField("threshold", result.Threshold).
+			Warn("beneficiary name check failed, release blocked")
+		return ErrBeneficiaryNameMismatch
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package business
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antinvestor/apis/go/common"
+	profileV1 "github.com/antinvestor/apis/go/profile/v1"
+	profileMocks "github.com/antinvestor/apis/go/profile/v1_mocks"
+	"github.com/antinvestor/service-payments/config"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/utility"
+	"go.uber.org/mock/gomock"
+
+	"github.com/pitabwire/frame"
+)
+
+// newTestBusiness returns a payment business on a service without a datastore, for the
+// checks that only talk to other services.
+func newTestBusiness(t *testing.T, cfg *config.PaymentConfig, profileCli *profileV1.ProfileClient) *paymentBusiness {
+	t.Helper()
+	_, service := frame.NewService("payment_business_test", frame.WithConfig(cfg), frame.WithNoopDriver())
+	pb, err := NewPaymentBusiness(context.Background(), service, profileCli, nil,
+		nil, nil, nil, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pb.(*paymentBusiness)
+}
+
+// profileClient returns a profile client serving the profiles given by ID.
+func profileClient(t *testing.T, profiles ...*profileV1.ProfileObject) *profileV1.ProfileClient {
+	t.Helper()
+	byID := make(map[string]*profileV1.ProfileObject)
+	for _, profile := range profiles {
+		byID[profile.GetId()] = profile
+	}
+
+	mockProfileService := profileMocks.NewMockProfileServiceClient(gomock.NewController(t))
+	mockProfileService.EXPECT().
+		GetById(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, req *profileV1.GetByIdRequest, _ ...any) (*profileV1.GetByIdResponse, error) {
+			profile, ok := byID[req.GetId()]
+			if !ok {
+				return nil, errors.New("profile not found")
+			}
+			return &profileV1.GetByIdResponse{Data: profile}, nil
+		}).AnyTimes()
+	return profileV1.Init(&common.GrpcClientBase{}, mockProfileService)
+}
+
+func TestValidateBeneficiary(t *testing.T) {
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "service-token", "token_type": "bearer"})
+	}))
+	defer tokens.Close()
+
+	var lookups []map[string]any
+	lookup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The lookup exposes account holders' names, it only answers the payment service
+		if r.Header.Get("Authorization") != "Bearer service-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var request map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		lookups = append(lookups, request)
+
+		switch request["accountNumber"] {
+		case "0100000001":
+			_ = json.NewEncoder(w).Encode(beneficiaryLookupResponse{
+				Status: "success", Name: "KAMAU JOHN", Reference: "0100000001"})
+		case "0100000002":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer lookup.Close()
+
+	pb := newTestBusiness(t,
+		&config.PaymentConfig{BeneficiaryLookupURI: lookup.URL, NameMatchThreshold: 0.8},
+		profileClient(t, &profileV1.ProfileObject{Id: "recipient", Properties: map[string]string{"name": "John Kamau"}}))
+	pb.credentials = utility.NewServiceCredentials(context.Background(), tokens.URL, "service_payments", "secret")
+
+	tests := []struct {
+		name        string
+		request     models.BeneficiaryValidationRequest
+		wantErr     error
+		wantMatched bool
+		lookupType  string
+	}{
+		{name: "matching account", wantMatched: true, lookupType: models.BeneficiaryLookupAccount,
+			request: models.BeneficiaryValidationRequest{
+				ProfileID: "recipient", CountryCode: "KE", AccountNumber: "0100000001"}},
+		{name: "unknown account", lookupType: models.BeneficiaryLookupAccount,
+			request: models.BeneficiaryValidationRequest{
+				ProfileID: "recipient", CountryCode: "KE", AccountNumber: "0100000002"}},
+		{name: "lookup failure", wantErr: ErrBeneficiaryLookupFailed,
+			request: models.BeneficiaryValidationRequest{
+				ProfileID: "recipient", CountryCode: "KE", AccountNumber: "0100000003"}},
+		{name: "mobile lookup needs a number", wantErr: ErrInvalidPaymentRequest,
+			request: models.BeneficiaryValidationRequest{
+				ProfileID: "recipient", CountryCode: "KE", LookupType: models.BeneficiaryLookupMobile}},
+		{name: "country required", wantErr: ErrInvalidPaymentRequest,
+			request: models.BeneficiaryValidationRequest{ProfileID: "recipient", AccountNumber: "0100000001"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := pb.ValidateBeneficiary(context.Background(), &tt.request)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateBeneficiary() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if result.Matched != tt.wantMatched || result.Threshold != 0.8 {
+				t.Errorf("ValidateBeneficiary() = %+v, want matched %v", result, tt.wantMatched)
+			}
+			if got := lookups[len(lookups)-1]["type"]; got != tt.lookupType {
+				t.Errorf("looked up %v, want %s", got, tt.lookupType)
+			}
+		})
+	}
+}
+
+func TestCheckBeneficiary(t *testing.T) {
+	lookup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(beneficiaryLookupResponse{Status: "success", Name: "Mary Atieno"})
+	}))
+	defer lookup.Close()
+
+	pb := newTestBusiness(t,
+		&config.PaymentConfig{BeneficiaryLookupURI: lookup.URL},
+		profileClient(t, &profileV1.ProfileObject{Id: "recipient", Properties: map[string]string{"name": "John Kamau"}}))
+
+	unverified := &models.Payment{RecipientProfileID: "recipient"}
+	if err := pb.checkBeneficiary(context.Background(), unverified); err != nil {
+		t.Errorf("checkBeneficiary() checked a payment that did not ask for it: %v", err)
+	}
+
+	verified := &models.Payment{RecipientProfileID: "recipient", Extra: map[string]any{
+		"verify_beneficiary":  "true",
+		"beneficiary_country": "KE",
+		"beneficiary_msisdn":  "254712345678",
+	}}
+	if err := pb.checkBeneficiary(context.Background(), verified); !errors.Is(err, ErrBeneficiaryNameMismatch) {
+		t.Errorf("checkBeneficiary() error = %v, want ErrBeneficiaryNameMismatch", err)
+	}
+}
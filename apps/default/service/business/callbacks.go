@@ -0,0 +1,85 @@
+package business
+
+import (
+	"context"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/service-payments/service/provider"
+	"github.com/antinvestor/service-payments/service/utility"
+)
+
+// ReceiveCallback records a callback posted by the provider serving a counter. The adapter turns
+// the body into a provider neutral callback, money received is recorded as an inbound payment
+// and a prompt that was not paid has its outcome recorded against it.
+func (pb *paymentBusiness) ReceiveCallback(
+	ctx context.Context,
+	counterID, kind string,
+	body []byte,
+) (*commonv1.StatusResponse, error) {
+	logger := pb.service.Log(ctx).WithField("counter", counterID).WithField("kind", kind)
+
+	psp, err := pb.providers.Lookup(counterID)
+	if err != nil {
+		return nil, ErrProviderDoesNotExist
+	}
+
+	callback, err := psp.ParseCallback(ctx, kind, body)
+	if err != nil {
+		logger.WithError(err).Warn("could not parse provider callback")
+		return nil, ErrInvalidCallback
+	}
+	if callback.Status == commonv1.STATUS_UNKNOWN {
+		logger.WithField("transaction", callback.TransactionID).Warn("provider callback has no status")
+		return nil, ErrCallbackStatusUnknown
+	}
+
+	if callback.Kind == provider.CallbackPayment && callback.Status == commonv1.STATUS_SUCCESSFUL {
+		return pb.Receive(ctx, callbackPayment(callback))
+	}
+
+	// Nothing was received, the outcome only matters to the prompt the callback answers
+	if callback.TransactionRef == "" {
+		logger.WithField("transaction", callback.TransactionID).Info("unsuccessful callback is not linked to a prompt")
+		return &commonv1.StatusResponse{Status: callback.Status, ExternalId: callback.TransactionID}, nil
+	}
+	return pb.StatusUpdate(ctx, &commonv1.StatusUpdateRequest{
+		State:      commonv1.STATE_ACTIVE,
+		Status:     callback.Status,
+		ExternalId: callback.TransactionID,
+		Extras: map[string]string{
+			"entity_type":     "prompt",
+			"transaction_ref": callback.TransactionRef,
+			"message":         callback.Message,
+		},
+	})
+}
+
+// callbackPayment is the inbound payment a successful provider callback reports.
+func callbackPayment(callback *provider.Callback) *paymentV1.Payment {
+	amount := utility.ToMoney(callback.Currency, callback.Amount)
+
+	extra := make(map[string]string, len(callback.Extra)+3)
+	for key, value := range callback.Extra {
+		extra[key] = value
+	}
+	for key, value := range map[string]string{
+		"transaction_ref":  callback.TransactionRef,
+		"payment_link_ref": callback.PaymentLinkRef,
+		"message":          callback.Message,
+	} {
+		if value != "" {
+			extra[key] = value
+		}
+	}
+
+	return &paymentV1.Payment{
+		TransactionId: callback.TransactionID,
+		Source: &commonv1.ContactLink{
+			ProfileName: callback.PayerName,
+			Detail:      callback.PayerMSISDN,
+		},
+		Amount: &amount,
+		Extra:  extra,
+	}
+}
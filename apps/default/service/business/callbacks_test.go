@@ -0,0 +1,83 @@
+package business
+
+import (
+	"errors"
+	"testing"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/config"
+	"github.com/antinvestor/service-payments/service/provider/jenga"
+	"github.com/antinvestor/service-payments/service/utility"
+	"github.com/shopspring/decimal"
+)
+
+func TestReceiveCallbackRejects(t *testing.T) {
+	pb := newTestBusiness(t, &config.PaymentConfig{}, nil)
+	pb.providers.Register("jenga", &jenga.Provider{})
+
+	tests := []struct {
+		name    string
+		counter string
+		kind    string
+		body    string
+		wantErr error
+	}{
+		{name: "unknown counter", counter: "mpesa", kind: jenga.CallbackKindIPN, body: `{}`,
+			wantErr: ErrProviderDoesNotExist},
+		{name: "unknown kind", counter: "jenga", kind: "unknown", body: `{}`, wantErr: ErrInvalidCallback},
+		{name: "malformed body", counter: "jenga", kind: jenga.CallbackKindIPN, body: `{`, wantErr: ErrInvalidCallback},
+		{name: "no status", counter: "jenga", kind: jenga.CallbackKindIPN,
+			body:    `{"transaction":{"reference":"741852963","amount":250,"currency":"KES"}}`,
+			wantErr: ErrCallbackStatusUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pb.ReceiveCallback(t.Context(), tt.counter, tt.kind, []byte(tt.body))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReceiveCallback() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReceiveCallbackUnlinkedFailure(t *testing.T) {
+	pb := newTestBusiness(t, &config.PaymentConfig{}, nil)
+	pb.providers.Register("jenga", &jenga.Provider{})
+
+	body := `{"paymentLinkRef":"PL7Q2X","transaction":{"reference":"963852741","amount":1000,` +
+		`"currency":"KES","status":"FAILED"}}`
+	status, err := pb.ReceiveCallback(t.Context(), "jenga", jenga.CallbackKindPaymentLink, []byte(body))
+	if err != nil {
+		t.Fatalf("ReceiveCallback() error = %v", err)
+	}
+	if status.GetStatus() != commonv1.STATUS_FAILED || status.GetExternalId() != "963852741" {
+		t.Errorf("ReceiveCallback() = %v, want the failed transaction", status)
+	}
+}
+
+func TestCallbackPayment(t *testing.T) {
+	callback, err := (&jenga.Provider{}).ParseCallback(t.Context(), jenga.CallbackKindStk,
+		[]byte(`{"status":true,"code":3,"transactionReference":"A12345","telcoReference":"RKL51ZDR4F",`+
+			`"mobileNumber":"254712345678","currency":"KES","requestAmount":100}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payment := callbackPayment(callback)
+	if payment.GetTransactionId() != "RKL51ZDR4F" {
+		t.Errorf("transaction id = %q, want RKL51ZDR4F", payment.GetTransactionId())
+	}
+	if payment.GetSource().GetDetail() != "254712345678" {
+		t.Errorf("source = %q, want the payer's number", payment.GetSource().GetDetail())
+	}
+	if payment.GetExtra()["transaction_ref"] != "A12345" {
+		t.Errorf("transaction_ref = %q, want A12345", payment.GetExtra()["transaction_ref"])
+	}
+	if _, ok := payment.GetExtra()["payment_link_ref"]; ok {
+		t.Error("payment_link_ref set for a callback without a link")
+	}
+	if amount := utility.FromMoney(payment.GetAmount()); !amount.Equal(decimal.NewFromInt(100)) ||
+		payment.GetAmount().GetCurrencyCode() != "KES" {
+		t.Errorf("amount = %s %s, want 100 KES", amount, payment.GetAmount().GetCurrencyCode())
+	}
+}
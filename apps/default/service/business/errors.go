@@ -19,6 +19,11 @@ var (
 
 	ErrPaymentAlreadyReleased = status.Error(codes.FailedPrecondition, "Specified payment has already been released")
 
+	ErrReleaseRequiresChecker = status.Error(
+		codes.PermissionDenied,
+		"Payment is above the release threshold and must be released by someone other than its sender",
+	)
+
 	ErrPaymentAlreadyRefunded = status.Error(codes.FailedPrecondition, "Specified payment has already been refunded")
 
 	ErrPaymentAlreadyCanceled = status.Error(codes.FailedPrecondition, "Specified payment has already been canceled")
@@ -34,4 +39,108 @@ var (
 		codes.FailedPrecondition,
 		"Specified payment has already been partially refunded",
 	)
+
+	ErrBeneficiaryLookupFailed = status.Error(codes.Unavailable, "Beneficiary name could not be verified")
+
+	ErrBeneficiaryNameMismatch = status.Error(
+		codes.FailedPrecondition,
+		"Beneficiary name does not match the registered account name",
+	)
+
+	ErrInvalidMobileNumber = status.Error(codes.InvalidArgument, "Invalid or unsupported mobile number")
+
+	ErrPromptDoesNotExist = status.Error(codes.NotFound, "Specified prompt does not exist")
+
+	ErrPromptStillPending = status.Error(codes.FailedPrecondition, "Specified prompt is still awaiting the customer")
+
+	ErrPromptAlreadyPaid = status.Error(codes.FailedPrecondition, "Specified prompt has already been paid")
+
+	ErrPromptAttemptsExhausted = status.Error(
+		codes.FailedPrecondition,
+		"Specified prompt has been sent the maximum number of times",
+	)
+
+	ErrPromptRateLimited = status.Error(
+		codes.ResourceExhausted,
+		"Too many prompts were sent to this mobile number, try again later",
+	)
+
+	ErrInvalidAccountRequest = status.Error(codes.InvalidArgument, "Invalid account request")
+
+	ErrAccountDoesNotExist = status.Error(codes.NotFound, "Specified account does not exist")
+
+	ErrAccountAlreadyRegistered = status.Error(
+		codes.AlreadyExists,
+		"Account is already registered with the provider in this partition",
+	)
+
+	ErrAccountInactive = status.Error(codes.FailedPrecondition, "Specified account is not an active collection account")
+
+	ErrAccountCurrencyMismatch = status.Error(
+		codes.InvalidArgument,
+		"Currency does not match the currency of the collection account",
+	)
+
+	ErrAccountValidationFailed = status.Error(
+		codes.FailedPrecondition,
+		"Account could not be validated with its provider",
+	)
+
+	ErrProviderDoesNotExist = status.Error(codes.NotFound, "No provider is registered for the specified counter")
+
+	ErrInvalidCallback = status.Error(codes.InvalidArgument, "Provider callback could not be understood")
+
+	ErrCallbackStatusUnknown = status.Error(
+		codes.FailedPrecondition,
+		"Provider callback does not report whether the transaction succeeded",
+	)
+
+	ErrReferenceDoesNotExist = status.Error(codes.NotFound, "Specified transaction reference does not exist")
+
+	ErrInvalidPaymentLinkRequest = status.Error(codes.InvalidArgument, "Invalid payment link request")
+
+	ErrPaymentLinkDoesNotExist = status.Error(codes.NotFound, "Specified payment link does not exist")
+
+	ErrPaymentLinkInactive = status.Error(codes.FailedPrecondition, "Specified payment link is no longer active")
+
+	ErrInvalidApprovalPolicy = status.Error(codes.InvalidArgument, "Invalid approval policy")
+
+	ErrApprovalPolicyDoesNotExist = status.Error(codes.NotFound, "Specified approval policy does not exist")
+
+	ErrApprovalNotRequired = status.Error(codes.NotFound, "Specified payment does not need approval")
+
+	ErrPaymentAwaitingApproval = status.Error(
+		codes.FailedPrecondition,
+		"Payment cannot be released before its approval policy is satisfied",
+	)
+
+	ErrPaymentRejected = status.Error(codes.FailedPrecondition, "Payment was rejected by an approver")
+
+	ErrApprovalAlreadyDecided = status.Error(codes.FailedPrecondition, "Approval has already been decided")
+
+	ErrApproverAlreadyDecided = status.Error(codes.AlreadyExists, "Approver has already decided on this payment")
+
+	ErrApproverIsSender = status.Error(codes.PermissionDenied, "Payments cannot be approved by their sender")
+
+	ErrApproverNotPermitted = status.Error(
+		codes.PermissionDenied,
+		"Approver does not hold a role the approval policy allows",
+	)
+
+	ErrLimitExceeded = status.Error(codes.ResourceExhausted, "Transaction limit exceeded")
+
+	ErrProfileDoesNotExist = status.Error(codes.NotFound, "Specified profile does not exist")
+
+	ErrContactDoesNotExist = status.Error(codes.NotFound, "Specified contact does not exist on the profile")
+
+	ErrProfileLookupFailed = status.Error(codes.Unavailable, "Could not look up the payment's profiles")
+
+	ErrScreeningDoesNotExist = status.Error(codes.NotFound, "Specified payment has not been screened")
+
+	ErrScreeningNotHeld = status.Error(codes.FailedPrecondition, "Payment is not held for screening review")
+
+	ErrReviewerIsSender = status.Error(
+		codes.PermissionDenied,
+		"Held payments cannot be reviewed by the callers who sent or released them",
+	)
 )
@@ -0,0 +1,90 @@
+package business
+
+import (
+	"context"
+	"time"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/service/events"
+	"github.com/antinvestor/service-payments/service/limits"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/datatypes"
+)
+
+// limitExceeded is returned for a transaction that broke a limit rule, naming the rule.
+func limitExceeded(rule *limits.Rule) error {
+	return status.Errorf(codes.ResourceExhausted, "%s: %s", ErrLimitExceeded.Error(), rule)
+}
+
+// enforceLimits counts a transaction against the limit rules. A transaction breaking a rule is
+// recorded through save with a failed status naming the rule, and the breach is returned.
+func (pb *paymentBusiness) enforceLimits(
+	ctx context.Context,
+	t limits.Transaction,
+	entityType, entityID string,
+	save func() error,
+) error {
+	rule, err := pb.countLimits(ctx, t, entityID)
+	if err != nil || rule == nil {
+		return err
+	}
+
+	logger := pb.service.Log(ctx).WithField("entityId", entityID).WithField("rule", rule.String())
+	logger.Info("transaction exceeds limit")
+
+	if err = save(); err != nil {
+		logger.WithError(err).Warn("could not record transaction over limit")
+		return err
+	}
+	failed := &models.Status{
+		EntityID:   entityID,
+		EntityType: entityType,
+		State:      int32(commonv1.STATE_INACTIVE.Number()),
+		Status:     int32(commonv1.STATUS_FAILED.Number()),
+		Extra:      datatypes.JSONMap{"limit_rule": rule.String()},
+	}
+	failed.GenID(ctx)
+	event := events.StatusSave{Service: pb.service}
+	if err = pb.service.Emit(ctx, event.Name(), failed); err != nil {
+		logger.WithError(err).Warn("could not emit limit status")
+		return err
+	}
+	return limitExceeded(rule)
+}
+
+// countLimits counts a transaction against the limit rules and returns the first rule it
+// breaks, a transaction breaking a rule is not counted.
+func (pb *paymentBusiness) countLimits(ctx context.Context, t limits.Transaction, entityID string) (*limits.Rule, error) {
+	if len(pb.limits) == 0 {
+		return nil, nil
+	}
+	if t.PartitionID == "" {
+		t.PartitionID = partitionFromContext(ctx)
+	}
+
+	rule, checks := pb.limits.Evaluate(t, time.Now())
+	if rule != nil {
+		return rule, nil
+	}
+	rule, err := repository.NewLimitRepository(ctx, pb.service).Consume(ctx, entityID, checks, t.Amount)
+	if err != nil {
+		pb.service.Log(ctx).WithError(err).Warn("could not count transaction against limits")
+		return nil, err
+	}
+	return rule, nil
+}
+
+// releaseLimits gives back what a transaction counted against the limits when it could not be
+// recorded after all.
+func (pb *paymentBusiness) releaseLimits(ctx context.Context, entityID string) {
+	if len(pb.limits) == 0 {
+		return
+	}
+	if _, err := repository.NewLimitRepository(ctx, pb.service).Release(ctx, entityID); err != nil {
+		pb.service.Log(ctx).WithError(err).WithField("entityId", entityID).
+			Warn("could not give back what the transaction counted against limits")
+	}
+}
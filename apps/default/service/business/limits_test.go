@@ -0,0 +1,84 @@
+package business
+
+import (
+	"context"
+	"testing"
+
+	"github.com/antinvestor/service-payments/service/events"
+	"github.com/antinvestor/service-payments/service/limits"
+	"github.com/antinvestor/service-payments/service/repository/repositorytest"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/pitabwire/frame"
+)
+
+// newLimitedBusiness returns a payment business enforcing rules on a service with a datastore.
+func newLimitedBusiness(t *testing.T, spec string) (context.Context, *paymentBusiness) {
+	t.Helper()
+	rules, err := limits.ParseRules(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, service := repositorytest.NewService(t, nil)
+	repositorytest.Start(t, ctx, service, &events.StatusSave{Service: service})
+	pb, err := NewPaymentBusiness(ctx, service, nil, nil, nil, nil, nil, nil, nil, rules, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx, pb.(*paymentBusiness)
+}
+
+func sendOf(amount int64) limits.Transaction {
+	return limits.Transaction{
+		Operation: limits.Send,
+		ProfileID: "profile",
+		Currency:  "KES",
+		Amount:    decimal.NewFromInt(amount),
+	}
+}
+
+func TestCountLimitsPerTransaction(t *testing.T) {
+	_, service := frame.NewService("payment_limits_test", frame.WithNoopDriver())
+	rules, err := limits.ParseRules("send.profile.transaction=KES 1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pb := &paymentBusiness{service: service, limits: rules}
+
+	// Rules on a single transaction are settled without counting anything
+	rule, err := pb.countLimits(context.Background(), sendOf(1500), "payment-1")
+	if err != nil || rule == nil || rule.Window != limits.WindowTransaction {
+		t.Errorf("countLimits() = %v, %v, want the per transaction rule broken", rule, err)
+	}
+}
+
+func TestEnforceLimits(t *testing.T) {
+	ctx, pb := newLimitedBusiness(t, "send.profile.day=2")
+
+	saved := 0
+	save := func() error {
+		saved++
+		return nil
+	}
+	for _, paymentID := range []string{"payment-1", "payment-2"} {
+		if err := pb.enforceLimits(ctx, sendOf(100), "payment", paymentID, save); err != nil {
+			t.Fatalf("enforceLimits(%s) error = %v", paymentID, err)
+		}
+	}
+	if saved != 0 {
+		t.Errorf("enforceLimits() saved %d payments within the limits, want none", saved)
+	}
+
+	err := pb.enforceLimits(ctx, sendOf(100), "payment", "payment-3", save)
+	if status.Code(err) != codes.ResourceExhausted || saved != 1 {
+		t.Fatalf("enforceLimits(payment-3) = %v with %d saved, want it recorded and refused", err, saved)
+	}
+
+	// A payment that did not go through gives its room back
+	pb.releaseLimits(ctx, "payment-1")
+	if err = pb.enforceLimits(ctx, sendOf(100), "payment", "payment-4", save); err != nil {
+		t.Errorf("enforceLimits(payment-4) error = %v, want the released room used", err)
+	}
+}
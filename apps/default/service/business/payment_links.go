@@ -0,0 +1,256 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/service-payments/service/events"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/repository"
+	"github.com/antinvestor/service-payments/service/tenancy"
+	"github.com/pitabwire/frame"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// findPaymentLinkForPayment returns the link an inbound payment was made against, matched on
+// the reference the provider assigned to the link or on our own external reference within the
+// partition the caller is confined to.
+func (pb *paymentBusiness) findPaymentLinkForPayment(
+	ctx context.Context,
+	message *paymentV1.Payment,
+) (*models.PaymentLink, error) {
+	linkRepo := repository.NewPaymentLinkRepository(ctx, pb.service)
+	partitionID := tenancy.PartitionOf(ctx)
+	for _, key := range []string{"payment_link_ref", "external_ref"} {
+		reference := message.GetExtra()[key]
+		if reference == "" {
+			continue
+		}
+
+		link, err := linkRepo.GetByReference(ctx, partitionID, reference)
+		if err == nil {
+			return link, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// applyPaymentLink ties an inbound payment to its link and flags payments the link should
+// not have accepted. Flagged payments are still recorded, the money has already moved.
+func applyPaymentLink(p *models.Payment, link *models.PaymentLink, now time.Time) {
+	if p.Extra == nil {
+		p.Extra = make(datatypes.JSONMap)
+	}
+	p.Extra["payment_link_id"] = link.ID
+	p.Extra["payment_link_ref"] = link.PaymentLinkRef
+
+	if !link.IsActive(now) {
+		p.Extra["link_inactive"] = "true"
+	}
+
+	if link.IsFixedAmount() && p.Amount.Valid && !link.Amount.Equal(p.Amount.Decimal) {
+		p.Extra["payment_link_amount"] = link.Amount.String()
+		p.Extra["amount_mismatch"] = "true"
+	}
+}
+
+// UpdatePaymentLink changes the editable details of a payment link and passes the change on
+// to the provider.
+func (pb *paymentBusiness) UpdatePaymentLink(
+	ctx context.Context,
+	id string,
+	req *models.PaymentLinkUpdateRequest,
+) (*models.PaymentLink, error) {
+	logger := pb.service.Log(ctx).WithField("paymentLinkId", id)
+
+	link, err := pb.getOpenPaymentLink(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		link.Name = *req.Name
+	}
+	if req.Description != nil {
+		link.Description = *req.Description
+	}
+	if req.RedirectURL != nil {
+		link.RedirectURL = *req.RedirectURL
+	}
+	if req.ExpiryDate != nil {
+		expiryDate, parseErr := time.Parse("2006-01-02", *req.ExpiryDate)
+		if parseErr != nil {
+			return nil, ErrInvalidPaymentLinkRequest
+		}
+		link.ExpiryDate = expiryDate
+	}
+	if req.Amount != nil {
+		if link.IsFixedAmount() && !req.Amount.IsPositive() {
+			return nil, ErrInvalidPaymentLinkRequest
+		}
+		link.Amount = *req.Amount
+	}
+
+	if err = repository.NewPaymentLinkRepository(ctx, pb.service).Save(ctx, link); err != nil {
+		logger.WithError(err).Warn("could not save payment link")
+		return nil, err
+	}
+
+	if err = pb.publishPaymentLinkChange(ctx, models.PaymentLinkActionUpdate, link); err != nil {
+		logger.WithError(err).Warn("could not publish payment link update")
+		return nil, err
+	}
+	return link, nil
+}
+
+// DeactivatePaymentLink stops a payment link from accepting further payments.
+func (pb *paymentBusiness) DeactivatePaymentLink(ctx context.Context, id string) (*models.PaymentLink, error) {
+	logger := pb.service.Log(ctx).WithField("paymentLinkId", id)
+
+	link, err := pb.getOpenPaymentLink(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	link.State = int32(commonv1.STATE_INACTIVE)
+	if err = repository.NewPaymentLinkRepository(ctx, pb.service).Save(ctx, link); err != nil {
+		logger.WithError(err).Warn("could not save payment link")
+		return nil, err
+	}
+
+	status := &models.Status{
+		EntityID:   link.ID,
+		EntityType: "payment_link",
+		State:      int32(commonv1.STATE_INACTIVE.Number()),
+		Status:     int32(commonv1.STATUS_SUCCESSFUL.Number()),
+		Extra:      datatypes.JSONMap{"reason": "deactivated"},
+	}
+	status.GenID(ctx)
+	statusEvent := events.StatusSave{Service: pb.service}
+	if err = pb.service.Emit(ctx, statusEvent.Name(), status); err != nil {
+		logger.WithError(err).Warn("could not emit payment link status event")
+		return nil, err
+	}
+
+	if err = pb.publishPaymentLinkChange(ctx, models.PaymentLinkActionDeactivate, link); err != nil {
+		logger.WithError(err).Warn("could not publish payment link deactivation")
+		return nil, err
+	}
+	return link, nil
+}
+
+// CreatePaymentLink creates a payment link on the default provider.
+func (pb *paymentBusiness) CreatePaymentLink(
+	ctx context.Context,
+	req *paymentV1.CreatePaymentLinkRequest,
+) (*commonv1.StatusResponse, error) {
+	return pb.createPaymentLink(ctx, req, nil)
+}
+
+// CreateAccountPaymentLink creates a payment link that collects into a registered account, on
+// the provider the account is held with.
+func (pb *paymentBusiness) CreateAccountPaymentLink(
+	ctx context.Context,
+	accountID string,
+	req *paymentV1.CreatePaymentLinkRequest,
+) (*commonv1.StatusResponse, error) {
+	account, err := pb.collectionAccount(ctx, accountID, "")
+	if err != nil {
+		return nil, err
+	}
+	return pb.createPaymentLink(ctx, req, account)
+}
+
+// GetPaymentLink returns a payment link with the URL and status assigned by the provider.
+func (pb *paymentBusiness) GetPaymentLink(ctx context.Context, id string) (*models.PaymentLink, error) {
+	link, err := repository.NewPaymentLinkRepository(ctx, pb.service).GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentLinkDoesNotExist
+		}
+		return nil, err
+	}
+	return link, nil
+}
+
+func (pb *paymentBusiness) getOpenPaymentLink(ctx context.Context, id string) (*models.PaymentLink, error) {
+	link, err := repository.NewPaymentLinkRepository(ctx, pb.service).GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentLinkDoesNotExist
+		}
+		return nil, err
+	}
+
+	if link.State == int32(commonv1.STATE_INACTIVE) || link.State == int32(commonv1.STATE_DELETED) {
+		return nil, ErrPaymentLinkInactive
+	}
+	return link, nil
+}
+
+func (pb *paymentBusiness) publishPaymentLinkChange(
+	ctx context.Context,
+	action string,
+	link *models.PaymentLink,
+) error {
+	psp, err := pb.providers.Get(link.CounterID)
+	if err != nil {
+		return err
+	}
+	return psp.UpdatePaymentLink(ctx, &models.PaymentLinkChange{Action: action, PaymentLink: link})
+}
+
+// recordPaymentLinkCollection adds a received payment to its link's collected totals.
+func (pb *paymentBusiness) recordPaymentLinkCollection(ctx context.Context, p *models.Payment) error {
+	linkID, _ := p.Extra["payment_link_id"].(string)
+	if linkID == "" || !p.Amount.Valid {
+		return nil
+	}
+	return repository.NewPaymentLinkRepository(ctx, pb.service).
+		AddCollection(ctx, linkID, p.Amount.Decimal, time.Now())
+}
+
+// existingInboundPayment returns the status of a payment already received under the same
+// provider transaction id, providers deliver the same payment through callbacks and polling.
+func (pb *paymentBusiness) existingInboundPayment(
+	ctx context.Context,
+	transactionID string,
+) (*commonv1.StatusResponse, error) {
+	if transactionID == "" {
+		return nil, nil
+	}
+
+	existing, err := repository.NewPaymentRepository(ctx, pb.service).GetInboundByTransactionID(ctx, transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	status, err := repository.NewStatusRepository(ctx, pb.service).GetByEntity(ctx, existing.GetID(), "payment")
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &commonv1.StatusResponse{
+				Id:     existing.GetID(),
+				State:  commonv1.STATE_CREATED,
+				Status: commonv1.STATUS_QUEUED,
+			}, nil
+		}
+		return nil, err
+	}
+
+	return &commonv1.StatusResponse{
+		Id:     status.EntityID,
+		State:  commonv1.STATE(status.State),
+		Status: commonv1.STATUS(status.Status),
+		Extras: frame.DBPropertiesToMap(status.Extra),
+	}, nil
+}
@@ -13,8 +13,13 @@ import (
 	partitionV1 "github.com/antinvestor/apis/go/partition/v1"
 	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
 	profileV1 "github.com/antinvestor/apis/go/profile/v1"
+	"github.com/antinvestor/service-payments/service/authorization"
 	"github.com/antinvestor/service-payments/service/events"
+	"github.com/antinvestor/service-payments/service/limits"
 	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/msisdn"
+	"github.com/antinvestor/service-payments/service/provider"
+	"github.com/antinvestor/service-payments/service/reference"
 	"github.com/antinvestor/service-payments/service/repository"
 	"github.com/antinvestor/service-payments/service/utility"
 	"github.com/shopspring/decimal"
@@ -23,6 +28,10 @@ import (
 	"github.com/pitabwire/frame"
 )
 
+// defaultPromptCountry reads national prompt numbers when neither the request nor the
+// configuration names a country.
+const defaultPromptCountry = "KE"
+
 type PaymentBusiness interface {
 	Send(ctx context.Context, payment *paymentV1.Payment) (*commonv1.StatusResponse, error)
 	Receive(ctx context.Context, payment *paymentV1.Payment) (*commonv1.StatusResponse, error)
@@ -31,7 +40,42 @@ type PaymentBusiness interface {
 	Release(ctx context.Context, status *paymentV1.ReleaseRequest) (*commonv1.StatusResponse, error)
 	Search(search *commonv1.SearchRequest, stream paymentV1.PaymentService_SearchServer) error
 	InitiatePrompt(ctx context.Context, req *paymentV1.InitiatePromptRequest) (*commonv1.StatusResponse, error)
+	ResendPrompt(ctx context.Context, id string) (*commonv1.StatusResponse, error)
 	CreatePaymentLink(ctx context.Context, req *paymentV1.CreatePaymentLinkRequest) (*commonv1.StatusResponse, error)
+	UpdatePaymentLink(
+		ctx context.Context,
+		id string,
+		req *models.PaymentLinkUpdateRequest,
+	) (*models.PaymentLink, error)
+	DeactivatePaymentLink(ctx context.Context, id string) (*models.PaymentLink, error)
+	GetPaymentLink(ctx context.Context, id string) (*models.PaymentLink, error)
+	LookupReference(ctx context.Context, counterID, ref string) (*models.TransactionReference, error)
+	ReceiveCallback(ctx context.Context, counterID, kind string, body []byte) (*commonv1.StatusResponse, error)
+	RegisterAccount(ctx context.Context, req *models.AccountRequest) (*models.Account, error)
+	ListAccounts(ctx context.Context) ([]*models.Account, error)
+	GetAccount(ctx context.Context, id string) (*models.Account, error)
+	UpdateAccount(ctx context.Context, id string, req *models.AccountUpdateRequest) (*models.Account, error)
+	DeactivateAccount(ctx context.Context, id string) (*models.Account, error)
+	CreateAccountPaymentLink(
+		ctx context.Context,
+		accountID string,
+		req *paymentV1.CreatePaymentLinkRequest,
+	) (*commonv1.StatusResponse, error)
+	ValidateBeneficiary(
+		ctx context.Context,
+		req *models.BeneficiaryValidationRequest,
+	) (*models.BeneficiaryValidation, error)
+	CreateApprovalPolicy(ctx context.Context, req *models.ApprovalPolicyRequest) (*models.ApprovalPolicy, error)
+	ListApprovalPolicies(ctx context.Context) ([]*models.ApprovalPolicy, error)
+	DeactivateApprovalPolicy(ctx context.Context, id string) (*models.ApprovalPolicy, error)
+	ListPendingApprovals(ctx context.Context) ([]*models.Approval, error)
+	GetPaymentApproval(ctx context.Context, paymentID string) (*models.Approval, error)
+	ApprovePayment(ctx context.Context, paymentID string, req *models.ApprovalDecisionRequest) (*models.Approval, error)
+	RejectPayment(ctx context.Context, paymentID string, req *models.ApprovalDecisionRequest) (*models.Approval, error)
+	ListHeldPayments(ctx context.Context) ([]*models.Screening, error)
+	GetPaymentScreening(ctx context.Context, paymentID string) (*models.Screening, error)
+	ClearHeldPayment(ctx context.Context, paymentID string, req *models.ScreeningReviewRequest) (*models.Screening, error)
+	RejectHeldPayment(ctx context.Context, paymentID string, req *models.ScreeningReviewRequest) (*models.Screening, error)
 }
 
 func NewPaymentBusiness(
@@ -40,16 +84,40 @@ func NewPaymentBusiness(
 	profileCli *profileV1.ProfileClient,
 	partitionCli *partitionV1.PartitionClient,
 	ledgerCli *ledgerv1.LedgerClient,
+	providers *provider.Registry,
+	numbers *msisdn.Resolver,
+	references *reference.Formats,
+	authorizer *authorization.Authorizer,
+	limitRules limits.Rules,
+	releaseThresholds map[string]decimal.Decimal,
+	credentials *utility.ServiceCredentials,
 ) (PaymentBusiness, error) {
 	// initialize the service
 	if service == nil {
 		return nil, ErrInitializationFail
 	}
+	if providers == nil {
+		providers = provider.NewRegistry()
+	}
+	if numbers == nil {
+		numbers = msisdn.NewResolver(defaultPromptCountry, nil)
+	}
+	if references == nil {
+		references = &reference.Formats{Default: reference.MustParseFormat(reference.DefaultFormat)}
+	}
 	return &paymentBusiness{
 		service:      service,
 		profileCli:   profileCli,
 		partitionCli: partitionCli,
 		ledgerCli:    ledgerCli,
+		providers:    providers,
+		numbers:      numbers,
+		references:   references,
+		authorizer:   authorizer,
+		limits:       limitRules,
+
+		releaseThresholds: releaseThresholds,
+		credentials:       credentials,
 	}, nil
 }
 
@@ -58,21 +126,48 @@ type paymentBusiness struct {
 	profileCli   *profileV1.ProfileClient
 	partitionCli *partitionV1.PartitionClient
 	ledgerCli    *ledgerv1.LedgerClient
+	providers    *provider.Registry
+	numbers      *msisdn.Resolver
+	references   *reference.Formats
+	authorizer   *authorization.Authorizer
+	limits       limits.Rules
+
+	// releaseThresholds are the amounts per currency above which a payment must be released
+	// by someone other than its sender
+	releaseThresholds map[string]decimal.Decimal
+	// credentials authenticate the requests made to the integration services' HTTP endpoints
+	credentials *utility.ServiceCredentials
 }
 
 func (pb *paymentBusiness) Send(ctx context.Context, message *paymentV1.Payment) (*commonv1.StatusResponse, error) {
 	p := &models.Payment{
-		SenderProfileType:    message.GetSource().GetProfileType(),
-		SenderProfileID:      message.GetSource().GetProfileId(),
-		SenderContactID:      message.GetSource().GetContactId(),
-		RecipientProfileType: message.GetRecipient().GetProfileType(),
-		RecipientProfileID:   message.GetRecipient().GetProfileId(),
-		RecipientContactID:   message.GetRecipient().GetContactId(),
-		ReferenceID:          message.GetReferenceId(),
-		BatchID:              message.GetBatchId(),
-		RouteID:              message.GetRoute(),
-		PaymentType:          "Bank Transfers",
-		OutBound:             true,
+		ReferenceID: message.GetReferenceId(),
+		BatchID:     message.GetBatchId(),
+		RouteID:     message.GetRoute(),
+		PaymentType: "Bank Transfers",
+		OutBound:    true,
+		InitiatedBy: subjectFromContext(ctx),
+		Extra:       frame.DBPropertiesFromMap(message.GetExtra()),
+	}
+
+	// Senders and recipients must be known to the profile service, their names and numbers are
+	// kept with the payment
+	sender, err := pb.resolveParty(ctx, message.GetSource())
+	if err != nil {
+		return nil, err
+	}
+	applySender(p, sender)
+	recipient, err := pb.resolveParty(ctx, message.GetRecipient())
+	if err != nil {
+		return nil, err
+	}
+	applyRecipient(p, recipient)
+
+	if paymentType := message.GetExtra()["payment_type"]; paymentType != "" {
+		p.PaymentType = paymentType
+	}
+	if message.GetExtra()["verify_beneficiary"] == "true" && message.GetExtra()["beneficiary_msisdn"] == "" {
+		p.Extra["beneficiary_msisdn"] = message.GetRecipient().GetDetail()
 	}
 
 	c := &models.Cost{
@@ -90,10 +185,28 @@ func (pb *paymentBusiness) Send(ctx context.Context, message *paymentV1.Payment)
 
 	pb.validateAmountAndCost(message, p, c)
 
+	// Payments over a limit are recorded as failed, naming the rule they broke
+	err = pb.enforceLimits(ctx, limits.Transaction{
+		Operation: limits.Send,
+		ProfileID: p.SenderProfileID,
+		ContactID: p.SenderContactID,
+		MSISDN:    p.SenderMSISDN,
+		RouteID:   p.RouteID,
+		Currency:  p.Currency,
+		Amount:    p.Amount.Decimal,
+	}, "payment", p.GetID(), func() error {
+		event := events.PaymentSave{Service: pb.service}
+		return pb.service.Emit(ctx, event.Name(), p)
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	// Save cost separately and add its ID to payment
 	costEvent := events.CostSave{Service: pb.service}
 	if err := pb.service.Emit(ctx, costEvent.Name(), c); err != nil {
 		pb.service.Log(ctx).WithError(err).Warn("could not emit cost event")
+		pb.releaseLimits(ctx, p.GetID())
 		return nil, err
 	}
 	p.CostIDs = []string{c.ID}
@@ -101,6 +214,7 @@ func (pb *paymentBusiness) Send(ctx context.Context, message *paymentV1.Payment)
 	event := events.PaymentSave{Service: pb.service}
 	if err := pb.service.Emit(ctx, event.Name(), p); err != nil {
 		pb.service.Log(ctx).WithError(err).Warn("could not emit payment event")
+		pb.releaseLimits(ctx, p.GetID())
 		return nil, err
 	}
 
@@ -109,14 +223,11 @@ func (pb *paymentBusiness) Send(ctx context.Context, message *paymentV1.Payment)
 		senderTel = message.GetSource().GetDetail()
 	}
 
-	// try member name from source profile name or extras
-	memberName := ""
-	if message.GetSource() != nil {
-		memberName = message.GetSource().GetProfileName()
-		if memberName == "" {
-			if v, ok := message.GetSource().GetExtras()["member_name"]; ok {
-				memberName = v
-			}
+	// try member name from the sender's profile, the source profile name or extras
+	memberName := p.SenderName
+	if memberName == "" && message.GetSource() != nil {
+		if v, ok := message.GetSource().GetExtras()["member_name"]; ok {
+			memberName = v
 		}
 	}
 
@@ -138,13 +249,19 @@ func (pb *paymentBusiness) Send(ctx context.Context, message *paymentV1.Payment)
 		}
 	}
 
+	// Payments an approval policy covers wait for their approvers before they can be released
+	approval, err := pb.openApproval(ctx, p)
+	if err != nil {
+		pb.service.Log(ctx).WithError(err).Warn("could not open payment approval")
+	}
+
 	// Unified status
 	status := &models.Status{
 		EntityID:   p.GetID(),
 		EntityType: "payment",
 		State:      int32(commonv1.STATE_CREATED.Number()),
 		Status:     int32(commonv1.STATUS_QUEUED.Number()),
-		Extra:      make(datatypes.JSONMap),
+		Extra:      approvalExtras(approval),
 	}
 	status.GenID(ctx)
 	statusEvent := events.StatusSave{Service: pb.service}
@@ -165,19 +282,31 @@ func (pb *paymentBusiness) Receive(ctx context.Context, message *paymentV1.Payme
 	logger := pb.service.Log(ctx).WithField("request", message)
 	logger.Info("handling receive request")
 
+	existing, err := pb.existingInboundPayment(ctx, message.GetTransactionId())
+	if err != nil {
+		logger.WithError(err).Warn("could not check for an existing payment")
+		return nil, err
+	}
+	if existing != nil {
+		logger.WithField("paymentId", existing.GetId()).Info("payment was already received")
+		return existing, nil
+	}
+
 	p := &models.Payment{
-		SenderProfileType:    message.GetSource().GetProfileType(),
-		SenderProfileID:      message.GetSource().GetProfileId(),
-		SenderContactID:      message.GetSource().GetContactId(),
-		RecipientProfileType: message.GetRecipient().GetProfileType(),
-		RecipientProfileID:   message.GetRecipient().GetProfileId(),
-		RecipientContactID:   message.GetRecipient().GetContactId(),
-		ReferenceID:          message.GetReferenceId(),
-		BatchID:              message.GetBatchId(),
-		RouteID:              message.GetRoute(),
-		OutBound:             false,
+		ReferenceID:   message.GetReferenceId(),
+		BatchID:       message.GetBatchId(),
+		RouteID:       message.GetRoute(),
+		TransactionID: message.GetTransactionId(),
+		OutBound:      false,
+		Extra:         frame.DBPropertiesFromMap(message.GetExtra()),
 	}
 
+	// Callbacks often only carry the payer's number, it is resolved to the profile holding it.
+	// Money already arrived, so parties that cannot be resolved are recorded as given and
+	// flagged instead of refusing the payment.
+	applySender(p, pb.receivedParty(ctx, p, "sender", message.GetSource()))
+	applyRecipient(p, pb.receivedParty(ctx, p, "recipient", message.GetRecipient()))
+
 	c := &models.Cost{
 		Amount: decimal.NullDecimal{
 			Valid:   true,
@@ -192,33 +321,109 @@ func (pb *paymentBusiness) Receive(ctx context.Context, message *paymentV1.Payme
 	}
 	pb.validateAmountAndCost(message, p, c)
 
-	// Save cost separately and add its ID to payment
+	prompt, err := pb.findPromptForPayment(ctx, message)
+	if err != nil {
+		logger.WithError(err).Warn("could not look up prompt for payment")
+		return nil, err
+	}
+	if prompt != nil {
+		applyPrompt(p, prompt)
+	}
+
+	link, err := pb.findPaymentLinkForPayment(ctx, message)
+	if err != nil {
+		logger.WithError(err).Warn("could not look up payment link for payment")
+		return nil, err
+	}
+	if link != nil {
+		applyPaymentLink(p, link, time.Now())
+	}
+
+	// Inbound payments are counted against the receiving profile and the number paying in. The
+	// money already arrived, a payment over a limit is recorded and held for review instead
+	rule, err := pb.countLimits(ctx, limits.Transaction{
+		Operation: limits.Receive,
+		ProfileID: p.RecipientProfileID,
+		ContactID: p.RecipientContactID,
+		MSISDN:    p.SenderMSISDN,
+		RouteID:   p.RouteID,
+		Currency:  p.Currency,
+		Amount:    p.Amount.Decimal,
+	}, p.GetID())
+	if err != nil {
+		return nil, err
+	}
+	if rule != nil {
+		logger.WithField("rule", rule.String()).Info("inbound payment exceeds limit, holding it for review")
+		p.Extra["limit_rule"] = rule.String()
+	}
+
+	p.CostIDs = []string{c.ID}
+
+	// Deliveries of one transaction race to record it, only the first goes on
+	claimed, err := repository.NewPaymentRepository(ctx, pb.service).ClaimInbound(ctx, p)
+	if err != nil {
+		logger.WithError(err).Warn("could not record received payment")
+		pb.releaseLimits(ctx, p.GetID())
+		return nil, err
+	}
+	if !claimed {
+		pb.releaseLimits(ctx, p.GetID())
+		existing, err = pb.existingInboundPayment(ctx, p.TransactionID)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			return nil, ErrPaymentAlreadyProcessed
+		}
+		logger.WithField("paymentId", existing.GetId()).Info("payment was already received")
+		return existing, nil
+	}
+
+	// Save cost separately, the payment is saved again to route it
 	costEvent := events.CostSave{Service: pb.service}
-	if err := pb.service.Emit(ctx, costEvent.Name(), c); err != nil {
+	if err = pb.service.Emit(ctx, costEvent.Name(), c); err != nil {
 		pb.service.Log(ctx).WithError(err).Warn("could not emit cost event")
 		return nil, err
 	}
-	p.CostIDs = []string{c.ID}
 
 	event := events.PaymentSave{Service: pb.service}
-	if err := pb.service.Emit(ctx, event.Name(), p); err != nil {
+	if err = pb.service.Emit(ctx, event.Name(), p); err != nil {
 		pb.service.Log(ctx).WithError(err).Warn("could not emit payment event")
 		return nil, err
 	}
 
+	if rule != nil {
+		if err = pb.holdForReview(ctx, p, limitExceeded(rule).Error()); err != nil {
+			logger.WithError(err).Warn("could not hold payment over limit for review")
+			return nil, err
+		}
+	}
+
+	if prompt != nil {
+		if err = pb.completePrompt(ctx, prompt, p); err != nil {
+			logger.WithError(err).WithField("promptId", prompt.ID).Warn("could not complete prompt")
+			return nil, err
+		}
+	}
+
+	if link != nil {
+		if err = pb.recordPaymentLinkCollection(ctx, p); err != nil {
+			// The payment itself is recorded, only the link totals lag behind
+			logger.WithError(err).WithField("paymentLinkId", link.ID).Warn("could not update payment link totals")
+		}
+	}
+
 	senderTel := ""
 	if message.GetSource() != nil {
 		senderTel = message.GetSource().GetDetail()
 	}
 
-	// try member name from source profile name or extras
-	memberName := ""
-	if message.GetSource() != nil {
-		memberName = message.GetSource().GetProfileName()
-		if memberName == "" {
-			if v, ok := message.GetSource().GetExtras()["member_name"]; ok {
-				memberName = v
-			}
+	// try member name from the sender's profile, the source profile name or extras
+	memberName := p.SenderName
+	if memberName == "" && message.GetSource() != nil {
+		if v, ok := message.GetSource().GetExtras()["member_name"]; ok {
+			memberName = v
 		}
 	}
 
@@ -248,6 +453,9 @@ func (pb *paymentBusiness) Receive(ctx context.Context, message *paymentV1.Payme
 		Status:     int32(commonv1.STATUS_QUEUED.Number()),
 		Extra:      make(datatypes.JSONMap),
 	}
+	if rule != nil {
+		status.Extra["limit_rule"] = rule.String()
+	}
 	status.GenID(ctx)
 	statusEvent := events.StatusSave{Service: pb.service}
 	if err := pb.service.Emit(ctx, statusEvent.Name(), status); err != nil {
@@ -297,8 +505,23 @@ func (pb *paymentBusiness) StatusUpdate(
 		return nil, errors.New("entity_type must be provided in extras for status update")
 	}
 
+	entityID := req.GetId()
+	if entityID == "" && entityType == "prompt" && req.GetExtras()["transaction_ref"] != "" {
+		// Provider callbacks only know the reference the prompt was sent with
+		prompt, err := repository.NewPromptRepository(ctx, pb.service).
+			GetByTransactionRef(ctx, req.GetExtras()["transaction_ref"])
+		if err != nil {
+			logger.WithError(err).Warn("could not find prompt for transaction reference")
+			return nil, err
+		}
+		entityID = prompt.ID
+	}
+	if entityID == "" {
+		return nil, ErrInvalidPaymentRequest
+	}
+
 	status := &models.Status{
-		EntityID:   req.GetId(),
+		EntityID:   entityID,
 		EntityType: entityType,
 		State:      int32(req.GetState()),
 		Status:     int32(req.GetStatus()),
@@ -396,8 +619,19 @@ func (pb *paymentBusiness) Release(
 	}
 
 	if !p.IsReleased() {
+		if err = pb.checkReleaser(ctx, p); err != nil {
+			return nil, err
+		}
+		if err = pb.checkApproval(ctx, p); err != nil {
+			return nil, err
+		}
+		if err = pb.checkBeneficiary(ctx, p); err != nil {
+			return nil, err
+		}
+
 		releaseDate := time.Now()
 		p.ReleasedAt = &releaseDate
+		p.ReleasedBy = subjectFromContext(ctx)
 
 		event := events.PaymentSave{Service: pb.service}
 		err = pb.service.Emit(ctx, event.Name(), p)
@@ -451,27 +685,37 @@ func (pb *paymentBusiness) InitiatePrompt(
 	logger := pb.service.Log(ctx).WithField("request", req)
 	logger.Info("handling initiate prompt request")
 
-	// Build Account from request
-	account := models.Account{
-		AccountNumber: req.GetRecipientAccount().GetAccountNumber(),
-		CountryCode:   req.GetRecipientAccount().GetCountryCode(),
-		Name:          req.GetRecipientAccount().GetName(),
-	}
-
-	// Use AccountRepository to get or create the account
+	// Prompts either name a registered account or carry the raw account details
 	var accountPtr *models.Account
 	var err error
-	accountPtr, err = repository.NewAccountRepository(ctx, pb.service).GetByAccountNumber(ctx, account.AccountNumber)
+	country := req.GetRecipientAccount().GetCountryCode()
+	if accountID := req.GetExtra()["account_id"]; accountID != "" {
+		accountPtr, err = pb.collectionAccount(ctx, accountID, req.GetAmount().GetCurrencyCode())
+		if err != nil {
+			return nil, err
+		}
+		country = accountPtr.CountryCode
+	}
+
+	number, err := pb.resolvePromptNumber(ctx, req, country)
 	if err != nil {
-		// If not found, create the account
-		account.GenID(ctx)
-		event := events.AccountSave{Service: pb.service}
-		err = pb.service.Emit(ctx, event.Name(), &account)
+		return nil, err
+	}
+	if err = pb.checkPromptRate(ctx, number.Digits()); err != nil {
+		return nil, err
+	}
+
+	if accountPtr == nil {
+		accountPtr, err = pb.recordPromptAccount(ctx, req.GetRecipientAccount())
 		if err != nil {
-			logger.WithError(err).Warn("could not emit account save")
+			logger.WithError(err).Warn("could not record prompt account")
 			return nil, err
 		}
-		accountPtr = &account
+	}
+
+	route := req.GetRoute()
+	if route == "" {
+		route = accountPtr.RouteID
 	}
 
 	p := &models.Prompt{
@@ -486,14 +730,13 @@ func (pb *paymentBusiness) InitiatePrompt(
 		DeviceID:             req.GetDeviceId(),
 		State:                int32(commonv1.STATE_CREATED.Number()),
 		Status:               int32(commonv1.STATUS_QUEUED.Number()),
+		Route:                route,
 		AccountID:            accountPtr.ID,
 		Account:              *accountPtr,
 		Extra:                frame.DBPropertiesFromMap(req.GetExtra()),
+		Attempt:              1,
 	}
 
-	// Generate a unique transaction reference (6 chars - letter prefix + 5 digits)
-	transactionRef := generateTransactionRef()
-
 	// First explicitly set the provided ID if one was given
 	if req.GetId() != "" {
 		p.ID = req.GetId()
@@ -506,15 +749,44 @@ func (pb *paymentBusiness) InitiatePrompt(
 
 	logger.WithField("promptId", p.ID).Info("Prompt ID set")
 
+	err = pb.enforceLimits(ctx, limits.Transaction{
+		Operation: limits.Prompt,
+		ProfileID: p.SourceID,
+		ContactID: p.SourceContactID,
+		MSISDN:    number.Digits(),
+		RouteID:   p.Route,
+		Currency:  req.GetAmount().GetCurrencyCode(),
+		Amount:    p.Amount.Decimal,
+	}, "prompt", p.ID, func() error {
+		p.State = int32(commonv1.STATE_INACTIVE.Number())
+		p.Status = int32(commonv1.STATUS_FAILED.Number())
+		p.Extra["currency"] = req.GetAmount().GetCurrencyCode()
+		p.Extra["mobile_number"] = number.Digits()
+		event := events.PromptSave{Service: pb.service}
+		return pb.service.Emit(ctx, event.Name(), p)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	transactionRef, err := pb.reserveTransactionRef(ctx, p.Route, "prompt", p.ID)
+	if err != nil {
+		logger.WithError(err).Warn("could not reserve transaction reference")
+		pb.releaseLimits(ctx, p.ID)
+		return nil, err
+	}
+
 	p.Extra["transaction_ref"] = transactionRef
 	p.Extra["currency"] = req.GetAmount().GetCurrencyCode()
-	p.Extra["mobile_number"] = req.GetSource().GetDetail()
-	// Add telco and pushType information if provided
+	p.Extra["mobile_number"] = number.Digits()
+	p.Extra["country"] = number.Country
+	p.Extra["telco"] = string(number.Operator)
 
 	event := events.PromptSave{Service: pb.service}
 	err = pb.service.Emit(ctx, event.Name(), p)
 	if err != nil {
 		logger.WithError(err).Warn("could not emit prompt save")
+		pb.releaseLimits(ctx, p.ID)
 		return nil, err
 	}
 
@@ -538,7 +810,13 @@ func (pb *paymentBusiness) InitiatePrompt(
 		return nil, err
 	}
 
-	err = pb.service.Publish(ctx, "initiate.prompt", p)
+	psp, err := pb.providers.ForRoute(ctx, pb.service, p.Route)
+	if err != nil {
+		logger.WithError(err).Warn("could not resolve provider for prompt")
+		return nil, err
+	}
+
+	err = psp.InitiatePrompt(ctx, p)
 	if err != nil {
 		logger.WithError(err).Warn("could not publish initiate-prompt")
 		return nil, err
@@ -552,9 +830,34 @@ func (pb *paymentBusiness) InitiatePrompt(
 	}, nil
 }
 
-func (pb *paymentBusiness) CreatePaymentLink(
+// resolvePromptNumber normalises the number the prompt is sent to and detects its operator,
+// numbers in national form are read in the country of the account the prompt collects into.
+func (pb *paymentBusiness) resolvePromptNumber(
+	ctx context.Context,
+	req *paymentV1.InitiatePromptRequest,
+	country string,
+) (msisdn.Number, error) {
+	raw := req.GetSource().GetDetail()
+	if raw == "" {
+		raw = req.GetSource().GetContactId()
+	}
+
+	number, err := pb.numbers.Resolve(ctx, raw, country)
+	switch {
+	case errors.Is(err, msisdn.ErrPortabilityUnavailable):
+		pb.service.Log(ctx).WithError(err).Warn("using the prefix operator for the prompt number")
+		return number, nil
+	case err != nil:
+		pb.service.Log(ctx).WithError(err).WithField("number", raw).Warn("invalid prompt mobile number")
+		return msisdn.Number{}, ErrInvalidMobileNumber
+	}
+	return number, nil
+}
+
+func (pb *paymentBusiness) createPaymentLink(
 	ctx context.Context,
 	req *paymentV1.CreatePaymentLinkRequest,
+	account *models.Account,
 ) (*commonv1.StatusResponse, error) {
 	logger := pb.service.Log(ctx).WithField("request", req)
 	logger.Info("handling create payment link request")
@@ -644,6 +947,15 @@ func (pb *paymentBusiness) CreatePaymentLink(
 		amount = utility.FromMoney(plReq.GetAmount())
 	}
 
+	amountOption := plReq.GetAmountOption()
+	if amountOption == "" {
+		amountOption = models.AmountOptionRestricted
+	}
+	if !strings.EqualFold(amountOption, models.AmountOptionOpen) && !amount.IsPositive() {
+		logger.Error("fixed amount payment link requires an amount")
+		return nil, ErrInvalidPaymentLinkRequest
+	}
+
 	// Build PaymentLink model
 	paymentLink := &models.PaymentLink{
 		ExpiryDate:      expiryDate,
@@ -655,11 +967,23 @@ func (pb *paymentBusiness) CreatePaymentLink(
 		ExternalRef:     plReq.GetExternalRef(),
 		PaymentLinkRef:  plReq.GetPaymentLinkRef(),
 		RedirectURL:     plReq.GetRedirectUrl(),
-		AmountOption:    plReq.GetAmountOption(),
+		AmountOption:    amountOption,
 		Amount:          amount,
 		Currency:        plReq.GetCurrency(),
 		Customers:       customersJSON,
 		Notifications:   notificationsJSON,
+		State:           int32(commonv1.STATE_CREATED),
+	}
+	if account != nil {
+		if paymentLink.Currency == "" {
+			paymentLink.Currency = account.Currency
+		}
+		if account.Currency != "" && !strings.EqualFold(paymentLink.Currency, account.Currency) {
+			logger.WithField("accountId", account.ID).Error("payment link currency differs from its account")
+			return nil, ErrAccountCurrencyMismatch
+		}
+		paymentLink.AccountID = account.ID
+		paymentLink.CounterID = account.CounterID
 	}
 
 	// Set ID if provided
@@ -694,7 +1018,10 @@ func (pb *paymentBusiness) CreatePaymentLink(
 		return nil, statusEmitErr
 	}
 
-	err = pb.service.Publish(ctx, "create.payment.link", paymentLink)
+	psp, err := pb.providers.Get(paymentLink.CounterID)
+	if err == nil {
+		err = psp.CreatePaymentLink(ctx, paymentLink)
+	}
 	if err != nil {
 		logger.WithError(err).Warn("could not publish create-payment-link")
 		// Emit the status event even if publish fails
@@ -737,22 +1064,6 @@ func (pb *paymentBusiness) validateAmountAndCost(message *paymentV1.Payment, p *
 	c.Currency = message.GetCost().GetCurrencyCode()
 }
 
-const (
-	// Transaction reference generation constants
-	millionMod    = 1000000 // Modulo for time component
-	asciiCharBase = 65      // ASCII A for character generation
-	alphabetSize  = 26      // Number of letters in alphabet
-	hundredKMod   = 100000  // Modulo for final component
-)
-
-// generateTransactionRef creates a unique 6-character reference for Jenga API.
-func generateTransactionRef() string {
-	timestamp := time.Now().UnixNano() / int64(time.Millisecond)
-	timeComponent := timestamp % millionMod
-	asciiChar := asciiCharBase + ((timestamp / millionMod) % 26)
-	return fmt.Sprintf("%c%05d", rune(asciiChar), timeComponent%hundredKMod)
-}
-
 // createDepositStep1 creates the initial receipt transaction:
 // DR – Mobile Operator
 // CR - Unidentified Deposits
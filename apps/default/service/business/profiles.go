@@ -0,0 +1,181 @@
+package business
+
+import (
+	"context"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	profileV1 "github.com/antinvestor/apis/go/profile/v1"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/msisdn"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/datatypes"
+)
+
+// party is the sender or recipient of a payment as the profile service knows it.
+type party struct {
+	ProfileID   string
+	ProfileType string
+	ContactID   string
+	Name        string
+	MSISDN      string
+}
+
+// resolveParty looks up the profile and contact a payment names. Profiles and contacts named
+// by ID must exist and the contact must belong to the profile. A party named only by its
+// mobile number, as provider callbacks do, is resolved to the profile holding the number,
+// numbers no profile holds are kept as they are. Without a profile client the party is taken
+// as given.
+func (pb *paymentBusiness) resolveParty(ctx context.Context, link *commonv1.ContactLink) (*party, error) {
+	p := pb.linkedParty(link)
+	if pb.profileCli == nil {
+		return p, nil
+	}
+	logger := pb.service.Log(ctx).WithField("profileId", p.ProfileID).WithField("contactId", p.ContactID)
+
+	var profile *profileV1.ProfileObject
+	var err error
+	switch {
+	case p.ProfileID != "":
+		profile, err = pb.profileCli.GetProfileByID(ctx, p.ProfileID)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return nil, ErrProfileDoesNotExist
+			}
+			logger.WithError(err).Warn("could not get profile")
+			return nil, ErrProfileLookupFailed
+		}
+	case p.MSISDN != "":
+		profile, err = pb.profileCli.GetProfileByContact(ctx, p.MSISDN)
+		if err != nil && status.Code(err) != codes.NotFound {
+			logger.WithError(err).Warn("could not get profile by mobile number")
+			return nil, ErrProfileLookupFailed
+		}
+	}
+
+	if profile.GetId() == "" {
+		// A contact ID cannot be checked without the profile holding it
+		if p.ContactID != "" {
+			return nil, ErrContactDoesNotExist
+		}
+		return p, nil
+	}
+
+	p.ProfileID = profile.GetId()
+	if p.ProfileType == "" {
+		p.ProfileType = profile.GetType().String()
+	}
+	if name := profile.GetProperties()["name"]; name != "" {
+		p.Name = name
+	}
+
+	var contact *profileV1.ContactObject
+	switch {
+	case p.ContactID != "":
+		contact = filterContactFromProfileByID(profile, p.ContactID)
+		if contact == nil {
+			return nil, ErrContactDoesNotExist
+		}
+	case p.MSISDN != "":
+		contact = filterContactFromProfileByMSISDN(profile, p.MSISDN, pb.normaliseMSISDN)
+	}
+	if contact != nil {
+		p.ContactID = contact.GetId()
+		if contact.GetType() == profileV1.ContactType_MSISDN {
+			p.MSISDN = pb.normaliseMSISDN(contact.GetDetail())
+		}
+	}
+	return p, nil
+}
+
+// receivedParty resolves a party of a received payment. A party that cannot be resolved is
+// kept as the payment names it and flagged on the payment with the reason, under
+// "<role>_unresolved".
+func (pb *paymentBusiness) receivedParty(
+	ctx context.Context,
+	p *models.Payment,
+	role string,
+	link *commonv1.ContactLink,
+) *party {
+	resolved, err := pb.resolveParty(ctx, link)
+	if err == nil {
+		return resolved
+	}
+
+	pb.service.Log(ctx).WithError(err).WithField("role", role).
+		Warn("could not resolve party of received payment, keeping the payment's details")
+	if p.Extra == nil {
+		p.Extra = make(datatypes.JSONMap)
+	}
+	p.Extra[role+"_unresolved"] = status.Convert(err).Message()
+	return pb.linkedParty(link)
+}
+
+// linkedParty returns the party a payment names, unchecked.
+func (pb *paymentBusiness) linkedParty(link *commonv1.ContactLink) *party {
+	return &party{
+		ProfileID:   link.GetProfileId(),
+		ProfileType: link.GetProfileType(),
+		ContactID:   link.GetContactId(),
+		Name:        link.GetProfileName(),
+		MSISDN:      pb.normaliseMSISDN(link.GetDetail()),
+	}
+}
+
+// normaliseMSISDN returns a mobile number in E.164 form, or nothing for details that are
+// not mobile numbers such as bank accounts.
+func (pb *paymentBusiness) normaliseMSISDN(detail string) string {
+	if detail == "" {
+		return ""
+	}
+	e164, _, err := msisdn.Normalise(detail, pb.numbers.DefaultCountry)
+	if err != nil {
+		return ""
+	}
+	return e164
+}
+
+// filterContactFromProfileByID finds a contact by ID in a profile.
+func filterContactFromProfileByID(profile *profileV1.ProfileObject, contactID string) *profileV1.ContactObject {
+	for _, contact := range profile.GetContacts() {
+		if contact.GetId() == contactID {
+			return contact
+		}
+	}
+
+	return nil
+}
+
+// filterContactFromProfileByMSISDN finds the mobile number contact of a profile holding a
+// number, contact details are normalised before they are compared.
+func filterContactFromProfileByMSISDN(
+	profile *profileV1.ProfileObject,
+	e164 string,
+	normalise func(string) string,
+) *profileV1.ContactObject {
+	for _, contact := range profile.GetContacts() {
+		if contact.GetType() == profileV1.ContactType_MSISDN && normalise(contact.GetDetail()) == e164 {
+			return contact
+		}
+	}
+
+	return nil
+}
+
+// applySender records the resolved sender on a payment.
+func applySender(p *models.Payment, sender *party) {
+	p.SenderProfileID = sender.ProfileID
+	p.SenderProfileType = sender.ProfileType
+	p.SenderContactID = sender.ContactID
+	p.SenderName = sender.Name
+	p.SenderMSISDN = sender.MSISDN
+}
+
+// applyRecipient records the resolved recipient on a payment.
+func applyRecipient(p *models.Payment, recipient *party) {
+	p.RecipientProfileID = recipient.ProfileID
+	p.RecipientProfileType = recipient.ProfileType
+	p.RecipientContactID = recipient.ContactID
+	p.RecipientName = recipient.Name
+	p.RecipientMSISDN = recipient.MSISDN
+}
@@ -0,0 +1,141 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/antinvestor/apis/go/common"
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	profileV1 "github.com/antinvestor/apis/go/profile/v1"
+	profileMocks "github.com/antinvestor/apis/go/profile/v1_mocks"
+	"github.com/antinvestor/service-payments/config"
+	"github.com/antinvestor/service-payments/service/models"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// directoryClient returns a profile client serving profiles by ID and by the numbers of their
+// contacts, as written on the contact. The profile "outage" cannot be looked up.
+func directoryClient(t *testing.T, profiles ...*profileV1.ProfileObject) *profileV1.ProfileClient {
+	t.Helper()
+	mockProfileService := profileMocks.NewMockProfileServiceClient(gomock.NewController(t))
+	mockProfileService.EXPECT().
+		GetById(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, req *profileV1.GetByIdRequest, _ ...any) (*profileV1.GetByIdResponse, error) {
+			if req.GetId() == "outage" {
+				return nil, status.Error(codes.Unavailable, "profile service is down")
+			}
+			for _, profile := range profiles {
+				if profile.GetId() == req.GetId() {
+					return &profileV1.GetByIdResponse{Data: profile}, nil
+				}
+			}
+			return nil, status.Error(codes.NotFound, "profile not found")
+		}).AnyTimes()
+	mockProfileService.EXPECT().
+		GetByContact(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context,
+			req *profileV1.GetByContactRequest,
+			_ ...any,
+		) (*profileV1.GetByContactResponse, error) {
+			for _, profile := range profiles {
+				for _, contact := range profile.GetContacts() {
+					if contact.GetDetail() == req.GetContact() {
+						return &profileV1.GetByContactResponse{Data: profile}, nil
+					}
+				}
+			}
+			return nil, status.Error(codes.NotFound, "profile not found")
+		}).AnyTimes()
+	return profileV1.Init(&common.GrpcClientBase{}, mockProfileService)
+}
+
+func customer() *profileV1.ProfileObject {
+	return &profileV1.ProfileObject{
+		Id:         "profile-1",
+		Type:       profileV1.ProfileType_PERSON,
+		Properties: map[string]string{"name": "Jane Doe"},
+		Contacts: []*profileV1.ContactObject{
+			{Id: "contact-email", Type: profileV1.ContactType_EMAIL, Detail: "jane@example.com"},
+			{Id: "contact-phone", Type: profileV1.ContactType_MSISDN, Detail: "+254712345678"},
+		},
+	}
+}
+
+func TestResolveParty(t *testing.T) {
+	pb := newTestBusiness(t, &config.PaymentConfig{}, directoryClient(t, customer()))
+
+	tests := []struct {
+		name    string
+		link    *commonv1.ContactLink
+		want    party
+		wantErr error
+	}{
+		{name: "profile and contact", link: &commonv1.ContactLink{ProfileId: "profile-1", ContactId: "contact-phone"},
+			want: party{ProfileID: "profile-1", ProfileType: "PERSON", ContactID: "contact-phone", Name: "Jane Doe",
+				MSISDN: "+254712345678"}},
+		{name: "profile only", link: &commonv1.ContactLink{ProfileId: "profile-1"},
+			want: party{ProfileID: "profile-1", ProfileType: "PERSON", Name: "Jane Doe"}},
+		{name: "number held by a profile", link: &commonv1.ContactLink{Detail: "+254712345678"},
+			want: party{ProfileID: "profile-1", ProfileType: "PERSON", ContactID: "contact-phone", Name: "Jane Doe",
+				MSISDN: "+254712345678"}},
+		{name: "number no profile holds", link: &commonv1.ContactLink{Detail: "0722000000", ProfileName: "JOHN"},
+			want: party{Name: "JOHN", MSISDN: "+254722000000"}},
+		{name: "unknown profile", link: &commonv1.ContactLink{ProfileId: "profile-2"},
+			wantErr: ErrProfileDoesNotExist},
+		{name: "contact of another profile", link: &commonv1.ContactLink{ProfileId: "profile-1", ContactId: "contact-9"},
+			wantErr: ErrContactDoesNotExist},
+		{name: "contact without a profile", link: &commonv1.ContactLink{ContactId: "contact-phone"},
+			wantErr: ErrContactDoesNotExist},
+		{name: "profile service outage", link: &commonv1.ContactLink{ProfileId: "outage"},
+			wantErr: ErrProfileLookupFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pb.resolveParty(t.Context(), tt.link)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("resolveParty() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || *got != tt.want {
+				t.Errorf("resolveParty() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReceivedPartyKeepsUnresolvedParties(t *testing.T) {
+	pb := newTestBusiness(t, &config.PaymentConfig{}, directoryClient(t, customer()))
+
+	tests := []struct {
+		name string
+		link *commonv1.ContactLink
+	}{
+		{name: "unknown profile", link: &commonv1.ContactLink{ProfileId: "profile-2", Detail: "0722000000"}},
+		{name: "unknown contact", link: &commonv1.ContactLink{ProfileId: "profile-1", ContactId: "contact-9"}},
+		{name: "profile service outage", link: &commonv1.ContactLink{ProfileId: "outage"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &models.Payment{}
+			got := pb.receivedParty(t.Context(), p, "recipient", tt.link)
+			if *got != *pb.linkedParty(tt.link) {
+				t.Errorf("receivedParty() = %+v, want the party as the payment names it", got)
+			}
+			if reason, _ := p.Extra["recipient_unresolved"].(string); reason == "" {
+				t.Errorf("payment extras = %v, want the recipient flagged as unresolved", p.Extra)
+			}
+		})
+	}
+
+	p := &models.Payment{}
+	pb.receivedParty(t.Context(), p, "sender", &commonv1.ContactLink{ProfileId: "profile-1"})
+	if _, flagged := p.Extra["sender_unresolved"]; flagged {
+		t.Error("resolved sender was flagged as unresolved")
+	}
+}
@@ -0,0 +1,284 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strconv"
+	"time"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/service-payments/config"
+	"github.com/antinvestor/service-payments/service/events"
+	"github.com/antinvestor/service-payments/service/limits"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/repository"
+	"github.com/pitabwire/frame"
+	"github.com/shopspring/decimal"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// defaultPromptMaxAttempts bounds how often a prompt is sent when the configuration does not.
+const defaultPromptMaxAttempts = 3
+
+// findPromptForPayment returns the prompt an inbound payment answers, matched on the
+// transaction reference the prompt was sent to the provider with.
+func (pb *paymentBusiness) findPromptForPayment(ctx context.Context, message *paymentV1.Payment) (*models.Prompt, error) {
+	transactionRef := message.GetExtra()["transaction_ref"]
+	if transactionRef == "" {
+		return nil, nil
+	}
+
+	prompt, err := repository.NewPromptRepository(ctx, pb.service).GetByTransactionRef(ctx, transactionRef)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return prompt, nil
+}
+
+// applyPrompt lets an inbound payment inherit the parties and account of the prompt that
+// requested it. Details already supplied with the payment take precedence.
+func applyPrompt(p *models.Payment, prompt *models.Prompt) {
+	if p.SenderProfileID == "" {
+		p.SenderProfileID = prompt.SourceID
+		p.SenderProfileType = prompt.SourceProfileType
+		p.SenderContactID = prompt.SourceContactID
+	}
+	if p.RecipientProfileID == "" {
+		p.RecipientProfileID = prompt.RecipientID
+		p.RecipientProfileType = prompt.RecipientProfileType
+		p.RecipientContactID = prompt.RecipientContactID
+	}
+	if p.RouteID == "" {
+		p.RouteID = prompt.Route
+	}
+
+	if p.Extra == nil {
+		p.Extra = make(datatypes.JSONMap)
+	}
+	p.Extra["prompt_id"] = prompt.ID
+	if prompt.AccountID != "" {
+		p.Extra["account_id"] = prompt.AccountID
+		p.Extra["account_number"] = prompt.Account.AccountNumber
+		p.Extra["account_country_code"] = prompt.Account.CountryCode
+		p.Extra["account_name"] = prompt.Account.Name
+	}
+
+	if prompt.Amount.Valid && p.Amount.Valid && !prompt.Amount.Decimal.Equal(p.Amount.Decimal) {
+		p.Extra["prompt_amount"] = prompt.Amount.Decimal.String()
+		p.Extra["amount_mismatch"] = "true"
+	}
+}
+
+// completePrompt finalises a prompt once the payment it requested has been received.
+func (pb *paymentBusiness) completePrompt(ctx context.Context, prompt *models.Prompt, p *models.Payment) error {
+	status := &models.Status{
+		EntityID:   prompt.ID,
+		EntityType: "prompt",
+		State:      int32(commonv1.STATE_ACTIVE.Number()),
+		Status:     int32(commonv1.STATUS_SUCCESSFUL.Number()),
+		Extra: datatypes.JSONMap{
+			"transaction_ref": prompt.Extra["transaction_ref"],
+			"transaction_id":  p.TransactionID,
+			"payment_id":      p.GetID(),
+			"resolution":      models.PromptResolutionPaid,
+			"resolved_by":     "callback",
+		},
+	}
+	status.GenID(ctx)
+
+	statusEvent := events.StatusSave{Service: pb.service}
+	return pb.service.Emit(ctx, statusEvent.Name(), status)
+}
+
+// ResendPrompt sends a failed prompt to the customer again. Each resend is saved as a new
+// attempt with its own transaction reference, linked to the original prompt so every attempt
+// and the reason it failed stay on record. Any attempt's ID may be given.
+func (pb *paymentBusiness) ResendPrompt(ctx context.Context, id string) (*commonv1.StatusResponse, error) {
+	logger := pb.service.Log(ctx).WithField("promptId", id)
+	logger.Info("handling resend prompt request")
+
+	promptRepo := repository.NewPromptRepository(ctx, pb.service)
+	prompt, err := promptRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPromptDoesNotExist
+		}
+		return nil, err
+	}
+
+	// Refuse early, before counting against the number's rate. The check is repeated once the
+	// original prompt is locked, so concurrent resends cannot both pass it
+	attempts, err := promptRepo.ListAttempts(ctx, prompt.RootID())
+	if err != nil {
+		logger.WithError(err).Warn("could not list prompt attempts")
+		return nil, err
+	}
+	latest, err := pb.resendablePrompt(attempts)
+	if err != nil {
+		return nil, err
+	}
+
+	mobileNumber, _ := latest.Extra["mobile_number"].(string)
+	if err = pb.checkPromptRate(ctx, mobileNumber); err != nil {
+		return nil, err
+	}
+
+	attemptID := frame.GenerateID(ctx)
+	transactionRef, err := pb.reserveTransactionRef(ctx, latest.Route, "prompt", attemptID)
+	if err != nil {
+		logger.WithError(err).Warn("could not reserve transaction reference")
+		return nil, err
+	}
+
+	attempt, err := promptRepo.AddAttempt(ctx, prompt.RootID(), func(attempts []*models.Prompt) (*models.Prompt, error) {
+		previous, checkErr := pb.resendablePrompt(attempts)
+		if checkErr != nil {
+			return nil, checkErr
+		}
+		latest = previous
+
+		next := nextPromptAttempt(previous)
+		next.BaseModel.ID = attemptID
+		next.ID = attemptID
+		next.Extra["transaction_ref"] = transactionRef
+		return next, nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPromptDoesNotExist
+		}
+		logger.WithError(err).Warn("could not save prompt attempt")
+		return nil, err
+	}
+
+	status := &models.Status{
+		EntityID:   attempt.ID,
+		EntityType: "prompt",
+		State:      attempt.State,
+		Status:     attempt.Status,
+		Extra: datatypes.JSONMap{
+			"transaction_ref":     attempt.Extra["transaction_ref"],
+			"attempt":             strconv.Itoa(attempt.Attempt),
+			"original_prompt_id":  attempt.OriginalPromptID,
+			"previous_prompt_id":  latest.ID,
+			"previous_resolution": latest.Resolution,
+		},
+	}
+	status.GenID(ctx)
+
+	statusEvent := events.StatusSave{Service: pb.service}
+	if err = pb.service.Emit(ctx, statusEvent.Name(), status); err != nil {
+		logger.WithError(err).Warn("could not emit status save")
+		return nil, err
+	}
+
+	psp, err := pb.providers.ForRoute(ctx, pb.service, attempt.Route)
+	if err != nil {
+		logger.WithError(err).Warn("could not resolve provider for prompt")
+		return nil, err
+	}
+
+	if err = psp.InitiatePrompt(ctx, attempt); err != nil {
+		logger.WithError(err).Warn("could not publish initiate-prompt")
+		return nil, err
+	}
+
+	logger.WithField("attemptId", attempt.ID).WithField("attempt", attempt.Attempt).Info("prompt resent")
+	return attempt.ToAPIStatus(), nil
+}
+
+// resendablePrompt returns the latest of a prompt's attempts when the prompt may be sent again,
+// which is once the latest attempt failed and fewer than the maximum attempts were sent.
+func (pb *paymentBusiness) resendablePrompt(attempts []*models.Prompt) (*models.Prompt, error) {
+	if len(attempts) == 0 {
+		return nil, ErrPromptDoesNotExist
+	}
+
+	latest := attempts[len(attempts)-1]
+	switch commonv1.STATUS(latest.Status) {
+	case commonv1.STATUS_FAILED:
+	case commonv1.STATUS_SUCCESSFUL:
+		return nil, ErrPromptAlreadyPaid
+	default:
+		return nil, ErrPromptStillPending
+	}
+	if len(attempts) >= pb.promptMaxAttempts() {
+		return nil, ErrPromptAttemptsExhausted
+	}
+	return latest, nil
+}
+
+// nextPromptAttempt copies the parties, amount and number of the latest attempt into a new,
+// queued attempt. The caller gives it a transaction reference of its own.
+func nextPromptAttempt(latest *models.Prompt) *models.Prompt {
+	extra := make(datatypes.JSONMap, len(latest.Extra)+1)
+	maps.Copy(extra, latest.Extra)
+	delete(extra, "transaction_ref")
+	extra["previous_prompt_id"] = latest.ID
+
+	return &models.Prompt{
+		BaseModel: frame.BaseModel{
+			TenantID:    latest.TenantID,
+			PartitionID: latest.PartitionID,
+			AccessID:    latest.AccessID,
+		},
+		SourceID:             latest.SourceID,
+		SourceProfileType:    latest.SourceProfileType,
+		SourceContactID:      latest.SourceContactID,
+		RecipientID:          latest.RecipientID,
+		RecipientProfileType: latest.RecipientProfileType,
+		RecipientContactID:   latest.RecipientContactID,
+		Amount:               latest.Amount,
+		DateCreated:          time.Now().Format("2006-01-02 15:04:05"),
+		DeviceID:             latest.DeviceID,
+		State:                int32(commonv1.STATE_CREATED.Number()),
+		Status:               int32(commonv1.STATUS_QUEUED.Number()),
+		Route:                latest.Route,
+		AccountID:            latest.AccountID,
+		Account:              latest.Account,
+		Extra:                extra,
+		OriginalPromptID:     latest.RootID(),
+		Attempt:              max(latest.Attempt, 1) + 1,
+	}
+}
+
+// checkPromptRate refuses to prompt a mobile number that was already sent the configured
+// number of prompts within the rate window, so customers are not flooded with pushes. Prompts
+// are counted on the limit counters as they are sent, a prompt that later fails still counts.
+func (pb *paymentBusiness) checkPromptRate(ctx context.Context, mobileNumber string) error {
+	cfg, ok := pb.service.Config().(*config.PaymentConfig)
+	if !ok || cfg.PromptNumberLimit <= 0 || cfg.PromptNumberWindow <= 0 || mobileNumber == "" {
+		return nil
+	}
+
+	check := limits.Velocity(limits.Transaction{
+		Operation:   limits.Prompt,
+		PartitionID: partitionFromContext(ctx),
+		MSISDN:      mobileNumber,
+	}, limits.ScopeMSISDN, int64(cfg.PromptNumberLimit), cfg.PromptNumberWindow, time.Now())
+	rule, err := repository.NewLimitRepository(ctx, pb.service).
+		Consume(ctx, frame.GenerateID(ctx), []limits.Check{check}, decimal.Zero)
+	if err != nil {
+		pb.service.Log(ctx).WithError(err).Warn("could not count prompt against number rate")
+		return err
+	}
+	if rule != nil {
+		pb.service.Log(ctx).WithField("number", mobileNumber).WithField("rule", rule.String()).
+			Warn("prompt rate limit reached for number")
+		return ErrPromptRateLimited
+	}
+	return nil
+}
+
+func (pb *paymentBusiness) promptMaxAttempts() int {
+	if cfg, ok := pb.service.Config().(*config.PaymentConfig); ok && cfg.PromptMaxAttempts > 0 {
+		return cfg.PromptMaxAttempts
+	}
+	return defaultPromptMaxAttempts
+}
@@ -0,0 +1,206 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/service/events"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/provider"
+	"github.com/antinvestor/service-payments/service/repository"
+	"github.com/antinvestor/service-payments/service/repository/repositorytest"
+	"gorm.io/datatypes"
+)
+
+// promptRecorder is a provider that records the prompts it is asked to send.
+type promptRecorder struct {
+	provider.Provider
+
+	mu   sync.Mutex
+	sent []string
+}
+
+func (p *promptRecorder) Name() string {
+	return "recorder"
+}
+
+func (p *promptRecorder) InitiatePrompt(_ context.Context, prompt *models.Prompt) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sent = append(p.sent, prompt.ID)
+	return nil
+}
+
+// newPromptBusiness returns a payment business sending prompts through a recorder, on a
+// service with a datastore that allows maxAttempts sends and rateLimit prompts per number.
+func newPromptBusiness(t *testing.T, maxAttempts, rateLimit int) (context.Context, *paymentBusiness, *promptRecorder) {
+	t.Helper()
+	cfg := repositorytest.Config(t)
+	cfg.PromptMaxAttempts = maxAttempts
+	cfg.PromptNumberLimit = rateLimit
+	cfg.PromptNumberWindow = time.Hour
+	ctx, service := repositorytest.NewService(t, cfg)
+	repositorytest.Start(t, ctx, service, &events.StatusSave{Service: service})
+
+	recorder := &promptRecorder{}
+	providers := provider.NewRegistry()
+	providers.Register("jenga", recorder)
+	pb, err := NewPaymentBusiness(ctx, service, nil, nil, nil, providers, nil, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx, pb.(*paymentBusiness), recorder
+}
+
+// failedPrompt saves a prompt whose send failed, as the original or as a resend of original.
+func failedPrompt(t *testing.T, ctx context.Context, pb *paymentBusiness, original *models.Prompt, attempt int) *models.Prompt {
+	t.Helper()
+	prompt := &models.Prompt{
+		State:   int32(commonv1.STATE_INACTIVE),
+		Status:  int32(commonv1.STATUS_FAILED),
+		Extra:   datatypes.JSONMap{"mobile_number": "254712345678"},
+		Attempt: attempt,
+	}
+	if original != nil {
+		prompt.OriginalPromptID = original.ID
+	}
+	prompt.GenID(ctx)
+	prompt.ID = prompt.GetID()
+	if err := repository.NewPromptRepository(ctx, pb.service).Save(ctx, prompt); err != nil {
+		t.Fatal(err)
+	}
+	return prompt
+}
+
+func TestResendPrompt(t *testing.T) {
+	ctx, pb, recorder := newPromptBusiness(t, 3, 0)
+	original := failedPrompt(t, ctx, pb, nil, 1)
+
+	status, err := pb.ResendPrompt(ctx, original.ID)
+	if err != nil {
+		t.Fatalf("ResendPrompt() error = %v", err)
+	}
+	attempts, err := repository.NewPromptRepository(ctx, pb.service).ListAttempts(ctx, original.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 2 || attempts[1].ID != status.GetId() || attempts[1].Attempt != 2 ||
+		attempts[1].OriginalPromptID != original.ID {
+		t.Fatalf("attempts after resend = %v, want the original and attempt 2", attempts)
+	}
+	if attempts[1].Extra["transaction_ref"] == "" {
+		t.Error("resend was not given a transaction reference of its own")
+	}
+	if len(recorder.sent) != 1 || recorder.sent[0] != status.GetId() {
+		t.Errorf("provider was sent %v, want the new attempt", recorder.sent)
+	}
+
+	// The new attempt has not failed, so neither it nor the original can be resent
+	for _, id := range []string{original.ID, status.GetId()} {
+		if _, err = pb.ResendPrompt(ctx, id); !errors.Is(err, ErrPromptStillPending) {
+			t.Errorf("ResendPrompt(%s) error = %v, want ErrPromptStillPending", id, err)
+		}
+	}
+}
+
+func TestResendPromptAttemptsExhausted(t *testing.T) {
+	ctx, pb, _ := newPromptBusiness(t, 2, 0)
+	original := failedPrompt(t, ctx, pb, nil, 1)
+	failedPrompt(t, ctx, pb, original, 2)
+
+	if _, err := pb.ResendPrompt(ctx, original.ID); !errors.Is(err, ErrPromptAttemptsExhausted) {
+		t.Errorf("ResendPrompt() error = %v, want ErrPromptAttemptsExhausted", err)
+	}
+}
+
+func TestResendPromptWithoutAttemptNumber(t *testing.T) {
+	ctx, pb, _ := newPromptBusiness(t, 3, 0)
+
+	// Prompts saved before attempts were numbered have none
+	original := failedPrompt(t, ctx, pb, nil, 0)
+	err := pb.service.DB(ctx, false).Model(&models.Prompt{}).
+		Where("id = ?", original.ID).Update("attempt", nil).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := failedPrompt(t, ctx, pb, original, 2)
+
+	attempts, err := repository.NewPromptRepository(ctx, pb.service).ListAttempts(ctx, original.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 2 || attempts[1].ID != second.ID {
+		t.Fatalf("ListAttempts() = %v, want the unnumbered original first", attempts)
+	}
+
+	status, err := pb.ResendPrompt(ctx, original.ID)
+	if err != nil {
+		t.Fatalf("ResendPrompt() error = %v", err)
+	}
+	third, err := repository.NewPromptRepository(ctx, pb.service).GetByID(ctx, status.GetId())
+	if err != nil || third.Attempt != 3 {
+		t.Errorf("resend = %v, %v, want attempt 3", third, err)
+	}
+}
+
+func TestResendPromptConcurrently(t *testing.T) {
+	ctx, pb, recorder := newPromptBusiness(t, 3, 0)
+	original := failedPrompt(t, ctx, pb, nil, 1)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	resent := 0
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := pb.ResendPrompt(ctx, original.ID); err == nil {
+				mu.Lock()
+				resent++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if resent != 1 || len(recorder.sent) != 1 {
+		t.Errorf("%d concurrent resends succeeded and %d were sent, want exactly one", resent, len(recorder.sent))
+	}
+}
+
+func TestCheckPromptRate(t *testing.T) {
+	ctx, pb, _ := newPromptBusiness(t, 3, 3)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed, limited := 0, 0
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := pb.checkPromptRate(ctx, "254712345678")
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				allowed++
+			case errors.Is(err, ErrPromptRateLimited):
+				limited++
+			default:
+				t.Errorf("checkPromptRate() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 3 || limited != 7 {
+		t.Errorf("burst of prompts allowed %d and limited %d, want 3 and 7", allowed, limited)
+	}
+	if err := pb.checkPromptRate(ctx, "254722000000"); err != nil {
+		t.Errorf("checkPromptRate() on another number error = %v, want it allowed", err)
+	}
+}
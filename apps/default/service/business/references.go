@@ -0,0 +1,43 @@
+package business
+
+import (
+	"context"
+	"errors"
+
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/repository"
+	"gorm.io/gorm"
+)
+
+// reserveTransactionRef issues the reference an entity is sent to its provider under. The
+// reference is taken from the sequence of the counter serving the route, written in the
+// format that counter's provider accepts, and recorded against the entity.
+func (pb *paymentBusiness) reserveTransactionRef(ctx context.Context, routeID, entityType, entityID string) (string, error) {
+	counterID, err := pb.providers.CounterForRoute(ctx, pb.service, routeID)
+	if err != nil {
+		return "", err
+	}
+
+	format := pb.references.For(counterID)
+	reserved, err := repository.NewReferenceRepository(ctx, pb.service).
+		Reserve(ctx, counterID, format.String(), format.Encode, entityType, entityID)
+	if err != nil {
+		return "", err
+	}
+	return reserved.Reference, nil
+}
+
+// LookupReference returns the entity a reference was issued to on a counter.
+func (pb *paymentBusiness) LookupReference(
+	ctx context.Context,
+	counterID, ref string,
+) (*models.TransactionReference, error) {
+	reserved, err := repository.NewReferenceRepository(ctx, pb.service).GetByReference(ctx, counterID, ref)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReferenceDoesNotExist
+		}
+		return nil, err
+	}
+	return reserved, nil
+}
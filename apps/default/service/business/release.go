@@ -0,0 +1,34 @@
+package business
+
+import (
+	"context"
+	"strings"
+
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/pitabwire/frame"
+)
+
+// subjectFromContext returns the profile of the authenticated caller.
+func subjectFromContext(ctx context.Context) string {
+	if claims := frame.ClaimsFromContext(ctx); claims != nil {
+		return claims.Subject
+	}
+	return ""
+}
+
+// checkReleaser applies maker-checker to releases: a payment above the release threshold of
+// its currency must be released by someone other than the caller who sent it.
+func (pb *paymentBusiness) checkReleaser(ctx context.Context, p *models.Payment) error {
+	threshold, ok := pb.releaseThresholds[strings.ToUpper(p.Currency)]
+	if !ok || !p.Amount.Valid || p.Amount.Decimal.LessThanOrEqual(threshold) {
+		return nil
+	}
+
+	releaser := subjectFromContext(ctx)
+	if releaser == "" || releaser == p.InitiatedBy {
+		pb.service.Log(ctx).WithField("paymentId", p.GetID()).WithField("threshold", threshold.String()).
+			Info("payment above the release threshold needs a checker")
+		return ErrReleaseRequiresChecker
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/service/events"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/repository"
+	"github.com/antinvestor/service-payments/service/screening"
+	"gorm.io/gorm"
+)
+
+// ListHeldPayments returns the screenings of the caller's partition waiting on a reviewer.
+func (pb *paymentBusiness) ListHeldPayments(ctx context.Context) ([]*models.Screening, error) {
+	return repository.NewScreeningRepository(ctx, pb.service).ListHeld(ctx)
+}
+
+// GetPaymentScreening returns the screening of a released outbound payment.
+func (pb *paymentBusiness) GetPaymentScreening(ctx context.Context, paymentID string) (*models.Screening, error) {
+	screened, err := repository.NewScreeningRepository(ctx, pb.service).GetByPaymentID(ctx, paymentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrScreeningDoesNotExist
+		}
+		return nil, err
+	}
+	return screened, nil
+}
+
+// holdForReview puts a payment in the review queue for a reason, without holding up the money
+// that already arrived for it.
+func (pb *paymentBusiness) holdForReview(ctx context.Context, p *models.Payment, reason string) error {
+	held := &models.Screening{
+		PaymentID: p.GetID(),
+		Decision:  screening.Review,
+		Reasons:   []string{reason},
+		State:     models.ScreeningHeld,
+	}
+	held.GenID(ctx)
+	held.TenantID, held.PartitionID = p.TenantID, p.PartitionID
+	_, _, err := repository.NewScreeningRepository(ctx, pb.service).Record(ctx, held)
+	return err
+}
+
+// ClearHeldPayment lets a payment screening held go out to its provider. Inbound payments
+// held for review are only marked as reviewed.
+func (pb *paymentBusiness) ClearHeldPayment(
+	ctx context.Context,
+	paymentID string,
+	req *models.ScreeningReviewRequest,
+) (*models.Screening, error) {
+	p, screened, err := pb.reviewHeldPayment(ctx, paymentID, models.ScreeningCleared, req.Comment)
+	if err != nil {
+		return nil, err
+	}
+	if !p.OutBound {
+		return screened, nil
+	}
+	if err = events.RouteScreenedPayment(ctx, pb.service, paymentID); err != nil {
+		return nil, err
+	}
+	return screened, nil
+}
+
+// RejectHeldPayment fails a payment screening held, it never goes out.
+func (pb *paymentBusiness) RejectHeldPayment(
+	ctx context.Context,
+	paymentID string,
+	req *models.ScreeningReviewRequest,
+) (*models.Screening, error) {
+	_, screened, err := pb.reviewHeldPayment(ctx, paymentID, models.ScreeningRejected, req.Comment)
+	if err != nil {
+		return nil, err
+	}
+	err = events.EmitScreeningStatus(ctx, pb.service, screened, commonv1.STATE_INACTIVE, commonv1.STATUS_FAILED)
+	if err != nil {
+		return nil, err
+	}
+	return screened, nil
+}
+
+func (pb *paymentBusiness) reviewHeldPayment(
+	ctx context.Context,
+	paymentID, state, comment string,
+) (*models.Payment, *models.Screening, error) {
+	logger := pb.service.Log(ctx).WithField("paymentId", paymentID).WithField("state", state)
+
+	p, err := repository.NewPaymentRepository(ctx, pb.service).GetByID(ctx, paymentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrPaymentDoesNotExist
+		}
+		return nil, nil, err
+	}
+
+	reviewer := subjectFromContext(ctx)
+	if reviewer != "" && (reviewer == p.InitiatedBy || reviewer == p.ReleasedBy) {
+		return nil, nil, ErrReviewerIsSender
+	}
+
+	screened, err := repository.NewScreeningRepository(ctx, pb.service).Review(ctx, paymentID,
+		func(screened *models.Screening) error {
+			if screened.State != models.ScreeningHeld {
+				return ErrScreeningNotHeld
+			}
+			now := time.Now()
+			screened.State = state
+			screened.ReviewedBy = reviewer
+			screened.ReviewedAt = &now
+			screened.Comment = strings.TrimSpace(comment)
+			return nil
+		})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrScreeningDoesNotExist
+		}
+		logger.WithError(err).Info("screening review was not recorded")
+		return nil, nil, err
+	}
+	logger.Info("screening review recorded")
+	return p, screened, nil
+}
@@ -0,0 +1,98 @@
+package business
+
+import (
+	"errors"
+	"testing"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/service/events"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/repository"
+	"github.com/antinvestor/service-payments/service/repository/repositorytest"
+	"github.com/antinvestor/service-payments/service/screening"
+)
+
+func TestReviewHeldPayments(t *testing.T) {
+	ctx, service := repositorytest.NewService(t, nil)
+	routed := repositorytest.NewRecorder((&events.PaymentOutRoute{}).Name(), func() any { return new(string) })
+	statuses := repositorytest.NewRecorder((&events.StatusSave{}).Name(), func() any { return &models.Status{} })
+	repositorytest.Start(t, ctx, service, routed, statuses)
+
+	pb, err := NewPaymentBusiness(ctx, service, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payments := repository.NewPaymentRepository(ctx, service)
+	screenings := repository.NewScreeningRepository(ctx, service)
+	held := func(outBound bool, initiatedBy string) *models.Payment {
+		payment := &models.Payment{OutBound: outBound, InitiatedBy: initiatedBy, ReleasedBy: "checker"}
+		payment.GenID(ctx)
+		if err = payments.Save(ctx, payment); err != nil {
+			t.Fatal(err)
+		}
+		screened := &models.Screening{PaymentID: payment.GetID(), Decision: screening.Review, State: models.ScreeningHeld}
+		screened.GenID(ctx)
+		if _, _, err = screenings.Record(ctx, screened); err != nil {
+			t.Fatal(err)
+		}
+		return payment
+	}
+	cleared := held(true, "maker")
+	rejected := held(true, "maker")
+	inbound := held(false, "")
+	ownPayment := held(true, repositorytest.ProfileID)
+
+	queue, err := pb.ListHeldPayments(ctx)
+	if err != nil || len(queue) != 4 {
+		t.Fatalf("ListHeldPayments() = %d screenings, %v, want the 4 held", len(queue), err)
+	}
+
+	review := &models.ScreeningReviewRequest{Comment: "  known customer "}
+	screened, err := pb.ClearHeldPayment(ctx, cleared.GetID(), review)
+	if err != nil {
+		t.Fatalf("ClearHeldPayment() error = %v", err)
+	}
+	if screened.State != models.ScreeningCleared || screened.ReviewedBy != repositorytest.ProfileID ||
+		screened.ReviewedAt == nil || screened.Comment != "known customer" {
+		t.Errorf("ClearHeldPayment() = %+v, want it cleared by the reviewer", screened)
+	}
+	if id := routed.Next(t).(*string); *id != cleared.GetID() {
+		t.Errorf("routed %s, want the cleared payment %s", *id, cleared.GetID())
+	}
+
+	if _, err = pb.RejectHeldPayment(ctx, rejected.GetID(), &models.ScreeningReviewRequest{}); err != nil {
+		t.Fatalf("RejectHeldPayment() error = %v", err)
+	}
+	status := statuses.Next(t).(*models.Status)
+	if status.EntityID != rejected.GetID() || status.Status != int32(commonv1.STATUS_FAILED) {
+		t.Errorf("status = %s %d, want the rejected payment failed", status.EntityID, status.Status)
+	}
+
+	// Inbound payments already arrived, clearing them only records the review
+	if _, err = pb.ClearHeldPayment(ctx, inbound.GetID(), &models.ScreeningReviewRequest{}); err != nil {
+		t.Fatalf("ClearHeldPayment(inbound) error = %v", err)
+	}
+	routed.None(t)
+
+	for _, tt := range []struct {
+		name      string
+		paymentID string
+		wantErr   error
+	}{
+		{name: "already reviewed", paymentID: cleared.GetID(), wantErr: ErrScreeningNotHeld},
+		{name: "reviewer sent the payment", paymentID: ownPayment.GetID(), wantErr: ErrReviewerIsSender},
+		{name: "unknown payment", paymentID: "unknown", wantErr: ErrPaymentDoesNotExist},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, reviewErr := pb.RejectHeldPayment(ctx, tt.paymentID, &models.ScreeningReviewRequest{}); !errors.Is(
+				reviewErr, tt.wantErr) {
+				t.Errorf("RejectHeldPayment() error = %v, want %v", reviewErr, tt.wantErr)
+			}
+		})
+	}
+
+	if queue, err = pb.ListHeldPayments(ctx); err != nil || len(queue) != 1 || queue[0].PaymentID != ownPayment.GetID() {
+		t.Errorf("ListHeldPayments() = %v, %v, want only the payment left for another reviewer", queue, err)
+	}
+}
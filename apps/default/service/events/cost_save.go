@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/tenancy"
 	"gorm.io/gorm/clause"
 
 	"github.com/pitabwire/frame"
@@ -49,7 +50,7 @@ func (e *CostSave) Execute(ctx context.Context, payload any) error {
 	logger := e.Service.Log(ctx).WithField("payload", cost).WithField("type", e.Name())
 	logger.Debug("handling event")
 
-	result := e.Service.DB(ctx, false).Clauses(clause.OnConflict{
+	result := tenancy.DB(ctx, e.Service, false).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "id"}},
 		UpdateAll: true,
 	}).Create(cost)
@@ -13,20 +13,6 @@ import (
 	"github.com/pitabwire/frame"
 )
 
-// filterContactFromProfileByID finds a contact by ID in a profile
-// Currently unused but may be needed for future functionality
-/*
-func filterContactFromProfileByID(profile *profileV1.ProfileObject, contactID string) *profileV1.ContactObject {
-	for _, contact := range profile.GetContacts() {
-		if contact.GetId() == contactID {
-			return contact
-		}
-	}
-
-	return nil
-}
-*/
-
 type PaymentInRoute struct {
 	Service *frame.Service
 }
@@ -6,6 +6,7 @@ import (
 
 	profileV1 "github.com/antinvestor/apis/go/profile/v1"
 	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/tenancy"
 	"gorm.io/gorm/clause"
 
 	"github.com/pitabwire/frame"
@@ -67,7 +68,7 @@ func (e *PaymentLinkSave) Execute(ctx context.Context, payload any) error {
 	logger.Debug("handling event")
 
 	// Attempt to save to database
-	result := e.Service.DB(ctx, false).Clauses(clause.OnConflict{
+	result := tenancy.DB(ctx, e.Service, false).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "id"}},
 		UpdateAll: true,
 	}).Create(paymentLink)
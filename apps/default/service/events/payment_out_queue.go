@@ -3,18 +3,20 @@ package events
 import (
 	"context"
 	"errors"
-	"time"
 
 	commonv1 "github.com/antinvestor/apis/go/common/v1"
 	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/provider"
 	"github.com/antinvestor/service-payments/service/repository"
-	"google.golang.org/protobuf/proto"
 
 	"github.com/pitabwire/frame"
 )
 
+// PaymentOutQueue pays out a routed payment through the provider serving its route, payments
+// paid by an integration are paid out through that integration's provider.
 type PaymentOutQueue struct {
-	Service *frame.Service
+	Service   *frame.Service
+	Providers *provider.Registry
 }
 
 func (event *PaymentOutQueue) Name() string {
@@ -53,34 +55,32 @@ func (event *PaymentOutQueue) Execute(ctx context.Context, payload any) error {
 		return err
 	}
 
-	// Fetch payment status
-	statusRepo := repository.NewStatusRepository(ctx, event.Service)
-	status, err := statusRepo.GetByEntity(ctx, payment.ID, "payment")
-	if err != nil {
-		logger.WithError(err).WithField("status_id", payment.ID).Warn("could not get payment status")
-		return err
-	}
-
-	apiPayment := payment.ToAPI(status, nil)
-
-	// Set the payment release date
-	if payment.IsReleased() {
-		apiPayment.Extra["ReleaseDate"] = payment.ReleasedAt.Format(time.RFC3339)
+	// Hand the payment to the integration paying it or the provider serving its route
+	var psp provider.Provider
+	if payment.PaidByIntegration() {
+		paidBy, _ := payment.Extra[models.ExtraPaidBy].(string)
+		psp, err = event.Providers.Named(paidBy)
+	} else {
+		psp, err = event.Providers.ForRoute(ctx, event.Service, payment.RouteID)
 	}
-	binaryProto, err := proto.Marshal(apiPayment)
-	if err != nil {
-		return err
+	if err == nil {
+		err = psp.Payout(ctx, payment)
 	}
-
-	// Publish the payment message for further processing
-	err = event.Service.Publish(ctx, payment.RouteID, binaryProto)
 	if err != nil {
+		if errors.Is(err, provider.ErrUnknownRoute) || errors.Is(err, provider.ErrUnknownProvider) ||
+			errors.Is(err, provider.ErrNotSupported) {
+			logger.WithError(err).WithField("route", payment.RouteID).Warn("payment can not be paid out")
+			return event.emitStatus(ctx, payment, commonv1.STATUS_FAILED, map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
 		return err
 	}
 
 	logger.WithField("payment_id", payment.GetID()).
 		WithField("route", payment.RouteID).
-		Debug("Payment message successfully queued")
+		WithField("provider", psp.Name()).
+		Debug("Payment successfully handed to provider")
 
 	// Save payment status
 	err = paymentRepo.Save(ctx, payment)
@@ -88,22 +88,26 @@ func (event *PaymentOutQueue) Execute(ctx context.Context, payload any) error {
 		return err
 	}
 
-	// Update payment status using unified Status
-	status = &models.Status{
+	return event.emitStatus(ctx, payment, commonv1.STATUS_IN_PROCESS, map[string]interface{}{
+		"provider": psp.Name(),
+	})
+}
+
+func (event *PaymentOutQueue) emitStatus(
+	ctx context.Context,
+	payment *models.Payment,
+	paymentStatus commonv1.STATUS,
+	extra map[string]interface{},
+) error {
+	status := &models.Status{
 		EntityID:   payment.GetID(),
 		EntityType: "payment",
 		State:      int32(commonv1.STATE_ACTIVE),
-		Status:     int32(commonv1.STATUS_IN_PROCESS),
-		Extra:      make(map[string]interface{}),
+		Status:     int32(paymentStatus),
+		Extra:      extra,
 	}
 	status.GenID(ctx)
 
-	// Emit status event
 	statusEvent := StatusSave{Service: event.Service}
-	err = event.Service.Emit(ctx, statusEvent.Name(), status)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return event.Service.Emit(ctx, statusEvent.Name(), status)
 }
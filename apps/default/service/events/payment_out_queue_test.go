@@ -0,0 +1,67 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/provider"
+	"github.com/antinvestor/service-payments/service/repository"
+	"github.com/antinvestor/service-payments/service/repository/repositorytest"
+	"gorm.io/datatypes"
+)
+
+// payoutRecorder is a provider that records the payments it is asked to pay out.
+type payoutRecorder struct {
+	provider.Provider
+
+	paid []string
+}
+
+func (p *payoutRecorder) Name() string {
+	return "recorder"
+}
+
+func (p *payoutRecorder) Payout(_ context.Context, payment *models.Payment) error {
+	p.paid = append(p.paid, payment.GetID())
+	return nil
+}
+
+func TestPaymentOutQueuePaysThroughRouteProvider(t *testing.T) {
+	ctx, service := repositorytest.NewService(t, nil)
+	repositorytest.Start(t, ctx, service, &StatusSave{Service: service})
+
+	route := &models.Route{CounterID: "jenga", Name: "jenga", Mode: "transact"}
+	route.GenID(ctx)
+	if err := repository.NewRouteRepository(ctx, service).Save(ctx, route); err != nil {
+		t.Fatal(err)
+	}
+
+	routed := &models.Payment{OutBound: true, RouteID: route.GetID()}
+	unrouted := &models.Payment{OutBound: true, RouteID: "missing-route"}
+	paidBy := &models.Payment{OutBound: true, Extra: datatypes.JSONMap{models.ExtraPaidBy: "recorder"}}
+	payments := repository.NewPaymentRepository(ctx, service)
+	for _, payment := range []*models.Payment{routed, unrouted, paidBy} {
+		payment.GenID(ctx)
+		if err := payments.Save(ctx, payment); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recorder := &payoutRecorder{}
+	providers := provider.NewRegistry()
+	providers.Register("jenga", recorder)
+	event := &PaymentOutQueue{Service: service, Providers: providers}
+
+	for _, payment := range []*models.Payment{routed, unrouted, paidBy} {
+		id := payment.GetID()
+		if err := event.Execute(ctx, &id); err != nil {
+			t.Fatalf("Execute(%s) error = %v", id, err)
+		}
+	}
+
+	want := []string{routed.GetID(), paidBy.GetID()}
+	if len(recorder.paid) != len(want) || recorder.paid[0] != want[0] || recorder.paid[1] != want[1] {
+		t.Errorf("paid out %v, want the routed payment and the one its integration pays %v", recorder.paid, want)
+	}
+}
@@ -57,36 +57,41 @@ func (event *PaymentOutRoute) Execute(ctx context.Context, payload any) error {
 		return err
 	}
 
-	route, err := routePayment(ctx, event.Service, models.RouteModeTransmit, p)
-	if err != nil {
-		logger.WithError(err).Error("could not route payment")
-
-		if strings.Contains(err.Error(), "no routes matched for payment") {
-			status := models.Status{
-				EntityID:   p.GetID(),
-				EntityType: "payment",
-				State:      int32(commonv1.STATE_INACTIVE),
-				Status:     int32(commonv1.STATUS_FAILED),
-				Extra:      frame.DBPropertiesFromMap(map[string]string{"error": err.Error()}),
-			}
-			status.GenID(ctx)
-			statusEvent := StatusSave{Service: event.Service}
-			err = event.Service.Emit(ctx, statusEvent.Name(), &status)
-			if err != nil {
-				logger.WithError(err).Warn("could not emit status for save")
-				return err
+	if p.PaidByIntegration() {
+		// The integration that took the payment pays it, there is no route to pick
+		logger.WithField("paidBy", p.Extra[models.ExtraPaidBy]).Debug("payment is paid by its integration")
+	} else {
+		route, err := routePayment(ctx, event.Service, models.RouteModeTransmit, p)
+		if err != nil {
+			logger.WithError(err).Error("could not route payment")
+
+			if strings.Contains(err.Error(), "no routes matched for payment") {
+				status := models.Status{
+					EntityID:   p.GetID(),
+					EntityType: "payment",
+					State:      int32(commonv1.STATE_INACTIVE),
+					Status:     int32(commonv1.STATUS_FAILED),
+					Extra:      frame.DBPropertiesFromMap(map[string]string{"error": err.Error()}),
+				}
+				status.GenID(ctx)
+				statusEvent := StatusSave{Service: event.Service}
+				err = event.Service.Emit(ctx, statusEvent.Name(), &status)
+				if err != nil {
+					logger.WithError(err).Warn("could not emit status for save")
+					return err
+				}
+				return nil
 			}
-			return nil
-		}
 
-		return err
-	}
+			return err
+		}
 
-	p.RouteID = route.ID
-	err = paymentRepo.Save(ctx, p)
-	if err != nil {
-		logger.WithError(err).Warn("could not save routed payment to db")
-		return err
+		p.RouteID = route.ID
+		err = paymentRepo.Save(ctx, p)
+		if err != nil {
+			logger.WithError(err).Warn("could not save routed payment to db")
+			return err
+		}
 	}
 
 	evt := PaymentOutQueue{}
@@ -6,6 +6,7 @@ import (
 
 	commonv1 "github.com/antinvestor/apis/go/common/v1"
 	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/tenancy"
 	"gorm.io/gorm/clause"
 
 	"github.com/pitabwire/frame"
@@ -45,7 +46,7 @@ func (event *PaymentSave) Execute(ctx context.Context, payload any) error {
 	logger := event.Service.Log(ctx).WithField("type", event.Name())
 	logger.WithField("payload", payment).Debug("handling event")
 
-	result := event.Service.DB(ctx, false).Clauses(clause.OnConflict{
+	result := tenancy.DB(ctx, event.Service, false).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "id"}},
 		UpdateAll: true,
 	}).Create(payment)
@@ -70,11 +71,11 @@ func (event *PaymentSave) Execute(ctx context.Context, payload any) error {
 	}
 
 	if payment.IsReleased() {
-		// Use the parent event's Service field instead of creating a new uninitialized event
-		outRouteEvent := PaymentOutRoute{Service: event.Service}
-		err = event.Service.Emit(ctx, outRouteEvent.Name(), payment.GetID())
+		// Released payments are screened before they are routed out
+		screenEvent := PaymentScreen{Service: event.Service}
+		err = event.Service.Emit(ctx, screenEvent.Name(), payment.GetID())
 		if err != nil {
-			logger.WithError(err).Warn("could not emit for queue out")
+			logger.WithError(err).Warn("could not emit for screening")
 			return err
 		}
 	} else {
@@ -0,0 +1,165 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/repository"
+	"github.com/antinvestor/service-payments/service/screening"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"github.com/pitabwire/frame"
+)
+
+// PaymentScreen screens a released outbound payment before it is routed out. Payments the
+// screener approves are routed, held ones wait in the review queue and rejected ones fail.
+type PaymentScreen struct {
+	Service  *frame.Service
+	Screener screening.Screener
+}
+
+func (event *PaymentScreen) Name() string {
+	return "payment.screen"
+}
+
+func (event *PaymentScreen) PayloadType() any {
+	pType := ""
+	return &pType
+}
+
+func (event *PaymentScreen) Validate(_ context.Context, payload any) error {
+	if _, ok := payload.(*string); !ok {
+		return errors.New(" payload is not of type string")
+	}
+
+	return nil
+}
+
+func (event *PaymentScreen) Execute(ctx context.Context, payload any) error {
+	paymentPtr, ok := payload.(*string)
+	if !ok {
+		return errors.New("payload is not of type *string")
+	}
+	if paymentPtr == nil {
+		return errors.New("payload is nil")
+	}
+	paymentID := *paymentPtr
+
+	logger := event.Service.Log(ctx).WithField("payload", paymentID).WithField("type", event.Name())
+	logger.Debug("handling event")
+
+	p, err := repository.NewPaymentRepository(ctx, event.Service).GetByID(ctx, paymentID)
+	if err != nil {
+		logger.WithError(err).Warn("could not get payment from db")
+		return err
+	}
+
+	screeningRepo := repository.NewScreeningRepository(ctx, event.Service)
+	screened, err := screeningRepo.GetByPaymentID(ctx, p.GetID())
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.WithError(err).Warn("could not get payment screening")
+		return err
+	}
+	recorded := false
+	if screened == nil {
+		result := screening.Result{Decision: screening.Approve}
+		if event.Screener != nil {
+			result, err = event.Screener.Screen(ctx, p)
+			if err != nil {
+				logger.WithError(err).Warn("could not screen payment")
+				return err
+			}
+		}
+
+		screened = &models.Screening{
+			PaymentID: p.GetID(),
+			Decision:  result.Decision,
+			Reasons:   result.Reasons,
+			State:     screeningState(result.Decision),
+		}
+		screened.GenID(ctx)
+		screened.TenantID, screened.PartitionID = p.TenantID, p.PartitionID
+		screened, recorded, err = screeningRepo.Record(ctx, screened)
+		if err != nil {
+			logger.WithError(err).Warn("could not record payment screening")
+			return err
+		}
+	}
+
+	switch screened.State {
+	case models.ScreeningCleared:
+		return RouteScreenedPayment(ctx, event.Service, p.GetID())
+	case models.ScreeningHeld:
+		if !recorded {
+			return nil
+		}
+		logger.WithField("reasons", screened.Reasons).Info("payment held for review")
+		return EmitScreeningStatus(ctx, event.Service, screened,
+			commonv1.STATE_CHECKED, commonv1.STATUS_QUEUED)
+	default:
+		if !recorded {
+			return nil
+		}
+		logger.WithField("reasons", screened.Reasons).Info("payment rejected by screening")
+		return EmitScreeningStatus(ctx, event.Service, screened,
+			commonv1.STATE_INACTIVE, commonv1.STATUS_FAILED)
+	}
+}
+
+func screeningState(decision string) string {
+	switch decision {
+	case screening.Reject:
+		return models.ScreeningRejected
+	case screening.Review:
+		return models.ScreeningHeld
+	default:
+		return models.ScreeningCleared
+	}
+}
+
+// RouteScreenedPayment sends a payment that cleared screening on to be routed out.
+func RouteScreenedPayment(ctx context.Context, service *frame.Service, paymentID string) error {
+	outRouteEvent := PaymentOutRoute{Service: service}
+	if err := service.Emit(ctx, outRouteEvent.Name(), paymentID); err != nil {
+		service.Log(ctx).WithError(err).WithField("paymentId", paymentID).Warn("could not emit for route out")
+		return err
+	}
+	return nil
+}
+
+// EmitScreeningStatus records the state screening left a payment in.
+func EmitScreeningStatus(
+	ctx context.Context,
+	service *frame.Service,
+	screened *models.Screening,
+	state commonv1.STATE,
+	status commonv1.STATUS,
+) error {
+	extra := datatypes.JSONMap{
+		"screening":         screened.State,
+		"screening_reasons": strings.Join(screened.Reasons, "; "),
+	}
+	if screened.ReviewedBy != "" {
+		extra["reviewed_by"] = screened.ReviewedBy
+		extra["comment"] = screened.Comment
+	}
+
+	paymentStatus := models.Status{
+		EntityID:   screened.PaymentID,
+		EntityType: "payment",
+		State:      int32(state.Number()),
+		Status:     int32(status.Number()),
+		Extra:      extra,
+	}
+	paymentStatus.GenID(ctx)
+	statusEvent := StatusSave{Service: service}
+	if err := service.Emit(ctx, statusEvent.Name(), &paymentStatus); err != nil {
+		service.Log(ctx).WithError(err).WithField("paymentId", screened.PaymentID).Warn("could not emit status")
+		return err
+	}
+	return nil
+}
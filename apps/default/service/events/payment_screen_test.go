@@ -0,0 +1,102 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/repository"
+	"github.com/antinvestor/service-payments/service/repository/repositorytest"
+	"github.com/antinvestor/service-payments/service/screening"
+	"gorm.io/datatypes"
+)
+
+// decisionScreener screens payments by the decision recorded for them, counting its screens.
+type decisionScreener struct {
+	decisions map[string]string
+	screened  int
+}
+
+func (s *decisionScreener) Screen(_ context.Context, p *models.Payment) (screening.Result, error) {
+	s.screened++
+	decision := s.decisions[p.GetID()]
+	return screening.Result{Decision: decision, Reasons: []string{decision + " for the test"}}, nil
+}
+
+func TestPaymentScreen(t *testing.T) {
+	ctx, service := repositorytest.NewService(t, nil)
+	routed := repositorytest.NewRecorder((&PaymentOutRoute{}).Name(), func() any { return new(string) })
+	statuses := repositorytest.NewRecorder((&StatusSave{}).Name(), func() any { return &models.Status{} })
+	repositorytest.Start(t, ctx, service, routed, statuses)
+
+	payments := repository.NewPaymentRepository(ctx, service)
+	newPayment := func(extra datatypes.JSONMap) *models.Payment {
+		payment := &models.Payment{OutBound: true, Extra: extra}
+		payment.GenID(ctx)
+		if err := payments.Save(ctx, payment); err != nil {
+			t.Fatal(err)
+		}
+		return payment
+	}
+	approved := newPayment(nil)
+	held := newPayment(nil)
+	rejected := newPayment(nil)
+	paidByJenga := newPayment(datatypes.JSONMap{models.ExtraPaidBy: "jenga"})
+
+	screener := &decisionScreener{decisions: map[string]string{
+		approved.GetID():    screening.Approve,
+		held.GetID():        screening.Review,
+		rejected.GetID():    screening.Reject,
+		paidByJenga.GetID(): screening.Review,
+	}}
+	event := &PaymentScreen{Service: service, Screener: screener}
+	execute := func(p *models.Payment) {
+		t.Helper()
+		id := p.GetID()
+		if err := event.Execute(ctx, &id); err != nil {
+			t.Fatalf("Execute(%s) error = %v", id, err)
+		}
+	}
+
+	execute(approved)
+	if id := routed.Next(t).(*string); *id != approved.GetID() {
+		t.Errorf("routed %s, want the approved payment %s", *id, approved.GetID())
+	}
+
+	for _, tt := range []struct {
+		payment *models.Payment
+		status  commonv1.STATUS
+	}{
+		{payment: held, status: commonv1.STATUS_QUEUED},
+		{payment: rejected, status: commonv1.STATUS_FAILED},
+		// A payment its integration pays is held like any other, it is paid once released
+		{payment: paidByJenga, status: commonv1.STATUS_QUEUED},
+	} {
+		execute(tt.payment)
+		status := statuses.Next(t).(*models.Status)
+		if status.EntityID != tt.payment.GetID() || status.Status != int32(tt.status.Number()) {
+			t.Errorf("status = %s %d, want %s %s", status.EntityID, status.Status, tt.payment.GetID(), tt.status)
+		}
+	}
+
+	// A payment screened before is not screened or reported again
+	execute(held)
+	statuses.None(t)
+	routed.None(t)
+	if screener.screened != 4 {
+		t.Errorf("screened %d times, want each payment screened once", screener.screened)
+	}
+
+	for id, state := range map[string]string{
+		approved.GetID():    models.ScreeningCleared,
+		held.GetID():        models.ScreeningHeld,
+		rejected.GetID():    models.ScreeningRejected,
+		paidByJenga.GetID(): models.ScreeningHeld,
+	} {
+		screened, err := repository.NewScreeningRepository(ctx, service).GetByPaymentID(ctx, id)
+		if err != nil || screened.State != state {
+			t.Errorf("screening of %s = %v, %v, want it %s", id, screened, err, state)
+		}
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
 	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/repository"
+	"github.com/antinvestor/service-payments/service/tenancy"
 	"gorm.io/gorm/clause"
 
 	"github.com/pitabwire/frame"
@@ -42,7 +45,7 @@ func (e *StatusSave) Execute(ctx context.Context, payload any) error {
 	logger := e.Service.Log(ctx).WithField("payload", status).WithField("type", e.Name())
 	logger.Debug("handling event")
 
-	result := e.Service.DB(ctx, false).Clauses(clause.OnConflict{
+	result := tenancy.DB(ctx, e.Service, false).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "id"}},
 		UpdateAll: true,
 	}).Create(status)
@@ -54,5 +57,119 @@ func (e *StatusSave) Execute(ctx context.Context, payload any) error {
 	}
 	logger.WithField("rows affected", result.RowsAffected).Debug("successfully saved record to db")
 
+	if err = e.releaseLimits(ctx, status); err != nil {
+		return err
+	}
+
+	switch status.EntityType {
+	case "payment":
+		return e.updatePayment(ctx, status)
+	case "prompt":
+		return e.updatePrompt(ctx, status)
+	case "payment_link":
+		return e.updatePaymentLink(ctx, status)
+	default:
+		return nil
+	}
+}
+
+// releaseLimits gives back what a payment or prompt counted against the limits once it fails,
+// transactions that did not go through do not use up a limit.
+func (e *StatusSave) releaseLimits(ctx context.Context, status *models.Status) error {
+	if status.Status != int32(commonv1.STATUS_FAILED) {
+		return nil
+	}
+	if status.EntityType != "payment" && status.EntityType != "prompt" {
+		return nil
+	}
+
+	released, err := repository.NewLimitRepository(ctx, e.Service).Release(ctx, status.EntityID)
+	if err != nil {
+		e.Service.Log(ctx).WithError(err).WithField("entityId", status.EntityID).
+			Warn("could not give back what the transaction counted against limits")
+		return err
+	}
+	if released {
+		e.Service.Log(ctx).WithField("entityId", status.EntityID).Debug("gave back limits of failed transaction")
+	}
 	return nil
 }
+
+// updatePayment records the provider's transaction id on the payment once it is reported.
+func (e *StatusSave) updatePayment(ctx context.Context, status *models.Status) error {
+	transactionID, _ := status.Extra["transaction_id"].(string)
+	if transactionID == "" {
+		return nil
+	}
+
+	result := tenancy.DB(ctx, e.Service, false).Model(&models.Payment{}).
+		Where("id = ? AND (transaction_id IS NULL OR transaction_id = '')", status.EntityID).
+		Update("transaction_id", transactionID)
+	if result.Error != nil {
+		e.Service.Log(ctx).WithError(result.Error).WithField("paymentId", status.EntityID).
+			Warn("could not record payment transaction id")
+		return result.Error
+	}
+	return nil
+}
+
+// updatePrompt keeps the prompt's own status in step with its latest status record.
+// Prompts that already reached a final status are not reopened, a resend creates a new attempt.
+func (e *StatusSave) updatePrompt(ctx context.Context, status *models.Status) error {
+	result := tenancy.DB(ctx, e.Service, false).Model(&models.Prompt{}).
+		Where("id = ? AND status NOT IN ?", status.EntityID,
+			[]int32{int32(commonv1.STATUS_FAILED), int32(commonv1.STATUS_SUCCESSFUL)}).
+		Updates(promptUpdates(status))
+	if result.Error != nil {
+		e.Service.Log(ctx).WithError(result.Error).WithField("promptId", status.EntityID).
+			Warn("could not update prompt status")
+		return result.Error
+	}
+	return nil
+}
+
+// promptUpdates collects the prompt columns carried by a status record. Failures the provider
+// gave no reason for are still recorded with a resolution so every final prompt has one.
+func promptUpdates(status *models.Status) map[string]any {
+	updates := map[string]any{"state": status.State, "status": status.Status}
+	resolution, _ := status.Extra["resolution"].(string)
+	if resolution == "" && status.Status == int32(commonv1.STATUS_FAILED) {
+		resolution = models.PromptResolutionFailed
+	}
+	if resolution != "" {
+		updates["resolution"] = resolution
+	}
+	return updates
+}
+
+// updatePaymentLink keeps the link's state in step with its latest status record.
+// Links that were deactivated or deleted stay closed.
+func (e *StatusSave) updatePaymentLink(ctx context.Context, status *models.Status) error {
+	result := tenancy.DB(ctx, e.Service, false).Model(&models.PaymentLink{}).
+		Where("id = ? AND state NOT IN ?", status.EntityID,
+			[]int32{int32(commonv1.STATE_INACTIVE), int32(commonv1.STATE_DELETED)}).
+		Updates(paymentLinkUpdates(status))
+	if result.Error != nil {
+		e.Service.Log(ctx).WithError(result.Error).WithField("paymentLinkId", status.EntityID).
+			Warn("could not update payment link state")
+		return result.Error
+	}
+	return nil
+}
+
+// paymentLinkUpdates collects the link columns carried by a status record, the provider
+// reports the reference and URL it assigned to the link through the status extras.
+func paymentLinkUpdates(status *models.Status) map[string]any {
+	updates := map[string]any{"state": status.State}
+	columns := map[string]string{
+		"payment_link_ref": "payment_link_ref",
+		"payment_link_url": "url",
+		"provider_status":  "provider_status",
+	}
+	for key, column := range columns {
+		if value, ok := status.Extra[key].(string); ok && value != "" {
+			updates[column] = value
+		}
+	}
+	return updates
+}
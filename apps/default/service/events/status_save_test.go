@@ -0,0 +1,88 @@
+package events
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/repository"
+	"github.com/antinvestor/service-payments/service/repository/repositorytest"
+	"gorm.io/datatypes"
+)
+
+func TestPaymentLinkUpdates(t *testing.T) {
+	active := int32(commonv1.STATE_ACTIVE)
+	tests := []struct {
+		name   string
+		status *models.Status
+		want   map[string]any
+	}{
+		{name: "state only", status: &models.Status{State: active},
+			want: map[string]any{"state": active}},
+		{name: "provider details", status: &models.Status{State: active, Extra: datatypes.JSONMap{
+			"payment_link_ref": "PL123",
+			"payment_link_url": "https://pay.example.com/PL123",
+			"provider_status":  "active",
+			"unrelated":        "ignored",
+		}}, want: map[string]any{
+			"state":            active,
+			"payment_link_ref": "PL123",
+			"url":              "https://pay.example.com/PL123",
+			"provider_status":  "active",
+		}},
+		{name: "blank and non text extras skipped", status: &models.Status{State: active, Extra: datatypes.JSONMap{
+			"payment_link_ref": "",
+			"payment_link_url": 42,
+		}}, want: map[string]any{"state": active}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := paymentLinkUpdates(tt.status); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("paymentLinkUpdates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusSaveUpdatesPaymentLink(t *testing.T) {
+	ctx, service := repositorytest.NewService(t, nil)
+	links := repository.NewPaymentLinkRepository(ctx, service)
+
+	open := &models.PaymentLink{Name: "open", State: int32(commonv1.STATE_CREATED)}
+	closed := &models.PaymentLink{Name: "closed", State: int32(commonv1.STATE_INACTIVE)}
+	for _, link := range []*models.PaymentLink{open, closed} {
+		link.GenID(ctx)
+		if err := links.Save(ctx, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkoutURL := "https://pay.example.com/checkout/" + strings.Repeat("x", 300)
+	event := &StatusSave{Service: service}
+	for _, link := range []*models.PaymentLink{open, closed} {
+		status := &models.Status{EntityID: link.GetID(), EntityType: "payment_link",
+			State: int32(commonv1.STATE_ACTIVE), Extra: datatypes.JSONMap{"payment_link_url": checkoutURL}}
+		status.GenID(ctx)
+		if err := event.Execute(ctx, status); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	saved, err := links.GetByID(ctx, open.GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.State != int32(commonv1.STATE_ACTIVE) || saved.URL != checkoutURL {
+		t.Errorf("open link = state %d url %q, want it active with the checkout URL", saved.State, saved.URL)
+	}
+
+	saved, err = links.GetByID(ctx, closed.GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.State != int32(commonv1.STATE_INACTIVE) || saved.URL != "" {
+		t.Errorf("closed link = state %d url %q, want it left closed", saved.State, saved.URL)
+	}
+}
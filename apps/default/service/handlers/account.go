@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/gorilla/mux"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// RegisterAccount registers a merchant collection account in the caller's partition.
+func (ps *PaymentServer) RegisterAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req models.AccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	account, err := paymentBusiness.RegisterAccount(ctx, &req)
+	if err != nil {
+		ps.Service.Log(ctx).WithError(err).Warn("account registration failed")
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, account)
+}
+
+// ListAccounts returns the collection accounts registered in the caller's partition.
+func (ps *PaymentServer) ListAccounts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	accounts, err := paymentBusiness.ListAccounts(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, accounts)
+}
+
+// GetAccount returns a collection account.
+func (ps *PaymentServer) GetAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	account, err := paymentBusiness.GetAccount(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, account)
+}
+
+// UpdateAccount changes the editable details of a collection account.
+func (ps *PaymentServer) UpdateAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req models.AccountUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	account, err := paymentBusiness.UpdateAccount(ctx, mux.Vars(r)["id"], &req)
+	if err != nil {
+		ps.Service.Log(ctx).WithError(err).Warn("account update failed")
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, account)
+}
+
+// DeactivateAccount stops prompts and payment links from collecting into an account.
+func (ps *PaymentServer) DeactivateAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	account, err := paymentBusiness.DeactivateAccount(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		ps.Service.Log(ctx).WithError(err).Warn("account deactivation failed")
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, account)
+}
+
+// CreateAccountPaymentLink creates a payment link collecting into a registered account, the
+// body is a CreatePaymentLinkRequest in its JSON form.
+func (ps *PaymentServer) CreateAccountPaymentLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var req paymentV1.CreatePaymentLinkRequest
+	if err = protojson.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	status, err := paymentBusiness.CreateAccountPaymentLink(ctx, mux.Vars(r)["id"], &req)
+	if err != nil {
+		ps.Service.Log(ctx).WithError(err).Warn("account payment link creation failed")
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, status)
+}
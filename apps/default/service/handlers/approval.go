@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/gorilla/mux"
+)
+
+// CreateApprovalPolicy adds an approval policy to the caller's partition.
+func (ps *PaymentServer) CreateApprovalPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req models.ApprovalPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	policy, err := paymentBusiness.CreateApprovalPolicy(ctx, &req)
+	if err != nil {
+		ps.Service.Log(ctx).WithError(err).Warn("approval policy creation failed")
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, policy)
+}
+
+// ListApprovalPolicies returns the approval policies in force in the caller's partition.
+func (ps *PaymentServer) ListApprovalPolicies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	policies, err := paymentBusiness.ListApprovalPolicies(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policies)
+}
+
+// DeactivateApprovalPolicy stops an approval policy from covering new payments.
+func (ps *PaymentServer) DeactivateApprovalPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	policy, err := paymentBusiness.DeactivateApprovalPolicy(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policy)
+}
+
+// ListPendingApprovals returns the payments of the caller's partition waiting on approvers.
+func (ps *PaymentServer) ListPendingApprovals(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	approvals, err := paymentBusiness.ListPendingApprovals(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, approvals)
+}
+
+// GetPaymentApproval returns the approval of a payment and the decisions taken on it.
+func (ps *PaymentServer) GetPaymentApproval(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	approval, err := paymentBusiness.GetPaymentApproval(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, approval)
+}
+
+// ApprovePayment records the caller's approval of a payment.
+func (ps *PaymentServer) ApprovePayment(w http.ResponseWriter, r *http.Request) {
+	ps.decidePayment(w, r, true)
+}
+
+// RejectPayment records the caller's rejection of a payment.
+func (ps *PaymentServer) RejectPayment(w http.ResponseWriter, r *http.Request) {
+	ps.decidePayment(w, r, false)
+}
+
+func (ps *PaymentServer) decidePayment(w http.ResponseWriter, r *http.Request, approve bool) {
+	ctx := r.Context()
+
+	// The comment is optional, an empty body decides without one
+	var req models.ApprovalDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	decide := paymentBusiness.RejectPayment
+	if approve {
+		decide = paymentBusiness.ApprovePayment
+	}
+	approval, err := decide(ctx, mux.Vars(r)["id"], &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, approval)
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/antinvestor/service-payments/service/models"
+)
+
+// ValidateBeneficiary compares the name registered with the provider for an account or
+// wallet against the name on the beneficiary's profile.
+func (ps *PaymentServer) ValidateBeneficiary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req models.BeneficiaryValidationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	result, err := paymentBusiness.ValidateBeneficiary(ctx, &req)
+	if err != nil {
+		ps.Service.Log(ctx).WithError(err).Warn("beneficiary validation failed")
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/antinvestor/service-payments/service/business"
+	"github.com/gorilla/mux"
+)
+
+// maxCallbackBytes bounds the callback bodies providers may post.
+const maxCallbackBytes = 1 << 20
+
+// ReceiveCallback hands a provider callback to the adapter serving the counter it was posted for.
+func (ps *PaymentServer) ReceiveCallback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCallbackBytes))
+	if err != nil {
+		writeError(w, business.ErrInvalidCallback)
+		return
+	}
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	status, err := paymentBusiness.ReceiveCallback(ctx, vars["counter"], vars["kind"], body)
+	if err != nil {
+		ps.Service.Log(ctx).WithError(err).WithField("counter", vars["counter"]).Warn("provider callback failed")
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
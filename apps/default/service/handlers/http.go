@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func writeJSON(w http.ResponseWriter, statusCode int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeError translates business errors, which carry grpc status codes, into http responses.
+func writeError(w http.ResponseWriter, err error) {
+	st, _ := status.FromError(err)
+
+	statusCode := http.StatusInternalServerError
+	switch st.Code() {
+	case codes.InvalidArgument:
+		statusCode = http.StatusBadRequest
+	case codes.NotFound:
+		statusCode = http.StatusNotFound
+	case codes.FailedPrecondition, codes.AlreadyExists:
+		statusCode = http.StatusConflict
+	case codes.PermissionDenied:
+		statusCode = http.StatusForbidden
+	case codes.Unauthenticated:
+		statusCode = http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		statusCode = http.StatusTooManyRequests
+	case codes.Unavailable:
+		statusCode = http.StatusBadGateway
+	default:
+	}
+
+	writeJSON(w, statusCode, map[string]string{"error": st.Message()})
+}
+
+// Authorized lets requests through to the handler once the caller is found to hold the
+// permission. Without an authorizer, when the service runs insecurely, every request passes.
+func (ps *PaymentServer) Authorized(permission string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ps.Authorizer != nil {
+			if err := ps.Authorizer.Authorize(r.Context(), permission); err != nil {
+				writeError(w, err)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
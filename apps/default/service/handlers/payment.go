@@ -7,8 +7,15 @@ import (
 	partitionv1 "github.com/antinvestor/apis/go/partition/v1"
 	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
 	profileV1 "github.com/antinvestor/apis/go/profile/v1"
+	"github.com/antinvestor/service-payments/service/authorization"
 	"github.com/antinvestor/service-payments/service/business"
+	"github.com/antinvestor/service-payments/service/limits"
+	"github.com/antinvestor/service-payments/service/msisdn"
+	"github.com/antinvestor/service-payments/service/provider"
+	"github.com/antinvestor/service-payments/service/reference"
+	"github.com/antinvestor/service-payments/service/utility"
 	ledgerv1 "github.com/antinvestor/apis/go/ledger/v1"
+	"github.com/shopspring/decimal"
 
 	"github.com/pitabwire/frame"
 )
@@ -18,12 +25,23 @@ type PaymentServer struct {
 	ProfileCli   *profileV1.ProfileClient
 	PartitionCli *partitionv1.PartitionClient
 	LedgerCli   *ledgerv1.LedgerClient // Uncomment if LedgerClient is needed
+	Providers    *provider.Registry
+	Numbers      *msisdn.Resolver
+	References   *reference.Formats
+	Authorizer   *authorization.Authorizer
+	Limits       limits.Rules
+
+	// ReleaseThresholds are the amounts per currency above which payments need a checker
+	ReleaseThresholds map[string]decimal.Decimal
+	// Credentials authenticate the requests made to the integration services' HTTP endpoints
+	Credentials *utility.ServiceCredentials
 
 	paymentV1.UnimplementedPaymentServiceServer
 }
 
 func (ps *PaymentServer) newPaymentBusiness(ctx context.Context) (business.PaymentBusiness, error) {
-	return business.NewPaymentBusiness(ctx, ps.Service, ps.ProfileCli, ps.PartitionCli, ps.LedgerCli)
+	return business.NewPaymentBusiness(ctx, ps.Service, ps.ProfileCli, ps.PartitionCli, ps.LedgerCli, ps.Providers,
+		ps.Numbers, ps.References, ps.Authorizer, ps.Limits, ps.ReleaseThresholds, ps.Credentials)
 }
 
 func (ps *PaymentServer) Send(ctx context.Context, req *paymentV1.SendRequest) (*paymentV1.SendResponse, error) {
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/gorilla/mux"
+)
+
+// GetPaymentLink returns a payment link, including the URL customers pay it on.
+func (ps *PaymentServer) GetPaymentLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	link, err := paymentBusiness.GetPaymentLink(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, link)
+}
+
+// UpdatePaymentLink changes the editable details of a payment link.
+func (ps *PaymentServer) UpdatePaymentLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req models.PaymentLinkUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	link, err := paymentBusiness.UpdatePaymentLink(ctx, mux.Vars(r)["id"], &req)
+	if err != nil {
+		ps.Service.Log(ctx).WithError(err).Warn("payment link update failed")
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, link)
+}
+
+// DeactivatePaymentLink stops a payment link from accepting further payments.
+func (ps *PaymentServer) DeactivatePaymentLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	link, err := paymentBusiness.DeactivatePaymentLink(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		ps.Service.Log(ctx).WithError(err).Warn("payment link deactivation failed")
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, link)
+}
@@ -0,0 +1,63 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/service/handlers"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/repository"
+	"github.com/antinvestor/service-payments/service/repository/repositorytest"
+	"github.com/antinvestor/service-payments/service/router"
+)
+
+func TestGetPaymentLink(t *testing.T) {
+	ctx, service := repositorytest.NewService(t, nil)
+
+	link := &models.PaymentLink{
+		Name:           "School fees",
+		State:          int32(commonv1.STATE_ACTIVE),
+		PaymentLinkRef: "PL123",
+		URL:            "https://pay.example.com/PL123",
+		ProviderStatus: "active",
+	}
+	link.GenID(ctx)
+	if err := repository.NewPaymentLinkRepository(ctx, service).Save(ctx, link); err != nil {
+		t.Fatal(err)
+	}
+
+	paymentRouter := router.NewRouter(&handlers.PaymentServer{Service: service})
+	tests := []struct {
+		name       string
+		id         string
+		wantStatus int
+	}{
+		{name: "existing link", id: link.GetID(), wantStatus: http.StatusOK},
+		{name: "unknown link", id: "unknown", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/payment-links/"+tt.id, nil).WithContext(ctx)
+			response := httptest.NewRecorder()
+			paymentRouter.ServeHTTP(response, request)
+
+			if response.Code != tt.wantStatus {
+				t.Fatalf("GET /payment-links/%s = %d, want %d: %s",
+					tt.id, response.Code, tt.wantStatus, response.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got models.PaymentLink
+			if err := json.Unmarshal(response.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.URL != link.URL || got.PaymentLinkRef != link.PaymentLinkRef || got.State != link.State {
+				t.Errorf("GET /payment-links/%s = %+v, want %+v", tt.id, got, link)
+			}
+		})
+	}
+}
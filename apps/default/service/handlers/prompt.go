@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// ResendPrompt sends a failed prompt to the customer again as a new attempt.
+func (ps *PaymentServer) ResendPrompt(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	status, err := paymentBusiness.ResendPrompt(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		ps.Service.Log(ctx).WithError(err).Warn("prompt resend failed")
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, status)
+}
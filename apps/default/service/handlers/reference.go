@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// LookupReference returns the entity a transaction reference was issued to on a counter.
+func (ps *PaymentServer) LookupReference(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	reserved, err := paymentBusiness.LookupReference(ctx, vars["counter"], vars["reference"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reserved)
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/gorilla/mux"
+)
+
+// ListHeldPayments returns the review queue, the screenings of the caller's partition
+// waiting on a reviewer.
+func (ps *PaymentServer) ListHeldPayments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	screenings, err := paymentBusiness.ListHeldPayments(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, screenings)
+}
+
+// GetPaymentScreening returns the screening of a released outbound payment.
+func (ps *PaymentServer) GetPaymentScreening(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	screening, err := paymentBusiness.GetPaymentScreening(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, screening)
+}
+
+// ClearHeldPayment lets a held payment go out to its provider.
+func (ps *PaymentServer) ClearHeldPayment(w http.ResponseWriter, r *http.Request) {
+	ps.reviewHeldPayment(w, r, true)
+}
+
+// RejectHeldPayment fails a held payment.
+func (ps *PaymentServer) RejectHeldPayment(w http.ResponseWriter, r *http.Request) {
+	ps.reviewHeldPayment(w, r, false)
+}
+
+func (ps *PaymentServer) reviewHeldPayment(w http.ResponseWriter, r *http.Request, clear bool) {
+	ctx := r.Context()
+
+	// The comment is optional, an empty body reviews without one
+	var req models.ScreeningReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	paymentBusiness, err := ps.newPaymentBusiness(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	review := paymentBusiness.RejectHeldPayment
+	if clear {
+		review = paymentBusiness.ClearHeldPayment
+	}
+	screening, err := review(ctx, mux.Vars(r)["id"], &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, screening)
+}
@@ -0,0 +1,82 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/antinvestor/service-payments/service/events"
+	"github.com/antinvestor/service-payments/service/handlers"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/repository"
+	"github.com/antinvestor/service-payments/service/repository/repositorytest"
+	"github.com/antinvestor/service-payments/service/router"
+	"github.com/antinvestor/service-payments/service/screening"
+)
+
+func TestScreeningReviews(t *testing.T) {
+	ctx, service := repositorytest.NewService(t, nil)
+	routed := repositorytest.NewRecorder((&events.PaymentOutRoute{}).Name(), func() any { return new(string) })
+	repositorytest.Start(t, ctx, service, routed)
+
+	payment := &models.Payment{OutBound: true, InitiatedBy: "maker"}
+	payment.GenID(ctx)
+	if err := repository.NewPaymentRepository(ctx, service).Save(ctx, payment); err != nil {
+		t.Fatal(err)
+	}
+	screened := &models.Screening{PaymentID: payment.GetID(), Decision: screening.Review, State: models.ScreeningHeld}
+	screened.GenID(ctx)
+	if _, _, err := repository.NewScreeningRepository(ctx, service).Record(ctx, screened); err != nil {
+		t.Fatal(err)
+	}
+
+	paymentRouter := router.NewRouter(&handlers.PaymentServer{Service: service})
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantState  string
+	}{
+		{name: "review queue", method: http.MethodGet, path: "/screenings", wantStatus: http.StatusOK},
+		{name: "screening", method: http.MethodGet, path: "/payments/" + payment.GetID() + "/screening",
+			wantStatus: http.StatusOK, wantState: models.ScreeningHeld},
+		{name: "unscreened payment", method: http.MethodGet, path: "/payments/unknown/screening",
+			wantStatus: http.StatusNotFound},
+		{name: "malformed review", method: http.MethodPost, path: "/payments/" + payment.GetID() + "/screening/clear",
+			body: "{", wantStatus: http.StatusBadRequest},
+		{name: "clear", method: http.MethodPost, path: "/payments/" + payment.GetID() + "/screening/clear",
+			body: `{"comment":"known customer"}`, wantStatus: http.StatusOK, wantState: models.ScreeningCleared},
+		{name: "reject once cleared", method: http.MethodPost,
+			path: "/payments/" + payment.GetID() + "/screening/reject", wantStatus: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)).WithContext(ctx)
+			response := httptest.NewRecorder()
+			paymentRouter.ServeHTTP(response, request)
+
+			if response.Code != tt.wantStatus {
+				t.Fatalf("%s %s = %d, want %d: %s", tt.method, tt.path, response.Code, tt.wantStatus,
+					response.Body.String())
+			}
+			if tt.wantState == "" {
+				return
+			}
+			var got models.Screening
+			if err := json.Unmarshal(response.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.PaymentID != payment.GetID() || got.State != tt.wantState {
+				t.Errorf("%s %s = %+v, want the screening %s", tt.method, tt.path, got, tt.wantState)
+			}
+		})
+	}
+
+	if id := routed.Next(t).(*string); *id != payment.GetID() {
+		t.Errorf("routed %s, want the cleared payment %s", *id, payment.GetID())
+	}
+}
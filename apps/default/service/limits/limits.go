@@ -0,0 +1,248 @@
+// Package limits describes the caps on how much and how often money may move through the
+// service. Rules cap single transactions, or the amount or number of transactions in a window
+// of time, for the profile, contact, phone number, route or partition a transaction belongs
+// to. Windowed rules are counted in fixed buckets, e.g. the calendar day, which lets their
+// counters be kept as single rows.
+package limits
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrInvalidRule is returned for rule specifications that cannot be parsed.
+var ErrInvalidRule = errors.New("invalid limit rule")
+
+// Operations rules apply to, Any covers all of them together.
+const (
+	Send    = "send"
+	Receive = "receive"
+	Prompt  = "prompt"
+	Any     = "*"
+)
+
+// Scopes transactions are counted by.
+const (
+	ScopeProfile   = "profile"
+	ScopeContact   = "contact"
+	ScopeMSISDN    = "msisdn"
+	ScopeRoute     = "route"
+	ScopePartition = "partition"
+)
+
+// Windows rules are counted over, Transaction caps single transactions.
+const (
+	WindowTransaction = "transaction"
+	WindowMinute      = "minute"
+	WindowHour        = "hour"
+	WindowDay         = "day"
+	WindowMonth       = "month"
+)
+
+var (
+	operations = []string{Send, Receive, Prompt, Any}
+	scopes     = []string{ScopeProfile, ScopeContact, ScopeMSISDN, ScopeRoute, ScopePartition}
+	windows    = []string{WindowTransaction, WindowMinute, WindowHour, WindowDay, WindowMonth}
+)
+
+// Rule caps the transactions of an operation in a scope. Amount rules cap the total amount in
+// Currency, count rules, the velocity rules, cap the number of transactions.
+type Rule struct {
+	Operation string
+	Scope     string
+	Window    string
+	Currency  string
+	Amount    decimal.Decimal
+	Count     int64
+}
+
+// IsCount reports whether the rule caps the number of transactions rather than their amount.
+func (r Rule) IsCount() bool {
+	return r.Currency == ""
+}
+
+// String returns the specification the rule was parsed from.
+func (r Rule) String() string {
+	spec := r.Operation + "." + r.Scope + "." + r.Window + "="
+	if r.IsCount() {
+		return spec + strconv.FormatInt(r.Count, 10)
+	}
+	return spec + r.Currency + " " + r.Amount.String()
+}
+
+// ParseRule reads an "operation.scope.window=limit" specification. The limit is either a
+// currency and amount, e.g. "send.profile.day=KES 150000", or a number of transactions, e.g.
+// "prompt.msisdn.minute=3".
+func ParseRule(spec string) (Rule, error) {
+	key, limit, found := strings.Cut(strings.TrimSpace(spec), "=")
+	parts := strings.Split(strings.TrimSpace(key), ".")
+	if !found || len(parts) != 3 {
+		return Rule{}, fmt.Errorf("%w: %q, expected operation.scope.window=limit", ErrInvalidRule, spec)
+	}
+
+	rule := Rule{Operation: parts[0], Scope: parts[1], Window: parts[2]}
+	for _, check := range []struct {
+		value   string
+		allowed []string
+	}{{rule.Operation, operations}, {rule.Scope, scopes}, {rule.Window, windows}} {
+		if !slices.Contains(check.allowed, check.value) {
+			return Rule{}, fmt.Errorf("%w: %q is not one of %s", ErrInvalidRule, check.value,
+				strings.Join(check.allowed, ", "))
+		}
+	}
+
+	fields := strings.Fields(limit)
+	switch len(fields) {
+	case 1:
+		count, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil || count <= 0 {
+			return Rule{}, fmt.Errorf("%w: invalid transaction count %q", ErrInvalidRule, fields[0])
+		}
+		if rule.Window == WindowTransaction {
+			return Rule{}, fmt.Errorf("%w: %q counts transactions one at a time", ErrInvalidRule, spec)
+		}
+		rule.Count = count
+	case 2:
+		amount, err := decimal.NewFromString(fields[1])
+		if len(fields[0]) != 3 || err != nil || !amount.IsPositive() {
+			return Rule{}, fmt.Errorf("%w: invalid amount %q", ErrInvalidRule, limit)
+		}
+		rule.Currency, rule.Amount = strings.ToUpper(fields[0]), amount
+	default:
+		return Rule{}, fmt.Errorf("%w: invalid limit %q", ErrInvalidRule, limit)
+	}
+	return rule, nil
+}
+
+// Rules is a set of limits, a transaction must stay within every rule that applies to it.
+type Rules []Rule
+
+// ParseRules reads a comma separated list of rule specifications.
+func ParseRules(spec string) (Rules, error) {
+	var rules Rules
+	for _, entry := range strings.Split(spec, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		rule, err := ParseRule(entry)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Transaction is what rules are evaluated against.
+type Transaction struct {
+	Operation   string
+	PartitionID string
+	ProfileID   string
+	ContactID   string
+	MSISDN      string
+	RouteID     string
+	Currency    string
+	Amount      decimal.Decimal
+}
+
+func (t Transaction) scopeValue(scope string) string {
+	switch scope {
+	case ScopeProfile:
+		return t.ProfileID
+	case ScopeContact:
+		return t.ContactID
+	case ScopeMSISDN:
+		return t.MSISDN
+	case ScopeRoute:
+		return t.RouteID
+	case ScopePartition:
+		return t.PartitionID
+	default:
+		return ""
+	}
+}
+
+// Check is a windowed rule a transaction is counted against. Counters of the same key are
+// shared by every transaction in the rule's scope and window.
+type Check struct {
+	Rule      Rule
+	Key       string
+	ExpiresAt time.Time
+}
+
+// Evaluate checks a transaction against the rules at a time. It returns the first per
+// transaction rule the transaction breaks, or else the windowed checks it must be counted
+// against.
+func (rules Rules) Evaluate(t Transaction, now time.Time) (*Rule, []Check) {
+	var checks []Check
+	for _, rule := range rules {
+		if rule.Operation != Any && rule.Operation != t.Operation {
+			continue
+		}
+		if !rule.IsCount() && !strings.EqualFold(rule.Currency, t.Currency) {
+			continue
+		}
+		value := t.scopeValue(rule.Scope)
+		if value == "" {
+			continue
+		}
+
+		if !rule.IsCount() && t.Amount.GreaterThan(rule.Amount) {
+			return &rule, nil
+		}
+		if rule.Window == WindowTransaction {
+			continue
+		}
+
+		bucket, expiresAt := window(rule.Window, now)
+		checks = append(checks, Check{
+			Rule: rule,
+			Key: strings.Join([]string{
+				t.PartitionID, rule.Operation, rule.Scope, value, rule.Window, bucket, rule.Currency,
+			}, "|"),
+			ExpiresAt: expiresAt,
+		})
+	}
+	return nil, checks
+}
+
+// Velocity returns the check capping the transactions of an operation in a scope to count per
+// period, for caps configured as a period rather than one of the rule windows. Periods are
+// counted in fixed buckets like the rule windows are.
+func Velocity(t Transaction, scope string, count int64, period time.Duration, now time.Time) Check {
+	start := now.UTC().Truncate(period)
+	rule := Rule{Operation: t.Operation, Scope: scope, Window: period.String(), Count: count}
+	return Check{
+		Rule: rule,
+		Key: strings.Join([]string{
+			t.PartitionID, rule.Operation, rule.Scope, t.scopeValue(scope), rule.Window,
+			start.Format(time.RFC3339), "",
+		}, "|"),
+		ExpiresAt: start.Add(period),
+	}
+}
+
+// window returns the bucket of a window a time falls in and when the bucket ends.
+func window(name string, now time.Time) (string, time.Time) {
+	now = now.UTC()
+	switch name {
+	case WindowMinute:
+		start := now.Truncate(time.Minute)
+		return start.Format("2006-01-02T15:04"), start.Add(time.Minute)
+	case WindowHour:
+		start := now.Truncate(time.Hour)
+		return start.Format("2006-01-02T15"), start.Add(time.Hour)
+	case WindowDay:
+		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		return start.Format("2006-01-02"), start.AddDate(0, 0, 1)
+	default:
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start.Format("2006-01"), start.AddDate(0, 1, 0)
+	}
+}
@@ -0,0 +1,179 @@
+package limits
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    string
+		count   bool
+		wantErr bool
+	}{
+		{name: "amount", spec: "send.profile.day=kes 150000", want: "send.profile.day=KES 150000"},
+		{name: "count", spec: " prompt.msisdn.minute=3 ", want: "prompt.msisdn.minute=3", count: true},
+		{name: "any operation", spec: "*.partition.month=USD 1000.50", want: "*.partition.month=USD 1000.5"},
+		{name: "per transaction", spec: "send.route.transaction=KES 70000", want: "send.route.transaction=KES 70000"},
+		{name: "missing limit", spec: "send.profile.day", wantErr: true},
+		{name: "unknown operation", spec: "refund.profile.day=KES 10", wantErr: true},
+		{name: "unknown scope", spec: "send.device.day=KES 10", wantErr: true},
+		{name: "unknown window", spec: "send.profile.week=KES 10", wantErr: true},
+		{name: "bad currency", spec: "send.profile.day=SHILLING 10", wantErr: true},
+		{name: "negative amount", spec: "send.profile.day=KES -10", wantErr: true},
+		{name: "zero count", spec: "send.profile.day=0", wantErr: true},
+		{name: "count per transaction", spec: "send.profile.transaction=1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRule(tt.spec)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRule) {
+					t.Fatalf("ParseRule(%q) error = %v, want ErrInvalidRule", tt.spec, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRule(%q) error = %v", tt.spec, err)
+			}
+			if rule.String() != tt.want || rule.IsCount() != tt.count {
+				t.Errorf("ParseRule(%q) = %s (count %v), want %s (count %v)",
+					tt.spec, rule, rule.IsCount(), tt.want, tt.count)
+			}
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("send.profile.day=KES 150000, ,prompt.msisdn.minute=3")
+	if err != nil || len(rules) != 2 {
+		t.Fatalf("ParseRules() = %v, %v, want two rules", rules, err)
+	}
+	if _, err = ParseRules("send.profile.day=KES 1,bad"); !errors.Is(err, ErrInvalidRule) {
+		t.Errorf("ParseRules() error = %v, want ErrInvalidRule", err)
+	}
+	if rules, err = ParseRules(""); err != nil || len(rules) != 0 {
+		t.Errorf("ParseRules(\"\") = %v, %v, want no rules", rules, err)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	rules, err := ParseRules("send.route.transaction=KES 70000,send.profile.day=KES 150000," +
+		"*.partition.month=KES 1000000,prompt.msisdn.minute=3,receive.contact.hour=USD 500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, time.March, 31, 23, 59, 30, 0, time.FixedZone("EAT", 3*60*60))
+	send := Transaction{
+		Operation:   Send,
+		PartitionID: "p1",
+		ProfileID:   "profile1",
+		RouteID:     "route1",
+		Currency:    "kes",
+		Amount:      decimal.NewFromInt(1000),
+	}
+
+	tests := []struct {
+		name   string
+		t      Transaction
+		breach string
+		keys   []string
+	}{
+		{
+			name: "windowed",
+			t:    send,
+			keys: []string{
+				"p1|send|profile|profile1|day|2026-03-31|KES",
+				"p1|*|partition|p1|month|2026-03|KES",
+			},
+		},
+		{
+			name: "per transaction breach",
+			t: func() Transaction {
+				over := send
+				over.Amount = decimal.NewFromInt(70001)
+				return over
+			}(),
+			breach: "send.route.transaction=KES 70000",
+		},
+		{
+			name: "velocity",
+			t:    Transaction{Operation: Prompt, PartitionID: "p1", MSISDN: "254700000000", Currency: "USD"},
+			keys: []string{"p1|prompt|msisdn|254700000000|minute|2026-03-31T20:59|"},
+		},
+		{
+			name: "other currency and missing scope",
+			t:    Transaction{Operation: Receive, Currency: "KES", Amount: decimal.NewFromInt(10)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breach, checks := rules.Evaluate(tt.t, now)
+			if tt.breach != "" {
+				if breach == nil || breach.String() != tt.breach {
+					t.Fatalf("Evaluate() breach = %v, want %s", breach, tt.breach)
+				}
+				return
+			}
+			if breach != nil {
+				t.Fatalf("Evaluate() breach = %s, want none", breach)
+			}
+			if len(checks) != len(tt.keys) {
+				t.Fatalf("Evaluate() = %d checks, want %d", len(checks), len(tt.keys))
+			}
+			for i, check := range checks {
+				if check.Key != tt.keys[i] {
+					t.Errorf("check %d key = %s, want %s", i, check.Key, tt.keys[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWindow(t *testing.T) {
+	now := time.Date(2026, time.December, 31, 23, 59, 30, 0, time.UTC)
+	tests := []struct {
+		window  string
+		bucket  string
+		expires time.Time
+	}{
+		{WindowMinute, "2026-12-31T23:59", time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{WindowHour, "2026-12-31T23", time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{WindowDay, "2026-12-31", time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{WindowMonth, "2026-12", time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.window, func(t *testing.T) {
+			bucket, expires := window(tt.window, now)
+			if bucket != tt.bucket || !expires.Equal(tt.expires) {
+				t.Errorf("window(%s) = %s, %s, want %s, %s", tt.window, bucket, expires, tt.bucket, tt.expires)
+			}
+		})
+	}
+}
+
+func TestVelocity(t *testing.T) {
+	tx := Transaction{Operation: Prompt, PartitionID: "partition", MSISDN: "254712345678"}
+	now := time.Date(2026, time.December, 31, 23, 59, 30, 0, time.UTC)
+
+	check := Velocity(tx, ScopeMSISDN, 5, 15*time.Minute, now)
+	if want := "partition|prompt|msisdn|254712345678|15m0s|2026-12-31T23:45:00Z|"; check.Key != want {
+		t.Errorf("Velocity() key = %s, want %s", check.Key, want)
+	}
+	if want := time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC); !check.ExpiresAt.Equal(want) {
+		t.Errorf("Velocity() expires = %s, want %s", check.ExpiresAt, want)
+	}
+	if !check.Rule.IsCount() || check.Rule.Count != 5 {
+		t.Errorf("Velocity() rule = %s, want a count of 5", check.Rule)
+	}
+
+	later := Velocity(tx, ScopeMSISDN, 5, 15*time.Minute, now.Add(time.Minute))
+	if later.Key == check.Key {
+		t.Error("Velocity() kept counting in the previous period")
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+// AccountRequest registers a merchant collection account.
+type AccountRequest struct {
+	AccountNumber string `json:"accountNumber"`
+	CountryCode   string `json:"countryCode"`
+	Name          string `json:"name"`
+	Currency      string `json:"currency"`
+	// CounterID names the provider the account is held with, it is taken from the route's
+	// counter when only a route is given.
+	CounterID string `json:"counterId"`
+	RouteID   string `json:"routeId"`
+}
+
+// AccountUpdateRequest carries the editable details of a registered account, unset fields are
+// left unchanged. Changing the route or currency validates the account with its provider again.
+type AccountUpdateRequest struct {
+	Name     *string `json:"name,omitempty"`
+	Currency *string `json:"currency,omitempty"`
+	RouteID  *string `json:"routeId,omitempty"`
+}
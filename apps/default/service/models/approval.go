@@ -0,0 +1,106 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pitabwire/frame"
+	"github.com/shopspring/decimal"
+	"gorm.io/datatypes"
+)
+
+// States of an approval.
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+)
+
+// Decisions approvers take on an approval.
+const (
+	DecisionApprove = "approve"
+	DecisionReject  = "reject"
+)
+
+// ApprovalPolicy requires outbound payments of a partition above an amount to be approved by
+// a number of people holding one of the allowed roles before they can be released.
+type ApprovalPolicy struct {
+	frame.BaseModel
+	Name string `gorm:"type:varchar(100)" json:"name"`
+	// Currency limits the policy to payments in it, payments in any currency are covered without one
+	Currency          string                      `gorm:"type:varchar(10)" json:"currency"`
+	Threshold         decimal.Decimal             `gorm:"type:numeric"     json:"threshold"`
+	RequiredApprovals int                         `json:"requiredApprovals"`
+	AllowedRoles      datatypes.JSONSlice[string] `json:"allowedRoles"`
+	State             int32                       `json:"state"`
+}
+
+// Covers reports whether the policy applies to an outbound payment.
+func (policy *ApprovalPolicy) Covers(payment *Payment) bool {
+	if !payment.OutBound || !payment.Amount.Valid {
+		return false
+	}
+	if policy.Currency != "" && !strings.EqualFold(policy.Currency, payment.Currency) {
+		return false
+	}
+	return payment.Amount.Decimal.GreaterThan(policy.Threshold)
+}
+
+// Approval tracks the approvals an outbound payment still needs before its release.
+type Approval struct {
+	frame.BaseModel
+	PaymentID         string                      `gorm:"type:varchar(50);uniqueIndex" json:"paymentId"`
+	PolicyID          string                      `gorm:"type:varchar(50)"             json:"policyId"`
+	RequiredApprovals int                         `json:"requiredApprovals"`
+	AllowedRoles      datatypes.JSONSlice[string] `json:"allowedRoles"`
+	Approvals         int                         `json:"approvals"`
+	State             string                      `gorm:"type:varchar(20);index"       json:"state"`
+	DecidedAt         *time.Time                  `json:"decidedAt,omitempty"`
+
+	Decisions []*ApprovalDecision `gorm:"-" json:"decisions,omitempty"`
+}
+
+// IsSatisfied reports whether enough approvers approved the payment.
+func (approval *Approval) IsSatisfied() bool {
+	return approval.State == ApprovalApproved
+}
+
+// AllowsRole reports whether an approver holding the roles may decide on the approval.
+func (approval *Approval) AllowsRole(roles []string) bool {
+	if len(approval.AllowedRoles) == 0 {
+		return true
+	}
+	for _, role := range roles {
+		if slices.ContainsFunc(approval.AllowedRoles, func(allowed string) bool {
+			return strings.EqualFold(allowed, role)
+		}) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApprovalDecision records an approver's decision on an approval, each approver decides once.
+type ApprovalDecision struct {
+	frame.BaseModel
+	ApprovalID string `gorm:"type:varchar(50);uniqueIndex:idx_approval_decision_approver" json:"approvalId"`
+	PaymentID  string `gorm:"type:varchar(50);index"                                      json:"paymentId"`
+	DecidedBy  string `gorm:"type:varchar(50);uniqueIndex:idx_approval_decision_approver" json:"decidedBy"`
+	Decision   string `gorm:"type:varchar(20)"                                            json:"decision"`
+	Comment    string `gorm:"type:text"                                                   json:"comment"`
+}
+
+// ApprovalPolicyRequest creates an approval policy.
+type ApprovalPolicyRequest struct {
+	Name              string          `json:"name"`
+	Currency          string          `json:"currency"`
+	Threshold         decimal.Decimal `json:"threshold"`
+	RequiredApprovals int             `json:"requiredApprovals"`
+	AllowedRoles      []string        `json:"allowedRoles"`
+}
+
+// ApprovalDecisionRequest carries an approver's decision on a payment.
+type ApprovalDecisionRequest struct {
+	Comment string `json:"comment"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/datatypes"
+)
+
+func TestApprovalPolicyCovers(t *testing.T) {
+	policy := &ApprovalPolicy{Currency: "KES", Threshold: decimal.NewFromInt(100000)}
+
+	tests := []struct {
+		name     string
+		outBound bool
+		amount   decimal.NullDecimal
+		currency string
+		want     bool
+	}{
+		{name: "above threshold", outBound: true, amount: decimal.NewNullDecimal(decimal.NewFromInt(100001)),
+			currency: "kes", want: true},
+		{name: "at threshold", outBound: true, amount: decimal.NewNullDecimal(decimal.NewFromInt(100000)),
+			currency: "KES"},
+		{name: "other currency", outBound: true, amount: decimal.NewNullDecimal(decimal.NewFromInt(500000)),
+			currency: "USD"},
+		{name: "inbound", amount: decimal.NewNullDecimal(decimal.NewFromInt(500000)), currency: "KES"},
+		{name: "no amount", outBound: true, currency: "KES"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payment := &Payment{OutBound: tt.outBound, Amount: tt.amount, Currency: tt.currency}
+			if got := policy.Covers(payment); got != tt.want {
+				t.Fatalf("Covers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	anyCurrency := &ApprovalPolicy{Threshold: decimal.Zero}
+	payment := &Payment{OutBound: true, Amount: decimal.NewNullDecimal(decimal.NewFromInt(1)), Currency: "USD"}
+	if !anyCurrency.Covers(payment) {
+		t.Fatalf("policy without a currency does not cover payments in USD")
+	}
+}
+
+func TestApprovalAllowsRole(t *testing.T) {
+	approval := &Approval{AllowedRoles: datatypes.NewJSONSlice([]string{"checker", "Treasury"})}
+	if !approval.AllowsRole([]string{"maker", "treasury"}) {
+		t.Fatalf("AllowsRole() rejected an approver holding an allowed role")
+	}
+	if approval.AllowsRole([]string{"maker"}) {
+		t.Fatalf("AllowsRole() accepted an approver without an allowed role")
+	}
+	if !(&Approval{}).AllowsRole(nil) {
+		t.Fatalf("AllowsRole() rejected an approver of an approval open to any role")
+	}
+}
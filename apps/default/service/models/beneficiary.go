@@ -0,0 +1,29 @@
+package models
+
+const (
+	BeneficiaryLookupAccount = "account"
+	BeneficiaryLookupMobile  = "mobile"
+)
+
+// BeneficiaryValidationRequest asks for the provider registered name of an account or
+// wallet to be compared against the name on the beneficiary's profile.
+type BeneficiaryValidationRequest struct {
+	ProfileID     string  `json:"profile_id"`
+	LookupType    string  `json:"lookup_type"`
+	CountryCode   string  `json:"country_code"`
+	AccountNumber string  `json:"account_number,omitempty"`
+	MobileNumber  string  `json:"mobile_number,omitempty"`
+	Wallet        string  `json:"wallet,omitempty"`
+	Threshold     float64 `json:"threshold,omitempty"`
+}
+
+// BeneficiaryValidation is the outcome of a beneficiary name check.
+type BeneficiaryValidation struct {
+	ProfileID    string  `json:"profile_id"`
+	ProfileName  string  `json:"profile_name"`
+	ProviderName string  `json:"provider_name"`
+	Reference    string  `json:"reference"`
+	Score        float64 `json:"score"`
+	Threshold    float64 `json:"threshold"`
+	Matched      bool    `json:"matched"`
+}
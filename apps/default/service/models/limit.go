@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/datatypes"
+)
+
+// LimitCounter tallies the transactions counted against a limit rule in one window, e.g. the
+// payments a profile sent on a day. Counters are dropped once their window has passed.
+type LimitCounter struct {
+	Key        string `gorm:"type:varchar(255);primaryKey"`
+	Count      int64
+	Amount     decimal.Decimal `gorm:"type:numeric"`
+	ExpiresAt  time.Time       `gorm:"index"`
+	ModifiedAt time.Time
+}
+
+// LimitConsumption remembers the counters a transaction was counted against, so they can be
+// given back when the transaction does not go through.
+type LimitConsumption struct {
+	EntityID  string `gorm:"type:varchar(50);primaryKey"`
+	Keys      datatypes.JSONSlice[string]
+	Amount    decimal.Decimal `gorm:"type:numeric"`
+	ExpiresAt time.Time       `gorm:"index"`
+}
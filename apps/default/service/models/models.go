@@ -2,6 +2,8 @@ package models
 
 import (
 	"encoding/json"
+	"strconv"
+	"strings"
 	"time"
 
 	"maps"
@@ -34,21 +36,43 @@ type Payment struct {
 	RecipientContactID   string `gorm:"type:varchar(50)"`
 
 	Amount        decimal.NullDecimal `gorm:"type:numeric"                          json:"amount"`
-	TransactionID string              `gorm:"type:varchar(50)"`
+	TransactionID string              `gorm:"type:varchar(50);uniqueIndex:idx_payment_inbound_transaction,where:out_bound = false AND transaction_id <> ''"`
 	ReferenceID   string              `gorm:"type:varchar(50)"`
 	BatchID       string              `gorm:"type:varchar(50)"`
 	RouteID       string              `gorm:"type:varchar(50)"`
 	Currency      string              `gorm:"type:varchar(10)"`
-	PaymentType   string              `gorm:"type:varchar(10)"`
+	PaymentType   string              `gorm:"type:varchar(50)"`
 	CostIDs       []string            `gorm:"type:text[]"                           json:"cost_ids"`
 	ReleasedAt    *time.Time
 	OutBound      bool
 	Extra         datatypes.JSONMap `gorm:"index:,type:gin;option:jsonb_path_ops" json:"extra"`
+
+	// InitiatedBy and ReleasedBy are the profiles of the callers who sent and released the payment
+	InitiatedBy string `gorm:"type:varchar(50)"`
+	ReleasedBy  string `gorm:"type:varchar(50)"`
+
+	// Names and mobile numbers of the sender and recipient as the profile service knew them
+	// when the payment was made
+	SenderName      string `gorm:"type:varchar(250)"`
+	SenderMSISDN    string `gorm:"type:varchar(20)"`
+	RecipientName   string `gorm:"type:varchar(250)"`
+	RecipientMSISDN string `gorm:"type:varchar(20)"`
 }
 
 func (model *Payment) IsReleased() bool {
 	return model.ReleasedAt != nil && !model.ReleasedAt.IsZero()
 }
+
+// ExtraPaidBy names the integration that took an outbound payment and pays it. Such payments go
+// through the release gates and screening like any other, then are queued out to the provider
+// of that integration instead of the one their route would pick.
+const ExtraPaidBy = "paid_by"
+
+// PaidByIntegration reports whether the payment names the integration that pays it.
+func (model *Payment) PaidByIntegration() bool {
+	paidBy, _ := model.Extra[ExtraPaidBy].(string)
+	return paidBy != ""
+}
 func (model *Payment) ToAPI(status *Status, message map[string]string) *paymentV1.Payment {
 	extra := make(map[string]string)
 	extra["tenant_id"] = model.TenantID
@@ -62,15 +86,19 @@ func (model *Payment) ToAPI(status *Status, message map[string]string) *paymentV
 	}
 
 	source := &commonv1.ContactLink{
+		ProfileName: model.SenderName,
 		ProfileType: model.SenderProfileType,
 		ProfileId:   model.SenderProfileID,
 		ContactId:   model.SenderContactID,
+		Detail:      model.SenderMSISDN,
 	}
 
 	recipient := &commonv1.ContactLink{
+		ProfileName: model.RecipientName,
 		ProfileType: model.RecipientProfileType,
 		ProfileId:   model.RecipientProfileID,
 		ContactId:   model.RecipientContactID,
+		Detail:      model.RecipientMSISDN,
 	}
 
 	amountMoney := utility.ToMoney(model.Currency, model.Amount.Decimal)
@@ -130,11 +158,28 @@ type Route struct {
 	URI         string `gorm:"type:varchar(255)"`
 }
 
+// Account is an account collections are credited to. Accounts registered through the account
+// API also carry the provider and route they collect on, accounts recorded implicitly for
+// prompts that named a raw account number only have the number, country and name.
 type Account struct {
 	frame.BaseModel
-	AccountNumber string `gorm:"type:varchar(50)"`
-	CountryCode   string `gorm:"type:varchar(50)"`
-	Name          string `gorm:"type:varchar(50)"`
+	// Accounts are registered once per provider in a partition, accounts recorded implicitly for
+	// prompts have no provider and are left out of the index
+	AccountNumber string `gorm:"type:varchar(50);uniqueIndex:idx_account_registration,expression:partition_id\\, counter_id\\, account_number,where:counter_id <> ''" json:"accountNumber"`
+	CountryCode   string `gorm:"type:varchar(50)"                                                                                                                   json:"countryCode"`
+	Name          string `gorm:"type:varchar(50)"                                                                                                                   json:"name"`
+
+	Currency    string     `gorm:"type:varchar(10)" json:"currency"`
+	CounterID   string     `gorm:"type:varchar(50)" json:"counterId"`
+	RouteID     string     `gorm:"type:varchar(50)" json:"routeId"`
+	State       int32      `gorm:"type:integer"     json:"state"`
+	ValidatedAt *time.Time `json:"validatedAt,omitempty"`
+}
+
+// IsRegistered reports whether the account was registered through the account API and is
+// still active, only such accounts can be referenced by ID.
+func (model *Account) IsRegistered() bool {
+	return model.State == int32(commonv1.STATE_ACTIVE)
 }
 
 type Prompt struct {
@@ -156,6 +201,33 @@ type Prompt struct {
 	AccountID            string              `gorm:"type:varchar(50)"`
 	Account              Account             `gorm:"foreignKey:AccountID;references:ID"`
 	Extra                datatypes.JSONMap   `gorm:"index:,type:gin;option:jsonb_path_ops" json:"extra"`
+
+	// OriginalPromptID links a resent prompt to the first attempt, it is empty on the original.
+	OriginalPromptID string `gorm:"type:varchar(50);index;uniqueIndex:idx_prompt_attempt,where:original_prompt_id <> ''"`
+	// Attempt counts the sends of the original prompt, starting at 1. A resend's attempt is
+	// unique among the resends of its original prompt.
+	Attempt int `gorm:"type:integer;uniqueIndex:idx_prompt_attempt,where:original_prompt_id <> ''"`
+	// Resolution records why the prompt reached its final status, e.g. PromptResolutionTimeout.
+	Resolution string `gorm:"type:varchar(50)"`
+}
+
+// Resolutions a prompt can reach its final status with.
+const (
+	PromptResolutionPaid              = "paid"
+	PromptResolutionCancelled         = "cancelled"
+	PromptResolutionTimeout           = "timeout"
+	PromptResolutionInsufficientFunds = "insufficient_funds"
+	// PromptResolutionFailed is recorded for prompts that failed without a reason, typically
+	// because the provider rejected the push.
+	PromptResolutionFailed = "failed"
+)
+
+// RootID returns the ID of the original prompt the attempts of this prompt are linked to.
+func (model *Prompt) RootID() string {
+	if model.OriginalPromptID != "" {
+		return model.OriginalPromptID
+	}
+	return model.ID
 }
 
 func (model *Prompt) getRecipientAccount() *paymentV1.Account {
@@ -209,10 +281,22 @@ func (model *Prompt) ToAPI(message map[string]string) *paymentV1.InitiatePromptR
 }
 
 func (model *Prompt) ToAPIStatus() *commonv1.StatusResponse {
+	extras := map[string]string{
+		"attempt":            strconv.Itoa(max(model.Attempt, 1)),
+		"original_prompt_id": model.RootID(),
+	}
+	if model.Resolution != "" {
+		extras["resolution"] = model.Resolution
+	}
+	if ref, ok := model.Extra["transaction_ref"].(string); ok {
+		extras["transaction_ref"] = ref
+	}
+
 	return &commonv1.StatusResponse{
 		Id:     model.ID,
 		State:  commonv1.STATE(model.State),
 		Status: commonv1.STATUS(model.Status),
+		Extras: extras,
 	}
 }
 
@@ -234,6 +318,70 @@ type PaymentLink struct {
 	Currency        string          `gorm:"type:varchar(10)"  json:"currency"`
 	Customers       datatypes.JSON  `gorm:"type:jsonb"        json:"customers"` // stores []Customer as JSON
 	Notifications   datatypes.JSON  `gorm:"type:jsonb"        json:"notifications"`
+
+	// Registered account the link collects into and the counter of its provider, links created
+	// without an account go to the default provider
+	AccountID string `gorm:"type:varchar(50)" json:"accountId"`
+	CounterID string `gorm:"type:varchar(50)" json:"counterId"`
+
+	// Lifecycle and collections against the link
+	State           int32           `gorm:"type:integer"           json:"state"`
+	CollectedAmount decimal.Decimal `gorm:"type:numeric;default:0" json:"collectedAmount"`
+	PaymentsCount   int             `gorm:"default:0"              json:"paymentsCount"`
+	LastPaymentAt   *time.Time      `json:"lastPaymentAt,omitempty"`
+
+	// Details assigned by the provider once the link is created
+	URL            string `gorm:"type:text"        json:"url"`
+	ProviderStatus string `gorm:"type:varchar(50)" json:"providerStatus"`
+}
+
+// Amount options of a payment link.
+const (
+	// AmountOptionRestricted links only accept the link amount.
+	AmountOptionRestricted = "RESTRICTED"
+	// AmountOptionOpen links accept whatever amount the customer chooses to pay.
+	AmountOptionOpen = "OPEN"
+)
+
+// IsFixedAmount reports whether payments against the link must match its amount.
+func (model *PaymentLink) IsFixedAmount() bool {
+	return !strings.EqualFold(model.AmountOption, AmountOptionOpen)
+}
+
+// IsExpired reports whether the link's expiry date has passed, links are valid through
+// the whole of their expiry date.
+func (model *PaymentLink) IsExpired(now time.Time) bool {
+	return !model.ExpiryDate.IsZero() && now.After(model.ExpiryDate.AddDate(0, 0, 1))
+}
+
+// IsActive reports whether the link still accepts payments.
+func (model *PaymentLink) IsActive(now time.Time) bool {
+	return model.State != int32(commonv1.STATE_INACTIVE) &&
+		model.State != int32(commonv1.STATE_DELETED) &&
+		!model.IsExpired(now)
+}
+
+// PaymentLink change actions published to the integrations.
+const (
+	PaymentLinkActionUpdate     = "update"
+	PaymentLinkActionDeactivate = "deactivate"
+)
+
+// PaymentLinkChange is published when a link is changed after creation so the provider
+// can be kept in step.
+type PaymentLinkChange struct {
+	Action      string       `json:"action"`
+	PaymentLink *PaymentLink `json:"paymentLink"`
+}
+
+// PaymentLinkUpdateRequest carries the editable details of a payment link, unset fields
+// are left unchanged.
+type PaymentLinkUpdateRequest struct {
+	Name        *string          `json:"name,omitempty"`
+	Description *string          `json:"description,omitempty"`
+	ExpiryDate  *string          `json:"expiry_date,omitempty"`
+	RedirectURL *string          `json:"redirect_url,omitempty"`
+	Amount      *decimal.Decimal `json:"amount,omitempty"`
 }
 
 // Customer represents a customer for a payment link.
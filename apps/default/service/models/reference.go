@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/pitabwire/frame"
+)
+
+// TransactionReference reserves a reference sent to a provider for the entity it was issued
+// to. References are unique within their scope, the counter of the merchant account they are
+// used on, which lets a reference reported by a provider be traced back to its entity.
+type TransactionReference struct {
+	frame.BaseModel
+	Scope      string `gorm:"type:varchar(50);uniqueIndex:idx_transaction_reference_scope" json:"scope"`
+	Reference  string `gorm:"type:varchar(50);uniqueIndex:idx_transaction_reference_scope" json:"reference"`
+	Sequence   int64  `json:"sequence"`
+	Format     string `gorm:"type:varchar(50)"                                             json:"format"`
+	EntityType string `gorm:"type:varchar(50);index:idx_transaction_reference_entity"      json:"entityType"`
+	EntityID   string `gorm:"type:varchar(50);index:idx_transaction_reference_entity"      json:"entityId"`
+}
+
+// ReferenceSequence is the counter references of a scope are numbered from.
+type ReferenceSequence struct {
+	Scope      string `gorm:"type:varchar(50);primaryKey"`
+	LastValue  int64
+	ModifiedAt time.Time
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/pitabwire/frame"
+	"gorm.io/datatypes"
+)
+
+// States of a payment's screening.
+const (
+	ScreeningCleared  = "cleared"
+	ScreeningHeld     = "held"
+	ScreeningRejected = "rejected"
+)
+
+// Screening records the risk screening of a released outbound payment. Payments the screener
+// held wait in the review queue until a reviewer clears or rejects them. Inbound payments over
+// a limit are held in the same queue, their money has already arrived.
+type Screening struct {
+	frame.BaseModel
+	PaymentID  string                      `gorm:"type:varchar(50);uniqueIndex" json:"paymentId"`
+	Decision   string                      `gorm:"type:varchar(20)"             json:"decision"`
+	Reasons    datatypes.JSONSlice[string] `json:"reasons"`
+	State      string                      `gorm:"type:varchar(20);index"       json:"state"`
+	ReviewedBy string                      `gorm:"type:varchar(50)"             json:"reviewedBy,omitempty"`
+	ReviewedAt *time.Time                  `json:"reviewedAt,omitempty"`
+	Comment    string                      `json:"comment,omitempty"`
+}
+
+// ScreeningReviewRequest carries a reviewer's optional comment on a held payment.
+type ScreeningReviewRequest struct {
+	Comment string `json:"comment"`
+}
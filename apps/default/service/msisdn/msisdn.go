@@ -0,0 +1,210 @@
+// Package msisdn normalises mobile numbers to E.164 and detects the operator they belong to,
+// so prompts are validated and routed to the right telco before they reach a provider.
+package msisdn
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrInvalidNumber is returned for input that is not a mobile number of a supported country.
+	ErrInvalidNumber = errors.New("invalid mobile number")
+	// ErrUnsupportedCountry is returned for numbers outside the countries with numbering plans.
+	ErrUnsupportedCountry = errors.New("unsupported country")
+	// ErrUnknownOperator is returned for well formed numbers on prefixes no operator holds.
+	ErrUnknownOperator = errors.New("unknown mobile operator")
+	// ErrPortabilityUnavailable is returned with the prefix based number when the portability
+	// lookup failed, callers may carry on with it.
+	ErrPortabilityUnavailable = errors.New("number portability lookup failed")
+)
+
+// Operator is the name of a mobile network operator as providers expect it in the telco field.
+type Operator string
+
+// Number is a normalised mobile number.
+type Number struct {
+	// E164 is the number in E.164 form, e.g. +254712345678.
+	E164 string
+	// Country is the ISO 3166 alpha-2 code of the number's country.
+	Country  string
+	Operator Operator
+	// Ported is set when a portability lookup moved the number away from its prefix operator.
+	Ported bool
+}
+
+// Digits returns the number without the leading plus, the form Jenga and Daraja expect.
+func (n Number) Digits() string {
+	return strings.TrimPrefix(n.E164, "+")
+}
+
+// PortabilityLookup reports the operator a number was ported to, ok is false when the number
+// is still with the operator its prefix was allocated to.
+type PortabilityLookup interface {
+	Lookup(ctx context.Context, e164 string) (op Operator, ok bool, err error)
+}
+
+// PortedNumbers is an in-memory PortabilityLookup keyed by E.164 number, e.g. loaded from a
+// regulator's porting file.
+type PortedNumbers map[string]Operator
+
+func (p PortedNumbers) Lookup(_ context.Context, e164 string) (Operator, bool, error) {
+	op, ok := p[e164]
+	return op, ok, nil
+}
+
+// LoadPortedNumbers reads "number,operator" lines, numbers may be in any form Normalise accepts
+// with a country code. Blank lines and lines starting with # are skipped.
+func LoadPortedNumbers(r io.Reader) (PortedNumbers, error) {
+	ported := make(PortedNumbers)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		number, op, found := strings.Cut(text, ",")
+		if !found || strings.TrimSpace(op) == "" {
+			return nil, fmt.Errorf("line %d: expected number,operator", line)
+		}
+		e164, _, err := Normalise(number, "")
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		ported[e164] = Operator(strings.TrimSpace(op))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ported, nil
+}
+
+// Normalise converts a mobile number written in international or national form to E.164,
+// national numbers are read in defaultCountry. It returns the number and its country.
+func Normalise(raw, defaultCountry string) (string, string, error) {
+	digits, international, err := clean(raw)
+	if err != nil {
+		return "", "", err
+	}
+
+	if international {
+		for _, c := range countries {
+			if nsn, ok := strings.CutPrefix(digits, c.callingCode); ok {
+				return c.format(nsn)
+			}
+		}
+		return "", "", fmt.Errorf("%w: %s", ErrUnsupportedCountry, raw)
+	}
+
+	c, ok := countries[strings.ToUpper(defaultCountry)]
+	if !ok {
+		// Without a country only numbers that start with a supported calling code can be read
+		for _, candidate := range countries {
+			if nsn, found := strings.CutPrefix(digits, candidate.callingCode); found && len(nsn) == candidate.nsnLength {
+				return candidate.format(nsn)
+			}
+		}
+		return "", "", fmt.Errorf("%w: %q", ErrUnsupportedCountry, defaultCountry)
+	}
+
+	switch {
+	case len(digits) == c.nsnLength+1 && digits[0] == '0':
+		return c.format(digits[1:])
+	case len(digits) == c.nsnLength:
+		return c.format(digits)
+	case strings.HasPrefix(digits, c.callingCode):
+		return c.format(strings.TrimPrefix(digits, c.callingCode))
+	default:
+		return "", "", fmt.Errorf("%w: %s", ErrInvalidNumber, raw)
+	}
+}
+
+// clean strips formatting characters and reports whether the number carried an international prefix.
+func clean(raw string) (string, bool, error) {
+	var digits strings.Builder
+	international := false
+	for i, r := range strings.TrimSpace(raw) {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+			international = true
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", false, fmt.Errorf("%w: %s", ErrInvalidNumber, raw)
+		}
+	}
+
+	number := digits.String()
+	if trimmed, ok := strings.CutPrefix(number, "00"); ok && !international {
+		number, international = trimmed, true
+	}
+	if number == "" {
+		return "", false, fmt.Errorf("%w: %q", ErrInvalidNumber, raw)
+	}
+	return number, international, nil
+}
+
+func (c country) format(nsn string) (string, string, error) {
+	nsn = strings.TrimPrefix(nsn, "0")
+	if len(nsn) != c.nsnLength {
+		return "", "", fmt.Errorf("%w: %s numbers have %d digits after +%s", ErrInvalidNumber, c.code,
+			c.nsnLength, c.callingCode)
+	}
+	return "+" + c.callingCode + nsn, c.code, nil
+}
+
+// Resolver normalises numbers and detects their operator, consulting the portability lookup
+// before the prefix tables when one is configured.
+type Resolver struct {
+	// DefaultCountry is used for numbers written in national form.
+	DefaultCountry string
+	Portability    PortabilityLookup
+}
+
+// NewResolver creates a resolver, portability may be nil.
+func NewResolver(defaultCountry string, portability PortabilityLookup) *Resolver {
+	return &Resolver{DefaultCountry: defaultCountry, Portability: portability}
+}
+
+// Resolve normalises raw and detects its operator. country overrides the default country for
+// national numbers when set. A failed portability lookup returns the prefix based number
+// together with an error wrapping ErrPortabilityUnavailable.
+func (r *Resolver) Resolve(ctx context.Context, raw, country string) (Number, error) {
+	if country == "" {
+		country = r.DefaultCountry
+	}
+	e164, code, err := Normalise(raw, country)
+	if err != nil {
+		return Number{}, err
+	}
+
+	number := Number{E164: e164, Country: code}
+	plan := countries[code]
+	op, allocated := plan.operator(strings.TrimPrefix(e164, "+"+plan.callingCode))
+	number.Operator = op
+
+	if r.Portability != nil {
+		ported, ok, lookupErr := r.Portability.Lookup(ctx, e164)
+		switch {
+		case lookupErr != nil:
+			if !allocated {
+				return Number{}, fmt.Errorf("%w: %s", ErrUnknownOperator, e164)
+			}
+			return number, fmt.Errorf("%w: %w", ErrPortabilityUnavailable, lookupErr)
+		case ok && ported != op:
+			number.Operator = ported
+			number.Ported = true
+			return number, nil
+		}
+	}
+
+	if !allocated {
+		return Number{}, fmt.Errorf("%w: %s", ErrUnknownOperator, e164)
+	}
+	return number, nil
+}
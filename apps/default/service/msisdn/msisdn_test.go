@@ -0,0 +1,118 @@
+package msisdn
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name         string
+		raw          string
+		country      string
+		wantE164     string
+		wantCountry  string
+		wantOperator Operator
+		wantErr      error
+	}{
+		{name: "ke national", raw: "0712 345 678", country: "KE", wantE164: "+254712345678",
+			wantCountry: "KE", wantOperator: Safaricom},
+		{name: "ke international", raw: "+254733123456", wantE164: "+254733123456", wantCountry: "KE",
+			wantOperator: Airtel},
+		{name: "ke without plus", raw: "254772123456", country: "KE", wantE164: "+254772123456",
+			wantCountry: "KE", wantOperator: Telkom},
+		{name: "ke double zero", raw: "00254110123456", wantE164: "+254110123456", wantCountry: "KE",
+			wantOperator: Safaricom},
+		{name: "ke short national", raw: "763123456", country: "KE", wantE164: "+254763123456",
+			wantCountry: "KE", wantOperator: Equitel},
+		{name: "ke airtel longest prefix", raw: "0752123456", country: "KE", wantE164: "+254752123456",
+			wantCountry: "KE", wantOperator: Airtel},
+		{name: "ug", raw: "+256 772 123456", wantE164: "+256772123456", wantCountry: "UG", wantOperator: MTN},
+		{name: "ug national", raw: "0701234567", country: "UG", wantE164: "+256701234567", wantCountry: "UG",
+			wantOperator: Airtel},
+		{name: "tz", raw: "+255 754 123 456", wantE164: "+255754123456", wantCountry: "TZ",
+			wantOperator: Vodacom},
+		{name: "tz tigo", raw: "0651234567", country: "TZ", wantE164: "+255651234567", wantCountry: "TZ",
+			wantOperator: Tigo},
+		{name: "rw", raw: "+250 788 123 456", wantE164: "+250788123456", wantCountry: "RW", wantOperator: MTN},
+		{name: "rw airtel", raw: "0731234567", country: "RW", wantE164: "+250731234567", wantCountry: "RW",
+			wantOperator: Airtel},
+		{name: "trunk zero after country code", raw: "+2540712345678", wantE164: "+254712345678",
+			wantCountry: "KE", wantOperator: Safaricom},
+		{name: "too short", raw: "071234567", country: "KE", wantErr: ErrInvalidNumber},
+		{name: "too long", raw: "+2547123456789", wantErr: ErrInvalidNumber},
+		{name: "letters", raw: "07123abc78", country: "KE", wantErr: ErrInvalidNumber},
+		{name: "empty", raw: "", country: "KE", wantErr: ErrInvalidNumber},
+		{name: "unsupported country code", raw: "+447911123456", wantErr: ErrUnsupportedCountry},
+		{name: "national without country", raw: "0712345678", wantErr: ErrUnsupportedCountry},
+		{name: "unallocated prefix", raw: "0201234567", country: "KE", wantErr: ErrUnknownOperator},
+	}
+
+	resolver := NewResolver("", nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			number, err := resolver.Resolve(context.Background(), tt.raw, tt.country)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Resolve(%q) error = %v, want %v", tt.raw, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve(%q) error = %v", tt.raw, err)
+			}
+			if number.E164 != tt.wantE164 || number.Country != tt.wantCountry || number.Operator != tt.wantOperator {
+				t.Fatalf("Resolve(%q) = %+v, want %s %s %s", tt.raw, number, tt.wantE164, tt.wantCountry,
+					tt.wantOperator)
+			}
+			if number.Digits() != strings.TrimPrefix(tt.wantE164, "+") {
+				t.Fatalf("Digits() = %s", number.Digits())
+			}
+		})
+	}
+}
+
+type failingLookup struct{}
+
+func (failingLookup) Lookup(context.Context, string) (Operator, bool, error) {
+	return "", false, errors.New("registry unreachable")
+}
+
+func TestResolvePortability(t *testing.T) {
+	ported, err := LoadPortedNumbers(strings.NewReader("# number,operator\n+254712345678,Airtel\n\n0256701234567,MTN\n"))
+	if err == nil {
+		t.Fatalf("LoadPortedNumbers accepted a number without a country code: %v", ported)
+	}
+
+	ported, err = LoadPortedNumbers(strings.NewReader("# number,operator\n+254712345678,Airtel\n\n256701234567,MTN\n"))
+	if err != nil {
+		t.Fatalf("LoadPortedNumbers() error = %v", err)
+	}
+
+	resolver := NewResolver("KE", ported)
+	number, err := resolver.Resolve(context.Background(), "0712345678", "")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if number.Operator != Airtel || !number.Ported {
+		t.Fatalf("Resolve() = %+v, want number ported to Airtel", number)
+	}
+
+	number, err = resolver.Resolve(context.Background(), "+256701234567", "")
+	if err != nil || number.Operator != MTN {
+		t.Fatalf("Resolve() = %+v, %v, want number ported to MTN", number, err)
+	}
+
+	number, err = resolver.Resolve(context.Background(), "0722000000", "")
+	if err != nil || number.Operator != Safaricom || number.Ported {
+		t.Fatalf("Resolve() = %+v, %v, want Safaricom number that was not ported", number, err)
+	}
+
+	resolver.Portability = failingLookup{}
+	number, err = resolver.Resolve(context.Background(), "0712345678", "")
+	if !errors.Is(err, ErrPortabilityUnavailable) || number.Operator != Safaricom {
+		t.Fatalf("Resolve() = %+v, %v, want prefix operator with ErrPortabilityUnavailable", number, err)
+	}
+}
@@ -0,0 +1,90 @@
+package msisdn
+
+// Mobile operators the payment service can prompt through.
+const (
+	Safaricom Operator = "Safaricom"
+	Airtel    Operator = "Airtel"
+	Telkom    Operator = "Telkom"
+	Equitel   Operator = "Equitel"
+	Faiba     Operator = "Faiba"
+	MTN       Operator = "MTN"
+	UTL       Operator = "UTL"
+	Vodacom   Operator = "Vodacom"
+	Tigo      Operator = "Tigo"
+	Zantel    Operator = "Zantel"
+	Halotel   Operator = "Halotel"
+	TTCL      Operator = "TTCL"
+)
+
+// country describes the numbering plan of a supported country.
+type country struct {
+	code         string
+	callingCode  string
+	nsnLength    int
+	operatorsFor map[string]Operator
+}
+
+// countries holds the mobile numbering plans, keyed by ISO 3166 alpha-2 code. Operator prefixes
+// are matched against the national significant number, the longest matching prefix wins.
+var countries = map[string]country{
+	"KE": {
+		code:        "KE",
+		callingCode: "254",
+		nsnLength:   9,
+		operatorsFor: map[string]Operator{
+			"70": Safaricom, "71": Safaricom, "72": Safaricom, "740": Safaricom, "741": Safaricom,
+			"742": Safaricom, "743": Safaricom, "745": Safaricom, "746": Safaricom, "748": Safaricom,
+			"757": Safaricom, "758": Safaricom, "759": Safaricom, "768": Safaricom, "769": Safaricom,
+			"79": Safaricom, "110": Safaricom, "111": Safaricom, "112": Safaricom, "113": Safaricom,
+			"114": Safaricom, "115": Safaricom,
+			"73": Airtel, "750": Airtel, "751": Airtel, "752": Airtel, "753": Airtel, "754": Airtel,
+			"755": Airtel, "756": Airtel, "762": Airtel, "78": Airtel, "100": Airtel, "101": Airtel,
+			"102": Airtel,
+			"77":  Telkom,
+			"763": Equitel, "764": Equitel, "765": Equitel, "766": Equitel,
+			"747": Faiba,
+		},
+	},
+	"UG": {
+		code:        "UG",
+		callingCode: "256",
+		nsnLength:   9,
+		operatorsFor: map[string]Operator{
+			"76": MTN, "77": MTN, "78": MTN, "39": MTN,
+			"70": Airtel, "74": Airtel, "75": Airtel, "20": Airtel,
+			"71": UTL,
+		},
+	},
+	"TZ": {
+		code:        "TZ",
+		callingCode: "255",
+		nsnLength:   9,
+		operatorsFor: map[string]Operator{
+			"74": Vodacom, "75": Vodacom, "76": Vodacom,
+			"68": Airtel, "69": Airtel, "78": Airtel,
+			"65": Tigo, "67": Tigo, "71": Tigo,
+			"77": Zantel,
+			"61": Halotel, "62": Halotel,
+			"73": TTCL,
+		},
+	},
+	"RW": {
+		code:        "RW",
+		callingCode: "250",
+		nsnLength:   9,
+		operatorsFor: map[string]Operator{
+			"78": MTN, "79": MTN,
+			"72": Airtel, "73": Airtel,
+		},
+	},
+}
+
+// operator finds the operator that was allocated the number's prefix.
+func (c country) operator(nsn string) (Operator, bool) {
+	for length := len(nsn); length > 0; length-- {
+		if op, ok := c.operatorsFor[nsn[:length]]; ok {
+			return op, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,202 @@
+// Package daraja adapts the M-Pesa Daraja integration service to the provider interface.
+// Requests are handed to the integration over its topics, it calls Safaricom directly and
+// reports outcomes back.
+package daraja
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/provider"
+	"github.com/shopspring/decimal"
+)
+
+// Daraja callback kinds, matching the endpoints the integration receives them on.
+const (
+	CallbackKindStk = "stk"
+	CallbackKindC2B = "c2b"
+
+	// currency is the only currency M-Pesa Kenya settles in.
+	currency = "KES"
+)
+
+// Transport is the part of frame.Service the adapter uses to reach the integration.
+type Transport interface {
+	Publish(ctx context.Context, reference string, payload any, headers ...map[string]string) error
+}
+
+// Provider forwards payment service requests to the Daraja integration. M-Pesa has no payment
+// links and the integration does not expose balances, so those operations are not supported.
+type Provider struct {
+	Transport        Transport
+	PromptTopic      string
+	StatusQueryTopic string
+	PayoutTopic      string
+}
+
+func (p *Provider) Name() string {
+	return "daraja"
+}
+
+func (p *Provider) InitiatePrompt(ctx context.Context, prompt *models.Prompt) error {
+	if prompt == nil || prompt.ID == "" {
+		return errors.New("prompt id is required")
+	}
+	if !prompt.Amount.Valid || !prompt.Amount.Decimal.IsPositive() {
+		return errors.New("prompt amount is required")
+	}
+	return p.publish(ctx, p.PromptTopic, prompt)
+}
+
+func (p *Provider) QueryStatus(ctx context.Context, prompt *models.Prompt) error {
+	if prompt == nil || prompt.ID == "" {
+		return errors.New("prompt id is required")
+	}
+	return p.publish(ctx, p.StatusQueryTopic, prompt)
+}
+
+func (p *Provider) Payout(ctx context.Context, payment *models.Payment) error {
+	if payment == nil || payment.ID == "" {
+		return errors.New("payment id is required")
+	}
+	if !payment.OutBound {
+		return errors.New("only outbound payments can be paid out")
+	}
+	return p.publish(ctx, p.PayoutTopic, payment)
+}
+
+func (p *Provider) CreatePaymentLink(_ context.Context, _ *models.PaymentLink) error {
+	return provider.ErrNotSupported
+}
+
+func (p *Provider) UpdatePaymentLink(_ context.Context, _ *models.PaymentLinkChange) error {
+	return provider.ErrNotSupported
+}
+
+func (p *Provider) Balance(_ context.Context, _ *models.Account) (*provider.Balance, error) {
+	return nil, provider.ErrNotSupported
+}
+
+func (p *Provider) ParseCallback(_ context.Context, kind string, body []byte) (*provider.Callback, error) {
+	switch kind {
+	case CallbackKindStk:
+		return parseStkCallback(body)
+	case CallbackKindC2B:
+		return parseC2BCallback(body)
+	default:
+		return nil, fmt.Errorf("%w: %s", provider.ErrUnknownCallback, kind)
+	}
+}
+
+func (p *Provider) publish(ctx context.Context, topic string, payload any) error {
+	if topic == "" {
+		return provider.ErrNotSupported
+	}
+	return p.Transport.Publish(ctx, topic, payload)
+}
+
+type stkCallback struct {
+	Body struct {
+		StkCallback struct {
+			MerchantRequestID string `json:"MerchantRequestID"`
+			CheckoutRequestID string `json:"CheckoutRequestID"`
+			ResultCode        int    `json:"ResultCode"`
+			ResultDesc        string `json:"ResultDesc"`
+			CallbackMetadata  struct {
+				Item []struct {
+					Name  string          `json:"Name"`
+					Value json.RawMessage `json:"Value"`
+				} `json:"Item"`
+			} `json:"CallbackMetadata"`
+		} `json:"stkCallback"`
+	} `json:"Body"`
+}
+
+func (c *stkCallback) metadata(name string) string {
+	for _, item := range c.Body.StkCallback.CallbackMetadata.Item {
+		if item.Name != name {
+			continue
+		}
+		// Numbers are kept as sent, amounts and phone numbers must not pass through a float
+		var text string
+		if err := json.Unmarshal(item.Value, &text); err == nil {
+			return text
+		}
+		var number json.Number
+		if err := json.Unmarshal(item.Value, &number); err == nil {
+			return number.String()
+		}
+	}
+	return ""
+}
+
+type c2bCallback struct {
+	TransID           string `json:"TransID"`
+	TransAmount       string `json:"TransAmount"`
+	BusinessShortCode string `json:"BusinessShortCode"`
+	BillRefNumber     string `json:"BillRefNumber"`
+	MSISDN            string `json:"MSISDN"`
+	FirstName         string `json:"FirstName"`
+}
+
+func parseStkCallback(body []byte) (*provider.Callback, error) {
+	var callback stkCallback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return nil, fmt.Errorf("decode stk callback: %w", err)
+	}
+	result := callback.Body.StkCallback
+	if result.CheckoutRequestID == "" {
+		return nil, errors.New("checkout request id is required")
+	}
+
+	// The integration maps the checkout request id back to the prompt's transaction reference
+	parsed := &provider.Callback{
+		Kind:           provider.CallbackPrompt,
+		Status:         commonv1.STATUS_FAILED,
+		TransactionRef: result.CheckoutRequestID,
+		TransactionID:  callback.metadata("MpesaReceiptNumber"),
+		Currency:       currency,
+		PayerMSISDN:    callback.metadata("PhoneNumber"),
+		Message:        result.ResultDesc,
+		Extra:          map[string]string{"merchant_request_id": result.MerchantRequestID},
+	}
+	if amount, err := decimal.NewFromString(callback.metadata("Amount")); err == nil {
+		parsed.Amount = amount
+	}
+	if result.ResultCode == 0 {
+		parsed.Kind = provider.CallbackPayment
+		parsed.Status = commonv1.STATUS_SUCCESSFUL
+	}
+	return parsed, nil
+}
+
+func parseC2BCallback(body []byte) (*provider.Callback, error) {
+	var callback c2bCallback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return nil, fmt.Errorf("decode c2b callback: %w", err)
+	}
+	if callback.TransID == "" {
+		return nil, errors.New("transaction id is required")
+	}
+	amount, err := decimal.NewFromString(callback.TransAmount)
+	if err != nil {
+		return nil, fmt.Errorf("parse c2b amount: %w", err)
+	}
+
+	// Customers pay links by typing the link reference as the account number
+	return &provider.Callback{
+		Kind:           provider.CallbackPayment,
+		Status:         commonv1.STATUS_SUCCESSFUL,
+		TransactionID:  callback.TransID,
+		Amount:         amount,
+		Currency:       currency,
+		PayerName:      callback.FirstName,
+		PayerMSISDN:    callback.MSISDN,
+		PaymentLinkRef: callback.BillRefNumber,
+		Extra:          map[string]string{"short_code": callback.BusinessShortCode},
+	}, nil
+}
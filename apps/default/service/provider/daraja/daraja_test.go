@@ -0,0 +1,104 @@
+package daraja_test
+
+import (
+	"context"
+	"testing"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/service/provider"
+	"github.com/antinvestor/service-payments/service/provider/daraja"
+	"github.com/antinvestor/service-payments/service/provider/providertest"
+	"github.com/shopspring/decimal"
+)
+
+type fakeTransport struct{}
+
+func (f *fakeTransport) Publish(_ context.Context, _ string, _ any, _ ...map[string]string) error {
+	return nil
+}
+
+func TestProviderConformance(t *testing.T) {
+	providertest.Run(t, providertest.Fixture{
+		New: func(_ *testing.T) provider.Provider {
+			return &daraja.Provider{
+				Transport:        &fakeTransport{},
+				PromptTopic:      "daraja.initiate.prompt",
+				StatusQueryTopic: "daraja.prompt.status.query",
+				PayoutTopic:      "daraja.payout",
+			}
+		},
+		Unsupported: []providertest.Operation{
+			providertest.CreatePaymentLink,
+			providertest.UpdatePaymentLink,
+			providertest.Balance,
+		},
+		Callbacks: []providertest.CallbackCase{
+			{
+				Name: "stk paid",
+				Kind: daraja.CallbackKindStk,
+				Body: `{"Body":{"stkCallback":{"MerchantRequestID":"29115-34620561-1",` +
+					`"CheckoutRequestID":"ws_CO_191220191020363925","ResultCode":0,` +
+					`"ResultDesc":"The service request is processed successfully.","CallbackMetadata":{"Item":[` +
+					`{"Name":"Amount","Value":1.00},{"Name":"MpesaReceiptNumber","Value":"NLJ7RT61SV"},` +
+					`{"Name":"TransactionDate","Value":20191219102115},{"Name":"PhoneNumber","Value":254708374149}]}}}}`,
+				Want: provider.Callback{
+					Kind:           provider.CallbackPayment,
+					Status:         commonv1.STATUS_SUCCESSFUL,
+					TransactionRef: "ws_CO_191220191020363925",
+					TransactionID:  "NLJ7RT61SV",
+					Amount:         decimal.NewFromInt(1),
+					Currency:       "KES",
+					PayerMSISDN:    "254708374149",
+				},
+			},
+			{
+				// Metadata numbers are read as sent, a float would round this amount
+				Name: "stk paid a large amount",
+				Kind: daraja.CallbackKindStk,
+				Body: `{"Body":{"stkCallback":{"MerchantRequestID":"29115-34620561-1",` +
+					`"CheckoutRequestID":"ws_CO_191220191020363926","ResultCode":0,` +
+					`"ResultDesc":"The service request is processed successfully.","CallbackMetadata":{"Item":[` +
+					`{"Name":"Amount","Value":9007199254740993},{"Name":"MpesaReceiptNumber","Value":"NLJ7RT61SW"},` +
+					`{"Name":"PhoneNumber","Value":254708374149}]}}}}`,
+				Want: provider.Callback{
+					Kind:           provider.CallbackPayment,
+					Status:         commonv1.STATUS_SUCCESSFUL,
+					TransactionRef: "ws_CO_191220191020363926",
+					TransactionID:  "NLJ7RT61SW",
+					Amount:         decimal.RequireFromString("9007199254740993"),
+					Currency:       "KES",
+					PayerMSISDN:    "254708374149",
+				},
+			},
+			{
+				Name: "stk cancelled",
+				Kind: daraja.CallbackKindStk,
+				Body: `{"Body":{"stkCallback":{"MerchantRequestID":"29115-34620561-1",` +
+					`"CheckoutRequestID":"ws_CO_191220191020363925","ResultCode":1032,` +
+					`"ResultDesc":"Request cancelled by user"}}}`,
+				Want: provider.Callback{
+					Kind:           provider.CallbackPrompt,
+					Status:         commonv1.STATUS_FAILED,
+					TransactionRef: "ws_CO_191220191020363925",
+					Currency:       "KES",
+				},
+			},
+			{
+				Name: "c2b confirmation",
+				Kind: daraja.CallbackKindC2B,
+				Body: `{"TransactionType":"Pay Bill","TransID":"RKTQDM7W6S","TransTime":"20191122063845",` +
+					`"TransAmount":"10.50","BusinessShortCode":"600638","BillRefNumber":"PL7Q2X",` +
+					`"MSISDN":"254708374149","FirstName":"John"}`,
+				Want: provider.Callback{
+					Kind:           provider.CallbackPayment,
+					Status:         commonv1.STATUS_SUCCESSFUL,
+					TransactionID:  "RKTQDM7W6S",
+					Amount:         decimal.RequireFromString("10.50"),
+					Currency:       "KES",
+					PayerMSISDN:    "254708374149",
+					PaymentLinkRef: "PL7Q2X",
+				},
+			},
+		},
+	})
+}
@@ -0,0 +1,291 @@
+// Package jenga adapts the Jenga integration service to the provider interface. Requests are
+// handed to the integration over its topics, it calls Jenga and reports outcomes back.
+package jenga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/provider"
+	"github.com/antinvestor/service-payments/service/utility"
+	"github.com/shopspring/decimal"
+)
+
+// Jenga callback kinds, matching the endpoints the integration receives them on.
+const (
+	CallbackKindStk         = "stk"
+	CallbackKindIPN         = "ipn"
+	CallbackKindTills       = "tills"
+	CallbackKindPaymentLink = "payment_link"
+
+	// stkCodeSettled is the callback code Jenga uses once the customer's payment has settled.
+	stkCodeSettled = 3
+)
+
+// Transport is the part of frame.Service the adapter uses to reach the integration.
+type Transport interface {
+	Publish(ctx context.Context, reference string, payload any, headers ...map[string]string) error
+	InvokeRestService(
+		ctx context.Context,
+		method string,
+		endpointURL string,
+		payload map[string]any,
+		headers map[string][]string,
+	) (int, []byte, error)
+}
+
+// Provider forwards payment service requests to the Jenga integration. Operations whose topic
+// or endpoint is not configured are reported as not supported.
+type Provider struct {
+	Transport              Transport
+	PromptTopic            string
+	StatusQueryTopic       string
+	PayoutTopic            string
+	PaymentLinkTopic       string
+	PaymentLinkUpdateTopic string
+	BalanceURI             string
+	// Credentials authenticate the requests made to the integration's endpoints.
+	Credentials *utility.ServiceCredentials
+}
+
+func (p *Provider) Name() string {
+	return "jenga"
+}
+
+func (p *Provider) InitiatePrompt(ctx context.Context, prompt *models.Prompt) error {
+	if prompt == nil || prompt.ID == "" {
+		return errors.New("prompt id is required")
+	}
+	if !prompt.Amount.Valid || !prompt.Amount.Decimal.IsPositive() {
+		return errors.New("prompt amount is required")
+	}
+	return p.publish(ctx, p.PromptTopic, prompt)
+}
+
+func (p *Provider) QueryStatus(ctx context.Context, prompt *models.Prompt) error {
+	if prompt == nil || prompt.ID == "" {
+		return errors.New("prompt id is required")
+	}
+	if ref, _ := prompt.Extra["transaction_ref"].(string); ref == "" {
+		return errors.New("prompt transaction reference is required")
+	}
+	return p.publish(ctx, p.StatusQueryTopic, prompt)
+}
+
+// Payout publishes the released payment as JSON on the payout topic, jenga-api's payout
+// consumer pays it and reports its status back. This replaces the protobuf payment that was
+// published on the topic named by the payment's route.
+func (p *Provider) Payout(ctx context.Context, payment *models.Payment) error {
+	if payment == nil || payment.ID == "" {
+		return errors.New("payment id is required")
+	}
+	if !payment.OutBound {
+		return errors.New("only outbound payments can be paid out")
+	}
+	return p.publish(ctx, p.PayoutTopic, payment)
+}
+
+func (p *Provider) CreatePaymentLink(ctx context.Context, link *models.PaymentLink) error {
+	if link == nil || link.ID == "" {
+		return errors.New("payment link id is required")
+	}
+	return p.publish(ctx, p.PaymentLinkTopic, link)
+}
+
+func (p *Provider) UpdatePaymentLink(ctx context.Context, change *models.PaymentLinkChange) error {
+	if change == nil || change.PaymentLink == nil || change.PaymentLink.ID == "" {
+		return errors.New("payment link id is required")
+	}
+	return p.publish(ctx, p.PaymentLinkUpdateTopic, change)
+}
+
+func (p *Provider) Balance(ctx context.Context, account *models.Account) (*provider.Balance, error) {
+	if account == nil || account.AccountNumber == "" || account.CountryCode == "" {
+		return nil, errors.New("account number and country code are required")
+	}
+	if p.BalanceURI == "" {
+		return nil, provider.ErrNotSupported
+	}
+
+	query := url.Values{}
+	query.Set("countryCode", account.CountryCode)
+	query.Set("accountId", account.AccountNumber)
+	headers, err := p.Credentials.Headers()
+	if err != nil {
+		return nil, err
+	}
+	statusCode, body, err := p.Transport.InvokeRestService(
+		ctx, http.MethodGet, p.BalanceURI+"?"+query.Encode(), nil, headers)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("balance lookup failed with status %d", statusCode)
+	}
+
+	var response balanceResponse
+	if err = json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("decode balance: %w", err)
+	}
+
+	balance := &provider.Balance{AccountNumber: account.AccountNumber, Currency: response.Data.Currency}
+	for _, entry := range response.Data.Balances {
+		amount, parseErr := decimal.NewFromString(entry.Amount)
+		if parseErr != nil {
+			return nil, fmt.Errorf("parse %s balance: %w", entry.Type, parseErr)
+		}
+		switch strings.ToLower(entry.Type) {
+		case "available":
+			balance.Available = amount
+		case "current":
+			balance.Current = amount
+		}
+	}
+	return balance, nil
+}
+
+func (p *Provider) ParseCallback(_ context.Context, kind string, body []byte) (*provider.Callback, error) {
+	switch kind {
+	case CallbackKindStk:
+		return parseStkCallback(body)
+	case CallbackKindIPN, CallbackKindTills, CallbackKindPaymentLink:
+		return parseTransactionCallback(kind, body)
+	default:
+		return nil, fmt.Errorf("%w: %s", provider.ErrUnknownCallback, kind)
+	}
+}
+
+func (p *Provider) publish(ctx context.Context, topic string, payload any) error {
+	if topic == "" {
+		return provider.ErrNotSupported
+	}
+	return p.Transport.Publish(ctx, topic, payload)
+}
+
+type balanceResponse struct {
+	Data struct {
+		Currency string `json:"currency"`
+		Balances []struct {
+			Amount string `json:"amount"`
+			Type   string `json:"type"`
+		} `json:"balances"`
+	} `json:"data"`
+}
+
+type stkCallback struct {
+	Status        bool        `json:"status"`
+	Code          int         `json:"code"`
+	Message       string      `json:"message"`
+	Transaction   string      `json:"transactionReference"`
+	Telco         string      `json:"telcoReference"`
+	MobileNumber  string      `json:"mobileNumber"`
+	Currency      string      `json:"currency"`
+	RequestAmount json.Number `json:"requestAmount"`
+	TelcoName     string      `json:"telco"`
+}
+
+// transactionCallback covers the IPN, till and payment link callbacks, which share a shape.
+type transactionCallback struct {
+	PaymentLinkRef string `json:"paymentLinkRef"`
+	Customer       struct {
+		Name         string `json:"name"`
+		MobileNumber string `json:"mobileNumber"`
+	} `json:"customer"`
+	Transaction struct {
+		Reference   string      `json:"reference"`
+		PaymentMode string      `json:"paymentMode"`
+		Amount      json.Number `json:"amount"`
+		Currency    string      `json:"currency"`
+		Status      string      `json:"status"`
+		Remarks     string      `json:"remarks"`
+	} `json:"transaction"`
+}
+
+func parseStkCallback(body []byte) (*provider.Callback, error) {
+	var callback stkCallback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return nil, fmt.Errorf("decode stk callback: %w", err)
+	}
+	if callback.Transaction == "" {
+		return nil, errors.New("transaction reference is required")
+	}
+	amount, err := parseAmount(callback.RequestAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &provider.Callback{
+		Kind:           provider.CallbackPrompt,
+		Status:         commonv1.STATUS_FAILED,
+		TransactionRef: callback.Transaction,
+		TransactionID:  callback.Telco,
+		Amount:         amount,
+		Currency:       callback.Currency,
+		PayerMSISDN:    callback.MobileNumber,
+		Message:        callback.Message,
+		Extra:          map[string]string{"telco": callback.TelcoName},
+	}
+	if callback.Status || callback.Code == stkCodeSettled {
+		parsed.Kind = provider.CallbackPayment
+		parsed.Status = commonv1.STATUS_SUCCESSFUL
+	}
+	return parsed, nil
+}
+
+func parseTransactionCallback(kind string, body []byte) (*provider.Callback, error) {
+	var callback transactionCallback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return nil, fmt.Errorf("decode %s callback: %w", kind, err)
+	}
+	if callback.Transaction.Reference == "" {
+		return nil, errors.New("transaction reference is required")
+	}
+	amount, err := parseAmount(callback.Transaction.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &provider.Callback{
+		Kind:           provider.CallbackPayment,
+		Status:         commonv1.STATUS_FAILED,
+		TransactionID:  callback.Transaction.Reference,
+		Amount:         amount,
+		Currency:       callback.Transaction.Currency,
+		PayerName:      callback.Customer.Name,
+		PayerMSISDN:    callback.Customer.MobileNumber,
+		PaymentLinkRef: callback.PaymentLinkRef,
+		Message:        callback.Transaction.Remarks,
+		Extra: map[string]string{
+			"callback_type": kind,
+			"payment_mode":  callback.Transaction.PaymentMode,
+		},
+	}
+	// A callback without a status is left unknown rather than taken as settled
+	switch strings.ToUpper(callback.Transaction.Status) {
+	case "":
+		parsed.Status = commonv1.STATUS_UNKNOWN
+	case "SUCCESS", "SUCCESSFUL", "COMPLETED", "SETTLED":
+		parsed.Status = commonv1.STATUS_SUCCESSFUL
+	}
+	return parsed, nil
+}
+
+// parseAmount reads a callback amount exactly, Jenga sends amounts both as numbers and as
+// strings. Callbacks without an amount carry zero.
+func parseAmount(amount json.Number) (decimal.Decimal, error) {
+	if amount == "" {
+		return decimal.Zero, nil
+	}
+	parsed, err := decimal.NewFromString(amount.String())
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("parse amount: %w", err)
+	}
+	return parsed, nil
+}
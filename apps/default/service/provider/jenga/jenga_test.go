@@ -0,0 +1,204 @@
+package jenga_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/provider"
+	"github.com/antinvestor/service-payments/service/provider/jenga"
+	"github.com/antinvestor/service-payments/service/provider/providertest"
+	"github.com/antinvestor/service-payments/service/utility"
+	"github.com/shopspring/decimal"
+)
+
+type fakeTransport struct {
+	published map[string]int
+	headers   map[string][]string
+}
+
+func (f *fakeTransport) Publish(_ context.Context, reference string, _ any, _ ...map[string]string) error {
+	f.published[reference]++
+	return nil
+}
+
+func (f *fakeTransport) InvokeRestService(
+	_ context.Context,
+	_ string,
+	_ string,
+	_ map[string]any,
+	headers map[string][]string,
+) (int, []byte, error) {
+	f.headers = headers
+	body := `{"status":true,"code":0,"data":{"currency":"KES","balances":[` +
+		`{"amount":"997382.57","type":"Current"},{"amount":"997382.57","type":"Available"}]}}`
+	return http.StatusOK, []byte(body), nil
+}
+
+func newProvider(transport *fakeTransport) *jenga.Provider {
+	return &jenga.Provider{
+		Transport:              transport,
+		PromptTopic:            "initiate.prompt",
+		StatusQueryTopic:       "prompt.status.query",
+		PayoutTopic:            "jenga.payout",
+		PaymentLinkTopic:       "create.payment.link",
+		PaymentLinkUpdateTopic: "update.payment.link",
+		BalanceURI:             "http://jenga_service:8080/accounts/balance",
+	}
+}
+
+func TestProviderConformance(t *testing.T) {
+	providertest.Run(t, providertest.Fixture{
+		New: func(_ *testing.T) provider.Provider {
+			return newProvider(&fakeTransport{published: make(map[string]int)})
+		},
+		Callbacks: []providertest.CallbackCase{
+			{
+				Name: "stk settled",
+				Kind: jenga.CallbackKindStk,
+				Body: `{"status":true,"code":3,"message":"Settled","transactionReference":"A12345",` +
+					`"telcoReference":"RKL51ZDR4F","mobileNumber":"254712345678","currency":"KES","requestAmount":100}`,
+				Want: provider.Callback{
+					Kind:           provider.CallbackPayment,
+					Status:         commonv1.STATUS_SUCCESSFUL,
+					TransactionRef: "A12345",
+					TransactionID:  "RKL51ZDR4F",
+					Amount:         decimal.NewFromInt(100),
+					Currency:       "KES",
+					PayerMSISDN:    "254712345678",
+				},
+			},
+			{
+				Name: "stk cancelled",
+				Kind: jenga.CallbackKindStk,
+				Body: `{"status":false,"code":4,"message":"Request cancelled by user","transactionReference":"A12345",` +
+					`"mobileNumber":"254712345678","currency":"KES","requestAmount":100}`,
+				Want: provider.Callback{
+					Kind:           provider.CallbackPrompt,
+					Status:         commonv1.STATUS_FAILED,
+					TransactionRef: "A12345",
+					Amount:         decimal.NewFromInt(100),
+					Currency:       "KES",
+					PayerMSISDN:    "254712345678",
+				},
+			},
+			{
+				Name: "ipn",
+				Kind: jenga.CallbackKindIPN,
+				Body: `{"customer":{"name":"JOHN DOE","mobileNumber":"254712345678"},` +
+					`"transaction":{"reference":"741852963","paymentMode":"MPESA","amount":250.5,"currency":"KES",` +
+					`"status":"SUCCESS"}}`,
+				Want: provider.Callback{
+					Kind:          provider.CallbackPayment,
+					Status:        commonv1.STATUS_SUCCESSFUL,
+					TransactionID: "741852963",
+					Amount:        decimal.RequireFromString("250.5"),
+					Currency:      "KES",
+					PayerMSISDN:   "254712345678",
+				},
+			},
+			{
+				Name: "ipn without status",
+				Kind: jenga.CallbackKindIPN,
+				Body: `{"customer":{"name":"JOHN DOE","mobileNumber":"254712345678"},` +
+					`"transaction":{"reference":"741852964","amount":80,"currency":"KES"}}`,
+				Want: provider.Callback{
+					Kind:          provider.CallbackPayment,
+					Status:        commonv1.STATUS_UNKNOWN,
+					TransactionID: "741852964",
+					Amount:        decimal.NewFromInt(80),
+					Currency:      "KES",
+					PayerMSISDN:   "254712345678",
+				},
+			},
+			{
+				// Amounts are read exactly whether Jenga sends them as numbers or strings
+				Name: "till with a large amount",
+				Kind: jenga.CallbackKindTills,
+				Body: `{"customer":{"name":"JOHN DOE","mobileNumber":"254712345678"},` +
+					`"transaction":{"reference":"852963741","amount":"12345678901234567.89","currency":"KES",` +
+					`"status":"SUCCESS"}}`,
+				Want: provider.Callback{
+					Kind:          provider.CallbackPayment,
+					Status:        commonv1.STATUS_SUCCESSFUL,
+					TransactionID: "852963741",
+					Amount:        decimal.RequireFromString("12345678901234567.89"),
+					Currency:      "KES",
+					PayerName:     "JOHN DOE",
+					PayerMSISDN:   "254712345678",
+				},
+			},
+			{
+				Name: "payment link",
+				Kind: jenga.CallbackKindPaymentLink,
+				Body: `{"paymentLinkRef":"PL7Q2X","customer":{"name":"JANE DOE","mobileNumber":"254722000000"},` +
+					`"transaction":{"reference":"963852741","amount":1000,"currency":"KES","status":"FAILED"}}`,
+				Want: provider.Callback{
+					Kind:           provider.CallbackPayment,
+					Status:         commonv1.STATUS_FAILED,
+					TransactionID:  "963852741",
+					Amount:         decimal.NewFromInt(1000),
+					Currency:       "KES",
+					PayerMSISDN:    "254722000000",
+					PaymentLinkRef: "PL7Q2X",
+				},
+			},
+		},
+	})
+}
+
+func TestProviderBalance(t *testing.T) {
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "service-token", "token_type": "bearer"})
+	}))
+	defer tokens.Close()
+
+	transport := &fakeTransport{published: make(map[string]int)}
+	p := newProvider(transport)
+	p.Credentials = utility.NewServiceCredentials(t.Context(), tokens.URL, "service_payments", "secret")
+
+	balance, err := p.Balance(t.Context(), &models.Account{AccountNumber: "1100161816677", CountryCode: "KE"})
+	if err != nil {
+		t.Fatalf("Balance() error = %v", err)
+	}
+	want := decimal.RequireFromString("997382.57")
+	if !balance.Available.Equal(want) || !balance.Current.Equal(want) {
+		t.Errorf("Balance() = %s available, %s current, want %s", balance.Available, balance.Current, want)
+	}
+	if balance.Currency != "KES" {
+		t.Errorf("Balance() currency = %q, want KES", balance.Currency)
+	}
+	// The integration only shows balances to authenticated services
+	if got := transport.headers["Authorization"]; len(got) != 1 || got[0] != "Bearer service-token" {
+		t.Errorf("Balance() authorization = %v, want the service token", got)
+	}
+}
+
+func TestProviderPublishesToConfiguredTopics(t *testing.T) {
+	transport := &fakeTransport{published: make(map[string]int)}
+	p := newProvider(transport)
+
+	prompt := &models.Prompt{
+		Amount: decimal.NullDecimal{Valid: true, Decimal: decimal.NewFromInt(100)},
+		Extra:  map[string]any{"transaction_ref": "A12345"},
+	}
+	prompt.ID = "prompt-1"
+
+	if err := p.InitiatePrompt(t.Context(), prompt); err != nil {
+		t.Fatalf("InitiatePrompt() error = %v", err)
+	}
+	if err := p.QueryStatus(t.Context(), prompt); err != nil {
+		t.Fatalf("QueryStatus() error = %v", err)
+	}
+
+	for _, topic := range []string{"initiate.prompt", "prompt.status.query"} {
+		if transport.published[topic] != 1 {
+			t.Errorf("published %d messages to %s, want 1", transport.published[topic], topic)
+		}
+	}
+}
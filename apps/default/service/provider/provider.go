@@ -0,0 +1,76 @@
+// Package provider decouples the payment service from the payment service providers (PSPs)
+// that move money on its behalf. Each PSP is reached through a Provider adapter, adapters are
+// looked up in a Registry by the counter id of the route a payment or prompt is sent on.
+package provider
+
+import (
+	"context"
+	"errors"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrNotSupported is returned by adapters for operations their PSP does not offer.
+	ErrNotSupported = errors.New("operation is not supported by the provider")
+	// ErrUnknownProvider is returned when no adapter is registered for a counter.
+	ErrUnknownProvider = errors.New("no provider registered for counter")
+	// ErrUnknownRoute is returned when a route a payment or prompt is sent on does not exist.
+	ErrUnknownRoute = errors.New("route is not known")
+	// ErrUnknownCallback is returned when an adapter does not recognise a callback kind.
+	ErrUnknownCallback = errors.New("callback kind is not recognised by the provider")
+)
+
+// Callback kinds understood across providers.
+const (
+	// CallbackPayment reports money received, from a prompt, a till, a link or a direct deposit.
+	CallbackPayment = "payment"
+	// CallbackPrompt reports the outcome of a prompt that did not result in a payment.
+	CallbackPrompt = "prompt"
+)
+
+// Provider is implemented by every PSP adapter. Operations are asynchronous, their outcome is
+// reported back to the payment service as status updates or received payments.
+type Provider interface {
+	// Name identifies the PSP in logs and status records.
+	Name() string
+	// InitiatePrompt asks the payer to authorise a collection, e.g. an STK push.
+	InitiatePrompt(ctx context.Context, prompt *models.Prompt) error
+	// QueryStatus asks the PSP for the outcome of a prompt that has not been answered.
+	QueryStatus(ctx context.Context, prompt *models.Prompt) error
+	// Payout sends money out to the payment's recipient.
+	Payout(ctx context.Context, payment *models.Payment) error
+	// CreatePaymentLink creates a shareable link customers can pay against.
+	CreatePaymentLink(ctx context.Context, link *models.PaymentLink) error
+	// UpdatePaymentLink keeps the PSP in step with a link changed after creation.
+	UpdatePaymentLink(ctx context.Context, change *models.PaymentLinkChange) error
+	// Balance returns the balance the PSP holds for an account.
+	Balance(ctx context.Context, account *models.Account) (*Balance, error)
+	// ParseCallback turns a PSP callback body into a provider neutral Callback.
+	ParseCallback(ctx context.Context, kind string, body []byte) (*Callback, error)
+}
+
+// Balance is an account balance reported by a PSP.
+type Balance struct {
+	AccountNumber string          `json:"accountNumber"`
+	Currency      string          `json:"currency"`
+	Available     decimal.Decimal `json:"available"`
+	Current       decimal.Decimal `json:"current"`
+}
+
+// Callback is a PSP notification in provider neutral form.
+type Callback struct {
+	Kind           string
+	Status         commonv1.STATUS
+	TransactionRef string
+	TransactionID  string
+	Amount         decimal.Decimal
+	Currency       string
+	PayerName      string
+	PayerMSISDN    string
+	PaymentLinkRef string
+	Message        string
+	Extra          map[string]string
+}
@@ -0,0 +1,244 @@
+// Package providertest holds the conformance suite every provider adapter must pass. Adapter
+// packages call Run from their own tests with the adapter wired to test doubles.
+package providertest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/provider"
+	"github.com/shopspring/decimal"
+	"gorm.io/datatypes"
+)
+
+// Operation names a provider operation in a Fixture.
+type Operation string
+
+const (
+	InitiatePrompt    Operation = "InitiatePrompt"
+	QueryStatus       Operation = "QueryStatus"
+	Payout            Operation = "Payout"
+	CreatePaymentLink Operation = "CreatePaymentLink"
+	UpdatePaymentLink Operation = "UpdatePaymentLink"
+	Balance           Operation = "Balance"
+)
+
+// Fixture describes the adapter under test.
+type Fixture struct {
+	// New returns the adapter wired to test doubles that accept every request.
+	New func(t *testing.T) provider.Provider
+	// Unsupported lists the operations the PSP does not offer.
+	Unsupported []Operation
+	// Callbacks are sample callbacks of every kind the adapter understands.
+	Callbacks []CallbackCase
+}
+
+// CallbackCase is a raw callback and the neutral callback it must parse to.
+type CallbackCase struct {
+	Name string
+	Kind string
+	Body string
+	Want provider.Callback
+}
+
+// Run checks that an adapter honours the provider contract.
+func Run(t *testing.T, fixture Fixture) {
+	t.Helper()
+	if fixture.New == nil {
+		t.Fatal("fixture must construct the provider")
+	}
+
+	t.Run("Name", func(t *testing.T) {
+		if fixture.New(t).Name() == "" {
+			t.Error("provider name is empty")
+		}
+	})
+
+	t.Run("Operations", func(t *testing.T) {
+		for op, call := range operations() {
+			t.Run(string(op), func(t *testing.T) {
+				err := call(t.Context(), fixture.New(t), true)
+				if fixture.unsupported(op) {
+					if !errors.Is(err, provider.ErrNotSupported) {
+						t.Errorf("unsupported operation returned %v, want ErrNotSupported", err)
+					}
+					return
+				}
+				if err != nil {
+					t.Errorf("complete request failed: %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("RejectsIncompleteRequests", func(t *testing.T) {
+		for op, call := range operations() {
+			t.Run(string(op), func(t *testing.T) {
+				if call(t.Context(), fixture.New(t), false) == nil {
+					t.Error("incomplete request was accepted")
+				}
+			})
+		}
+	})
+
+	t.Run("Callbacks", func(t *testing.T) {
+		if len(fixture.Callbacks) == 0 {
+			t.Fatal("every provider must understand at least one callback")
+		}
+
+		for _, tc := range fixture.Callbacks {
+			t.Run(tc.Name, func(t *testing.T) {
+				got, err := fixture.New(t).ParseCallback(t.Context(), tc.Kind, []byte(tc.Body))
+				if err != nil {
+					t.Fatalf("ParseCallback() error = %v", err)
+				}
+				checkCallback(t, tc.Want, got)
+			})
+		}
+	})
+
+	t.Run("MalformedCallbacks", func(t *testing.T) {
+		p := fixture.New(t)
+		for _, tc := range fixture.Callbacks {
+			if _, err := p.ParseCallback(t.Context(), tc.Kind, []byte(`{`)); err == nil {
+				t.Errorf("%s: truncated body was accepted", tc.Name)
+			}
+			if _, err := p.ParseCallback(t.Context(), tc.Kind, []byte(`{}`)); err == nil {
+				t.Errorf("%s: callback without a reference was accepted", tc.Name)
+			}
+		}
+
+		if _, err := p.ParseCallback(t.Context(), "no-such-kind", []byte(`{}`)); !errors.Is(err, provider.ErrUnknownCallback) {
+			t.Errorf("unknown callback kind returned %v, want ErrUnknownCallback", err)
+		}
+	})
+}
+
+func (f Fixture) unsupported(op Operation) bool {
+	for _, unsupported := range f.Unsupported {
+		if unsupported == op {
+			return true
+		}
+	}
+	return false
+}
+
+type operation func(ctx context.Context, p provider.Provider, complete bool) error
+
+// operations calls each provider operation with a complete request, or with an empty one.
+func operations() map[Operation]operation {
+	return map[Operation]operation{
+		InitiatePrompt: func(ctx context.Context, p provider.Provider, complete bool) error {
+			if !complete {
+				return p.InitiatePrompt(ctx, &models.Prompt{})
+			}
+			return p.InitiatePrompt(ctx, samplePrompt())
+		},
+		QueryStatus: func(ctx context.Context, p provider.Provider, complete bool) error {
+			if !complete {
+				return p.QueryStatus(ctx, &models.Prompt{})
+			}
+			return p.QueryStatus(ctx, samplePrompt())
+		},
+		Payout: func(ctx context.Context, p provider.Provider, complete bool) error {
+			if !complete {
+				return p.Payout(ctx, &models.Payment{})
+			}
+			return p.Payout(ctx, samplePayment())
+		},
+		CreatePaymentLink: func(ctx context.Context, p provider.Provider, complete bool) error {
+			if !complete {
+				return p.CreatePaymentLink(ctx, &models.PaymentLink{})
+			}
+			return p.CreatePaymentLink(ctx, samplePaymentLink())
+		},
+		UpdatePaymentLink: func(ctx context.Context, p provider.Provider, complete bool) error {
+			if !complete {
+				return p.UpdatePaymentLink(ctx, &models.PaymentLinkChange{})
+			}
+			return p.UpdatePaymentLink(ctx, &models.PaymentLinkChange{
+				Action:      models.PaymentLinkActionDeactivate,
+				PaymentLink: samplePaymentLink(),
+			})
+		},
+		Balance: func(ctx context.Context, p provider.Provider, complete bool) error {
+			if !complete {
+				_, err := p.Balance(ctx, &models.Account{})
+				return err
+			}
+			balance, err := p.Balance(ctx, &models.Account{AccountNumber: "1100161816677", CountryCode: "KE"})
+			if err == nil && balance == nil {
+				return errors.New("balance returned neither a balance nor an error")
+			}
+			return err
+		},
+	}
+}
+
+func samplePrompt() *models.Prompt {
+	prompt := &models.Prompt{
+		Amount: decimal.NullDecimal{Valid: true, Decimal: decimal.NewFromInt(100)},
+		Extra: datatypes.JSONMap{
+			"transaction_ref": "A12345",
+			"currency":        "KES",
+			"mobile_number":   "254712345678",
+		},
+	}
+	prompt.ID = "prompt-1"
+	return prompt
+}
+
+func samplePayment() *models.Payment {
+	payment := &models.Payment{
+		OutBound: true,
+		Amount:   decimal.NullDecimal{Valid: true, Decimal: decimal.NewFromInt(100)},
+		Currency: "KES",
+	}
+	payment.ID = "payment-1"
+	return payment
+}
+
+func samplePaymentLink() *models.PaymentLink {
+	link := &models.PaymentLink{
+		Name:         "Invoice 1",
+		AmountOption: models.AmountOptionRestricted,
+		Amount:       decimal.NewFromInt(100),
+		Currency:     "KES",
+	}
+	link.ID = "link-1"
+	return link
+}
+
+func checkCallback(t *testing.T, want provider.Callback, got *provider.Callback) {
+	t.Helper()
+	if got == nil {
+		t.Fatal("ParseCallback() returned no callback")
+	}
+
+	if got.Kind != want.Kind {
+		t.Errorf("kind = %q, want %q", got.Kind, want.Kind)
+	}
+	if got.Status != want.Status {
+		t.Errorf("status = %v, want %v", got.Status, want.Status)
+	}
+	if got.TransactionRef != want.TransactionRef {
+		t.Errorf("transaction reference = %q, want %q", got.TransactionRef, want.TransactionRef)
+	}
+	if got.TransactionID != want.TransactionID {
+		t.Errorf("transaction id = %q, want %q", got.TransactionID, want.TransactionID)
+	}
+	if !got.Amount.Equal(want.Amount) {
+		t.Errorf("amount = %s, want %s", got.Amount, want.Amount)
+	}
+	if got.Currency != want.Currency {
+		t.Errorf("currency = %q, want %q", got.Currency, want.Currency)
+	}
+	if got.PayerMSISDN != want.PayerMSISDN {
+		t.Errorf("payer msisdn = %q, want %q", got.PayerMSISDN, want.PayerMSISDN)
+	}
+	if got.PaymentLinkRef != want.PaymentLinkRef {
+		t.Errorf("payment link reference = %q, want %q", got.PaymentLinkRef, want.PaymentLinkRef)
+	}
+}
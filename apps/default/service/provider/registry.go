@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/antinvestor/service-payments/service/repository"
+	"github.com/pitabwire/frame"
+	"gorm.io/gorm"
+)
+
+// Registry holds the provider adapters keyed by the counter id of the routes they serve.
+// Traffic without a route is served by the default provider.
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+	defaultID string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{providers: make(map[string]Provider)}
+}
+
+// Register adds the adapter serving a counter, the first adapter registered becomes the default.
+func (r *Registry) Register(counterID string, p Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.providers) == 0 {
+		r.defaultID = counterID
+	}
+	r.providers[counterID] = p
+}
+
+// SetDefault selects the adapter used for counters without one of their own.
+func (r *Registry) SetDefault(counterID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.defaultID = counterID
+}
+
+// Get returns the adapter serving a counter, falling back to the default provider.
+func (r *Registry) Get(counterID string) (Provider, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if p, ok := r.providers[counterID]; ok {
+		return p, nil
+	}
+	if p, ok := r.providers[r.defaultID]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, counterID)
+}
+
+// Lookup returns the adapter registered for a counter without falling back to the default.
+func (r *Registry) Lookup(counterID string) (Provider, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if p, ok := r.providers[counterID]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, counterID)
+}
+
+// Named returns the adapter of the provider with the name given, for payments that name the
+// integration paying them instead of a route.
+func (r *Registry) Named(name string) (Provider, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, p := range r.providers {
+		if p.Name() == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, name)
+}
+
+// ForRoute returns the adapter serving the counter of a route. Traffic without a route goes to
+// the default provider, a route that is not known or whose counter has no adapter is an error.
+func (r *Registry) ForRoute(ctx context.Context, service *frame.Service, routeID string) (Provider, error) {
+	counterID, err := r.CounterForRoute(ctx, service, routeID)
+	if err != nil {
+		return nil, err
+	}
+	return r.Lookup(counterID)
+}
+
+// CounterForRoute returns the counter whose adapter serves a route, resolving routes the
+// way ForRoute does.
+func (r *Registry) CounterForRoute(ctx context.Context, service *frame.Service, routeID string) (string, error) {
+	if routeID == "" {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		if _, ok := r.providers[r.defaultID]; !ok {
+			return "", fmt.Errorf("%w: no default provider", ErrUnknownProvider)
+		}
+		return r.defaultID, nil
+	}
+
+	route, err := repository.NewRouteRepository(ctx, service).GetByID(ctx, routeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", fmt.Errorf("%w: %q", ErrUnknownRoute, routeID)
+		}
+		return "", err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.providers[route.CounterID]; !ok {
+		return "", fmt.Errorf("%w: %q on route %q", ErrUnknownProvider, route.CounterID, routeID)
+	}
+	return route.CounterID, nil
+}
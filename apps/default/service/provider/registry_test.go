@@ -0,0 +1,99 @@
+package provider_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/provider"
+	"github.com/antinvestor/service-payments/service/provider/jenga"
+	"github.com/antinvestor/service-payments/service/repository"
+	"github.com/antinvestor/service-payments/service/repository/repositorytest"
+)
+
+func TestRegistryFallsBackToDefault(t *testing.T) {
+	registry := provider.NewRegistry()
+	if _, err := registry.Get("jenga"); !errors.Is(err, provider.ErrUnknownProvider) {
+		t.Fatalf("Get() on an empty registry returned %v, want ErrUnknownProvider", err)
+	}
+
+	first := &jenga.Provider{PromptTopic: "first"}
+	second := &jenga.Provider{PromptTopic: "second"}
+	registry.Register("jenga", first)
+	registry.Register("other", second)
+
+	tests := []struct {
+		counterID string
+		want      provider.Provider
+	}{
+		{counterID: "jenga", want: first},
+		{counterID: "other", want: second},
+		{counterID: "", want: first},
+		{counterID: "unregistered", want: first},
+	}
+	for _, tt := range tests {
+		got, err := registry.Get(tt.counterID)
+		if err != nil || got != tt.want {
+			t.Errorf("Get(%q) = %v, %v", tt.counterID, got, err)
+		}
+	}
+
+	registry.SetDefault("other")
+	if got, _ := registry.Get("unregistered"); got != second {
+		t.Errorf("Get() after SetDefault did not return the new default")
+	}
+
+	if _, err := registry.Lookup("unregistered"); !errors.Is(err, provider.ErrUnknownProvider) {
+		t.Errorf("Lookup() returned %v, want ErrUnknownProvider", err)
+	}
+
+	if got, err := registry.Named("jenga"); err != nil || got.Name() != "jenga" {
+		t.Errorf("Named(jenga) = %v, %v", got, err)
+	}
+	if _, err := registry.Named("daraja"); !errors.Is(err, provider.ErrUnknownProvider) {
+		t.Errorf("Named(daraja) returned %v, want ErrUnknownProvider", err)
+	}
+}
+
+func TestRegistryForRoute(t *testing.T) {
+	ctx, service := repositorytest.NewService(t, nil)
+
+	registered := &models.Route{CounterID: "jenga", Name: "jenga", Mode: "transact"}
+	unregistered := &models.Route{CounterID: "unregistered", Name: "unregistered", Mode: "transact"}
+	routes := repository.NewRouteRepository(ctx, service)
+	for _, route := range []*models.Route{registered, unregistered} {
+		route.GenID(ctx)
+		if err := routes.Save(ctx, route); err != nil {
+			t.Fatalf("Save() route error = %v", err)
+		}
+	}
+
+	defaultProvider := &jenga.Provider{PromptTopic: "default"}
+	registry := provider.NewRegistry()
+	registry.Register("jenga", defaultProvider)
+
+	tests := []struct {
+		name    string
+		routeID string
+		wantErr error
+	}{
+		{name: "no route", routeID: ""},
+		{name: "registered counter", routeID: registered.GetID()},
+		{name: "unregistered counter", routeID: unregistered.GetID(), wantErr: provider.ErrUnknownProvider},
+		{name: "unknown route", routeID: "missing-route", wantErr: provider.ErrUnknownRoute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := registry.ForRoute(ctx, service, tt.routeID)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ForRoute() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != defaultProvider {
+				t.Errorf("ForRoute() = %v, %v", got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Package reference describes the transaction references providers accept and turns the
+// numbers of a database sequence into them. Every sequence number maps to a distinct
+// reference of the format, so references drawn from one sequence never collide.
+package reference
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidFormat is returned for format specifications that cannot be parsed.
+	ErrInvalidFormat = errors.New("invalid reference format")
+	// ErrExhausted is returned once a sequence has issued every reference its format allows.
+	ErrExhausted = errors.New("reference format has no references left")
+)
+
+// DefaultFormat is used on counters no format was configured for, ten characters fit the
+// reference fields of the providers the service works with.
+const DefaultFormat = "alnum:10"
+
+// Character sets references can be written in.
+const (
+	Digits       = "digits"
+	Letters      = "letters"
+	Alphanumeric = "alnum"
+)
+
+var charsets = map[string]string{
+	Digits:       "0123456789",
+	Letters:      "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	Alphanumeric: "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+}
+
+// scramble is a prime that spreads consecutive sequence numbers over the whole format, so
+// references do not reveal how many were issued before them. It shares no factor with the
+// capacity of any format, which keeps the mapping one to one.
+const scramble = 2654435761
+
+// Format is the shape of the references a provider accepts: a fixed prefix followed by
+// Length characters from the charset.
+type Format struct {
+	Charset string
+	Length  int
+	Prefix  string
+
+	alphabet string
+	capacity uint64
+}
+
+// ParseFormat reads a "charset:length[:prefix]" specification, e.g. "alnum:12" or "digits:8:PR".
+func ParseFormat(spec string) (Format, error) {
+	parts := strings.Split(strings.TrimSpace(spec), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Format{}, fmt.Errorf("%w: %q, expected charset:length[:prefix]", ErrInvalidFormat, spec)
+	}
+
+	alphabet, ok := charsets[parts[0]]
+	if !ok {
+		return Format{}, fmt.Errorf("%w: unknown charset %q", ErrInvalidFormat, parts[0])
+	}
+	length, err := strconv.Atoi(parts[1])
+	if err != nil || length <= 0 {
+		return Format{}, fmt.Errorf("%w: invalid length %q", ErrInvalidFormat, parts[1])
+	}
+
+	format := Format{Charset: parts[0], Length: length, alphabet: alphabet, capacity: 1}
+	if len(parts) == 3 {
+		format.Prefix = parts[2]
+	}
+	for range length {
+		hi, lo := bits.Mul64(format.capacity, uint64(len(alphabet)))
+		if hi != 0 {
+			return Format{}, fmt.Errorf("%w: %d %s characters do not fit a 64 bit sequence", ErrInvalidFormat,
+				length, parts[0])
+		}
+		format.capacity = lo
+	}
+	return format, nil
+}
+
+// MustParseFormat is ParseFormat for specifications known to be valid.
+func MustParseFormat(spec string) Format {
+	format, err := ParseFormat(spec)
+	if err != nil {
+		panic(err)
+	}
+	return format
+}
+
+// String returns the specification the format was parsed from.
+func (f Format) String() string {
+	spec := f.Charset + ":" + strconv.Itoa(f.Length)
+	if f.Prefix != "" {
+		spec += ":" + f.Prefix
+	}
+	return spec
+}
+
+// Capacity is the number of distinct references the format can express.
+func (f Format) Capacity() uint64 {
+	return f.capacity
+}
+
+// Encode returns the reference for a sequence number. Sequence numbers below the capacity
+// map to distinct references.
+func (f Format) Encode(sequence int64) (string, error) {
+	if f.capacity == 0 {
+		return "", fmt.Errorf("%w: format was not parsed", ErrInvalidFormat)
+	}
+	if sequence < 0 || uint64(sequence) >= f.capacity {
+		return "", fmt.Errorf("%w: %s", ErrExhausted, f)
+	}
+
+	hi, lo := bits.Mul64(uint64(sequence), scramble)
+	value := bits.Rem64(hi, lo, f.capacity)
+
+	base := uint64(len(f.alphabet))
+	encoded := make([]byte, f.Length)
+	for i := f.Length - 1; i >= 0; i-- {
+		encoded[i] = f.alphabet[value%base]
+		value /= base
+	}
+	return f.Prefix + string(encoded), nil
+}
+
+// Formats holds the reference format of each counter, counters without one use Default.
+type Formats struct {
+	Default   Format
+	byCounter map[string]Format
+}
+
+// ParseFormats reads a comma separated list of "counter=charset:length[:prefix]" entries.
+// An entry without a counter replaces the default format.
+func ParseFormats(spec string, defaultFormat Format) (*Formats, error) {
+	formats := &Formats{Default: defaultFormat, byCounter: make(map[string]Format)}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		counter, formatSpec, found := strings.Cut(entry, "=")
+		if !found {
+			counter, formatSpec = "", entry
+		}
+		format, err := ParseFormat(formatSpec)
+		if err != nil {
+			return nil, err
+		}
+
+		if counter = strings.TrimSpace(counter); counter == "" {
+			formats.Default = format
+			continue
+		}
+		formats.byCounter[counter] = format
+	}
+	return formats, nil
+}
+
+// For returns the format references issued for a counter are written in.
+func (f *Formats) For(counterID string) Format {
+	if format, ok := f.byCounter[counterID]; ok {
+		return format
+	}
+	return f.Default
+}
@@ -0,0 +1,95 @@
+package reference
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		spec         string
+		wantCapacity uint64
+		wantErr      bool
+	}{
+		{spec: "digits:6", wantCapacity: 1_000_000},
+		{spec: "letters:2:PR", wantCapacity: 676},
+		{spec: "alnum:12", wantCapacity: 4_738_381_338_321_616_896},
+		{spec: "digits:19", wantCapacity: 10_000_000_000_000_000_000},
+		{spec: "digits:20", wantErr: true},
+		{spec: "alnum:13", wantErr: true},
+		{spec: "hex:6", wantErr: true},
+		{spec: "digits:0", wantErr: true},
+		{spec: "digits", wantErr: true},
+		{spec: "digits:6:A:B", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			format, err := ParseFormat(tt.spec)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidFormat) {
+					t.Fatalf("ParseFormat(%q) error = %v, want ErrInvalidFormat", tt.spec, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFormat(%q) error = %v", tt.spec, err)
+			}
+			if format.Capacity() != tt.wantCapacity || format.String() != tt.spec {
+				t.Fatalf("ParseFormat(%q) = %s with capacity %d, want capacity %d", tt.spec, format,
+					format.Capacity(), tt.wantCapacity)
+			}
+		})
+	}
+}
+
+func TestEncodeIsUniqueAcrossTheFormat(t *testing.T) {
+	format := MustParseFormat("letters:2:PR")
+
+	seen := make(map[string]int64, format.Capacity())
+	for sequence := range int64(format.Capacity()) {
+		reference, err := format.Encode(sequence)
+		if err != nil {
+			t.Fatalf("Encode(%d) error = %v", sequence, err)
+		}
+		if len(reference) != 4 || !strings.HasPrefix(reference, "PR") {
+			t.Fatalf("Encode(%d) = %q, want PR and two letters", sequence, reference)
+		}
+		if previous, ok := seen[reference]; ok {
+			t.Fatalf("Encode(%d) = %q, already issued for %d", sequence, reference, previous)
+		}
+		seen[reference] = sequence
+	}
+
+	if _, err := format.Encode(int64(format.Capacity())); !errors.Is(err, ErrExhausted) {
+		t.Fatalf("Encode past the capacity error = %v, want ErrExhausted", err)
+	}
+}
+
+func TestEncodeDoesNotRevealTheSequence(t *testing.T) {
+	format := MustParseFormat("digits:8")
+
+	first, _ := format.Encode(1)
+	second, _ := format.Encode(2)
+	if first == "00000001" || second == "00000002" {
+		t.Fatalf("Encode(1), Encode(2) = %s, %s, want scrambled references", first, second)
+	}
+}
+
+func TestParseFormats(t *testing.T) {
+	formats, err := ParseFormats("jenga=alnum:6, mpesa=alnum:12:AI ,digits:10", MustParseFormat("alnum:8"))
+	if err != nil {
+		t.Fatalf("ParseFormats() error = %v", err)
+	}
+
+	for counter, want := range map[string]string{"jenga": "alnum:6", "mpesa": "alnum:12:AI", "other": "digits:10"} {
+		if got := formats.For(counter).String(); got != want {
+			t.Fatalf("For(%q) = %s, want %s", counter, got, want)
+		}
+	}
+
+	if _, err = ParseFormats("jenga=alnum", Format{}); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("ParseFormats() error = %v, want ErrInvalidFormat", err)
+	}
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 
+	"github.com/antinvestor/service-payments/service/tenancy"
 	"gorm.io/gorm"
 
 	"github.com/pitabwire/frame"
@@ -13,9 +14,9 @@ type abstractRepository struct {
 }
 
 func (ar *abstractRepository) readDB(ctx context.Context) *gorm.DB {
-	return ar.service.DB(ctx, true)
+	return tenancy.DB(ctx, ar.service, true)
 }
 
 func (ar *abstractRepository) writeDB(ctx context.Context) *gorm.DB {
-	return ar.service.DB(ctx, true)
+	return tenancy.DB(ctx, ar.service, false)
 }
@@ -3,14 +3,20 @@ package repository
 import (
 	"context"
 
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
 	"github.com/antinvestor/service-payments/service/models"
+	"gorm.io/gorm/clause"
 
 	"github.com/pitabwire/frame"
 )
 
 type AccountRepository interface {
 	GetByID(ctx context.Context, id string) (*models.Account, error)
-	GetByAccountNumber(ctx context.Context, accountNumber string) (*models.Account, error)
+	GetByPartitionAndID(ctx context.Context, partitionID string, id string) (*models.Account, error)
+	GetByAccountNumber(ctx context.Context, partitionID string, accountNumber string) (*models.Account, error)
+	GetRegistered(ctx context.Context, partitionID, counterID, accountNumber string) (*models.Account, error)
+	ListByPartition(ctx context.Context, partitionID string) ([]*models.Account, error)
+	Register(ctx context.Context, account *models.Account) (bool, error)
 	Save(ctx context.Context, account *models.Account) error
 }
 
@@ -31,15 +37,81 @@ func (repo *accountRepository) GetByID(ctx context.Context, id string) (*models.
 	return &account, nil
 }
 
-func (repo *accountRepository) GetByAccountNumber(ctx context.Context, accountNumber string) (*models.Account, error) {
+func (repo *accountRepository) GetByPartitionAndID(
+	ctx context.Context,
+	partitionID string,
+	id string,
+) (*models.Account, error) {
 	account := models.Account{}
-	err := repo.readDB(ctx).First(&account, "account_number = ? ", accountNumber).Error
+	err := repo.readDB(ctx).First(&account, "partition_id = ? AND id = ?", partitionID, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &account, nil
 }
 
+// GetByAccountNumber finds an account of the partition by its number, registered accounts
+// are preferred over the ones recorded implicitly for prompts.
+func (repo *accountRepository) GetByAccountNumber(
+	ctx context.Context,
+	partitionID string,
+	accountNumber string,
+) (*models.Account, error) {
+	account := models.Account{}
+	registeredFirst := clause.OrderBy{Expression: clause.Expr{
+		SQL:  "state = ? DESC",
+		Vars: []any{int32(commonv1.STATE_ACTIVE)},
+	}}
+	err := repo.readDB(ctx).Order(registeredFirst).
+		First(&account, "partition_id = ? AND account_number = ?", partitionID, accountNumber).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetRegistered finds the account registered for a number with a provider in a partition.
+func (repo *accountRepository) GetRegistered(
+	ctx context.Context,
+	partitionID, counterID, accountNumber string,
+) (*models.Account, error) {
+	account := models.Account{}
+	err := repo.readDB(ctx).First(&account,
+		"partition_id = ? AND counter_id = ? AND account_number = ?", partitionID, counterID, accountNumber).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// ListByPartition returns the accounts registered in a partition, most recent first.
+func (repo *accountRepository) ListByPartition(ctx context.Context, partitionID string) ([]*models.Account, error) {
+	var accounts []*models.Account
+	err := repo.readDB(ctx).
+		Where("partition_id = ? AND counter_id <> ''", partitionID).
+		Order("created_at DESC").
+		Find(&accounts).Error
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// Register records a new registered account unless its number is already registered with the
+// provider in the partition, and reports whether it was recorded. Concurrent registrations are
+// settled by the unique index on registered accounts.
+func (repo *accountRepository) Register(ctx context.Context, account *models.Account) (bool, error) {
+	result := repo.writeDB(ctx).Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "partition_id"}, {Name: "counter_id"}, {Name: "account_number"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "counter_id <> ''"}}},
+		DoNothing:   true,
+	}).Create(account)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
 func (repo *accountRepository) Save(ctx context.Context, account *models.Account) error {
 	return repo.writeDB(ctx).Save(account).Error
 }
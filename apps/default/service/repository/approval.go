@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/service/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/pitabwire/frame"
+)
+
+type ApprovalRepository interface {
+	ListActivePolicies(ctx context.Context) ([]*models.ApprovalPolicy, error)
+	GetPolicyByID(ctx context.Context, id string) (*models.ApprovalPolicy, error)
+	SavePolicy(ctx context.Context, policy *models.ApprovalPolicy) error
+	GetByPaymentID(ctx context.Context, paymentID string) (*models.Approval, error)
+	ListPending(ctx context.Context) ([]*models.Approval, error)
+	ListDecisions(ctx context.Context, approvalID string) ([]*models.ApprovalDecision, error)
+	Open(ctx context.Context, approval *models.Approval) (*models.Approval, error)
+	Decide(
+		ctx context.Context,
+		approvalID string,
+		decide func(approval *models.Approval, decisions []*models.ApprovalDecision) (*models.ApprovalDecision, error),
+	) (*models.Approval, error)
+}
+
+type approvalRepository struct {
+	abstractRepository
+}
+
+func NewApprovalRepository(_ context.Context, service *frame.Service) ApprovalRepository {
+	return &approvalRepository{abstractRepository{service: service}}
+}
+
+// ListActivePolicies returns the approval policies in force, confined to the caller's partition.
+func (repo *approvalRepository) ListActivePolicies(ctx context.Context) ([]*models.ApprovalPolicy, error) {
+	var policies []*models.ApprovalPolicy
+	err := repo.readDB(ctx).
+		Where("state = ?", int32(commonv1.STATE_ACTIVE)).
+		Order("threshold").
+		Find(&policies).Error
+	if err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (repo *approvalRepository) GetPolicyByID(ctx context.Context, id string) (*models.ApprovalPolicy, error) {
+	policy := models.ApprovalPolicy{}
+	err := repo.readDB(ctx).First(&policy, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (repo *approvalRepository) SavePolicy(ctx context.Context, policy *models.ApprovalPolicy) error {
+	return repo.writeDB(ctx).Save(policy).Error
+}
+
+func (repo *approvalRepository) GetByPaymentID(ctx context.Context, paymentID string) (*models.Approval, error) {
+	approval := models.Approval{}
+	err := repo.readDB(ctx).First(&approval, "payment_id = ?", paymentID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &approval, nil
+}
+
+// ListPending returns the approvals still waiting on approvers, oldest first.
+func (repo *approvalRepository) ListPending(ctx context.Context) ([]*models.Approval, error) {
+	var approvals []*models.Approval
+	err := repo.readDB(ctx).
+		Where("state = ?", models.ApprovalPending).
+		Order("created_at").
+		Find(&approvals).Error
+	if err != nil {
+		return nil, err
+	}
+	return approvals, nil
+}
+
+// ListDecisions returns the decisions taken on an approval in the order they were taken.
+func (repo *approvalRepository) ListDecisions(
+	ctx context.Context,
+	approvalID string,
+) ([]*models.ApprovalDecision, error) {
+	var decisions []*models.ApprovalDecision
+	err := repo.readDB(ctx).
+		Where("approval_id = ?", approvalID).
+		Order("created_at").
+		Find(&decisions).Error
+	if err != nil {
+		return nil, err
+	}
+	return decisions, nil
+}
+
+// Open records an approval for its payment, or returns the one already opened for it.
+func (repo *approvalRepository) Open(ctx context.Context, approval *models.Approval) (*models.Approval, error) {
+	result := repo.writeDB(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "payment_id"}}, DoNothing: true}).
+		Create(approval)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repo.GetByPaymentID(ctx, approval.PaymentID)
+	}
+	return approval, nil
+}
+
+// Decide locks an approval while decide weighs a decision against it and the decisions taken
+// before, then records the decision and the approval's new tally together.
+func (repo *approvalRepository) Decide(
+	ctx context.Context,
+	approvalID string,
+	decide func(approval *models.Approval, decisions []*models.ApprovalDecision) (*models.ApprovalDecision, error),
+) (*models.Approval, error) {
+	approval := &models.Approval{}
+	err := repo.writeDB(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(approval, "id = ?", approvalID).Error
+		if err != nil {
+			return err
+		}
+
+		var decisions []*models.ApprovalDecision
+		err = tx.Where("approval_id = ?", approvalID).Order("created_at").Find(&decisions).Error
+		if err != nil {
+			return err
+		}
+
+		decision, err := decide(approval, decisions)
+		if err != nil {
+			return err
+		}
+		if err = tx.Create(decision).Error; err != nil {
+			return err
+		}
+		approval.Decisions = append(decisions, decision)
+		return tx.Save(approval).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return approval, nil
+}
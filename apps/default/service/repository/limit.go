@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/antinvestor/service-payments/service/limits"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/pitabwire/frame"
+)
+
+// errLimitBreached rolls back the counters of a transaction that broke a rule.
+var errLimitBreached = errors.New("limit breached")
+
+type LimitRepository interface {
+	Consume(ctx context.Context, entityID string, checks []limits.Check, amount decimal.Decimal) (*limits.Rule, error)
+	Release(ctx context.Context, entityID string) (bool, error)
+	PurgeExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type limitRepository struct {
+	abstractRepository
+}
+
+func NewLimitRepository(_ context.Context, service *frame.Service) LimitRepository {
+	return &limitRepository{abstractRepository{service: service}}
+}
+
+// Consume counts a transaction against the counters of its checks in one transaction. Each
+// counter only moves while it stays within its rule, the first rule that would be broken is
+// returned and none of the counters move. The counters moved are remembered against the
+// entity so Release can give them back.
+func (repo *limitRepository) Consume(
+	ctx context.Context,
+	entityID string,
+	checks []limits.Check,
+	amount decimal.Decimal,
+) (*limits.Rule, error) {
+	if len(checks) == 0 {
+		return nil, nil
+	}
+
+	var breached *limits.Rule
+	err := repo.writeDB(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		for _, check := range checks {
+			within := gorm.Expr("limit_counters.count + 1 <= ?", check.Rule.Count)
+			if !check.Rule.IsCount() {
+				within = gorm.Expr("limit_counters.amount + ? <= ?", amount, check.Rule.Amount)
+			}
+
+			counter := models.LimitCounter{
+				Key:        check.Key,
+				Count:      1,
+				Amount:     amount,
+				ExpiresAt:  check.ExpiresAt,
+				ModifiedAt: now,
+			}
+			result := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "key"}},
+				DoUpdates: clause.Assignments(map[string]any{
+					"count":       gorm.Expr("limit_counters.count + 1"),
+					"amount":      gorm.Expr("limit_counters.amount + ?", amount),
+					"modified_at": now,
+				}),
+				Where: clause.Where{Exprs: []clause.Expression{within}},
+			}).Create(&counter)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				rule := check.Rule
+				breached = &rule
+				return errLimitBreached
+			}
+		}
+
+		consumption := models.LimitConsumption{EntityID: entityID, Amount: amount}
+		for _, check := range checks {
+			consumption.Keys = append(consumption.Keys, check.Key)
+			if check.ExpiresAt.After(consumption.ExpiresAt) {
+				consumption.ExpiresAt = check.ExpiresAt
+			}
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&consumption).Error
+	})
+	if errors.Is(err, errLimitBreached) {
+		return breached, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// Release gives back what an entity was counted against its limits, for a transaction that did
+// not go through. It reports whether anything was given back, an entity is only released once.
+func (repo *limitRepository) Release(ctx context.Context, entityID string) (bool, error) {
+	released := false
+	err := repo.writeDB(ctx).Transaction(func(tx *gorm.DB) error {
+		var consumption models.LimitConsumption
+		result := tx.Clauses(clause.Returning{}).Where("entity_id = ?", entityID).Delete(&consumption)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		released = true
+		if len(consumption.Keys) == 0 {
+			return nil
+		}
+
+		return tx.Model(&models.LimitCounter{}).Where("key IN ?", []string(consumption.Keys)).
+			Updates(map[string]any{
+				"count":       gorm.Expr("GREATEST(count - 1, 0)"),
+				"amount":      gorm.Expr("GREATEST(amount - ?, 0)", consumption.Amount),
+				"modified_at": time.Now(),
+			}).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	return released, nil
+}
+
+// PurgeExpired drops the counters of windows that ended before a time, along with the record of
+// what was counted against them.
+func (repo *limitRepository) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := repo.writeDB(ctx).Where("expires_at < ?", before).Delete(&models.LimitCounter{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	err := repo.writeDB(ctx).Where("expires_at < ?", before).Delete(&models.LimitConsumption{}).Error
+	return result.RowsAffected, err
+}
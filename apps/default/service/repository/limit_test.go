@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antinvestor/service-payments/service/limits"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/repository/repositorytest"
+	"github.com/shopspring/decimal"
+)
+
+func TestLimitConsumeAndRelease(t *testing.T) {
+	ctx, service := repositorytest.NewService(t, nil)
+	repo := NewLimitRepository(ctx, service)
+
+	rules, err := limits.ParseRules("send.profile.day=2,send.profile.day=KES 1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, checks := rules.Evaluate(limits.Transaction{
+		Operation: limits.Send, PartitionID: "partition", ProfileID: "profile", Currency: "KES",
+		Amount: decimal.NewFromInt(400),
+	}, time.Now())
+	if len(checks) != 2 {
+		t.Fatalf("Evaluate() = %d checks, want 2", len(checks))
+	}
+	amount := decimal.NewFromInt(400)
+
+	for _, entityID := range []string{"payment-1", "payment-2"} {
+		if rule, consumeErr := repo.Consume(ctx, entityID, checks, amount); consumeErr != nil || rule != nil {
+			t.Fatalf("Consume(%s) = %v, %v, want it within the limits", entityID, rule, consumeErr)
+		}
+	}
+	rule, err := repo.Consume(ctx, "payment-3", checks, amount)
+	if err != nil || rule == nil || !rule.IsCount() {
+		t.Fatalf("Consume(payment-3) = %v, %v, want the count rule broken", rule, err)
+	}
+	assertCounter(t, repo.(*limitRepository), checks[0].Key, 2, decimal.NewFromInt(800))
+
+	released, err := repo.Release(ctx, "payment-1")
+	if err != nil || !released {
+		t.Fatalf("Release(payment-1) = %v, %v, want it given back", released, err)
+	}
+	if released, err = repo.Release(ctx, "payment-1"); err != nil || released {
+		t.Errorf("Release(payment-1) again = %v, %v, want nothing given back twice", released, err)
+	}
+	if released, err = repo.Release(ctx, "payment-3"); err != nil || released {
+		t.Errorf("Release(payment-3) = %v, %v, want nothing given back for a breach", released, err)
+	}
+	assertCounter(t, repo.(*limitRepository), checks[0].Key, 1, decimal.NewFromInt(400))
+
+	if rule, err = repo.Consume(ctx, "payment-4", checks, amount); err != nil || rule != nil {
+		t.Errorf("Consume(payment-4) = %v, %v, want the released room reused", rule, err)
+	}
+}
+
+func assertCounter(t *testing.T, repo *limitRepository, key string, count int64, amount decimal.Decimal) {
+	t.Helper()
+	var counter models.LimitCounter
+	if err := repo.writeDB(t.Context()).First(&counter, "key = ?", key).Error; err != nil {
+		t.Fatal(err)
+	}
+	if counter.Count != count || !counter.Amount.Equal(amount) {
+		t.Errorf("counter %s = %d, %s, want %d, %s", key, counter.Count, counter.Amount, count, amount)
+	}
+}
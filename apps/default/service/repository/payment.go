@@ -4,8 +4,13 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
 	"github.com/antinvestor/service-payments/service/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/pitabwire/frame"
 )
@@ -15,6 +20,10 @@ type PaymentRepository interface {
 	GetByID(ctx context.Context, id string) (*models.Payment, error)
 	Search(ctx context.Context, query string) ([]*models.Payment, error)
 	Save(ctx context.Context, payment *models.Payment) error
+	ClaimInbound(ctx context.Context, payment *models.Payment) (bool, error)
+	GetInboundByTransactionID(ctx context.Context, transactionID string) (*models.Payment, error)
+	SentAmounts(ctx context.Context, p *models.Payment, since time.Time) (int64, decimal.Decimal, error)
+	HasPaidRecipient(ctx context.Context, p *models.Payment) (bool, error)
 }
 
 type paymentRepository struct {
@@ -67,3 +76,85 @@ func (repo *paymentRepository) Search(ctx context.Context, query string) ([]*mod
 func (repo *paymentRepository) Save(ctx context.Context, payment *models.Payment) error {
 	return repo.writeDB(ctx).Save(payment).Error
 }
+
+// ClaimInbound records a received payment unless a payment was already received under its
+// provider transaction id, and reports whether it was recorded. Concurrent deliveries of one
+// transaction are settled by the unique index on inbound transaction ids.
+func (repo *paymentRepository) ClaimInbound(ctx context.Context, payment *models.Payment) (bool, error) {
+	result := repo.writeDB(ctx).Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "transaction_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "out_bound = false AND transaction_id <> ''"}}},
+		DoNothing:   true,
+	}).Create(payment)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// GetInboundByTransactionID finds a received payment by the provider's transaction id.
+func (repo *paymentRepository) GetInboundByTransactionID(
+	ctx context.Context,
+	transactionID string,
+) (*models.Payment, error) {
+	payment := models.Payment{}
+	err := repo.readDB(ctx).First(&payment, "transaction_id = ? AND out_bound = ?", transactionID, false).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// sentPayments scopes a query to the outbound payments a sender released that went on to be
+// paid, or may still be. Payments screening or an approver rejected and payments whose latest
+// status is failed are left out of the sender's history.
+func sentPayments(db *gorm.DB, p *models.Payment) *gorm.DB {
+	return db.Model(&models.Payment{}).
+		Where("sender_profile_id = ? AND out_bound = ? AND id <> ? AND released_at IS NOT NULL",
+			p.SenderProfileID, true, p.GetID()).
+		Where("NOT EXISTS (SELECT 1 FROM screenings WHERE screenings.payment_id = payments.id "+
+			"AND screenings.state = ? AND screenings.deleted_at IS NULL)", models.ScreeningRejected).
+		Where("NOT EXISTS (SELECT 1 FROM approvals WHERE approvals.payment_id = payments.id "+
+			"AND approvals.state = ? AND approvals.deleted_at IS NULL)", models.ApprovalRejected).
+		Where("COALESCE((SELECT statuses.status FROM statuses WHERE statuses.entity_id = payments.id "+
+			"AND statuses.entity_type = ? AND statuses.deleted_at IS NULL "+
+			"ORDER BY statuses.created_at DESC LIMIT 1), 0) <> ?", "payment", int32(commonv1.STATUS_FAILED))
+}
+
+// SentAmounts counts the outbound payments the sender of a payment released in its currency
+// since a time, the payment itself excluded, and averages their amounts.
+func (repo *paymentRepository) SentAmounts(
+	ctx context.Context,
+	p *models.Payment,
+	since time.Time,
+) (int64, decimal.Decimal, error) {
+	var sent struct {
+		Count   int64
+		Average decimal.NullDecimal
+	}
+	err := sentPayments(repo.readDB(ctx), p).
+		Select("count(*) AS count, avg(amount) AS average").
+		Where("currency = ? AND released_at >= ?", p.Currency, since).
+		Scan(&sent).Error
+	if err != nil {
+		return 0, decimal.Zero, err
+	}
+	return sent.Count, sent.Average.Decimal, nil
+}
+
+// HasPaidRecipient reports whether the sender of a payment released another one to the same
+// recipient profile, or to the same contact when the recipient has no profile.
+func (repo *paymentRepository) HasPaidRecipient(ctx context.Context, p *models.Payment) (bool, error) {
+	query := sentPayments(repo.readDB(ctx), p)
+	if p.RecipientProfileID != "" {
+		query = query.Where("recipient_profile_id = ?", p.RecipientProfileID)
+	} else {
+		query = query.Where("recipient_contact_id = ?", p.RecipientContactID)
+	}
+
+	var count int64
+	if err := query.Limit(1).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -3,8 +3,13 @@ package repository
 import (
 	"context"
 	"strings"
+	"time"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
 
 	"github.com/antinvestor/service-payments/service/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 
 	"github.com/pitabwire/frame"
 )
@@ -14,6 +19,15 @@ type PaymentLinkRepository interface {
 	GetByPartitionAndID(ctx context.Context, partitionID string, id string) (*models.PaymentLink, error)
 	Search(ctx context.Context, query string) ([]*models.PaymentLink, error)
 	Save(ctx context.Context, link *models.PaymentLink) error
+	GetByReference(ctx context.Context, partitionID, reference string) (*models.PaymentLink, error)
+	AddCollection(ctx context.Context, id string, amount decimal.Decimal, paidAt time.Time) error
+	ListExpiredBefore(ctx context.Context, date time.Time, limit int) ([]*models.PaymentLink, error)
+	ListActiveWithProviderRef(
+		ctx context.Context,
+		now time.Time,
+		afterID string,
+		limit int,
+	) ([]*models.PaymentLink, error)
 }
 
 type paymentLinkRepository struct {
@@ -58,3 +72,89 @@ func (repo *paymentLinkRepository) Search(ctx context.Context, query string) ([]
 func (repo *paymentLinkRepository) Save(ctx context.Context, link *models.PaymentLink) error {
 	return repo.writeDB(ctx).Save(link).Error
 }
+
+// GetByReference finds a link in a partition by the reference the provider assigned to it or by
+// our own external reference. Without a partition only the provider's reference is matched, an
+// external reference is only unique within the partition that chose it.
+func (repo *paymentLinkRepository) GetByReference(
+	ctx context.Context,
+	partitionID, reference string,
+) (*models.PaymentLink, error) {
+	link := models.PaymentLink{}
+	query := repo.readDB(ctx)
+	if partitionID == "" {
+		query = query.Where("payment_link_ref = ?", reference)
+	} else {
+		query = query.Where("partition_id = ? AND (payment_link_ref = ? OR external_ref = ?)",
+			partitionID, reference, reference)
+	}
+	if err := query.First(&link).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// AddCollection adds a payment to the link's collected totals in a single atomic update.
+func (repo *paymentLinkRepository) AddCollection(
+	ctx context.Context,
+	id string,
+	amount decimal.Decimal,
+	paidAt time.Time,
+) error {
+	return repo.writeDB(ctx).Model(&models.PaymentLink{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"collected_amount": gorm.Expr("COALESCE(collected_amount, 0) + ?", amount),
+			"payments_count":   gorm.Expr("COALESCE(payments_count, 0) + 1"),
+			"last_payment_at":  paidAt,
+		}).Error
+}
+
+// ListExpiredBefore returns links that are still open but whose expiry date is before the given date.
+func (repo *paymentLinkRepository) ListExpiredBefore(
+	ctx context.Context,
+	date time.Time,
+	limit int,
+) ([]*models.PaymentLink, error) {
+	var links []*models.PaymentLink
+	err := repo.readDB(ctx).
+		Scopes(openLinks).
+		Where("expiry_date < ?", date).
+		Order("expiry_date").
+		Limit(limit).
+		Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// ListActiveWithProviderRef returns a page of the open links that the provider has acknowledged
+// and can be polled for payments, ordered by id and starting after afterID.
+func (repo *paymentLinkRepository) ListActiveWithProviderRef(
+	ctx context.Context,
+	now time.Time,
+	afterID string,
+	limit int,
+) ([]*models.PaymentLink, error) {
+	var links []*models.PaymentLink
+	err := repo.readDB(ctx).
+		Scopes(openLinks).
+		Where("payment_link_ref <> '' AND expiry_date >= ? AND id > ?", now.Truncate(24*time.Hour), afterID).
+		Order("id").
+		Limit(limit).
+		Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// openLinks selects links that are not closed, links saved before they had a state are open.
+func openLinks(db *gorm.DB) *gorm.DB {
+	return db.Where("(state IS NULL OR state NOT IN ?)", closedLinkStates())
+}
+
+func closedLinkStates() []int32 {
+	return []int32{int32(commonv1.STATE_INACTIVE), int32(commonv1.STATE_DELETED)}
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/repository/repositorytest"
+	"gorm.io/gorm"
+)
+
+func TestListActiveWithProviderRefPages(t *testing.T) {
+	ctx, service := repositorytest.NewService(t, nil)
+	repo := NewPaymentLinkRepository(ctx, service)
+
+	expiry := time.Now().AddDate(0, 0, 7)
+	open := map[string]bool{}
+	for i := range 5 {
+		link := &models.PaymentLink{PaymentLinkRef: "PL" + string(rune('A'+i)), ExpiryDate: expiry,
+			State: int32(commonv1.STATE_ACTIVE)}
+		link.GenID(ctx)
+		if err := repo.Save(ctx, link); err != nil {
+			t.Fatal(err)
+		}
+		open[link.GetID()] = true
+	}
+	closed := &models.PaymentLink{PaymentLinkRef: "PLZ", ExpiryDate: expiry, State: int32(commonv1.STATE_INACTIVE)}
+	unacknowledged := &models.PaymentLink{ExpiryDate: expiry, State: int32(commonv1.STATE_ACTIVE)}
+	for _, link := range []*models.PaymentLink{closed, unacknowledged} {
+		link.GenID(ctx)
+		if err := repo.Save(ctx, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Links saved before they had a state are open
+	unset := &models.PaymentLink{PaymentLinkRef: "PLN", ExpiryDate: expiry}
+	unset.GenID(ctx)
+	if err := repo.Save(ctx, unset); err != nil {
+		t.Fatal(err)
+	}
+	err := service.DB(ctx, false).Model(&models.PaymentLink{}).
+		Where("id = ?", unset.GetID()).Update("state", nil).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	open[unset.GetID()] = true
+
+	polled := map[string]bool{}
+	afterID := ""
+	for {
+		page, listErr := repo.ListActiveWithProviderRef(ctx, time.Now(), afterID, 2)
+		if listErr != nil {
+			t.Fatal(listErr)
+		}
+		for _, link := range page {
+			if polled[link.GetID()] {
+				t.Errorf("link %s listed twice", link.GetID())
+			}
+			polled[link.GetID()] = true
+		}
+		if len(page) < 2 {
+			break
+		}
+		afterID = page[len(page)-1].GetID()
+	}
+
+	if len(polled) != len(open) {
+		t.Errorf("listed %d links, want the %d open links", len(polled), len(open))
+	}
+	for id := range open {
+		if !polled[id] {
+			t.Errorf("open link %s was not listed", id)
+		}
+	}
+}
+
+func TestPaymentLinkGetByReference(t *testing.T) {
+	ctx, service := repositorytest.NewService(t, nil)
+	repo := NewPaymentLinkRepository(ctx, service)
+
+	ours := &models.PaymentLink{PaymentLinkRef: "PL7Q2X", ExternalRef: "INV-1"}
+	theirs := &models.PaymentLink{PaymentLinkRef: "PL8R3Y", ExternalRef: "INV-1"}
+	ours.PartitionID, theirs.PartitionID = "partition-a", "partition-b"
+	for _, link := range []*models.PaymentLink{ours, theirs} {
+		link.GenID(ctx)
+		if err := repo.Save(ctx, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		partition string
+		reference string
+		want      string
+	}{
+		{name: "external reference in partition", partition: "partition-a", reference: "INV-1", want: ours.GetID()},
+		{name: "provider reference in partition", partition: "partition-b", reference: "PL8R3Y", want: theirs.GetID()},
+		{name: "provider reference of another partition", partition: "partition-a", reference: "PL8R3Y"},
+		{name: "provider reference without partition", reference: "PL7Q2X", want: ours.GetID()},
+		{name: "external reference without partition", reference: "INV-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, err := repo.GetByReference(ctx, tt.partition, tt.reference)
+			if tt.want == "" {
+				if !errors.Is(err, gorm.ErrRecordNotFound) {
+					t.Errorf("GetByReference() = %v, %v, want no link", link, err)
+				}
+				return
+			}
+			if err != nil || link.GetID() != tt.want {
+				t.Errorf("GetByReference() = %v, %v, want %s", link, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/repository/repositorytest"
+	"github.com/shopspring/decimal"
+)
+
+func TestClaimInbound(t *testing.T) {
+	ctx, service := repositorytest.NewService(t, nil)
+	repo := NewPaymentRepository(ctx, service)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	claimed := 0
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			payment := &models.Payment{TransactionID: "RKL51ZDR4F"}
+			payment.GenID(ctx)
+			ok, err := repo.ClaimInbound(ctx, payment)
+			if err != nil {
+				t.Errorf("ClaimInbound() error = %v", err)
+				return
+			}
+			if ok {
+				mu.Lock()
+				claimed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if claimed != 1 {
+		t.Errorf("%d deliveries recorded the transaction, want one", claimed)
+	}
+
+	// Outbound payments and payments without a transaction id are never held back
+	for _, payment := range []*models.Payment{
+		{TransactionID: "RKL51ZDR4F", OutBound: true},
+		{},
+		{},
+	} {
+		payment.GenID(ctx)
+		if ok, err := repo.ClaimInbound(ctx, payment); err != nil || !ok {
+			t.Errorf("ClaimInbound(%+v) = %v, %v, want it recorded", payment, ok, err)
+		}
+	}
+}
+
+func TestSenderHistoryLeavesOutRejectedAndFailedPayments(t *testing.T) {
+	ctx, service := repositorytest.NewService(t, nil)
+	repo := NewPaymentRepository(ctx, service)
+
+	released := time.Now().Add(-time.Hour)
+	sent := func(amount int64, recipient string) *models.Payment {
+		payment := &models.Payment{
+			SenderProfileID:    "sender",
+			RecipientProfileID: recipient,
+			Amount:             decimal.NewNullDecimal(decimal.NewFromInt(amount)),
+			Currency:           "KES",
+			OutBound:           true,
+			ReleasedAt:         &released,
+		}
+		payment.GenID(ctx)
+		if err := repo.Save(ctx, payment); err != nil {
+			t.Fatal(err)
+		}
+		return payment
+	}
+
+	paid := sent(100, "paid")
+	screenedOut := sent(5000, "screened-out")
+	declined := sent(5000, "declined")
+	failed := sent(5000, "failed")
+	sent(300, "in-flight")
+
+	screened := &models.Screening{PaymentID: screenedOut.GetID(), State: models.ScreeningRejected}
+	screened.GenID(ctx)
+	if _, _, err := NewScreeningRepository(ctx, service).Record(ctx, screened); err != nil {
+		t.Fatal(err)
+	}
+	approval := &models.Approval{PaymentID: declined.GetID(), State: models.ApprovalRejected}
+	approval.GenID(ctx)
+	if _, err := NewApprovalRepository(ctx, service).Open(ctx, approval); err != nil {
+		t.Fatal(err)
+	}
+	statuses := NewStatusRepository(ctx, service)
+	for _, status := range []*models.Status{
+		{EntityID: paid.GetID(), EntityType: "payment", Status: int32(commonv1.STATUS_SUCCESSFUL)},
+		{EntityID: failed.GetID(), EntityType: "payment", Status: int32(commonv1.STATUS_QUEUED)},
+		{EntityID: failed.GetID(), EntityType: "payment", Status: int32(commonv1.STATUS_FAILED)},
+	} {
+		status.GenID(ctx)
+		if err := statuses.Save(ctx, status); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	next := &models.Payment{SenderProfileID: "sender", Currency: "KES", OutBound: true}
+	next.GenID(ctx)
+	count, average, err := repo.SentAmounts(ctx, next, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 || !average.Equal(decimal.NewFromInt(200)) {
+		t.Errorf("SentAmounts() = %d, %s, want the paid and in flight payments averaging 200", count, average)
+	}
+
+	for _, tt := range []struct {
+		recipient string
+		want      bool
+	}{
+		{recipient: "paid", want: true},
+		{recipient: "in-flight", want: true},
+		{recipient: "screened-out", want: false},
+		{recipient: "declined", want: false},
+		{recipient: "failed", want: false},
+	} {
+		next.RecipientProfileID = tt.recipient
+		if got, hasErr := repo.HasPaidRecipient(ctx, next); hasErr != nil || got != tt.want {
+			t.Errorf("HasPaidRecipient(%s) = %v, %v, want %v", tt.recipient, got, hasErr, tt.want)
+		}
+	}
+}
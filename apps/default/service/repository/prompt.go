@@ -3,8 +3,14 @@ package repository
 import (
 	"context"
 	"strings"
+	"time"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
 
 	"github.com/antinvestor/service-payments/service/models"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/pitabwire/frame"
 )
@@ -13,6 +19,14 @@ type PromptRepository interface {
 	GetByID(ctx context.Context, id string) (*models.Prompt, error)
 	GetByPartitionAndID(ctx context.Context, partitionID string, id string) (*models.Prompt, error)
 	Search(ctx context.Context, query string) ([]*models.Prompt, error)
+	GetByTransactionRef(ctx context.Context, transactionRef string) (*models.Prompt, error)
+	ListPendingBefore(ctx context.Context, before time.Time, limit int) ([]*models.Prompt, error)
+	ListAttempts(ctx context.Context, originalPromptID string) ([]*models.Prompt, error)
+	AddAttempt(
+		ctx context.Context,
+		originalPromptID string,
+		next func(attempts []*models.Prompt) (*models.Prompt, error),
+	) (*models.Prompt, error)
 	Save(ctx context.Context, prompt *models.Prompt) error
 }
 
@@ -55,6 +69,87 @@ func (repo *promptRepository) Search(ctx context.Context, query string) ([]*mode
 	return prompts, nil
 }
 
+// GetByTransactionRef finds the prompt that was sent to the provider under the given reference.
+func (repo *promptRepository) GetByTransactionRef(ctx context.Context, transactionRef string) (*models.Prompt, error) {
+	prompt := models.Prompt{}
+	err := repo.readDB(ctx).Preload("Account").
+		First(&prompt, "extra @> ?", datatypes.JSONMap{"transaction_ref": transactionRef}).Error
+	if err != nil {
+		return nil, err
+	}
+	return &prompt, nil
+}
+
+// ListPendingBefore returns prompts created before the given time that have no final status yet.
+func (repo *promptRepository) ListPendingBefore(
+	ctx context.Context,
+	before time.Time,
+	limit int,
+) ([]*models.Prompt, error) {
+	var prompts []*models.Prompt
+	err := repo.readDB(ctx).
+		Where("status IN ? AND created_at < ?",
+			[]int32{int32(commonv1.STATUS_QUEUED), int32(commonv1.STATUS_IN_PROCESS)}, before).
+		Order("created_at").
+		Limit(limit).
+		Find(&prompts).Error
+	if err != nil {
+		return nil, err
+	}
+	return prompts, nil
+}
+
+// ListAttempts returns the original prompt and every resend of it, oldest attempt first.
+func (repo *promptRepository) ListAttempts(ctx context.Context, originalPromptID string) ([]*models.Prompt, error) {
+	var prompts []*models.Prompt
+	err := repo.readDB(ctx).Preload("Account").Scopes(attemptsOf(originalPromptID)).Find(&prompts).Error
+	if err != nil {
+		return nil, err
+	}
+	return prompts, nil
+}
+
+// AddAttempt locks the original prompt while next works out the attempt that follows the ones
+// already sent, then saves it. Concurrent resends of one prompt are taken one at a time.
+func (repo *promptRepository) AddAttempt(
+	ctx context.Context,
+	originalPromptID string,
+	next func(attempts []*models.Prompt) (*models.Prompt, error),
+) (*models.Prompt, error) {
+	var attempt *models.Prompt
+	err := repo.writeDB(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&models.Prompt{}, "id = ?", originalPromptID).Error
+		if err != nil {
+			return err
+		}
+
+		var attempts []*models.Prompt
+		if err = tx.Preload("Account").Scopes(attemptsOf(originalPromptID)).Find(&attempts).Error; err != nil {
+			return err
+		}
+
+		attempt, err = next(attempts)
+		if err != nil {
+			return err
+		}
+		return tx.Create(attempt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attempt, nil
+}
+
+// attemptsOf selects the original prompt and its resends, oldest attempt first. Prompts saved
+// before attempts were numbered have none and count as the first attempt.
+func attemptsOf(originalPromptID string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ? OR original_prompt_id = ?", originalPromptID, originalPromptID).
+			Order("COALESCE(attempt, 1), created_at")
+	}
+}
+
 func (repo *promptRepository) Save(ctx context.Context, prompt *models.Prompt) error {
 	return repo.writeDB(ctx).Save(prompt).Error
 }
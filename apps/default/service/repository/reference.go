@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/antinvestor/service-payments/service/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/pitabwire/frame"
+)
+
+type ReferenceRepository interface {
+	Reserve(
+		ctx context.Context,
+		scope, format string,
+		encode func(sequence int64) (string, error),
+		entityType, entityID string,
+	) (*models.TransactionReference, error)
+	GetByReference(ctx context.Context, scope, reference string) (*models.TransactionReference, error)
+	ListByEntity(ctx context.Context, entityType, entityID string) ([]*models.TransactionReference, error)
+}
+
+type referenceRepository struct {
+	abstractRepository
+}
+
+func NewReferenceRepository(_ context.Context, service *frame.Service) ReferenceRepository {
+	return &referenceRepository{abstractRepository{service: service}}
+}
+
+// Reserve takes the next number of the scope's sequence, encodes it and records the reference
+// against the entity in one transaction. The unique index on scope and reference rejects any
+// reference that was issued before, e.g. under a different format.
+func (repo *referenceRepository) Reserve(
+	ctx context.Context,
+	scope, format string,
+	encode func(sequence int64) (string, error),
+	entityType, entityID string,
+) (*models.TransactionReference, error) {
+	var reserved *models.TransactionReference
+	err := repo.writeDB(ctx).Transaction(func(tx *gorm.DB) error {
+		sequence := models.ReferenceSequence{Scope: scope, LastValue: 1, ModifiedAt: time.Now()}
+		err := tx.Clauses(
+			clause.OnConflict{
+				Columns: []clause.Column{{Name: "scope"}},
+				DoUpdates: clause.Assignments(map[string]any{
+					"last_value":  gorm.Expr("reference_sequences.last_value + 1"),
+					"modified_at": sequence.ModifiedAt,
+				}),
+			},
+			clause.Returning{Columns: []clause.Column{{Name: "last_value"}}},
+		).Create(&sequence).Error
+		if err != nil {
+			return err
+		}
+
+		reference, err := encode(sequence.LastValue)
+		if err != nil {
+			return err
+		}
+
+		reserved = &models.TransactionReference{
+			Scope:      scope,
+			Reference:  reference,
+			Sequence:   sequence.LastValue,
+			Format:     format,
+			EntityType: entityType,
+			EntityID:   entityID,
+		}
+		reserved.GenID(ctx)
+		return tx.Create(reserved).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reserved, nil
+}
+
+func (repo *referenceRepository) GetByReference(
+	ctx context.Context,
+	scope, reference string,
+) (*models.TransactionReference, error) {
+	reserved := models.TransactionReference{}
+	err := repo.readDB(ctx).First(&reserved, "scope = ? AND reference = ?", scope, reference).Error
+	if err != nil {
+		return nil, err
+	}
+	return &reserved, nil
+}
+
+// ListByEntity returns the references issued to an entity, oldest first.
+func (repo *referenceRepository) ListByEntity(
+	ctx context.Context,
+	entityType, entityID string,
+) ([]*models.TransactionReference, error) {
+	var references []*models.TransactionReference
+	err := repo.readDB(ctx).
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Order("sequence").
+		Find(&references).Error
+	if err != nil {
+		return nil, err
+	}
+	return references, nil
+}
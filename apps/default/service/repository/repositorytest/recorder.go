@@ -0,0 +1,60 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// Recorder stands in for an event handler the test does not exercise, it keeps the payloads
+// emitted to it so the test can check what was emitted.
+type Recorder struct {
+	name     string
+	payload  func() any
+	received chan any
+}
+
+// NewRecorder returns a recorder handling the event named, payload returns an empty value of
+// the event's payload type.
+func NewRecorder(name string, payload func() any) *Recorder {
+	return &Recorder{name: name, payload: payload, received: make(chan any, 100)}
+}
+
+func (r *Recorder) Name() string {
+	return r.name
+}
+
+func (r *Recorder) PayloadType() any {
+	return r.payload()
+}
+
+func (r *Recorder) Validate(context.Context, any) error {
+	return nil
+}
+
+func (r *Recorder) Execute(_ context.Context, payload any) error {
+	r.received <- payload
+	return nil
+}
+
+// Next waits for the next payload emitted to the event.
+func (r *Recorder) Next(t *testing.T) any {
+	t.Helper()
+	select {
+	case payload := <-r.received:
+		return payload
+	case <-time.After(10 * time.Second):
+		t.Fatalf("nothing was emitted to %s", r.name)
+		return nil
+	}
+}
+
+// None checks nothing more is emitted to the event for a while.
+func (r *Recorder) None(t *testing.T) {
+	t.Helper()
+	select {
+	case payload := <-r.received:
+		t.Errorf("%s was emitted %v, want nothing", r.name, payload)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
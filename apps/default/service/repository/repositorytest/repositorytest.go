@@ -0,0 +1,109 @@
+// Package repositorytest runs tests against a throwaway postgres database holding the
+// service's tables. Tests are skipped where no container runtime is available.
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/antinvestor/service-payments/config"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/pitabwire/frame"
+)
+
+// Claims of the caller the returned contexts act as.
+const (
+	TenantID    = "test_tenant-id"
+	PartitionID = "test_partition-id"
+	ProfileID   = "test_profile-id"
+)
+
+// Models are the tables the service migrates.
+var Models = []any{
+	&models.Route{}, &models.Payment{}, &models.Cost{}, &models.Status{}, &models.Prompt{},
+	&models.PaymentLink{}, &models.Account{}, &models.ReferenceSequence{}, &models.TransactionReference{},
+	&models.ApprovalPolicy{}, &models.Approval{}, &models.ApprovalDecision{}, &models.LimitCounter{},
+	&models.LimitConsumption{}, &models.Screening{},
+}
+
+// Config returns the service configuration with its defaults.
+func Config(t *testing.T) *config.PaymentConfig {
+	t.Helper()
+	cfg, err := frame.ConfigFromEnv[config.PaymentConfig]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &cfg
+}
+
+// NewService starts a database, migrates it and returns a service using it along with a
+// context carrying the test caller's claims. The database is removed when the test ends.
+// A nil configuration uses the defaults.
+func NewService(t *testing.T, cfg *config.PaymentConfig) (context.Context, *frame.Service) {
+	t.Helper()
+	ctx := context.Background()
+
+	postgres, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "postgres:latest",
+			ExposedPorts: []string{"5432/tcp"},
+			Env: map[string]string{
+				"POSTGRES_USER":     "ant",
+				"POSTGRES_PASSWORD": "secret",
+				"POSTGRES_DB":       "service_payment",
+			},
+			WaitingFor: wait.ForListeningPort("5432/tcp").WithStartupTimeout(5 * time.Minute),
+		},
+		Started: true,
+	})
+	if err != nil {
+		t.Skipf("could not start postgres: %v", err)
+	}
+	t.Cleanup(func() { _ = postgres.Terminate(ctx) })
+
+	host, err := postgres.Host(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := postgres.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbURL := fmt.Sprintf("postgres://ant:secret@%s/service_payment?sslmode=disable",
+		net.JoinHostPort(host, port.Port()))
+
+	if cfg == nil {
+		cfg = Config(t)
+	}
+	ctx, service := frame.NewService(t.Name(),
+		frame.WithDatastoreConnection(dbURL, false), frame.WithConfig(cfg), frame.WithNoopDriver())
+	t.Cleanup(func() { service.Stop(ctx) })
+
+	if err = service.DB(ctx, false).AutoMigrate(Models...); err != nil {
+		t.Fatal(err)
+	}
+
+	claims := frame.ClaimsFromMap(map[string]string{
+		"sub":          ProfileID,
+		"tenant_id":    TenantID,
+		"partition_id": PartitionID,
+		"access_id":    "test_access-id",
+	})
+	return claims.ClaimsToContext(ctx), service
+}
+
+// Start registers the events the test relies on and starts the service so emitted events are
+// handled. Every event the code under test emits must be registered.
+func Start(t *testing.T, ctx context.Context, service *frame.Service, events ...frame.EventI) {
+	t.Helper()
+	service.Init(ctx, frame.WithRegisterEvents(events...))
+	if err := service.Run(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+}
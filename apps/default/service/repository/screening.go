@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/antinvestor/service-payments/service/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/pitabwire/frame"
+)
+
+type ScreeningRepository interface {
+	GetByPaymentID(ctx context.Context, paymentID string) (*models.Screening, error)
+	ListHeld(ctx context.Context) ([]*models.Screening, error)
+	Record(ctx context.Context, screening *models.Screening) (*models.Screening, bool, error)
+	Review(
+		ctx context.Context,
+		paymentID string,
+		review func(screening *models.Screening) error,
+	) (*models.Screening, error)
+}
+
+type screeningRepository struct {
+	abstractRepository
+}
+
+func NewScreeningRepository(_ context.Context, service *frame.Service) ScreeningRepository {
+	return &screeningRepository{abstractRepository{service: service}}
+}
+
+func (repo *screeningRepository) GetByPaymentID(ctx context.Context, paymentID string) (*models.Screening, error) {
+	screening := models.Screening{}
+	err := repo.readDB(ctx).First(&screening, "payment_id = ?", paymentID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &screening, nil
+}
+
+// ListHeld returns the screenings waiting on a reviewer, oldest first.
+func (repo *screeningRepository) ListHeld(ctx context.Context) ([]*models.Screening, error) {
+	var screenings []*models.Screening
+	err := repo.readDB(ctx).
+		Where("state = ?", models.ScreeningHeld).
+		Order("created_at").
+		Find(&screenings).Error
+	if err != nil {
+		return nil, err
+	}
+	return screenings, nil
+}
+
+// Record saves the screening of a payment unless it was already screened, in which case the
+// earlier screening is returned. It reports whether the screening given was recorded.
+func (repo *screeningRepository) Record(
+	ctx context.Context,
+	screening *models.Screening,
+) (*models.Screening, bool, error) {
+	result := repo.writeDB(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "payment_id"}}, DoNothing: true}).
+		Create(screening)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		existing, err := repo.GetByPaymentID(ctx, screening.PaymentID)
+		return existing, false, err
+	}
+	return screening, true, nil
+}
+
+// Review locks the screening of a payment while review settles it, then saves it.
+func (repo *screeningRepository) Review(
+	ctx context.Context,
+	paymentID string,
+	review func(screening *models.Screening) error,
+) (*models.Screening, error) {
+	screening := &models.Screening{}
+	err := repo.writeDB(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(screening, "payment_id = ?", paymentID).Error
+		if err != nil {
+			return err
+		}
+		if err = review(screening); err != nil {
+			return err
+		}
+		return tx.Save(screening).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return screening, nil
+}
@@ -0,0 +1,67 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/antinvestor/service-payments/service/authorization"
+	"github.com/antinvestor/service-payments/service/handlers"
+	"github.com/gorilla/mux"
+)
+
+func NewRouter(ps *handlers.PaymentServer) *mux.Router {
+	router := mux.NewRouter().StrictSlash(true)
+	// Beneficiary checks
+	router.HandleFunc("/beneficiaries/validate",
+		ps.Authorized(authorization.PermissionSend, ps.ValidateBeneficiary)).Methods("POST")
+	// Merchant collection accounts
+	accounts := func(handler http.HandlerFunc) http.HandlerFunc {
+		return ps.Authorized(authorization.PermissionAccounts, handler)
+	}
+	router.HandleFunc("/accounts", accounts(ps.RegisterAccount)).Methods("POST")
+	router.HandleFunc("/accounts", accounts(ps.ListAccounts)).Methods("GET")
+	router.HandleFunc("/accounts/{id}", accounts(ps.GetAccount)).Methods("GET")
+	router.HandleFunc("/accounts/{id}", accounts(ps.UpdateAccount)).Methods("PATCH")
+	router.HandleFunc("/accounts/{id}/deactivate", accounts(ps.DeactivateAccount)).Methods("POST")
+	router.HandleFunc("/accounts/{id}/payment-links", accounts(ps.CreateAccountPaymentLink)).Methods("POST")
+	// Transaction references issued to providers
+	router.HandleFunc("/references/{counter}/{reference}",
+		ps.Authorized(authorization.PermissionStatus, ps.LookupReference)).Methods("GET")
+	// Callbacks posted by the provider serving a counter
+	router.HandleFunc("/providers/{counter}/callbacks/{kind}",
+		ps.Authorized(authorization.PermissionReceive, ps.ReceiveCallback)).Methods("POST")
+	// Prompt attempts
+	router.HandleFunc("/prompts/{id}/resend",
+		ps.Authorized(authorization.PermissionPrompt, ps.ResendPrompt)).Methods("POST")
+	// Payment link lifecycle
+	paymentLinks := func(handler http.HandlerFunc) http.HandlerFunc {
+		return ps.Authorized(authorization.PermissionPaymentLink, handler)
+	}
+	router.HandleFunc("/payment-links/{id}", paymentLinks(ps.GetPaymentLink)).Methods("GET")
+	router.HandleFunc("/payment-links/{id}", paymentLinks(ps.UpdatePaymentLink)).Methods("PATCH")
+	router.HandleFunc("/payment-links/{id}/deactivate", paymentLinks(ps.DeactivatePaymentLink)).Methods("POST")
+	// Approval policies and the approvals outbound payments wait on
+	policies := func(handler http.HandlerFunc) http.HandlerFunc {
+		return ps.Authorized(authorization.PermissionPolicies, handler)
+	}
+	router.HandleFunc("/approval-policies", policies(ps.CreateApprovalPolicy)).Methods("POST")
+	router.HandleFunc("/approval-policies", policies(ps.ListApprovalPolicies)).Methods("GET")
+	router.HandleFunc("/approval-policies/{id}/deactivate", policies(ps.DeactivateApprovalPolicy)).Methods("POST")
+	approvals := func(handler http.HandlerFunc) http.HandlerFunc {
+		return ps.Authorized(authorization.PermissionApprove, handler)
+	}
+	router.HandleFunc("/approvals", approvals(ps.ListPendingApprovals)).Methods("GET")
+	router.HandleFunc("/payments/{id}/approval",
+		ps.Authorized(authorization.PermissionStatus, ps.GetPaymentApproval)).Methods("GET")
+	router.HandleFunc("/payments/{id}/approve", approvals(ps.ApprovePayment)).Methods("POST")
+	router.HandleFunc("/payments/{id}/reject", approvals(ps.RejectPayment)).Methods("POST")
+	// Review queue of the payments screening held
+	reviews := func(handler http.HandlerFunc) http.HandlerFunc {
+		return ps.Authorized(authorization.PermissionScreening, handler)
+	}
+	router.HandleFunc("/screenings", reviews(ps.ListHeldPayments)).Methods("GET")
+	router.HandleFunc("/payments/{id}/screening",
+		ps.Authorized(authorization.PermissionStatus, ps.GetPaymentScreening)).Methods("GET")
+	router.HandleFunc("/payments/{id}/screening/clear", reviews(ps.ClearHeldPayment)).Methods("POST")
+	router.HandleFunc("/payments/{id}/screening/reject", reviews(ps.RejectHeldPayment)).Methods("POST")
+	return router
+}
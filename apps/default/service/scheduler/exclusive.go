@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pitabwire/frame"
+	"gorm.io/gorm"
+)
+
+// exclusiveLockPrefix namespaces the advisory locks taken for scheduled tasks.
+const exclusiveLockPrefix = "service_payments.scheduler."
+
+// ExclusiveTask runs a task in one replica at a time. Every replica schedules the task, a run
+// first takes a postgres advisory lock named after the task and is skipped where another
+// replica holds it. The lock is released when the run's transaction ends, also when the
+// replica holding it goes away.
+type ExclusiveTask struct {
+	Service *frame.Service
+	Task    Task
+}
+
+// Exclusive wraps a task so its runs never overlap across replicas.
+func Exclusive(service *frame.Service, task Task) *ExclusiveTask {
+	return &ExclusiveTask{Service: service, Task: task}
+}
+
+func (t *ExclusiveTask) Name() string {
+	return t.Task.Name()
+}
+
+func (t *ExclusiveTask) Interval() time.Duration {
+	return t.Task.Interval()
+}
+
+// Run runs the task while holding its lock, a run the lock is not available for does nothing.
+func (t *ExclusiveTask) Run(ctx context.Context) error {
+	db := t.Service.DB(ctx, false)
+	if db == nil {
+		return errors.New("scheduled task needs a datastore to take its lock")
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		err := tx.Raw("SELECT pg_try_advisory_xact_lock(hashtext(?))", exclusiveLockPrefix+t.Name()).
+			Scan(&locked).Error
+		if err != nil {
+			return err
+		}
+		if !locked {
+			t.Service.Log(ctx).WithField("task", t.Name()).Debug("task is running in another replica")
+			return nil
+		}
+		return t.Task.Run(ctx)
+	})
+}
@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/antinvestor/service-payments/service/repository/repositorytest"
+)
+
+// blockingTask counts its runs and holds each one until released.
+type blockingTask struct {
+	runs    atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (t *blockingTask) Name() string {
+	return "blocking.task"
+}
+
+func (t *blockingTask) Interval() time.Duration {
+	return time.Minute
+}
+
+func (t *blockingTask) Run(context.Context) error {
+	t.runs.Add(1)
+	t.started <- struct{}{}
+	<-t.release
+	return nil
+}
+
+func TestExclusiveTaskRunsInOneReplica(t *testing.T) {
+	ctx, service := repositorytest.NewService(t, nil)
+	task := &blockingTask{started: make(chan struct{}, 2), release: make(chan struct{})}
+
+	// Two replicas schedule the same task
+	first, second := Exclusive(service, task), Exclusive(service, task)
+
+	done := make(chan error, 1)
+	go func() { done <- first.Run(ctx) }()
+	<-task.started
+
+	if err := second.Run(ctx); err != nil {
+		t.Fatalf("Run() while locked = %v, want the run skipped", err)
+	}
+	if runs := task.runs.Load(); runs != 1 {
+		t.Fatalf("task ran %d times while locked, want 1", runs)
+	}
+
+	close(task.release)
+	if err := <-done; err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+
+	// The lock is given back with the run
+	if err := second.Run(ctx); err != nil {
+		t.Fatalf("Run() after release = %v", err)
+	}
+	if runs := task.runs.Load(); runs != 2 {
+		t.Errorf("task ran %d times, want 2", runs)
+	}
+}
@@ -0,0 +1,34 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/antinvestor/service-payments/service/repository"
+
+	"github.com/pitabwire/frame"
+)
+
+// LimitCounterPurger drops the limit counters of windows that have passed. Replicas would
+// purge the same counters, it is scheduled through Exclusive.
+type LimitCounterPurger struct {
+	Service *frame.Service
+	Every   time.Duration
+}
+
+func (t *LimitCounterPurger) Name() string {
+	return "limit.counter.purger"
+}
+
+func (t *LimitCounterPurger) Interval() time.Duration {
+	return t.Every
+}
+
+func (t *LimitCounterPurger) Run(ctx context.Context) error {
+	purged, err := repository.NewLimitRepository(ctx, t.Service).PurgeExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	t.Service.Log(ctx).WithField("task", t.Name()).WithField("count", purged).Debug("purged limit counters")
+	return nil
+}
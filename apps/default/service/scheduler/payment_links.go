@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/service/events"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/repository"
+	"gorm.io/datatypes"
+
+	"github.com/pitabwire/frame"
+)
+
+const paymentLinkBatchSize = 100
+
+// PaymentLinkExpirer closes payment links once their expiry date has passed. Replicas would
+// expire the same links, it is scheduled through Exclusive.
+type PaymentLinkExpirer struct {
+	Service *frame.Service
+	Every   time.Duration
+}
+
+func (t *PaymentLinkExpirer) Name() string {
+	return "payment.link.expirer"
+}
+
+func (t *PaymentLinkExpirer) Interval() time.Duration {
+	return t.Every
+}
+
+func (t *PaymentLinkExpirer) Run(ctx context.Context) error {
+	logger := t.Service.Log(ctx).WithField("task", t.Name())
+
+	// Links are valid through the whole of their expiry date
+	today := time.Now().Truncate(24 * time.Hour)
+	linkRepo := repository.NewPaymentLinkRepository(ctx, t.Service)
+	links, err := linkRepo.ListExpiredBefore(ctx, today, paymentLinkBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		if err = t.expire(ctx, link); err != nil {
+			logger.WithError(err).WithField("paymentLinkId", link.ID).Warn("could not expire payment link")
+		}
+	}
+
+	logger.WithField("count", len(links)).Debug("expired payment links")
+	return nil
+}
+
+func (t *PaymentLinkExpirer) expire(ctx context.Context, link *models.PaymentLink) error {
+	status := &models.Status{
+		EntityID:   link.ID,
+		EntityType: "payment_link",
+		State:      int32(commonv1.STATE_INACTIVE.Number()),
+		Status:     int32(commonv1.STATUS_SUCCESSFUL.Number()),
+		Extra: datatypes.JSONMap{
+			"reason":      "expired",
+			"expiry_date": link.ExpiryDate.Format("2006-01-02"),
+		},
+	}
+	status.GenID(ctx)
+
+	statusEvent := events.StatusSave{Service: t.Service}
+	return t.Service.Emit(ctx, statusEvent.Name(), status)
+}
+
+// PaymentLinkPoller asks the integration to fetch payments made against open links, a
+// fallback for payments whose callbacks never arrive. Replicas would poll every link once
+// each, it is scheduled through Exclusive.
+type PaymentLinkPoller struct {
+	Service   *frame.Service
+	PollTopic string
+	Every     time.Duration
+}
+
+func (t *PaymentLinkPoller) Name() string {
+	return "payment.link.poller"
+}
+
+func (t *PaymentLinkPoller) Interval() time.Duration {
+	return t.Every
+}
+
+func (t *PaymentLinkPoller) Run(ctx context.Context) error {
+	logger := t.Service.Log(ctx).WithField("task", t.Name())
+
+	// Every open link is polled on each run, a page at a time
+	linkRepo := repository.NewPaymentLinkRepository(ctx, t.Service)
+	polled := 0
+	afterID := ""
+	for {
+		links, err := linkRepo.ListActiveWithProviderRef(ctx, time.Now(), afterID, paymentLinkBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, link := range links {
+			if err = t.Service.Publish(ctx, t.PollTopic, link); err != nil {
+				logger.WithError(err).WithField("paymentLinkId", link.ID).Warn("could not poll payment link")
+			}
+		}
+		polled += len(links)
+
+		if len(links) < paymentLinkBatchSize {
+			break
+		}
+		afterID = links[len(links)-1].ID
+	}
+
+	logger.WithField("count", polled).Debug("polled payment links")
+	return nil
+}
@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	"github.com/antinvestor/service-payments/service/events"
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/provider"
+	"github.com/antinvestor/service-payments/service/repository"
+	"gorm.io/datatypes"
+
+	"github.com/pitabwire/frame"
+)
+
+const promptSweepBatchSize = 100
+
+// PromptSweeper looks for prompts that have not received a callback. Prompts older than
+// QueryAfter are handed to their provider for a transaction status query while those
+// older than Timeout are given up on and failed. Replicas would sweep the same prompts, it
+// is scheduled through Exclusive.
+type PromptSweeper struct {
+	Service    *frame.Service
+	Providers  *provider.Registry
+	QueryAfter time.Duration
+	Timeout    time.Duration
+	Every      time.Duration
+}
+
+func (t *PromptSweeper) Name() string {
+	return "prompt.sweeper"
+}
+
+func (t *PromptSweeper) Interval() time.Duration {
+	return t.Every
+}
+
+func (t *PromptSweeper) Run(ctx context.Context) error {
+	logger := t.Service.Log(ctx).WithField("task", t.Name())
+
+	now := time.Now()
+	promptRepo := repository.NewPromptRepository(ctx, t.Service)
+	prompts, err := promptRepo.ListPendingBefore(ctx, now.Add(-t.QueryAfter), promptSweepBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, prompt := range prompts {
+		if now.Sub(prompt.CreatedAt) >= t.Timeout {
+			err = t.expire(ctx, prompt)
+		} else {
+			err = t.query(ctx, prompt)
+		}
+		if err != nil {
+			logger.WithError(err).WithField("promptId", prompt.ID).Warn("could not sweep prompt")
+		}
+	}
+
+	logger.WithField("count", len(prompts)).Debug("swept pending prompts")
+	return nil
+}
+
+func (t *PromptSweeper) query(ctx context.Context, prompt *models.Prompt) error {
+	psp, err := t.Providers.ForRoute(ctx, t.Service, prompt.Route)
+	if err != nil {
+		return err
+	}
+	return psp.QueryStatus(ctx, prompt)
+}
+
+func (t *PromptSweeper) expire(ctx context.Context, prompt *models.Prompt) error {
+	status := &models.Status{
+		EntityID:   prompt.ID,
+		EntityType: "prompt",
+		State:      int32(commonv1.STATE_ACTIVE.Number()),
+		Status:     int32(commonv1.STATUS_FAILED.Number()),
+		Extra: datatypes.JSONMap{
+			"transaction_ref": prompt.Extra["transaction_ref"],
+			"resolution":      models.PromptResolutionTimeout,
+			"resolved_by":     "sweeper",
+		},
+	}
+	status.GenID(ctx)
+
+	statusEvent := events.StatusSave{Service: t.Service}
+	return t.Service.Emit(ctx, statusEvent.Name(), status)
+}
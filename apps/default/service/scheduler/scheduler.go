@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pitabwire/frame"
+)
+
+// Task is periodic background work run by the Scheduler.
+type Task interface {
+	Name() string
+	Interval() time.Duration
+	Run(ctx context.Context) error
+}
+
+// Scheduler runs registered tasks on their own intervals until the service stops.
+// It is intended to be registered as the service's background consumer.
+type Scheduler struct {
+	service *frame.Service
+	tasks   []Task
+}
+
+func NewScheduler(service *frame.Service, tasks ...Task) *Scheduler {
+	return &Scheduler{service: service, tasks: tasks}
+}
+
+// Register adds a task to be run once the scheduler starts.
+func (s *Scheduler) Register(task Task) {
+	s.tasks = append(s.tasks, task)
+}
+
+// Run blocks until the context is cancelled.
+func (s *Scheduler) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, task := range s.tasks {
+		wg.Add(1)
+		go func(task Task) {
+			defer wg.Done()
+			s.runTask(ctx, task)
+		}(task)
+	}
+
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (s *Scheduler) runTask(ctx context.Context, task Task) {
+	logger := s.service.Log(ctx).WithField("task", task.Name())
+	if task.Interval() <= 0 {
+		logger.Warn("scheduled task has no interval configured, it will not run")
+		return
+	}
+
+	ticker := time.NewTicker(task.Interval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := task.Run(ctx); err != nil {
+				logger.WithError(err).Warn("scheduled task run failed")
+			}
+		}
+	}
+}
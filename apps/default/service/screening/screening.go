@@ -0,0 +1,217 @@
+// Package screening decides whether a released outbound payment may go out to its provider.
+// Screeners approve a payment, hold it for a reviewer, or reject it outright. The built in
+// Rules screener matches beneficiary names against a local watchlist and holds payments that
+// are unusually large for their sender or go to a beneficiary the sender never paid before.
+package screening
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/antinvestor/service-payments/service/utility"
+	"github.com/shopspring/decimal"
+)
+
+// Decisions a screener takes on a payment, from the most to the least permissive.
+const (
+	Approve = "approve"
+	Review  = "review"
+	Reject  = "reject"
+)
+
+// NameExtras are the payment extras holding beneficiary names that are screened besides the
+// recipient's profile name.
+var NameExtras = []string{"recipient_name", "beneficiary_name", "account_name"}
+
+// Result is a screener's decision on a payment and the reasons it was taken.
+type Result struct {
+	Decision string
+	Reasons  []string
+}
+
+// escalate raises the decision to another one when it is stricter and records the reason.
+func (r *Result) escalate(decision, reason string) {
+	if severity(decision) > severity(r.Decision) {
+		r.Decision = decision
+	}
+	r.Reasons = append(r.Reasons, reason)
+}
+
+func severity(decision string) int {
+	switch decision {
+	case Reject:
+		return 2
+	case Review:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Screener screens outbound payments once they are released.
+type Screener interface {
+	Screen(ctx context.Context, p *models.Payment) (Result, error)
+}
+
+// History is what the rules screener knows of the payments senders made before.
+type History interface {
+	// SentAmounts returns how many released outbound payments in the currency the profile
+	// made since a time, other than the one given, and their average amount. Payments that
+	// were rejected or failed are not counted.
+	SentAmounts(
+		ctx context.Context,
+		p *models.Payment,
+		since time.Time,
+	) (int64, decimal.Decimal, error)
+	// HasPaidRecipient reports whether the sender released a payment to the recipient before
+	// that was not rejected and did not fail.
+	HasPaidRecipient(ctx context.Context, p *models.Payment) (bool, error)
+}
+
+// Entry is a name on a sanctions list or watchlist.
+type Entry struct {
+	Name string
+	List string
+}
+
+// Watchlist is the local list of names payments may not go out to.
+type Watchlist []Entry
+
+// LoadWatchlist reads "name" or "name,list" lines. Blank lines and lines starting with # are
+// skipped.
+func LoadWatchlist(r io.Reader) (Watchlist, error) {
+	var watchlist Watchlist
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, list, _ := strings.Cut(text, ",")
+		if strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("line %d: expected name,list", line)
+		}
+		watchlist = append(watchlist, Entry{Name: strings.TrimSpace(name), List: strings.TrimSpace(list)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return watchlist, nil
+}
+
+// Match returns the entry a name resembles most and how closely, between 0 and 1.
+func (w Watchlist) Match(name string) (Entry, float64) {
+	var best Entry
+	bestScore := 0.0
+	for _, entry := range w {
+		if score := utility.NameMatchScore(name, entry.Name); score > bestScore {
+			best, bestScore = entry, score
+		}
+	}
+	return best, bestScore
+}
+
+// Rules is the built in screener. Checks whose settings are left at zero are skipped.
+type Rules struct {
+	Watchlist Watchlist
+	History   History
+
+	// Names resembling a watchlist entry at least ReviewScore are held, at least RejectScore
+	// rejected
+	ReviewScore float64
+	RejectScore float64
+
+	// Payments above UnusualFactor times the average the sender paid over HistoryWindow are
+	// held, once the sender made at least MinHistory payments
+	UnusualFactor decimal.Decimal
+	MinHistory    int64
+	HistoryWindow time.Duration
+
+	// Payments above the threshold of their currency to a beneficiary the sender never paid
+	// are held
+	NewBeneficiaryThresholds map[string]decimal.Decimal
+}
+
+// Screen applies every rule to a payment, the strictest decision wins.
+func (rules *Rules) Screen(ctx context.Context, p *models.Payment) (Result, error) {
+	result := Result{Decision: Approve}
+	rules.screenNames(p, &result)
+
+	if rules.History == nil || !p.Amount.Valid {
+		return result, nil
+	}
+	if err := rules.screenAmount(ctx, p, &result); err != nil {
+		return Result{}, err
+	}
+	if err := rules.screenBeneficiary(ctx, p, &result); err != nil {
+		return Result{}, err
+	}
+	return result, nil
+}
+
+func (rules *Rules) screenNames(p *models.Payment, result *Result) {
+	if len(rules.Watchlist) == 0 || rules.ReviewScore <= 0 {
+		return
+	}
+	rules.screenName("recipient", p.RecipientName, result)
+	for _, key := range NameExtras {
+		name, _ := p.Extra[key].(string)
+		rules.screenName(key, name, result)
+	}
+}
+
+func (rules *Rules) screenName(field, name string, result *Result) {
+	if strings.TrimSpace(name) == "" {
+		return
+	}
+	entry, score := rules.Watchlist.Match(name)
+	if score < rules.ReviewScore {
+		return
+	}
+	reason := fmt.Sprintf("%s %q resembles watchlist entry %q (%.2f)", field, name, entry.Name, score)
+	if entry.List != "" {
+		reason += " on " + entry.List
+	}
+	decision := Review
+	if rules.RejectScore > 0 && score >= rules.RejectScore {
+		decision = Reject
+	}
+	result.escalate(decision, reason)
+}
+
+func (rules *Rules) screenAmount(ctx context.Context, p *models.Payment, result *Result) error {
+	if !rules.UnusualFactor.IsPositive() || rules.HistoryWindow <= 0 || p.SenderProfileID == "" {
+		return nil
+	}
+	count, average, err := rules.History.SentAmounts(ctx, p, time.Now().Add(-rules.HistoryWindow))
+	if err != nil {
+		return err
+	}
+	if count == 0 || count < rules.MinHistory {
+		return nil
+	}
+	if limit := average.Mul(rules.UnusualFactor); p.Amount.Decimal.GreaterThan(limit) {
+		result.escalate(Review, fmt.Sprintf("amount %s %s is over %s times the sender's average of %s",
+			p.Currency, p.Amount.Decimal, rules.UnusualFactor, average.Round(2)))
+	}
+	return nil
+}
+
+func (rules *Rules) screenBeneficiary(ctx context.Context, p *models.Payment, result *Result) error {
+	threshold, ok := rules.NewBeneficiaryThresholds[strings.ToUpper(p.Currency)]
+	if !ok || p.Amount.Decimal.LessThanOrEqual(threshold) || p.SenderProfileID == "" ||
+		(p.RecipientProfileID == "" && p.RecipientContactID == "") {
+		return nil
+	}
+	paid, err := rules.History.HasPaidRecipient(ctx, p)
+	if err != nil || paid {
+		return err
+	}
+	result.escalate(Review, fmt.Sprintf("first payment to the beneficiary is over %s %s", p.Currency, threshold))
+	return nil
+}
@@ -0,0 +1,106 @@
+package screening
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/datatypes"
+)
+
+type fakeHistory struct {
+	count   int64
+	average decimal.Decimal
+	paid    bool
+}
+
+func (h *fakeHistory) SentAmounts(context.Context, *models.Payment, time.Time) (int64, decimal.Decimal, error) {
+	return h.count, h.average, nil
+}
+
+func (h *fakeHistory) HasPaidRecipient(context.Context, *models.Payment) (bool, error) {
+	return h.paid, nil
+}
+
+func TestLoadWatchlist(t *testing.T) {
+	watchlist, err := LoadWatchlist(strings.NewReader("# sanctions\n\nJohn Kamau Doe, OFAC\nAcme Trading Ltd\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Watchlist{{Name: "John Kamau Doe", List: "OFAC"}, {Name: "Acme Trading Ltd"}}
+	if len(watchlist) != len(want) || watchlist[0] != want[0] || watchlist[1] != want[1] {
+		t.Errorf("LoadWatchlist() = %v, want %v", watchlist, want)
+	}
+
+	if _, err = LoadWatchlist(strings.NewReader(",OFAC\n")); err == nil {
+		t.Error("LoadWatchlist() accepted an entry without a name")
+	}
+}
+
+func TestRulesScreen(t *testing.T) {
+	rules := &Rules{
+		Watchlist:                Watchlist{{Name: "John Kamau Doe", List: "OFAC"}},
+		ReviewScore:              0.8,
+		RejectScore:              0.97,
+		UnusualFactor:            decimal.NewFromInt(5),
+		MinHistory:               3,
+		HistoryWindow:            24 * time.Hour,
+		NewBeneficiaryThresholds: map[string]decimal.Decimal{"KES": decimal.NewFromInt(10000)},
+	}
+	payment := func(amount int64, name string) *models.Payment {
+		return &models.Payment{
+			SenderProfileID:    "sender",
+			RecipientProfileID: "recipient",
+			Amount:             decimal.NewNullDecimal(decimal.NewFromInt(amount)),
+			Currency:           "KES",
+			OutBound:           true,
+			Extra:              datatypes.JSONMap{"recipient_name": name},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		payment  *models.Payment
+		history  *fakeHistory
+		decision string
+		reasons  int
+	}{
+		{name: "ordinary payment", payment: payment(1000, "Jane Wanjiru"),
+			history: &fakeHistory{count: 10, average: decimal.NewFromInt(900), paid: true}, decision: Approve},
+		{name: "watchlisted name", payment: payment(1000, "doe, john kamau"),
+			history: &fakeHistory{paid: true}, decision: Reject, reasons: 1},
+		{name: "similar name", payment: payment(1000, "John Doe"),
+			history: &fakeHistory{paid: true}, decision: Review, reasons: 1},
+		{name: "watchlisted recipient profile", payment: func() *models.Payment {
+			p := payment(1000, "")
+			p.RecipientName = "John Kamau Doe"
+			return p
+		}(), history: &fakeHistory{paid: true}, decision: Reject, reasons: 1},
+		{name: "unusual amount", payment: payment(9000, "Jane Wanjiru"),
+			history: &fakeHistory{count: 10, average: decimal.NewFromInt(1000), paid: true}, decision: Review, reasons: 1},
+		{name: "too little history to judge", payment: payment(9000, "Jane Wanjiru"),
+			history: &fakeHistory{count: 2, average: decimal.NewFromInt(1000), paid: true}, decision: Approve},
+		{name: "new beneficiary", payment: payment(20000, "Jane Wanjiru"),
+			history: &fakeHistory{}, decision: Review, reasons: 1},
+		{name: "small first payment", payment: payment(5000, "Jane Wanjiru"),
+			history: &fakeHistory{}, decision: Approve},
+		{name: "strictest decision wins", payment: payment(20000, "John Kamau Doe"),
+			history: &fakeHistory{}, decision: Reject, reasons: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules.History = tt.history
+			result, err := rules.Screen(context.Background(), tt.payment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Decision != tt.decision || len(result.Reasons) != tt.reasons {
+				t.Errorf("Screen() = %s %v, want %s with %d reasons",
+					result.Decision, result.Reasons, tt.decision, tt.reasons)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+// Package tenancy keeps every read and write of the service inside the tenant and partition
+// of the claims in its context. It hooks into gorm, so repositories and event handlers are
+// confined without having to filter by tenant themselves.
+package tenancy
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pitabwire/frame"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	// ErrNoTenancy is returned when the claims of a context name no tenant and partition to confine it to.
+	ErrNoTenancy = errors.New("claims carry no tenant and partition")
+	// ErrCrossTenantWrite is returned for writes of records that belong to another tenant or partition.
+	ErrCrossTenantWrite = errors.New("record belongs to another tenant or partition")
+)
+
+const (
+	tenantColumn    = "tenant_id"
+	partitionColumn = "partition_id"
+	callbackPrefix  = "tenancy:"
+)
+
+// Tenancy is the tenant and partition a context is confined to.
+type Tenancy struct {
+	TenantID    string
+	PartitionID string
+}
+
+// Policy decides which callers may work across tenants.
+type Policy struct {
+	// PrivilegedServices are the internal service accounts, by service name, whose reads and
+	// writes are not confined.
+	PrivilegedServices []string
+}
+
+// NewPolicy returns a policy privileging the comma separated service names.
+func NewPolicy(privilegedServices string) Policy {
+	policy := Policy{}
+	for _, name := range strings.Split(privilegedServices, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			policy.PrivilegedServices = append(policy.PrivilegedServices, name)
+		}
+	}
+	return policy
+}
+
+// Confinement returns the tenancy a context's reads and writes are held to, nil when it may
+// work across tenants. Contexts without claims belong to the service's own background work
+// and are not confined, neither are internal calls of a privileged service account. Every
+// other caller is confined to the tenant and partition of its claims.
+func (p Policy) Confinement(ctx context.Context) (*Tenancy, error) {
+	claims := frame.ClaimsFromContext(ctx)
+	if claims == nil {
+		return nil, nil
+	}
+	if frame.IsTenancyChecksOnClaimSkipped(ctx) && slices.Contains(p.PrivilegedServices, claims.GetServiceName()) {
+		return nil, nil
+	}
+
+	tenancy := &Tenancy{TenantID: claims.GetTenantID(), PartitionID: claims.GetPartitionID()}
+	if tenancy.TenantID == "" || tenancy.PartitionID == "" {
+		return nil, ErrNoTenancy
+	}
+	return tenancy, nil
+}
+
+var (
+	policyMu sync.RWMutex
+	policy   Policy
+
+	guarded sync.Map
+)
+
+// SetPolicy replaces the policy applied on guarded database handles, until it is set no
+// service account is privileged.
+func SetPolicy(p Policy) {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	policy = p
+}
+
+func currentPolicy() Policy {
+	policyMu.RLock()
+	defer policyMu.RUnlock()
+	return policy
+}
+
+// PartitionOf returns the partition a context's reads and writes are confined to under the
+// current policy, empty when the context may work across partitions.
+func PartitionOf(ctx context.Context) string {
+	confined, err := currentPolicy().Confinement(ctx)
+	if err != nil || confined == nil {
+		return ""
+	}
+	return confined.PartitionID
+}
+
+// DB returns a database handle of the service with tenancy enforced on it.
+func DB(ctx context.Context, service *frame.Service, readOnly bool) *gorm.DB {
+	return Guard(service.DB(ctx, readOnly))
+}
+
+// Guard enforces tenancy on a database handle and every session derived from it. The hooks
+// are installed once per connection pool.
+func Guard(db *gorm.DB) *gorm.DB {
+	if db == nil {
+		return nil
+	}
+	once, _ := guarded.LoadOrStore(db.Config, &sync.Once{})
+	once.(*sync.Once).Do(func() {
+		if err := register(db); err != nil {
+			panic(err)
+		}
+	})
+	return db
+}
+
+func register(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Query().Before("gorm:query").Register(callbackPrefix+"query", confineQuery); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register(callbackPrefix+"update", confineUpdate); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register(callbackPrefix+"delete", confineQuery); err != nil {
+		return err
+	}
+	if err := callbacks.Create().Before("gorm:create").Register(callbackPrefix+"create", confineCreate); err != nil {
+		return err
+	}
+	return callbacks.Create().After("gorm:create").Register(callbackPrefix+"upsert", checkUpsert)
+}
+
+// confinement returns the tenancy of the statement's context when its model is partitioned.
+func confinement(db *gorm.DB) *Tenancy {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || stmt.SQL.Len() > 0 ||
+		stmt.Schema.LookUpField(tenantColumn) == nil || stmt.Schema.LookUpField(partitionColumn) == nil {
+		return nil
+	}
+
+	tenancy, err := currentPolicy().Confinement(stmt.Context)
+	if err != nil {
+		_ = db.AddError(err)
+		return nil
+	}
+	return tenancy
+}
+
+func (t *Tenancy) condition() clause.Expression {
+	return clause.And(
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: tenantColumn}, Value: t.TenantID},
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: partitionColumn}, Value: t.PartitionID},
+	)
+}
+
+// confineQuery limits reads and deletes to the rows of the caller's tenancy.
+func confineQuery(db *gorm.DB) {
+	if tenancy := confinement(db); tenancy != nil {
+		db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{tenancy.condition()}})
+	}
+}
+
+// confineUpdate limits updates to the rows of the caller's tenancy and keeps them from
+// moving a row into another tenancy.
+func confineUpdate(db *gorm.DB) {
+	tenancy := confinement(db)
+	if tenancy == nil {
+		return
+	}
+	if updates, ok := db.Statement.Dest.(map[string]any); ok {
+		for column, value := range map[string]string{tenantColumn: tenancy.TenantID, partitionColumn: tenancy.PartitionID} {
+			if assigned, found := updates[column]; found && assigned != value {
+				_ = db.AddError(ErrCrossTenantWrite)
+				return
+			}
+		}
+	}
+	stampRecords(db, tenancy)
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{tenancy.condition()}})
+}
+
+// confineCreate stamps new records with the caller's tenancy and, on upserts, only lets the
+// conflicting row be overwritten when it belongs to the same tenancy.
+func confineCreate(db *gorm.DB) {
+	tenancy := confinement(db)
+	if tenancy == nil {
+		return
+	}
+	stampRecords(db, tenancy)
+
+	onConflict, ok := upsertClause(db.Statement)
+	if !ok {
+		return
+	}
+	onConflict.Where.Exprs = append(onConflict.Where.Exprs, tenancy.condition())
+	db.Statement.AddClause(onConflict)
+}
+
+// checkUpsert fails upserts whose conflicting rows were left alone for belonging to another
+// tenancy, the records were then neither inserted nor updated.
+func checkUpsert(db *gorm.DB) {
+	if db.Error != nil || db.DryRun {
+		return
+	}
+	if _, ok := upsertClause(db.Statement); !ok || confinement(db) == nil {
+		return
+	}
+	records := int64(1)
+	if rv := reflect.Indirect(db.Statement.ReflectValue); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		records = int64(rv.Len())
+	}
+	if db.RowsAffected < records {
+		_ = db.AddError(ErrCrossTenantWrite)
+	}
+}
+
+func upsertClause(stmt *gorm.Statement) (clause.OnConflict, bool) {
+	c, ok := stmt.Clauses[clause.OnConflict{}.Name()]
+	if !ok {
+		return clause.OnConflict{}, false
+	}
+	onConflict, ok := c.Expression.(clause.OnConflict)
+	if !ok || onConflict.DoNothing {
+		return clause.OnConflict{}, false
+	}
+	return onConflict, true
+}
+
+// stampRecords gives records without a tenancy the caller's one and rejects records of another.
+func stampRecords(db *gorm.DB, tenancy *Tenancy) {
+	stmt := db.Statement
+	fields := map[*schema.Field]string{
+		stmt.Schema.LookUpField(tenantColumn):    tenancy.TenantID,
+		stmt.Schema.LookUpField(partitionColumn): tenancy.PartitionID,
+	}
+
+	stamp := func(record reflect.Value) {
+		for field, value := range fields {
+			current, zero := field.ValueOf(stmt.Context, record)
+			if zero {
+				if err := field.Set(stmt.Context, record, value); err != nil {
+					_ = db.AddError(err)
+				}
+				continue
+			}
+			if current != value {
+				_ = db.AddError(ErrCrossTenantWrite)
+			}
+		}
+	}
+
+	switch rv := reflect.Indirect(stmt.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := range rv.Len() {
+			stamp(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		stamp(rv)
+	default:
+	}
+}
@@ -0,0 +1,207 @@
+package tenancy
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/antinvestor/service-payments/service/models"
+	"github.com/pitabwire/frame"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dryRunDB builds statements without a database, so the SQL they would run can be inspected.
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost user=test dbname=test"}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("could not open dry run database: %v", err)
+	}
+	SetPolicy(NewPolicy("service_jenga_api"))
+	t.Cleanup(func() { SetPolicy(Policy{}) })
+	return Guard(db)
+}
+
+func tenantContext(tenantID, partitionID string) context.Context {
+	claims := &frame.AuthenticationClaims{TenantID: tenantID, PartitionID: partitionID}
+	return claims.ClaimsToContext(context.Background())
+}
+
+func serviceContext(serviceName string) context.Context {
+	claims := &frame.AuthenticationClaims{
+		TenantID:    "tenant-a",
+		PartitionID: "partition-a",
+		ServiceName: serviceName,
+		Roles:       []string{"system_internal"},
+	}
+	return claims.ClaimsToContext(context.Background())
+}
+
+func confinedTo(stmt *gorm.Statement, tenantID, partitionID string) bool {
+	sql := stmt.SQL.String()
+	return strings.Contains(sql, `"tenant_id" = `) && strings.Contains(sql, `"partition_id" = `) &&
+		slices.Contains(stmt.Vars, any(tenantID)) && slices.Contains(stmt.Vars, any(partitionID))
+}
+
+func TestPolicyConfinement(t *testing.T) {
+	policy := NewPolicy(" service_jenga_api, ,service_daraja_api")
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		want    *Tenancy
+		wantErr error
+	}{
+		{name: "background work", ctx: context.Background()},
+		{name: "tenant caller", ctx: tenantContext("tenant-a", "partition-a"),
+			want: &Tenancy{TenantID: "tenant-a", PartitionID: "partition-a"}},
+		{name: "privileged service", ctx: serviceContext("service_daraja_api")},
+		{name: "internal service", ctx: serviceContext("service_notification"),
+			want: &Tenancy{TenantID: "tenant-a", PartitionID: "partition-a"}},
+		{name: "caller without partition", ctx: tenantContext("tenant-a", ""), wantErr: ErrNoTenancy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := policy.Confinement(tt.ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Confinement() error = %v, want %v", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Fatalf("Confinement() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartitionOf(t *testing.T) {
+	SetPolicy(NewPolicy("service_jenga_api"))
+	t.Cleanup(func() { SetPolicy(Policy{}) })
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{name: "background work", ctx: context.Background()},
+		{name: "tenant caller", ctx: tenantContext("tenant-a", "partition-b"), want: "partition-b"},
+		{name: "privileged service", ctx: serviceContext("service_jenga_api")},
+		{name: "caller without partition", ctx: tenantContext("tenant-a", "")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PartitionOf(tt.ctx); got != tt.want {
+				t.Errorf("PartitionOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadsAreConfined(t *testing.T) {
+	db := dryRunDB(t)
+
+	stmt := db.WithContext(tenantContext("tenant-a", "partition-a")).
+		First(&models.Payment{}, "id = ?", "payment-of-b").Statement
+	if !confinedTo(stmt, "tenant-a", "partition-a") {
+		t.Fatalf("tenant read is not confined: %s %v", stmt.SQL.String(), stmt.Vars)
+	}
+
+	stmt = db.WithContext(serviceContext("service_notification")).
+		Find(&[]*models.Status{}, "entity_id = ?", "payment-of-b").Statement
+	if !confinedTo(stmt, "tenant-a", "partition-a") {
+		t.Fatalf("unprivileged service read is not confined: %s", stmt.SQL.String())
+	}
+
+	for name, ctx := range map[string]context.Context{
+		"background work":    context.Background(),
+		"privileged service": serviceContext("service_jenga_api"),
+	} {
+		stmt = db.WithContext(ctx).First(&models.Payment{}, "id = ?", "payment-of-b").Statement
+		if strings.Contains(stmt.SQL.String(), "tenant_id") {
+			t.Fatalf("%s read is confined: %s", name, stmt.SQL.String())
+		}
+	}
+
+	stmt = db.WithContext(tenantContext("tenant-a", "partition-a")).
+		First(&models.ReferenceSequence{}, "scope = ?", "jenga").Statement
+	if strings.Contains(stmt.SQL.String(), "tenant_id") {
+		t.Fatalf("table without tenancy is confined: %s", stmt.SQL.String())
+	}
+
+	err := db.WithContext(tenantContext("tenant-a", "")).First(&models.Payment{}, "id = ?", "payment").Error
+	if !errors.Is(err, ErrNoTenancy) {
+		t.Fatalf("read without tenancy error = %v, want ErrNoTenancy", err)
+	}
+}
+
+func TestWritesAreConfined(t *testing.T) {
+	db := dryRunDB(t)
+	ctx := tenantContext("tenant-a", "partition-a")
+
+	payment := &models.Payment{}
+	payment.ID = "payment-a"
+	if err := db.WithContext(ctx).Create(payment).Error; err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if payment.TenantID != "tenant-a" || payment.PartitionID != "partition-a" {
+		t.Fatalf("created payment tenancy = %s/%s, want tenant-a/partition-a", payment.TenantID, payment.PartitionID)
+	}
+
+	foreign := &models.Payment{}
+	foreign.ID = "payment-b"
+	foreign.TenantID, foreign.PartitionID = "tenant-b", "partition-b"
+	if err := db.WithContext(ctx).Create(foreign).Error; !errors.Is(err, ErrCrossTenantWrite) {
+		t.Fatalf("creating another tenant's payment error = %v, want ErrCrossTenantWrite", err)
+	}
+	if err := db.WithContext(ctx).Save(foreign).Error; !errors.Is(err, ErrCrossTenantWrite) {
+		t.Fatalf("saving another tenant's payment error = %v, want ErrCrossTenantWrite", err)
+	}
+
+	stmt := db.WithContext(ctx).Model(&models.Payment{}).
+		Where("id = ?", "payment-of-b").Update("transaction_id", "TX1").Statement
+	if !confinedTo(stmt, "tenant-a", "partition-a") {
+		t.Fatalf("update is not confined: %s", stmt.SQL.String())
+	}
+
+	err := db.WithContext(ctx).Model(&models.Payment{}).
+		Where("id = ?", "payment-a").Update("tenant_id", "tenant-b").Error
+	if !errors.Is(err, ErrCrossTenantWrite) {
+		t.Fatalf("moving a payment to another tenant error = %v, want ErrCrossTenantWrite", err)
+	}
+
+	stmt = db.WithContext(ctx).Delete(&models.Payment{}, "id = ?", "payment-of-b").Statement
+	if !confinedTo(stmt, "tenant-a", "partition-a") {
+		t.Fatalf("delete is not confined: %s", stmt.SQL.String())
+	}
+}
+
+func TestUpsertsOnlyOverwriteOwnRows(t *testing.T) {
+	db := dryRunDB(t)
+
+	status := &models.Status{EntityID: "payment-a", EntityType: "payment"}
+	status.ID = "status-a"
+	stmt := db.WithContext(tenantContext("tenant-a", "partition-a")).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "id"}}, UpdateAll: true}).
+		Create(status).Statement
+
+	sql := stmt.SQL.String()
+	_, doUpdate, found := strings.Cut(sql, "DO UPDATE SET")
+	if !found {
+		t.Fatalf("upsert has no update: %s", sql)
+	}
+	if !strings.Contains(doUpdate, `WHERE "statuses"."tenant_id" = `) {
+		t.Fatalf("upsert may overwrite another tenant's row: %s", sql)
+	}
+
+	stmt = db.WithContext(context.Background()).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "id"}}, UpdateAll: true}).
+		Create(&models.Status{EntityID: "payment-b"}).Statement
+	if strings.Contains(stmt.SQL.String(), `"statuses"."tenant_id" =`) {
+		t.Fatalf("background upsert is confined: %s", stmt.SQL.String())
+	}
+}
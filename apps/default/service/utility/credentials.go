@@ -0,0 +1,53 @@
+package utility
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// ServiceCredentials authenticate the payment service's requests to the HTTP endpoints of the
+// integration services with a client credentials token from the OAuth2 service.
+type ServiceCredentials struct {
+	source oauth2.TokenSource
+}
+
+// NewServiceCredentials returns credentials for the client given, tokens are obtained from the
+// token endpoint and reused until they expire.
+func NewServiceCredentials(
+	ctx context.Context,
+	tokenURL, clientID, clientSecret string,
+	audiences ...string,
+) *ServiceCredentials {
+	cfg := clientcredentials.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     tokenURL,
+	}
+	if len(audiences) > 0 {
+		cfg.EndpointParams = url.Values{"audience": audiences}
+	}
+	return &ServiceCredentials{source: cfg.TokenSource(ctx)}
+}
+
+// Headers returns the headers of an authenticated JSON request. Requests made without
+// credentials are left unauthenticated.
+func (c *ServiceCredentials) Headers() (map[string][]string, error) {
+	headers := map[string][]string{
+		"Content-Type": {"application/json"},
+		"Accept":       {"application/json"},
+	}
+	if c == nil {
+		return headers, nil
+	}
+
+	token, err := c.source.Token()
+	if err != nil {
+		return nil, fmt.Errorf("obtain service token: %w", err)
+	}
+	headers["Authorization"] = []string{token.Type() + " " + token.AccessToken}
+	return headers, nil
+}
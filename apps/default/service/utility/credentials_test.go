@@ -0,0 +1,52 @@
+package utility_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antinvestor/service-payments/service/utility"
+)
+
+func TestServiceCredentialsHeaders(t *testing.T) {
+	issued := 0
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("audience") != "service_jenga_api" {
+			t.Errorf("token request form = %v", r.Form)
+		}
+		issued++
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "service-token",
+			"token_type":   "bearer",
+			"expires_in":   3600,
+		})
+	}))
+	defer tokens.Close()
+
+	credentials := utility.NewServiceCredentials(context.Background(), tokens.URL,
+		"service_payments", "secret", "service_jenga_api")
+	for range 2 {
+		headers, err := credentials.Headers()
+		if err != nil {
+			t.Fatalf("Headers() error = %v", err)
+		}
+		if got := headers["Authorization"]; len(got) != 1 || got[0] != "Bearer service-token" {
+			t.Errorf("Authorization = %v, want the service token", got)
+		}
+	}
+	if issued != 1 {
+		t.Errorf("issued %d tokens, want the token reused until it expires", issued)
+	}
+
+	var none *utility.ServiceCredentials
+	headers, err := none.Headers()
+	if err != nil || headers["Authorization"] != nil || headers["Content-Type"][0] != "application/json" {
+		t.Errorf("Headers() without credentials = %v, %v", headers, err)
+	}
+}
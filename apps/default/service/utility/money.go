@@ -1,7 +1,9 @@
 package utility
 
 import (
+	"fmt"
 	"math"
+	"strings"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -71,3 +73,25 @@ func CleanDecimal(d decimal.Decimal) decimal.Decimal {
 func IsValidTime(t *time.Time) bool {
 	return t != nil && !t.IsZero()
 }
+
+// ParseThresholds reads a comma separated list of "currency=amount" thresholds, keyed by the
+// upper cased currency.
+func ParseThresholds(spec string) (map[string]decimal.Decimal, error) {
+	thresholds := make(map[string]decimal.Decimal)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		currency, amount, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("threshold %q is not currency=amount", entry)
+		}
+		threshold, err := decimal.NewFromString(strings.TrimSpace(amount))
+		if err != nil {
+			return nil, fmt.Errorf("threshold %q: %w", entry, err)
+		}
+		thresholds[strings.ToUpper(strings.TrimSpace(currency))] = threshold
+	}
+	return thresholds, nil
+}
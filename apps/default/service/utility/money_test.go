@@ -0,0 +1,23 @@
+package utility
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseThresholds(t *testing.T) {
+	thresholds, err := ParseThresholds("kes=50000, USD=500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !thresholds["KES"].Equal(decimal.NewFromInt(50000)) || !thresholds["USD"].Equal(decimal.NewFromInt(500)) {
+		t.Errorf("ParseThresholds() = %v", thresholds)
+	}
+	if _, err = ParseThresholds("KES"); err == nil {
+		t.Error("ParseThresholds() accepted a threshold without an amount")
+	}
+	if _, err = ParseThresholds("KES=lots"); err == nil {
+		t.Error("ParseThresholds() accepted a threshold that is not an amount")
+	}
+}
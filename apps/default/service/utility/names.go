@@ -0,0 +1,103 @@
+package utility
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// fuzzyTokenThreshold is the minimum similarity for two name parts to be treated as the same word.
+	fuzzyTokenThreshold = 0.75
+	// initialMatchWeight is the credit given when an initial matches the first letter of a full name part.
+	initialMatchWeight = 0.5
+)
+
+// NameMatchScore compares two personal or business names and returns a score between 0 and 1.
+// Word order, case, punctuation and honorifics are ignored, initials score partially
+// and small spelling differences are tolerated.
+func NameMatchScore(a, b string) float64 {
+	left := nameTokens(a)
+	right := nameTokens(b)
+	if len(left) == 0 || len(right) == 0 {
+		return 0
+	}
+
+	used := make([]bool, len(right))
+	matched := 0.0
+	for _, l := range left {
+		best, bestIdx := 0.0, -1
+		for i, r := range right {
+			if used[i] {
+				continue
+			}
+			if score := tokenScore(l, r); score > best {
+				best, bestIdx = score, i
+			}
+		}
+		if bestIdx >= 0 {
+			used[bestIdx] = true
+			matched += best
+		}
+	}
+
+	return 2 * matched / float64(len(left)+len(right))
+}
+
+var nameHonorifics = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "miss": true, "dr": true, "prof": true, "eng": true,
+}
+
+func nameTokens(name string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if !nameHonorifics[f] {
+			tokens = append(tokens, f)
+		}
+	}
+	return tokens
+}
+
+func tokenScore(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	lenA, lenB := utf8.RuneCountInString(a), utf8.RuneCountInString(b)
+	firstA, _ := utf8.DecodeRuneInString(a)
+	firstB, _ := utf8.DecodeRuneInString(b)
+	if (lenA == 1 || lenB == 1) && firstA == firstB {
+		return initialMatchWeight
+	}
+
+	similarity := 1 - float64(levenshtein(a, b))/float64(max(lenA, lenB))
+	if similarity >= fuzzyTokenThreshold {
+		return similarity
+	}
+	return 0
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
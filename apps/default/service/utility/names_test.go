@@ -0,0 +1,34 @@
+package utility
+
+import "testing"
+
+func TestNameMatchScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		minScore float64
+		maxScore float64
+	}{
+		{name: "identical", a: "John Kamau", b: "John Kamau", minScore: 1, maxScore: 1},
+		{name: "order and case insensitive", a: "KAMAU JOHN", b: "john kamau", minScore: 1, maxScore: 1},
+		{name: "honorifics ignored", a: "Mr. John Kamau", b: "John Kamau", minScore: 1, maxScore: 1},
+		{name: "extra middle name", a: "John Mwangi Kamau", b: "John Kamau", minScore: 0.79, maxScore: 0.81},
+		{name: "initial", a: "J Kamau", b: "John Kamau", minScore: 0.74, maxScore: 0.76},
+		{name: "small typo", a: "Jon Kamau", b: "John Kamau", minScore: 0.85, maxScore: 0.99},
+		{name: "accented typo", a: "José Müller", b: "Jose Müller", minScore: 0.87, maxScore: 0.88},
+		{name: "accented initial", a: "É Okafor", b: "Émile Okafor", minScore: 0.74, maxScore: 0.76},
+		{name: "different person", a: "Mary Atieno", b: "John Kamau", minScore: 0, maxScore: 0},
+		{name: "empty", a: "", b: "John Kamau", minScore: 0, maxScore: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := NameMatchScore(tt.a, tt.b)
+			if score < tt.minScore || score > tt.maxScore {
+				t.Fatalf("NameMatchScore(%q, %q) = %v, want between %v and %v",
+					tt.a, tt.b, score, tt.minScore, tt.maxScore)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/daraja-api/config"
+	"github.com/antinvestor/daraja-api/service/coreapi"
+	"github.com/antinvestor/daraja-api/service/events/events_b2c"
+	"github.com/antinvestor/daraja-api/service/events/events_callback"
+	"github.com/antinvestor/daraja-api/service/events/events_stk"
+	handler "github.com/antinvestor/daraja-api/service/handler"
+	"github.com/antinvestor/daraja-api/service/middleware"
+	"github.com/antinvestor/daraja-api/service/models"
+	"github.com/antinvestor/daraja-api/service/router"
+	"github.com/gorilla/mux"
+	"github.com/pitabwire/frame"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func main() {
+	serviceName := "service_daraja_api"
+	darajaConfig, err := frame.ConfigFromEnv[config.DarajaConfig]()
+	if err != nil {
+		panic(err)
+	}
+	//nolint:revive,staticcheck // clientApi more readable than clientAPI
+	clientApi := coreapi.New(
+		darajaConfig.ConsumerKey,
+		darajaConfig.ConsumerSecret,
+		darajaConfig.ShortCode,
+		darajaConfig.PassKey,
+		darajaConfig.InitiatorName,
+		darajaConfig.SecurityCredential,
+		darajaConfig.Env,
+	)
+	ctx, service := frame.NewService(serviceName, frame.WithConfig(&darajaConfig), frame.WithDatastore())
+	defer service.Stop(ctx)
+	logger := service.Log(ctx).WithField("type", "main")
+
+	db := service.DB(ctx, false)
+	if db == nil {
+		logger.Fatal("Database connection is nil - check DATABASE_URL and database availability")
+		return
+	}
+	if migrateErr := db.AutoMigrate(&models.StkRequest{}, &models.Payout{}); migrateErr != nil {
+		logger.WithError(migrateErr).Fatal("Failed to auto-migrate database tables - cannot continue")
+		return
+	}
+
+	var paymentClient *paymentV1.PaymentClient
+	//nolint:staticcheck // DialContext deprecated but still supported in 1.x
+	clientConn, dialErr := grpc.DialContext(
+		ctx,
+		darajaConfig.PaymentServiceURI,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(1024*1024*16), // 16MB max message size
+			grpc.MaxCallSendMsgSize(1024*1024*16),
+		),
+	)
+	if dialErr != nil {
+		logger.WithError(dialErr).Error("Failed to connect to payment service")
+	} else {
+		paymentClient = &paymentV1.PaymentClient{
+			Client: paymentV1.NewPaymentServiceClient(clientConn),
+		}
+		logger.Info("Successfully connected to payment service at ", darajaConfig.PaymentServiceURI)
+	}
+
+	callbackURL := func(path string) string {
+		return handler.CallbackURL(darajaConfig.CallbackBaseURL, path, darajaConfig.CallbackSharedSecret)
+	}
+	stkCallback := &events_callback.DarajaStkCallback{Service: service, PaymentClient: paymentClient}
+	c2bConfirmation := &events_callback.DarajaC2BConfirmation{Service: service, PaymentClient: paymentClient}
+	b2cResult := &events_callback.DarajaB2CResult{Service: service, PaymentClient: paymentClient}
+	statusResult := &events_callback.DarajaTransactionStatusResult{Service: service, PaymentClient: paymentClient}
+
+	initiatePrompt := &events_stk.InitiatePrompt{
+		Service:       service,
+		Client:        clientApi,
+		PaymentClient: paymentClient,
+		CallbackURL:   callbackURL(handler.CallbackPathStk),
+	}
+	promptStatusQuery := &events_stk.PromptStatusQuery{
+		Service:       service,
+		Client:        clientApi,
+		PaymentClient: paymentClient,
+	}
+	payout := &events_b2c.Payout{
+		Service:         service,
+		Client:          clientApi,
+		PaymentClient:   paymentClient,
+		CommandID:       darajaConfig.B2CCommandID,
+		ResultURL:       callbackURL(handler.CallbackPathB2CResult),
+		QueueTimeOutURL: callbackURL(handler.CallbackPathB2CTimeout),
+		// Payouts without a definite answer are queried, the answer settles them
+		StatusResultURL:  callbackURL(handler.CallbackPathStatusResult),
+		StatusTimeOutURL: callbackURL(handler.CallbackPathStatusTimeout),
+	}
+
+	js := &handler.JobServer{
+		Service:         service,
+		Client:          clientApi,
+		PaymentClient:   paymentClient,
+		ShortCode:       darajaConfig.ShortCode,
+		CallbackBaseURL: darajaConfig.CallbackBaseURL,
+		CallbackSecret:  darajaConfig.CallbackSharedSecret,
+		C2BResponseType: darajaConfig.C2BResponseType,
+		Callbacks: map[string]frame.EventI{
+			handler.CallbackKindStk:               stkCallback,
+			handler.CallbackKindC2BConfirmation:   c2bConfirmation,
+			handler.CallbackKindB2CResult:         b2cResult,
+			handler.CallbackKindTransactionStatus: statusResult,
+		},
+	}
+	callbackAuth, err := newCallbackAuth(service, &darajaConfig)
+	if err != nil {
+		logger.WithError(err).Fatal("could not set up callback authentication")
+	}
+	var adminAuth mux.MiddlewareFunc
+	if darajaConfig.SecurelyRunService {
+		adminAuth = func(next http.Handler) http.Handler {
+			return service.AuthenticationMiddleware(next, darajaConfig.Oauth2JwtVerifyAudience,
+				darajaConfig.Oauth2JwtVerifyIssuer)
+		}
+	} else {
+		logger.Warn("Admin endpoints are not authenticated: secure by setting SECURELY_RUN_SERVICE=True")
+	}
+
+	eventHandlers := []frame.EventI{
+		stkCallback,
+		c2bConfirmation,
+		b2cResult,
+		statusResult,
+		initiatePrompt,
+		promptStatusQuery,
+		payout,
+	}
+
+	natsURL := darajaConfig.NATS_URL
+	promptTopic := darajaConfig.PromptTopic
+	promptStatusQueryTopic := darajaConfig.PromptStatusQueryTopic
+	payoutTopic := darajaConfig.PayoutTopic
+	serviceOptions := []frame.Option{
+		frame.WithHTTPHandler(router.NewRouter(js, callbackAuth.Middleware, adminAuth)),
+		frame.WithRegisterEvents(eventHandlers...),
+		frame.WithRegisterSubscriber(promptTopic, natsURL+promptTopic, initiatePrompt),
+		frame.WithRegisterSubscriber(promptStatusQueryTopic, natsURL+promptStatusQueryTopic, promptStatusQuery),
+		frame.WithRegisterSubscriber(payoutTopic, natsURL+payoutTopic, payout),
+		frame.WithBackgroundConsumer(payout.RunStatusQueries),
+	}
+
+	service.Init(ctx, serviceOptions...)
+
+	logger.Info("Daraja API service started successfully on port 8080")
+	if runErr := service.Run(ctx, ":8080"); runErr != nil {
+		logger.WithError(runErr).Fatal("Failed to run Daraja API service")
+	}
+}
+
+// newCallbackAuth configures the checks every Daraja callback must pass. Safaricom's
+// addresses are allowed unless an allow-list is configured.
+func newCallbackAuth(service *frame.Service, darajaConfig *config.DarajaConfig) (*middleware.CallbackAuth, error) {
+	allowedIPs := darajaConfig.CallbackAllowedIPs
+	if len(allowedIPs) == 0 {
+		allowedIPs = middleware.SafaricomCallbackIPs
+	}
+	allowedNets, err := middleware.ParseAllowedNets(allowedIPs)
+	if err != nil {
+		return nil, err
+	}
+
+	callbackAuth := &middleware.CallbackAuth{
+		SharedSecret:   darajaConfig.CallbackSharedSecret,
+		AllowedNets:    allowedNets,
+		TrustedProxies: darajaConfig.CallbackTrustedProxies,
+		Service:        service,
+	}
+	if err = callbackAuth.Validate(); err != nil {
+		return nil, fmt.Errorf("callback authentication: %w", err)
+	}
+	return callbackAuth, nil
+}
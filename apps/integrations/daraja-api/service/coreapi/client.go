@@ -0,0 +1,277 @@
+package coreapi
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/antinvestor/daraja-api/service/models"
+)
+
+const (
+	// timestampFormat is the layout Daraja expects STK push timestamps in.
+	timestampFormat = "20060102150405"
+
+	transactionTypePayBill  = "CustomerPayBillOnline"
+	commandTransactionQuery = "TransactionStatusQuery"
+	// identifierTypeShortCode identifies the party of a status query as an organisation short code.
+	identifierTypeShortCode = "4"
+)
+
+// Client represents the Daraja API client.
+type Client struct {
+	ConsumerKey        string
+	ConsumerSecret     string
+	ShortCode          string
+	PassKey            string
+	InitiatorName      string
+	SecurityCredential string
+	HttpClient         *http.Client //nolint:staticcheck // API field name
+	Env                string
+	// Now stamps STK push passwords, tests replace it to get predictable passwords.
+	Now func() time.Time
+}
+
+// New creates a new instance of the Daraja API client.
+func New(consumerKey, consumerSecret, shortCode, passKey, initiatorName, securityCredential, env string) *Client {
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		},
+		MaxIdleConns:       10,
+		IdleConnTimeout:    30 * time.Second,
+		DisableCompression: true,
+	}
+
+	return &Client{
+		ConsumerKey:        consumerKey,
+		ConsumerSecret:     consumerSecret,
+		ShortCode:          shortCode,
+		PassKey:            passKey,
+		InitiatorName:      initiatorName,
+		SecurityCredential: securityCredential,
+		HttpClient: &http.Client{
+			Transport: tr,
+			Timeout:   30 * time.Second,
+		},
+		Env: env,
+		Now: time.Now,
+	}
+}
+
+// APIError is returned for requests Daraja rejects.
+type APIError struct {
+	StatusCode int
+	Response   models.ErrorResponse
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("daraja request failed with status %d: %s %s",
+		e.StatusCode, e.Response.ErrorCode, e.Response.ErrorMessage)
+}
+
+// AccessTokenResponse represents the response structure for access token generation.
+type AccessTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   string `json:"expires_in"`
+}
+
+// GenerateAccessToken generates an OAuth access token from the consumer key and secret.
+func (c *Client) GenerateAccessToken() (*AccessTokenResponse, error) {
+	url := fmt.Sprintf("%s/oauth/v1/generate?grant_type=client_credentials", c.Env)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.ConsumerKey, c.ConsumerSecret)
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			fmt.Printf("failed to close response body: %v\n", closeErr)
+		}
+	}()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to generate token: %s, body: %s", resp.Status, string(respBody))
+	}
+
+	var tokenResponse AccessTokenResponse
+	if err := json.Unmarshal(respBody, &tokenResponse); err != nil {
+		return nil, err
+	}
+	return &tokenResponse, nil
+}
+
+// Password returns the STK push password for a timestamp, base64(shortCode+passKey+timestamp).
+func (c *Client) Password(timestamp string) string {
+	return base64.StdEncoding.EncodeToString([]byte(c.ShortCode + c.PassKey + timestamp))
+}
+
+func (c *Client) timestamp() string {
+	now := time.Now
+	if c.Now != nil {
+		now = c.Now
+	}
+	return now().Format(timestampFormat)
+}
+
+// STKPush asks the customer to authorise a payment to the short code on their phone.
+func (c *Client) STKPush(request models.STKPushRequest, accessToken string) (*models.STKPushResponse, error) {
+	url := fmt.Sprintf("%s/mpesa/stkpush/v1/processrequest", c.Env)
+
+	request.BusinessShortCode = c.ShortCode
+	request.Timestamp = c.timestamp()
+	request.Password = c.Password(request.Timestamp)
+	if request.TransactionType == "" {
+		request.TransactionType = transactionTypePayBill
+	}
+	if request.PartyB == "" {
+		request.PartyB = c.ShortCode
+	}
+
+	var pushResponse models.STKPushResponse
+	if err := c.doRequest(http.MethodPost, url, request, accessToken, &pushResponse); err != nil {
+		return nil, err
+	}
+	return &pushResponse, nil
+}
+
+// STKPushQuery fetches the outcome of an STK push. Pushes the customer has not answered yet
+// are reported by Daraja as an APIError.
+func (c *Client) STKPushQuery(checkoutRequestID, accessToken string) (*models.STKPushQueryResponse, error) {
+	url := fmt.Sprintf("%s/mpesa/stkpushquery/v1/query", c.Env)
+
+	timestamp := c.timestamp()
+	request := models.STKPushQueryRequest{
+		BusinessShortCode: c.ShortCode,
+		Password:          c.Password(timestamp),
+		Timestamp:         timestamp,
+		CheckoutRequestID: checkoutRequestID,
+	}
+
+	var queryResponse models.STKPushQueryResponse
+	if err := c.doRequest(http.MethodPost, url, request, accessToken, &queryResponse); err != nil {
+		return nil, err
+	}
+	return &queryResponse, nil
+}
+
+// RegisterC2BURLs registers the validation and confirmation URLs of the short code.
+func (c *Client) RegisterC2BURLs(
+	request models.C2BRegisterRequest,
+	accessToken string,
+) (*models.C2BRegisterResponse, error) {
+	url := fmt.Sprintf("%s/mpesa/c2b/v1/registerurl", c.Env)
+
+	request.ShortCode = c.ShortCode
+
+	var registerResponse models.C2BRegisterResponse
+	if err := c.doRequest(http.MethodPost, url, request, accessToken, &registerResponse); err != nil {
+		return nil, err
+	}
+	return &registerResponse, nil
+}
+
+// B2CPayment sends money to a customer, the outcome is delivered to the request's result URL.
+func (c *Client) B2CPayment(request models.B2CRequest, accessToken string) (*models.AsyncResponse, error) {
+	url := fmt.Sprintf("%s/mpesa/b2c/v3/paymentrequest", c.Env)
+
+	request.InitiatorName = c.InitiatorName
+	request.SecurityCredential = c.SecurityCredential
+	request.PartyA = c.ShortCode
+
+	var paymentResponse models.AsyncResponse
+	if err := c.doRequest(http.MethodPost, url, request, accessToken, &paymentResponse); err != nil {
+		return nil, err
+	}
+	return &paymentResponse, nil
+}
+
+// TransactionStatus queries the state of a transaction, the answer is delivered to the
+// request's result URL.
+func (c *Client) TransactionStatus(
+	request models.TransactionStatusRequest,
+	accessToken string,
+) (*models.AsyncResponse, error) {
+	url := fmt.Sprintf("%s/mpesa/transactionstatus/v1/query", c.Env)
+
+	request.Initiator = c.InitiatorName
+	request.SecurityCredential = c.SecurityCredential
+	request.CommandID = commandTransactionQuery
+	request.PartyA = c.ShortCode
+	request.IdentifierType = identifierTypeShortCode
+
+	var statusResponse models.AsyncResponse
+	if err := c.doRequest(http.MethodPost, url, request, accessToken, &statusResponse); err != nil {
+		return nil, err
+	}
+	return &statusResponse, nil
+}
+
+// doRequest sends an authorised request to Daraja and decodes the JSON response into out.
+// Rejected requests are returned as an APIError carrying Daraja's error code.
+func (c *Client) doRequest(method, url string, payload any, accessToken string, out any) error {
+	var body io.Reader
+	if payload != nil {
+		jsonBody, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewBuffer(jsonBody)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			fmt.Printf("failed to close response body: %v\n", closeErr)
+		}
+	}()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		if unmarshalErr := json.Unmarshal(respBody, &apiErr.Response); unmarshalErr != nil {
+			apiErr.Response.ErrorMessage = string(respBody)
+		}
+		return apiErr
+	}
+
+	if unmarshalErr := json.Unmarshal(respBody, out); unmarshalErr != nil {
+		return fmt.Errorf(
+			"failed to parse response: %w (status: %s, body: %s)",
+			unmarshalErr,
+			resp.Status,
+			string(respBody),
+		)
+	}
+	return nil
+}
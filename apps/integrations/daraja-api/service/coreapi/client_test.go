@@ -0,0 +1,139 @@
+package coreapi
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/antinvestor/daraja-api/service/coreapi/darajatest"
+	"github.com/antinvestor/daraja-api/service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testShortCode = "174379"
+	testPassKey   = "passkey"
+)
+
+func newTestClient(server *darajatest.Server) *Client {
+	client := New("key", "secret", testShortCode, testPassKey, "testapi", "credential", server.URL)
+	client.Now = func() time.Time {
+		return time.Date(2024, 3, 1, 14, 5, 9, 0, time.UTC)
+	}
+	return client
+}
+
+func TestGenerateAccessToken(t *testing.T) {
+	server := darajatest.NewServer()
+	defer server.Close()
+
+	token, err := newTestClient(server).GenerateAccessToken()
+	require.NoError(t, err)
+	assert.Equal(t, darajatest.AccessToken, token.AccessToken)
+
+	requests := server.Requests(darajatest.PathOAuth)
+	require.Len(t, requests, 1)
+	assert.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("key:secret")),
+		requests[0].Header.Get("Authorization"))
+}
+
+func TestPassword(t *testing.T) {
+	client := New("", "", testShortCode, testPassKey, "", "", "")
+
+	password, err := base64.StdEncoding.DecodeString(client.Password("20240301140509"))
+	require.NoError(t, err)
+	assert.Equal(t, testShortCode+testPassKey+"20240301140509", string(password))
+}
+
+func TestSTKPush(t *testing.T) {
+	server := darajatest.NewServer()
+	defer server.Close()
+
+	response, err := newTestClient(server).STKPush(models.STKPushRequest{
+		Amount:           10,
+		PartyA:           "254708374149",
+		PhoneNumber:      "254708374149",
+		CallBackURL:      "https://example.com/callbacks/stk",
+		AccountReference: "INV001",
+		TransactionDesc:  "Payment",
+	}, darajatest.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "ws_CO_191220191020363925", response.CheckoutRequestID)
+
+	requests := server.Requests(darajatest.PathSTKPush)
+	require.Len(t, requests, 1)
+	var sent models.STKPushRequest
+	require.NoError(t, requests[0].Decode(&sent))
+	assert.Equal(t, testShortCode, sent.BusinessShortCode)
+	assert.Equal(t, testShortCode, sent.PartyB)
+	assert.Equal(t, "20240301140509", sent.Timestamp)
+	assert.Equal(t, newTestClient(server).Password("20240301140509"), sent.Password)
+	assert.Equal(t, "CustomerPayBillOnline", sent.TransactionType)
+	assert.Equal(t, int64(10), sent.Amount)
+}
+
+func TestSTKPushQueryPending(t *testing.T) {
+	server := darajatest.NewServer()
+	defer server.Close()
+	server.Respond(darajatest.PathSTKPushQuery, http.StatusInternalServerError, models.ErrorResponse{
+		RequestID:    "2",
+		ErrorCode:    "500.001.1001",
+		ErrorMessage: "The transaction is being processed",
+	})
+
+	_, err := newTestClient(server).STKPushQuery("ws_CO_191220191020363925", darajatest.AccessToken)
+	require.Error(t, err)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+	assert.Equal(t, "500.001.1001", apiErr.Response.ErrorCode)
+}
+
+func TestRequestWithInvalidToken(t *testing.T) {
+	server := darajatest.NewServer()
+	defer server.Close()
+
+	_, err := newTestClient(server).B2CPayment(models.B2CRequest{Amount: 10}, "expired")
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+}
+
+func TestB2CPaymentAndTransactionStatus(t *testing.T) {
+	server := darajatest.NewServer()
+	defer server.Close()
+	client := newTestClient(server)
+
+	_, err := client.B2CPayment(models.B2CRequest{
+		OriginatorConversationID: "payment-1",
+		CommandID:                "BusinessPayment",
+		Amount:                   250,
+		PartyB:                   "254708374149",
+	}, darajatest.AccessToken)
+	require.NoError(t, err)
+
+	var payout models.B2CRequest
+	require.NoError(t, server.Requests(darajatest.PathB2CPayment)[0].Decode(&payout))
+	assert.Equal(t, "testapi", payout.InitiatorName)
+	assert.Equal(t, "credential", payout.SecurityCredential)
+	assert.Equal(t, testShortCode, payout.PartyA)
+	assert.Equal(t, "payment-1", payout.OriginatorConversationID)
+
+	_, err = client.TransactionStatus(models.TransactionStatusRequest{
+		TransactionID: "OEI2AK4Q16",
+		Occasion:      "payment-1",
+	}, darajatest.AccessToken)
+	require.NoError(t, err)
+
+	var query models.TransactionStatusRequest
+	require.NoError(t, server.Requests(darajatest.PathTransactionStatus)[0].Decode(&query))
+	assert.Equal(t, "TransactionStatusQuery", query.CommandID)
+	assert.Equal(t, "4", query.IdentifierType)
+	assert.Equal(t, testShortCode, query.PartyA)
+	assert.Equal(t, "payment-1", query.Occasion)
+}
@@ -0,0 +1,154 @@
+// Package darajatest runs a local stand-in for the Daraja API so the client and the events
+// built on it can be exercised without Safaricom's sandbox. Every endpoint answers with a
+// successful default response that tests can replace with Respond.
+package darajatest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/antinvestor/daraja-api/service/models"
+)
+
+// Daraja endpoints served by the stand-in.
+const (
+	PathOAuth             = "/oauth/v1/generate"
+	PathSTKPush           = "/mpesa/stkpush/v1/processrequest"
+	PathSTKPushQuery      = "/mpesa/stkpushquery/v1/query"
+	PathC2BRegister       = "/mpesa/c2b/v1/registerurl"
+	PathB2CPayment        = "/mpesa/b2c/v3/paymentrequest"
+	PathTransactionStatus = "/mpesa/transactionstatus/v1/query"
+
+	// AccessToken is the token the stand-in issues and expects on every other request.
+	AccessToken = "darajatest-token"
+)
+
+// Request is a request received by the stand-in.
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// Decode unmarshals the request body into out.
+func (r Request) Decode(out any) error {
+	return json.Unmarshal(r.Body, out)
+}
+
+type response struct {
+	status int
+	body   any
+}
+
+// Server is the Daraja stand-in, close it once the test is done.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	requests  []Request
+	responses map[string]response
+}
+
+// NewServer starts a stand-in answering every endpoint successfully.
+func NewServer() *Server {
+	s := &Server{
+		responses: map[string]response{
+			PathOAuth: {http.StatusOK, map[string]string{
+				"access_token": AccessToken,
+				"expires_in":   "3599",
+			}},
+			PathSTKPush: {http.StatusOK, models.STKPushResponse{
+				MerchantRequestID:   "29115-34620561-1",
+				CheckoutRequestID:   "ws_CO_191220191020363925",
+				ResponseCode:        "0",
+				ResponseDescription: "Success. Request accepted for processing",
+				CustomerMessage:     "Success. Request accepted for processing",
+			}},
+			PathSTKPushQuery: {http.StatusOK, models.STKPushQueryResponse{
+				ResponseCode:        "0",
+				ResponseDescription: "The service request has been accepted successfully",
+				MerchantRequestID:   "29115-34620561-1",
+				CheckoutRequestID:   "ws_CO_191220191020363925",
+				ResultCode:          "0",
+				ResultDesc:          "The service request is processed successfully.",
+			}},
+			PathC2BRegister: {http.StatusOK, models.C2BRegisterResponse{
+				ResponseCode:        "0",
+				ResponseDescription: "Success",
+			}},
+			PathB2CPayment: {http.StatusOK, models.AsyncResponse{
+				ConversationID:           "AG_20191219_00005797af5d7d75f652",
+				OriginatorConversationID: "16740-34861180-1",
+				ResponseCode:             "0",
+				ResponseDescription:      "Accept the service request successfully.",
+			}},
+			PathTransactionStatus: {http.StatusOK, models.AsyncResponse{
+				ConversationID:           "AG_20191219_00006c6fddb15123addf",
+				OriginatorConversationID: "16738-27456357-1",
+				ResponseCode:             "0",
+				ResponseDescription:      "Accept the service request successfully.",
+			}},
+		},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Respond replaces the response of an endpoint.
+func (s *Server) Respond(path string, status int, body any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.responses[path] = response{status: status, body: body}
+}
+
+// Requests returns the requests received on an endpoint in the order they arrived.
+func (s *Server) Requests(path string) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var requests []Request
+	for _, request := range s.requests {
+		if request.Path == path {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Header: r.Header.Clone(), Body: body})
+	resp, ok := s.responses[r.URL.Path]
+	s.mu.Unlock()
+
+	switch {
+	case !ok:
+		resp = response{http.StatusNotFound, models.ErrorResponse{
+			ErrorCode:    "404.001.01",
+			ErrorMessage: "Resource not found",
+		}}
+	case r.URL.Path == PathOAuth:
+		if _, _, hasAuth := r.BasicAuth(); !hasAuth {
+			resp = response{http.StatusBadRequest, models.ErrorResponse{
+				ErrorCode:    "400.008.01",
+				ErrorMessage: "Invalid Authentication passed",
+			}}
+		}
+	case r.Header.Get("Authorization") != "Bearer "+AccessToken:
+		resp = response{http.StatusUnauthorized, models.ErrorResponse{
+			ErrorCode:    "404.001.03",
+			ErrorMessage: "Invalid Access Token",
+		}}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.status)
+	_ = json.NewEncoder(w).Encode(resp.body)
+}
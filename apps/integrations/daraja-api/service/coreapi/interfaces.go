@@ -0,0 +1,13 @@
+package coreapi
+
+import "github.com/antinvestor/daraja-api/service/models"
+
+// DarajaApiClient is the part of the Daraja API the service uses.
+type DarajaApiClient interface { //nolint:staticcheck // API interface name
+	GenerateAccessToken() (*AccessTokenResponse, error)
+	STKPush(request models.STKPushRequest, accessToken string) (*models.STKPushResponse, error)
+	STKPushQuery(checkoutRequestID, accessToken string) (*models.STKPushQueryResponse, error)
+	RegisterC2BURLs(request models.C2BRegisterRequest, accessToken string) (*models.C2BRegisterResponse, error)
+	B2CPayment(request models.B2CRequest, accessToken string) (*models.AsyncResponse, error)
+	TransactionStatus(request models.TransactionStatusRequest, accessToken string) (*models.AsyncResponse, error)
+}
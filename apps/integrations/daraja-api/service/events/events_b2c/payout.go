@@ -0,0 +1,344 @@
+//nolint:revive // package name matches directory structure
+package events_b2c //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/daraja-api/service/coreapi"
+	"github.com/antinvestor/daraja-api/service/models"
+	"github.com/antinvestor/daraja-api/service/repository"
+	"github.com/antinvestor/daraja-api/service/utility"
+	"github.com/pitabwire/frame"
+)
+
+const (
+	entityTypePayment    = "payment"
+	defaultRemarks       = "Payout"
+	statusQueryRemarks   = "Payout status query"
+	responseCodeAccepted = "0"
+
+	defaultStatusQueryDelay = 2 * time.Minute
+	statusQueryBatchSize    = 100
+)
+
+// Payout sends outbound payments to the recipient's M-Pesa wallet through B2C. The payment id
+// is used as the originator conversation id so the result callback can be matched to it.
+type Payout struct {
+	Service         *frame.Service
+	Client          coreapi.DarajaApiClient
+	PaymentClient   *paymentV1.PaymentClient
+	CommandID       string
+	ResultURL       string
+	QueueTimeOutURL string
+	// StatusResultURL and StatusTimeOutURL receive the answers to payout status queries.
+	StatusResultURL  string
+	StatusTimeOutURL string
+	// StatusQueryDelay is how long an unanswered payout is left before Daraja is queried for it.
+	StatusQueryDelay time.Duration
+	// Payouts stores the progress of each payout, the service datastore is used when it is nil.
+	Payouts repository.PayoutRepository
+}
+
+// Name returns the name of the event handler.
+func (h *Payout) Name() string {
+	return "daraja.payout"
+}
+
+// PayloadType returns the type of payload this event expects.
+func (h *Payout) PayloadType() any {
+	return &models.Payment{}
+}
+
+// Validate validates the payload.
+func (h *Payout) Validate(_ context.Context, payload any) error {
+	payment, ok := payload.(*models.Payment)
+	if !ok {
+		return errors.New("invalid payload type, expected *models.Payment")
+	}
+
+	if payment.ID == "" {
+		return errors.New("payment ID is required")
+	}
+	if !payment.OutBound {
+		return errors.New("only outbound payments can be paid out")
+	}
+	if !payment.Amount.Valid || !payment.Amount.Decimal.IsPositive() {
+		return errors.New("payment amount is required")
+	}
+	if !payment.Amount.Decimal.Equal(payment.Amount.Decimal.Truncate(0)) {
+		return errors.New("M-Pesa only accepts whole shilling amounts")
+	}
+	if _, err := utility.NormalizeMSISDN(RecipientMSISDN(payment)); err != nil {
+		return fmt.Errorf("recipient mobile number: %w", err)
+	}
+
+	return nil
+}
+
+// Handle implements the frame.SubscribeWorker interface.
+func (h *Payout) Handle(ctx context.Context, _ map[string]string, message []byte) error {
+	payload := h.PayloadType()
+	if err := json.Unmarshal(message, payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	if err := h.Validate(ctx, payload); err != nil {
+		return fmt.Errorf("payload validation failed: %w", err)
+	}
+
+	return h.Execute(ctx, payload)
+}
+
+// Execute sends the B2C request, the outcome arrives on the result URL. Progress is recorded
+// before Daraja is asked to pay, a payout seen before carries on from where it got to and is
+// never sent twice.
+func (h *Payout) Execute(ctx context.Context, payload any) error {
+	payment, ok := payload.(*models.Payment)
+	if !ok {
+		return errors.New("invalid payload type, expected *models.Payment")
+	}
+
+	logger := h.Service.Log(ctx).WithField("paymentId", payment.ID)
+	logger.Info("Processing daraja.payout event")
+
+	phoneNumber, err := utility.NormalizeMSISDN(RecipientMSISDN(payment))
+	if err != nil {
+		return h.updateStatus(ctx, payment.ID, commonv1.STATUS_FAILED, map[string]string{"error": err.Error()})
+	}
+
+	payouts := h.payouts(ctx)
+	progress := &models.Payout{
+		PaymentID:   payment.ID,
+		PhoneNumber: phoneNumber,
+		Amount:      payment.Amount.Decimal,
+		State:       models.PayoutStateNew,
+	}
+	if _, err = payouts.Create(ctx, progress); err != nil {
+		return fmt.Errorf("record payout: %w", err)
+	}
+	if progress, err = payouts.GetByPaymentID(ctx, payment.ID); err != nil {
+		return fmt.Errorf("load payout: %w", err)
+	}
+
+	switch progress.State {
+	case models.PayoutStatePaid:
+		logger.Info("payout was already paid")
+		return nil
+	case models.PayoutStateFailed:
+		// Only reporting the failure may have failed
+		return h.updateStatus(ctx, payment.ID, commonv1.STATUS_FAILED, map[string]string{"error": progress.LastError})
+	case models.PayoutStateAccepted:
+		// Daraja accepted the payout, only reporting it may have failed
+		return h.updateStatus(ctx, payment.ID, commonv1.STATUS_IN_PROCESS, map[string]string{
+			"conversation_id": progress.ConversationID,
+			"message":         "Accepted by Daraja",
+		})
+	case models.PayoutStateSubmitted:
+		// Daraja may have paid already, the payout is queried instead of sent again
+		return h.queryStatus(ctx, progress)
+	}
+
+	remarks := defaultRemarks
+	if value, isString := payment.Extra["remarks"].(string); isString && value != "" {
+		remarks = value
+	}
+
+	token, err := h.Client.GenerateAccessToken()
+	if err != nil {
+		logger.WithError(err).Error("failed to generate access token")
+		return fmt.Errorf("generate access token: %w", err)
+	}
+
+	submittedAt := time.Now()
+	progress.State = models.PayoutStateSubmitted
+	progress.SubmittedAt = &submittedAt
+	if err = payouts.Save(ctx, progress); err != nil {
+		return fmt.Errorf("record payout submission: %w", err)
+	}
+
+	response, err := h.Client.B2CPayment(models.B2CRequest{
+		OriginatorConversationID: payment.ID,
+		CommandID:                h.CommandID,
+		Amount:                   payment.Amount.Decimal.IntPart(),
+		PartyB:                   phoneNumber,
+		Remarks:                  remarks,
+		QueueTimeOutURL:          h.QueueTimeOutURL,
+		ResultURL:                h.ResultURL,
+		Occasion:                 payment.ReferenceID,
+	}, token.AccessToken)
+	if err != nil {
+		if rejected(err) {
+			logger.WithError(err).Warn("B2C payment was rejected")
+			return h.fail(ctx, progress, map[string]string{"error": err.Error()})
+		}
+		h.unconfirmed(ctx, progress, err)
+		return nil
+	}
+	if response.ResponseCode != responseCodeAccepted {
+		return h.fail(ctx, progress, map[string]string{
+			"code":    response.ResponseCode,
+			"message": response.ResponseDescription,
+		})
+	}
+
+	progress.State = models.PayoutStateAccepted
+	progress.ConversationID = response.ConversationID
+	if err = payouts.Save(ctx, progress); err != nil {
+		// Left submitted the payout is queried, it is not sent again
+		logger.WithError(err).Error("failed to record accepted payout")
+	}
+
+	return h.updateStatus(ctx, payment.ID, commonv1.STATUS_IN_PROCESS, map[string]string{
+		"conversation_id": response.ConversationID,
+		"message":         response.ResponseDescription,
+	})
+}
+
+// RunStatusQueries periodically queries Daraja for payouts it gave no definite answer for. It
+// blocks until the context is cancelled and is meant to run as a background consumer.
+func (h *Payout) RunStatusQueries(ctx context.Context) error {
+	ticker := time.NewTicker(h.statusQueryDelay())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			h.QueryUnconfirmed(ctx)
+		}
+	}
+}
+
+// QueryUnconfirmed queries the payouts that have waited for Daraja longer than the status
+// query delay, the answers are recorded by the transaction status result callback.
+func (h *Payout) QueryUnconfirmed(ctx context.Context) {
+	logger := h.Service.Log(ctx).WithField("type", "daraja.payout.status.query")
+
+	submitted, err := h.payouts(ctx).ListSubmitted(ctx, time.Now().Add(-h.statusQueryDelay()), statusQueryBatchSize)
+	if err != nil {
+		logger.WithError(err).Warn("could not list unconfirmed payouts")
+		return
+	}
+	for _, progress := range submitted {
+		if err = h.queryStatus(ctx, progress); err != nil {
+			logger.WithError(err).WithField("paymentId", progress.PaymentID).Warn("could not query payout")
+		}
+	}
+}
+
+// unconfirmed records a payout that may have reached Daraja without an answer and queries it.
+func (h *Payout) unconfirmed(ctx context.Context, progress *models.Payout, err error) {
+	logger := h.Service.Log(ctx).WithField("paymentId", progress.PaymentID)
+	logger.WithError(err).Warn("payout outcome is unknown, it will be queried")
+
+	progress.LastError = err.Error()
+	if saveErr := h.payouts(ctx).Save(ctx, progress); saveErr != nil {
+		logger.WithError(saveErr).Warn("failed to record payout error")
+	}
+	if queryErr := h.queryStatus(ctx, progress); queryErr != nil {
+		logger.WithError(queryErr).Warn("failed to query payout, it is queried again later")
+	}
+	if statusErr := h.updateStatus(ctx, progress.PaymentID, commonv1.STATUS_IN_PROCESS, map[string]string{
+		"message": "Awaiting confirmation from Daraja",
+		"error":   err.Error(),
+	}); statusErr != nil {
+		logger.WithError(statusErr).Warn("failed to report unconfirmed payout")
+	}
+}
+
+// fail records a payout Daraja refused and fails its payment.
+func (h *Payout) fail(ctx context.Context, progress *models.Payout, extras map[string]string) error {
+	progress.State = models.PayoutStateFailed
+	progress.LastError = extras["error"]
+	if progress.LastError == "" {
+		progress.LastError = extras["message"]
+	}
+	if err := h.payouts(ctx).Save(ctx, progress); err != nil {
+		return fmt.Errorf("record failed payout: %w", err)
+	}
+	return h.updateStatus(ctx, progress.PaymentID, commonv1.STATUS_FAILED, extras)
+}
+
+// queryStatus asks Daraja for the state of a payout by the originator conversation id it was
+// sent with, the answer arrives on the status result URL.
+func (h *Payout) queryStatus(ctx context.Context, progress *models.Payout) error {
+	token, err := h.Client.GenerateAccessToken()
+	if err != nil {
+		return fmt.Errorf("generate access token: %w", err)
+	}
+	_, err = h.Client.TransactionStatus(models.TransactionStatusRequest{
+		OriginatorConversationID: progress.PaymentID,
+		ResultURL:                h.StatusResultURL,
+		QueueTimeOutURL:          h.StatusTimeOutURL,
+		Remarks:                  statusQueryRemarks,
+		// The result callback reads the payment id back from the occasion
+		Occasion: progress.PaymentID,
+	}, token.AccessToken)
+	if err != nil {
+		return fmt.Errorf("query payout status: %w", err)
+	}
+	return nil
+}
+
+// rejected reports whether Daraja refused a request, anything else may have reached it.
+func rejected(err error) bool {
+	var apiErr *coreapi.APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode < http.StatusInternalServerError
+}
+
+func (h *Payout) payouts(ctx context.Context) repository.PayoutRepository {
+	if h.Payouts != nil {
+		return h.Payouts
+	}
+	return repository.NewPayoutRepository(ctx, h.Service)
+}
+
+func (h *Payout) statusQueryDelay() time.Duration {
+	if h.StatusQueryDelay > 0 {
+		return h.StatusQueryDelay
+	}
+	return defaultStatusQueryDelay
+}
+
+func (h *Payout) updateStatus(
+	ctx context.Context,
+	paymentID string,
+	status commonv1.STATUS,
+	extras map[string]string,
+) error {
+	extras["entity_type"] = entityTypePayment
+	_, err := h.PaymentClient.StatusUpdate(ctx, &commonv1.StatusUpdateRequest{
+		Id:     paymentID,
+		State:  commonv1.STATE_ACTIVE,
+		Status: status,
+		Extras: extras,
+	})
+	if err != nil {
+		h.Service.Log(ctx).WithError(err).WithField("paymentId", paymentID).
+			Error("failed to update payment status")
+		return fmt.Errorf("payment client status update: %w", err)
+	}
+	return nil
+}
+
+// RecipientMSISDN returns the wallet a payout goes to, the number captured on the payment is
+// preferred over the extras older payments carry it in.
+func RecipientMSISDN(payment *models.Payment) string {
+	if payment.RecipientMSISDN != "" {
+		return payment.RecipientMSISDN
+	}
+	for _, key := range []string{"beneficiary_msisdn", "mobile_number"} {
+		if value, ok := payment.Extra[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
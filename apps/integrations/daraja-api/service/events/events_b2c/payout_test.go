@@ -0,0 +1,230 @@
+//nolint:revive // package name matches directory structure
+package events_b2c //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/daraja-api/service/coreapi"
+	"github.com/antinvestor/daraja-api/service/coreapi/darajatest"
+	"github.com/antinvestor/daraja-api/service/models"
+	"github.com/pitabwire/frame"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// paymentService records the statuses reported to it, failing them while err is set.
+type paymentService struct {
+	paymentV1.PaymentServiceClient
+
+	mu       sync.Mutex
+	err      error
+	statuses []*commonv1.StatusUpdateRequest
+}
+
+func (p *paymentService) StatusUpdate(
+	_ context.Context,
+	in *commonv1.StatusUpdateRequest,
+	_ ...grpc.CallOption,
+) (*commonv1.StatusUpdateResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return nil, p.err
+	}
+	p.statuses = append(p.statuses, in)
+	return &commonv1.StatusUpdateResponse{}, nil
+}
+
+func (p *paymentService) lastStatus(t *testing.T) commonv1.STATUS {
+	t.Helper()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	require.NotEmpty(t, p.statuses)
+	return p.statuses[len(p.statuses)-1].GetStatus()
+}
+
+// payouts keeps payout progress in memory.
+type payouts struct {
+	mu      sync.Mutex
+	entries map[string]models.Payout
+}
+
+func (p *payouts) GetByPaymentID(_ context.Context, paymentID string) (*models.Payout, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	payout, ok := p.entries[paymentID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &payout, nil
+}
+
+func (p *payouts) Create(ctx context.Context, payout *models.Payout) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.entries[payout.PaymentID]; ok {
+		return false, nil
+	}
+	payout.GenID(ctx)
+	p.entries[payout.PaymentID] = *payout
+	return true, nil
+}
+
+func (p *payouts) Save(_ context.Context, payout *models.Payout) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.entries[payout.PaymentID] = *payout
+	return nil
+}
+
+func (p *payouts) ListSubmitted(_ context.Context, before time.Time, _ int) ([]*models.Payout, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var submitted []*models.Payout
+	for _, payout := range p.entries {
+		if payout.State == models.PayoutStateSubmitted && !payout.SubmittedAt.After(before) {
+			submitted = append(submitted, &payout)
+		}
+	}
+	return submitted, nil
+}
+
+func newPayout(t *testing.T) (*Payout, *darajatest.Server, *paymentService) {
+	t.Helper()
+	ctx, service := frame.NewService(t.Name(), frame.WithNoopDriver())
+	t.Cleanup(func() { service.Stop(ctx) })
+
+	server := darajatest.NewServer()
+	t.Cleanup(server.Close)
+
+	payments := &paymentService{}
+	return &Payout{
+		Service:         service,
+		Client:          coreapi.New("key", "secret", "600000", "passkey", "testapi", "credential", server.URL),
+		PaymentClient:   &paymentV1.PaymentClient{Client: payments},
+		CommandID:       "BusinessPayment",
+		ResultURL:       "https://example.com/callbacks/b2c/result",
+		QueueTimeOutURL: "https://example.com/callbacks/b2c/timeout",
+		Payouts:         &payouts{entries: make(map[string]models.Payout)},
+	}, server, payments
+}
+
+func payoutPayment() *models.Payment {
+	payment := &models.Payment{
+		RecipientMSISDN: "254712345678",
+		Amount:          decimal.NewNullDecimal(decimal.NewFromInt(1500)),
+		Currency:        "KES",
+		OutBound:        true,
+	}
+	payment.ID = "payment-1"
+	return payment
+}
+
+func (h *Payout) progress(t *testing.T) *models.Payout {
+	t.Helper()
+	progress, err := h.Payouts.GetByPaymentID(t.Context(), "payment-1")
+	require.NoError(t, err)
+	return progress
+}
+
+func TestPayoutAccepted(t *testing.T) {
+	event, server, payments := newPayout(t)
+
+	require.NoError(t, event.Validate(t.Context(), payoutPayment()))
+	require.NoError(t, event.Execute(t.Context(), payoutPayment()))
+	assert.Equal(t, commonv1.STATUS_IN_PROCESS, payments.lastStatus(t))
+	assert.Equal(t, models.PayoutStateAccepted, event.progress(t).State)
+
+	var request models.B2CRequest
+	require.NoError(t, server.Requests(darajatest.PathB2CPayment)[0].Decode(&request))
+	assert.Equal(t, "payment-1", request.OriginatorConversationID)
+	assert.Equal(t, "254712345678", request.PartyB)
+	assert.Equal(t, int64(1500), request.Amount)
+}
+
+func TestPayoutUnknownOutcomeIsQueried(t *testing.T) {
+	event, server, payments := newPayout(t)
+	server.Respond(darajatest.PathB2CPayment, http.StatusServiceUnavailable, models.ErrorResponse{
+		ErrorCode:    "503.001.01",
+		ErrorMessage: "Service unavailable",
+	})
+
+	// Daraja may have accepted the payout, a redelivery would pay again
+	require.NoError(t, event.Execute(t.Context(), payoutPayment()))
+	assert.Equal(t, commonv1.STATUS_IN_PROCESS, payments.lastStatus(t))
+	assert.Equal(t, models.PayoutStateSubmitted, event.progress(t).State)
+
+	require.NoError(t, event.Execute(t.Context(), payoutPayment()))
+	assert.Len(t, server.Requests(darajatest.PathB2CPayment), 1)
+
+	queries := server.Requests(darajatest.PathTransactionStatus)
+	require.Len(t, queries, 2)
+	var query models.TransactionStatusRequest
+	require.NoError(t, queries[0].Decode(&query))
+	assert.Equal(t, "payment-1", query.OriginatorConversationID)
+	assert.Equal(t, "payment-1", query.Occasion)
+}
+
+func TestPayoutReportFailureIsNotResent(t *testing.T) {
+	event, server, payments := newPayout(t)
+	payments.err = errors.New("payment service unavailable")
+
+	require.Error(t, event.Execute(t.Context(), payoutPayment()))
+	assert.Equal(t, models.PayoutStateAccepted, event.progress(t).State)
+
+	// The redelivery only reports the accepted payout
+	payments.err = nil
+	require.NoError(t, event.Execute(t.Context(), payoutPayment()))
+	assert.Len(t, server.Requests(darajatest.PathB2CPayment), 1)
+	assert.Equal(t, commonv1.STATUS_IN_PROCESS, payments.lastStatus(t))
+}
+
+func TestPayoutRejected(t *testing.T) {
+	event, server, payments := newPayout(t)
+	server.Respond(darajatest.PathB2CPayment, http.StatusBadRequest, models.ErrorResponse{
+		ErrorCode:    "400.002.02",
+		ErrorMessage: "Bad Request - Invalid PartyB",
+	})
+
+	require.NoError(t, event.Execute(t.Context(), payoutPayment()))
+	assert.Equal(t, commonv1.STATUS_FAILED, payments.lastStatus(t))
+	assert.Equal(t, models.PayoutStateFailed, event.progress(t).State)
+
+	require.NoError(t, event.Execute(t.Context(), payoutPayment()))
+	assert.Len(t, server.Requests(darajatest.PathB2CPayment), 1)
+	assert.Empty(t, server.Requests(darajatest.PathTransactionStatus))
+}
+
+func TestQueryUnconfirmed(t *testing.T) {
+	event, server, _ := newPayout(t)
+	server.Respond(darajatest.PathB2CPayment, http.StatusBadGateway, nil)
+	require.NoError(t, event.Execute(t.Context(), payoutPayment()))
+
+	event.StatusQueryDelay = time.Hour
+	event.QueryUnconfirmed(t.Context())
+	assert.Len(t, server.Requests(darajatest.PathTransactionStatus), 1)
+
+	event.StatusQueryDelay = time.Nanosecond
+	event.QueryUnconfirmed(t.Context())
+	assert.Len(t, server.Requests(darajatest.PathTransactionStatus), 2)
+}
+
+func TestRecipientMSISDN(t *testing.T) {
+	payment := payoutPayment()
+	payment.Extra = datatypes.JSONMap{"beneficiary_msisdn": "254700000000"}
+	assert.Equal(t, "254712345678", RecipientMSISDN(payment))
+
+	payment.RecipientMSISDN = ""
+	assert.Equal(t, "254700000000", RecipientMSISDN(payment))
+}
@@ -0,0 +1,109 @@
+//nolint:revive // package name matches directory structure
+package events_callback //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/daraja-api/service/models"
+	"github.com/antinvestor/daraja-api/service/utility"
+	"github.com/pitabwire/frame"
+	"github.com/shopspring/decimal"
+)
+
+const c2bCurrency = "KES"
+
+// DarajaC2BConfirmation records a payment a customer made to the short code as an inbound
+// payment.
+type DarajaC2BConfirmation struct {
+	Service       *frame.Service
+	PaymentClient *paymentV1.PaymentClient
+}
+
+func (event *DarajaC2BConfirmation) Name() string {
+	return "daraja.callback.c2b.confirmation"
+}
+
+func (event *DarajaC2BConfirmation) PayloadType() any {
+	return &models.C2BTransaction{}
+}
+
+func (event *DarajaC2BConfirmation) Validate(_ context.Context, payload any) error {
+	transaction, ok := payload.(*models.C2BTransaction)
+	if !ok {
+		return errors.New("invalid payload type")
+	}
+
+	if transaction.TransID == "" {
+		return errors.New("transaction id is required")
+	}
+	_, err := ParseC2BAmount(transaction.TransAmount)
+	return err
+}
+
+func (event *DarajaC2BConfirmation) Execute(ctx context.Context, payload any) error {
+	if event.PaymentClient == nil {
+		return errors.New("payment client not initialized")
+	}
+
+	transaction, ok := payload.(*models.C2BTransaction)
+	if !ok {
+		return errors.New("invalid payload type")
+	}
+
+	logger := event.Service.Log(ctx).WithField("transId", transaction.TransID)
+	logger.Info("Received Daraja C2B confirmation")
+
+	paidAmount, err := ParseC2BAmount(transaction.TransAmount)
+	if err != nil {
+		return err
+	}
+	amount := utility.ToMoney(c2bCurrency, paidAmount)
+
+	payerName := strings.Join(strings.Fields(strings.Join(
+		[]string{transaction.FirstName, transaction.MiddleName, transaction.LastName}, " ")), " ")
+
+	_, err = event.PaymentClient.Client.Receive(ctx, &paymentV1.ReceiveRequest{
+		Data: &paymentV1.Payment{
+			Source: &commonv1.ContactLink{
+				Detail: transaction.MSISDN,
+			},
+			Recipient: &commonv1.ContactLink{
+				Detail: transaction.BusinessShortCode,
+			},
+			TransactionId: transaction.TransID,
+			Amount:        &amount,
+			Extra: map[string]string{
+				"callback_type":    "c2b",
+				"transaction_type": transaction.TransactionType,
+				"transaction_time": transaction.TransTime,
+				"mpesa_receipt":    transaction.TransID,
+				// The account number the customer typed, payment links are matched on it
+				"external_ref":   transaction.BillRefNumber,
+				"invoice_number": transaction.InvoiceNumber,
+				"payer_name":     payerName,
+				"telco":          "Safaricom",
+			},
+		},
+	})
+	if err != nil {
+		logger.WithError(err).Error("failed to receive C2B payment")
+		return err
+	}
+	return nil
+}
+
+// ParseC2BAmount parses the amount of a C2B transaction, which Daraja sends as a string.
+func ParseC2BAmount(value string) (decimal.Decimal, error) {
+	amount, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Zero, errors.New("transaction amount is not a number")
+	}
+	if !amount.IsPositive() {
+		return decimal.Zero, errors.New("transaction amount must be greater than zero")
+	}
+	return amount, nil
+}
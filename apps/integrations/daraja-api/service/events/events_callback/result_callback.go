@@ -0,0 +1,224 @@
+//nolint:revive // package name matches directory structure
+package events_callback //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/daraja-api/service/models"
+	"github.com/antinvestor/daraja-api/service/repository"
+	"github.com/pitabwire/frame"
+	"gorm.io/gorm"
+)
+
+// DarajaB2CResult reports the outcome of a B2C payout against its payment, payouts are sent
+// with the payment id as their originator conversation id.
+type DarajaB2CResult struct {
+	Service       *frame.Service
+	PaymentClient *paymentV1.PaymentClient
+	// Payouts stores the progress of each payout, the service datastore is used when it is nil.
+	Payouts repository.PayoutRepository
+}
+
+func (event *DarajaB2CResult) Name() string {
+	return "daraja.callback.b2c.result"
+}
+
+func (event *DarajaB2CResult) PayloadType() any {
+	return &models.ResultCallback{}
+}
+
+func (event *DarajaB2CResult) Validate(_ context.Context, payload any) error {
+	callback, ok := payload.(*models.ResultCallback)
+	if !ok {
+		return errors.New("invalid payload type")
+	}
+
+	if callback.Result.OriginatorConversationID == "" {
+		return errors.New("originator conversation id is required")
+	}
+	return nil
+}
+
+func (event *DarajaB2CResult) Execute(ctx context.Context, payload any) error {
+	if event.PaymentClient == nil {
+		return errors.New("payment client not initialized")
+	}
+
+	callback, ok := payload.(*models.ResultCallback)
+	if !ok {
+		return errors.New("invalid payload type")
+	}
+
+	result := callback.Result
+	logger := event.Service.Log(ctx).WithField("paymentId", result.OriginatorConversationID)
+	logger.WithField("resultCode", result.ResultCode).Info("Received Daraja B2C result")
+
+	status := commonv1.STATUS_FAILED
+	if result.ResultCode == models.ResultCodeSuccess {
+		status = commonv1.STATUS_SUCCESSFUL
+	}
+
+	_, err := event.PaymentClient.StatusUpdate(ctx, &commonv1.StatusUpdateRequest{
+		Id:     result.OriginatorConversationID,
+		State:  commonv1.STATE_ACTIVE,
+		Status: status,
+		Extras: map[string]string{
+			"entity_type":     "payment",
+			"transaction_id":  result.TransactionID,
+			"conversation_id": result.ConversationID,
+			"receiver_name":   callback.Parameter("ReceiverPartyPublicName"),
+			"completed_at":    callback.Parameter("TransactionCompletedDateTime"),
+			"code":            strconv.Itoa(result.ResultCode),
+			"message":         result.ResultDesc,
+		},
+	})
+	if err != nil {
+		logger.WithError(err).Error("failed to record B2C result")
+		return err
+	}
+	return settlePayout(ctx, payouts(ctx, event.Service, event.Payouts),
+		result.OriginatorConversationID, status, result.TransactionID)
+}
+
+// DarajaTransactionStatusResult reports the state of a transaction looked up with a status
+// query against the payment named in the query's occasion. Transactions that have not reached
+// a final state are only logged.
+type DarajaTransactionStatusResult struct {
+	Service       *frame.Service
+	PaymentClient *paymentV1.PaymentClient
+	// Payouts stores the progress of each payout, the service datastore is used when it is nil.
+	Payouts repository.PayoutRepository
+}
+
+func (event *DarajaTransactionStatusResult) Name() string {
+	return "daraja.callback.transaction.status"
+}
+
+func (event *DarajaTransactionStatusResult) PayloadType() any {
+	return &models.ResultCallback{}
+}
+
+func (event *DarajaTransactionStatusResult) Validate(_ context.Context, payload any) error {
+	callback, ok := payload.(*models.ResultCallback)
+	if !ok {
+		return errors.New("invalid payload type")
+	}
+
+	if callback.Result.ConversationID == "" && callback.Result.OriginatorConversationID == "" {
+		return errors.New("conversation id is required")
+	}
+	return nil
+}
+
+func (event *DarajaTransactionStatusResult) Execute(ctx context.Context, payload any) error {
+	if event.PaymentClient == nil {
+		return errors.New("payment client not initialized")
+	}
+
+	callback, ok := payload.(*models.ResultCallback)
+	if !ok {
+		return errors.New("invalid payload type")
+	}
+
+	result := callback.Result
+	paymentID := occasion(result.ReferenceData.ReferenceItem)
+	receipt := callback.Parameter("ReceiptNo")
+	logger := event.Service.Log(ctx).WithField("paymentId", paymentID).WithField("receipt", receipt)
+
+	status, ok := ResolveTransactionStatus(result.ResultCode, callback.Parameter("TransactionStatus"))
+	if !ok || paymentID == "" {
+		logger.WithField("resultCode", result.ResultCode).
+			WithField("transactionStatus", callback.Parameter("TransactionStatus")).
+			Info("transaction status result does not resolve a payment")
+		return nil
+	}
+
+	_, err := event.PaymentClient.StatusUpdate(ctx, &commonv1.StatusUpdateRequest{
+		Id:     paymentID,
+		State:  commonv1.STATE_ACTIVE,
+		Status: status,
+		Extras: map[string]string{
+			"entity_type":        "payment",
+			"transaction_id":     receipt,
+			"transaction_status": callback.Parameter("TransactionStatus"),
+			"resolved_by":        "status_query",
+			"code":               strconv.Itoa(result.ResultCode),
+			"message":            result.ResultDesc,
+		},
+	})
+	if err != nil {
+		logger.WithError(err).Error("failed to record transaction status")
+		return err
+	}
+	return settlePayout(ctx, payouts(ctx, event.Service, event.Payouts), paymentID, status, receipt)
+}
+
+// settlePayout records the outcome of a payout so it is no longer queried. Payments that were
+// not paid out through B2C have no payout to settle.
+func settlePayout(
+	ctx context.Context,
+	payouts repository.PayoutRepository,
+	paymentID string,
+	status commonv1.STATUS,
+	transactionID string,
+) error {
+	payout, err := payouts.GetByPaymentID(ctx, paymentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("load payout: %w", err)
+	}
+
+	payout.State = models.PayoutStatePaid
+	if status != commonv1.STATUS_SUCCESSFUL {
+		payout.State = models.PayoutStateFailed
+	}
+	payout.TransactionID = transactionID
+	if err = payouts.Save(ctx, payout); err != nil {
+		return fmt.Errorf("record payout outcome: %w", err)
+	}
+	return nil
+}
+
+func payouts(
+	ctx context.Context,
+	service *frame.Service,
+	payouts repository.PayoutRepository,
+) repository.PayoutRepository {
+	if payouts != nil {
+		return payouts
+	}
+	return repository.NewPayoutRepository(ctx, service)
+}
+
+// ResolveTransactionStatus maps the result of a status query to a payment status, false is
+// returned while the transaction has no final state.
+func ResolveTransactionStatus(resultCode int, transactionStatus string) (commonv1.STATUS, bool) {
+	if resultCode != models.ResultCodeSuccess {
+		return commonv1.STATUS_UNKNOWN, false
+	}
+
+	switch strings.ToLower(transactionStatus) {
+	case "completed":
+		return commonv1.STATUS_SUCCESSFUL, true
+	case "failed", "cancelled", "declined", "reversed":
+		return commonv1.STATUS_FAILED, true
+	default:
+		return commonv1.STATUS_UNKNOWN, false
+	}
+}
+
+func occasion(item models.ResultParameter) string {
+	if item.Key != "Occasion" {
+		return ""
+	}
+	value, _ := item.Value.(string)
+	return value
+}
@@ -0,0 +1,152 @@
+//nolint:revive // package name matches directory structure
+package events_callback //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/daraja-api/service/events/events_stk"
+	"github.com/antinvestor/daraja-api/service/models"
+	"github.com/antinvestor/daraja-api/service/repository"
+	"github.com/antinvestor/daraja-api/service/utility"
+	"github.com/pitabwire/frame"
+	"github.com/shopspring/decimal"
+)
+
+// DarajaStkCallback reports the outcome of an STK push to the payment service, paid pushes
+// are received as payments while the others fail their prompt.
+type DarajaStkCallback struct {
+	Service       *frame.Service
+	PaymentClient *paymentV1.PaymentClient
+}
+
+func (event *DarajaStkCallback) Name() string {
+	return "daraja.callback.stk"
+}
+
+func (event *DarajaStkCallback) PayloadType() any {
+	return &models.StkCallback{}
+}
+
+func (event *DarajaStkCallback) Validate(_ context.Context, payload any) error {
+	callback, ok := payload.(*models.StkCallback)
+	if !ok {
+		return errors.New("invalid payload type")
+	}
+
+	result := callback.Body.StkCallback
+	if result.CheckoutRequestID == "" {
+		return errors.New("checkout request id is required")
+	}
+	if result.ResultCode == models.ResultCodeSuccess && callback.Metadata("MpesaReceiptNumber") == "" {
+		return errors.New("receipt number is required for a paid STK push")
+	}
+
+	return nil
+}
+
+func (event *DarajaStkCallback) Execute(ctx context.Context, payload any) error {
+	if event.PaymentClient == nil {
+		return errors.New("payment client not initialized")
+	}
+
+	callback, ok := payload.(*models.StkCallback)
+	if !ok {
+		return errors.New("invalid payload type")
+	}
+
+	result := callback.Body.StkCallback
+	logger := event.Service.Log(ctx).WithField("checkoutRequestId", result.CheckoutRequestID)
+	logger.WithField("resultCode", result.ResultCode).Info("Received Daraja STK callback")
+
+	stkRepo := repository.NewStkRequestRepository(ctx, event.Service)
+	stkRequest, err := stkRepo.GetByCheckoutRequestID(ctx, result.CheckoutRequestID)
+	if err != nil {
+		logger.WithError(err).Error("no STK push recorded for callback")
+		return fmt.Errorf("find STK push: %w", err)
+	}
+
+	status, resolution := events_stk.ResolveResultCode(result.ResultCode)
+	stkRequest.State = resolution
+	stkRequest.ResultCode = &result.ResultCode
+	stkRequest.ResultDesc = result.ResultDesc
+	stkRequest.ReceiptNumber = callback.Metadata("MpesaReceiptNumber")
+	if err = stkRepo.Save(ctx, stkRequest); err != nil {
+		logger.WithError(err).Error("failed to save STK push")
+		return fmt.Errorf("save STK push: %w", err)
+	}
+
+	if status != commonv1.STATUS_SUCCESSFUL {
+		return event.failPrompt(ctx, stkRequest, result.ResultCode, result.ResultDesc, resolution)
+	}
+
+	// The amount prompted for is what gets credited, the callback only carries it for reference
+	if value := callback.Metadata("Amount"); value != "" {
+		if reported, parseErr := decimal.NewFromString(value); parseErr != nil || !reported.Equal(stkRequest.Amount) {
+			logger.WithField("reportedAmount", value).WithField("promptedAmount", stkRequest.Amount.String()).
+				Warn("STK callback amount differs from the prompted amount")
+		}
+	}
+	phoneNumber := callback.Metadata("PhoneNumber")
+	if phoneNumber == "" {
+		phoneNumber = stkRequest.PhoneNumber
+	}
+
+	amount := utility.ToMoney(stkRequest.Currency, stkRequest.Amount)
+	_, err = event.PaymentClient.Client.Receive(ctx, &paymentV1.ReceiveRequest{
+		Data: &paymentV1.Payment{
+			Source: &commonv1.ContactLink{
+				Detail: phoneNumber,
+			},
+			TransactionId: stkRequest.ReceiptNumber,
+			Amount:        &amount,
+			Extra: map[string]string{
+				// transaction_ref lets the payment service match the payment to its prompt
+				"transaction_ref":     stkRequest.TransactionRef,
+				"checkout_request_id": stkRequest.CheckoutRequestID,
+				"mpesa_receipt":       stkRequest.ReceiptNumber,
+				"transaction_date":    callback.Metadata("TransactionDate"),
+				"telco":               "Safaricom",
+			},
+		},
+	})
+	if err != nil {
+		logger.WithError(err).Error("failed to receive STK payment")
+		return err
+	}
+	return nil
+}
+
+// failPrompt records the failure reported by Daraja against the prompt the push was sent for.
+func (event *DarajaStkCallback) failPrompt(
+	ctx context.Context,
+	stkRequest *models.StkRequest,
+	resultCode int,
+	resultDesc, resolution string,
+) error {
+	_, err := event.PaymentClient.StatusUpdate(ctx, &commonv1.StatusUpdateRequest{
+		Id:     stkRequest.PromptID,
+		State:  commonv1.STATE_ACTIVE,
+		Status: commonv1.STATUS_FAILED,
+		Extras: map[string]string{
+			"entity_type":         "prompt",
+			"transaction_ref":     stkRequest.TransactionRef,
+			"checkout_request_id": stkRequest.CheckoutRequestID,
+			"resolution":          resolution,
+			"resolved_by":         "callback",
+			"code":                strconv.Itoa(resultCode),
+			"message":             resultDesc,
+		},
+	})
+	if err != nil {
+		event.Service.Log(ctx).WithError(err).
+			WithField("checkoutRequestId", stkRequest.CheckoutRequestID).
+			Error("failed to record failed STK callback")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+//nolint:revive // package name matches directory structure
+package events_stk //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/daraja-api/service/coreapi"
+	"github.com/antinvestor/daraja-api/service/models"
+	"github.com/antinvestor/daraja-api/service/repository"
+	"github.com/antinvestor/daraja-api/service/utility"
+	"github.com/pitabwire/frame"
+)
+
+const (
+	defaultCurrency    = "KES"
+	defaultDescription = "Payment"
+	entityTypePrompt   = "prompt"
+	// Daraja limits the account reference to 12 and the description to 13 characters.
+	maxAccountReference  = 12
+	maxDescription       = 13
+	responseCodeAccepted = "0"
+	statusActive         = commonv1.STATE_ACTIVE
+	statusFailed         = commonv1.STATUS_FAILED
+	statusInProcess      = commonv1.STATUS_IN_PROCESS
+	statusSuccessful     = commonv1.STATUS_SUCCESSFUL
+)
+
+// InitiatePrompt sends an STK push for the initiate.prompt messages the payment service
+// publishes for prompts routed to M-Pesa.
+type InitiatePrompt struct {
+	Service       *frame.Service
+	Client        coreapi.DarajaApiClient
+	PaymentClient *paymentV1.PaymentClient
+	CallbackURL   string
+}
+
+// Name returns the name of the event handler.
+func (h *InitiatePrompt) Name() string {
+	return "daraja.initiate.prompt"
+}
+
+// PayloadType returns the type of payload this event expects.
+func (h *InitiatePrompt) PayloadType() any {
+	return &models.Prompt{}
+}
+
+// Validate validates the payload.
+func (h *InitiatePrompt) Validate(_ context.Context, payload any) error {
+	prompt, ok := payload.(*models.Prompt)
+	if !ok {
+		return errors.New("invalid payload type, expected *models.Prompt")
+	}
+
+	if prompt.ID == "" {
+		return errors.New("prompt ID is required")
+	}
+	if !prompt.Amount.Valid || !prompt.Amount.Decimal.IsPositive() {
+		return errors.New("payment amount is required")
+	}
+	if !prompt.Amount.Decimal.Equal(prompt.Amount.Decimal.Truncate(0)) {
+		return errors.New("M-Pesa only accepts whole shilling amounts")
+	}
+	if _, ok = getStringFromExtra(prompt.Extra, "transaction_ref"); !ok {
+		return errors.New("transaction reference is required")
+	}
+	if _, err := utility.NormalizeMSISDN(promptMobileNumber(prompt)); err != nil {
+		return fmt.Errorf("mobile number: %w", err)
+	}
+
+	return nil
+}
+
+// Handle implements the frame.SubscribeWorker interface.
+func (h *InitiatePrompt) Handle(ctx context.Context, _ map[string]string, message []byte) error {
+	payload := h.PayloadType()
+	if err := json.Unmarshal(message, payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	if err := h.Validate(ctx, payload); err != nil {
+		return fmt.Errorf("payload validation failed: %w", err)
+	}
+
+	return h.Execute(ctx, payload)
+}
+
+// Execute sends the STK push and records it against the prompt, the outcome arrives with
+// Daraja's callback or a later status query.
+func (h *InitiatePrompt) Execute(ctx context.Context, payload any) error {
+	prompt, ok := payload.(*models.Prompt)
+	if !ok {
+		return errors.New("invalid payload type, expected *models.Prompt")
+	}
+
+	transactionRef, _ := getStringFromExtra(prompt.Extra, "transaction_ref")
+	logger := h.Service.Log(ctx).WithField("promptId", prompt.ID).WithField("transactionRef", transactionRef)
+	logger.Info("Processing daraja.initiate.prompt event")
+
+	phoneNumber, err := utility.NormalizeMSISDN(promptMobileNumber(prompt))
+	if err != nil {
+		return h.handleError(ctx, prompt.ID, transactionRef, fmt.Errorf("mobile number: %w", err))
+	}
+
+	accountReference := getStringWithDefault(prompt.Extra, "account_reference", transactionRef)
+	description := getStringWithDefault(prompt.Extra, "description", defaultDescription)
+	pushRequest := models.STKPushRequest{
+		Amount:           prompt.Amount.Decimal.IntPart(),
+		PartyA:           phoneNumber,
+		PhoneNumber:      phoneNumber,
+		CallBackURL:      h.CallbackURL,
+		AccountReference: truncate(accountReference, maxAccountReference),
+		TransactionDesc:  truncate(description, maxDescription),
+	}
+
+	token, err := h.Client.GenerateAccessToken()
+	if err != nil {
+		logger.WithError(err).Error("failed to generate access token")
+		return h.handleError(ctx, prompt.ID, transactionRef, fmt.Errorf("generate access token: %w", err))
+	}
+
+	response, err := h.Client.STKPush(pushRequest, token.AccessToken)
+	if err != nil {
+		logger.WithError(err).Error("failed to send STK push")
+		return h.handleError(ctx, prompt.ID, transactionRef, fmt.Errorf("send STK push: %w", err))
+	}
+	if response.ResponseCode != responseCodeAccepted {
+		return h.handleError(ctx, prompt.ID, transactionRef,
+			fmt.Errorf("STK push rejected: %s", response.ResponseDescription))
+	}
+
+	stkRequest := &models.StkRequest{
+		PromptID:          prompt.ID,
+		TransactionRef:    transactionRef,
+		CheckoutRequestID: response.CheckoutRequestID,
+		MerchantRequestID: response.MerchantRequestID,
+		PhoneNumber:       phoneNumber,
+		Amount:            prompt.Amount.Decimal,
+		Currency:          getStringWithDefault(prompt.Extra, "currency", defaultCurrency),
+		State:             models.StkStatePending,
+	}
+	if err = repository.NewStkRequestRepository(ctx, h.Service).Save(ctx, stkRequest); err != nil {
+		logger.WithError(err).Error("failed to save STK push")
+		return fmt.Errorf("save STK push: %w", err)
+	}
+
+	_, err = h.PaymentClient.StatusUpdate(ctx, &commonv1.StatusUpdateRequest{
+		Id:     prompt.ID,
+		State:  statusActive,
+		Status: statusInProcess,
+		Extras: map[string]string{
+			"entity_type":         entityTypePrompt,
+			"transaction_ref":     transactionRef,
+			"checkout_request_id": response.CheckoutRequestID,
+			"message":             response.CustomerMessage,
+		},
+	})
+	if err != nil {
+		logger.WithError(err).Error("failed to update prompt status")
+		return fmt.Errorf("payment client status update: %w", err)
+	}
+
+	return nil
+}
+
+// handleError updates the prompt status to failed and returns the error.
+func (h *InitiatePrompt) handleError(ctx context.Context, promptID, transactionRef string, err error) error {
+	_, updateErr := h.PaymentClient.StatusUpdate(ctx, &commonv1.StatusUpdateRequest{
+		Id:     promptID,
+		State:  statusActive,
+		Status: statusFailed,
+		Extras: map[string]string{
+			"entity_type":     entityTypePrompt,
+			"transaction_ref": transactionRef,
+			"error":           err.Error(),
+		},
+	})
+	if updateErr != nil {
+		h.Service.Log(ctx).WithError(updateErr).WithField("promptId", promptID).
+			Error("failed to update prompt status")
+		return fmt.Errorf("update prompt status: %w", updateErr)
+	}
+
+	return err
+}
+
+// promptMobileNumber returns the number the push goes to, the payment service keeps the
+// number the customer gave in the prompt extras.
+func promptMobileNumber(prompt *models.Prompt) string {
+	return getStringWithDefault(prompt.Extra, "mobile_number", prompt.SourceContactID)
+}
+
+// getStringFromExtra safely extracts a string value from the extras map.
+func getStringFromExtra(extras map[string]any, key string) (string, bool) {
+	if extras == nil {
+		return "", false
+	}
+	val, ok := extras[key].(string)
+	return val, ok && val != ""
+}
+
+// getStringWithDefault extracts a string with a default value if not found.
+func getStringWithDefault(extras map[string]any, key, defaultValue string) string {
+	if val, ok := getStringFromExtra(extras, key); ok {
+		return val
+	}
+	return defaultValue
+}
+
+func truncate(value string, limit int) string {
+	if len(value) > limit {
+		return value[:limit]
+	}
+	return value
+}
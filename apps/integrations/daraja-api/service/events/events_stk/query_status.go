@@ -0,0 +1,166 @@
+//nolint:revive // package name matches directory structure
+package events_stk //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/daraja-api/service/coreapi"
+	"github.com/antinvestor/daraja-api/service/models"
+	"github.com/antinvestor/daraja-api/service/repository"
+	"github.com/pitabwire/frame"
+)
+
+const (
+	ResolutionPaid              = "paid"
+	ResolutionCancelled         = "cancelled"
+	ResolutionTimeout           = "timeout"
+	ResolutionInsufficientFunds = "insufficient_funds"
+
+	// errorCodeProcessing is returned by the STK push query while the customer has not answered.
+	errorCodeProcessing = "500.001.1001"
+)
+
+// PromptStatusQuery resolves prompts for which Daraja never delivered a callback by querying
+// the STK push. Pushes the customer has not answered are left untouched so the next sweep can
+// try again.
+type PromptStatusQuery struct {
+	Service       *frame.Service
+	Client        coreapi.DarajaApiClient
+	PaymentClient *paymentV1.PaymentClient
+}
+
+// Name returns the name of the event handler.
+func (h *PromptStatusQuery) Name() string {
+	return "daraja.prompt.status.query"
+}
+
+// PayloadType returns the type of payload this event expects.
+func (h *PromptStatusQuery) PayloadType() any {
+	return &models.Prompt{}
+}
+
+// Validate validates the payload.
+func (h *PromptStatusQuery) Validate(_ context.Context, payload any) error {
+	prompt, ok := payload.(*models.Prompt)
+	if !ok {
+		return errors.New("invalid payload type, expected *models.Prompt")
+	}
+
+	if prompt.ID == "" {
+		return errors.New("prompt ID is required")
+	}
+
+	return nil
+}
+
+// Handle implements the frame.SubscribeWorker interface.
+func (h *PromptStatusQuery) Handle(ctx context.Context, _ map[string]string, message []byte) error {
+	payload := h.PayloadType()
+	if err := json.Unmarshal(message, payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	if err := h.Validate(ctx, payload); err != nil {
+		return fmt.Errorf("payload validation failed: %w", err)
+	}
+
+	return h.Execute(ctx, payload)
+}
+
+// Execute queries Daraja for the prompt's STK push and reports any final outcome.
+func (h *PromptStatusQuery) Execute(ctx context.Context, payload any) error {
+	prompt, ok := payload.(*models.Prompt)
+	if !ok {
+		return errors.New("invalid payload type, expected *models.Prompt")
+	}
+
+	logger := h.Service.Log(ctx).WithField("promptId", prompt.ID)
+
+	stkRepo := repository.NewStkRequestRepository(ctx, h.Service)
+	stkRequest, err := stkRepo.GetLatestByPromptID(ctx, prompt.ID)
+	if err != nil {
+		logger.WithError(err).Warn("no STK push recorded for prompt")
+		return fmt.Errorf("find STK push: %w", err)
+	}
+	if stkRequest.State != models.StkStatePending {
+		logger.WithField("state", stkRequest.State).Debug("STK push already resolved")
+		return nil
+	}
+
+	logger = logger.WithField("checkoutRequestId", stkRequest.CheckoutRequestID)
+	logger.Debug("Querying STK push status")
+
+	token, err := h.Client.GenerateAccessToken()
+	if err != nil {
+		logger.WithError(err).Error("failed to generate access token")
+		return fmt.Errorf("generate access token: %w", err)
+	}
+
+	response, err := h.Client.STKPushQuery(stkRequest.CheckoutRequestID, token.AccessToken)
+	if err != nil {
+		var apiErr *coreapi.APIError
+		if errors.As(err, &apiErr) && apiErr.Response.ErrorCode == errorCodeProcessing {
+			logger.Debug("STK push is still pending")
+			return nil
+		}
+		logger.WithError(err).Warn("failed to query STK push")
+		return fmt.Errorf("query STK push: %w", err)
+	}
+
+	resultCode, err := strconv.Atoi(response.ResultCode)
+	if err != nil {
+		logger.WithField("resultCode", response.ResultCode).Debug("STK push has no result yet")
+		return nil
+	}
+
+	status, resolution := ResolveResultCode(resultCode)
+	stkRequest.State = resolution
+	stkRequest.ResultCode = &resultCode
+	stkRequest.ResultDesc = response.ResultDesc
+	if err = stkRepo.Save(ctx, stkRequest); err != nil {
+		logger.WithError(err).Error("failed to save STK push")
+		return fmt.Errorf("save STK push: %w", err)
+	}
+
+	_, err = h.PaymentClient.StatusUpdate(ctx, &commonv1.StatusUpdateRequest{
+		Id:     prompt.ID,
+		State:  statusActive,
+		Status: status,
+		Extras: map[string]string{
+			"entity_type":         entityTypePrompt,
+			"transaction_ref":     stkRequest.TransactionRef,
+			"checkout_request_id": stkRequest.CheckoutRequestID,
+			"resolution":          resolution,
+			"resolved_by":         "status_query",
+			"code":                response.ResultCode,
+			"message":             response.ResultDesc,
+		},
+	})
+	if err != nil {
+		logger.WithError(err).Error("failed to update prompt status")
+		return fmt.Errorf("payment client status update: %w", err)
+	}
+
+	logger.WithField("resolution", resolution).Info("prompt status resolved from STK push query")
+	return nil
+}
+
+// ResolveResultCode maps a Daraja STK result code to a payment status and a resolution.
+func ResolveResultCode(resultCode int) (commonv1.STATUS, string) {
+	switch resultCode {
+	case models.ResultCodeSuccess:
+		return statusSuccessful, ResolutionPaid
+	case models.ResultCodeTimeout:
+		return statusFailed, ResolutionTimeout
+	case models.ResultCodeInsufficientFunds:
+		return statusFailed, ResolutionInsufficientFunds
+	default:
+		return statusFailed, ResolutionCancelled
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/antinvestor/daraja-api/service/events/events_callback"
+	"github.com/antinvestor/daraja-api/service/models"
+)
+
+// Result codes the validation URL rejects a C2B payment with.
+const (
+	c2bAccepted            = "0"
+	c2bRejectInvalidAmount = "C2B00013"
+	c2bRejectShortCode     = "C2B00015"
+)
+
+// HandleC2BValidation accepts or rejects a payment to the short code before M-Pesa completes
+// it. Daraja waits for the answer, so the payment is checked here rather than through an event.
+func (js *JobServer) HandleC2BValidation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := js.Service.Log(ctx).WithField("type", "C2BValidationHandler")
+
+	var transaction models.C2BTransaction
+	if err := json.NewDecoder(r.Body).Decode(&transaction); err != nil {
+		logger.WithError(err).Error("failed to decode C2B validation request")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	logger = logger.WithField("transId", transaction.TransID)
+	if js.ShortCode != "" && transaction.BusinessShortCode != js.ShortCode {
+		logger.WithField("shortCode", transaction.BusinessShortCode).Warn("C2B payment to unknown short code")
+		writeJSON(w, http.StatusOK, models.C2BValidationResponse{
+			ResultCode: c2bRejectShortCode,
+			ResultDesc: "Rejected",
+		})
+		return
+	}
+	if _, err := events_callback.ParseC2BAmount(transaction.TransAmount); err != nil {
+		logger.WithError(err).Warn("C2B payment has an invalid amount")
+		writeJSON(w, http.StatusOK, models.C2BValidationResponse{
+			ResultCode: c2bRejectInvalidAmount,
+			ResultDesc: "Rejected",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.C2BValidationResponse{ResultCode: c2bAccepted, ResultDesc: "Accepted"})
+}
+
+// RegisterC2BURLs registers the validation and confirmation URLs of this service for the
+// short code, it only needs to run when the URLs change.
+func (js *JobServer) RegisterC2BURLs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := js.Service.Log(ctx).WithField("type", "RegisterC2BURLs")
+
+	token, err := js.Client.GenerateAccessToken()
+	if err != nil {
+		logger.WithError(err).Error("failed to generate access token")
+		http.Error(w, "Failed to authenticate with Daraja", http.StatusBadGateway)
+		return
+	}
+
+	response, err := js.Client.RegisterC2BURLs(models.C2BRegisterRequest{
+		ShortCode:       js.ShortCode,
+		ResponseType:    js.C2BResponseType,
+		ConfirmationURL: js.callbackURL(CallbackPathC2BConfirmation),
+		ValidationURL:   js.callbackURL(CallbackPathC2BValidation),
+	}, token.AccessToken)
+	if err != nil {
+		logger.WithError(err).Error("failed to register C2B URLs")
+		http.Error(w, "Failed to register C2B URLs: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antinvestor/daraja-api/service/models"
+	"github.com/pitabwire/frame"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleC2BValidation(t *testing.T) {
+	ctx, service := frame.NewService("daraja_handler_test")
+	defer service.Stop(ctx)
+
+	js := &JobServer{Service: service, ShortCode: "600984"}
+
+	tests := []struct {
+		name         string
+		transaction  models.C2BTransaction
+		expectedCode string
+	}{
+		{
+			name: "Happy path - payment to our short code",
+			transaction: models.C2BTransaction{
+				TransID:           "RKTQDM7W6S",
+				TransAmount:       "100.00",
+				BusinessShortCode: "600984",
+				BillRefNumber:     "INV001",
+			},
+			expectedCode: c2bAccepted,
+		},
+		{
+			name: "Rejected - unknown short code",
+			transaction: models.C2BTransaction{
+				TransID:           "RKTQDM7W6S",
+				TransAmount:       "100.00",
+				BusinessShortCode: "600000",
+			},
+			expectedCode: c2bRejectShortCode,
+		},
+		{
+			name: "Rejected - amount is not positive",
+			transaction: models.C2BTransaction{
+				TransID:           "RKTQDM7W6S",
+				TransAmount:       "0",
+				BusinessShortCode: "600984",
+			},
+			expectedCode: c2bRejectInvalidAmount,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.transaction)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, CallbackPathC2BValidation, bytes.NewReader(body))
+			rr := httptest.NewRecorder()
+			js.HandleC2BValidation(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			var response models.C2BValidationResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedCode, response.ResultCode)
+		})
+	}
+}
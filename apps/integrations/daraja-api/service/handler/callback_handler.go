@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/antinvestor/daraja-api/service/middleware"
+	"github.com/antinvestor/daraja-api/service/models"
+)
+
+// Callback kinds, each is processed by its own event.
+const (
+	CallbackKindStk               = "stk"
+	CallbackKindC2BConfirmation   = "c2b_confirmation"
+	CallbackKindB2CResult         = "b2c_result"
+	CallbackKindTransactionStatus = "transaction_status"
+)
+
+// Paths Daraja calls back on, they are registered with Daraja relative to the callback base URL.
+const (
+	CallbackPathStk             = "/callbacks/stk"
+	CallbackPathC2BValidation   = "/callbacks/c2b/validation"
+	CallbackPathC2BConfirmation = "/callbacks/c2b/confirmation"
+	CallbackPathB2CResult       = "/callbacks/b2c/result"
+	CallbackPathB2CTimeout      = "/callbacks/b2c/timeout"
+	CallbackPathStatusResult    = "/callbacks/status/result"
+	CallbackPathStatusTimeout   = "/callbacks/status/timeout"
+)
+
+// CallbackURL returns the URL Daraja calls back on for path, carrying the shared secret the
+// callback authentication expects when one is configured.
+func CallbackURL(baseURL, path, secret string) string {
+	callbackURL := strings.TrimRight(baseURL, "/") + path
+	if secret == "" {
+		return callbackURL
+	}
+	return callbackURL + "?" + url.Values{middleware.SharedSecretParam: {secret}}.Encode()
+}
+
+func (js *JobServer) callbackURL(path string) string {
+	return CallbackURL(js.CallbackBaseURL, path, js.CallbackSecret)
+}
+
+// HandleStkCallback receives STK push results.
+func (js *JobServer) HandleStkCallback(w http.ResponseWriter, r *http.Request) {
+	js.receiveCallback(w, r, CallbackKindStk)
+}
+
+// HandleC2BConfirmation receives payments customers made to the short code.
+func (js *JobServer) HandleC2BConfirmation(w http.ResponseWriter, r *http.Request) {
+	js.receiveCallback(w, r, CallbackKindC2BConfirmation)
+}
+
+// HandleB2CResult receives the outcome of payouts.
+func (js *JobServer) HandleB2CResult(w http.ResponseWriter, r *http.Request) {
+	js.receiveCallback(w, r, CallbackKindB2CResult)
+}
+
+// HandleTransactionStatusResult receives the outcome of transaction status queries.
+func (js *JobServer) HandleTransactionStatusResult(w http.ResponseWriter, r *http.Request) {
+	js.receiveCallback(w, r, CallbackKindTransactionStatus)
+}
+
+// HandleQueueTimeout receives requests Daraja dropped from its queue. The payment stays in
+// process and is resolved with a transaction status query, so the timeout is only logged.
+func (js *JobServer) HandleQueueTimeout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := js.Service.Log(ctx).WithField("type", "QueueTimeoutHandler").WithField("path", r.URL.Path)
+
+	var callback models.ResultCallback
+	if err := json.NewDecoder(r.Body).Decode(&callback); err != nil {
+		logger.WithError(err).Error("failed to decode queue timeout")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	logger.WithField("originatorConversationId", callback.Result.OriginatorConversationID).
+		WithField("conversationId", callback.Result.ConversationID).
+		Warn("Daraja request timed out in queue")
+	writeJSON(w, http.StatusOK, models.CallbackAcknowledgement{ResultCode: 0, ResultDesc: "Accepted"})
+}
+
+// receiveCallback validates a callback against the schema of the event that processes its
+// kind before emitting it, Daraja only needs the acknowledgement.
+func (js *JobServer) receiveCallback(w http.ResponseWriter, r *http.Request, kind string) {
+	ctx := r.Context()
+	logger := js.Service.Log(ctx).WithField("type", "CallbackHandler").WithField("kind", kind)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	processor, ok := js.Callbacks[kind]
+	if !ok {
+		logger.Error("no processor configured for callback kind")
+		http.Error(w, "Callback type not supported", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		logger.WithError(err).Error("failed to read callback request")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	payload := processor.PayloadType()
+	if err = json.Unmarshal(body, payload); err != nil {
+		logger.WithError(err).Error("failed to decode callback request")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err = processor.Validate(ctx, payload); err != nil {
+		logger.WithError(err).Error("invalid callback")
+		http.Error(w, "Invalid callback: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = js.Service.Emit(ctx, processor.Name(), payload); err != nil {
+		// Daraja retries callbacks that are not acknowledged
+		logger.WithError(err).Error("failed to emit callback event")
+		http.Error(w, "Failed to process callback", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.CallbackAcknowledgement{ResultCode: 0, ResultDesc: "Accepted"})
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/daraja-api/service/coreapi"
+	"github.com/pitabwire/frame"
+)
+
+//job server handlers
+
+type JobServer struct {
+	Service         *frame.Service
+	Client          coreapi.DarajaApiClient
+	PaymentClient   *paymentV1.PaymentClient
+	ShortCode       string
+	CallbackBaseURL string
+	// CallbackSecret is added to the callback URLs given to Daraja, see middleware.CallbackAuth
+	CallbackSecret  string
+	C2BResponseType string
+	// Events that process each Daraja callback, keyed by the callback path
+	Callbacks map[string]frame.EventI
+}
+
+// HealthHandler is a simple health check handler.
+func HealthHandler(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/antinvestor/daraja-api/service/models"
+)
+
+const defaultStatusRemarks = "Transaction status query"
+
+// QueryTransactionStatus asks Daraja for the state of an M-Pesa transaction. The answer is
+// delivered to the status result URL and recorded against the payment named in the request.
+func (js *JobServer) QueryTransactionStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := js.Service.Log(ctx).WithField("type", "QueryTransactionStatus")
+
+	var request models.TransactionStatusQueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.WithError(err).Error("failed to decode request")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.TransactionID == "" || request.PaymentID == "" {
+		http.Error(w, "Invalid request: transactionId and paymentId are required", http.StatusBadRequest)
+		return
+	}
+	if request.Remarks == "" {
+		request.Remarks = defaultStatusRemarks
+	}
+
+	token, err := js.Client.GenerateAccessToken()
+	if err != nil {
+		logger.WithError(err).Error("failed to generate access token")
+		http.Error(w, "Failed to authenticate with Daraja", http.StatusBadGateway)
+		return
+	}
+
+	response, err := js.Client.TransactionStatus(models.TransactionStatusRequest{
+		TransactionID:   request.TransactionID,
+		ResultURL:       js.callbackURL(CallbackPathStatusResult),
+		QueueTimeOutURL: js.callbackURL(CallbackPathStatusTimeout),
+		Remarks:         request.Remarks,
+		// The result callback reads the payment id back from the occasion
+		Occasion: request.PaymentID,
+	}, token.AccessToken)
+	if err != nil {
+		logger.WithError(err).WithField("transactionId", request.TransactionID).
+			Error("transaction status query failed")
+		http.Error(w, "Transaction status query failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, response)
+}
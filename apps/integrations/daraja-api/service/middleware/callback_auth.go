@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pitabwire/frame"
+)
+
+// SharedSecretParam is the query parameter carrying the shared secret. Daraja cannot add
+// headers to its callbacks, so the secret is made part of the callback URLs it is given.
+const SharedSecretParam = "secret"
+
+// SafaricomCallbackIPs are the addresses Safaricom documents its Daraja callbacks come from.
+var SafaricomCallbackIPs = []string{
+	"196.201.214.200", "196.201.214.206", "196.201.213.114", "196.201.214.207",
+	"196.201.214.208", "196.201.213.44", "196.201.212.127", "196.201.212.138",
+	"196.201.212.129", "196.201.212.136", "196.201.212.74", "196.201.212.69",
+}
+
+// ErrUnauthenticatedCallbacks is returned for callback authentication that would let any
+// caller through.
+var ErrUnauthenticatedCallbacks = errors.New(
+	"callbacks are not authenticated: configure a shared secret or a source allow-list")
+
+// CallbackAuth verifies that callbacks originate from Safaricom before they reach a handler.
+// Each check is enforced once it has been configured and at least one must be, callbacks are
+// rejected otherwise.
+type CallbackAuth struct {
+	SharedSecret string
+	AllowedNets  []*net.IPNet
+	// TrustedProxies is the number of proxies in front of the service that append the address
+	// they received a request from to X-Forwarded-For. The source IP is taken that many
+	// entries from the right, entries further left are set by the caller and not trusted.
+	TrustedProxies int
+	Service        *frame.Service
+}
+
+// Validate reports configurations that would let callbacks through unchecked.
+func (ca *CallbackAuth) Validate() error {
+	if ca.SharedSecret == "" && len(ca.AllowedNets) == 0 {
+		return ErrUnauthenticatedCallbacks
+	}
+	return nil
+}
+
+// Middleware wraps next with the configured callback checks.
+func (ca *CallbackAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := ca.Validate(); err != nil {
+			ca.reject(w, r, "unauthenticated", http.StatusUnauthorized, err)
+			return
+		}
+		if !ca.sourceAllowed(r) {
+			ca.reject(w, r, "source_ip_not_allowed", http.StatusForbidden, nil)
+			return
+		}
+		if ca.SharedSecret != "" && !secureCompare(r.URL.Query().Get(SharedSecretParam), ca.SharedSecret) {
+			ca.reject(w, r, "invalid_credentials", http.StatusUnauthorized, nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (ca *CallbackAuth) sourceAllowed(r *http.Request) bool {
+	if len(ca.AllowedNets) == 0 {
+		return true
+	}
+
+	ip := net.ParseIP(ca.remoteIP(r))
+	if ip == nil {
+		return false
+	}
+	for _, allowed := range ca.AllowedNets {
+		if allowed.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ca *CallbackAuth) remoteIP(r *http.Request) string {
+	if ca.TrustedProxies > 0 {
+		var hops []string
+		for _, forwarded := range r.Header.Values("X-Forwarded-For") {
+			hops = append(hops, strings.Split(forwarded, ",")...)
+		}
+		if len(hops) >= ca.TrustedProxies {
+			return strings.TrimSpace(hops[len(hops)-ca.TrustedProxies])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func (ca *CallbackAuth) reject(w http.ResponseWriter, r *http.Request, reason string, statusCode int, cause error) {
+	if ca.Service != nil {
+		ca.Service.Log(r.Context()).WithError(cause).
+			WithField("path", r.URL.Path).
+			WithField("remote_ip", ca.remoteIP(r)).
+			WithField("reason", reason).
+			Warn("rejected callback")
+	}
+	http.Error(w, http.StatusText(statusCode), statusCode)
+}
+
+func secureCompare(given, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(given), []byte(expected)) == 1
+}
+
+// ParseAllowedNets turns a list of IPs or CIDR ranges into networks for the allow-list.
+func ParseAllowedNets(entries []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			if strings.Contains(entry, ":") {
+				entry += "/128"
+			} else {
+				entry += "/32"
+			}
+		}
+
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed callback source %q: %w", entry, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
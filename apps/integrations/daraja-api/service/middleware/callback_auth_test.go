@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallbackAuthMiddleware(t *testing.T) {
+	safaricom, err := ParseAllowedNets(SafaricomCallbackIPs)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		auth           CallbackAuth
+		remoteAddr     string
+		forwardedFor   string
+		target         string
+		expectedStatus int
+	}{
+		{
+			name:           "Happy path - Safaricom address",
+			auth:           CallbackAuth{AllowedNets: safaricom},
+			remoteAddr:     "196.201.214.200:443",
+			target:         "/callbacks/c2b/confirmation",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Error - address outside the allow-list",
+			auth:           CallbackAuth{AllowedNets: safaricom},
+			remoteAddr:     "203.0.113.10:443",
+			target:         "/callbacks/c2b/confirmation",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Happy path - Safaricom address behind a proxy",
+			auth:           CallbackAuth{AllowedNets: safaricom, TrustedProxies: 1},
+			remoteAddr:     "10.0.0.2:443",
+			forwardedFor:   "196.201.214.200",
+			target:         "/callbacks/stk",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Error - forged forwarded address",
+			auth:           CallbackAuth{AllowedNets: safaricom, TrustedProxies: 1},
+			remoteAddr:     "10.0.0.2:443",
+			forwardedFor:   "196.201.214.200, 203.0.113.10",
+			target:         "/callbacks/stk",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Happy path - shared secret",
+			auth:           CallbackAuth{AllowedNets: safaricom, SharedSecret: "s3cret"},
+			remoteAddr:     "196.201.214.200:443",
+			target:         "/callbacks/stk?secret=s3cret",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Error - wrong shared secret",
+			auth:           CallbackAuth{AllowedNets: safaricom, SharedSecret: "s3cret"},
+			remoteAddr:     "196.201.214.200:443",
+			target:         "/callbacks/stk?secret=guess",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Error - nothing configured",
+			remoteAddr:     "196.201.214.200:443",
+			target:         "/callbacks/stk",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached := false
+			handler := tt.auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				reached = true
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, tt.target, nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedStatus == http.StatusOK, reached)
+		})
+	}
+}
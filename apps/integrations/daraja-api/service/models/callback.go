@@ -0,0 +1,123 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Result codes Daraja reports in callbacks.
+const (
+	ResultCodeSuccess           = 0
+	ResultCodeInsufficientFunds = 1
+	ResultCodeCancelled         = 1032
+	ResultCodeTimeout           = 1037
+)
+
+// CallbackItem is a name and value pair in the metadata of a callback.
+type CallbackItem struct {
+	Name  string `json:"Name"`
+	Value any    `json:"Value,omitempty"`
+}
+
+// StkCallback is the outcome of an STK push.
+type StkCallback struct {
+	Body struct {
+		StkCallback struct {
+			MerchantRequestID string `json:"MerchantRequestID"`
+			CheckoutRequestID string `json:"CheckoutRequestID"`
+			ResultCode        int    `json:"ResultCode"`
+			ResultDesc        string `json:"ResultDesc"`
+			CallbackMetadata  struct {
+				Item []CallbackItem `json:"Item"`
+			} `json:"CallbackMetadata"`
+		} `json:"stkCallback"`
+	} `json:"Body"`
+}
+
+// Metadata returns a callback metadata value as a string, numbers are formatted without
+// exponents so transaction dates and phone numbers survive.
+func (c *StkCallback) Metadata(name string) string {
+	for _, item := range c.Body.StkCallback.CallbackMetadata.Item {
+		if item.Name == name {
+			return formatValue(item.Value)
+		}
+	}
+	return ""
+}
+
+// C2BTransaction is sent to the validation and confirmation URLs when a customer pays the
+// short code.
+type C2BTransaction struct {
+	TransactionType   string `json:"TransactionType"`
+	TransID           string `json:"TransID"`
+	TransTime         string `json:"TransTime"`
+	TransAmount       string `json:"TransAmount"`
+	BusinessShortCode string `json:"BusinessShortCode"`
+	BillRefNumber     string `json:"BillRefNumber"`
+	InvoiceNumber     string `json:"InvoiceNumber"`
+	OrgAccountBalance string `json:"OrgAccountBalance"`
+	ThirdPartyTransID string `json:"ThirdPartyTransID"`
+	MSISDN            string `json:"MSISDN"`
+	FirstName         string `json:"FirstName"`
+	MiddleName        string `json:"MiddleName"`
+	LastName          string `json:"LastName"`
+}
+
+// C2BValidationResponse accepts or rejects a C2B payment before it completes.
+type C2BValidationResponse struct {
+	ResultCode string `json:"ResultCode"`
+	ResultDesc string `json:"ResultDesc"`
+}
+
+// ResultParameter is a key and value pair in an asynchronous result.
+type ResultParameter struct {
+	Key   string `json:"Key"`
+	Value any    `json:"Value,omitempty"`
+}
+
+// ResultCallback is delivered to the result URL of B2C and transaction status requests.
+type ResultCallback struct {
+	Result struct {
+		ResultType               int    `json:"ResultType"`
+		ResultCode               int    `json:"ResultCode"`
+		ResultDesc               string `json:"ResultDesc"`
+		OriginatorConversationID string `json:"OriginatorConversationID"`
+		ConversationID           string `json:"ConversationID"`
+		TransactionID            string `json:"TransactionID"`
+		ResultParameters         struct {
+			ResultParameter []ResultParameter `json:"ResultParameter"`
+		} `json:"ResultParameters"`
+		ReferenceData struct {
+			ReferenceItem ResultParameter `json:"ReferenceItem"`
+		} `json:"ReferenceData"`
+	} `json:"Result"`
+}
+
+// Parameter returns a result parameter as a string.
+func (c *ResultCallback) Parameter(key string) string {
+	for _, parameter := range c.Result.ResultParameters.ResultParameter {
+		if parameter.Key == key {
+			return formatValue(parameter.Value)
+		}
+	}
+	return ""
+}
+
+// CallbackAcknowledgement is the body Daraja expects in reply to every callback.
+type CallbackAcknowledgement struct {
+	ResultCode int    `json:"ResultCode"`
+	ResultDesc string `json:"ResultDesc"`
+}
+
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,112 @@
+package models
+
+// STKPushRequest is a Lipa na M-Pesa Online (STK push) request. The client fills in the
+// business short code, password and timestamp.
+type STKPushRequest struct {
+	BusinessShortCode string `json:"BusinessShortCode"`
+	Password          string `json:"Password"`
+	Timestamp         string `json:"Timestamp"`
+	TransactionType   string `json:"TransactionType"`
+	Amount            int64  `json:"Amount"`
+	PartyA            string `json:"PartyA"`
+	PartyB            string `json:"PartyB"`
+	PhoneNumber       string `json:"PhoneNumber"`
+	CallBackURL       string `json:"CallBackURL"`
+	AccountReference  string `json:"AccountReference"`
+	TransactionDesc   string `json:"TransactionDesc"`
+}
+
+// STKPushResponse acknowledges an STK push, the outcome arrives on the callback URL.
+type STKPushResponse struct {
+	MerchantRequestID   string `json:"MerchantRequestID"`
+	CheckoutRequestID   string `json:"CheckoutRequestID"`
+	ResponseCode        string `json:"ResponseCode"`
+	ResponseDescription string `json:"ResponseDescription"`
+	CustomerMessage     string `json:"CustomerMessage"`
+}
+
+// STKPushQueryRequest asks for the outcome of an STK push.
+type STKPushQueryRequest struct {
+	BusinessShortCode string `json:"BusinessShortCode"`
+	Password          string `json:"Password"`
+	Timestamp         string `json:"Timestamp"`
+	CheckoutRequestID string `json:"CheckoutRequestID"`
+}
+
+// STKPushQueryResponse reports the outcome of an STK push, ResultCode is only set once the
+// customer has answered or the push has timed out.
+type STKPushQueryResponse struct {
+	ResponseCode        string `json:"ResponseCode"`
+	ResponseDescription string `json:"ResponseDescription"`
+	MerchantRequestID   string `json:"MerchantRequestID"`
+	CheckoutRequestID   string `json:"CheckoutRequestID"`
+	ResultCode          string `json:"ResultCode"`
+	ResultDesc          string `json:"ResultDesc"`
+}
+
+// C2BRegisterRequest registers the URLs M-Pesa calls when customers pay the short code.
+type C2BRegisterRequest struct {
+	ShortCode       string `json:"ShortCode"`
+	ResponseType    string `json:"ResponseType"`
+	ConfirmationURL string `json:"ConfirmationURL"`
+	ValidationURL   string `json:"ValidationURL"`
+}
+
+type C2BRegisterResponse struct {
+	OriginatorConversationID string `json:"OriginatorCoversationID"` //nolint:misspell // API field name
+	ResponseCode             string `json:"ResponseCode"`
+	ResponseDescription      string `json:"ResponseDescription"`
+}
+
+// B2CRequest sends money from the short code to a customer's M-Pesa wallet.
+type B2CRequest struct {
+	OriginatorConversationID string `json:"OriginatorConversationID"`
+	InitiatorName            string `json:"InitiatorName"`
+	SecurityCredential       string `json:"SecurityCredential"`
+	CommandID                string `json:"CommandID"`
+	Amount                   int64  `json:"Amount"`
+	PartyA                   string `json:"PartyA"`
+	PartyB                   string `json:"PartyB"`
+	Remarks                  string `json:"Remarks"`
+	QueueTimeOutURL          string `json:"QueueTimeOutURL"`
+	ResultURL                string `json:"ResultURL"`
+	Occasion                 string `json:"Occasion"`
+}
+
+// TransactionStatusRequest asks for the state of an M-Pesa transaction, the answer is
+// delivered to the result URL.
+type TransactionStatusRequest struct {
+	Initiator                string `json:"Initiator"`
+	SecurityCredential       string `json:"SecurityCredential"`
+	CommandID                string `json:"CommandID"`
+	TransactionID            string `json:"TransactionID"`
+	OriginatorConversationID string `json:"OriginatorConversationID"`
+	PartyA                   string `json:"PartyA"`
+	IdentifierType           string `json:"IdentifierType"`
+	ResultURL                string `json:"ResultURL"`
+	QueueTimeOutURL          string `json:"QueueTimeOutURL"`
+	Remarks                  string `json:"Remarks"`
+	Occasion                 string `json:"Occasion"`
+}
+
+// AsyncResponse acknowledges requests whose outcome is delivered to a result URL.
+type AsyncResponse struct {
+	ConversationID           string `json:"ConversationID"`
+	OriginatorConversationID string `json:"OriginatorConversationID"`
+	ResponseCode             string `json:"ResponseCode"`
+	ResponseDescription      string `json:"ResponseDescription"`
+}
+
+// ErrorResponse is returned by Daraja for requests it rejects.
+type ErrorResponse struct {
+	RequestID    string `json:"requestId"`
+	ErrorCode    string `json:"errorCode"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+// TransactionStatusQueryRequest is the body of the transaction status endpoint.
+type TransactionStatusQueryRequest struct {
+	TransactionID string `json:"transactionId"`
+	PaymentID     string `json:"paymentId"`
+	Remarks       string `json:"remarks"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"github.com/pitabwire/frame"
+	"github.com/shopspring/decimal"
+	"gorm.io/datatypes"
+)
+
+// Prompt is the initiate.prompt message published by the payment service.
+type Prompt struct {
+	frame.BaseModel
+	SourceID          string `gorm:"type:varchar(50)"`
+	SourceProfileType string `gorm:"type:varchar(50)"`
+	SourceContactID   string `gorm:"type:varchar(50)"`
+
+	RecipientID          string              `gorm:"type:varchar(50)"`
+	RecipientProfileType string              `gorm:"type:varchar(50)"`
+	RecipientContactID   string              `gorm:"type:varchar(50)"`
+	Amount               decimal.NullDecimal `gorm:"type:numeric"                          json:"amount"`
+	DateCreated          string              `gorm:"type:varchar(50)"`
+	DeviceID             string              `gorm:"type:varchar(50)"`
+	State                int32               `gorm:"type:integer"`
+	Status               int32               `gorm:"type:integer"`
+	Route                string              `gorm:"type:varchar(50)"`
+	Account              datatypes.JSON      `gorm:"type:jsonb"`
+	Extra                datatypes.JSONMap   `gorm:"index:,type:gin;option:jsonb_path_ops" json:"extra"`
+}
+
+type Account struct {
+	frame.BaseModel
+	AccountNumber string `gorm:"type:varchar(50)"`
+	CountryCode   string `gorm:"type:varchar(50)"`
+	Name          string `gorm:"type:varchar(50)"`
+}
+
+// Payment is the outbound payment message the payment service publishes for payouts.
+type Payment struct {
+	frame.BaseModel
+	RecipientProfileID string              `gorm:"type:varchar(250)"`
+	RecipientContactID string              `gorm:"type:varchar(50)"`
+	RecipientName      string              `gorm:"type:varchar(250)"`
+	RecipientMSISDN    string              `gorm:"type:varchar(20)"`
+	Amount             decimal.NullDecimal `gorm:"type:numeric"                          json:"amount"`
+	ReferenceID        string              `gorm:"type:varchar(50)"`
+	RouteID            string              `gorm:"type:varchar(50)"`
+	Currency           string              `gorm:"type:varchar(10)"`
+	OutBound           bool
+	Extra              datatypes.JSONMap `gorm:"index:,type:gin;option:jsonb_path_ops" json:"extra"`
+}
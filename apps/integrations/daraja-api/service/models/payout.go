@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/pitabwire/frame"
+	"github.com/shopspring/decimal"
+)
+
+// Progress of a B2C payout.
+const (
+	// PayoutStateNew payouts have not been sent to Daraja.
+	PayoutStateNew = "new"
+	// PayoutStateSubmitted payouts were sent to Daraja without a definite answer, their outcome
+	// is queried and they are never sent again.
+	PayoutStateSubmitted = "submitted"
+	// PayoutStateAccepted payouts were accepted by Daraja, the result arrives on the result URL.
+	PayoutStateAccepted = "accepted"
+	PayoutStatePaid     = "paid"
+	PayoutStateFailed   = "failed"
+)
+
+// Payout records how far the B2C payout of a payment got, so that a redelivered payout
+// carries on from there instead of paying a second time.
+type Payout struct {
+	frame.BaseModel
+	PaymentID      string          `gorm:"type:varchar(50);uniqueIndex"`
+	PhoneNumber    string          `gorm:"type:varchar(20)"`
+	Amount         decimal.Decimal `gorm:"type:numeric"`
+	State          string          `gorm:"type:varchar(20);index"`
+	ConversationID string          `gorm:"type:varchar(100)"`
+	TransactionID  string          `gorm:"type:varchar(100)"`
+	LastError      string          `gorm:"type:text"`
+	SubmittedAt    *time.Time
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"github.com/pitabwire/frame"
+	"github.com/shopspring/decimal"
+)
+
+// STK push states.
+const (
+	StkStatePending   = "pending"
+	StkStatePaid      = "paid"
+	StkStateCancelled = "cancelled"
+	StkStateTimeout   = "timeout"
+)
+
+// StkRequest ties an STK push to the prompt it was sent for. Daraja only returns the checkout
+// request id in callbacks and queries, the prompt and its transaction reference are found here.
+type StkRequest struct {
+	frame.BaseModel
+	PromptID          string          `gorm:"type:varchar(50);index"`
+	TransactionRef    string          `gorm:"type:varchar(50)"`
+	CheckoutRequestID string          `gorm:"type:varchar(100);uniqueIndex"`
+	MerchantRequestID string          `gorm:"type:varchar(100)"`
+	PhoneNumber       string          `gorm:"type:varchar(20)"`
+	Amount            decimal.Decimal `gorm:"type:numeric"`
+	Currency          string          `gorm:"type:varchar(10)"`
+	State             string          `gorm:"type:varchar(20);index"`
+	ResultCode        *int
+	ResultDesc        string `gorm:"type:text"`
+	ReceiptNumber     string `gorm:"type:varchar(50)"`
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/antinvestor/daraja-api/service/models"
+	"github.com/pitabwire/frame"
+	"gorm.io/gorm/clause"
+)
+
+type PayoutRepository interface {
+	GetByPaymentID(ctx context.Context, paymentID string) (*models.Payout, error)
+	// Create stores a new payout and reports false when one was already stored for its payment.
+	Create(ctx context.Context, payout *models.Payout) (bool, error)
+	Save(ctx context.Context, payout *models.Payout) error
+	// ListSubmitted returns payouts without an answer from Daraja that were submitted before the given time.
+	ListSubmitted(ctx context.Context, before time.Time, limit int) ([]*models.Payout, error)
+}
+
+type payoutRepository struct {
+	abstractRepository
+}
+
+func NewPayoutRepository(_ context.Context, service *frame.Service) PayoutRepository {
+	return &payoutRepository{abstractRepository{service: service}}
+}
+
+func (repo *payoutRepository) GetByPaymentID(ctx context.Context, paymentID string) (*models.Payout, error) {
+	payout := models.Payout{}
+	// Read from the primary, a redelivered payout must see the progress just recorded
+	err := repo.writeDB(ctx).First(&payout, "payment_id = ?", paymentID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payout, nil
+}
+
+func (repo *payoutRepository) Create(ctx context.Context, payout *models.Payout) (bool, error) {
+	if payout.GetID() == "" {
+		payout.GenID(ctx)
+	}
+	result := repo.writeDB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(payout)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (repo *payoutRepository) Save(ctx context.Context, payout *models.Payout) error {
+	return repo.writeDB(ctx).Save(payout).Error
+}
+
+func (repo *payoutRepository) ListSubmitted(ctx context.Context, before time.Time, limit int) ([]*models.Payout, error) {
+	var payouts []*models.Payout
+	err := repo.readDB(ctx).
+		Where("state = ? AND submitted_at <= ?", models.PayoutStateSubmitted, before).
+		Order("submitted_at").
+		Limit(limit).
+		Find(&payouts).Error
+	if err != nil {
+		return nil, err
+	}
+	return payouts, nil
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/antinvestor/daraja-api/service/models"
+	"github.com/pitabwire/frame"
+)
+
+type StkRequestRepository interface {
+	GetByCheckoutRequestID(ctx context.Context, checkoutRequestID string) (*models.StkRequest, error)
+	// GetLatestByPromptID returns the most recent push sent for a prompt.
+	GetLatestByPromptID(ctx context.Context, promptID string) (*models.StkRequest, error)
+	Save(ctx context.Context, request *models.StkRequest) error
+}
+
+type stkRequestRepository struct {
+	abstractRepository
+}
+
+func NewStkRequestRepository(_ context.Context, service *frame.Service) StkRequestRepository {
+	return &stkRequestRepository{abstractRepository{service: service}}
+}
+
+func (repo *stkRequestRepository) GetByCheckoutRequestID(
+	ctx context.Context,
+	checkoutRequestID string,
+) (*models.StkRequest, error) {
+	request := models.StkRequest{}
+	err := repo.readDB(ctx).First(&request, "checkout_request_id = ?", checkoutRequestID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (repo *stkRequestRepository) GetLatestByPromptID(ctx context.Context, promptID string) (*models.StkRequest, error) {
+	request := models.StkRequest{}
+	err := repo.readDB(ctx).Where("prompt_id = ?", promptID).Order("created_at DESC").First(&request).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (repo *stkRequestRepository) Save(ctx context.Context, request *models.StkRequest) error {
+	if request.GetID() == "" {
+		request.GenID(ctx)
+	}
+	return repo.writeDB(ctx).Save(request).Error
+}
@@ -0,0 +1,44 @@
+package router
+
+import (
+	"net/http"
+
+	handlers "github.com/antinvestor/daraja-api/service/handler"
+	"github.com/gorilla/mux"
+)
+
+// NewRouter builds the service routes, callbackAuth guards the endpoints Daraja calls back on
+// while adminAuth guards the operational endpoints.
+func NewRouter(js *handlers.JobServer, callbackAuth, adminAuth mux.MiddlewareFunc) *mux.Router {
+	if callbackAuth == nil {
+		callbackAuth = passThrough
+	}
+	if adminAuth == nil {
+		adminAuth = passThrough
+	}
+
+	router := mux.NewRouter().StrictSlash(true)
+	// Health check endpoint
+	router.HandleFunc("/health", handlers.HealthHandler).Methods("GET")
+	// Callback endpoints Daraja calls back on
+	callback := func(path string, handler http.HandlerFunc) {
+		router.Handle(path, callbackAuth(handler)).Methods("POST")
+	}
+	callback(handlers.CallbackPathStk, js.HandleStkCallback)
+	callback(handlers.CallbackPathC2BValidation, js.HandleC2BValidation)
+	callback(handlers.CallbackPathC2BConfirmation, js.HandleC2BConfirmation)
+	callback(handlers.CallbackPathB2CResult, js.HandleB2CResult)
+	callback(handlers.CallbackPathB2CTimeout, js.HandleQueueTimeout)
+	callback(handlers.CallbackPathStatusResult, js.HandleTransactionStatusResult)
+	callback(handlers.CallbackPathStatusTimeout, js.HandleQueueTimeout)
+	// Operational endpoints
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.Use(adminAuth)
+	admin.HandleFunc("/c2b/register", js.RegisterC2BURLs).Methods("POST")
+	admin.HandleFunc("/transactions/status", js.QueryTransactionStatus).Methods("POST")
+	return router
+}
+
+func passThrough(next http.Handler) http.Handler {
+	return next
+}
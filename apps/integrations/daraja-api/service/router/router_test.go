@@ -0,0 +1,57 @@
+package router
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	handlers "github.com/antinvestor/daraja-api/service/handler"
+	"github.com/antinvestor/daraja-api/service/middleware"
+	"github.com/pitabwire/frame"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallbackRoutesAreAuthenticated(t *testing.T) {
+	ctx, service := frame.NewService("daraja_router_test")
+	defer service.Stop(ctx)
+
+	safaricom, err := middleware.ParseAllowedNets(middleware.SafaricomCallbackIPs)
+	require.NoError(t, err)
+	auth := &middleware.CallbackAuth{AllowedNets: safaricom, Service: service}
+	router := NewRouter(&handlers.JobServer{Service: service}, auth.Middleware, nil)
+
+	paths := []string{
+		handlers.CallbackPathStk,
+		handlers.CallbackPathC2BValidation,
+		handlers.CallbackPathC2BConfirmation,
+		handlers.CallbackPathB2CResult,
+		handlers.CallbackPathB2CTimeout,
+		handlers.CallbackPathStatusResult,
+		handlers.CallbackPathStatusTimeout,
+	}
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(`{"TransID":"RKTQDM7W6S"}`))
+			req.RemoteAddr = "203.0.113.10:443"
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodPost, handlers.CallbackPathC2BValidation,
+		bytes.NewBufferString(`{"TransID":"RKTQDM7W6S","TransAmount":"100.00"}`))
+	req.RemoteAddr = "196.201.214.200:443"
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestCallbackURL(t *testing.T) {
+	assert.Equal(t, "https://daraja.example.com/callbacks/stk",
+		handlers.CallbackURL("https://daraja.example.com/", handlers.CallbackPathStk, ""))
+	assert.Equal(t, "https://daraja.example.com/callbacks/stk?secret=a%2Bb",
+		handlers.CallbackURL("https://daraja.example.com", handlers.CallbackPathStk, "a+b"))
+}
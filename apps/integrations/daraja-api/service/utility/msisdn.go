@@ -0,0 +1,37 @@
+package utility
+
+import (
+	"errors"
+	"strings"
+)
+
+const kenyaCountryCode = "254"
+
+// ErrInvalidMSISDN is returned for numbers that are not Kenyan mobile numbers.
+var ErrInvalidMSISDN = errors.New("not a Kenyan mobile number")
+
+// NormalizeMSISDN returns a Kenyan mobile number in the 2547XXXXXXXX form M-Pesa expects,
+// accepting the +254, 254, 07 and 7 forms customers usually give.
+func NormalizeMSISDN(number string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, number)
+
+	switch {
+	case strings.HasPrefix(digits, kenyaCountryCode) && len(digits) == 12:
+	case strings.HasPrefix(digits, "0") && len(digits) == 10:
+		digits = kenyaCountryCode + digits[1:]
+	case len(digits) == 9:
+		digits = kenyaCountryCode + digits
+	default:
+		return "", ErrInvalidMSISDN
+	}
+
+	if digits[3] != '7' && digits[3] != '1' {
+		return "", ErrInvalidMSISDN
+	}
+	return digits, nil
+}
@@ -0,0 +1,32 @@
+package utility
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeMSISDN(t *testing.T) {
+	tests := []struct {
+		number string
+		want   string
+		err    error
+	}{
+		{number: "254712345678", want: "254712345678"},
+		{number: "+254 712 345 678", want: "254712345678"},
+		{number: "0712345678", want: "254712345678"},
+		{number: "712345678", want: "254712345678"},
+		{number: "0110345678", want: "254110345678"},
+		{number: "0201234567", err: ErrInvalidMSISDN},
+		{number: "25671234567", err: ErrInvalidMSISDN},
+		{number: "", err: ErrInvalidMSISDN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			got, err := NormalizeMSISDN(tt.number)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -1,15 +1,30 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
 	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
 	"github.com/antinvestor/jenga-api/config"
 	"github.com/antinvestor/jenga-api/service/coreapi"
+	"github.com/antinvestor/jenga-api/service/events/events_bill_payment"
 	"github.com/antinvestor/jenga-api/service/events/events_callback"
+	"github.com/antinvestor/jenga-api/service/events/events_inbox"
 	"github.com/antinvestor/jenga-api/service/events/events_link_processing"
+	"github.com/antinvestor/jenga-api/service/events/events_payout"
 	"github.com/antinvestor/jenga-api/service/events/events_stk"
 	"github.com/antinvestor/jenga-api/service/events/events_tills_pay"
 	handler "github.com/antinvestor/jenga-api/service/handler"
+	"github.com/antinvestor/jenga-api/service/middleware"
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/antinvestor/jenga-api/service/repository"
 	"github.com/antinvestor/jenga-api/service/router"
+	"github.com/gorilla/mux"
 	"github.com/pitabwire/frame"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -23,17 +38,32 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	ctx, service := frame.NewService(serviceName, frame.WithConfig(&jengaConfig), frame.WithDatastore())
+	defer service.Stop(ctx)
+	logger := service.Log(ctx).WithField("type", "main")
+
+	signer, err := newSigner(&jengaConfig)
+	if err != nil {
+		logger.WithError(err).Fatal("could not load the Jenga signing key")
+	}
 	//nolint:revive,staticcheck // clientApi more readable than clientAPI
 	clientApi := coreapi.New(
 		jengaConfig.MerchantCode,
 		jengaConfig.ConsumerSecret,
 		jengaConfig.ApiKey,
 		jengaConfig.Env,
-		jengaConfig.JengaPrivateKey,
+		signer,
 	)
-	ctx, service := frame.NewService(serviceName, frame.WithConfig(&jengaConfig))
-	defer service.Stop(ctx)
-	logger := service.Log(ctx).WithField("type", "main")
+
+	db := service.DB(ctx, false)
+	if db == nil {
+		logger.Fatal("Database connection is nil - check DATABASE_URL and database availability")
+		return
+	}
+	if migrateErr := db.AutoMigrate(&models.CallbackRejection{}, &models.CallbackInbox{}, &models.PaymentJob{}); migrateErr != nil {
+		logger.WithError(migrateErr).Fatal("Failed to auto-migrate database tables - cannot continue")
+		return
+	}
 	// Use environment variable for the gRPC endpoint or default to container service name
 	paymentServiceEndpoint := jengaConfig.PaymentServiceURI
 
@@ -63,42 +93,150 @@ func main() {
 		}
 		logger.Info("Successfully connected to payment service at ", paymentServiceEndpoint)
 	}
+	partnerID := jengaConfig.PartnerID
+	if partnerID == "" {
+		partnerID = jengaConfig.MerchantCode
+	}
+	billPayment := &events_bill_payment.JengaBillPayment{
+		Service:       service,
+		Client:        clientApi,
+		PaymentClient: paymentClient,
+		Catalogue:     events_bill_payment.NewBillerCatalogue(clientApi, jengaConfig.BillerCacheTTL),
+		PartnerID:     partnerID,
+	}
+
+	tillsPay := &events_tills_pay.JengaTillsPay{
+		Service:       service,
+		Client:        clientApi,
+		PaymentClient: paymentClient,
+	}
+
+	payout := &events_payout.JengaPayout{
+		Service:       service,
+		Client:        clientApi,
+		PaymentClient: paymentClient,
+		SourceAccount: jengaConfig.PayoutAccount,
+		SourceName:    jengaConfig.PayoutAccountName,
+		CountryCode:   jengaConfig.PayoutCountryCode,
+		WalletName:    jengaConfig.PayoutWalletName,
+		Payers: map[string]events_payout.Payer{
+			events_tills_pay.PaymentType:    tillsPay,
+			events_bill_payment.PaymentType: billPayment,
+		},
+	}
+	if jengaConfig.PayoutAccount == "" {
+		logger.Warn("Payouts will fail until JENGA_PAYOUT_ACCOUNT is set")
+	}
+
+	stkCallback := &events_callback.JengaStkCallback{Service: service, PaymentClient: paymentClient}
+	ipnCallback := &events_callback.JengaCallbackReceivePayment{Service: service, PaymentClient: paymentClient}
+	tillsCallback := &events_callback.JengaTillsCallback{Service: service, PaymentClient: paymentClient}
+	paymentLinkCallback := &events_callback.JengaPaymentLinkCallback{Service: service, PaymentClient: paymentClient}
+	callbackInbox := &events_inbox.ProcessCallback{
+		Service: service,
+		Processors: map[string]frame.EventI{
+			handler.CallbackKindStk:         stkCallback,
+			handler.CallbackKindIPN:         ipnCallback,
+			handler.CallbackKindTills:       tillsCallback,
+			handler.CallbackKindPaymentLink: paymentLinkCallback,
+		},
+		MaxAttempts:       jengaConfig.CallbackMaxAttempts,
+		RetryDelay:        jengaConfig.CallbackRetryDelay,
+		ProcessingTimeout: jengaConfig.CallbackProcessingTimeout,
+	}
+
 	// Initialize JobServer
 	js := &handler.JobServer{
-		Service: service,
+		Service:       service,
+		Client:        clientApi,
+		PaymentClient: paymentClient,
+		BillPayment:   billPayment,
+		TillsPay:      tillsPay,
+		Inbox:         callbackInbox,
+	}
+	callbackAuth, err := newCallbackAuth(ctx, service, &jengaConfig)
+	if err != nil {
+		logger.WithError(err).Fatal("could not set up callback authentication")
+	}
+	var serviceAuth mux.MiddlewareFunc
+	if jengaConfig.SecurelyRunService {
+		serviceAuth = func(next http.Handler) http.Handler {
+			return service.AuthenticationMiddleware(next, jengaConfig.Oauth2JwtVerifyAudience,
+				jengaConfig.Oauth2JwtVerifyIssuer)
+		}
+	} else {
+		logger.Warn("Service and admin endpoints are disabled: enable them by setting SECURELY_RUN_SERVICE=True")
 	}
-	router := router.NewRouter(js)
+	router := router.NewRouter(js, callbackAuth.Middleware, serviceAuth)
 	initiatePrompt := &events_stk.InitiatePrompt{
 		Service:       service,
 		Client:        clientApi,
 		PaymentClient: *paymentClient,
 		CallbackURL:   jengaConfig.JengaCallbackURL,
 	}
+	promptStatusQuery := &events_stk.PromptStatusQuery{
+		Service:       service,
+		Client:        clientApi,
+		PaymentClient: *paymentClient,
+	}
 	createPaymentLink := &events_link_processing.CreatePaymentLink{
 		Service:       service,
 		Client:        clientApi,
 		PaymentClient: *paymentClient,
+		LinkBaseURL:   jengaConfig.PaymentLinkBaseURL,
+	}
+	updatePaymentLink := &events_link_processing.UpdatePaymentLink{
+		Service:       service,
+		Client:        clientApi,
+		PaymentClient: *paymentClient,
+	}
+	pollPaymentLink := &events_link_processing.PollPaymentLink{
+		Service:  service,
+		Client:   clientApi,
+		Callback: paymentLinkCallback,
 	}
 
 	eventHandlers := []frame.EventI{
-		&events_callback.JengaCallbackReceivePayment{Service: service, PaymentClient: paymentClient},
+		ipnCallback,
+		stkCallback,
+		tillsCallback,
+		paymentLinkCallback,
+		callbackInbox,
 		initiatePrompt,
+		promptStatusQuery,
 		createPaymentLink,
-		&events_tills_pay.JengaTillsPay{Service: service, Client: clientApi},
+		updatePaymentLink,
+		pollPaymentLink,
+		tillsPay,
+		billPayment,
+		payout,
 	}
 
 	// NATS-only configuration
 	natsURL := jengaConfig.NATS_URL
 	promptTopic := initiatePrompt.Name()
+	promptStatusQueryTopic := promptStatusQuery.Name()
 	paymentLinkTopic := createPaymentLink.Name()
+	paymentLinkUpdateTopic := updatePaymentLink.Name()
+	paymentLinkPollTopic := pollPaymentLink.Name()
+	payoutTopic := jengaConfig.PayoutTopic
 	//TODO to ensure to put the topics and the urls in the config file
 	serviceOptions := []frame.Option{
 		frame.WithHTTPHandler(router),
 		frame.WithRegisterEvents(eventHandlers...),
+		frame.WithBackgroundConsumer(callbackInbox.RunRetries),
+		frame.WithBackgroundConsumer(billPayment.RunStatusQueries),
+		frame.WithBackgroundConsumer(tillsPay.RunStatusQueries),
+		frame.WithBackgroundConsumer(payout.RunStatusQueries),
+		frame.WithBackgroundConsumer(reloadSignerOnHangup(service, signer)),
 		frame.WithRegisterPublisher(promptTopic, natsURL+promptTopic),
 		frame.WithRegisterPublisher(paymentLinkTopic, natsURL+paymentLinkTopic),
 		frame.WithRegisterSubscriber(promptTopic, natsURL+promptTopic, initiatePrompt),
+		frame.WithRegisterSubscriber(promptStatusQueryTopic, natsURL+promptStatusQueryTopic, promptStatusQuery),
 		frame.WithRegisterSubscriber(paymentLinkTopic, natsURL+paymentLinkTopic, createPaymentLink),
+		frame.WithRegisterSubscriber(paymentLinkUpdateTopic, natsURL+paymentLinkUpdateTopic, updatePaymentLink),
+		frame.WithRegisterSubscriber(paymentLinkPollTopic, natsURL+paymentLinkPollTopic, pollPaymentLink),
+		frame.WithRegisterSubscriber(payoutTopic, natsURL+payoutTopic, payout),
 	}
 
 	service.Init(ctx, serviceOptions...)
@@ -108,3 +246,88 @@ func main() {
 		logger.WithError(runErr).Fatal("Failed to run Jenga API service")
 	}
 }
+
+func newSigner(jengaConfig *config.JengaConfig) (coreapi.Signer, error) {
+	switch jengaConfig.Signer {
+	case "file":
+		return coreapi.NewFileSigner(jengaConfig.JengaPrivateKey)
+	case "env":
+		return coreapi.NewEnvSigner(jengaConfig.PrivateKeyVariable)
+	case "remote":
+		if jengaConfig.SigningServiceURL == "" || jengaConfig.SigningKeyID == "" {
+			return nil, errors.New("remote signer needs JENGA_SIGNING_SERVICE_URL and JENGA_SIGNING_KEY_ID")
+		}
+		keyService := coreapi.NewHTTPKeyService(jengaConfig.SigningServiceURL, jengaConfig.SigningServiceToken)
+		return coreapi.NewRemoteSigner(keyService, jengaConfig.SigningKeyID, jengaConfig.SigningTimeout), nil
+	case "test":
+		return coreapi.TestSigner{}, nil
+	default:
+		return nil, fmt.Errorf("unknown signer %q, expected file, env, remote or test", jengaConfig.Signer)
+	}
+}
+
+// reloadSignerOnHangup rotates the signing key on SIGHUP, a key that fails to load is
+// reported and the previous one stays in use.
+func reloadSignerOnHangup(service *frame.Service, signer coreapi.Signer) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		reloader, ok := signer.(coreapi.Reloader)
+		if !ok {
+			return nil
+		}
+
+		hangup := make(chan os.Signal, 1)
+		signal.Notify(hangup, syscall.SIGHUP)
+		defer signal.Stop(hangup)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-hangup:
+				logger := service.Log(ctx).WithField("type", "signer")
+				if err := reloader.Reload(); err != nil {
+					logger.WithError(err).Error("could not reload the Jenga signing key")
+					continue
+				}
+				logger.Info("reloaded the Jenga signing key")
+			}
+		}
+	}
+}
+
+func newCallbackAuth(
+	ctx context.Context,
+	service *frame.Service,
+	jengaConfig *config.JengaConfig,
+) (*middleware.CallbackAuth, error) {
+	allowedNets, err := middleware.ParseAllowedNets(jengaConfig.CallbackAllowedIPs)
+	if err != nil {
+		return nil, err
+	}
+
+	callbackAuth := &middleware.CallbackAuth{
+		Username:        jengaConfig.CallbackUsername,
+		Password:        jengaConfig.CallbackPassword,
+		SharedSecret:    jengaConfig.CallbackSharedSecret,
+		AllowedNets:     allowedNets,
+		TrustedProxies:  jengaConfig.CallbackTrustedProxies,
+		Recorder:        repository.NewCallbackRejectionRepository(ctx, service),
+		RejectionLimit:  jengaConfig.CallbackRejectionLimit,
+		RejectionWindow: jengaConfig.CallbackRejectionWindow,
+		Service:         service,
+	}
+
+	if jengaConfig.JengaPublicKey != "" {
+		callbackAuth.PublicKey, err = middleware.LoadPublicKey(jengaConfig.JengaPublicKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = callbackAuth.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: set JENGA_CALLBACK_USERNAME, JENGA_CALLBACK_SECRET, "+
+			"JENGA_PUBLIC_KEY_PATH or JENGA_CALLBACK_ALLOWED_IPS", err)
+	}
+
+	return callbackAuth, nil
+}
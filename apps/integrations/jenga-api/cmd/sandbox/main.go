@@ -0,0 +1,49 @@
+// Command sandbox serves the Jenga sandbox on a fixed address so the jenga-api service can be
+// run against it locally by pointing JENGA_ENV at it.
+package main
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/antinvestor/jenga-api/service/coreapi/jengatest"
+)
+
+func main() {
+	address := getEnv("JENGA_SANDBOX_ADDRESS", "localhost:8090")
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		log.Fatalf("listen on %s: %v", address, err)
+	}
+
+	sandbox := jengatest.NewUnstartedServer()
+	_ = sandbox.Listener.Close()
+	sandbox.Listener = listener
+	sandbox.CallbackURL = getEnv("JENGA_SANDBOX_CALLBACK_URL", "http://localhost:8080/callbacks/stk")
+	sandbox.PaymentLinkCallbackURL = getEnv("JENGA_SANDBOX_PAYMENT_LINK_CALLBACK_URL", "")
+	if username := os.Getenv("JENGA_CALLBACK_USERNAME"); username != "" {
+		request, _ := http.NewRequest(http.MethodPost, sandbox.CallbackURL, nil)
+		request.SetBasicAuth(username, os.Getenv("JENGA_CALLBACK_PASSWORD"))
+		sandbox.CallbackHeader = http.Header{"Authorization": request.Header.Values("Authorization")}
+	}
+	sandbox.Start()
+	defer sandbox.Close()
+
+	log.Printf("jenga sandbox listening on %s, script outcomes with POST %s", sandbox.URL, jengatest.PathScript)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,16 +16,17 @@ import (
 
 // Client represents the Jenga API client.
 type Client struct {
-	MerchantCode    string
-	ConsumerSecret  string
-	ApiKey          string       //nolint:staticcheck // API field name
-	HttpClient      *http.Client //nolint:staticcheck // API field name
-	Env             string
-	JengaPrivateKey string
+	MerchantCode   string
+	ConsumerSecret string
+	ApiKey         string       //nolint:staticcheck // API field name
+	HttpClient     *http.Client //nolint:staticcheck // API field name
+	Env            string
+	// Signer signs the request fields Jenga verifies on every call.
+	Signer Signer
 }
 
 // New creates a new instance of the Jenga API client.
-func New(merchantCode, consumerSecret, apiKey, env string, jengaPrivateKey string) *Client {
+func New(merchantCode, consumerSecret, apiKey, env string, signer Signer) *Client {
 	// Create a custom transport with TLS configuration
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{
@@ -47,6 +49,7 @@ func New(merchantCode, consumerSecret, apiKey, env string, jengaPrivateKey strin
 		ApiKey:         apiKey,
 		HttpClient:     httpClient,
 		Env:            env,
+		Signer:         signer,
 	}
 }
 
@@ -104,21 +107,29 @@ func (c *Client) GenerateBearerToken() (*BearerTokenResponse, error) {
 	return &tokenResponse, nil
 }
 
+// GeneratePaymentSignature signs the concatenated request fields with the client's signer.
 func (c *Client) GeneratePaymentSignature(args ...string) (string, error) {
-	// Generate signature
-	// Use the private key path stored in the client configuration
-	privateKeyPath := c.JengaPrivateKey
-	if privateKeyPath == "" {
-		privateKeyPath = "app/keys/privatekey.pem" // Fallback to default path
+	if c.Signer == nil {
+		return "", errors.New("failed to generate signature: no signer configured")
 	}
 
-	signature, err := GenerateSignature(strings.Join(args, ""), privateKeyPath)
+	signature, err := c.Signer.Sign(strings.Join(args, ""))
 	if err != nil {
 		return "", fmt.Errorf("failed to generate signature: %w", err)
 	}
 	return signature, nil
 }
 
+// exactAmount returns a request amount in the exact form of its currency, amounts the currency
+// cannot represent are rejected.
+func exactAmount(currency, value string) (string, error) {
+	amount, err := models.ParseAmount(currency, value)
+	if err != nil {
+		return "", err
+	}
+	return amount.Exact(currency)
+}
+
 // InitiateSTKUSSD initiates an STK/USSD push request.
 func (c *Client) InitiateSTKUSSD(request models.STKUSSDRequest, accessToken string) (*models.STKUSSDResponse, error) {
 	url := fmt.Sprintf("%s/v3-apis/payment-api/v3.0/stkussdpush/initiate", c.Env)
@@ -177,9 +188,8 @@ func (c *Client) CreatePaymentLink(
 	// Prepare signature fields as per the formula:
 	// paymentLink.expiryDate+paymentLink.amount+paymentLink.currency+paymentLink.amountOption+paymentLink.externalRef
 	expiryDate := request.PaymentLink.ExpiryDate
-	amount := fmt.Sprint(
-		request.PaymentLink.Amount,
-	) // Convert amount to string for signature generation.request.PaymentLink.Amount
+	// The amount is signed exactly as it is encoded in the request body
+	amount := request.PaymentLink.Amount.String()
 	currency := request.PaymentLink.Currency
 	amountOption := request.PaymentLink.AmountOption
 	externalRef := request.PaymentLink.ExternalRef
@@ -243,6 +253,13 @@ func (c *Client) InitiateTillsPay(
 ) (*models.TillsPayResponse, error) {
 	url := fmt.Sprintf("%s/v3-apis/transaction-api/v3.0/tills/pay", c.Env)
 
+	// The amount is signed and sent in the exact form of its currency
+	amount, err := exactAmount(request.Payment.Currency, request.Payment.Amount)
+	if err != nil {
+		return nil, err
+	}
+	request.Payment.Amount = amount
+
 	// Generate the signature for the request
 	//merchant.till+partner.id+payment.amount+payment.currency+payment.ref
 	signature, err := c.GeneratePaymentSignature(
@@ -252,8 +269,6 @@ func (c *Client) InitiateTillsPay(
 		request.Payment.Currency,
 		request.Payment.Ref,
 	)
-	fmt.Println("------------------------------signature--------------------------------")
-	fmt.Println(signature)
 	if err != nil {
 		return nil, err
 	}
@@ -298,3 +313,266 @@ func (c *Client) InitiateTillsPay(
 
 	return &tillsPayResponse, nil
 }
+
+// billersPageSize is the number of billers requested per catalogue page.
+const billersPageSize = 100
+
+// FetchBillers retrieves the full billers catalogue, walking through every page Jenga reports.
+func (c *Client) FetchBillers(accessToken string) ([]models.Biller, error) {
+	request := models.FetchBillersRequest{Page: 1, PerPage: billersPageSize}
+
+	var billers []models.Biller
+	for {
+		url := fmt.Sprintf("%s/v3-apis/transaction-api/v3.0/billers?page=%d&per_page=%d",
+			c.Env, request.Page, request.PerPage)
+		if request.Category != "" {
+			url = fmt.Sprintf("%s&category=%s", url, request.Category)
+		}
+
+		var billersResponse models.BillersResponse
+		if err := c.doRequest(http.MethodGet, url, nil, accessToken, "", &billersResponse); err != nil {
+			return nil, err
+		}
+		if !billersResponse.Status {
+			return nil, fmt.Errorf("failed to fetch billers: %s", billersResponse.Message)
+		}
+
+		billers = append(billers, billersResponse.Data.Billers...)
+		if billersResponse.Data.TotalPages <= request.Page || len(billersResponse.Data.Billers) == 0 {
+			return billers, nil
+		}
+		request.Page++
+	}
+}
+
+// ValidateBill confirms with the biller that a bill reference exists and returns the customer details.
+func (c *Client) ValidateBill(
+	request models.BillValidationRequest,
+	accessToken string,
+) (*models.BillValidationResponse, error) {
+	url := fmt.Sprintf("%s/v3-apis/transaction-api/v3.0/bills/validation", c.Env)
+
+	var validationResponse models.BillValidationResponse
+	if err := c.doRequest(http.MethodPost, url, request, accessToken, "", &validationResponse); err != nil {
+		return nil, err
+	}
+	return &validationResponse, nil
+}
+
+// PayBill pays a bill to a biller using the Jenga API.
+func (c *Client) PayBill(request models.PaymentRequest, accessToken string) (*models.PaymentResponse, error) {
+	url := fmt.Sprintf("%s/v3-apis/transaction-api/v3.0/bills/pay", c.Env)
+
+	// The amount is signed and sent in the exact form of its currency
+	amount, err := exactAmount(request.Bill.Currency, request.Bill.Amount)
+	if err != nil {
+		return nil, err
+	}
+	request.Bill.Amount = amount
+
+	// Generate the signature for the request
+	//biller.billerCode+bill.amount+payer.reference+partnerId
+	signature, err := c.GeneratePaymentSignature(
+		request.Biller.BillerCode,
+		request.Bill.Amount,
+		request.Payer.Reference,
+		request.PartnerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var paymentResponse models.PaymentResponse
+	if err := c.doRequest(http.MethodPost, url, request, accessToken, signature, &paymentResponse); err != nil {
+		return nil, err
+	}
+	return &paymentResponse, nil
+}
+
+// SendMoney sends money from the merchant account to a mobile wallet.
+func (c *Client) SendMoney(request models.SendMoneyRequest, accessToken string) (*models.SendMoneyResponse, error) {
+	url := fmt.Sprintf("%s/v3-apis/transaction-api/v3.0/remittance/sendmobile", c.Env)
+
+	//transfer.amount+transfer.currencyCode+transfer.reference+source.accountNumber
+	signature, err := c.GeneratePaymentSignature(
+		request.Transfer.Amount,
+		request.Transfer.CurrencyCode,
+		request.Transfer.Reference,
+		request.Source.AccountNumber,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var sendResponse models.SendMoneyResponse
+	if err := c.doRequest(http.MethodPost, url, request, accessToken, signature, &sendResponse); err != nil {
+		return nil, err
+	}
+	return &sendResponse, nil
+}
+
+// AccountInquiry looks up the name registered against a bank account.
+func (c *Client) AccountInquiry(
+	countryCode, accountNumber, accessToken string,
+) (*models.AccountInquiryResponse, error) {
+	url := fmt.Sprintf("%s/v3-apis/account-api/v3.0/search/%s/%s", c.Env, countryCode, accountNumber)
+
+	//countryCode+accountNumber
+	signature, err := c.GeneratePaymentSignature(countryCode, accountNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var inquiryResponse models.AccountInquiryResponse
+	if err := c.doRequest(http.MethodGet, url, nil, accessToken, signature, &inquiryResponse); err != nil {
+		return nil, err
+	}
+	return &inquiryResponse, nil
+}
+
+// InitiateAccountBalance fetches the available and current balance of a bank account.
+//
+//nolint:revive // accountId follows API parameter naming convention
+func (c *Client) InitiateAccountBalance(
+	countryCode, accountId, accessToken string, //nolint:staticcheck // API parameter name
+) (*models.BalanceResponse, error) {
+	url := fmt.Sprintf("%s/v3-apis/account-api/v3.0/accounts/balances/%s/%s", c.Env, countryCode, accountId)
+
+	//countryCode+accountId
+	signature, err := c.GeneratePaymentSignature(countryCode, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	var balanceResponse models.BalanceResponse
+	if err := c.doRequest(http.MethodGet, url, nil, accessToken, signature, &balanceResponse); err != nil {
+		return nil, err
+	}
+	return &balanceResponse, nil
+}
+
+// MobileWalletLookup looks up the name registered against a mobile money wallet.
+func (c *Client) MobileWalletLookup(
+	request models.MobileWalletLookupRequest,
+	accessToken string,
+) (*models.MobileWalletLookupResponse, error) {
+	url := fmt.Sprintf("%s/v3-apis/transaction-api/v3.0/mobile-wallets/lookup", c.Env)
+
+	//countryCode+mobileNumber
+	signature, err := c.GeneratePaymentSignature(request.CountryCode, request.MobileNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var lookupResponse models.MobileWalletLookupResponse
+	if err := c.doRequest(http.MethodPost, url, request, accessToken, signature, &lookupResponse); err != nil {
+		return nil, err
+	}
+	return &lookupResponse, nil
+}
+
+// QueryTransactionStatus fetches the current state of a transaction by its reference.
+func (c *Client) QueryTransactionStatus(
+	transactionRef, accessToken string,
+) (*models.TransactionStatusResponse, error) {
+	url := fmt.Sprintf("%s/v3-apis/transaction-api/v3.0/transactions/details/%s", c.Env, transactionRef)
+
+	//transactionReference
+	signature, err := c.GeneratePaymentSignature(transactionRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var statusResponse models.TransactionStatusResponse
+	if err := c.doRequest(http.MethodGet, url, nil, accessToken, signature, &statusResponse); err != nil {
+		return nil, err
+	}
+	return &statusResponse, nil
+}
+
+// UpdatePaymentLink changes the details or status of an existing payment link.
+func (c *Client) UpdatePaymentLink(
+	request models.PaymentLinkUpdateRequest,
+	accessToken string,
+) (*models.PaymentLinkResponse, error) {
+	url := fmt.Sprintf("%s/api-checkout/api/v1/update/payment-link", c.Env)
+
+	//paymentLinkRef+expiryDate+status
+	signature, err := c.GeneratePaymentSignature(request.PaymentLinkRef, request.ExpiryDate, request.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	var paymentLinkResponse models.PaymentLinkResponse
+	if err := c.doRequest(http.MethodPut, url, request, accessToken, signature, &paymentLinkResponse); err != nil {
+		return nil, err
+	}
+	return &paymentLinkResponse, nil
+}
+
+// PaymentLinkPayments lists the payments made against a payment link.
+func (c *Client) PaymentLinkPayments(
+	paymentLinkRef, accessToken string,
+) (*models.PaymentLinkPaymentsResponse, error) {
+	url := fmt.Sprintf("%s/api-checkout/api/v1/payment-link/%s/payments", c.Env, paymentLinkRef)
+
+	//paymentLinkRef
+	signature, err := c.GeneratePaymentSignature(paymentLinkRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var paymentsResponse models.PaymentLinkPaymentsResponse
+	if err := c.doRequest(http.MethodGet, url, nil, accessToken, signature, &paymentsResponse); err != nil {
+		return nil, err
+	}
+	return &paymentsResponse, nil
+}
+
+// doRequest sends an authorised request to Jenga and decodes the JSON response into out.
+// Non JSON responses are reported together with the HTTP status so failures remain traceable.
+func (c *Client) doRequest(method, url string, payload any, accessToken, signature string, out any) error {
+	var body io.Reader
+	if payload != nil {
+		jsonBody, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewBuffer(jsonBody)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	if signature != "" {
+		req.Header.Set("Signature", signature)
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			fmt.Printf("failed to close response body: %v\n", closeErr)
+		}
+	}()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if unmarshalErr := json.Unmarshal(respBody, out); unmarshalErr != nil {
+		return fmt.Errorf(
+			"failed to parse response: %w (status: %s, body: %s)",
+			unmarshalErr,
+			resp.Status,
+			string(respBody),
+		)
+	}
+	return nil
+}
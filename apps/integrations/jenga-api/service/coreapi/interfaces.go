@@ -8,4 +8,17 @@ type JengaApiClient interface { //nolint:staticcheck // API interface name
 	InitiateSTKUSSD(request models.STKUSSDRequest, accessToken string) (*models.STKUSSDResponse, error)
 	CreatePaymentLink(request models.PaymentLinkRequest, accessToken string) (*models.PaymentLinkResponse, error)
 	InitiateTillsPay(request models.TillsPayRequest, accessToken string) (*models.TillsPayResponse, error)
+	FetchBillers(accessToken string) ([]models.Biller, error)
+	ValidateBill(request models.BillValidationRequest, accessToken string) (*models.BillValidationResponse, error)
+	PayBill(request models.PaymentRequest, accessToken string) (*models.PaymentResponse, error)
+	SendMoney(request models.SendMoneyRequest, accessToken string) (*models.SendMoneyResponse, error)
+	AccountInquiry(countryCode, accountNumber, accessToken string) (*models.AccountInquiryResponse, error)
+	InitiateAccountBalance(countryCode, accountID, accessToken string) (*models.BalanceResponse, error)
+	MobileWalletLookup(
+		request models.MobileWalletLookupRequest,
+		accessToken string,
+	) (*models.MobileWalletLookupResponse, error)
+	QueryTransactionStatus(transactionRef, accessToken string) (*models.TransactionStatusResponse, error)
+	UpdatePaymentLink(request models.PaymentLinkUpdateRequest, accessToken string) (*models.PaymentLinkResponse, error)
+	PaymentLinkPayments(paymentLinkRef, accessToken string) (*models.PaymentLinkPaymentsResponse, error)
 }
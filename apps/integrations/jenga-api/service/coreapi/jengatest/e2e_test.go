@@ -0,0 +1,514 @@
+package jengatest_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/jenga-api/config"
+	"github.com/antinvestor/jenga-api/service/coreapi"
+	"github.com/antinvestor/jenga-api/service/coreapi/jengatest"
+	"github.com/antinvestor/jenga-api/service/events/events_callback"
+	"github.com/antinvestor/jenga-api/service/events/events_inbox"
+	"github.com/antinvestor/jenga-api/service/events/events_payout"
+	"github.com/antinvestor/jenga-api/service/events/events_stk"
+	"github.com/antinvestor/jenga-api/service/events/events_tills_pay"
+	handler "github.com/antinvestor/jenga-api/service/handler"
+	"github.com/antinvestor/jenga-api/service/middleware"
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/antinvestor/jenga-api/service/repository/repositorytest"
+	"github.com/antinvestor/jenga-api/service/router"
+	"github.com/pitabwire/frame"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"gorm.io/datatypes"
+)
+
+// paymentService records what the integration reports to the payment service.
+type paymentService struct {
+	paymentV1.PaymentServiceClient
+
+	mu            sync.Mutex
+	statusUpdates []*commonv1.StatusUpdateRequest
+	receipts      []*paymentV1.Payment
+	sent          []*paymentV1.Payment
+}
+
+func (p *paymentService) StatusUpdate(
+	_ context.Context,
+	in *commonv1.StatusUpdateRequest,
+	_ ...grpc.CallOption,
+) (*commonv1.StatusUpdateResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.statusUpdates = append(p.statusUpdates, in)
+	return &commonv1.StatusUpdateResponse{}, nil
+}
+
+func (p *paymentService) Send(
+	_ context.Context,
+	in *paymentV1.SendRequest,
+	_ ...grpc.CallOption,
+) (*paymentV1.SendResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sent = append(p.sent, in.GetData())
+	return &paymentV1.SendResponse{
+		Data: &commonv1.StatusResponse{Id: fmt.Sprintf("payment-%d", len(p.sent))},
+	}, nil
+}
+
+func (p *paymentService) Receive(
+	_ context.Context,
+	in *paymentV1.ReceiveRequest,
+	_ ...grpc.CallOption,
+) (*paymentV1.ReceiveResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.receipts = append(p.receipts, in.GetData())
+	return &paymentV1.ReceiveResponse{}, nil
+}
+
+func (p *paymentService) lastStatus(t *testing.T) *commonv1.StatusUpdateRequest {
+	t.Helper()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	require.NotEmpty(t, p.statusUpdates, "no status update reached the payment service")
+	return p.statusUpdates[len(p.statusUpdates)-1]
+}
+
+// loop wires the integration to the sandbox: requests go out through the real client and
+// callbacks come back through the service's router and callback inbox to the events that
+// process them.
+type loop struct {
+	sandbox  *jengatest.Server
+	client   *coreapi.Client
+	payments *paymentService
+	service  *frame.Service
+	callback *httptest.Server
+}
+
+// signingKey is shared by every loop, the sandbox verifies each request against it.
+var signingKey = sync.OnceValue(func() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+})
+
+func newLoop(t *testing.T) *loop {
+	t.Helper()
+
+	cfg, err := frame.ConfigFromEnv[config.JengaConfig]()
+	require.NoError(t, err)
+	ctx, service := frame.NewService(t.Name(), frame.WithConfig(&cfg), frame.WithNoopDriver())
+	t.Cleanup(func() { service.Stop(ctx) })
+
+	l := &loop{
+		sandbox:  jengatest.NewServer(),
+		payments: &paymentService{},
+		service:  service,
+	}
+	paymentClient := &paymentV1.PaymentClient{Client: l.payments}
+
+	// Callbacks go through the service's own routes into the inbox, kept in memory
+	inbox := &events_inbox.ProcessCallback{
+		Service: service,
+		Processors: map[string]frame.EventI{
+			handler.CallbackKindStk:         &events_callback.JengaStkCallback{Service: service, PaymentClient: paymentClient},
+			handler.CallbackKindPaymentLink: &events_callback.JengaPaymentLinkCallback{Service: service, PaymentClient: paymentClient},
+		},
+		Repository: repositorytest.NewCallbackInbox(),
+	}
+	service.Init(ctx, frame.WithRegisterEvents(inbox))
+	require.NoError(t, service.Run(ctx, ""))
+
+	auth := &middleware.CallbackAuth{Username: "jenga", Password: "callback-secret", Service: service}
+	js := &handler.JobServer{Service: service, PaymentClient: paymentClient, Inbox: inbox}
+	l.callback = httptest.NewServer(router.NewRouter(js, auth.Middleware, nil))
+
+	l.sandbox.MerchantCode = "0011547896523"
+	l.sandbox.ConsumerSecret = "consumer-secret"
+	l.sandbox.APIKey = "api-key"
+	l.sandbox.PublicKey = &signingKey().PublicKey
+	l.sandbox.PaymentLinkCallbackURL = l.callback.URL + "/callbacks/payment-link"
+	l.sandbox.CallbackHeader = http.Header{}
+	request := httptest.NewRequest(http.MethodPost, "/", nil)
+	request.SetBasicAuth("jenga", "callback-secret")
+	l.sandbox.CallbackHeader.Set("Authorization", request.Header.Get("Authorization"))
+
+	l.client = coreapi.New(
+		"0011547896523", "consumer-secret", "api-key", l.sandbox.URL, coreapi.NewKeySigner(signingKey()))
+	t.Cleanup(func() {
+		l.sandbox.Close()
+		l.callback.Close()
+	})
+	return l
+}
+
+// receipt waits for the inbox to process a callback into a payment received.
+func (p *paymentService) receipt(t *testing.T) *paymentV1.Payment {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return len(p.receipts) > 0
+	}, 5*time.Second, 10*time.Millisecond, "no payment was received")
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	require.Len(t, p.receipts, 1)
+	return p.receipts[0]
+}
+
+// settled waits for the inbox to process a callback into the status given.
+func (p *paymentService) settled(t *testing.T, status commonv1.STATUS) *commonv1.StatusUpdateRequest {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return p.lastStatus(t).GetStatus() == status
+	}, 5*time.Second, 10*time.Millisecond, "the payment service was never told %s", status)
+	return p.lastStatus(t)
+}
+
+func (l *loop) initiatePrompt(t *testing.T, transactionRef string) error {
+	t.Helper()
+
+	account, err := json.Marshal(models.Account{AccountNumber: "1100161816677", CountryCode: "KE", Name: "Merchant"})
+	require.NoError(t, err)
+
+	prompt := &models.Prompt{
+		ID:              "prompt-" + transactionRef,
+		SourceContactID: "254712345678",
+		Amount:          decimal.NullDecimal{Valid: true, Decimal: decimal.NewFromInt(150)},
+		Account:         datatypes.JSON(account),
+		Extra:           datatypes.JSONMap{"transaction_ref": transactionRef},
+	}
+
+	initiate := &events_stk.InitiatePrompt{
+		Service:       l.service,
+		Client:        l.client,
+		PaymentClient: paymentV1.PaymentClient{Client: l.payments},
+		CallbackURL:   l.callback.URL + "/receivepayments",
+	}
+	err = initiate.Execute(t.Context(), prompt)
+	l.sandbox.Flush()
+	return err
+}
+
+func TestPromptLoop(t *testing.T) {
+	tests := []struct {
+		name           string
+		outcome        jengatest.Outcome
+		wantReceived   bool
+		wantStatus     commonv1.STATUS
+		wantResolution string
+	}{
+		{name: "customer pays", outcome: jengatest.Success, wantReceived: true},
+		{
+			name:           "customer cancels",
+			outcome:        jengatest.UserCancel,
+			wantStatus:     commonv1.STATUS_FAILED,
+			wantResolution: events_stk.ResolutionCancelled,
+		},
+		{
+			name:           "customer never answers",
+			outcome:        jengatest.Timeout,
+			wantStatus:     commonv1.STATUS_FAILED,
+			wantResolution: events_stk.ResolutionTimeout,
+		},
+		{
+			name:           "customer cannot afford it",
+			outcome:        jengatest.InsufficientFunds,
+			wantStatus:     commonv1.STATUS_FAILED,
+			wantResolution: events_stk.ResolutionInsufficientFunds,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLoop(t)
+			l.sandbox.Script(jengatest.STKPush, tt.outcome)
+
+			require.NoError(t, l.initiatePrompt(t, "A12345"))
+
+			deliveries := l.sandbox.Deliveries()
+			require.Len(t, deliveries, 1)
+			require.NoError(t, deliveries[0].Err)
+			assert.Equal(t, http.StatusOK, deliveries[0].StatusCode)
+
+			if tt.wantReceived {
+				receipt := l.payments.receipt(t)
+				assert.Equal(t, "A12345", receipt.GetExtra()["transaction_ref"])
+				assert.Equal(t, "254712345678", receipt.GetSource().GetDetail())
+				assert.Equal(t, int64(150), receipt.GetAmount().GetUnits())
+				return
+			}
+
+			status := l.payments.settled(t, tt.wantStatus)
+			assert.Empty(t, l.payments.receipts)
+			assert.Equal(t, tt.wantResolution, status.GetExtras()["resolution"])
+			assert.Equal(t, "A12345", status.GetExtras()["transaction_ref"])
+		})
+	}
+}
+
+func TestPromptLoopServerError(t *testing.T) {
+	l := newLoop(t)
+	l.sandbox.Script(jengatest.STKPush, jengatest.ServerError)
+
+	// The sandbox refuses the push, so no customer is prompted and nothing calls back
+	_ = l.initiatePrompt(t, "A12345")
+	assert.Empty(t, l.sandbox.Deliveries())
+	assert.Empty(t, l.payments.receipts)
+	_, pushed := l.sandbox.Transaction("A12345")
+	assert.False(t, pushed)
+}
+
+func TestPromptLoopLostCallbackResolvedByStatusQuery(t *testing.T) {
+	l := newLoop(t)
+	l.sandbox.Script(jengatest.STKPush, jengatest.NoCallback)
+
+	require.NoError(t, l.initiatePrompt(t, "A12345"))
+	assert.Empty(t, l.sandbox.Deliveries())
+	assert.Equal(t, commonv1.STATUS_IN_PROCESS, l.payments.lastStatus(t).GetStatus())
+
+	query := &events_stk.PromptStatusQuery{
+		Service:       l.service,
+		Client:        l.client,
+		PaymentClient: paymentV1.PaymentClient{Client: l.payments},
+	}
+	prompt := &models.Prompt{ID: "prompt-A12345", Extra: datatypes.JSONMap{"transaction_ref": "A12345"}}
+
+	// The push is still pending, nothing is reported
+	require.NoError(t, query.Execute(t.Context(), prompt))
+	assert.Equal(t, commonv1.STATUS_IN_PROCESS, l.payments.lastStatus(t).GetStatus())
+
+	transaction, ok := l.sandbox.Transaction("A12345")
+	require.True(t, ok)
+	assert.Equal(t, jengatest.StatePending, transaction.State)
+	assert.Empty(t, l.payments.receipts)
+
+	// The customer pays, the query receives the payment the lost callback would have reported
+	l.sandbox.Settle("A12345")
+	prompt.SourceContactID = "254712345678"
+	prompt.Amount = decimal.NullDecimal{Valid: true, Decimal: decimal.NewFromInt(150)}
+	require.NoError(t, query.Execute(t.Context(), prompt))
+
+	require.Len(t, l.payments.receipts, 1)
+	receipt := l.payments.receipts[0]
+	assert.Equal(t, "A12345", receipt.GetTransactionId())
+	assert.Equal(t, "A12345", receipt.GetExtra()["transaction_ref"])
+	assert.Equal(t, transaction.TelcoReference, receipt.GetExtra()["telco_reference"])
+	assert.Equal(t, "254712345678", receipt.GetSource().GetDetail())
+	assert.Equal(t, int64(150), receipt.GetAmount().GetUnits())
+	assert.Equal(t, "KES", receipt.GetAmount().GetCurrencyCode())
+	assert.Equal(t, commonv1.STATUS_SUCCESSFUL, l.payments.lastStatus(t).GetStatus())
+}
+
+func TestPaymentLinkLoop(t *testing.T) {
+	l := newLoop(t)
+
+	token, err := l.client.GenerateBearerToken()
+	require.NoError(t, err)
+
+	created, err := l.client.CreatePaymentLink(models.PaymentLinkRequest{
+		PaymentLink: models.PaymentLinkDetails{
+			ExpiryDate:   "2030-01-01",
+			Name:         "Invoice",
+			ExternalRef:  "INV-001",
+			AmountOption: "RESTRICTED",
+			Amount:       models.NewAmount(decimal.NewFromInt(500)),
+			Currency:     "KES",
+		},
+	}, token.AccessToken)
+	require.NoError(t, err)
+	require.True(t, created.Status)
+
+	require.NoError(t, l.sandbox.PayLink(created.Data.PaymentLinkRef, "254722000000", models.NewAmount(decimal.NewFromInt(500)), jengatest.Success))
+	l.sandbox.Flush()
+
+	receipt := l.payments.receipt(t)
+	assert.Equal(t, created.Data.PaymentLinkRef, receipt.GetExtra()["payment_link_ref"])
+	assert.Equal(t, "INV-001", receipt.GetExtra()["external_ref"])
+
+	payments, err := l.client.PaymentLinkPayments(created.Data.PaymentLinkRef, token.AccessToken)
+	require.NoError(t, err)
+	assert.Len(t, payments.Data, 1)
+}
+
+func TestTillsPayOutcomes(t *testing.T) {
+	tests := []struct {
+		name       string
+		outcome    jengatest.Outcome
+		wantErr    error
+		wantStatus commonv1.STATUS
+	}{
+		{name: "paid", outcome: jengatest.Success, wantStatus: commonv1.STATUS_SUCCESSFUL},
+		{
+			name:       "declined",
+			outcome:    jengatest.UserCancel,
+			wantErr:    events_tills_pay.ErrTillsPayDeclined,
+			wantStatus: commonv1.STATUS_FAILED,
+		},
+		{name: "gateway timeout", outcome: jengatest.Timeout, wantStatus: commonv1.STATUS_FAILED},
+		{name: "server error", outcome: jengatest.ServerError, wantStatus: commonv1.STATUS_FAILED},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLoop(t)
+			l.sandbox.Script(jengatest.TillsPay, tt.outcome)
+
+			paymentClient := &paymentV1.PaymentClient{Client: l.payments}
+			tillsPay := &events_tills_pay.JengaTillsPay{
+				Service:       l.service,
+				Client:        l.client,
+				PaymentClient: paymentClient,
+				Jobs:          repositorytest.NewPaymentJobs(),
+			}
+			payout := &events_payout.JengaPayout{
+				Service:       l.service,
+				Client:        l.client,
+				PaymentClient: paymentClient,
+				Payers:        map[string]events_payout.Payer{events_tills_pay.PaymentType: tillsPay},
+			}
+
+			// The till is paid once the payment service queues the released payment out
+			paymentID, err := tillsPay.Register(t.Context(), "job-1", &models.TillsPayRequest{
+				Merchant: models.TillsPayMerchant{Till: "5432100"},
+				Payment:  models.TillsPayPayment{Ref: "TP0001", Amount: "250.00", Currency: "KES"},
+				Partner:  models.TillsPayPartner{ID: "0011547896523", Ref: "TP0001"},
+			})
+			require.NoError(t, err)
+			_, paid := l.sandbox.Transaction("TP0001")
+			require.False(t, paid, "the till was paid before the payment was released")
+
+			message, err := json.Marshal(map[string]any{
+				"id":       paymentID,
+				"amount":   "250.00",
+				"Currency": "KES",
+				"OutBound": true,
+				"extra":    l.payments.sent[0].GetExtra(),
+			})
+			require.NoError(t, err)
+			err = payout.Handle(t.Context(), nil, message)
+
+			switch {
+			case tt.wantErr != nil:
+				require.ErrorIs(t, err, tt.wantErr)
+			case tt.wantStatus == commonv1.STATUS_SUCCESSFUL:
+				require.NoError(t, err)
+			default:
+				require.Error(t, err)
+			}
+			assert.Equal(t, tt.wantStatus, l.payments.lastStatus(t).GetStatus())
+		})
+	}
+}
+
+func TestPayoutOutcomes(t *testing.T) {
+	tests := []struct {
+		name       string
+		outcome    jengatest.Outcome
+		wantErr    error
+		wantStatus commonv1.STATUS
+	}{
+		{name: "paid", outcome: jengatest.Success, wantStatus: commonv1.STATUS_SUCCESSFUL},
+		{
+			name:       "declined",
+			outcome:    jengatest.UserCancel,
+			wantErr:    events_payout.ErrPayoutDeclined,
+			wantStatus: commonv1.STATUS_FAILED,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLoop(t)
+			l.sandbox.Script(jengatest.SendMoney, tt.outcome, jengatest.Success)
+
+			payout := &events_payout.JengaPayout{
+				Service:       l.service,
+				Client:        l.client,
+				PaymentClient: &paymentV1.PaymentClient{Client: l.payments},
+				SourceAccount: "1100161816677",
+				SourceName:    "Merchant",
+				Jobs:          repositorytest.NewPaymentJobs(),
+			}
+			message, err := json.Marshal(map[string]any{
+				"id":              "payout-1",
+				"RecipientName":   "John Doe",
+				"RecipientMSISDN": "254712345678",
+				"amount":          "1000.00",
+				"Currency":        "KES",
+				"OutBound":        true,
+			})
+			require.NoError(t, err)
+
+			err = payout.Handle(t.Context(), nil, message)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			// The redelivered message is not paid a second time
+			require.NoError(t, payout.Handle(t.Context(), nil, message))
+			assert.Equal(t, tt.wantStatus, l.payments.lastStatus(t).GetStatus())
+			assert.Equal(t, "payout-1", l.payments.lastStatus(t).GetId())
+
+			transaction, ok := l.sandbox.Transaction("payout-1")
+			require.True(t, ok)
+			assert.Equal(t, "254712345678", transaction.MobileNumber)
+			assert.Equal(t, "1000", transaction.Amount.String())
+		})
+	}
+}
+
+func TestScriptOverHTTP(t *testing.T) {
+	l := newLoop(t)
+
+	body, err := json.Marshal(jengatest.ScriptRequest{
+		Operation: jengatest.STKPush,
+		Outcomes:  []jengatest.Outcome{jengatest.ServerError},
+	})
+	require.NoError(t, err)
+	response, err := http.Post(l.sandbox.URL+jengatest.PathScript, "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	_ = l.initiatePrompt(t, "A10001")
+	_, pushed := l.sandbox.Transaction("A10001")
+	assert.False(t, pushed, "the scripted server error was not applied")
+}
+
+func TestSandboxRejectsUnauthenticatedRequests(t *testing.T) {
+	l := newLoop(t)
+
+	_, err := coreapi.New("unknown", "consumer-secret", "api-key", l.sandbox.URL, coreapi.TestSigner{}).GenerateBearerToken()
+	require.Error(t, err)
+
+	response, err := l.client.QueryTransactionStatus("A12345", "stale-token")
+	require.NoError(t, err)
+	assert.False(t, response.Status)
+
+	token, err := l.client.GenerateBearerToken()
+	require.NoError(t, err)
+	unsigned := coreapi.New("0011547896523", "consumer-secret", "api-key", l.sandbox.URL, coreapi.TestSigner{})
+	response, err = unsigned.QueryTransactionStatus("A12345", token.AccessToken)
+	require.NoError(t, err)
+	assert.False(t, response.Status)
+	assert.Equal(t, "Invalid signature", response.Message)
+}
@@ -0,0 +1,796 @@
+// Package jengatest runs a local stand-in for the Jenga API so the client, the events built
+// on it and the callback endpoints can be exercised together without Jenga's UAT environment.
+//
+// The sandbox authenticates merchants, checks the bearer token and signature of every call and
+// answers STK/USSD pushes, payment links, tills pay and send money requests. Outcomes are
+// successful unless scripted otherwise with Script, and the callbacks Jenga would send are
+// posted back to the callback URLs the same way Jenga does.
+package jengatest
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antinvestor/jenga-api/service/models"
+)
+
+// Jenga endpoints served by the sandbox.
+const (
+	PathAuthenticate       = "/authentication/api/v3/authenticate/merchant"
+	PathSTKPush            = "/v3-apis/payment-api/v3.0/stkussdpush/initiate"
+	PathTillsPay           = "/v3-apis/transaction-api/v3.0/tills/pay"
+	PathSendMoney          = "/v3-apis/transaction-api/v3.0/remittance/sendmobile"
+	PathTransactionDetails = "/v3-apis/transaction-api/v3.0/transactions/details/"
+	PathCreatePaymentLink  = "/api-checkout/api/v1/create/payment-link"
+	PathUpdatePaymentLink  = "/api-checkout/api/v1/update/payment-link"
+	PathPaymentLink        = "/api-checkout/api/v1/payment-link/"
+	// PathScript scripts outcomes over HTTP when the sandbox runs as its own process.
+	PathScript = "/sandbox/script"
+
+	// AccessToken is the token the sandbox issues and expects on every other request.
+	AccessToken = "jengatest-token"
+)
+
+// Operation names a Jenga operation whose outcome can be scripted.
+type Operation string
+
+const (
+	STKPush     Operation = "stk_push"
+	TillsPay    Operation = "tills_pay"
+	SendMoney   Operation = "send_money"
+	PaymentLink Operation = "payment_link"
+)
+
+// Outcome is how the sandbox answers a request.
+type Outcome string
+
+const (
+	// Success accepts the request and, for pushes, settles it.
+	Success Outcome = "success"
+	// UserCancel accepts a push but the customer cancels it, synchronous requests are declined.
+	UserCancel Outcome = "user_cancel"
+	// Timeout accepts a push but the customer never answers it before Jenga gives up,
+	// synchronous requests are answered with a gateway timeout.
+	Timeout Outcome = "timeout"
+	// InsufficientFunds accepts a push but the customer's wallet cannot cover it, synchronous
+	// requests are declined.
+	InsufficientFunds Outcome = "insufficient_funds"
+	// NoCallback accepts a push and leaves it pending without ever calling back, the way a
+	// lost callback looks to the service.
+	NoCallback Outcome = "no_callback"
+	// ServerError answers the request with an internal server error.
+	ServerError Outcome = "server_error"
+)
+
+// Jenga states reported by the transaction details endpoint.
+const (
+	StatePending   = "PENDING"
+	StateCompleted = "COMPLETED"
+	StateFailed    = "FAILED"
+)
+
+// Transaction is a transaction the sandbox processed.
+type Transaction struct {
+	Reference      string
+	TelcoReference string
+	Operation      Operation
+	State          string
+	Description    string
+	MobileNumber   string
+	Amount         models.Amount
+	Currency       string
+}
+
+// Link is a payment link created in the sandbox.
+type Link struct {
+	Ref         string
+	ExternalRef string
+	Amount      models.Amount
+	Currency    string
+	Status      string
+	ExpiryDate  string
+	Payments    []models.PaymentLinkCallback
+}
+
+// Delivery is a callback the sandbox posted.
+type Delivery struct {
+	URL        string
+	Body       []byte
+	StatusCode int
+	Err        error
+}
+
+// Server is the Jenga sandbox, close it once the test is done.
+type Server struct {
+	*httptest.Server
+
+	// Credentials accepted by the authentication endpoint, empty values accept anything.
+	MerchantCode   string
+	ConsumerSecret string
+	APIKey         string
+	// PublicKey verifies request signatures when set, otherwise a signature only has to be present.
+	PublicKey *rsa.PublicKey
+	// CallbackURL receives STK callbacks for pushes sent without a callback URL.
+	CallbackURL string
+	// PaymentLinkCallbackURL receives the callbacks of payments made through payment links.
+	PaymentLinkCallbackURL string
+	// CallbackHeader is added to every callback, e.g. Basic Auth or a shared secret.
+	CallbackHeader http.Header
+	// CallbackClient posts the callbacks.
+	CallbackClient *http.Client
+	// CallbackDelay is how long the customer takes to answer a push, Jenga never calls back
+	// before it has answered the request that started the transaction.
+	CallbackDelay time.Duration
+
+	mu           sync.Mutex
+	scripts      map[Operation][]Outcome
+	transactions map[string]*Transaction
+	links        map[string]*Link
+	deliveries   []Delivery
+	sequence     int
+	pending      sync.WaitGroup
+}
+
+// NewServer starts a sandbox that accepts every request.
+func NewServer() *Server {
+	s := NewUnstartedServer()
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer returns a sandbox that is not listening yet, set its Listener to serve
+// it on a fixed address before calling Start.
+func NewUnstartedServer() *Server {
+	s := &Server{
+		CallbackClient: &http.Client{Timeout: 10 * time.Second},
+		CallbackDelay:  50 * time.Millisecond,
+		scripts:        make(map[Operation][]Outcome),
+		transactions:   make(map[string]*Transaction),
+		links:          make(map[string]*Link),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(PathAuthenticate, s.authenticate)
+	mux.HandleFunc(PathSTKPush, s.authorised(s.stkPush))
+	mux.HandleFunc(PathTillsPay, s.authorised(s.tillsPay))
+	mux.HandleFunc(PathSendMoney, s.authorised(s.sendMoney))
+	mux.HandleFunc(PathTransactionDetails, s.authorised(s.transactionDetails))
+	mux.HandleFunc(PathCreatePaymentLink, s.authorised(s.createPaymentLink))
+	mux.HandleFunc(PathUpdatePaymentLink, s.authorised(s.updatePaymentLink))
+	mux.HandleFunc(PathPaymentLink, s.authorised(s.paymentLinkPayments))
+	mux.HandleFunc(PathScript, s.script)
+	s.Server = httptest.NewUnstartedServer(mux)
+	return s
+}
+
+// Close waits for pending callbacks before shutting the sandbox down.
+func (s *Server) Close() {
+	s.Flush()
+	s.Server.Close()
+}
+
+// Script queues the outcomes of the next requests of an operation, requests beyond the
+// script succeed.
+func (s *Server) Script(operation Operation, outcomes ...Outcome) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scripts[operation] = append(s.scripts[operation], outcomes...)
+}
+
+// Flush waits until every callback fired so far has been delivered.
+func (s *Server) Flush() {
+	s.pending.Wait()
+}
+
+// Deliveries returns the callbacks posted so far in the order they were sent.
+func (s *Server) Deliveries() []Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Delivery(nil), s.deliveries...)
+}
+
+// Transaction returns a transaction by its reference.
+func (s *Server) Transaction(reference string) (Transaction, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transaction, ok := s.transactions[reference]
+	if !ok {
+		return Transaction{}, false
+	}
+	return *transaction, true
+}
+
+// Settle completes a pending push without calling back, the way a push looks to the service
+// when the customer paid and Jenga's callback was lost.
+func (s *Server) Settle(reference string) {
+	s.resolve(reference, StateCompleted, "Transaction Successful")
+}
+
+// PayLink simulates a customer paying a payment link and posts Jenga's payment link callback,
+// only Success and UserCancel are meaningful outcomes for a link payment.
+func (s *Server) PayLink(paymentLinkRef, mobileNumber string, amount models.Amount, outcome Outcome) error {
+	s.mu.Lock()
+	link, ok := s.links[paymentLinkRef]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("no payment link %s", paymentLinkRef)
+	}
+
+	status := "SUCCESS"
+	if outcome != Success {
+		status = "FAILED"
+	}
+
+	var callback models.PaymentLinkCallback
+	callback.CallbackType = "PaymentLink"
+	callback.PaymentLinkRef = link.Ref
+	callback.ExternalRef = link.ExternalRef
+	callback.Customer.Name = "SANDBOX CUSTOMER"
+	callback.Customer.MobileNumber = mobileNumber
+	callback.Transaction.Date = time.Now().Format(time.RFC3339)
+	callback.Transaction.Reference = s.nextReference("PL")
+	callback.Transaction.PaymentMode = "MPESA"
+	callback.Transaction.Amount = amount
+	callback.Transaction.Currency = link.Currency
+	callback.Transaction.Status = status
+	link.Payments = append(link.Payments, callback)
+	s.mu.Unlock()
+
+	s.deliver(s.PaymentLinkCallbackURL, callback)
+	return nil
+}
+
+// ScriptRequest is the body accepted by PathScript.
+type ScriptRequest struct {
+	Operation Operation `json:"operation"`
+	Outcomes  []Outcome `json:"outcomes"`
+}
+
+func (s *Server) script(w http.ResponseWriter, r *http.Request) {
+	var request ScriptRequest
+	if !decode(w, r, http.MethodPost, &request) {
+		return
+	}
+	if request.Operation == "" || len(request.Outcomes) == 0 {
+		writeError(w, http.StatusBadRequest, "operation and outcomes are required")
+		return
+	}
+
+	s.Script(request.Operation, request.Outcomes...)
+	writeJSON(w, http.StatusOK, map[string]any{"status": true, "code": 0, "message": "scripted"})
+}
+
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var credentials struct {
+		MerchantCode   string `json:"merchantCode"`
+		ConsumerSecret string `json:"consumerSecret"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !matches(s.APIKey, r.Header.Get("Api-Key")) ||
+		!matches(s.MerchantCode, credentials.MerchantCode) ||
+		!matches(s.ConsumerSecret, credentials.ConsumerSecret) {
+		writeError(w, http.StatusUnauthorized, "Invalid merchant credentials")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"accessToken":  AccessToken,
+		"refreshToken": "jengatest-refresh-token",
+		"expiresIn":    time.Now().Add(time.Hour).Format(time.RFC3339),
+		"issuedAt":     time.Now().Format(time.RFC3339),
+		"tokenType":    "Bearer",
+	})
+}
+
+// authorised checks the bearer token of a request, the signature is checked by each
+// endpoint since the signed fields differ between them.
+func (s *Server) authorised(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+AccessToken {
+			writeError(w, http.StatusUnauthorized, "Invalid access token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// signed verifies the request signature over the fields Jenga signs for the endpoint.
+func (s *Server) signed(w http.ResponseWriter, r *http.Request, fields ...string) bool {
+	signature := r.Header.Get("Signature")
+	if signature == "" {
+		writeError(w, http.StatusUnauthorized, "Signature is required")
+		return false
+	}
+	if s.PublicKey == nil {
+		return true
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err == nil {
+		hashed := sha256.Sum256([]byte(strings.Join(fields, "")))
+		err = rsa.VerifyPKCS1v15(s.PublicKey, crypto.SHA256, hashed[:], decoded)
+	}
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "Invalid signature")
+		return false
+	}
+	return true
+}
+
+func (s *Server) stkPush(w http.ResponseWriter, r *http.Request) {
+	var request models.STKUSSDRequest
+	if !decode(w, r, http.MethodPost, &request) {
+		return
+	}
+	payment := request.Payment
+	if !s.signed(w, r, request.Merchant.AccountNumber, payment.Ref, payment.MobileNumber,
+		payment.Telco, payment.Amount, payment.Currency) {
+		return
+	}
+	amount, err := models.ParseAmount(payment.Currency, payment.Amount)
+	if err != nil || payment.Ref == "" || payment.MobileNumber == "" {
+		writeJSON(w, http.StatusBadRequest, models.STKUSSDResponse{
+			Code:    400,
+			Message: "Invalid payment details",
+		})
+		return
+	}
+
+	outcome := s.next(STKPush)
+	if outcome == ServerError {
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	transaction := &Transaction{
+		Reference:    payment.Ref,
+		Operation:    STKPush,
+		State:        StatePending,
+		Description:  "Awaiting customer confirmation",
+		MobileNumber: payment.MobileNumber,
+		Amount:       amount,
+		Currency:     payment.Currency,
+	}
+	s.mu.Lock()
+	transaction.TelcoReference = s.nextReference("SBX")
+	s.transactions[payment.Ref] = transaction
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, models.STKUSSDResponse{
+		Status:        true,
+		Code:          0,
+		Message:       "success",
+		Reference:     payment.Ref,
+		TransactionID: transaction.TelcoReference,
+	})
+
+	callback := models.StkCallback{
+		Transaction:   payment.Ref,
+		MobileNumber:  payment.MobileNumber,
+		Currency:      payment.Currency,
+		RequestAmount: amount,
+		TelcoName:     payment.Telco,
+	}
+	switch outcome {
+	case Success:
+		callback.Status = true
+		callback.Code = 3
+		callback.Message = "Transaction Successful - Settled"
+		callback.Telco = transaction.TelcoReference
+		callback.DebitedAmount = amount
+		s.resolve(payment.Ref, StateCompleted, "Transaction Successful")
+	case UserCancel:
+		callback.Code = 4
+		callback.Message = "Request cancelled by user"
+		s.resolve(payment.Ref, StateFailed, callback.Message)
+	case Timeout:
+		callback.Code = 5
+		callback.Message = "Request timed out"
+		s.resolve(payment.Ref, StateFailed, callback.Message)
+	case InsufficientFunds:
+		callback.Code = 6
+		callback.Message = "Insufficient funds"
+		s.resolve(payment.Ref, StateFailed, callback.Message)
+	default:
+		return
+	}
+
+	callbackURL := payment.CallBackUrl
+	if callbackURL == "" {
+		callbackURL = s.CallbackURL
+	}
+	s.deliver(callbackURL, callback)
+}
+
+func (s *Server) tillsPay(w http.ResponseWriter, r *http.Request) {
+	var request models.TillsPayRequest
+	if !decode(w, r, http.MethodPost, &request) {
+		return
+	}
+	if !s.signed(w, r, request.Merchant.Till, request.Partner.ID, request.Payment.Amount,
+		request.Payment.Currency, request.Payment.Ref) {
+		return
+	}
+	amount, err := models.ParseAmount(request.Payment.Currency, request.Payment.Amount)
+	if err != nil || request.Merchant.Till == "" || request.Payment.Ref == "" {
+		writeJSON(w, http.StatusBadRequest, models.TillsPayResponse{Code: 400, Message: "Invalid payment details"})
+		return
+	}
+
+	state, status, ok := s.synchronous(w, TillsPay)
+	if !ok {
+		return
+	}
+	reference := s.record(request.Payment.Ref, TillsPay, state, status.message, "", amount, request.Payment.Currency)
+
+	writeJSON(w, http.StatusOK, models.TillsPayResponse{
+		Status:        status.accepted,
+		Code:          status.code,
+		MerchantName:  "SANDBOX MERCHANT",
+		TransactionID: reference,
+		Message:       status.message,
+	})
+}
+
+func (s *Server) sendMoney(w http.ResponseWriter, r *http.Request) {
+	var request models.SendMoneyRequest
+	if !decode(w, r, http.MethodPost, &request) {
+		return
+	}
+	transfer := request.Transfer
+	if !s.signed(w, r, transfer.Amount, transfer.CurrencyCode, transfer.Reference, request.Source.AccountNumber) {
+		return
+	}
+	amount, err := models.ParseAmount(transfer.CurrencyCode, transfer.Amount)
+	if err != nil || transfer.Reference == "" || request.Destination.MobileNumber == "" {
+		writeJSON(w, http.StatusBadRequest, models.SendMoneyResponse{Code: 400, Message: "Invalid transfer details"})
+		return
+	}
+
+	state, status, ok := s.synchronous(w, SendMoney)
+	if !ok {
+		return
+	}
+	reference := s.record(transfer.Reference, SendMoney, state, status.message,
+		request.Destination.MobileNumber, amount, transfer.CurrencyCode)
+
+	response := models.SendMoneyResponse{
+		Status:    status.accepted,
+		Code:      status.code,
+		Message:   status.message,
+		Reference: transfer.Reference,
+	}
+	response.Data.TransactionID = reference
+	response.Data.Status = state
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) transactionDetails(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	reference := strings.TrimPrefix(r.URL.Path, PathTransactionDetails)
+	if !s.signed(w, r, reference) {
+		return
+	}
+
+	transaction, ok := s.Transaction(reference)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, models.TransactionStatusResponse{
+			Code:    404,
+			Message: "Transaction not found",
+		})
+		return
+	}
+
+	response := models.TransactionStatusResponse{Status: true, Code: 0, Message: "success"}
+	response.Data.TransactionReference = transaction.Reference
+	response.Data.TelcoReference = transaction.TelcoReference
+	response.Data.State = transaction.State
+	response.Data.StateDescription = transaction.Description
+	response.Data.Amount = transaction.Amount
+	response.Data.Currency = transaction.Currency
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) createPaymentLink(w http.ResponseWriter, r *http.Request) {
+	var request models.PaymentLinkRequest
+	if !decode(w, r, http.MethodPost, &request) {
+		return
+	}
+	details := request.PaymentLink
+	if !s.signed(w, r, details.ExpiryDate, details.Amount.String(), details.Currency,
+		details.AmountOption, details.ExternalRef) {
+		return
+	}
+	if _, err := details.Amount.Exact(details.Currency); err != nil || details.ExternalRef == "" {
+		writeJSON(w, http.StatusBadRequest, models.PaymentLinkResponse{Code: 400, Message: "Invalid payment link"})
+		return
+	}
+
+	switch s.next(PaymentLink) {
+	case ServerError:
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	case Timeout:
+		writeError(w, http.StatusGatewayTimeout, "Gateway timeout")
+		return
+	case UserCancel:
+		writeJSON(w, http.StatusOK, models.PaymentLinkResponse{Code: 409, Message: "Payment link rejected"})
+		return
+	}
+
+	s.mu.Lock()
+	link := &Link{
+		Ref:         s.nextReference("PL"),
+		ExternalRef: details.ExternalRef,
+		Amount:      details.Amount,
+		Currency:    details.Currency,
+		Status:      "ACTIVE",
+		ExpiryDate:  details.ExpiryDate,
+	}
+	s.links[link.Ref] = link
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, models.PaymentLinkResponse{
+		Status:  true,
+		Code:    0,
+		Message: "success",
+		Data:    s.linkData(link),
+	})
+}
+
+func (s *Server) updatePaymentLink(w http.ResponseWriter, r *http.Request) {
+	var request models.PaymentLinkUpdateRequest
+	if !decode(w, r, http.MethodPut, &request) {
+		return
+	}
+	if !s.signed(w, r, request.PaymentLinkRef, request.ExpiryDate, request.Status) {
+		return
+	}
+
+	s.mu.Lock()
+	link, ok := s.links[request.PaymentLinkRef]
+	if ok {
+		if request.Status != "" {
+			link.Status = strings.ToUpper(request.Status)
+		}
+		if request.ExpiryDate != "" {
+			link.ExpiryDate = request.ExpiryDate
+		}
+		if request.Amount != nil && request.Amount.IsPositive() {
+			link.Amount = *request.Amount
+		}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, models.PaymentLinkResponse{Code: 404, Message: "Payment link not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, models.PaymentLinkResponse{
+		Status:  true,
+		Code:    0,
+		Message: "success",
+		Data:    s.linkData(link),
+	})
+}
+
+func (s *Server) paymentLinkPayments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet || !strings.HasSuffix(r.URL.Path, "/payments") {
+		writeError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+	ref := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, PathPaymentLink), "/payments")
+	if !s.signed(w, r, ref) {
+		return
+	}
+
+	s.mu.Lock()
+	link, ok := s.links[ref]
+	var payments []models.PaymentLinkCallback
+	if ok {
+		payments = append(payments, link.Payments...)
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, models.PaymentLinkPaymentsResponse{
+			Code:    404,
+			Message: "Payment link not found",
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, models.PaymentLinkPaymentsResponse{
+		Status:  true,
+		Code:    0,
+		Message: "success",
+		Data:    payments,
+	})
+}
+
+type syncStatus struct {
+	accepted bool
+	code     int
+	message  string
+}
+
+// synchronous answers the error outcomes of a synchronous operation, the other outcomes are
+// returned with the state and status the response should carry.
+func (s *Server) synchronous(w http.ResponseWriter, operation Operation) (string, syncStatus, bool) {
+	switch s.next(operation) {
+	case ServerError:
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return "", syncStatus{}, false
+	case Timeout:
+		writeError(w, http.StatusGatewayTimeout, "Gateway timeout")
+		return "", syncStatus{}, false
+	case UserCancel:
+		return StateFailed, syncStatus{accepted: false, code: 4, message: "Transaction declined"}, true
+	case InsufficientFunds:
+		return StateFailed, syncStatus{accepted: false, code: 6, message: "Insufficient funds"}, true
+	default:
+		return StateCompleted, syncStatus{accepted: true, code: 0, message: "success"}, true
+	}
+}
+
+func (s *Server) next(operation Operation) Outcome {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	outcomes := s.scripts[operation]
+	if len(outcomes) == 0 {
+		return Success
+	}
+	s.scripts[operation] = outcomes[1:]
+	return outcomes[0]
+}
+
+func (s *Server) record(
+	reference string,
+	operation Operation,
+	state, description, mobileNumber string,
+	amount models.Amount,
+	currency string,
+) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transaction := &Transaction{
+		Reference:      reference,
+		TelcoReference: s.nextReference("SBX"),
+		Operation:      operation,
+		State:          state,
+		Description:    description,
+		MobileNumber:   mobileNumber,
+		Amount:         amount,
+		Currency:       currency,
+	}
+	s.transactions[reference] = transaction
+	return transaction.TelcoReference
+}
+
+func (s *Server) resolve(reference, state, description string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if transaction, ok := s.transactions[reference]; ok {
+		transaction.State = state
+		transaction.Description = description
+	}
+}
+
+func (s *Server) linkData(link *Link) *models.PaymentLinkResponseData {
+	data := &models.PaymentLinkResponseData{
+		DateCreated:    time.Now().UnixMilli(),
+		PaymentLinkRef: link.Ref,
+		ExternalRef:    link.ExternalRef,
+		URL:            s.URL + "/pay/" + link.Ref,
+	}
+	data.Status.Code = link.Status
+	data.Status.Name = link.Status
+	return data
+}
+
+// nextReference returns a unique reference, the caller holds the lock.
+func (s *Server) nextReference(prefix string) string {
+	s.sequence++
+	return fmt.Sprintf("%s%08d", prefix, s.sequence)
+}
+
+// deliver posts a callback in the background, the way Jenga calls back after answering.
+func (s *Server) deliver(url string, callback any) {
+	if url == "" {
+		return
+	}
+	body, err := json.Marshal(callback)
+	if err != nil {
+		s.recordDelivery(Delivery{URL: url, Err: err})
+		return
+	}
+
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		time.Sleep(s.CallbackDelay)
+
+		delivery := Delivery{URL: url, Body: body}
+		request, requestErr := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		if requestErr != nil {
+			delivery.Err = requestErr
+			s.recordDelivery(delivery)
+			return
+		}
+		request.Header.Set("Content-Type", "application/json")
+		for key, values := range s.CallbackHeader {
+			for _, value := range values {
+				request.Header.Add(key, value)
+			}
+		}
+
+		response, postErr := s.CallbackClient.Do(request)
+		if postErr != nil {
+			delivery.Err = postErr
+			s.recordDelivery(delivery)
+			return
+		}
+		_ = response.Body.Close()
+		delivery.StatusCode = response.StatusCode
+		s.recordDelivery(delivery)
+	}()
+}
+
+func (s *Server) recordDelivery(delivery Delivery) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deliveries = append(s.deliveries, delivery)
+}
+
+func decode(w http.ResponseWriter, r *http.Request, method string, out any) bool {
+	if r.Method != method {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(out); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return false
+	}
+	return true
+}
+
+func matches(expected, actual string) bool {
+	return expected == "" || expected == actual
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{"status": false, "code": status, "message": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -100,3 +100,122 @@ func (m *MockClient) InitiateTillsPay(
 	}
 	return resp, args.Error(1)
 }
+
+// ValidateBill mocks the ValidateBill method.
+func (m *MockClient) ValidateBill(
+	request models.BillValidationRequest,
+	accessToken string,
+) (*models.BillValidationResponse, error) {
+	args := m.Called(request, accessToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	resp, ok := args.Get(0).(*models.BillValidationResponse)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return resp, args.Error(1)
+}
+
+// PayBill mocks the PayBill method.
+func (m *MockClient) PayBill(request models.PaymentRequest, accessToken string) (*models.PaymentResponse, error) {
+	args := m.Called(request, accessToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	resp, ok := args.Get(0).(*models.PaymentResponse)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return resp, args.Error(1)
+}
+
+// SendMoney mocks the SendMoney method.
+func (m *MockClient) SendMoney(request models.SendMoneyRequest, accessToken string) (*models.SendMoneyResponse, error) {
+	args := m.Called(request, accessToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	resp, ok := args.Get(0).(*models.SendMoneyResponse)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return resp, args.Error(1)
+}
+
+// AccountInquiry mocks the AccountInquiry method.
+func (m *MockClient) AccountInquiry(
+	countryCode, accountNumber, accessToken string,
+) (*models.AccountInquiryResponse, error) {
+	args := m.Called(countryCode, accountNumber, accessToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	resp, ok := args.Get(0).(*models.AccountInquiryResponse)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return resp, args.Error(1)
+}
+
+// MobileWalletLookup mocks the MobileWalletLookup method.
+func (m *MockClient) MobileWalletLookup(
+	request models.MobileWalletLookupRequest,
+	accessToken string,
+) (*models.MobileWalletLookupResponse, error) {
+	args := m.Called(request, accessToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	resp, ok := args.Get(0).(*models.MobileWalletLookupResponse)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return resp, args.Error(1)
+}
+
+// QueryTransactionStatus mocks the QueryTransactionStatus method.
+func (m *MockClient) QueryTransactionStatus(
+	transactionRef, accessToken string,
+) (*models.TransactionStatusResponse, error) {
+	args := m.Called(transactionRef, accessToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	resp, ok := args.Get(0).(*models.TransactionStatusResponse)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return resp, args.Error(1)
+}
+
+// UpdatePaymentLink mocks the UpdatePaymentLink method.
+func (m *MockClient) UpdatePaymentLink(
+	request models.PaymentLinkUpdateRequest,
+	accessToken string,
+) (*models.PaymentLinkResponse, error) {
+	args := m.Called(request, accessToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	resp, ok := args.Get(0).(*models.PaymentLinkResponse)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return resp, args.Error(1)
+}
+
+// PaymentLinkPayments mocks the PaymentLinkPayments method.
+func (m *MockClient) PaymentLinkPayments(
+	paymentLinkRef, accessToken string,
+) (*models.PaymentLinkPaymentsResponse, error) {
+	args := m.Called(paymentLinkRef, accessToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	resp, ok := args.Get(0).(*models.PaymentLinkPaymentsResponse)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return resp, args.Error(1)
+}
@@ -1,70 +1,65 @@
 package coreapi
 
 import (
-	"crypto"
-	"crypto/rand"
 	"crypto/rsa"
-	"crypto/sha256"
 	"crypto/x509"
-	"encoding/base64"
 	"encoding/pem"
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
-// TestMode is a flag to skip actual signature validation during tests.
-//
-//nolint:revive // TestMode follows standard naming
-var TestMode bool = false
-
-// GenerateSignature generates a SHA-256 signature with RSA private key.
+// GenerateSignature signs a message with the RSA private key stored at privateKeyPath.
+// The key is read on every call, long running clients should use a FileSigner instead.
 //
 //nolint:revive // GenerateSignature follows standard naming
 func GenerateSignature(message, privateKeyPath string) (string, error) {
-	// For tests, return a dummy signature to avoid actual RSA key parsing
-	if TestMode {
-		return "TEST_SIGNATURE_FOR_UNIT_TESTS", nil
+	signer, err := NewFileSigner(privateKeyPath)
+	if err != nil {
+		return "", err
 	}
+	return signer.Sign(message)
+}
 
-	// Read private key file
+// readPrivateKey loads a PEM encoded RSA private key from disk.
+func readPrivateKey(privateKeyPath string) (*rsa.PrivateKey, error) {
 	// SECURITY: The privateKeyPath should be set from a trusted source (e.g., environment variable or config file)
 	// and must not be influenced by untrusted user input to avoid file inclusion vulnerabilities (G304).
-	if privateKeyPath == "" || privateKeyPath[0] == '/' || strings.Contains(privateKeyPath, "..") {
-		return "", errors.New("invalid private key path")
+	if privateKeyPath == "" || strings.Contains(privateKeyPath, "..") {
+		return nil, errors.New("invalid private key path")
 	}
-	privateKeyBytes, err := os.ReadFile(privateKeyPath)
+	privateKeyBytes, err := os.ReadFile(filepath.Clean(privateKeyPath))
 	if err != nil {
-		return "", fmt.Errorf("failed to read private key: %w", err)
+		return nil, fmt.Errorf("failed to read private key: %w", err)
 	}
+	return ParsePrivateKey(privateKeyBytes)
+}
 
-	// Decode PEM format
-	block, _ := pem.Decode(privateKeyBytes)
+// ParsePrivateKey parses a PEM encoded RSA private key in PKCS#8 or PKCS#1 form.
+func ParsePrivateKey(privateKeyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKeyPEM)
 	if block == nil {
-		return "", errors.New("failed to decode private key PEM")
+		return nil, errors.New("failed to decode private key PEM")
+	}
+
+	if block.Type == "RSA PRIVATE KEY" {
+		privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RSA private key: %w", err)
+		}
+		return privateKey, nil
 	}
 
-	// Parse RSA private key
 	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse RSA private key: %w", err)
+		return nil, fmt.Errorf("failed to parse RSA private key: %w", err)
 	}
 
 	privateKey, ok := key.(*rsa.PrivateKey)
 	if !ok {
-		return "", errors.New("failed to cast parsed key to RSA private key")
-	}
-
-	// Compute SHA-256 hash
-	hashed := sha256.Sum256([]byte(message))
-
-	// Sign the hash using RSA PKCS1v15
-	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hashed[:])
-	if err != nil {
-		return "", fmt.Errorf("failed to sign data: %w", err)
+		return nil, errors.New("failed to cast parsed key to RSA private key")
 	}
-
-	// Encode to Base64
-	return base64.StdEncoding.EncodeToString(signature), nil
+	return privateKey, nil
 }
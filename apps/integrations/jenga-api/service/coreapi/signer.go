@@ -0,0 +1,273 @@
+package coreapi
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Signer produces the Signature header Jenga expects, a base64 encoded RSA PKCS#1 v1.5
+// signature over the SHA-256 digest of the concatenated request fields.
+type Signer interface {
+	Sign(message string) (string, error)
+}
+
+// Reloader is implemented by signers whose key can be rotated without restarting the service.
+type Reloader interface {
+	Reload() error
+}
+
+// KeySigner signs with an RSA private key held in memory.
+type KeySigner struct {
+	mu  sync.RWMutex
+	key *rsa.PrivateKey
+}
+
+// NewKeySigner creates a signer for an already parsed private key.
+func NewKeySigner(key *rsa.PrivateKey) *KeySigner {
+	return &KeySigner{key: key}
+}
+
+// Sign signs the message with the current key.
+func (s *KeySigner) Sign(message string) (string, error) {
+	s.mu.RLock()
+	key := s.key
+	s.mu.RUnlock()
+
+	if key == nil {
+		return "", errors.New("no private key loaded")
+	}
+
+	hashed := sha256.Sum256([]byte(message))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign data: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// Rotate replaces the key used for subsequent signatures.
+func (s *KeySigner) Rotate(key *rsa.PrivateKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.key = key
+}
+
+// FileSigner signs with a PEM private key read from disk once, Reload picks up a rotated file.
+type FileSigner struct {
+	*KeySigner
+	path string
+}
+
+// NewFileSigner loads the private key at path.
+func NewFileSigner(path string) (*FileSigner, error) {
+	signer := &FileSigner{KeySigner: &KeySigner{}, path: path}
+	if err := signer.Reload(); err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+// Reload reads the key file again, the previous key stays in use if the new one is invalid.
+func (s *FileSigner) Reload() error {
+	key, err := readPrivateKey(s.path)
+	if err != nil {
+		return err
+	}
+	s.Rotate(key)
+	return nil
+}
+
+// EnvSigner signs with a PEM private key taken from an environment variable, the value may
+// also be base64 encoded so it fits on a single line. The environment of a running process
+// does not change, so the key is rotated by restarting with the new value.
+type EnvSigner struct {
+	*KeySigner
+}
+
+// NewEnvSigner loads the private key held in the named environment variable.
+func NewEnvSigner(variable string) (*EnvSigner, error) {
+	value := os.Getenv(variable)
+	if value == "" {
+		return nil, fmt.Errorf("environment variable %s is empty", variable)
+	}
+
+	privateKeyPEM := []byte(value)
+	if decoded, err := base64.StdEncoding.DecodeString(value); err == nil {
+		privateKeyPEM = decoded
+	}
+
+	key, err := ParsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("environment variable %s: %w", variable, err)
+	}
+	return &EnvSigner{KeySigner: NewKeySigner(key)}, nil
+}
+
+// KeyService is a key store that signs SHA-256 digests with keys it never releases, such as
+// a cloud KMS or an HSM reached through PKCS#11.
+type KeyService interface {
+	SignDigest(ctx context.Context, keyID string, digest []byte) ([]byte, error)
+}
+
+// RemoteSigner delegates signing to a KeyService, rotating only changes the key id it asks for.
+type RemoteSigner struct {
+	Service KeyService
+	// Timeout bounds each call to the key service, zero means no timeout.
+	Timeout time.Duration
+
+	mu    sync.RWMutex
+	keyID string
+}
+
+// NewRemoteSigner creates a signer for a key held by the key service.
+func NewRemoteSigner(service KeyService, keyID string, timeout time.Duration) *RemoteSigner {
+	return &RemoteSigner{Service: service, Timeout: timeout, keyID: keyID}
+}
+
+// Sign asks the key service to sign the message digest.
+func (s *RemoteSigner) Sign(message string) (string, error) {
+	s.mu.RLock()
+	keyID := s.keyID
+	s.mu.RUnlock()
+
+	ctx := context.Background()
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	hashed := sha256.Sum256([]byte(message))
+	signature, err := s.Service.SignDigest(ctx, keyID, hashed[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign data with key %s: %w", keyID, err)
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// Rotate switches to another key held by the key service.
+func (s *RemoteSigner) Rotate(keyID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keyID = keyID
+}
+
+// CryptoKeyService serves keys exposed as crypto.Signer, the interface PKCS#11 and KMS client
+// libraries implement, and stands in for a remote key service locally.
+type CryptoKeyService struct {
+	mu   sync.RWMutex
+	keys map[string]crypto.Signer
+}
+
+// NewCryptoKeyService creates an empty key service.
+func NewCryptoKeyService() *CryptoKeyService {
+	return &CryptoKeyService{keys: make(map[string]crypto.Signer)}
+}
+
+// Add registers a key under an id.
+func (s *CryptoKeyService) Add(keyID string, key crypto.Signer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[keyID] = key
+}
+
+// SignDigest signs a SHA-256 digest with the key registered under keyID.
+func (s *CryptoKeyService) SignDigest(ctx context.Context, keyID string, digest []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	key, ok := s.keys[keyID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown key %s", keyID)
+	}
+	return key.Sign(rand.Reader, digest, crypto.SHA256)
+}
+
+// HTTPKeyService reaches a signing service over HTTP, such as a KMS gateway or a PKCS#11
+// proxy in front of an HSM. It posts the key id and the base64 encoded digest as JSON and
+// reads back the base64 encoded signature.
+type HTTPKeyService struct {
+	URL string
+	// Token, when set, is sent as a bearer token
+	Token      string
+	HttpClient *http.Client
+}
+
+// NewHTTPKeyService creates a key service for the signing endpoint at url.
+func NewHTTPKeyService(url, token string) *HTTPKeyService {
+	return &HTTPKeyService{URL: url, Token: token, HttpClient: &http.Client{}}
+}
+
+type signDigestRequest struct {
+	KeyID     string `json:"keyId"`
+	Algorithm string `json:"algorithm"`
+	Digest    string `json:"digest"`
+}
+
+type signDigestResponse struct {
+	Signature string `json:"signature"`
+}
+
+// SignDigest asks the signing service to sign a SHA-256 digest with the key under keyID.
+func (s *HTTPKeyService) SignDigest(ctx context.Context, keyID string, digest []byte) ([]byte, error) {
+	body, err := json.Marshal(signDigestRequest{
+		KeyID:     keyID,
+		Algorithm: "RSASSA_PKCS1_V1_5_SHA_256",
+		Digest:    base64.StdEncoding.EncodeToString(digest),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	resp, err := s.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("signing service returned %s", resp.Status)
+	}
+
+	var signed signDigestResponse
+	if err = json.NewDecoder(resp.Body).Decode(&signed); err != nil {
+		return nil, fmt.Errorf("failed to parse signing service response: %w", err)
+	}
+	return base64.StdEncoding.DecodeString(signed.Signature)
+}
+
+// TestSigner returns a deterministic signature derived from the message alone so tests can
+// run without a key and still assert on what was signed.
+type TestSigner struct{}
+
+// Sign returns the base64 encoded SHA-256 digest of the message.
+func (TestSigner) Sign(message string) (string, error) {
+	hashed := sha256.Sum256([]byte(message))
+	return base64.StdEncoding.EncodeToString(hashed[:]), nil
+}
@@ -0,0 +1,194 @@
+package coreapi
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generateKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func encodeKey(t *testing.T, key *rsa.PrivateKey) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func assertSignedBy(t *testing.T, key *rsa.PrivateKey, message, signature string) {
+	t.Helper()
+
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	require.NoError(t, err)
+	hashed := sha256.Sum256([]byte(message))
+	assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], decoded))
+}
+
+func TestFileSignerAcceptsAbsolutePathAndRotates(t *testing.T) {
+	first, second := generateKey(t), generateKey(t)
+	path := filepath.Join(t.TempDir(), "privatekey.pem")
+	require.True(t, filepath.IsAbs(path))
+	require.NoError(t, os.WriteFile(path, encodeKey(t, first), 0o600))
+
+	signer, err := NewFileSigner(path)
+	require.NoError(t, err)
+
+	signature, err := signer.Sign("message")
+	require.NoError(t, err)
+	assertSignedBy(t, first, "message", signature)
+
+	require.NoError(t, os.WriteFile(path, encodeKey(t, second), 0o600))
+	require.NoError(t, signer.Reload())
+	signature, err = signer.Sign("message")
+	require.NoError(t, err)
+	assertSignedBy(t, second, "message", signature)
+
+	// A broken file must not replace the key in use
+	require.NoError(t, os.WriteFile(path, []byte("not a key"), 0o600))
+	require.Error(t, signer.Reload())
+	signature, err = signer.Sign("message")
+	require.NoError(t, err)
+	assertSignedBy(t, second, "message", signature)
+}
+
+func TestFileSignerRejectsTraversal(t *testing.T) {
+	_, err := NewFileSigner("../keys/privatekey.pem")
+	require.Error(t, err)
+}
+
+func TestEnvSigner(t *testing.T) {
+	key := generateKey(t)
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "pem", value: string(encodeKey(t, key))},
+		{name: "base64 pem", value: base64.StdEncoding.EncodeToString(encodeKey(t, key))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JENGA_PRIVATE_KEY", tt.value)
+
+			signer, err := NewEnvSigner("JENGA_PRIVATE_KEY")
+			require.NoError(t, err)
+			signature, err := signer.Sign("KE0011547896523")
+			require.NoError(t, err)
+			assertSignedBy(t, key, "KE0011547896523", signature)
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		t.Setenv("JENGA_PRIVATE_KEY", "")
+		_, err := NewEnvSigner("JENGA_PRIVATE_KEY")
+		require.Error(t, err)
+	})
+
+	t.Run("not reloadable", func(t *testing.T) {
+		t.Setenv("JENGA_PRIVATE_KEY", string(encodeKey(t, key)))
+		signer, err := NewEnvSigner("JENGA_PRIVATE_KEY")
+		require.NoError(t, err)
+		_, reloadable := Signer(signer).(Reloader)
+		assert.False(t, reloadable, "the environment of a running process never changes")
+	})
+}
+
+func TestHTTPKeyService(t *testing.T) {
+	key := generateKey(t)
+	keys := NewCryptoKeyService()
+	keys.Add("jenga-2025", key)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var request signDigestRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		digest, err := base64.StdEncoding.DecodeString(request.Digest)
+		require.NoError(t, err)
+
+		signature, err := keys.SignDigest(r.Context(), request.KeyID, digest)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(signDigestResponse{
+			Signature: base64.StdEncoding.EncodeToString(signature),
+		}))
+	}))
+	defer server.Close()
+
+	signer := NewRemoteSigner(NewHTTPKeyService(server.URL, "secret"), "jenga-2025", time.Second)
+	signature, err := signer.Sign("message")
+	require.NoError(t, err)
+	assertSignedBy(t, key, "message", signature)
+
+	signer.Rotate("retired")
+	_, err = signer.Sign("message")
+	require.Error(t, err)
+
+	unauthorized := NewRemoteSigner(NewHTTPKeyService(server.URL, ""), "jenga-2025", time.Second)
+	_, err = unauthorized.Sign("message")
+	require.Error(t, err)
+}
+
+func TestRemoteSignerRotatesKeyID(t *testing.T) {
+	first, second := generateKey(t), generateKey(t)
+	keys := NewCryptoKeyService()
+	keys.Add("jenga-2024", first)
+	keys.Add("jenga-2025", second)
+
+	signer := NewRemoteSigner(keys, "jenga-2024", 0)
+	signature, err := signer.Sign("message")
+	require.NoError(t, err)
+	assertSignedBy(t, first, "message", signature)
+
+	signer.Rotate("jenga-2025")
+	signature, err = signer.Sign("message")
+	require.NoError(t, err)
+	assertSignedBy(t, second, "message", signature)
+
+	signer.Rotate("retired")
+	_, err = signer.Sign("message")
+	require.Error(t, err)
+}
+
+func TestTestSignerIsDeterministic(t *testing.T) {
+	first, err := TestSigner{}.Sign("message")
+	require.NoError(t, err)
+	second, err := TestSigner{}.Sign("message")
+	require.NoError(t, err)
+	other, err := TestSigner{}.Sign("other message")
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first, other)
+}
+
+func TestClientWithoutSigner(t *testing.T) {
+	_, err := (&Client{}).GeneratePaymentSignature("KE", "0011547896523")
+	require.Error(t, err)
+}
@@ -0,0 +1,123 @@
+//nolint:revive // package name matches directory structure
+package events_bill_payment //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antinvestor/jenga-api/service/coreapi"
+	"github.com/antinvestor/jenga-api/service/models"
+)
+
+const defaultCatalogueTTL = 6 * time.Hour
+
+// ErrBillerNotFound is returned when a biller code is not part of the Jenga catalogue.
+var ErrBillerNotFound = errors.New("biller not found in catalogue")
+
+// BillerCatalogue caches the Jenga billers catalogue so lookups do not hit the API on every request.
+type BillerCatalogue struct {
+	Client coreapi.JengaApiClient
+	TTL    time.Duration
+
+	mu        sync.RWMutex
+	billers   []models.Biller
+	byCode    map[string]models.Biller
+	fetchedAt time.Time
+}
+
+// NewBillerCatalogue creates a catalogue that refreshes itself once the ttl has elapsed.
+func NewBillerCatalogue(client coreapi.JengaApiClient, ttl time.Duration) *BillerCatalogue {
+	if ttl <= 0 {
+		ttl = defaultCatalogueTTL
+	}
+	return &BillerCatalogue{
+		Client: client,
+		TTL:    ttl,
+	}
+}
+
+// Billers returns the cached billers, optionally filtered by category.
+func (bc *BillerCatalogue) Billers(ctx context.Context, category string) ([]models.Biller, error) {
+	if err := bc.refreshIfStale(ctx); err != nil {
+		return nil, err
+	}
+
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	if category == "" {
+		return append([]models.Biller(nil), bc.billers...), nil
+	}
+
+	var filtered []models.Biller
+	for _, biller := range bc.billers {
+		if strings.EqualFold(biller.Category, category) {
+			filtered = append(filtered, biller)
+		}
+	}
+	return filtered, nil
+}
+
+// Get looks up a single biller by its code.
+func (bc *BillerCatalogue) Get(ctx context.Context, billerCode string) (*models.Biller, error) {
+	if err := bc.refreshIfStale(ctx); err != nil {
+		return nil, err
+	}
+
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	biller, ok := bc.byCode[billerCode]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrBillerNotFound, billerCode)
+	}
+	return &biller, nil
+}
+
+// Invalidate drops the cached catalogue forcing the next lookup to fetch it again.
+func (bc *BillerCatalogue) Invalidate() {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.fetchedAt = time.Time{}
+}
+
+func (bc *BillerCatalogue) refreshIfStale(_ context.Context) error {
+	bc.mu.RLock()
+	fresh := !bc.fetchedAt.IsZero() && time.Since(bc.fetchedAt) < bc.TTL
+	bc.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	// Another caller may have refreshed the catalogue while we waited for the lock
+	if !bc.fetchedAt.IsZero() && time.Since(bc.fetchedAt) < bc.TTL {
+		return nil
+	}
+
+	token, err := bc.Client.GenerateBearerToken()
+	if err != nil {
+		return fmt.Errorf("generate bearer token: %w", err)
+	}
+
+	billers, err := bc.Client.FetchBillers(token.AccessToken)
+	if err != nil {
+		return fmt.Errorf("fetch billers: %w", err)
+	}
+
+	byCode := make(map[string]models.Biller, len(billers))
+	for _, biller := range billers {
+		byCode[biller.BillerCode] = biller
+	}
+
+	bc.billers = billers
+	bc.byCode = byCode
+	bc.fetchedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,374 @@
+//nolint:revive // package name matches directory structure
+package events_bill_payment //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/jenga-api/service/coreapi"
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/antinvestor/jenga-api/service/paymentjob"
+	"github.com/antinvestor/jenga-api/service/repository"
+	"github.com/antinvestor/jenga-api/service/utility"
+	"github.com/pitabwire/frame"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// PaymentType marks the payments of bill payments, the payout consumer hands the released
+	// ones back to be paid.
+	PaymentType        = "bill_payment"
+	paidByJenga        = "jenga"
+	defaultBillRemarks = "Bill payment"
+)
+
+// ErrBillPaymentUnpayable is returned for a released payment that lacks the bill details.
+var ErrBillPaymentUnpayable = errors.New("bill payment cannot be paid")
+
+// JengaBillPayment pays bills through Jenga and reports each step back to the payment service.
+// Every bill payment is registered with the payment service as an outbound payment paid by
+// Jenga. Once it has been released and screened the payment service queues it out to the
+// payout consumer, which hands it back here to be paid. The progress of each job is recorded
+// before Jenga is asked to pay, a redelivered job carries on from there and a bill Jenga gave
+// no definite answer for is queried, never paid again.
+type JengaBillPayment struct {
+	Service       *frame.Service
+	Client        coreapi.JengaApiClient
+	PaymentClient *paymentV1.PaymentClient
+	Catalogue     *BillerCatalogue
+	PartnerID     string
+	// StatusQueryDelay is how long an unanswered payment is left before Jenga is queried for it.
+	StatusQueryDelay time.Duration
+	// Jobs stores the progress of each job, the service datastore is used when it is nil.
+	Jobs repository.PaymentJobRepository
+}
+
+func (event *JengaBillPayment) Name() string {
+	return "jenga.bill.pay"
+}
+
+func (event *JengaBillPayment) PayloadType() any {
+	return &models.Job{}
+}
+
+func (event *JengaBillPayment) Validate(_ context.Context, payload any) error {
+	job, ok := payload.(*models.Job)
+	if !ok {
+		return errors.New("invalid payload type, expected *models.Job")
+	}
+
+	if job.ID == "" {
+		return errors.New("job id is required")
+	}
+	return ValidatePaymentRequest(&job.ExtraData)
+}
+
+// ValidatePaymentRequest checks that a bill payment request carries everything Jenga needs.
+func ValidatePaymentRequest(request *models.PaymentRequest) error {
+	switch {
+	case request.Biller.BillerCode == "":
+		return errors.New("biller.billerCode is required")
+	case request.Biller.CountryCode == "":
+		return errors.New("biller.countryCode is required")
+	case request.Bill.Reference == "":
+		return errors.New("bill.reference is required")
+	case request.Bill.Amount == "":
+		return errors.New("bill.amount is required")
+	case request.Bill.Currency == "":
+		return errors.New("bill.currency is required")
+	case request.Payer.Reference == "":
+		return errors.New("payer.reference is required")
+	}
+
+	if _, err := models.ParseAmount(request.Bill.Currency, request.Bill.Amount); err != nil {
+		return fmt.Errorf("bill.amount is not a valid amount: %w", err)
+	}
+	return nil
+}
+
+// Execute registers the bill payment with the payment service, the bill is paid once the
+// payment has been released.
+func (event *JengaBillPayment) Execute(ctx context.Context, payload any) error {
+	if event.PaymentClient == nil {
+		return errors.New("payment client not initialized")
+	}
+
+	job, ok := payload.(*models.Job)
+	if !ok {
+		return errors.New("invalid payload type, expected *models.Job")
+	}
+
+	request := job.ExtraData
+	if request.PartnerID == "" {
+		request.PartnerID = event.PartnerID
+	}
+	if request.Remarks == "" {
+		request.Remarks = defaultBillRemarks
+	}
+
+	logger := event.Service.Log(ctx).WithField("type", event.Name()).
+		WithField("jobId", job.ID).
+		WithField("billerCode", request.Biller.BillerCode)
+	logger.Info("registering bill payment")
+
+	tracker := event.tracker()
+	progress, err := tracker.Start(ctx, job.ID, request.Payer.Reference)
+	if err != nil {
+		logger.WithError(err).Error("failed to record bill payment job")
+		return err
+	}
+	if progress.State != models.PaymentJobStateNew {
+		return nil
+	}
+
+	paymentID, err := event.registerPayment(ctx, job.ID, &request)
+	if err != nil {
+		logger.WithError(err).Error("failed to register bill payment with payment service")
+		return err
+	}
+	if err = tracker.Registered(ctx, progress, paymentID); err != nil {
+		return err
+	}
+	logger.WithField("paymentId", paymentID).Info("bill payment awaits release")
+	return nil
+}
+
+// PayReleased pays the bill of a payment the payment service released, screened and queued
+// out to Jenga, and reports the outcome. A payment that was seen before carries on from where
+// it got to.
+func (event *JengaBillPayment) PayReleased(ctx context.Context, payout *models.Payout) error {
+	if event.Client == nil {
+		return errors.New("jenga client not initialized")
+	}
+	if event.PaymentClient == nil {
+		return errors.New("payment client not initialized")
+	}
+
+	jobID, _ := payout.Extra["job_id"].(string)
+	if jobID == "" {
+		jobID = payout.ID
+	}
+	payerReference, _ := payout.Extra["payer_reference"].(string)
+	logger := event.Service.Log(ctx).WithField("type", event.Name()).
+		WithField("jobId", jobID).
+		WithField("paymentId", payout.ID)
+	logger.Info("paying released bill payment")
+
+	tracker := event.tracker()
+	progress, err := tracker.Start(ctx, jobID, payerReference)
+	if err != nil {
+		logger.WithError(err).Error("failed to record bill payment job")
+		return err
+	}
+
+	switch {
+	case progress.Settled():
+		logger.WithField("state", progress.State).Info("bill payment was already settled")
+		return nil
+	case progress.State == models.PaymentJobStateSubmitted:
+		// Jenga may have paid already, only its answer is awaited
+		return tracker.Resolve(ctx, progress)
+	case progress.State == models.PaymentJobStateNew:
+		if err = tracker.Registered(ctx, progress, payout.ID); err != nil {
+			return err
+		}
+	}
+
+	request, err := paymentRequest(payout)
+	if err != nil {
+		logger.WithError(err).Warn("bill payment cannot be paid")
+		return tracker.Fail(ctx, progress, err)
+	}
+
+	if event.Catalogue != nil {
+		if _, err = event.Catalogue.Get(ctx, request.Biller.BillerCode); err != nil {
+			logger.WithError(err).Warn("biller is not available")
+			return tracker.Fail(ctx, progress, err)
+		}
+	}
+
+	token, err := event.Client.GenerateBearerToken()
+	if err != nil {
+		logger.WithError(err).Error("failed to generate bearer token")
+		return tracker.Fail(ctx, progress, fmt.Errorf("generate bearer token: %w", err))
+	}
+
+	validation, err := event.validateReference(request, token.AccessToken)
+	if err != nil {
+		logger.WithError(err).Warn("bill reference validation failed")
+		return tracker.Fail(ctx, progress, err)
+	}
+
+	if statusErr := tracker.UpdateStatus(ctx, progress.PaymentID, commonv1.STATUS_IN_PROCESS, map[string]string{
+		"message":       "Bill reference validated",
+		"customer_name": validation.Data.CustomerName,
+	}); statusErr != nil {
+		logger.WithError(statusErr).Warn("failed to report in process status")
+	}
+
+	if err = tracker.Submit(ctx, progress); err != nil {
+		logger.WithError(err).Error("failed to record bill payment submission")
+		return err
+	}
+
+	response, err := event.Client.PayBill(*request, token.AccessToken)
+	if err != nil {
+		tracker.Unconfirmed(ctx, progress, err)
+		return nil
+	}
+	if !response.Status {
+		logger.WithField("response", response).Warn("bill payment was declined")
+		return tracker.Fail(ctx, progress, fmt.Errorf("pay bill declined: %s", response.Message))
+	}
+
+	logger.WithField("response", response).Info("bill payment response received")
+
+	return tracker.Paid(ctx, progress, response.Data.TransactionId, map[string]string{
+		"message":        response.Message,
+		"reference":      response.Reference,
+		"transaction_id": response.Data.TransactionId,
+		"jenga_status":   response.Data.Status,
+	})
+}
+
+// RunStatusQueries periodically queries Jenga for bill payments it gave no definite answer
+// for. It blocks until the context is cancelled and is meant to run as a background consumer.
+func (event *JengaBillPayment) RunStatusQueries(ctx context.Context) error {
+	return event.tracker().RunStatusQueries(ctx)
+}
+
+// ValidateReference confirms the bill reference with the biller before any money moves.
+func (event *JengaBillPayment) ValidateReference(
+	_ context.Context,
+	request *models.PaymentRequest,
+) (*models.BillValidationResponse, error) {
+	token, err := event.Client.GenerateBearerToken()
+	if err != nil {
+		return nil, fmt.Errorf("generate bearer token: %w", err)
+	}
+	return event.validateReference(request, token.AccessToken)
+}
+
+func (event *JengaBillPayment) validateReference(
+	request *models.PaymentRequest,
+	accessToken string,
+) (*models.BillValidationResponse, error) {
+	validation, err := event.Client.ValidateBill(models.BillValidationRequest{
+		BillerCode:        request.Biller.BillerCode,
+		CustomerRefNumber: request.Bill.Reference,
+		Amount:            request.Bill.Amount,
+		AmountCurrency:    request.Bill.Currency,
+	}, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("validate bill: %w", err)
+	}
+	if !validation.Status {
+		return validation, fmt.Errorf("bill reference rejected by biller: %s", validation.Message)
+	}
+	return validation, nil
+}
+
+// registerPayment records the bill payment as an outbound payment so it has its own status
+// lifecycle and goes through the release gates, returning the id the payment service tracks
+// it under. The extras carry what paying the bill needs once it is released.
+func (event *JengaBillPayment) registerPayment(
+	ctx context.Context,
+	jobID string,
+	request *models.PaymentRequest,
+) (string, error) {
+	amountDecimal, err := decimal.NewFromString(request.Bill.Amount)
+	if err != nil {
+		return "", fmt.Errorf("parse bill amount: %w", err)
+	}
+	amount := utility.ToMoney(request.Bill.Currency, amountDecimal)
+
+	payment := &paymentV1.Payment{
+		ReferenceId: request.Bill.Reference,
+		Source: &commonv1.ContactLink{
+			ProfileName: request.Payer.Name,
+			Detail:      request.Payer.MobileNumber,
+		},
+		Recipient: &commonv1.ContactLink{
+			ProfileName: request.Biller.Name,
+			Detail:      request.Biller.BillerCode,
+		},
+		Amount:   &amount,
+		Outbound: true,
+		Extra: map[string]string{
+			"payment_type":    PaymentType,
+			"paid_by":         paidByJenga,
+			"job_id":          jobID,
+			"biller_code":     request.Biller.BillerCode,
+			"biller_country":  request.Biller.CountryCode,
+			"biller_name":     request.Biller.Name,
+			"bill_reference":  request.Bill.Reference,
+			"payer_name":      request.Payer.Name,
+			"payer_reference": request.Payer.Reference,
+			"payer_account":   request.Payer.Account,
+			"payer_mobile":    request.Payer.MobileNumber,
+			"partner_id":      request.PartnerID,
+			"remarks":         request.Remarks,
+		},
+	}
+
+	response, err := event.PaymentClient.Client.Send(ctx, &paymentV1.SendRequest{Data: payment})
+	if err != nil {
+		return "", fmt.Errorf("payment client send: %w", err)
+	}
+	if response.GetData().GetId() == "" {
+		return "", errors.New("payment client send: no payment id returned")
+	}
+	return response.GetData().GetId(), nil
+}
+
+// paymentRequest rebuilds the request paying the bill from the details registered with the
+// payment.
+func paymentRequest(payout *models.Payout) (*models.PaymentRequest, error) {
+	extra := func(key string) string {
+		value, _ := payout.Extra[key].(string)
+		return value
+	}
+	if !payout.Amount.Valid {
+		return nil, fmt.Errorf("%w: the amount is missing", ErrBillPaymentUnpayable)
+	}
+	amount, err := models.NewAmount(payout.Amount.Decimal).Exact(payout.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBillPaymentUnpayable, err)
+	}
+
+	request := &models.PaymentRequest{
+		Biller: models.Biller{
+			BillerCode:  extra("biller_code"),
+			CountryCode: extra("biller_country"),
+			Name:        extra("biller_name"),
+		},
+		Bill: models.Bill{Reference: extra("bill_reference"), Amount: amount, Currency: payout.Currency},
+		Payer: models.Payer{
+			Name:         extra("payer_name"),
+			Account:      extra("payer_account"),
+			Reference:    extra("payer_reference"),
+			MobileNumber: extra("payer_mobile"),
+		},
+		PartnerID: extra("partner_id"),
+		Remarks:   extra("remarks"),
+	}
+	if err = ValidatePaymentRequest(request); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBillPaymentUnpayable, err)
+	}
+	return request, nil
+}
+
+func (event *JengaBillPayment) tracker() *paymentjob.Tracker {
+	return &paymentjob.Tracker{
+		Service:          event.Service,
+		Client:           event.Client,
+		PaymentClient:    event.PaymentClient,
+		Kind:             PaymentType,
+		StatusQueryDelay: event.StatusQueryDelay,
+		Jobs:             event.Jobs,
+	}
+}
@@ -0,0 +1,240 @@
+//nolint:revive // package name matches directory structure
+package events_bill_payment //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/jenga-api/service/coreapi"
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/antinvestor/jenga-api/service/repository/repositorytest"
+	"github.com/pitabwire/frame"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"gorm.io/datatypes"
+)
+
+// paymentService records the payments registered with it and the statuses reported.
+type paymentService struct {
+	paymentV1.PaymentServiceClient
+
+	mu       sync.Mutex
+	sent     []*paymentV1.Payment
+	statuses []*commonv1.StatusUpdateRequest
+}
+
+func (p *paymentService) Send(
+	_ context.Context,
+	in *paymentV1.SendRequest,
+	_ ...grpc.CallOption,
+) (*paymentV1.SendResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sent = append(p.sent, in.GetData())
+	return &paymentV1.SendResponse{Data: &commonv1.StatusResponse{Id: "payment-1"}}, nil
+}
+
+func (p *paymentService) StatusUpdate(
+	_ context.Context,
+	in *commonv1.StatusUpdateRequest,
+	_ ...grpc.CallOption,
+) (*commonv1.StatusUpdateResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.statuses = append(p.statuses, in)
+	return &commonv1.StatusUpdateResponse{}, nil
+}
+
+func (p *paymentService) lastStatus(t *testing.T) *commonv1.StatusUpdateRequest {
+	t.Helper()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	require.NotEmpty(t, p.statuses)
+	return p.statuses[len(p.statuses)-1]
+}
+
+func newBillPayment(t *testing.T) (*JengaBillPayment, *coreapi.MockClient, *paymentService) {
+	t.Helper()
+	ctx, service := frame.NewService("jenga_bill_payment_test")
+	t.Cleanup(func() { service.Stop(ctx) })
+
+	client := new(coreapi.MockClient)
+	client.On("GenerateBearerToken").Return(&coreapi.BearerTokenResponse{AccessToken: "token"}, nil)
+	validation := &models.BillValidationResponse{Status: true}
+	validation.Data.CustomerName = "JOHN KAMAU"
+	client.On("ValidateBill", mock.Anything, "token").Return(validation, nil)
+
+	payments := &paymentService{}
+	return &JengaBillPayment{
+		Service:       service,
+		Client:        client,
+		PaymentClient: &paymentV1.PaymentClient{Client: payments},
+		PartnerID:     "partner",
+		Jobs:          repositorytest.NewPaymentJobs(),
+	}, client, payments
+}
+
+func billJob() *models.Job {
+	return &models.Job{ID: "job-1", ExtraData: models.PaymentRequest{
+		Biller: models.Biller{BillerCode: "320320", CountryCode: "KE"},
+		Bill:   models.Bill{Reference: "ACC-1", Amount: "150.00", Currency: "KES"},
+		Payer:  models.Payer{Name: "John Kamau", Reference: "PAYER-REF-1", MobileNumber: "254712345678"},
+	}}
+}
+
+// released is the bill payment as the payment service queues it out once it was released.
+func released(t *testing.T, payments *paymentService) *models.Payout {
+	t.Helper()
+	payments.mu.Lock()
+	defer payments.mu.Unlock()
+	require.Len(t, payments.sent, 1)
+
+	extra := datatypes.JSONMap{}
+	for key, value := range payments.sent[0].GetExtra() {
+		extra[key] = value
+	}
+	payout := &models.Payout{
+		Amount:   decimal.NewNullDecimal(decimal.RequireFromString("150.00")),
+		Currency: "KES",
+		OutBound: true,
+		Extra:    extra,
+	}
+	payout.ID = "payment-1"
+	return payout
+}
+
+// registeredAndReleased registers the bill job and returns its payment once released.
+func registeredAndReleased(t *testing.T, event *JengaBillPayment, payments *paymentService) *models.Payout {
+	t.Helper()
+	require.NoError(t, event.Execute(t.Context(), billJob()))
+	return released(t, payments)
+}
+
+func transactionState(state string) *models.TransactionStatusResponse {
+	response := &models.TransactionStatusResponse{Status: true}
+	response.Data.State = state
+	response.Data.TransactionReference = "JENGA-TX-1"
+	return response
+}
+
+func TestBillPaymentAwaitsRelease(t *testing.T) {
+	event, client, payments := newBillPayment(t)
+
+	for range 2 {
+		require.NoError(t, event.Execute(t.Context(), billJob()))
+	}
+
+	client.AssertNotCalled(t, "PayBill", mock.Anything, mock.Anything)
+	require.Len(t, payments.sent, 1)
+	assert.Equal(t, "job-1", payments.sent[0].GetExtra()["job_id"])
+	assert.Equal(t, paidByJenga, payments.sent[0].GetExtra()["paid_by"])
+	assert.Empty(t, payments.statuses)
+
+	progress, err := event.Jobs.GetByJobID(t.Context(), "job-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentJobStateRegistered, progress.State)
+}
+
+func TestBillPaymentRedeliveryPaysOnce(t *testing.T) {
+	event, client, payments := newBillPayment(t)
+	paid := &models.PaymentResponse{Status: true, Message: "paid"}
+	paid.Data.TransactionId = "JENGA-TX-1"
+	client.On("PayBill", models.PaymentRequest{
+		Biller:    models.Biller{BillerCode: "320320", CountryCode: "KE"},
+		Bill:      models.Bill{Reference: "ACC-1", Amount: "150.00", Currency: "KES"},
+		Payer:     models.Payer{Name: "John Kamau", Reference: "PAYER-REF-1", MobileNumber: "254712345678"},
+		PartnerID: "partner",
+		Remarks:   defaultBillRemarks,
+	}, "token").Return(paid, nil).Once()
+
+	payout := registeredAndReleased(t, event, payments)
+	for range 2 {
+		require.NoError(t, event.PayReleased(t.Context(), payout))
+	}
+
+	client.AssertNumberOfCalls(t, "PayBill", 1)
+	status := payments.lastStatus(t)
+	assert.Equal(t, "payment-1", status.GetId())
+	assert.Equal(t, commonv1.STATUS_SUCCESSFUL, status.GetStatus())
+
+	progress, err := event.Jobs.GetByJobID(t.Context(), "job-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentJobStatePaid, progress.State)
+	assert.Equal(t, "JENGA-TX-1", progress.TransactionID)
+}
+
+func TestBillPaymentUnknownOutcomeIsQueried(t *testing.T) {
+	tests := []struct {
+		name       string
+		queried    string
+		wantStatus commonv1.STATUS
+		wantState  string
+	}{
+		{name: "jenga paid", queried: "SUCCESS",
+			wantStatus: commonv1.STATUS_SUCCESSFUL, wantState: models.PaymentJobStatePaid},
+		{name: "jenga failed", queried: "FAILED",
+			wantStatus: commonv1.STATUS_FAILED, wantState: models.PaymentJobStateFailed},
+		{name: "still pending", queried: "PENDING",
+			wantStatus: commonv1.STATUS_IN_PROCESS, wantState: models.PaymentJobStateSubmitted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, client, payments := newBillPayment(t)
+			client.On("PayBill", mock.Anything, "token").Return(nil, errors.New("connection reset")).Once()
+			client.On("QueryTransactionStatus", "PAYER-REF-1", "token").Return(transactionState(tt.queried), nil)
+			payout := registeredAndReleased(t, event, payments)
+
+			// The transport error is not handed back, a redelivery would pay again
+			require.NoError(t, event.PayReleased(t.Context(), payout))
+			status := payments.lastStatus(t)
+			assert.Equal(t, commonv1.STATUS_IN_PROCESS, status.GetStatus())
+			assert.Equal(t, "connection reset", status.GetExtras()["error"])
+
+			require.NoError(t, event.PayReleased(t.Context(), payout))
+			client.AssertNumberOfCalls(t, "PayBill", 1)
+			assert.Equal(t, tt.wantStatus, payments.lastStatus(t).GetStatus())
+
+			progress, err := event.Jobs.GetByJobID(t.Context(), "job-1")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantState, progress.State)
+		})
+	}
+}
+
+func TestBillPaymentDeclined(t *testing.T) {
+	event, client, payments := newBillPayment(t)
+	client.On("PayBill", mock.Anything, "token").
+		Return(&models.PaymentResponse{Status: false, Message: "insufficient balance"}, nil).Once()
+	payout := registeredAndReleased(t, event, payments)
+
+	err := event.PayReleased(t.Context(), payout)
+	require.ErrorContains(t, err, "insufficient balance")
+	assert.Equal(t, commonv1.STATUS_FAILED, payments.lastStatus(t).GetStatus())
+
+	require.NoError(t, event.PayReleased(t.Context(), payout))
+	client.AssertNumberOfCalls(t, "PayBill", 1)
+}
+
+func TestBillPaymentStatusQueries(t *testing.T) {
+	event, client, payments := newBillPayment(t)
+	event.StatusQueryDelay = time.Millisecond
+	client.On("PayBill", mock.Anything, "token").Return(nil, errors.New("timeout")).Once()
+	client.On("QueryTransactionStatus", "PAYER-REF-1", "token").Return(transactionState("SUCCESS"), nil)
+
+	require.NoError(t, event.PayReleased(t.Context(), registeredAndReleased(t, event, payments)))
+	time.Sleep(5 * time.Millisecond)
+	event.tracker().QueryUnconfirmed(t.Context())
+
+	status := payments.lastStatus(t)
+	assert.Equal(t, commonv1.STATUS_SUCCESSFUL, status.GetStatus())
+	assert.Equal(t, "status_query", status.GetExtras()["resolved_by"])
+	client.AssertNumberOfCalls(t, "PayBill", 1)
+}
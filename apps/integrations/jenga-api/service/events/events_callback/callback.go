@@ -4,15 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 
 	commonv1 "github.com/antinvestor/apis/go/common/v1"
 	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
 	"github.com/antinvestor/jenga-api/service/models"
 	"github.com/antinvestor/jenga-api/service/utility"
 	"github.com/pitabwire/frame"
-	"github.com/shopspring/decimal"
 )
 
+const callbackTypeIPN = "IPN"
+
+// JengaCallbackReceivePayment records a bank instant payment notification as an inbound payment.
+// Notifications that do not confirm the payment only update the status of what they answer.
 type JengaCallbackReceivePayment struct {
 	Service       *frame.Service
 	PaymentClient *paymentV1.PaymentClient
@@ -35,8 +40,11 @@ func (event *JengaCallbackReceivePayment) Validate(_ context.Context, payload an
 	if req.Transaction.Reference == "" {
 		return errors.New("transaction reference is required")
 	}
+	if req.CallbackType != "" && !strings.EqualFold(req.CallbackType, callbackTypeIPN) {
+		return fmt.Errorf("unexpected callback type %q for an IPN", req.CallbackType)
+	}
 
-	return nil
+	return validateAmount(req.Transaction.Amount, req.Transaction.Currency)
 }
 
 func (event *JengaCallbackReceivePayment) Execute(ctx context.Context, payload any) error {
@@ -54,9 +62,15 @@ func (event *JengaCallbackReceivePayment) Execute(ctx context.Context, payload a
 
 	logger.WithField("callback", req).Info("Received Jenga callback for payment processing")
 
+	paid, err := isSuccessfulStatus(req.Transaction.Status)
+	if !paid {
+		return event.reportUnpaid(ctx, req, err)
+	}
+
 	// Create base payment structure
-	amount := utility.ToMoney(req.Transaction.Currency, decimal.NewFromFloat(req.Transaction.Amount))
-	cost := utility.ToMoney(req.Transaction.Currency, decimal.NewFromFloat(req.Transaction.ServiceCharge))
+	currency := req.Transaction.Currency
+	amount := utility.ToMoney(currency, req.Transaction.Amount.Decimal)
+	cost := utility.ToMoney(currency, req.Transaction.ServiceCharge.RoundTo(currency).Decimal)
 	payment := &paymentV1.Payment{
 		Source: &commonv1.ContactLink{
 			Detail: req.Customer.MobileNumber,
@@ -67,10 +81,16 @@ func (event *JengaCallbackReceivePayment) Execute(ctx context.Context, payload a
 		TransactionId: req.Transaction.Reference,
 		Amount:        &amount,
 		Cost:          &cost,
+		Extra: map[string]string{
+			"callback_type":   "ipn",
+			"transaction_ref": req.Transaction.Reference,
+			"bank_reference":  req.Bank.Reference,
+			"bill_number":     req.Transaction.BillNumber,
+			"payment_mode":    req.Transaction.PaymentMode,
+		},
 	}
 
 	var callbackJSON []byte
-	var err error
 	if callbackJSON, err = json.Marshal(req); err == nil {
 		payment.Extra["additional_info"] = string(callbackJSON)
 	}
@@ -86,3 +106,68 @@ func (event *JengaCallbackReceivePayment) Execute(ctx context.Context, payload a
 	}
 	return nil
 }
+
+// reportUnpaid sends the status of a notification that does not confirm its payment, nothing is
+// received for it. The payment service matches the status to the prompt it answers by reference.
+func (event *JengaCallbackReceivePayment) reportUnpaid(
+	ctx context.Context,
+	req *models.CallbackRequest,
+	statusErr error,
+) error {
+	status := commonv1.STATUS_FAILED
+	if errors.Is(statusErr, ErrUnknownStatus) {
+		status = commonv1.STATUS_UNKNOWN
+	}
+
+	_, err := event.PaymentClient.Client.StatusUpdate(ctx, &commonv1.StatusUpdateRequest{
+		State:      commonv1.STATE_ACTIVE,
+		Status:     status,
+		ExternalId: req.Transaction.Reference,
+		Extras: map[string]string{
+			"entity_type":     "prompt",
+			"transaction_ref": req.Transaction.Reference,
+			"callback_type":   "ipn",
+			"jenga_status":    req.Transaction.Status,
+			"message":         req.Transaction.Remarks,
+		},
+	})
+	if err != nil {
+		event.Service.Log(ctx).WithError(err).
+			WithField("transaction_ref", req.Transaction.Reference).
+			Error("failed to record unpaid IPN callback")
+		return err
+	}
+	return nil
+}
+
+// validateAmount checks the amount and currency common to all payment callbacks. Amounts in
+// currencies without minor-unit rules are accepted as sent.
+func validateAmount(amount models.Amount, currency string) error {
+	if !amount.IsPositive() {
+		return errors.New("amount must be greater than zero")
+	}
+	if len(currency) != 3 {
+		return errors.New("a three letter currency code is required")
+	}
+	if _, err := amount.Exact(currency); err != nil && !errors.Is(err, models.ErrUnsupportedCurrency) {
+		return err
+	}
+	return nil
+}
+
+// ErrUnknownStatus is returned for callbacks whose transaction status neither confirms nor
+// refuses the payment, the inbox keeps them for review instead of guessing.
+var ErrUnknownStatus = errors.New("callback transaction status is unknown")
+
+// isSuccessfulStatus reports whether a callback's transaction status confirms payment. A
+// callback without a status says nothing about the payment and ErrUnknownStatus is returned.
+func isSuccessfulStatus(status string) (bool, error) {
+	switch strings.ToUpper(strings.TrimSpace(status)) {
+	case "":
+		return false, ErrUnknownStatus
+	case "SUCCESS", "SUCCESSFUL", "COMPLETED", "SETTLED":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
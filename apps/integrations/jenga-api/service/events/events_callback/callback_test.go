@@ -0,0 +1,241 @@
+//nolint:revive // package name matches directory structure
+package events_callback //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/jenga-api/service/events/events_stk"
+	"github.com/pitabwire/frame"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// paymentService records what the callbacks report to the payment service.
+type paymentService struct {
+	paymentV1.PaymentServiceClient
+
+	receipts      []*paymentV1.Payment
+	statusUpdates []*commonv1.StatusUpdateRequest
+}
+
+func (p *paymentService) Receive(
+	_ context.Context,
+	in *paymentV1.ReceiveRequest,
+	_ ...grpc.CallOption,
+) (*paymentV1.ReceiveResponse, error) {
+	p.receipts = append(p.receipts, in.GetData())
+	return &paymentV1.ReceiveResponse{}, nil
+}
+
+func (p *paymentService) StatusUpdate(
+	_ context.Context,
+	in *commonv1.StatusUpdateRequest,
+	_ ...grpc.CallOption,
+) (*commonv1.StatusUpdateResponse, error) {
+	p.statusUpdates = append(p.statusUpdates, in)
+	return &commonv1.StatusUpdateResponse{}, nil
+}
+
+// process decodes a callback body into the event's payload, validates and executes it.
+func process(t *testing.T, event frame.EventI, body string) error {
+	t.Helper()
+	payload := event.PayloadType()
+	require.NoError(t, json.Unmarshal([]byte(body), payload))
+	if err := event.Validate(t.Context(), payload); err != nil {
+		return err
+	}
+	return event.Execute(t.Context(), payload)
+}
+
+func newService(t *testing.T) (*frame.Service, *paymentV1.PaymentClient, *paymentService) {
+	t.Helper()
+	ctx, service := frame.NewService(t.Name(), frame.WithNoopDriver())
+	t.Cleanup(func() { service.Stop(ctx) })
+	payments := &paymentService{}
+	return service, &paymentV1.PaymentClient{Client: payments}, payments
+}
+
+func TestStkCallback(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		wantErr        bool
+		wantReceived   bool
+		wantResolution string
+	}{
+		{
+			name: "settled",
+			body: `{"status":true,"code":3,"transactionReference":"A12345","mobileNumber":"254712345678",` +
+				`"currency":"KES","requestAmount":150,"charge":1,"telco":"Safaricom"}`,
+			wantReceived: true,
+		},
+		{
+			name: "cancelled by the customer",
+			body: `{"status":false,"code":4,"message":"Request cancelled by user","transactionReference":"A12345",` +
+				`"mobileNumber":"254712345678","currency":"KES","requestAmount":150}`,
+			wantResolution: events_stk.ResolveFailure("Request cancelled by user"),
+		},
+		{
+			name:    "without a mobile number",
+			body:    `{"status":true,"code":3,"transactionReference":"A12345","currency":"KES"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, client, payments := newService(t)
+			err := process(t, &JengaStkCallback{Service: service, PaymentClient: client}, tt.body)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Empty(t, payments.receipts)
+				assert.Empty(t, payments.statusUpdates)
+				return
+			}
+			require.NoError(t, err)
+
+			if tt.wantReceived {
+				require.Len(t, payments.receipts, 1)
+				receipt := payments.receipts[0]
+				assert.Equal(t, "A12345", receipt.GetTransactionId())
+				assert.Equal(t, "A12345", receipt.GetExtra()["transaction_ref"])
+				assert.Equal(t, "254712345678", receipt.GetSource().GetDetail())
+				assert.Equal(t, int64(150), receipt.GetAmount().GetUnits())
+				assert.Empty(t, payments.statusUpdates)
+				return
+			}
+			assert.Empty(t, payments.receipts)
+			require.Len(t, payments.statusUpdates, 1)
+			update := payments.statusUpdates[0]
+			assert.Equal(t, commonv1.STATUS_FAILED, update.GetStatus())
+			assert.Equal(t, "prompt", update.GetExtras()["entity_type"])
+			assert.Equal(t, "A12345", update.GetExtras()["transaction_ref"])
+			assert.Equal(t, tt.wantResolution, update.GetExtras()["resolution"])
+		})
+	}
+}
+
+func TestIPNCallback(t *testing.T) {
+	service, client, payments := newService(t)
+	event := &JengaCallbackReceivePayment{Service: service, PaymentClient: client}
+
+	require.NoError(t, process(t, event, `{"callbackType":"IPN","customer":{"mobileNumber":"254712345678"},`+
+		`"transaction":{"reference":"IPN001","amount":"500.50","currency":"KES","serviceCharge":"10",`+
+		`"status":"SUCCESS"},"bank":{"account":"1100161816677","reference":"BNK001"}}`))
+	require.Len(t, payments.receipts, 1)
+	receipt := payments.receipts[0]
+	assert.Equal(t, "IPN001", receipt.GetTransactionId())
+	assert.Equal(t, "1100161816677", receipt.GetRecipient().GetDetail())
+	assert.Equal(t, "ipn", receipt.GetExtra()["callback_type"])
+	assert.Equal(t, "BNK001", receipt.GetExtra()["bank_reference"])
+	assert.Equal(t, int64(500), receipt.GetAmount().GetUnits())
+	assert.Equal(t, int32(500000000), receipt.GetAmount().GetNanos())
+
+	for _, body := range []string{
+		`{"callbackType":"TILLS","transaction":{"reference":"IPN002","amount":"500","currency":"KES"}}`,
+		`{"transaction":{"reference":"IPN003","amount":"0","currency":"KES"}}`,
+		`{"transaction":{"reference":"IPN004","amount":"500","currency":"KSH1"}}`,
+	} {
+		require.Error(t, process(t, event, body), body)
+	}
+	assert.Len(t, payments.receipts, 1)
+
+	// Notifications that do not confirm the payment credit nobody
+	for status, want := range map[string]commonv1.STATUS{
+		"FAILED": commonv1.STATUS_FAILED,
+		"":       commonv1.STATUS_UNKNOWN,
+	} {
+		payments.statusUpdates = nil
+		require.NoError(t, process(t, event, `{"callbackType":"IPN","transaction":{"reference":"IPN005",`+
+			`"amount":"500","currency":"KES","status":"`+status+`"}}`), status)
+		require.Len(t, payments.statusUpdates, 1, status)
+		update := payments.statusUpdates[0]
+		assert.Equal(t, want, update.GetStatus(), status)
+		assert.Equal(t, "prompt", update.GetExtras()["entity_type"])
+		assert.Equal(t, "IPN005", update.GetExtras()["transaction_ref"])
+	}
+	assert.Len(t, payments.receipts, 1)
+}
+
+func TestTransactionStatusCallbacks(t *testing.T) {
+	tills := func(status string) string {
+		return `{"till":{"number":"5432100"},"customer":{"name":"JANE","mobileNumber":"254712345678"},` +
+			`"transaction":{"reference":"TILL001","amount":"250","currency":"KES","status":"` + status + `"}}`
+	}
+	paymentLink := func(status string) string {
+		return `{"paymentLinkRef":"PL123","externalRef":"INV-001","customer":{"mobileNumber":"254712345678"},` +
+			`"transaction":{"reference":"PLT001","amount":"500","currency":"KES","status":"` + status + `"}}`
+	}
+
+	tests := []struct {
+		name         string
+		event        func(*frame.Service, *paymentV1.PaymentClient) frame.EventI
+		body         func(status string) string
+		extra        string
+		extraValue   string
+		recipient    string
+		transactions string
+	}{
+		{
+			name: "till payment",
+			event: func(service *frame.Service, client *paymentV1.PaymentClient) frame.EventI {
+				return &JengaTillsCallback{Service: service, PaymentClient: client}
+			},
+			body:  tills,
+			extra: "till_number", extraValue: "5432100", recipient: "5432100", transactions: "TILL001",
+		},
+		{
+			name: "payment link payment",
+			event: func(service *frame.Service, client *paymentV1.PaymentClient) frame.EventI {
+				return &JengaPaymentLinkCallback{Service: service, PaymentClient: client}
+			},
+			body:  paymentLink,
+			extra: "payment_link_ref", extraValue: "PL123", transactions: "PLT001",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, client, payments := newService(t)
+			event := tt.event(service, client)
+
+			require.NoError(t, process(t, event, tt.body("SUCCESS")))
+			require.Len(t, payments.receipts, 1)
+			receipt := payments.receipts[0]
+			assert.Equal(t, tt.transactions, receipt.GetTransactionId())
+			assert.Equal(t, tt.extraValue, receipt.GetExtra()[tt.extra])
+			assert.Equal(t, tt.recipient, receipt.GetRecipient().GetDetail())
+			assert.Equal(t, "254712345678", receipt.GetSource().GetDetail())
+
+			// Payments that did not go through are acknowledged and ignored
+			require.NoError(t, process(t, event, tt.body("FAILED")))
+			// Callbacks that do not say how the payment went are kept for review
+			require.ErrorIs(t, process(t, event, tt.body("")), ErrUnknownStatus)
+			assert.Len(t, payments.receipts, 1)
+		})
+	}
+}
+
+func TestIsSuccessfulStatus(t *testing.T) {
+	tests := []struct {
+		status  string
+		want    bool
+		wantErr error
+	}{
+		{status: "SUCCESS", want: true},
+		{status: "completed", want: true},
+		{status: "Settled", want: true},
+		{status: "FAILED"},
+		{status: "PENDING"},
+		{status: "", wantErr: ErrUnknownStatus},
+		{status: "  ", wantErr: ErrUnknownStatus},
+	}
+	for _, tt := range tests {
+		got, err := isSuccessfulStatus(tt.status)
+		assert.Equal(t, tt.want, got, tt.status)
+		assert.ErrorIs(t, err, tt.wantErr, tt.status)
+	}
+}
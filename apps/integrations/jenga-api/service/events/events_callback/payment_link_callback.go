@@ -0,0 +1,102 @@
+//nolint:revive // package name matches directory structure
+package events_callback //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/antinvestor/jenga-api/service/utility"
+	"github.com/pitabwire/frame"
+)
+
+type JengaPaymentLinkCallback struct {
+	Service       *frame.Service
+	PaymentClient *paymentV1.PaymentClient
+}
+
+func (event *JengaPaymentLinkCallback) Name() string {
+	return "jenga.callback.payment.link"
+}
+
+func (event *JengaPaymentLinkCallback) PayloadType() any {
+	return &models.PaymentLinkCallback{}
+}
+
+func (event *JengaPaymentLinkCallback) Validate(_ context.Context, payload any) error {
+	callback, ok := payload.(*models.PaymentLinkCallback)
+	if !ok {
+		return errors.New("invalid payload type")
+	}
+
+	if callback.Transaction.Reference == "" {
+		return errors.New("transaction reference is required")
+	}
+	if callback.PaymentLinkRef == "" {
+		return errors.New("payment link reference is required")
+	}
+	return validateAmount(callback.Transaction.Amount, callback.Transaction.Currency)
+}
+
+func (event *JengaPaymentLinkCallback) Execute(ctx context.Context, payload any) error {
+	logger := event.Service.Log(ctx)
+
+	if event.PaymentClient == nil {
+		return errors.New("payment client not initialized")
+	}
+
+	callback, ok := payload.(*models.PaymentLinkCallback)
+	if !ok {
+		return errors.New("invalid payload type")
+	}
+	logger = logger.
+		WithField("transaction_ref", callback.Transaction.Reference).
+		WithField("payment_link_ref", callback.PaymentLinkRef)
+
+	paid, err := isSuccessfulStatus(callback.Transaction.Status)
+	if err != nil {
+		logger.WithError(err).Warn("Payment link payment callback carries no status")
+		return err
+	}
+	if !paid {
+		logger.WithField("status", callback.Transaction.Status).Info("ignoring unsuccessful payment link payment")
+		return nil
+	}
+
+	currency := callback.Transaction.Currency
+	amount := utility.ToMoney(currency, callback.Transaction.Amount.Decimal)
+	cost := utility.ToMoney(currency, callback.Transaction.ServiceCharge.RoundTo(currency).Decimal)
+	payment := &paymentV1.Payment{
+		Source: &commonv1.ContactLink{
+			ProfileName: callback.Customer.Name,
+			Detail:      callback.Customer.MobileNumber,
+			Extras: map[string]string{
+				"email": callback.Customer.Email,
+			},
+		},
+		TransactionId: callback.Transaction.Reference,
+		Amount:        &amount,
+		Cost:          &cost,
+		Extra: map[string]string{
+			"callback_type":    "payment_link",
+			"transaction_ref":  callback.Transaction.Reference,
+			"payment_link_ref": callback.PaymentLinkRef,
+			"external_ref":     callback.ExternalRef,
+			"payment_mode":     callback.Transaction.PaymentMode,
+		},
+	}
+
+	if callbackJSON, err := json.Marshal(callback); err == nil {
+		payment.Extra["additional_info"] = string(callbackJSON)
+	}
+
+	_, err = event.PaymentClient.Client.Receive(ctx, &paymentV1.ReceiveRequest{Data: payment})
+	if err != nil {
+		logger.WithError(err).Error("failed to process payment link callback")
+		return err
+	}
+	return nil
+}
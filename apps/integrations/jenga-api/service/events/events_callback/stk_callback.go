@@ -3,14 +3,14 @@ package events_callback //nolint:staticcheck // underscore package name required
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"strconv"
 
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
 	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/jenga-api/service/events/events_stk"
 	"github.com/antinvestor/jenga-api/service/models"
-	"github.com/antinvestor/jenga-api/service/utility"
 	"github.com/pitabwire/frame"
-	"github.com/shopspring/decimal"
 )
 
 type JengaStkCallback struct {
@@ -38,6 +38,9 @@ func (event *JengaStkCallback) Validate(ctx context.Context, payload any) error
 	if callback.MobileNumber == "" {
 		return errors.New("mobile number is required")
 	}
+	if callback.Currency == "" {
+		return errors.New("currency is required")
+	}
 
 	return nil
 }
@@ -55,20 +58,12 @@ func (event *JengaStkCallback) Execute(ctx context.Context, payload any) error {
 	}
 	logger.WithField("callback", callback).Info("Received Jenga STK callback")
 
-	amount := utility.ToMoney(callback.Currency, decimal.NewFromFloat(callback.RequestAmount))
-	cost := utility.ToMoney(callback.Currency, decimal.NewFromFloat(callback.Charge))
-
-	payment := &paymentV1.Payment{
-		TransactionId: callback.Transaction,
-		Amount:        &amount,
-		Cost:          &cost,
+	if !IsStkPaid(callback) {
+		return event.failPrompt(ctx, callback)
 	}
 
-	if callbackJSON, err := json.Marshal(callback); err == nil {
-		payment.Extra["additional_info"] = string(callbackJSON)
-	}
 	_, err := event.PaymentClient.Client.Receive(ctx, &paymentV1.ReceiveRequest{
-		Data: payment,
+		Data: events_stk.ReceivedPayment(callback),
 	})
 	if err != nil {
 		logger.WithError(err).Error("failed to process STK callback")
@@ -77,3 +72,33 @@ func (event *JengaStkCallback) Execute(ctx context.Context, payload any) error {
 
 	return nil
 }
+
+// IsStkPaid reports whether an STK callback confirms a completed payment.
+func IsStkPaid(callback *models.StkCallback) bool {
+	return callback.Status || callback.Code == events_stk.StkCodeSettled
+}
+
+// failPrompt records the failure reported by Jenga against the prompt the callback answers.
+func (event *JengaStkCallback) failPrompt(ctx context.Context, callback *models.StkCallback) error {
+	resolution := events_stk.ResolveFailure(callback.Message)
+
+	_, err := event.PaymentClient.Client.StatusUpdate(ctx, &commonv1.StatusUpdateRequest{
+		State:  commonv1.STATE_ACTIVE,
+		Status: commonv1.STATUS_FAILED,
+		Extras: map[string]string{
+			"entity_type":     "prompt",
+			"transaction_ref": callback.Transaction,
+			"resolution":      resolution,
+			"resolved_by":     "callback",
+			"code":            strconv.Itoa(callback.Code),
+			"message":         callback.Message,
+		},
+	})
+	if err != nil {
+		event.Service.Log(ctx).WithError(err).
+			WithField("transaction_ref", callback.Transaction).
+			Error("failed to record failed STK callback")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+//nolint:revive // package name matches directory structure
+package events_callback //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/antinvestor/jenga-api/service/utility"
+	"github.com/pitabwire/frame"
+)
+
+type JengaTillsCallback struct {
+	Service       *frame.Service
+	PaymentClient *paymentV1.PaymentClient
+}
+
+func (event *JengaTillsCallback) Name() string {
+	return "jenga.callback.tills.payment"
+}
+
+func (event *JengaTillsCallback) PayloadType() any {
+	return &models.TillsCallback{}
+}
+
+func (event *JengaTillsCallback) Validate(_ context.Context, payload any) error {
+	callback, ok := payload.(*models.TillsCallback)
+	if !ok {
+		return errors.New("invalid payload type")
+	}
+
+	if callback.Transaction.Reference == "" {
+		return errors.New("transaction reference is required")
+	}
+	if callback.Till.Number == "" {
+		return errors.New("till number is required")
+	}
+	return validateAmount(callback.Transaction.Amount, callback.Transaction.Currency)
+}
+
+func (event *JengaTillsCallback) Execute(ctx context.Context, payload any) error {
+	logger := event.Service.Log(ctx)
+
+	if event.PaymentClient == nil {
+		return errors.New("payment client not initialized")
+	}
+
+	callback, ok := payload.(*models.TillsCallback)
+	if !ok {
+		return errors.New("invalid payload type")
+	}
+	logger = logger.WithField("transaction_ref", callback.Transaction.Reference)
+
+	paid, err := isSuccessfulStatus(callback.Transaction.Status)
+	if err != nil {
+		logger.WithError(err).Warn("Till payment callback carries no status")
+		return err
+	}
+	if !paid {
+		logger.WithField("status", callback.Transaction.Status).Info("ignoring unsuccessful till payment")
+		return nil
+	}
+
+	currency := callback.Transaction.Currency
+	amount := utility.ToMoney(currency, callback.Transaction.Amount.Decimal)
+	cost := utility.ToMoney(currency, callback.Transaction.ServiceCharge.RoundTo(currency).Decimal)
+	payment := &paymentV1.Payment{
+		Source: &commonv1.ContactLink{
+			ProfileName: callback.Customer.Name,
+			Detail:      callback.Customer.MobileNumber,
+		},
+		Recipient: &commonv1.ContactLink{
+			Detail: callback.Till.Number,
+		},
+		TransactionId: callback.Transaction.Reference,
+		Amount:        &amount,
+		Cost:          &cost,
+		Extra: map[string]string{
+			"callback_type":   "tills",
+			"transaction_ref": callback.Transaction.Reference,
+			"till_number":     callback.Till.Number,
+			"payment_mode":    callback.Transaction.PaymentMode,
+		},
+	}
+
+	if callbackJSON, err := json.Marshal(callback); err == nil {
+		payment.Extra["additional_info"] = string(callbackJSON)
+	}
+
+	_, err = event.PaymentClient.Client.Receive(ctx, &paymentV1.ReceiveRequest{Data: payment})
+	if err != nil {
+		logger.WithError(err).Error("failed to process till payment callback")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+//nolint:revive // package name matches directory structure
+package events_inbox //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/antinvestor/jenga-api/service/middleware"
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/antinvestor/jenga-api/service/repository"
+	"github.com/pitabwire/frame"
+)
+
+const (
+	defaultMaxAttempts       = 5
+	defaultRetryDelay        = time.Minute
+	defaultProcessingTimeout = 5 * time.Minute
+	maxRetryDelay            = time.Hour
+)
+
+var (
+	// ErrUnknownCallbackKind is returned when no processor is registered for an inbox entry.
+	ErrUnknownCallbackKind = errors.New("no processor registered for callback kind")
+	// ErrCallbackAlreadyProcessed is returned when replaying a callback that was applied,
+	// replaying it would apply it a second time.
+	ErrCallbackAlreadyProcessed = errors.New("callback was already processed")
+	// ErrCallbackNotReplayable is returned when replaying a callback that has not failed
+	// without forcing it, or one that is being processed.
+	ErrCallbackNotReplayable = errors.New("callback has not failed")
+)
+
+// InboxRequest identifies the inbox entry to process.
+type InboxRequest struct {
+	ID string `json:"id"`
+}
+
+// ProcessCallback persists Jenga callbacks on receipt and processes them asynchronously.
+// Each kind of callback is handed to the event registered for it, failures are retried
+// with an increasing delay until MaxAttempts is reached. An entry is leased to the worker
+// processing it for ProcessingTimeout, after which the retrier considers it abandoned.
+type ProcessCallback struct {
+	Service           *frame.Service
+	Processors        map[string]frame.EventI
+	MaxAttempts       int
+	RetryDelay        time.Duration
+	ProcessingTimeout time.Duration
+	// Repository stores the inbox, the service datastore is used when it is nil.
+	Repository repository.CallbackInboxRepository
+}
+
+// Name returns the name of the event handler.
+func (event *ProcessCallback) Name() string {
+	return "jenga.callback.inbox.process"
+}
+
+// PayloadType returns the type of payload this event expects.
+func (event *ProcessCallback) PayloadType() any {
+	return &InboxRequest{}
+}
+
+// Validate validates the payload.
+func (event *ProcessCallback) Validate(_ context.Context, payload any) error {
+	request, ok := payload.(*InboxRequest)
+	if !ok {
+		return errors.New("invalid payload type, expected *InboxRequest")
+	}
+	if request.ID == "" {
+		return errors.New("inbox entry id is required")
+	}
+	return nil
+}
+
+// Accept stores a raw callback and queues it for processing. It reports false without
+// queueing anything when a callback of the same kind and reference was already received.
+func (event *ProcessCallback) Accept(
+	ctx context.Context,
+	kind, reference string,
+	r *http.Request,
+	body []byte,
+) (*models.CallbackInbox, bool, error) {
+	now := time.Now()
+	// Hold off the retrier while the queued event gets its first chance
+	nextAttempt := now.Add(event.retryDelay())
+	entry := &models.CallbackInbox{
+		Kind:          kind,
+		Reference:     reference,
+		Path:          r.URL.Path,
+		Headers:       middleware.RedactHeaders(r.Header),
+		Body:          string(body),
+		ReceivedAt:    now,
+		State:         models.InboxStateReceived,
+		NextAttemptAt: &nextAttempt,
+	}
+
+	created, err := event.repository(ctx).Create(ctx, entry)
+	if err != nil || !created {
+		return entry, created, err
+	}
+
+	if err = event.Service.Emit(ctx, event.Name(), &InboxRequest{ID: entry.GetID()}); err != nil {
+		// The entry is stored, the retrier will pick it up once the delay passes
+		event.Service.Log(ctx).WithError(err).WithField("inboxId", entry.GetID()).
+			Warn("could not queue callback for processing")
+	}
+	return entry, true, nil
+}
+
+// Replay queues a failed inbox entry for processing again. Forcing it also requeues entries
+// that were received but not processed, processed entries are never replayed.
+func (event *ProcessCallback) Replay(ctx context.Context, id string, force bool) (*models.CallbackInbox, error) {
+	inboxRepo := event.repository(ctx)
+	entry, err := inboxRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	states := []string{models.InboxStateFailed}
+	if force {
+		states = append(states, models.InboxStateReceived, models.InboxStateProcessing)
+	}
+	requeued, err := inboxRepo.Requeue(ctx, id, states, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !requeued {
+		if entry.State == models.InboxStateProcessed {
+			return nil, ErrCallbackAlreadyProcessed
+		}
+		return nil, ErrCallbackNotReplayable
+	}
+
+	entry.State = models.InboxStateReceived
+	entry.Attempts = 0
+	entry.LastError = ""
+	entry.NextAttemptAt = nil
+
+	if err = event.Service.Emit(ctx, event.Name(), &InboxRequest{ID: entry.GetID()}); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Execute processes a stored callback.
+func (event *ProcessCallback) Execute(ctx context.Context, payload any) error {
+	request, ok := payload.(*InboxRequest)
+	if !ok {
+		return errors.New("invalid payload type, expected *InboxRequest")
+	}
+
+	logger := event.Service.Log(ctx).WithField("inboxId", request.ID)
+
+	inboxRepo := event.repository(ctx)
+	entry, err := inboxRepo.GetByID(ctx, request.ID)
+	if err != nil {
+		logger.WithError(err).Error("could not load inbox entry")
+		return err
+	}
+
+	if entry.State == models.InboxStateProcessed {
+		logger.Debug("inbox entry already processed")
+		return nil
+	}
+
+	now := time.Now()
+	leaseUntil := now.Add(event.processingTimeout())
+	claimed, err := inboxRepo.Claim(ctx, entry.GetID(), now, leaseUntil)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		// Processed meanwhile or held by another worker whose lease has not expired
+		logger.Debug("inbox entry is not due for processing")
+		return nil
+	}
+	entry.State = models.InboxStateProcessing
+	entry.Attempts++
+	entry.NextAttemptAt = &leaseUntil
+
+	processErr := event.process(ctx, entry)
+
+	now = time.Now()
+	if processErr != nil {
+		entry.State = models.InboxStateFailed
+		entry.LastError = processErr.Error()
+		nextAttempt := now.Add(event.backoff(entry.Attempts))
+		entry.NextAttemptAt = &nextAttempt
+		logger.WithError(processErr).
+			WithField("attempts", entry.Attempts).
+			WithField("kind", entry.Kind).
+			Warn("callback processing failed")
+	} else {
+		entry.State = models.InboxStateProcessed
+		entry.LastError = ""
+		entry.NextAttemptAt = nil
+		entry.ProcessedAt = &now
+	}
+
+	// Failures are retried through the inbox rather than the event queue
+	return inboxRepo.Save(ctx, entry)
+}
+
+func (event *ProcessCallback) process(ctx context.Context, entry *models.CallbackInbox) error {
+	processor, ok := event.Processors[entry.Kind]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownCallbackKind, entry.Kind)
+	}
+
+	payload := processor.PayloadType()
+	if err := json.Unmarshal([]byte(entry.Body), payload); err != nil {
+		return fmt.Errorf("failed to unmarshal callback: %w", err)
+	}
+	if err := processor.Validate(ctx, payload); err != nil {
+		return fmt.Errorf("callback validation failed: %w", err)
+	}
+	return processor.Execute(ctx, payload)
+}
+
+func (event *ProcessCallback) repository(ctx context.Context) repository.CallbackInboxRepository {
+	if event.Repository != nil {
+		return event.Repository
+	}
+	return repository.NewCallbackInboxRepository(ctx, event.Service)
+}
+
+func (event *ProcessCallback) maxAttempts() int {
+	if event.MaxAttempts > 0 {
+		return event.MaxAttempts
+	}
+	return defaultMaxAttempts
+}
+
+func (event *ProcessCallback) retryDelay() time.Duration {
+	if event.RetryDelay > 0 {
+		return event.RetryDelay
+	}
+	return defaultRetryDelay
+}
+
+func (event *ProcessCallback) processingTimeout() time.Duration {
+	if event.ProcessingTimeout > 0 {
+		return event.ProcessingTimeout
+	}
+	return defaultProcessingTimeout
+}
+
+// backoff doubles the retry delay with each attempt.
+func (event *ProcessCallback) backoff(attempts int) time.Duration {
+	delay := event.retryDelay()
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
@@ -0,0 +1,273 @@
+//nolint:revive // package name matches directory structure
+package events_inbox //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/antinvestor/jenga-api/config"
+	"github.com/antinvestor/jenga-api/service/middleware"
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/antinvestor/jenga-api/service/repository/repositorytest"
+	"github.com/pitabwire/frame"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const testKind = "test"
+
+type testCallback struct {
+	Reference string `json:"reference"`
+}
+
+// recordingProcessor stands in for a callback event, recording each reference it applies
+// and failing the ones it is told to.
+type recordingProcessor struct {
+	mu      sync.Mutex
+	applied []string
+	failing map[string]bool
+}
+
+func (p *recordingProcessor) Name() string { return "test.callback" }
+
+func (p *recordingProcessor) PayloadType() any { return &testCallback{} }
+
+func (p *recordingProcessor) Validate(_ context.Context, _ any) error { return nil }
+
+func (p *recordingProcessor) Execute(_ context.Context, payload any) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	reference := payload.(*testCallback).Reference
+	if p.failing[reference] {
+		return errors.New("processing failed")
+	}
+	p.applied = append(p.applied, reference)
+	return nil
+}
+
+func (p *recordingProcessor) times(reference string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	count := 0
+	for _, applied := range p.applied {
+		if applied == reference {
+			count++
+		}
+	}
+	return count
+}
+
+// newInbox returns a running inbox kept in memory, so entries it queues are processed.
+func newInbox(t *testing.T, processor *recordingProcessor) (context.Context, *ProcessCallback) {
+	t.Helper()
+	cfg, err := frame.ConfigFromEnv[config.JengaConfig]()
+	require.NoError(t, err)
+
+	ctx, service := frame.NewService(t.Name(), frame.WithConfig(&cfg), frame.WithNoopDriver())
+	t.Cleanup(func() { service.Stop(ctx) })
+
+	inbox := &ProcessCallback{
+		Service:    service,
+		Processors: map[string]frame.EventI{testKind: processor},
+		Repository: repositorytest.NewCallbackInbox(),
+		RetryDelay: time.Minute,
+	}
+	service.Init(ctx, frame.WithRegisterEvents(inbox))
+	require.NoError(t, service.Run(ctx, ""))
+	return ctx, inbox
+}
+
+// store saves an inbox entry for a test callback with the given reference.
+func store(t *testing.T, ctx context.Context, inbox *ProcessCallback, entry *models.CallbackInbox) *models.CallbackInbox {
+	t.Helper()
+	entry.Kind = testKind
+	entry.Body = `{"reference":"` + entry.Reference + `"}`
+	entry.ReceivedAt = time.Now()
+	entry.GenID(ctx)
+	require.NoError(t, inbox.Repository.Save(ctx, entry))
+	return entry
+}
+
+func stateOf(t *testing.T, ctx context.Context, inbox *ProcessCallback, id string) string {
+	t.Helper()
+	entry, err := inbox.Repository.GetByID(ctx, id)
+	require.NoError(t, err)
+	return entry.State
+}
+
+func at(offset time.Duration) *time.Time {
+	moment := time.Now().Add(offset)
+	return &moment
+}
+
+func TestAccept(t *testing.T) {
+	processor := &recordingProcessor{}
+	ctx, inbox := newInbox(t, processor)
+
+	r := httptest.NewRequest(http.MethodPost, "/callbacks/test", nil)
+	r.SetBasicAuth("jenga", "callback-password")
+	r.Header.Set(middleware.SharedSecretHeader, "shared-secret")
+	r.Header.Set("X-Api-Token", "api-token")
+	r.Header.Set("Content-Type", "application/json")
+	body := []byte(`{"reference":"REF1"}`)
+
+	entry, created, err := inbox.Accept(ctx, testKind, "REF1", r, body)
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "application/json", entry.Headers["Content-Type"])
+	for _, header := range []string{"Authorization", middleware.SharedSecretHeader, "X-Api-Token"} {
+		assert.NotContains(t, entry.Headers, header)
+	}
+
+	_, created, err = inbox.Accept(ctx, testKind, "REF1", r, body)
+	require.NoError(t, err)
+	assert.False(t, created, "a redelivered callback is stored again")
+
+	require.Eventually(t, func() bool {
+		return stateOf(t, ctx, inbox, entry.GetID()) == models.InboxStateProcessed
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, processor.times("REF1"))
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name         string
+		entry        models.CallbackInbox
+		wantState    string
+		wantAttempts int
+		wantApplied  int
+	}{
+		{name: "received",
+			entry:     models.CallbackInbox{Reference: "received", State: models.InboxStateReceived},
+			wantState: models.InboxStateProcessed, wantAttempts: 1, wantApplied: 1},
+		{name: "failure is scheduled for retry",
+			entry:     models.CallbackInbox{Reference: "failing", State: models.InboxStateReceived},
+			wantState: models.InboxStateFailed, wantAttempts: 1},
+		{name: "processed is not applied again",
+			entry:     models.CallbackInbox{Reference: "processed", State: models.InboxStateProcessed, Attempts: 1},
+			wantState: models.InboxStateProcessed, wantAttempts: 1},
+		{name: "leased to another worker",
+			entry: models.CallbackInbox{Reference: "leased", State: models.InboxStateProcessing, Attempts: 1,
+				NextAttemptAt: at(time.Minute)},
+			wantState: models.InboxStateProcessing, wantAttempts: 1},
+		{name: "abandoned lease",
+			entry: models.CallbackInbox{Reference: "abandoned", State: models.InboxStateProcessing, Attempts: 1,
+				NextAttemptAt: at(-time.Minute)},
+			wantState: models.InboxStateProcessed, wantAttempts: 2, wantApplied: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := &recordingProcessor{failing: map[string]bool{"failing": true}}
+			ctx, inbox := newInbox(t, processor)
+			entry := store(t, ctx, inbox, &tt.entry)
+
+			require.NoError(t, inbox.Execute(ctx, &InboxRequest{ID: entry.GetID()}))
+
+			saved, err := inbox.Repository.GetByID(ctx, entry.GetID())
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantState, saved.State)
+			assert.Equal(t, tt.wantAttempts, saved.Attempts)
+			assert.Equal(t, tt.wantApplied, processor.times(tt.entry.Reference))
+			if tt.wantState == models.InboxStateFailed {
+				assert.Equal(t, "processing failed", saved.LastError)
+				require.NotNil(t, saved.NextAttemptAt)
+				assert.True(t, saved.NextAttemptAt.After(time.Now()))
+			}
+		})
+	}
+}
+
+func TestReplay(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   models.CallbackInbox
+		force   bool
+		wantErr error
+	}{
+		{name: "failed", entry: models.CallbackInbox{State: models.InboxStateFailed, Attempts: 5}},
+		{name: "processed", entry: models.CallbackInbox{State: models.InboxStateProcessed},
+			wantErr: ErrCallbackAlreadyProcessed},
+		{name: "processed is not forced", entry: models.CallbackInbox{State: models.InboxStateProcessed},
+			force: true, wantErr: ErrCallbackAlreadyProcessed},
+		{name: "received needs force", entry: models.CallbackInbox{State: models.InboxStateReceived},
+			wantErr: ErrCallbackNotReplayable},
+		{name: "received forced", entry: models.CallbackInbox{State: models.InboxStateReceived}, force: true},
+		{name: "leased is not forced",
+			entry: models.CallbackInbox{State: models.InboxStateProcessing, NextAttemptAt: at(time.Minute)},
+			force: true, wantErr: ErrCallbackNotReplayable},
+		{name: "abandoned lease forced",
+			entry: models.CallbackInbox{State: models.InboxStateProcessing, NextAttemptAt: at(-time.Minute)},
+			force: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := &recordingProcessor{}
+			ctx, inbox := newInbox(t, processor)
+			tt.entry.Reference = strings.ReplaceAll(tt.name, " ", "-")
+			entry := store(t, ctx, inbox, &tt.entry)
+
+			_, err := inbox.Replay(ctx, entry.GetID(), tt.force)
+			require.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.entry.State, stateOf(t, ctx, inbox, entry.GetID()))
+				assert.Zero(t, processor.times(tt.entry.Reference))
+				return
+			}
+
+			require.Eventually(t, func() bool {
+				return stateOf(t, ctx, inbox, entry.GetID()) == models.InboxStateProcessed
+			}, 5*time.Second, 10*time.Millisecond)
+			assert.Equal(t, 1, processor.times(tt.entry.Reference))
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		ctx, inbox := newInbox(t, &recordingProcessor{})
+		_, err := inbox.Replay(ctx, "unknown", true)
+		require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestRetryDue(t *testing.T) {
+	processor := &recordingProcessor{}
+	ctx, inbox := newInbox(t, processor)
+
+	due := []*models.CallbackInbox{
+		store(t, ctx, inbox, &models.CallbackInbox{Reference: "never-picked-up",
+			State: models.InboxStateReceived, NextAttemptAt: at(-time.Second)}),
+		store(t, ctx, inbox, &models.CallbackInbox{Reference: "failed",
+			State: models.InboxStateFailed, Attempts: 1, NextAttemptAt: at(-time.Second)}),
+		store(t, ctx, inbox, &models.CallbackInbox{Reference: "abandoned",
+			State: models.InboxStateProcessing, Attempts: 1, NextAttemptAt: at(-time.Second)}),
+	}
+	notDue := []*models.CallbackInbox{
+		store(t, ctx, inbox, &models.CallbackInbox{Reference: "backing-off",
+			State: models.InboxStateFailed, Attempts: 1, NextAttemptAt: at(time.Minute)}),
+		store(t, ctx, inbox, &models.CallbackInbox{Reference: "exhausted",
+			State: models.InboxStateFailed, Attempts: defaultMaxAttempts, NextAttemptAt: at(-time.Second)}),
+		store(t, ctx, inbox, &models.CallbackInbox{Reference: "leased",
+			State: models.InboxStateProcessing, Attempts: 1, NextAttemptAt: at(time.Minute)}),
+		store(t, ctx, inbox, &models.CallbackInbox{Reference: "processed",
+			State: models.InboxStateProcessed, Attempts: 1}),
+	}
+
+	inbox.retryDue(ctx)
+
+	for _, entry := range due {
+		require.Eventually(t, func() bool {
+			return stateOf(t, ctx, inbox, entry.GetID()) == models.InboxStateProcessed
+		}, 5*time.Second, 10*time.Millisecond, entry.Reference)
+		assert.Equal(t, 1, processor.times(entry.Reference), entry.Reference)
+	}
+	for _, entry := range notDue {
+		assert.Equal(t, entry.State, stateOf(t, ctx, inbox, entry.GetID()), entry.Reference)
+		assert.Zero(t, processor.times(entry.Reference), entry.Reference)
+	}
+}
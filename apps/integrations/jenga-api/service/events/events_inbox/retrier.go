@@ -0,0 +1,54 @@
+//nolint:revive // package name matches directory structure
+package events_inbox //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"time"
+)
+
+const retryBatchSize = 100
+
+// RunRetries periodically re-queues inbox entries that failed, were never picked up or were
+// abandoned mid processing.
+// It blocks until the context is cancelled and is meant to run as a background consumer.
+func (event *ProcessCallback) RunRetries(ctx context.Context) error {
+	ticker := time.NewTicker(event.retryDelay())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			event.retryDue(ctx)
+		}
+	}
+}
+
+func (event *ProcessCallback) retryDue(ctx context.Context) {
+	logger := event.Service.Log(ctx).WithField("type", "inbox.retrier")
+
+	inboxRepo := event.repository(ctx)
+	now := time.Now()
+	entries, err := inboxRepo.ListDue(ctx, now, event.maxAttempts(), retryBatchSize)
+	if err != nil {
+		logger.WithError(err).Warn("could not list inbox entries due for retry")
+		return
+	}
+
+	for _, entry := range entries {
+		// Push the next attempt out so a slow queue does not get the same entry twice
+		rescheduled, rescheduleErr := inboxRepo.Reschedule(ctx, entry.GetID(), now, now.Add(event.retryDelay()))
+		if rescheduleErr != nil {
+			logger.WithError(rescheduleErr).WithField("inboxId", entry.GetID()).Warn("could not reschedule inbox entry")
+			continue
+		}
+		if !rescheduled {
+			continue
+		}
+
+		if err = event.Service.Emit(ctx, event.Name(), &InboxRequest{ID: entry.GetID()}); err != nil {
+			logger.WithError(err).WithField("inboxId", entry.GetID()).Warn("could not queue inbox retry")
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	commonv1 "github.com/antinvestor/apis/go/common/v1"
 	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
@@ -17,7 +19,8 @@ import (
 
 const (
 	dateFormat            = "2006-01-02"
-	updateTypePaymentLink = "payment_link"
+	amountOptionOpen      = "OPEN"
+	entityTypePaymentLink = "payment_link"
 	statusActive          = commonv1.STATE_ACTIVE
 	statusFailed          = commonv1.STATUS_FAILED
 	statusSuccessful      = commonv1.STATUS_SUCCESSFUL
@@ -42,6 +45,8 @@ type CreatePaymentLink struct {
 	Service       *frame.Service
 	Client        coreapi.JengaApiClient
 	PaymentClient paymentV1.PaymentClient
+	// LinkBaseURL builds the customer facing URL when Jenga does not return one
+	LinkBaseURL string
 }
 
 func NewCreatePaymentLink(service *frame.Service, client coreapi.JengaApiClient,
@@ -70,7 +75,7 @@ func (h *CreatePaymentLink) Validate(ctx context.Context, payload any) error {
 	switch {
 	case paymentLink.Name == "":
 		return errors.New("payment link name is required")
-	case paymentLink.Amount.IsZero():
+	case paymentLink.Amount.IsZero() && !strings.EqualFold(paymentLink.AmountOption, amountOptionOpen):
 		return errors.New("payment link amount is required")
 	case paymentLink.ExpiryDate.IsZero():
 		return errors.New("expiry date is required")
@@ -132,7 +137,7 @@ func (h *CreatePaymentLink) Execute(ctx context.Context, payload any) error {
 
 	h.logResponse(response)
 
-	if err := h.updateStatusSuccess(ctx, paymentLink.ID); err != nil {
+	if err := h.updateStatusSuccess(ctx, paymentLink.ID, response.Data); err != nil {
 		logger.WithError(err).Error("failed to update payment link status")
 		return fmt.Errorf("update payment status: %w", err)
 	}
@@ -152,6 +157,11 @@ func (h *CreatePaymentLink) prepareRequest(paymentLink *models.PaymentLink) (mod
 		}
 	}
 
+	amount := models.NewAmount(paymentLink.Amount)
+	if _, err := amount.Exact(paymentLink.Currency); err != nil {
+		return models.PaymentLinkRequest{}, fmt.Errorf("payment link amount: %w", err)
+	}
+
 	return models.PaymentLinkRequest{
 		Customers: customers,
 		PaymentLink: models.PaymentLinkDetails{
@@ -165,7 +175,7 @@ func (h *CreatePaymentLink) prepareRequest(paymentLink *models.PaymentLink) (mod
 			PaymentLinkRef:  paymentLink.PaymentLinkRef,
 			RedirectURL:     paymentLink.RedirectURL,
 			AmountOption:    paymentLink.AmountOption,
-			Amount:          paymentLink.Amount.InexactFloat64(),
+			Amount:          amount,
 			Currency:        paymentLink.Currency,
 		},
 		Notifications: notifications,
@@ -197,7 +207,7 @@ func (h *CreatePaymentLink) handleError(ctx context.Context, id string, err erro
 		State:  statusActive,
 		Status: statusFailed,
 		Extras: map[string]string{
-			"update_type": updateTypePaymentLink,
+			"entity_type": entityTypePaymentLink,
 			"error":       err.Error(),
 		},
 	}
@@ -209,15 +219,31 @@ func (h *CreatePaymentLink) handleError(ctx context.Context, id string, err erro
 	return err
 }
 
-func (h *CreatePaymentLink) updateStatusSuccess(ctx context.Context, id string) error {
+func (h *CreatePaymentLink) updateStatusSuccess(
+	ctx context.Context,
+	id string,
+	data *models.PaymentLinkResponseData,
+) error {
+	extras := map[string]string{
+		"entity_type": entityTypePaymentLink,
+		"message":     "Payment link successfully generated",
+	}
+	// The payment service stores the Jenga reference and URL on the link
+	if data != nil {
+		extras["payment_link_ref"] = data.PaymentLinkRef
+		extras["payment_link_url"] = h.linkURL(data)
+		extras["provider_status"] = data.Status.Name
+		extras["provider_status_code"] = data.Status.Code
+		if data.DateCreated > 0 {
+			extras["date_created"] = time.UnixMilli(data.DateCreated).UTC().Format(time.RFC3339)
+		}
+	}
+
 	statusUpdateRequest := &commonv1.StatusUpdateRequest{
 		Id:     id,
 		State:  statusActive,
 		Status: statusSuccessful,
-		Extras: map[string]string{
-			"update_type": updateTypePaymentLink,
-			"message":     "Payment link successfully generated",
-		},
+		Extras: extras,
 	}
 
 	_, err := h.PaymentClient.StatusUpdate(ctx, statusUpdateRequest)
@@ -226,3 +252,14 @@ func (h *CreatePaymentLink) updateStatusSuccess(ctx context.Context, id string)
 	}
 	return nil
 }
+
+// linkURL returns the URL customers pay the link on.
+func (h *CreatePaymentLink) linkURL(data *models.PaymentLinkResponseData) string {
+	if data.URL != "" {
+		return data.URL
+	}
+	if h.LinkBaseURL == "" || data.PaymentLinkRef == "" {
+		return ""
+	}
+	return strings.TrimSuffix(h.LinkBaseURL, "/") + "/" + data.PaymentLinkRef
+}
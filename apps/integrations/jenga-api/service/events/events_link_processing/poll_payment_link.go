@@ -0,0 +1,98 @@
+//nolint:revive // package name matches directory structure
+package events_link_processing //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/antinvestor/jenga-api/service/coreapi"
+	models "github.com/antinvestor/jenga-api/service/models"
+	"github.com/pitabwire/frame"
+)
+
+// PollPaymentLink fetches the payments made against a link and passes them through the
+// payment link callback, picking up payments whose callbacks never arrived. Payments that
+// were already received are recognised by the payment service from their transaction id.
+type PollPaymentLink struct {
+	Service  *frame.Service
+	Client   coreapi.JengaApiClient
+	Callback frame.EventI
+}
+
+func (h *PollPaymentLink) Name() string {
+	return "payment.link.poll"
+}
+
+func (h *PollPaymentLink) PayloadType() any {
+	return &models.PaymentLink{}
+}
+
+func (h *PollPaymentLink) Validate(_ context.Context, payload any) error {
+	paymentLink, ok := payload.(*models.PaymentLink)
+	if !ok {
+		return errors.New("invalid payload type, expected *models.PaymentLink")
+	}
+	if paymentLink.PaymentLinkRef == "" {
+		return errors.New("payment link reference is required")
+	}
+	return nil
+}
+
+func (h *PollPaymentLink) Handle(ctx context.Context, _ map[string]string, message []byte) error {
+	payload := h.PayloadType()
+	if err := json.Unmarshal(message, payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	if err := h.Validate(ctx, payload); err != nil {
+		return fmt.Errorf("payload validation failed: %w", err)
+	}
+	return h.Execute(ctx, payload)
+}
+
+func (h *PollPaymentLink) Execute(ctx context.Context, payload any) error {
+	paymentLink, ok := payload.(*models.PaymentLink)
+	if !ok {
+		return errors.New("invalid payload type, expected *models.PaymentLink")
+	}
+	logger := h.Service.Log(ctx).WithField("paymentLinkRef", paymentLink.PaymentLinkRef)
+
+	token, err := h.Client.GenerateBearerToken()
+	if err != nil {
+		logger.WithError(err).Error("failed to generate bearer token")
+		return fmt.Errorf("generate bearer token: %w", err)
+	}
+
+	response, err := h.Client.PaymentLinkPayments(paymentLink.PaymentLinkRef, token.AccessToken)
+	if err != nil {
+		logger.WithError(err).Warn("failed to fetch payment link payments")
+		return fmt.Errorf("fetch payment link payments: %w", err)
+	}
+
+	var failed int
+	for i := range response.Data {
+		payment := &response.Data[i]
+		if payment.PaymentLinkRef == "" {
+			payment.PaymentLinkRef = paymentLink.PaymentLinkRef
+		}
+		if payment.ExternalRef == "" {
+			payment.ExternalRef = paymentLink.ExternalRef
+		}
+
+		if err = h.Callback.Validate(ctx, payment); err == nil {
+			err = h.Callback.Execute(ctx, payment)
+		}
+		if err != nil {
+			failed++
+			logger.WithError(err).WithField("transactionRef", payment.Transaction.Reference).
+				Warn("could not process polled payment link payment")
+		}
+	}
+
+	logger.WithField("payments", len(response.Data)).WithField("failed", failed).Debug("polled payment link")
+	if failed > 0 {
+		return fmt.Errorf("%d of %d payment link payments could not be processed", failed, len(response.Data))
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+//nolint:revive // package name matches directory structure
+package events_link_processing //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/jenga-api/service/coreapi"
+	models "github.com/antinvestor/jenga-api/service/models"
+	"github.com/pitabwire/frame"
+)
+
+const paymentLinkStatusInactive = "INACTIVE"
+
+// UpdatePaymentLink keeps Jenga in step with links that are changed or deactivated after
+// they were created.
+type UpdatePaymentLink struct {
+	Service       *frame.Service
+	Client        coreapi.JengaApiClient
+	PaymentClient paymentV1.PaymentClient
+}
+
+func (h *UpdatePaymentLink) Name() string {
+	return "update.payment.link"
+}
+
+func (h *UpdatePaymentLink) PayloadType() any {
+	return &models.PaymentLinkChange{}
+}
+
+func (h *UpdatePaymentLink) Validate(_ context.Context, payload any) error {
+	change, ok := payload.(*models.PaymentLinkChange)
+	if !ok {
+		return errors.New("invalid payload type, expected *models.PaymentLinkChange")
+	}
+
+	switch {
+	case change.PaymentLink == nil:
+		return errors.New("payment link is required")
+	case change.PaymentLink.ID == "":
+		return errors.New("payment link ID is required")
+	case change.Action != models.PaymentLinkActionUpdate && change.Action != models.PaymentLinkActionDeactivate:
+		return fmt.Errorf("unknown payment link action %q", change.Action)
+	}
+	return nil
+}
+
+func (h *UpdatePaymentLink) Handle(ctx context.Context, _ map[string]string, message []byte) error {
+	payload := h.PayloadType()
+	if err := json.Unmarshal(message, payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	if err := h.Validate(ctx, payload); err != nil {
+		return fmt.Errorf("payload validation failed: %w", err)
+	}
+	return h.Execute(ctx, payload)
+}
+
+func (h *UpdatePaymentLink) Execute(ctx context.Context, payload any) error {
+	change, ok := payload.(*models.PaymentLinkChange)
+	if !ok {
+		return errors.New("invalid payload type, expected *models.PaymentLinkChange")
+	}
+	paymentLink := change.PaymentLink
+	logger := h.Service.Log(ctx).WithField("paymentLinkId", paymentLink.ID).WithField("action", change.Action)
+
+	if paymentLink.PaymentLinkRef == "" {
+		// Jenga never acknowledged the link, there is nothing to change on its side
+		logger.Info("payment link has no Jenga reference, skipping provider update")
+		return nil
+	}
+
+	request := models.PaymentLinkUpdateRequest{
+		PaymentLinkRef: paymentLink.PaymentLinkRef,
+		ExpiryDate:     paymentLink.ExpiryDate.Format(dateFormat),
+		Name:           paymentLink.Name,
+		Description:    paymentLink.Description,
+		RedirectURL:    paymentLink.RedirectURL,
+	}
+	switch {
+	case change.Action == models.PaymentLinkActionDeactivate:
+		request.Status = paymentLinkStatusInactive
+	case !paymentLink.Amount.IsZero():
+		amount := models.NewAmount(paymentLink.Amount)
+		if _, err := amount.Exact(paymentLink.Currency); err != nil {
+			logger.WithError(err).Error("payment link amount cannot be sent to Jenga")
+			return h.reportFailure(ctx, paymentLink.ID, change.Action, err.Error())
+		}
+		request.Amount = &amount
+	}
+
+	token, err := h.Client.GenerateBearerToken()
+	if err != nil {
+		logger.WithError(err).Error("failed to generate bearer token")
+		return fmt.Errorf("generate bearer token: %w", err)
+	}
+
+	response, err := h.Client.UpdatePaymentLink(request, token.AccessToken)
+	if err != nil || !response.Status {
+		errorMsg := h.getErrorResponse(err, response)
+		logger.WithField("error", errorMsg).Error("failed to update payment link")
+		return h.reportFailure(ctx, paymentLink.ID, change.Action, errorMsg)
+	}
+
+	logger.Info("payment link updated at Jenga")
+	return nil
+}
+
+func (h *UpdatePaymentLink) getErrorResponse(err error, response *models.PaymentLinkResponse) string {
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("API call failed with status: %v, message: %s", response.Status, response.Message)
+}
+
+// reportFailure records the failed provider update against the link without changing its
+// state, the change is already in effect on our side.
+func (h *UpdatePaymentLink) reportFailure(ctx context.Context, id, action, errorMsg string) error {
+	statusUpdateRequest := &commonv1.StatusUpdateRequest{
+		Id:     id,
+		State:  statusActive,
+		Status: statusFailed,
+		Extras: map[string]string{
+			"entity_type": entityTypePaymentLink,
+			"action":      action,
+			"error":       errorMsg,
+		},
+	}
+	if action == models.PaymentLinkActionDeactivate {
+		statusUpdateRequest.State = commonv1.STATE_INACTIVE
+	}
+
+	if _, err := h.PaymentClient.StatusUpdate(ctx, statusUpdateRequest); err != nil {
+		return fmt.Errorf("payment client status update: %w", err)
+	}
+	return fmt.Errorf("update payment link: %s", errorMsg)
+}
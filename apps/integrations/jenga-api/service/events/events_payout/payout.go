@@ -0,0 +1,263 @@
+//nolint:revive // package name matches directory structure
+package events_payout //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/jenga-api/service/coreapi"
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/antinvestor/jenga-api/service/paymentjob"
+	"github.com/antinvestor/jenga-api/service/repository"
+	"github.com/pitabwire/frame"
+)
+
+const (
+	paymentTypePayout = "payout"
+
+	defaultCountryCode = "KE"
+	defaultWalletName  = "Mpesa"
+)
+
+var (
+	// ErrPayoutUnpayable is returned for a payout that lacks what Jenga needs to pay it.
+	ErrPayoutUnpayable = errors.New("payout cannot be paid through Jenga")
+	// ErrPayoutDeclined is returned when Jenga answers a payout without accepting it.
+	ErrPayoutDeclined = errors.New("payout declined")
+)
+
+// Payer pays the released payments of a payment type Jenga pays other than by sending money to
+// a mobile wallet, such as till payments.
+type Payer interface {
+	PayReleased(ctx context.Context, payout *models.Payout) error
+}
+
+// JengaPayout pays out the payments the payment service released and queued out to Jenga,
+// sending the money from the merchant account to the recipient's mobile wallet. Progress is
+// recorded before Jenga is asked to pay, so a redelivered payout is queried instead of paid
+// again.
+type JengaPayout struct {
+	Service       *frame.Service
+	Client        coreapi.JengaApiClient
+	PaymentClient *paymentV1.PaymentClient
+	// Payers pay the payments registered by this integration, keyed by their payment_type extra.
+	Payers map[string]Payer
+	// SourceAccount is the merchant account payouts are paid from, payouts fail without it.
+	SourceAccount string
+	SourceName    string
+	CountryCode   string
+	WalletName    string
+	// StatusQueryDelay is how long an unanswered payout is left before Jenga is queried for it.
+	StatusQueryDelay time.Duration
+	// Jobs stores the progress of each payout, the service datastore is used when it is nil.
+	Jobs repository.PaymentJobRepository
+}
+
+// Name returns the name of the event handler.
+func (event *JengaPayout) Name() string {
+	return "jenga.payout"
+}
+
+// PayloadType returns the type of payload this event expects.
+func (event *JengaPayout) PayloadType() any {
+	return &models.Payout{}
+}
+
+// Validate validates the payload, payouts that cannot be paid are failed when executed so the
+// message is not redelivered.
+func (event *JengaPayout) Validate(_ context.Context, payload any) error {
+	payout, ok := payload.(*models.Payout)
+	if !ok {
+		return errors.New("invalid payload type, expected *models.Payout")
+	}
+
+	if payout.ID == "" {
+		return errors.New("payment ID is required")
+	}
+	return nil
+}
+
+// Handle implements the frame.SubscribeWorker interface.
+func (event *JengaPayout) Handle(ctx context.Context, _ map[string]string, message []byte) error {
+	payload := event.PayloadType()
+	if err := json.Unmarshal(message, payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	if err := event.Validate(ctx, payload); err != nil {
+		return fmt.Errorf("payload validation failed: %w", err)
+	}
+
+	return event.Execute(ctx, payload)
+}
+
+// Execute pays the payout through Jenga and reports the outcome. A payout that was seen before
+// carries on from where it got to.
+func (event *JengaPayout) Execute(ctx context.Context, payload any) error {
+	payout, ok := payload.(*models.Payout)
+	if !ok {
+		return errors.New("invalid payload type, expected *models.Payout")
+	}
+	paymentType, _ := payout.Extra["payment_type"].(string)
+	if payer, found := event.Payers[paymentType]; found {
+		return payer.PayReleased(ctx, payout)
+	}
+	if event.Client == nil {
+		return errors.New("jenga client not initialized")
+	}
+	if event.PaymentClient == nil {
+		return errors.New("payment client not initialized")
+	}
+
+	logger := event.Service.Log(ctx).WithField("type", event.Name()).WithField("paymentId", payout.ID)
+	logger.Info("processing payout")
+
+	tracker := event.tracker()
+	progress, err := tracker.Start(ctx, payout.ID, payout.ID)
+	if err != nil {
+		logger.WithError(err).Error("failed to record payout job")
+		return err
+	}
+
+	switch {
+	case progress.Settled():
+		logger.WithField("state", progress.State).Info("payout was already settled")
+		return nil
+	case progress.State == models.PaymentJobStateSubmitted:
+		// Jenga may have paid already, only its answer is awaited
+		return tracker.Resolve(ctx, progress)
+	case progress.State == models.PaymentJobStateNew:
+		if err = tracker.Registered(ctx, progress, payout.ID); err != nil {
+			return err
+		}
+	}
+
+	request, err := event.sendMoneyRequest(payout)
+	if err != nil {
+		logger.WithError(err).Warn("payout cannot be paid")
+		return tracker.Fail(ctx, progress, err)
+	}
+
+	token, err := event.Client.GenerateBearerToken()
+	if err != nil {
+		logger.WithError(err).Error("failed to generate bearer token")
+		return tracker.Fail(ctx, progress, fmt.Errorf("generate bearer token: %w", err))
+	}
+
+	if err = tracker.Submit(ctx, progress); err != nil {
+		logger.WithError(err).Error("failed to record payout submission")
+		return err
+	}
+
+	response, err := event.Client.SendMoney(*request, token.AccessToken)
+	if err != nil {
+		// Jenga may have accepted the payout, it is queried and never sent again
+		tracker.Unconfirmed(ctx, progress, err)
+		return nil
+	}
+	if !response.Status {
+		logger.WithField("response", response).Warn("payout was declined")
+		return tracker.Fail(ctx, progress, fmt.Errorf("%w: %s", ErrPayoutDeclined, response.Message))
+	}
+
+	logger.WithField("response", response).Info("payout response received")
+
+	if err = tracker.Paid(ctx, progress, response.Data.TransactionID, map[string]string{
+		"message":        response.Message,
+		"transaction_id": response.Data.TransactionID,
+		"jenga_status":   response.Data.Status,
+	}); err != nil {
+		logger.WithError(err).Error("failed to report payout outcome")
+	}
+	return nil
+}
+
+// RunStatusQueries periodically queries Jenga for payouts it gave no definite answer for. It
+// blocks until the context is cancelled and is meant to run as a background consumer.
+func (event *JengaPayout) RunStatusQueries(ctx context.Context) error {
+	return event.tracker().RunStatusQueries(ctx)
+}
+
+// sendMoneyRequest builds the transfer paying the payout to the recipient's mobile wallet.
+func (event *JengaPayout) sendMoneyRequest(payout *models.Payout) (*models.SendMoneyRequest, error) {
+	if event.SourceAccount == "" {
+		return nil, fmt.Errorf("%w: no source account is configured", ErrPayoutUnpayable)
+	}
+	msisdn := RecipientMSISDN(payout)
+	if msisdn == "" {
+		return nil, fmt.Errorf("%w: the recipient has no mobile number", ErrPayoutUnpayable)
+	}
+	if !payout.Amount.Valid {
+		return nil, fmt.Errorf("%w: the amount is missing", ErrPayoutUnpayable)
+	}
+	amount, err := models.NewAmount(payout.Amount.Decimal).Exact(payout.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPayoutUnpayable, err)
+	}
+
+	countryCode := event.CountryCode
+	if countryCode == "" {
+		countryCode = defaultCountryCode
+	}
+	walletName := event.WalletName
+	if walletName == "" {
+		walletName = defaultWalletName
+	}
+	description := payout.ReferenceID
+	if description == "" {
+		description = "Payout " + payout.ID
+	}
+
+	return &models.SendMoneyRequest{
+		Source: models.SendMoneySource{
+			CountryCode:   countryCode,
+			Name:          event.SourceName,
+			AccountNumber: event.SourceAccount,
+		},
+		Destination: models.SendMoneyDestination{
+			Type:         "mobile",
+			CountryCode:  countryCode,
+			Name:         payout.RecipientName,
+			MobileNumber: msisdn,
+			WalletName:   walletName,
+		},
+		Transfer: models.SendMoneyTransfer{
+			Type:         "MobileWallet",
+			Amount:       amount,
+			CurrencyCode: strings.ToUpper(payout.Currency),
+			Reference:    payout.ID,
+			Date:         time.Now().Format(time.DateOnly),
+			Description:  description,
+		},
+	}, nil
+}
+
+// RecipientMSISDN returns the mobile number a payout is paid to, the number captured on the
+// payment is preferred over the extras older payments carry it in.
+func RecipientMSISDN(payout *models.Payout) string {
+	if payout.RecipientMSISDN != "" {
+		return payout.RecipientMSISDN
+	}
+	for _, key := range []string{"beneficiary_msisdn", "mobile_number"} {
+		if value, ok := payout.Extra[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func (event *JengaPayout) tracker() *paymentjob.Tracker {
+	return &paymentjob.Tracker{
+		Service:          event.Service,
+		Client:           event.Client,
+		PaymentClient:    event.PaymentClient,
+		Kind:             paymentTypePayout,
+		StatusQueryDelay: event.StatusQueryDelay,
+		Jobs:             event.Jobs,
+	}
+}
@@ -0,0 +1,122 @@
+//nolint:revive // package name matches directory structure
+package events_payout //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/jenga-api/service/coreapi"
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/antinvestor/jenga-api/service/repository/repositorytest"
+	"github.com/pitabwire/frame"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"gorm.io/datatypes"
+)
+
+// paymentService records the statuses reported to it.
+type paymentService struct {
+	paymentV1.PaymentServiceClient
+
+	mu       sync.Mutex
+	statuses []*commonv1.StatusUpdateRequest
+}
+
+func (p *paymentService) StatusUpdate(
+	_ context.Context,
+	in *commonv1.StatusUpdateRequest,
+	_ ...grpc.CallOption,
+) (*commonv1.StatusUpdateResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.statuses = append(p.statuses, in)
+	return &commonv1.StatusUpdateResponse{}, nil
+}
+
+func (p *paymentService) lastStatus(t *testing.T) *commonv1.StatusUpdateRequest {
+	t.Helper()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	require.NotEmpty(t, p.statuses)
+	return p.statuses[len(p.statuses)-1]
+}
+
+func newPayout(t *testing.T) (*JengaPayout, *coreapi.MockClient, *paymentService) {
+	t.Helper()
+	ctx, service := frame.NewService("jenga_payout_test")
+	t.Cleanup(func() { service.Stop(ctx) })
+
+	client := new(coreapi.MockClient)
+	client.On("GenerateBearerToken").Return(&coreapi.BearerTokenResponse{AccessToken: "token"}, nil)
+
+	payments := &paymentService{}
+	return &JengaPayout{
+		Service:       service,
+		Client:        client,
+		PaymentClient: &paymentV1.PaymentClient{Client: payments},
+		SourceAccount: "1100161816677",
+		Jobs:          repositorytest.NewPaymentJobs(),
+	}, client, payments
+}
+
+func payoutPayment() *models.Payout {
+	payout := &models.Payout{
+		RecipientMSISDN: "254712345678",
+		Amount:          decimal.NewNullDecimal(decimal.RequireFromString("1000.50")),
+		Currency:        "KES",
+		OutBound:        true,
+	}
+	payout.ID = "payment-1"
+	return payout
+}
+
+func TestPayoutUnknownOutcomeIsQueried(t *testing.T) {
+	event, client, payments := newPayout(t)
+	client.On("SendMoney", mock.Anything, "token").Return(nil, errors.New("connection reset")).Once()
+	state := &models.TransactionStatusResponse{Status: true}
+	state.Data.State = "SUCCESS"
+	state.Data.TransactionReference = "JENGA-TX-1"
+	client.On("QueryTransactionStatus", "payment-1", "token").Return(state, nil)
+
+	// The transport error is not handed back, a redelivery would pay again
+	require.NoError(t, event.Execute(t.Context(), payoutPayment()))
+	assert.Equal(t, commonv1.STATUS_IN_PROCESS, payments.lastStatus(t).GetStatus())
+
+	require.NoError(t, event.Execute(t.Context(), payoutPayment()))
+	client.AssertNumberOfCalls(t, "SendMoney", 1)
+	assert.Equal(t, commonv1.STATUS_SUCCESSFUL, payments.lastStatus(t).GetStatus())
+
+	progress, err := event.Jobs.GetByJobID(t.Context(), "payment-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentJobStatePaid, progress.State)
+}
+
+func TestPayoutWithoutRecipientFails(t *testing.T) {
+	event, client, payments := newPayout(t)
+	payout := payoutPayment()
+	payout.RecipientMSISDN = ""
+
+	err := event.Execute(t.Context(), payout)
+	require.ErrorIs(t, err, ErrPayoutUnpayable)
+	assert.Equal(t, commonv1.STATUS_FAILED, payments.lastStatus(t).GetStatus())
+	client.AssertNotCalled(t, "SendMoney", mock.Anything, mock.Anything)
+
+	// The failed payout is settled, its redelivery is dropped
+	require.NoError(t, event.Execute(t.Context(), payout))
+}
+
+func TestRecipientMSISDN(t *testing.T) {
+	payout := payoutPayment()
+	payout.Extra = datatypes.JSONMap{"beneficiary_msisdn": "254700000000"}
+	assert.Equal(t, "254712345678", RecipientMSISDN(payout))
+
+	payout.RecipientMSISDN = ""
+	assert.Equal(t, "254700000000", RecipientMSISDN(payout))
+}
@@ -21,10 +21,10 @@ const (
 	defaultTelco     = "Safaricom"
 	defaultPushType  = "STK"
 	dateFormat       = "2006-01-02"
-	amountFormat     = "%.2f"
-	updateTypePrompt = "prompt"
+	entityTypePrompt = "prompt"
 	statusActive     = commonv1.STATE_ACTIVE
 	statusFailed     = commonv1.STATUS_FAILED
+	statusInProcess  = commonv1.STATUS_IN_PROCESS
 	statusSuccessful = commonv1.STATUS_SUCCESSFUL
 )
 
@@ -74,7 +74,7 @@ func (h *InitiatePrompt) Validate(ctx context.Context, payload any) error {
 	if !prompt.Amount.Valid {
 		return errors.New("payment amount is required")
 	}
-	if prompt.SourceContactID == "" {
+	if _, ok := getStringFromExtra(prompt.Extra, "mobile_number"); !ok && prompt.SourceContactID == "" {
 		return errors.New("source contact ID (mobile number) is required")
 	}
 
@@ -119,9 +119,15 @@ func (h *InitiatePrompt) Execute(ctx context.Context, payload any) error {
 
 	currency := getStringWithDefault(prompt.Extra, "currency", defaultCurrency)
 	telco := getStringWithDefault(prompt.Extra, "telco", defaultTelco)
+	// The payment service normalises the number and detects its telco before publishing
+	mobileNumber := getStringWithDefault(prompt.Extra, "mobile_number", prompt.SourceContactID)
 	pushType := getStringWithDefault(prompt.Extra, "pushType", defaultPushType)
 
-	amountStr := fmt.Sprintf(amountFormat, prompt.Amount.Decimal.InexactFloat64())
+	amountStr, err := models.NewAmount(prompt.Amount.Decimal).Exact(currency)
+	if err != nil {
+		logger.WithError(err).Error("prompt amount cannot be sent to Jenga")
+		return h.handleError(ctx, prompt.ID, transactionRef, fmt.Errorf("prompt amount: %w", err))
+	}
 	currentDate := time.Now().Format(dateFormat)
 
 	stkRequest := &models.STKUSSDRequest{
@@ -135,7 +141,7 @@ func (h *InitiatePrompt) Execute(ctx context.Context, payload any) error {
 			Amount:       amountStr,
 			Currency:     currency,
 			Telco:        telco,
-			MobileNumber: prompt.SourceContactID,
+			MobileNumber: mobileNumber,
 			Date:         currentDate,
 			CallBackUrl:  h.CallbackURL,
 			PushType:     pushType,
@@ -203,7 +209,7 @@ func (h *InitiatePrompt) handleError(ctx context.Context, promptID, transactionR
 		State:  statusActive,
 		Status: statusFailed,
 		Extras: map[string]string{
-			"update_type":     updateTypePrompt,
+			"entity_type":     entityTypePrompt,
 			"transaction_ref": transactionRef,
 			"error":           err.Error(),
 		},
@@ -217,7 +223,8 @@ func (h *InitiatePrompt) handleError(ctx context.Context, promptID, transactionR
 	return err
 }
 
-// updateStatus updates the payment status to successful.
+// updateStatus marks the prompt as in process, the final outcome only arrives with
+// Jenga's callback or a later transaction status query.
 func (h *InitiatePrompt) updateStatus(
 	ctx context.Context,
 	promptID, transactionRef, transactionID, message string,
@@ -225,9 +232,9 @@ func (h *InitiatePrompt) updateStatus(
 	statusUpdateRequest := &commonv1.StatusUpdateRequest{
 		Id:     promptID,
 		State:  statusActive,
-		Status: statusSuccessful,
+		Status: statusInProcess,
 		Extras: map[string]string{
-			"update_type":     updateTypePrompt,
+			"entity_type":     entityTypePrompt,
 			"transaction_ref": transactionRef,
 			"transaction_id":  transactionID,
 			"message":         message,
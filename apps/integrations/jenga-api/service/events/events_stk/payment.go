@@ -0,0 +1,43 @@
+//nolint:revive // package name matches directory structure
+package events_stk //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"encoding/json"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/antinvestor/jenga-api/service/utility"
+)
+
+// StkCodeSettled is the callback code Jenga uses once the customer's payment has settled.
+const StkCodeSettled = 3
+
+// ReceivedPayment is the inbound payment a paid push is recorded as. The callback and the
+// status query report the same payment, so the payment service books it once whichever
+// arrives first.
+func ReceivedPayment(callback *models.StkCallback) *paymentV1.Payment {
+	currency := callback.Currency
+	amount := utility.ToMoney(currency, callback.RequestAmount.Decimal)
+	cost := utility.ToMoney(currency, callback.Charge.RoundTo(currency).Decimal)
+
+	payment := &paymentV1.Payment{
+		Source: &commonv1.ContactLink{
+			Detail: callback.MobileNumber,
+		},
+		TransactionId: callback.Transaction,
+		Amount:        &amount,
+		Cost:          &cost,
+		Extra: map[string]string{
+			// transaction_ref lets the payment service match the payment to its prompt
+			"transaction_ref": callback.Transaction,
+			"telco_reference": callback.Telco,
+			"telco":           callback.TelcoName,
+		},
+	}
+
+	if callbackJSON, err := json.Marshal(callback); err == nil {
+		payment.Extra["additional_info"] = string(callbackJSON)
+	}
+	return payment
+}
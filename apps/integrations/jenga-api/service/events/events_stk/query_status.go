@@ -0,0 +1,197 @@
+//nolint:revive // package name matches directory structure
+package events_stk //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/jenga-api/service/coreapi"
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/pitabwire/frame"
+)
+
+const (
+	ResolutionPaid              = "paid"
+	ResolutionCancelled         = "cancelled"
+	ResolutionTimeout           = "timeout"
+	ResolutionInsufficientFunds = "insufficient_funds"
+)
+
+// PromptStatusQuery resolves prompts for which Jenga never delivered a callback by
+// querying the transaction status API. Pending transactions are left untouched so the
+// next sweep can try again.
+type PromptStatusQuery struct {
+	Service       *frame.Service
+	Client        coreapi.JengaApiClient
+	PaymentClient paymentV1.PaymentClient
+}
+
+// Name returns the name of the event handler.
+func (h *PromptStatusQuery) Name() string {
+	return "prompt.status.query"
+}
+
+// PayloadType returns the type of payload this event expects.
+func (h *PromptStatusQuery) PayloadType() any {
+	return &models.Prompt{}
+}
+
+// Validate validates the payload.
+func (h *PromptStatusQuery) Validate(_ context.Context, payload any) error {
+	prompt, ok := payload.(*models.Prompt)
+	if !ok {
+		return errors.New("invalid payload type, expected *models.Prompt")
+	}
+
+	if prompt.ID == "" {
+		return errors.New("prompt ID is required")
+	}
+	if _, ok = getStringFromExtra(prompt.Extra, "transaction_ref"); !ok {
+		return errors.New("transaction reference is required")
+	}
+
+	return nil
+}
+
+// Handle implements the frame.SubscribeWorker interface.
+func (h *PromptStatusQuery) Handle(ctx context.Context, _ map[string]string, message []byte) error {
+	payload := h.PayloadType()
+	if err := json.Unmarshal(message, payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	if err := h.Validate(ctx, payload); err != nil {
+		return fmt.Errorf("payload validation failed: %w", err)
+	}
+
+	return h.Execute(ctx, payload)
+}
+
+// Execute queries Jenga for the prompt's transaction and reports any final outcome.
+func (h *PromptStatusQuery) Execute(ctx context.Context, payload any) error {
+	prompt, ok := payload.(*models.Prompt)
+	if !ok {
+		return errors.New("invalid payload type, expected *models.Prompt")
+	}
+
+	transactionRef, _ := getStringFromExtra(prompt.Extra, "transaction_ref")
+	logger := h.Service.Log(ctx).WithField("promptId", prompt.ID).WithField("transactionRef", transactionRef)
+	logger.Debug("Querying prompt transaction status")
+
+	token, err := h.Client.GenerateBearerToken()
+	if err != nil {
+		logger.WithError(err).Error("failed to generate bearer token")
+		return fmt.Errorf("generate bearer token: %w", err)
+	}
+
+	response, err := h.Client.QueryTransactionStatus(transactionRef, token.AccessToken)
+	if err != nil {
+		logger.WithError(err).Warn("failed to query transaction status")
+		return fmt.Errorf("query transaction status: %w", err)
+	}
+
+	status, resolution := ResolveTransactionState(response)
+	if resolution == "" {
+		logger.WithField("state", response.Data.State).Debug("transaction is still pending")
+		return nil
+	}
+
+	if resolution == ResolutionPaid {
+		// The callback was lost, the payment it would have reported is received here
+		_, err = h.PaymentClient.Receive(ctx, ReceivedPayment(paidCallback(prompt, transactionRef, response)))
+		if err != nil {
+			logger.WithError(err).Error("failed to receive paid prompt")
+			return fmt.Errorf("payment client receive: %w", err)
+		}
+	}
+
+	statusUpdateRequest := &commonv1.StatusUpdateRequest{
+		Id:     prompt.ID,
+		State:  statusActive,
+		Status: status,
+		Extras: map[string]string{
+			"entity_type":     entityTypePrompt,
+			"transaction_ref": transactionRef,
+			"transaction_id":  response.Data.TelcoReference,
+			"resolution":      resolution,
+			"resolved_by":     "status_query",
+			"message":         response.Message,
+		},
+	}
+
+	if _, err = h.PaymentClient.StatusUpdate(ctx, statusUpdateRequest); err != nil {
+		logger.WithError(err).Error("failed to update prompt status")
+		return fmt.Errorf("payment client status update: %w", err)
+	}
+
+	logger.WithField("resolution", resolution).Info("prompt status resolved from transaction query")
+	return nil
+}
+
+// paidCallback is the callback Jenga would have sent for a push the status query found paid.
+// The transaction details are preferred, the prompt fills in what they leave out.
+func paidCallback(
+	prompt *models.Prompt,
+	transactionRef string,
+	response *models.TransactionStatusResponse,
+) *models.StkCallback {
+	currency := response.Data.Currency
+	if currency == "" {
+		currency = getStringWithDefault(prompt.Extra, "currency", defaultCurrency)
+	}
+	amount := response.Data.Amount
+	if !amount.IsPositive() {
+		amount = models.NewAmount(prompt.Amount.Decimal)
+	}
+
+	return &models.StkCallback{
+		Status:        true,
+		Code:          StkCodeSettled,
+		Message:       response.Message,
+		Transaction:   transactionRef,
+		Telco:         response.Data.TelcoReference,
+		MobileNumber:  getStringWithDefault(prompt.Extra, "mobile_number", prompt.SourceContactID),
+		Currency:      currency,
+		RequestAmount: amount,
+		DebitedAmount: amount,
+		Charge:        response.Data.Charge,
+		TelcoName:     getStringWithDefault(prompt.Extra, "telco", defaultTelco),
+	}
+}
+
+// ResolveTransactionState maps a Jenga transaction status to a payment status and a
+// resolution. An empty resolution means the transaction has not reached a final state.
+func ResolveTransactionState(response *models.TransactionStatusResponse) (commonv1.STATUS, string) {
+	state := strings.ToUpper(response.Data.State)
+	description := strings.ToLower(response.Data.StateDescription + " " + response.Message)
+
+	switch state {
+	case "SUCCESS", "SUCCESSFUL", "COMPLETED", "SETTLED":
+		return statusSuccessful, ResolutionPaid
+	case "CANCELLED", "CANCELED", "FAILED", "DECLINED", "REVERSED":
+		return statusFailed, ResolveFailure(description)
+	case "EXPIRED", "TIMEOUT":
+		return statusFailed, ResolutionTimeout
+	default:
+		return statusInProcess, ""
+	}
+}
+
+// ResolveFailure tells apart why Jenga failed a push from the message it gave, pushes
+// that were neither timed out nor short of funds are treated as cancelled by the customer.
+func ResolveFailure(message string) string {
+	message = strings.ToLower(message)
+	switch {
+	case strings.Contains(message, "timeout") || strings.Contains(message, "timed out"):
+		return ResolutionTimeout
+	case strings.Contains(message, "insufficient"):
+		return ResolutionInsufficientFunds
+	default:
+		return ResolutionCancelled
+	}
+}
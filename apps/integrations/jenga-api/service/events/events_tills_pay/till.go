@@ -4,15 +4,51 @@ package events_tills_pay //nolint:staticcheck // underscore package name require
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
 	"github.com/antinvestor/jenga-api/service/coreapi"
 	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/antinvestor/jenga-api/service/paymentjob"
+	"github.com/antinvestor/jenga-api/service/repository"
+	"github.com/antinvestor/jenga-api/service/utility"
 	"github.com/pitabwire/frame"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+const (
+	// PaymentType marks the payments of till payments, the payout consumer hands the released
+	// ones back to be paid.
+	PaymentType = "tills_pay"
+	paidByJenga = "jenga"
+)
+
+var (
+	// ErrTillsPayDeclined is returned when Jenga answers a till payment without accepting it.
+	ErrTillsPayDeclined = errors.New("tills pay declined")
+	// ErrTillsPayRefused is returned when the payment service does not take a till payment,
+	// because of a limit or what it was asked to pay.
+	ErrTillsPayRefused = errors.New("tills pay refused by the payment service")
+	// ErrTillsPayUnpayable is returned for a released payment that lacks the till details.
+	ErrTillsPayUnpayable = errors.New("till payment cannot be paid")
+)
+
+// JengaTillsPay pays merchant tills through Jenga. Every till payment is registered with
+// the payment service as an outbound payment paid by Jenga. Once it has been released and
+// screened the payment service queues it out to the payout consumer, which hands it back here
+// to be paid.
 type JengaTillsPay struct {
-	Service *frame.Service
-	Client  coreapi.JengaApiClient
+	Service       *frame.Service
+	Client        coreapi.JengaApiClient
+	PaymentClient *paymentV1.PaymentClient
+	// StatusQueryDelay is how long an unanswered payment is left before Jenga is queried for it.
+	StatusQueryDelay time.Duration
+	// Jobs stores the progress of each payment, the service datastore is used when it is nil.
+	Jobs repository.PaymentJobRepository
 }
 
 func (event *JengaTillsPay) Name() string {
@@ -20,65 +56,267 @@ func (event *JengaTillsPay) Name() string {
 }
 
 func (event *JengaTillsPay) PayloadType() any {
-	return &models.TillsPayRequest{}
+	return &models.TillsPayJob{}
 }
 
-func (event *JengaTillsPay) Validate(ctx context.Context, payload any) error {
-	request, ok := payload.(*models.TillsPayRequest)
+func (event *JengaTillsPay) Validate(_ context.Context, payload any) error {
+	job, ok := payload.(*models.TillsPayJob)
 	if !ok {
-		return errors.New("invalid payload type")
+		return errors.New("invalid payload type, expected *models.TillsPayJob")
 	}
 
-	if request.Merchant.Till == "" {
-		return errors.New("merchant.till is required")
+	if job.ID == "" {
+		return errors.New("job id is required")
 	}
-	if request.Payment.Ref == "" {
+	return ValidateTillsPayRequest(&job.Request)
+}
+
+// ValidateTillsPayRequest checks that a till payment request carries everything Jenga needs.
+func ValidateTillsPayRequest(request *models.TillsPayRequest) error {
+	switch {
+	case request == nil:
+		return errors.New("tills pay request is required")
+	case request.Merchant.Till == "":
+		return errors.New("merchant.till is required")
+	case request.Payment.Ref == "":
 		return errors.New("payment.ref is required")
-	}
-	if request.Payment.Amount == "" {
+	case request.Payment.Amount == "":
 		return errors.New("payment.amount is required")
-	}
-	if request.Payment.Currency == "" {
+	case request.Payment.Currency == "":
 		return errors.New("payment.currency is required")
-	}
-	if request.Partner.ID == "" {
+	case request.Partner.ID == "":
 		return errors.New("partner.id is required")
-	}
-	if request.Partner.Ref == "" {
+	case request.Partner.Ref == "":
 		return errors.New("partner.ref is required")
 	}
 
+	if _, err := models.ParseAmount(request.Payment.Currency, request.Payment.Amount); err != nil {
+		return fmt.Errorf("payment.amount is not a valid amount: %w", err)
+	}
 	return nil
 }
 
 func (event *JengaTillsPay) Execute(ctx context.Context, payload any) error {
+	job, ok := payload.(*models.TillsPayJob)
+	if !ok {
+		return errors.New("invalid payload type, expected *models.TillsPayJob")
+	}
+
+	_, err := event.Register(ctx, job.ID, &job.Request)
+	return err
+}
+
+// Register registers the till payment with the payment service, returning the id the payment
+// is tracked under. The till is paid once the payment has been released, registering a job
+// that was seen before returns its payment.
+func (event *JengaTillsPay) Register(
+	ctx context.Context,
+	jobID string,
+	request *models.TillsPayRequest,
+) (string, error) {
+	if event.PaymentClient == nil {
+		return "", errors.New("payment client not initialized")
+	}
+
+	logger := event.Service.Log(ctx).WithField("type", event.Name()).
+		WithField("jobId", jobID).
+		WithField("till", request.Merchant.Till)
+	logger.Info("registering tills pay")
+
+	tracker := event.tracker()
+	progress, err := tracker.Start(ctx, jobID, request.Payment.Ref)
+	if err != nil {
+		logger.WithError(err).Error("failed to record till payment job")
+		return "", err
+	}
+	if progress.State != models.PaymentJobStateNew {
+		return progress.PaymentID, nil
+	}
+
+	paymentID, err := event.registerPayment(ctx, jobID, request)
+	if err != nil {
+		logger.WithError(err).Error("failed to register till payment with payment service")
+		return "", err
+	}
+	if err = tracker.Registered(ctx, progress, paymentID); err != nil {
+		return paymentID, err
+	}
+	logger.WithField("paymentId", paymentID).Info("till payment awaits release")
+	return paymentID, nil
+}
+
+// PayReleased pays the till of a payment the payment service released, screened and queued
+// out to Jenga, and reports the outcome. A payment that was seen before carries on from where
+// it got to and a payment Jenga gave no definite answer for is queried, never paid again.
+func (event *JengaTillsPay) PayReleased(ctx context.Context, payout *models.Payout) error {
 	if event.Client == nil {
 		return errors.New("jenga client not initialized")
 	}
+	if event.PaymentClient == nil {
+		return errors.New("payment client not initialized")
+	}
 
-	request, ok := payload.(*models.TillsPayRequest)
-	if !ok {
-		return errors.New("invalid payload type")
+	jobID, _ := payout.Extra["job_id"].(string)
+	if jobID == "" {
+		jobID = payout.ID
+	}
+	logger := event.Service.Log(ctx).WithField("type", event.Name()).
+		WithField("jobId", jobID).
+		WithField("paymentId", payout.ID)
+	logger.Info("paying released till payment")
+
+	paymentRef, _ := payout.Extra["payment_ref"].(string)
+	tracker := event.tracker()
+	progress, err := tracker.Start(ctx, jobID, paymentRef)
+	if err != nil {
+		logger.WithError(err).Error("failed to record till payment job")
+		return err
 	}
 
-	logger := event.Service.Log(ctx).WithField("type", event.Name()).WithField("TillsPayRequest", request)
-	logger.WithField("request", request).Debug("processing tills pay")
+	switch {
+	case progress.Settled():
+		logger.WithField("state", progress.State).Info("till payment was already settled")
+		return nil
+	case progress.State == models.PaymentJobStateSubmitted:
+		// Jenga may have paid already, only its answer is awaited
+		return tracker.Resolve(ctx, progress)
+	case progress.State == models.PaymentJobStateNew:
+		if err = tracker.Registered(ctx, progress, payout.ID); err != nil {
+			return err
+		}
+	}
+
+	request, err := tillsPayRequest(payout, paymentRef)
+	if err != nil {
+		logger.WithError(err).Warn("till payment cannot be paid")
+		return tracker.Fail(ctx, progress, err)
+	}
 
-	// Generate bearer token for authorization
 	token, err := event.Client.GenerateBearerToken()
 	if err != nil {
 		logger.WithError(err).Error("failed to generate bearer token")
+		return tracker.Fail(ctx, progress, fmt.Errorf("generate bearer token: %w", err))
+	}
+
+	if err = tracker.Submit(ctx, progress); err != nil {
+		logger.WithError(err).Error("failed to record till payment submission")
 		return err
 	}
-	logger.WithField("token", token.AccessToken).Info("generated token for tills pay")
 
-	// TODO: Implement the actual tills/pay API call using event.Client
-	resp, err := event.Client.InitiateTillsPay(*request, token.AccessToken)
+	response, err := event.Client.InitiateTillsPay(*request, token.AccessToken)
 	if err != nil {
-		logger.WithError(err).Error("failed to initiate tills pay")
-		return err
+		// Jenga may have paid the till, it is queried and never paid again
+		tracker.Unconfirmed(ctx, progress, err)
+		return nil
 	}
-	logger.WithField("response", resp).Info("tills pay response")
+	if !response.Status {
+		logger.WithField("response", response).Warn("till payment was declined")
+		return tracker.Fail(ctx, progress, fmt.Errorf("%w: %s", ErrTillsPayDeclined, response.Message))
+	}
+
+	logger.WithField("response", response).Info("tills pay response received")
 
+	if err = tracker.Paid(ctx, progress, response.TransactionID, map[string]string{
+		"message":        response.Message,
+		"transaction_id": response.TransactionID,
+		"merchant_name":  response.MerchantName,
+	}); err != nil {
+		logger.WithError(err).Error("failed to report till payment outcome")
+	}
 	return nil
 }
+
+// RunStatusQueries periodically queries Jenga for till payments it gave no definite answer
+// for. It blocks until the context is cancelled and is meant to run as a background consumer.
+func (event *JengaTillsPay) RunStatusQueries(ctx context.Context) error {
+	return event.tracker().RunStatusQueries(ctx)
+}
+
+// registerPayment records the till payment as an outbound payment so it has its own status
+// lifecycle and goes through the release gates, returning the id the payment service tracks
+// it under. The payment service applies its limits here.
+func (event *JengaTillsPay) registerPayment(
+	ctx context.Context,
+	jobID string,
+	request *models.TillsPayRequest,
+) (string, error) {
+	amountDecimal, err := decimal.NewFromString(request.Payment.Amount)
+	if err != nil {
+		return "", fmt.Errorf("parse till payment amount: %w", err)
+	}
+	amount := utility.ToMoney(request.Payment.Currency, amountDecimal)
+
+	payment := &paymentV1.Payment{
+		ReferenceId: request.Payment.Ref,
+		Recipient: &commonv1.ContactLink{
+			Detail: request.Merchant.Till,
+		},
+		Amount:   &amount,
+		Outbound: true,
+		Extra: map[string]string{
+			"payment_type": PaymentType,
+			"paid_by":      paidByJenga,
+			"job_id":       jobID,
+			"till":         request.Merchant.Till,
+			"payment_ref":  request.Payment.Ref,
+			"partner_id":   request.Partner.ID,
+			"partner_ref":  request.Partner.Ref,
+		},
+	}
+
+	response, err := event.PaymentClient.Client.Send(ctx, &paymentV1.SendRequest{Data: payment})
+	if err != nil {
+		return "", paymentServiceError("payment client send", err)
+	}
+	if response.GetData().GetId() == "" {
+		return "", errors.New("payment client send: no payment id returned")
+	}
+	return response.GetData().GetId(), nil
+}
+
+// tillsPayRequest rebuilds the request paying the till from the details registered with the
+// payment.
+func tillsPayRequest(payout *models.Payout, paymentRef string) (*models.TillsPayRequest, error) {
+	till, _ := payout.Extra["till"].(string)
+	partnerID, _ := payout.Extra["partner_id"].(string)
+	partnerRef, _ := payout.Extra["partner_ref"].(string)
+	if !payout.Amount.Valid {
+		return nil, fmt.Errorf("%w: the amount is missing", ErrTillsPayUnpayable)
+	}
+	amount, err := models.NewAmount(payout.Amount.Decimal).Exact(payout.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTillsPayUnpayable, err)
+	}
+
+	request := &models.TillsPayRequest{
+		Merchant: models.TillsPayMerchant{Till: till},
+		Payment:  models.TillsPayPayment{Ref: paymentRef, Amount: amount, Currency: payout.Currency},
+		Partner:  models.TillsPayPartner{ID: partnerID, Ref: partnerRef},
+	}
+	if err = ValidateTillsPayRequest(request); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTillsPayUnpayable, err)
+	}
+	return request, nil
+}
+
+// paymentServiceError tells apart the payment service refusing a payment from failing to
+// reach it.
+func paymentServiceError(action string, err error) error {
+	switch status.Code(err) {
+	case codes.ResourceExhausted, codes.PermissionDenied, codes.FailedPrecondition, codes.InvalidArgument:
+		return fmt.Errorf("%w: %s: %w", ErrTillsPayRefused, action, err)
+	default:
+		return fmt.Errorf("%s: %w", action, err)
+	}
+}
+
+func (event *JengaTillsPay) tracker() *paymentjob.Tracker {
+	return &paymentjob.Tracker{
+		Service:          event.Service,
+		Client:           event.Client,
+		PaymentClient:    event.PaymentClient,
+		Kind:             PaymentType,
+		StatusQueryDelay: event.StatusQueryDelay,
+		Jobs:             event.Jobs,
+	}
+}
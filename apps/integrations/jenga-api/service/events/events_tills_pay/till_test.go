@@ -0,0 +1,227 @@
+//nolint:revive // package name matches directory structure
+package events_tills_pay //nolint:staticcheck // underscore package name required by project structure
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/jenga-api/service/coreapi"
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/antinvestor/jenga-api/service/repository/repositorytest"
+	"github.com/pitabwire/frame"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/datatypes"
+)
+
+// paymentService records the payments registered with it and the statuses reported.
+type paymentService struct {
+	paymentV1.PaymentServiceClient
+
+	mu       sync.Mutex
+	sent     []*paymentV1.Payment
+	sendErr  error
+	statuses []*commonv1.StatusUpdateRequest
+}
+
+func (p *paymentService) Send(
+	_ context.Context,
+	in *paymentV1.SendRequest,
+	_ ...grpc.CallOption,
+) (*paymentV1.SendResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.sendErr != nil {
+		return nil, p.sendErr
+	}
+	p.sent = append(p.sent, in.GetData())
+	return &paymentV1.SendResponse{Data: &commonv1.StatusResponse{Id: "payment-1"}}, nil
+}
+
+func (p *paymentService) StatusUpdate(
+	_ context.Context,
+	in *commonv1.StatusUpdateRequest,
+	_ ...grpc.CallOption,
+) (*commonv1.StatusUpdateResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.statuses = append(p.statuses, in)
+	return &commonv1.StatusUpdateResponse{}, nil
+}
+
+func (p *paymentService) lastStatus(t *testing.T) *commonv1.StatusUpdateRequest {
+	t.Helper()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	require.NotEmpty(t, p.statuses)
+	return p.statuses[len(p.statuses)-1]
+}
+
+func newTillsPay(t *testing.T) (*JengaTillsPay, *coreapi.MockClient, *paymentService) {
+	t.Helper()
+	ctx, service := frame.NewService("jenga_tills_pay_test")
+	t.Cleanup(func() { service.Stop(ctx) })
+
+	client := new(coreapi.MockClient)
+	client.On("GenerateBearerToken").Return(&coreapi.BearerTokenResponse{AccessToken: "token"}, nil)
+
+	payments := &paymentService{}
+	return &JengaTillsPay{
+		Service:       service,
+		Client:        client,
+		PaymentClient: &paymentV1.PaymentClient{Client: payments},
+		Jobs:          repositorytest.NewPaymentJobs(),
+	}, client, payments
+}
+
+func tillsJob() *models.TillsPayJob {
+	return &models.TillsPayJob{ID: "job-1", Request: models.TillsPayRequest{
+		Merchant: models.TillsPayMerchant{Till: "5432100"},
+		Payment:  models.TillsPayPayment{Ref: "TP0001", Amount: "250.00", Currency: "KES"},
+		Partner:  models.TillsPayPartner{ID: "0011547896523", Ref: "TP0001"},
+	}}
+}
+
+func transactionState(state string) *models.TransactionStatusResponse {
+	response := &models.TransactionStatusResponse{Status: true}
+	response.Data.State = state
+	response.Data.TransactionReference = "JENGA-TX-1"
+	return response
+}
+
+// released is the till payment as the payment service queues it out once it was released.
+func released(t *testing.T, payments *paymentService) *models.Payout {
+	t.Helper()
+	payments.mu.Lock()
+	defer payments.mu.Unlock()
+	require.Len(t, payments.sent, 1)
+
+	extra := datatypes.JSONMap{}
+	for key, value := range payments.sent[0].GetExtra() {
+		extra[key] = value
+	}
+	payout := &models.Payout{
+		Amount:   decimal.NewNullDecimal(decimal.RequireFromString("250.00")),
+		Currency: "KES",
+		OutBound: true,
+		Extra:    extra,
+	}
+	payout.ID = "payment-1"
+	return payout
+}
+
+func TestTillsPayAwaitsRelease(t *testing.T) {
+	event, client, payments := newTillsPay(t)
+
+	for range 2 {
+		require.NoError(t, event.Execute(t.Context(), tillsJob()))
+	}
+
+	client.AssertNotCalled(t, "InitiateTillsPay", mock.Anything, mock.Anything)
+	require.Len(t, payments.sent, 1)
+	assert.Equal(t, "job-1", payments.sent[0].GetExtra()["job_id"])
+	assert.Equal(t, paidByJenga, payments.sent[0].GetExtra()["paid_by"])
+	assert.Empty(t, payments.statuses)
+
+	progress, err := event.Jobs.GetByJobID(t.Context(), "job-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentJobStateRegistered, progress.State)
+	assert.Equal(t, "payment-1", progress.PaymentID)
+}
+
+func TestTillsPayReleasedPaysOnce(t *testing.T) {
+	event, client, payments := newTillsPay(t)
+	client.On("InitiateTillsPay", models.TillsPayRequest{
+		Merchant: models.TillsPayMerchant{Till: "5432100"},
+		Payment:  models.TillsPayPayment{Ref: "TP0001", Amount: "250.00", Currency: "KES"},
+		Partner:  models.TillsPayPartner{ID: "0011547896523", Ref: "TP0001"},
+	}, "token").
+		Return(&models.TillsPayResponse{Status: true, TransactionID: "JENGA-TX-1", MerchantName: "DUKA"}, nil).Once()
+
+	require.NoError(t, event.Execute(t.Context(), tillsJob()))
+	for range 2 {
+		require.NoError(t, event.PayReleased(t.Context(), released(t, payments)))
+	}
+
+	client.AssertNumberOfCalls(t, "InitiateTillsPay", 1)
+	status := payments.lastStatus(t)
+	assert.Equal(t, "payment-1", status.GetId())
+	assert.Equal(t, commonv1.STATUS_SUCCESSFUL, status.GetStatus())
+
+	progress, err := event.Jobs.GetByJobID(t.Context(), "job-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentJobStatePaid, progress.State)
+	assert.Equal(t, "JENGA-TX-1", progress.TransactionID)
+}
+
+func TestTillsPayDeclined(t *testing.T) {
+	event, client, payments := newTillsPay(t)
+	client.On("InitiateTillsPay", mock.Anything, "token").
+		Return(&models.TillsPayResponse{Status: false, Message: "till is closed"}, nil).Once()
+
+	paymentID, err := event.Register(t.Context(), "job-1", &tillsJob().Request)
+	require.NoError(t, err)
+	assert.Equal(t, "payment-1", paymentID)
+
+	err = event.PayReleased(t.Context(), released(t, payments))
+	require.ErrorIs(t, err, ErrTillsPayDeclined)
+	assert.Equal(t, commonv1.STATUS_FAILED, payments.lastStatus(t).GetStatus())
+
+	require.NoError(t, event.PayReleased(t.Context(), released(t, payments)))
+	client.AssertNumberOfCalls(t, "InitiateTillsPay", 1)
+}
+
+func TestTillsPayRefusedRegistration(t *testing.T) {
+	event, client, payments := newTillsPay(t)
+	payments.sendErr = status.Error(codes.ResourceExhausted, "daily limit reached")
+
+	_, err := event.Register(t.Context(), "job-1", &tillsJob().Request)
+	require.ErrorIs(t, err, ErrTillsPayRefused)
+	client.AssertNotCalled(t, "InitiateTillsPay", mock.Anything, mock.Anything)
+}
+
+func TestTillsPayUnknownOutcomeIsQueried(t *testing.T) {
+	tests := []struct {
+		name       string
+		queried    string
+		wantStatus commonv1.STATUS
+		wantState  string
+	}{
+		{name: "jenga paid", queried: "SUCCESS",
+			wantStatus: commonv1.STATUS_SUCCESSFUL, wantState: models.PaymentJobStatePaid},
+		{name: "jenga failed", queried: "FAILED",
+			wantStatus: commonv1.STATUS_FAILED, wantState: models.PaymentJobStateFailed},
+		{name: "still pending", queried: "PENDING",
+			wantStatus: commonv1.STATUS_IN_PROCESS, wantState: models.PaymentJobStateSubmitted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, client, payments := newTillsPay(t)
+			client.On("InitiateTillsPay", mock.Anything, "token").
+				Return(nil, errors.New("connection reset")).Once()
+			client.On("QueryTransactionStatus", "TP0001", "token").Return(transactionState(tt.queried), nil)
+			require.NoError(t, event.Execute(t.Context(), tillsJob()))
+
+			// The transport error is not handed back, a redelivery would pay again
+			require.NoError(t, event.PayReleased(t.Context(), released(t, payments)))
+			assert.Equal(t, commonv1.STATUS_IN_PROCESS, payments.lastStatus(t).GetStatus())
+
+			require.NoError(t, event.PayReleased(t.Context(), released(t, payments)))
+			client.AssertNumberOfCalls(t, "InitiateTillsPay", 1)
+			assert.Equal(t, tt.wantStatus, payments.lastStatus(t).GetStatus())
+
+			progress, err := event.Jobs.GetByJobID(t.Context(), "job-1")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantState, progress.State)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+// AccountBalance returns the balance Jenga holds for a bank account.
+func (js *JobServer) AccountBalance(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := js.Service.Log(ctx).WithField("type", "AccountBalance")
+
+	if js.Client == nil {
+		http.Error(w, "Jenga client is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	countryCode := r.URL.Query().Get("countryCode")
+	accountID := r.URL.Query().Get("accountId")
+	if countryCode == "" || accountID == "" {
+		http.Error(w, "Invalid request: countryCode and accountId are required", http.StatusBadRequest)
+		return
+	}
+
+	token, err := js.Client.GenerateBearerToken()
+	if err != nil {
+		logger.WithError(err).Error("failed to generate bearer token")
+		http.Error(w, "Failed to authenticate with Jenga", http.StatusBadGateway)
+		return
+	}
+
+	balance, err := js.Client.InitiateAccountBalance(countryCode, accountID, token.AccessToken)
+	if err != nil {
+		logger.WithError(err).Error("account balance lookup failed")
+		http.Error(w, "Failed to fetch account balance", http.StatusBadGateway)
+		return
+	}
+	if !balance.Status {
+		writeJSON(w, http.StatusUnprocessableEntity, balance)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, balance)
+}
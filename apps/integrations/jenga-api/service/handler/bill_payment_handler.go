@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/antinvestor/jenga-api/service/events/events_bill_payment"
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/pitabwire/frame"
+)
+
+// ListBillers returns the cached Jenga billers catalogue, optionally filtered by category.
+func (js *JobServer) ListBillers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := js.Service.Log(ctx).WithField("type", "ListBillers")
+
+	if js.BillPayment == nil || js.BillPayment.Catalogue == nil {
+		http.Error(w, "Bill payments are not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	billers, err := js.BillPayment.Catalogue.Billers(ctx, r.URL.Query().Get("category"))
+	if err != nil {
+		logger.WithError(err).Error("failed to load billers catalogue")
+		http.Error(w, "Failed to load billers", http.StatusBadGateway)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":  "success",
+		"billers": billers,
+	})
+}
+
+// ValidateBill checks a bill reference with the biller without paying it.
+func (js *JobServer) ValidateBill(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := js.Service.Log(ctx).WithField("type", "ValidateBill")
+
+	if js.BillPayment == nil {
+		http.Error(w, "Bill payments are not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var request models.PaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.WithError(err).Error("failed to decode request")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := events_bill_payment.ValidatePaymentRequest(&request); err != nil {
+		http.Error(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	validation, err := js.BillPayment.ValidateReference(ctx, &request)
+	if err != nil {
+		logger.WithError(err).WithField("reference", request.Bill.Reference).Warn("bill validation failed")
+		if validation != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, validation)
+			return
+		}
+		http.Error(w, "Failed to validate bill", http.StatusBadGateway)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, validation)
+}
+
+// PayBill queues a bill payment, the outcome is reported to the payment service as a payment status.
+func (js *JobServer) PayBill(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := js.Service.Log(ctx).WithField("type", "PayBill")
+
+	if js.BillPayment == nil {
+		http.Error(w, "Bill payments are not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var request models.PaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.WithError(err).Error("failed to decode request")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := events_bill_payment.ValidatePaymentRequest(&request); err != nil {
+		http.Error(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if js.BillPayment.Catalogue != nil {
+		if _, err := js.BillPayment.Catalogue.Get(ctx, request.Biller.BillerCode); err != nil {
+			if errors.Is(err, events_bill_payment.ErrBillerNotFound) {
+				http.Error(w, "Unknown biller", http.StatusBadRequest)
+				return
+			}
+			logger.WithError(err).Error("failed to load billers catalogue")
+			http.Error(w, "Failed to load billers", http.StatusBadGateway)
+			return
+		}
+	}
+
+	job := &models.Job{
+		ID:        frame.GenerateID(ctx),
+		ExtraData: request,
+	}
+
+	if err := js.Service.Emit(ctx, js.BillPayment.Name(), job); err != nil {
+		logger.WithError(err).WithField("reference", request.Bill.Reference).Error("failed to queue bill payment")
+		http.Error(w, "Failed to process bill payment", http.StatusInternalServerError)
+		return
+	}
+
+	// The payment is registered when the job runs, it carries the job id in its extras and the
+	// bill is paid once the payment has been released
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"status":  "accepted",
+		"message": "Bill payment queued for processing",
+		"jobId":   job.ID,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/antinvestor/jenga-api/service/models"
+)
+
+// Callback kinds, each is stored in the inbox and processed by its own event.
+const (
+	CallbackKindStk         = "stk"
+	CallbackKindIPN         = "ipn"
+	CallbackKindTills       = "tills"
+	CallbackKindPaymentLink = "payment_link"
+)
+
+// HandleStkCallback receives STK/USSD push results.
+func (js *JobServer) HandleStkCallback(w http.ResponseWriter, r *http.Request) {
+	js.receiveCallback(w, r, CallbackKindStk)
+}
+
+// HandleIPNCallback receives bank instant payment notifications.
+func (js *JobServer) HandleIPNCallback(w http.ResponseWriter, r *http.Request) {
+	js.receiveCallback(w, r, CallbackKindIPN)
+}
+
+// HandleTillsCallback receives payments made to the merchant's tills.
+func (js *JobServer) HandleTillsCallback(w http.ResponseWriter, r *http.Request) {
+	js.receiveCallback(w, r, CallbackKindTills)
+}
+
+// HandlePaymentLinkCallback receives payments made through payment links.
+func (js *JobServer) HandlePaymentLinkCallback(w http.ResponseWriter, r *http.Request) {
+	js.receiveCallback(w, r, CallbackKindPaymentLink)
+}
+
+// receiveCallback validates a callback against the schema of the event that processes its
+// kind before handing it to the inbox.
+func (js *JobServer) receiveCallback(w http.ResponseWriter, r *http.Request, kind string) {
+	ctx := r.Context()
+	logger := js.Service.Log(ctx).WithField("type", "CallbackHandler").WithField("kind", kind)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Authentication is enforced by the callback middleware before the request gets here
+
+	processor, ok := js.Inbox.Processors[kind]
+	if !ok {
+		logger.Error("no processor configured for callback kind")
+		http.Error(w, "Callback type not supported", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		logger.WithError(err).Error("failed to read callback request")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	payload := processor.PayloadType()
+	if err = json.Unmarshal(body, payload); err != nil {
+		logger.WithError(err).Error("failed to decode callback request")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err = processor.Validate(ctx, payload); err != nil {
+		logger.WithError(err).Error("invalid callback")
+		http.Error(w, "Invalid callback: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	callback, ok := payload.(models.CallbackPayload)
+	if !ok {
+		logger.Error("callback payload does not expose a reference")
+		http.Error(w, "Callback type not supported", http.StatusInternalServerError)
+		return
+	}
+
+	logger.WithField("reference", callback.CallbackReference()).Info("received callback")
+	js.acceptCallback(w, r, kind, callback.CallbackReference(), body)
+}
+
+// acceptCallback stores the callback in the inbox and acknowledges it straight away,
+// processing happens asynchronously. Repeated deliveries are acknowledged but ignored.
+func (js *JobServer) acceptCallback(w http.ResponseWriter, r *http.Request, kind, reference string, body []byte) {
+	ctx := r.Context()
+	logger := js.Service.Log(ctx).WithField("kind", kind).WithField("reference", reference)
+
+	entry, created, err := js.Inbox.Accept(ctx, kind, reference, r, body)
+	if err != nil {
+		// Jenga retries callbacks that are not acknowledged
+		logger.WithError(err).Error("failed to store callback")
+		http.Error(w, "Failed to store callback", http.StatusInternalServerError)
+		return
+	}
+
+	message := "Callback received successfully"
+	if !created {
+		logger.Info("duplicate callback ignored")
+		message = "Callback already received"
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status":  "success",
+		"message": message,
+		"inboxId": entry.GetID(),
+	})
+}
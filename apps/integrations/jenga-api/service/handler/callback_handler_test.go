@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antinvestor/jenga-api/config"
+	"github.com/antinvestor/jenga-api/service/events/events_callback"
+	"github.com/antinvestor/jenga-api/service/events/events_inbox"
+	"github.com/antinvestor/jenga-api/service/repository/repositorytest"
+	"github.com/pitabwire/frame"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiveCallback(t *testing.T) {
+	cfg, err := frame.ConfigFromEnv[config.JengaConfig]()
+	require.NoError(t, err)
+	ctx, service := frame.NewService(t.Name(), frame.WithConfig(&cfg), frame.WithNoopDriver())
+	t.Cleanup(func() { service.Stop(ctx) })
+
+	inbox := &events_inbox.ProcessCallback{
+		Service: service,
+		Processors: map[string]frame.EventI{
+			CallbackKindStk:         &events_callback.JengaStkCallback{Service: service},
+			CallbackKindIPN:         &events_callback.JengaCallbackReceivePayment{Service: service},
+			CallbackKindTills:       &events_callback.JengaTillsCallback{Service: service},
+			CallbackKindPaymentLink: &events_callback.JengaPaymentLinkCallback{Service: service},
+		},
+		Repository: repositorytest.NewCallbackInbox(),
+		RetryDelay: time.Minute,
+	}
+	js := &JobServer{Service: service, Inbox: inbox}
+
+	stk := `{"status":true,"code":3,"transactionReference":"TRX123","mobileNumber":"254712345678",` +
+		`"currency":"KES","requestAmount":1000}`
+	tests := []struct {
+		name           string
+		handler        http.HandlerFunc
+		method         string
+		body           string
+		expectedStatus int
+		expectedKind   string
+		expectedRef    string
+		expectedMsg    string
+	}{
+		{
+			name: "stk callback", handler: js.HandleStkCallback, method: http.MethodPost, body: stk,
+			expectedStatus: http.StatusOK, expectedKind: CallbackKindStk, expectedRef: "TRX123",
+			expectedMsg: "Callback received successfully",
+		},
+		{
+			name: "stk callback delivered again", handler: js.HandleStkCallback, method: http.MethodPost, body: stk,
+			expectedStatus: http.StatusOK, expectedMsg: "Callback already received",
+		},
+		{
+			name: "stk callback without mobile number", handler: js.HandleStkCallback, method: http.MethodPost,
+			body:           `{"transactionReference":"TRX124","currency":"KES"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "ipn", handler: js.HandleIPNCallback, method: http.MethodPost,
+			body: `{"callbackType":"IPN","transaction":{"reference":"IPN001","amount":"500","currency":"KES"},` +
+				`"bank":{"account":"1100161816677"}}`,
+			expectedStatus: http.StatusOK, expectedKind: CallbackKindIPN, expectedRef: "IPN001",
+			expectedMsg: "Callback received successfully",
+		},
+		{
+			name: "ipn of another callback type", handler: js.HandleIPNCallback, method: http.MethodPost,
+			body:           `{"callbackType":"TILLS","transaction":{"reference":"IPN002","amount":"500","currency":"KES"}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "till payment", handler: js.HandleTillsCallback, method: http.MethodPost,
+			body: `{"till":{"number":"5432100"},` +
+				`"transaction":{"reference":"TILL001","amount":"250","currency":"KES","status":"SUCCESS"}}`,
+			expectedStatus: http.StatusOK, expectedKind: CallbackKindTills, expectedRef: "TILL001",
+			expectedMsg: "Callback received successfully",
+		},
+		{
+			name: "till payment without till", handler: js.HandleTillsCallback, method: http.MethodPost,
+			body:           `{"transaction":{"reference":"TILL002","amount":"250","currency":"KES"}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "payment link payment", handler: js.HandlePaymentLinkCallback, method: http.MethodPost,
+			body: `{"paymentLinkRef":"PL123",` +
+				`"transaction":{"reference":"PLT001","amount":"500","currency":"KES","status":"SUCCESS"}}`,
+			expectedStatus: http.StatusOK, expectedKind: CallbackKindPaymentLink, expectedRef: "PLT001",
+			expectedMsg: "Callback received successfully",
+		},
+		{
+			name: "payment link payment without amount", handler: js.HandlePaymentLinkCallback, method: http.MethodPost,
+			body:           `{"paymentLinkRef":"PL123","transaction":{"reference":"PLT002","currency":"KES"}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "malformed body", handler: js.HandleStkCallback, method: http.MethodPost, body: `{`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "wrong method", handler: js.HandleStkCallback, method: http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/callbacks", strings.NewReader(tt.body)).WithContext(ctx)
+			rr := httptest.NewRecorder()
+			tt.handler(rr, req)
+
+			require.Equal(t, tt.expectedStatus, rr.Code, rr.Body.String())
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response map[string]string
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedMsg, response["message"])
+			if tt.expectedKind == "" {
+				// Repeated deliveries are acknowledged without being stored again
+				return
+			}
+			entry, err := inbox.Repository.GetByID(ctx, response["inboxId"])
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedKind, entry.Kind)
+			assert.Equal(t, tt.expectedRef, entry.Reference)
+			assert.JSONEq(t, tt.body, entry.Body)
+		})
+	}
+}
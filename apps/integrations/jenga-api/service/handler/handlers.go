@@ -2,10 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
 	"github.com/antinvestor/jenga-api/service/coreapi"
+	"github.com/antinvestor/jenga-api/service/events/events_bill_payment"
+	"github.com/antinvestor/jenga-api/service/events/events_inbox"
 	"github.com/antinvestor/jenga-api/service/events/events_tills_pay"
 	"github.com/antinvestor/jenga-api/service/models"
 	"github.com/pitabwire/frame"
@@ -15,61 +18,63 @@ import (
 
 type JobServer struct {
 	Service       *frame.Service
-	Client        *coreapi.Client
+	Client        coreapi.JengaApiClient
 	PaymentClient *paymentV1.PaymentClient
+	BillPayment   *events_bill_payment.JengaBillPayment
+	TillsPay      *events_tills_pay.JengaTillsPay
+	Inbox         *events_inbox.ProcessCallback
 }
 
+// InitiateTillsPay registers a payment to a merchant till with the payment service and
+// returns the id it is tracked under. The till is paid once the payment has been released.
 func (js *JobServer) InitiateTillsPay(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
 		return
 	}
 
-	//background context for async processing
 	ctx := r.Context()
 	logger := js.Service.Log(ctx).WithField("type", "InitiateTillsPay")
 
+	if js.TillsPay == nil {
+		http.Error(w, "Tills pay is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
 	var request models.TillsPayRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		logger.WithError(err).Error("failed to decode request")
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
-
-	// Validate required fields (basic check, event will do full validation)
-	if request.Merchant.Till == "" || request.Payment.Ref == "" || request.Payment.Amount == "" ||
-		request.Payment.Currency == "" ||
-		request.Partner.ID == "" ||
-		request.Partner.Ref == "" {
-		http.Error(w, "Invalid request: missing required fields", http.StatusBadRequest)
+	if err := events_tills_pay.ValidateTillsPayRequest(&request); err != nil {
+		http.Error(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Create event
-	event := &events_tills_pay.JengaTillsPay{
-		Service: js.Service,
-		Client:  js.Client,
-	}
-
-	// Execute event synchronously with request context
-	err := js.Service.Emit(ctx, event.Name(), &request)
+	paymentID, err := js.TillsPay.Register(ctx, frame.GenerateID(ctx), &request)
 	if err != nil {
-		logger.WithError(err).WithField("reference", request.Payment.Ref).Error("failed to process tills pay request")
-		http.Error(w, "Failed to process tills pay request", http.StatusInternalServerError)
+		logger.WithError(err).WithField("reference", request.Payment.Ref).Error("tills pay failed")
+		body := map[string]string{
+			"status":      "failed",
+			"message":     err.Error(),
+			"paymentId":   paymentID,
+			"referenceId": request.Payment.Ref,
+		}
+		if errors.Is(err, events_tills_pay.ErrTillsPayRefused) {
+			writeJSON(w, http.StatusUnprocessableEntity, body)
+		} else {
+			writeJSON(w, http.StatusBadGateway, body)
+		}
 		return
 	}
 
-	// Return success response after processing
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]string{
-		"status":      "success",
-		"message":     "Tills pay request processed successfully",
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"status":      "pending",
+		"message":     "Till payment awaits release",
+		"paymentId":   paymentID,
 		"referenceId": request.Payment.Ref,
-	}); err != nil {
-		logger.WithError(err).Error("failed to encode response")
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
+	})
 }
 
 // HealthHandler is a simple health check handler.
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/antinvestor/jenga-api/service/events/events_inbox"
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/antinvestor/jenga-api/service/repository"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+const defaultInboxListLimit = 50
+
+// ListCallbacks lists inbox entries in a given state, failed ones by default.
+func (js *JobServer) ListCallbacks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	state := r.URL.Query().Get("state")
+	if state == "" {
+		state = models.InboxStateFailed
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultInboxListLimit
+	}
+
+	entries, err := repository.NewCallbackInboxRepository(ctx, js.Service).ListByState(ctx, state, limit)
+	if err != nil {
+		js.Service.Log(ctx).WithError(err).Error("failed to list callbacks")
+		http.Error(w, "Failed to list callbacks", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":    "success",
+		"callbacks": entries,
+	})
+}
+
+// ReplayCallback queues a failed callback for processing again, typically after a fix.
+// Callbacks that were received but not processed are only replayed with force=true.
+func (js *JobServer) ReplayCallback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := mux.Vars(r)["id"]
+	logger := js.Service.Log(ctx).WithField("type", "ReplayCallback").WithField("inboxId", id)
+
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+	entry, err := js.Inbox.Replay(ctx, id, force)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Callback not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, events_inbox.ErrCallbackAlreadyProcessed) ||
+			errors.Is(err, events_inbox.ErrCallbackNotReplayable) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		logger.WithError(err).Error("failed to replay callback")
+		http.Error(w, "Failed to replay callback", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("callback queued for replay")
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"status":  "queued",
+		"inboxId": entry.GetID(),
+	})
+}
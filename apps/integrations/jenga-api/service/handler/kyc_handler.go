@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/antinvestor/jenga-api/service/models"
+)
+
+const (
+	kycTypeAccount = "account"
+	kycTypeMobile  = "mobile"
+	defaultWallet  = "Mpesa"
+)
+
+// LookupBeneficiary resolves the registered name behind a bank account or mobile wallet.
+func (js *JobServer) LookupBeneficiary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := js.Service.Log(ctx).WithField("type", "LookupBeneficiary")
+
+	if js.Client == nil {
+		http.Error(w, "Jenga client is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var request models.KYCLookupRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.WithError(err).Error("failed to decode request")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := validateKYCLookup(&request); err != nil {
+		http.Error(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, err := js.Client.GenerateBearerToken()
+	if err != nil {
+		logger.WithError(err).Error("failed to generate bearer token")
+		http.Error(w, "Failed to authenticate with Jenga", http.StatusBadGateway)
+		return
+	}
+
+	response := models.KYCLookupResponse{Type: request.Type}
+	switch request.Type {
+	case kycTypeAccount:
+		inquiry, inquiryErr := js.Client.AccountInquiry(request.CountryCode, request.AccountNumber, token.AccessToken)
+		if inquiryErr != nil {
+			logger.WithError(inquiryErr).Error("account inquiry failed")
+			http.Error(w, "Failed to look up account", http.StatusBadGateway)
+			return
+		}
+		response.Reference = request.AccountNumber
+		response.Message = inquiry.Message
+		if inquiry.Status && len(inquiry.Data.Customer) > 0 {
+			response.Name = inquiry.Data.Customer[0].Name
+		}
+	case kycTypeMobile:
+		lookup, lookupErr := js.Client.MobileWalletLookup(models.MobileWalletLookupRequest{
+			CountryCode:  request.CountryCode,
+			MobileNumber: request.MobileNumber,
+			Wallet:       request.Wallet,
+		}, token.AccessToken)
+		if lookupErr != nil {
+			logger.WithError(lookupErr).Error("mobile wallet lookup failed")
+			http.Error(w, "Failed to look up mobile wallet", http.StatusBadGateway)
+			return
+		}
+		response.Reference = request.MobileNumber
+		response.Message = lookup.Message
+		if lookup.Status {
+			response.Name = lookup.Data.Name
+		}
+	}
+
+	if response.Name == "" {
+		response.Status = "not_found"
+		writeJSON(w, http.StatusNotFound, response)
+		return
+	}
+
+	response.Status = "success"
+	writeJSON(w, http.StatusOK, response)
+}
+
+func validateKYCLookup(request *models.KYCLookupRequest) error {
+	request.Type = strings.ToLower(strings.TrimSpace(request.Type))
+	if request.CountryCode == "" {
+		return errors.New("countryCode is required")
+	}
+
+	switch request.Type {
+	case kycTypeAccount:
+		if request.AccountNumber == "" {
+			return errors.New("accountNumber is required for account lookups")
+		}
+	case kycTypeMobile:
+		if request.MobileNumber == "" {
+			return errors.New("mobileNumber is required for mobile lookups")
+		}
+		if request.Wallet == "" {
+			request.Wallet = defaultWallet
+		}
+	default:
+		return errors.New("type must be either account or mobile")
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antinvestor/jenga-api/service/coreapi"
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/pitabwire/frame"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupBeneficiary(t *testing.T) {
+	found := &models.AccountInquiryResponse{}
+	require.NoError(t, json.Unmarshal(
+		[]byte(`{"status":true,"data":{"customer":[{"name":"JOHN KAMAU"}]}}`), found))
+	wallet := &models.MobileWalletLookupResponse{Status: true}
+	wallet.Data.Name = "MARY ATIENO"
+
+	tests := []struct {
+		name           string
+		request        models.KYCLookupRequest
+		setup          func(client *coreapi.MockClient)
+		expectedStatus int
+		expectedName   string
+	}{
+		{
+			name:    "account holder found",
+			request: models.KYCLookupRequest{Type: "Account", CountryCode: "KE", AccountNumber: "0100000001"},
+			setup: func(client *coreapi.MockClient) {
+				client.On("AccountInquiry", "KE", "0100000001", "token").Return(found, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedName:   "JOHN KAMAU",
+		},
+		{
+			name:    "wallet defaults to mpesa",
+			request: models.KYCLookupRequest{Type: "mobile", CountryCode: "KE", MobileNumber: "254712345678"},
+			setup: func(client *coreapi.MockClient) {
+				client.On("MobileWalletLookup", models.MobileWalletLookupRequest{
+					CountryCode: "KE", MobileNumber: "254712345678", Wallet: "Mpesa",
+				}, "token").Return(wallet, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedName:   "MARY ATIENO",
+		},
+		{
+			name:    "unknown account",
+			request: models.KYCLookupRequest{Type: "account", CountryCode: "KE", AccountNumber: "0100000002"},
+			setup: func(client *coreapi.MockClient) {
+				client.On("AccountInquiry", "KE", "0100000002", "token").
+					Return(&models.AccountInquiryResponse{Message: "account not found"}, nil)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:    "jenga unavailable",
+			request: models.KYCLookupRequest{Type: "account", CountryCode: "KE", AccountNumber: "0100000003"},
+			setup: func(client *coreapi.MockClient) {
+				client.On("AccountInquiry", "KE", "0100000003", "token").Return(nil, assert.AnError)
+			},
+			expectedStatus: http.StatusBadGateway,
+		},
+		{
+			name:           "account number required",
+			request:        models.KYCLookupRequest{Type: "account", CountryCode: "KE"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown lookup type",
+			request:        models.KYCLookupRequest{Type: "card", CountryCode: "KE", AccountNumber: "0100000001"},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	_, service := frame.NewService("jenga_kyc_test")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := new(coreapi.MockClient)
+			if tt.setup != nil {
+				client.On("GenerateBearerToken").Return(&coreapi.BearerTokenResponse{AccessToken: "token"}, nil)
+				tt.setup(client)
+			}
+			jobServer := &JobServer{Service: service, Client: client}
+
+			body, err := json.Marshal(tt.request)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			jobServer.LookupBeneficiary(rr, httptest.NewRequest(http.MethodPost, "/kyc/lookup", bytes.NewReader(body)))
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedName != "" {
+				var response models.KYCLookupResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedName, response.Name)
+				assert.Equal(t, "success", response.Status)
+			}
+			client.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLookupBeneficiaryWithoutClient(t *testing.T) {
+	_, service := frame.NewService("jenga_kyc_test")
+	jobServer := &JobServer{Service: service}
+
+	rr := httptest.NewRecorder()
+	jobServer.LookupBeneficiary(rr, httptest.NewRequest(http.MethodPost, "/kyc/lookup", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
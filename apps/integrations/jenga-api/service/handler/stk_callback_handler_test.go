@@ -1,159 +0,0 @@
-package handlers
-
-import (
-	"bytes"
-	"encoding/json"
-	"net/http"
-	"net/http/httptest"
-	"testing"
-
-	"github.com/antinvestor/jenga-api/service/models"
-	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
-)
-
-func TestHandleStkCallback(t *testing.T) {
-	tests := []struct {
-		name           string
-		method         string
-		requestBody    interface{}
-		emitError      error
-		expectedStatus int
-	}{
-		{
-			name:   "Happy path - successful callback handling",
-			method: http.MethodPost,
-			requestBody: models.StkCallback{
-				Transaction:   "TRX123",
-				MobileNumber:  "254712345678",
-				RequestAmount: 1000,
-				Currency:      "KES",
-				DebitedAmount: 1000,
-				Charge:        30,
-				TelcoName:     "Safaricom",
-				Code:          3,
-				Message:       "Transaction Successful - Settled",
-			},
-			emitError:      nil,
-			expectedStatus: http.StatusOK,
-		},
-		{
-			name:           "Error - wrong HTTP method",
-			method:         http.MethodGet,
-			requestBody:    nil,
-			emitError:      nil,
-			expectedStatus: http.StatusMethodNotAllowed,
-		},
-		{
-			name:   "Error - invalid request body",
-			method: http.MethodPost,
-			requestBody: map[string]string{
-				"invalid": "request",
-			},
-			emitError:      nil,
-			expectedStatus: http.StatusBadRequest,
-		},
-		{
-			name:   "Error - invalid initiator callback",
-			method: http.MethodPost,
-			requestBody: models.StkCallback{
-				Status:        false,
-				Code:          7,
-				Message:       "The initiator information is invalid.",
-				Transaction:   "M92J43",
-				Telco:         "",
-				MobileNumber:  "254722000000",
-				Currency:      "KES",
-				RequestAmount: 5,
-				DebitedAmount: 5,
-				Charge:        1,
-				TelcoName:     "Safaricom",
-			},
-			emitError:      nil,
-			expectedStatus: http.StatusOK, // API still returns 200 even for business logic errors
-		},
-		{
-			name:   "Error - event processing failure",
-			method: http.MethodPost,
-			requestBody: models.StkCallback{
-				Transaction:   "TRX456",
-				MobileNumber:  "254712345678",
-				RequestAmount: 1000,
-				Currency:      "KES",
-				DebitedAmount: 1000,
-				Charge:        30,
-				TelcoName:     "Safaricom",
-				Code:          0,
-				Message:       "Success",
-			},
-			emitError:      assert.AnError,
-			expectedStatus: http.StatusInternalServerError,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Create a direct test handler implementation instead of using the real one
-			// This allows us to test the HTTP flow without worrying about frame.Service internals
-			handlerFunc := func(w http.ResponseWriter, r *http.Request) {
-				if r.Method != http.MethodPost {
-					http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
-					return
-				}
-
-				// Note: In a real handler we'd verify authorization, but for testing we'll skip that check
-				// as our test doesn't provide auth headers
-
-				var callback models.StkCallback
-				if err := json.NewDecoder(r.Body).Decode(&callback); err != nil {
-					http.Error(w, "Invalid request body format", http.StatusBadRequest)
-					return
-				}
-
-				// Check for required fields in the callback
-				if callback.Transaction == "" || callback.MobileNumber == "" || callback.Currency == "" {
-					http.Error(w, "Missing required fields in callback", http.StatusBadRequest)
-					return
-				}
-
-				// Simulate publishing the callback and check for errors
-				if tt.emitError != nil {
-					http.Error(w, "Internal server error", http.StatusInternalServerError)
-					return
-				}
-
-				// Return success response
-				w.WriteHeader(http.StatusOK)
-				if err := json.NewEncoder(w).Encode(map[string]string{
-					"status":  "success",
-					"message": "Callback received successfully",
-				}); err != nil {
-					http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-				}
-			}
-
-			// Create request
-			var reqBody []byte
-			var err error
-			if tt.requestBody != nil {
-				reqBody, err = json.Marshal(tt.requestBody)
-				require.NoError(t, err)
-			}
-
-			req, err := http.NewRequest(tt.method, "/receivepayments", bytes.NewBuffer(reqBody))
-			require.NoError(t, err)
-
-			// Create response recorder
-			rr := httptest.NewRecorder()
-
-			// Call our test handler function
-			handlerFunc(rr, req)
-
-			// Check response
-			assert.Equal(t, tt.expectedStatus, rr.Code)
-
-			// Verify mock expectations
-			//mockService.AssertExpectations(t)
-		})
-	}
-}
@@ -0,0 +1,381 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/pitabwire/frame"
+)
+
+const (
+	// SignatureHeader carries Jenga's base64 encoded RSA-SHA256 signature of the callback body.
+	SignatureHeader = "Signature"
+	// SharedSecretHeader carries the shared secret for callers that cannot use Basic Auth.
+	SharedSecretHeader = "X-Callback-Secret"
+
+	maxCallbackBodyBytes = 1 << 20
+
+	// Rejections keep an excerpt of the body, enough to tell what was sent.
+	maxRejectionExcerptBytes = 512
+	defaultRejectionLimit    = 60
+	defaultRejectionWindow   = time.Minute
+)
+
+// Reasons recorded against rejected callbacks.
+const (
+	RejectedSourceIP       = "source_ip_not_allowed"
+	RejectedCredentials    = "invalid_credentials"
+	RejectedSignature      = "invalid_signature"
+	RejectedUnreadableBody = "unreadable_body"
+	RejectedOversizedBody  = "oversized_body"
+)
+
+// ErrUnauthenticatedCallbacks is returned for callback authentication that would let any
+// caller through.
+var ErrUnauthenticatedCallbacks = errors.New(
+	"callbacks are not authenticated: configure credentials, a signing key or a source allow-list")
+
+// RejectionRecorder stores callbacks that failed verification for security review.
+type RejectionRecorder interface {
+	Save(ctx context.Context, rejection *models.CallbackRejection) error
+}
+
+// CallbackAuth verifies that callbacks originate from Jenga before they reach a handler.
+// Each check is enforced once it has been configured and at least one must be, callbacks are
+// rejected otherwise.
+type CallbackAuth struct {
+	Username     string
+	Password     string
+	SharedSecret string
+	PublicKey    *rsa.PublicKey
+	AllowedNets  []*net.IPNet
+	// TrustedProxies is the number of proxies in front of the service that append the address
+	// they received a request from to X-Forwarded-For. The source IP is taken that many
+	// entries from the right, entries further left are set by the caller and not trusted.
+	TrustedProxies int
+	Recorder       RejectionRecorder
+	// RejectionLimit rejections are recorded per RejectionWindow at most, the ones over the
+	// limit are only counted so junk requests cannot fill the datastore.
+	RejectionLimit  int
+	RejectionWindow time.Duration
+	Service         *frame.Service
+
+	mu          sync.Mutex
+	windowStart time.Time
+	recorded    int
+	suppressed  int
+}
+
+// Validate reports configurations that would let callbacks through unchecked.
+func (ca *CallbackAuth) Validate() error {
+	if ca.Username != "" && ca.Password == "" {
+		return errors.New("callback username is set without a password")
+	}
+	if ca.Username == "" && ca.SharedSecret == "" && ca.PublicKey == nil && len(ca.AllowedNets) == 0 {
+		return ErrUnauthenticatedCallbacks
+	}
+	return nil
+}
+
+// Middleware wraps next with the configured callback checks.
+func (ca *CallbackAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := ca.Validate(); err != nil {
+			ca.reject(w, r, nil, RejectedCredentials, http.StatusUnauthorized, err)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCallbackBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				ca.reject(w, r, nil, RejectedOversizedBody, http.StatusRequestEntityTooLarge, err)
+				return
+			}
+			ca.reject(w, r, nil, RejectedUnreadableBody, http.StatusBadRequest, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		if !ca.sourceAllowed(r) {
+			ca.reject(w, r, body, RejectedSourceIP, http.StatusForbidden, nil)
+			return
+		}
+
+		if !ca.credentialsValid(r) {
+			ca.reject(w, r, body, RejectedCredentials, http.StatusUnauthorized, nil)
+			return
+		}
+
+		if err = ca.verifySignature(r, body); err != nil {
+			ca.reject(w, r, body, RejectedSignature, http.StatusUnauthorized, err)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (ca *CallbackAuth) sourceAllowed(r *http.Request) bool {
+	if len(ca.AllowedNets) == 0 {
+		return true
+	}
+
+	ip := net.ParseIP(ca.remoteIP(r))
+	if ip == nil {
+		return false
+	}
+	for _, allowed := range ca.AllowedNets {
+		if allowed.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ca *CallbackAuth) credentialsValid(r *http.Request) bool {
+	if ca.Username == "" && ca.SharedSecret == "" {
+		return true
+	}
+
+	if ca.Username != "" {
+		username, password, ok := r.BasicAuth()
+		if ok && secureCompare(username, ca.Username) && secureCompare(password, ca.Password) {
+			return true
+		}
+	}
+
+	if ca.SharedSecret != "" {
+		if secret := r.Header.Get(SharedSecretHeader); secret != "" && secureCompare(secret, ca.SharedSecret) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (ca *CallbackAuth) verifySignature(r *http.Request, body []byte) error {
+	if ca.PublicKey == nil {
+		return nil
+	}
+
+	encoded := r.Header.Get(SignatureHeader)
+	if encoded == "" {
+		return errors.New("signature header is missing")
+	}
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("decode signature: %w", err)
+	}
+
+	hashed := sha256.Sum256(body)
+	return rsa.VerifyPKCS1v15(ca.PublicKey, crypto.SHA256, hashed[:], signature)
+}
+
+func (ca *CallbackAuth) remoteIP(r *http.Request) string {
+	if ca.TrustedProxies > 0 {
+		var hops []string
+		for _, forwarded := range r.Header.Values("X-Forwarded-For") {
+			hops = append(hops, strings.Split(forwarded, ",")...)
+		}
+		if len(hops) >= ca.TrustedProxies {
+			return strings.TrimSpace(hops[len(hops)-ca.TrustedProxies])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func (ca *CallbackAuth) reject(
+	w http.ResponseWriter,
+	r *http.Request,
+	body []byte,
+	reason string,
+	statusCode int,
+	cause error,
+) {
+	ctx := r.Context()
+	if ca.Service != nil {
+		ca.Service.Log(ctx).WithError(cause).
+			WithField("path", r.URL.Path).
+			WithField("remote_ip", ca.remoteIP(r)).
+			WithField("reason", reason).
+			Warn("rejected callback")
+	}
+
+	if suppressed, record := ca.allowRecord(time.Now()); ca.Recorder != nil && record {
+		hashed := sha256.Sum256(body)
+		rejection := &models.CallbackRejection{
+			Path:       r.URL.Path,
+			RemoteIP:   ca.remoteIP(r),
+			Reason:     reason,
+			Headers:    RedactHeaders(r.Header),
+			Body:       excerpt(body),
+			BodySHA256: hex.EncodeToString(hashed[:]),
+			BodySize:   len(body),
+			Suppressed: suppressed,
+		}
+		if err := ca.Recorder.Save(ctx, rejection); err != nil && ca.Service != nil {
+			ca.Service.Log(ctx).WithError(err).Error("could not record rejected callback")
+		}
+	}
+
+	http.Error(w, http.StatusText(statusCode), statusCode)
+}
+
+// allowRecord reports whether a rejection may be recorded in the current window, along with
+// the number of rejections left unrecorded since the last one that was.
+func (ca *CallbackAuth) allowRecord(now time.Time) (int, bool) {
+	limit := ca.RejectionLimit
+	if limit <= 0 {
+		limit = defaultRejectionLimit
+	}
+	window := ca.RejectionWindow
+	if window <= 0 {
+		window = defaultRejectionWindow
+	}
+
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	if now.Sub(ca.windowStart) >= window {
+		ca.windowStart = now
+		ca.recorded = 0
+	}
+	if ca.recorded >= limit {
+		ca.suppressed++
+		return 0, false
+	}
+	ca.recorded++
+	suppressed := ca.suppressed
+	ca.suppressed = 0
+	return suppressed, true
+}
+
+// excerpt returns the start of a rejected body as text postgres can store.
+func excerpt(body []byte) string {
+	if len(body) > maxRejectionExcerptBytes {
+		body = body[:maxRejectionExcerptBytes]
+	}
+	return strings.ReplaceAll(strings.ToValidUTF8(string(body), ""), "\x00", "")
+}
+
+// RedactHeaders flattens request headers for storage, leaving out credentials so they never
+// end up in a table operators can read.
+func RedactHeaders(header http.Header) map[string]any {
+	headers := make(map[string]any, len(header))
+	for key, values := range header {
+		if sensitiveHeader(key) {
+			continue
+		}
+		headers[key] = strings.Join(values, ",")
+	}
+	return headers
+}
+
+func sensitiveHeader(key string) bool {
+	switch http.CanonicalHeaderKey(key) {
+	case "Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", SharedSecretHeader:
+		return true
+	}
+	name := strings.ToLower(key)
+	for _, marker := range []string{"secret", "token", "password", "api-key", "apikey"} {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func secureCompare(given, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(given), []byte(expected)) == 1
+}
+
+// ParseAllowedNets turns a list of IPs or CIDR ranges into networks for the allow-list.
+func ParseAllowedNets(entries []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			if strings.Contains(entry, ":") {
+				entry += "/128"
+			} else {
+				entry += "/32"
+			}
+		}
+
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed callback source %q: %w", entry, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// LoadPublicKey reads an RSA public key from a PEM encoded key or certificate file.
+func LoadPublicKey(path string) (*rsa.PublicKey, error) {
+	keyBytes, err := os.ReadFile(path) //nolint:gosec // path comes from service configuration
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	return ParsePublicKey(keyBytes)
+}
+
+// ParsePublicKey parses a PEM encoded PKIX or PKCS1 public key, or a certificate.
+func ParsePublicKey(keyBytes []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(keyBytes)
+	if block == nil {
+		return nil, errors.New("failed to decode public key PEM")
+	}
+
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, errors.New("certificate does not hold an RSA public key")
+		}
+		return publicKey, nil
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, errors.New("key is not an RSA public key")
+		}
+		return publicKey, nil
+	}
+}
@@ -0,0 +1,289 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	rejections []*models.CallbackRejection
+}
+
+func (r *recorder) Save(_ context.Context, rejection *models.CallbackRejection) error {
+	r.rejections = append(r.rejections, rejection)
+	return nil
+}
+
+func sign(t *testing.T, key *rsa.PrivateKey, body string) string {
+	hashed := sha256.Sum256([]byte(body))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func TestCallbackAuthMiddleware(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	allowedNets, err := ParseAllowedNets([]string{"196.201.214.0/24", "10.0.0.7"})
+	require.NoError(t, err)
+
+	body := `{"transactionReference":"ABCDE1"}`
+
+	tests := []struct {
+		name           string
+		remoteAddr     string
+		setup          func(r *http.Request)
+		expectedStatus int
+		expectedReason string
+	}{
+		{
+			name:       "Happy path - basic auth and valid signature",
+			remoteAddr: "196.201.214.10:4000",
+			setup: func(r *http.Request) {
+				r.SetBasicAuth("jenga", "secret")
+				r.Header.Set(SignatureHeader, sign(t, privateKey, body))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:       "Happy path - shared secret and valid signature",
+			remoteAddr: "10.0.0.7:4000",
+			setup: func(r *http.Request) {
+				r.Header.Set(SharedSecretHeader, "shared")
+				r.Header.Set(SignatureHeader, sign(t, privateKey, body))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:       "Error - source IP not allowed",
+			remoteAddr: "8.8.8.8:4000",
+			setup: func(r *http.Request) {
+				r.SetBasicAuth("jenga", "secret")
+				r.Header.Set(SignatureHeader, sign(t, privateKey, body))
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedReason: RejectedSourceIP,
+		},
+		{
+			name:       "Error - wrong password",
+			remoteAddr: "196.201.214.10:4000",
+			setup: func(r *http.Request) {
+				r.SetBasicAuth("jenga", "guess")
+				r.Header.Set(SignatureHeader, sign(t, privateKey, body))
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedReason: RejectedCredentials,
+		},
+		{
+			name:       "Error - signature over a different body",
+			remoteAddr: "196.201.214.10:4000",
+			setup: func(r *http.Request) {
+				r.SetBasicAuth("jenga", "secret")
+				r.Header.Set(SignatureHeader, sign(t, privateKey, `{"transactionReference":"OTHER"}`))
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedReason: RejectedSignature,
+		},
+		{
+			name:       "Error - missing signature",
+			remoteAddr: "196.201.214.10:4000",
+			setup: func(r *http.Request) {
+				r.SetBasicAuth("jenga", "secret")
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedReason: RejectedSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{}
+			auth := &CallbackAuth{
+				Username:     "jenga",
+				Password:     "secret",
+				SharedSecret: "shared",
+				PublicKey:    &privateKey.PublicKey,
+				AllowedNets:  allowedNets,
+				Recorder:     rec,
+			}
+
+			var received string
+			handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, readErr := io.ReadAll(r.Body)
+				assert.NoError(t, readErr)
+				received = string(data)
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/receivepayments", strings.NewReader(body))
+			req.RemoteAddr = tt.remoteAddr
+			tt.setup(req)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedReason == "" {
+				assert.Equal(t, body, received, "handler should still be able to read the body")
+				assert.Empty(t, rec.rejections)
+				return
+			}
+
+			require.Len(t, rec.rejections, 1)
+			assert.Equal(t, tt.expectedReason, rec.rejections[0].Reason)
+			assert.Equal(t, body, rec.rejections[0].Body)
+			assert.Equal(t, len(body), rec.rejections[0].BodySize)
+			assert.NotContains(t, rec.rejections[0].Headers, "Authorization")
+		})
+	}
+}
+
+func TestCallbackAuthSourceBehindProxies(t *testing.T) {
+	allowedNets, err := ParseAllowedNets([]string{"196.201.214.0/24"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		trustedProxies int
+		forwardedFor   []string
+		expectedStatus int
+	}{
+		{name: "address appended by the proxy", trustedProxies: 1,
+			forwardedFor: []string{"196.201.214.10"}, expectedStatus: http.StatusOK},
+		{name: "spoofed leftmost entry is ignored", trustedProxies: 1,
+			forwardedFor: []string{"196.201.214.10, 8.8.8.8"}, expectedStatus: http.StatusForbidden},
+		{name: "address two hops from the right", trustedProxies: 2,
+			forwardedFor: []string{"8.8.8.8, 196.201.214.10", "10.0.0.1"}, expectedStatus: http.StatusOK},
+		{name: "header ignored without trusted proxies",
+			forwardedFor: []string{"196.201.214.10"}, expectedStatus: http.StatusForbidden},
+		{name: "too few hops falls back to the peer", trustedProxies: 2,
+			forwardedFor: []string{"196.201.214.10"}, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &CallbackAuth{AllowedNets: allowedNets, TrustedProxies: tt.trustedProxies}
+			handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/callbacks/stk", strings.NewReader("{}"))
+			req.RemoteAddr = "10.0.0.2:4000"
+			for _, forwarded := range tt.forwardedFor {
+				req.Header.Add("X-Forwarded-For", forwarded)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+		})
+	}
+}
+
+func TestCallbackAuthRequiresConfiguration(t *testing.T) {
+	require.ErrorIs(t, (&CallbackAuth{}).Validate(), ErrUnauthenticatedCallbacks)
+	require.Error(t, (&CallbackAuth{Username: "jenga"}).Validate())
+	require.NoError(t, (&CallbackAuth{SharedSecret: "shared"}).Validate())
+
+	rec := &recorder{}
+	auth := &CallbackAuth{Recorder: rec}
+	handler := auth.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Fatal("unconfigured callback auth let a request through")
+	}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/callbacks/stk", strings.NewReader("{}")))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	require.Len(t, rec.rejections, 1)
+}
+
+func TestCallbackAuthRejectsOversizedBody(t *testing.T) {
+	rec := &recorder{}
+	auth := &CallbackAuth{SharedSecret: "shared", Recorder: rec}
+	handler := auth.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Fatal("oversized callback reached the handler")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/callbacks/stk",
+		strings.NewReader(strings.Repeat("x", maxCallbackBodyBytes+1)))
+	req.Header.Set(SharedSecretHeader, "shared")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	require.Len(t, rec.rejections, 1)
+	assert.Equal(t, RejectedOversizedBody, rec.rejections[0].Reason)
+}
+
+func TestCallbackAuthRecordsExcerpts(t *testing.T) {
+	rec := &recorder{}
+	auth := &CallbackAuth{SharedSecret: "shared", Recorder: rec}
+	handler := auth.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Fatal("unauthenticated callback reached the handler")
+	}))
+
+	body := strings.Repeat("junk", maxCallbackBodyBytes/8)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/callbacks/stk", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	require.Len(t, rec.rejections, 1)
+	rejection := rec.rejections[0]
+	assert.Len(t, rejection.Body, maxRejectionExcerptBytes)
+	assert.True(t, strings.HasPrefix(body, rejection.Body))
+	assert.Equal(t, len(body), rejection.BodySize)
+	hashed := sha256.Sum256([]byte(body))
+	assert.Equal(t, hex.EncodeToString(hashed[:]), rejection.BodySHA256)
+}
+
+func TestCallbackAuthLimitsRecordedRejections(t *testing.T) {
+	rec := &recorder{}
+	auth := &CallbackAuth{SharedSecret: "shared", Recorder: rec, RejectionLimit: 2, RejectionWindow: time.Hour}
+	handler := auth.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Fatal("unauthenticated callback reached the handler")
+	}))
+
+	for range 5 {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/callbacks/stk", strings.NewReader("{}")))
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	}
+	assert.Len(t, rec.rejections, 2)
+
+	// The next window records again and counts what was left out
+	suppressed, record := auth.allowRecord(time.Now().Add(time.Hour))
+	assert.True(t, record)
+	assert.Equal(t, 3, suppressed)
+}
+
+func TestParsePublicKey(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+
+	publicKey, err := ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	require.NoError(t, err)
+	assert.True(t, publicKey.Equal(&privateKey.PublicKey))
+
+	_, err = ParsePublicKey([]byte("not a key"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrUnsupportedCurrency is returned for currencies without known minor-unit rules.
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	// ErrInexactAmount is returned for amounts finer than the currency's minor unit.
+	ErrInexactAmount = errors.New("amount cannot be represented exactly in the currency")
+)
+
+// minorUnits is the number of decimal places each currency Jenga settles in is quoted with.
+var minorUnits = map[string]int32{
+	"KES": 2,
+	"UGX": 0,
+	"TZS": 2,
+	"RWF": 0,
+	"USD": 2,
+}
+
+// MinorUnits returns the number of decimal places of a currency.
+func MinorUnits(currency string) (int32, error) {
+	places, ok := minorUnits[strings.ToUpper(currency)]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnsupportedCurrency, currency)
+	}
+	return places, nil
+}
+
+// Amount is an exact monetary amount exchanged with Jenga. It decodes JSON numbers and
+// strings without passing through a float and encodes as a JSON number.
+type Amount struct {
+	decimal.Decimal
+}
+
+// NewAmount wraps a decimal as an Amount.
+func NewAmount(value decimal.Decimal) Amount {
+	return Amount{Decimal: value}
+}
+
+// ParseAmount parses the textual amount of a request and checks it against the currency.
+func ParseAmount(currency, value string) (Amount, error) {
+	parsed, err := decimal.NewFromString(value)
+	if err != nil {
+		return Amount{}, fmt.Errorf("invalid amount %q: %w", value, err)
+	}
+	amount := NewAmount(parsed)
+	if _, err := amount.Exact(currency); err != nil {
+		return Amount{}, err
+	}
+	return amount, nil
+}
+
+// Exact formats the amount with exactly the currency's minor units, amounts that would have to
+// be rounded to fit are rejected.
+func (a Amount) Exact(currency string) (string, error) {
+	places, err := MinorUnits(currency)
+	if err != nil {
+		return "", err
+	}
+	if !a.Round(places).Equal(a.Decimal) {
+		return "", fmt.Errorf("%w: %s %s", ErrInexactAmount, a.String(), strings.ToUpper(currency))
+	}
+	return a.StringFixed(places), nil
+}
+
+// RoundTo rounds the amount half to even to the currency's minor units, for values such as
+// charges that Jenga may compute at a finer precision. Unknown currencies are left untouched.
+func (a Amount) RoundTo(currency string) Amount {
+	places, err := MinorUnits(currency)
+	if err != nil {
+		return a
+	}
+	return NewAmount(a.RoundBank(places))
+}
+
+// MarshalJSON encodes the amount as a JSON number.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts JSON numbers and strings, null leaves the amount at zero.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	return a.Decimal.UnmarshalJSON(data)
+}
@@ -0,0 +1,77 @@
+package models_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAmountExact(t *testing.T) {
+	tests := []struct {
+		currency string
+		amount   string
+		want     string
+		wantErr  error
+	}{
+		{currency: "KES", amount: "150", want: "150.00"},
+		{currency: "KES", amount: "0.1", want: "0.10"},
+		{currency: "kes", amount: "99.99", want: "99.99"},
+		{currency: "KES", amount: "10.005", wantErr: models.ErrInexactAmount},
+		{currency: "USD", amount: "12.5", want: "12.50"},
+		{currency: "TZS", amount: "2500.75", want: "2500.75"},
+		{currency: "UGX", amount: "5000", want: "5000"},
+		{currency: "UGX", amount: "5000.50", wantErr: models.ErrInexactAmount},
+		{currency: "RWF", amount: "1000.00", want: "1000"},
+		{currency: "EUR", amount: "10", wantErr: models.ErrUnsupportedCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.currency+" "+tt.amount, func(t *testing.T) {
+			got, err := models.NewAmount(decimal.RequireFromString(tt.amount)).Exact(tt.currency)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAmountRoundTo(t *testing.T) {
+	charge := models.NewAmount(decimal.RequireFromString("10.125"))
+	assert.Equal(t, "10.12", charge.RoundTo("KES").String())
+	assert.Equal(t, "10", charge.RoundTo("UGX").String())
+	assert.Equal(t, "10.125", charge.RoundTo("EUR").String())
+}
+
+func TestParseAmount(t *testing.T) {
+	amount, err := models.ParseAmount("KES", "250.50")
+	require.NoError(t, err)
+	assert.Equal(t, "250.5", amount.String())
+
+	_, err = models.ParseAmount("KES", "250.505")
+	require.ErrorIs(t, err, models.ErrInexactAmount)
+
+	_, err = models.ParseAmount("KES", "two hundred")
+	require.Error(t, err)
+}
+
+func TestAmountJSON(t *testing.T) {
+	var callback models.StkCallback
+	require.NoError(t, json.Unmarshal(
+		[]byte(`{"requestAmount":9007199254740993.01,"debitedAmount":"0.10","charge":null}`), &callback))
+
+	// Neither value survives a round trip through float64
+	assert.Equal(t, "9007199254740993.01", callback.RequestAmount.String())
+	assert.Equal(t, "0.1", callback.DebitedAmount.String())
+	assert.True(t, callback.Charge.IsZero())
+
+	encoded, err := json.Marshal(models.PaymentLinkDetails{Amount: callback.RequestAmount})
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"amount":9007199254740993.01`)
+}
@@ -1,18 +1,31 @@
 package models
 
+// CallbackPayload is implemented by every callback Jenga delivers, the reference is used to
+// recognise repeated deliveries of the same callback.
+type CallbackPayload interface {
+	CallbackReference() string
+}
+
+// StkCallback is the outcome of an STK/USSD push.
 type StkCallback struct {
-	Status        bool    `json:"status"`
-	Code          int     `json:"code"`
-	Message       string  `json:"message"`
-	Transaction   string  `json:"transactionReference"`
-	Telco         string  `json:"telcoReference"`
-	MobileNumber  string  `json:"mobileNumber"`
-	Currency      string  `json:"currency"`
-	RequestAmount float64 `json:"requestAmount"`
-	DebitedAmount float64 `json:"debitedAmount"`
-	Charge        float64 `json:"charge"`
-	TelcoName     string  `json:"telco"`
+	Status        bool   `json:"status"`
+	Code          int    `json:"code"`
+	Message       string `json:"message"`
+	Transaction   string `json:"transactionReference"`
+	Telco         string `json:"telcoReference"`
+	MobileNumber  string `json:"mobileNumber"`
+	Currency      string `json:"currency"`
+	RequestAmount Amount `json:"requestAmount"`
+	DebitedAmount Amount `json:"debitedAmount"`
+	Charge        Amount `json:"charge"`
+	TelcoName     string `json:"telco"`
+}
+
+func (c *StkCallback) CallbackReference() string {
+	return c.Transaction
 }
+
+// CallbackRequest is a bank instant payment notification (IPN).
 type CallbackRequest struct {
 	CallbackType string `json:"callbackType"`
 	Customer     struct {
@@ -21,19 +34,19 @@ type CallbackRequest struct {
 		Reference    string `json:"reference"`
 	} `json:"customer"`
 	Transaction struct {
-		Date           string  `json:"date"`
-		Reference      string  `json:"reference"`
-		PaymentMode    string  `json:"paymentMode"`
-		Amount         float64 `json:"amount"`
-		Currency       string  `json:"currency"`
-		BillNumber     string  `json:"billNumber"`
-		ServedBy       string  `json:"servedBy"`
-		AdditionalInfo string  `json:"additionalInfo"`
-		OrderAmount    float64 `json:"orderAmount"`
-		ServiceCharge  float64 `json:"serviceCharge"`
-		OrderCurrency  string  `json:"orderCurrency"`
-		Status         string  `json:"status"`
-		Remarks        string  `json:"remarks"`
+		Date           string `json:"date"`
+		Reference      string `json:"reference"`
+		PaymentMode    string `json:"paymentMode"`
+		Amount         Amount `json:"amount"`
+		Currency       string `json:"currency"`
+		BillNumber     string `json:"billNumber"`
+		ServedBy       string `json:"servedBy"`
+		AdditionalInfo string `json:"additionalInfo"`
+		OrderAmount    Amount `json:"orderAmount"`
+		ServiceCharge  Amount `json:"serviceCharge"`
+		OrderCurrency  string `json:"orderCurrency"`
+		Status         string `json:"status"`
+		Remarks        string `json:"remarks"`
 	} `json:"transaction"`
 	Bank struct {
 		Reference       string `json:"reference"`
@@ -41,3 +54,59 @@ type CallbackRequest struct {
 		Account         string `json:"account"`
 	} `json:"bank"`
 }
+
+func (c *CallbackRequest) CallbackReference() string {
+	return c.Transaction.Reference
+}
+
+// TillsCallback notifies a payment made to one of the merchant's tills.
+type TillsCallback struct {
+	CallbackType string `json:"callbackType"`
+	Till         struct {
+		Number string `json:"number"`
+		Name   string `json:"name"`
+	} `json:"till"`
+	Customer struct {
+		Name         string `json:"name"`
+		MobileNumber string `json:"mobileNumber"`
+	} `json:"customer"`
+	Transaction struct {
+		Date          string `json:"date"`
+		Reference     string `json:"reference"`
+		PaymentMode   string `json:"paymentMode"`
+		Amount        Amount `json:"amount"`
+		Currency      string `json:"currency"`
+		ServiceCharge Amount `json:"serviceCharge"`
+		Status        string `json:"status"`
+		Remarks       string `json:"remarks"`
+	} `json:"transaction"`
+}
+
+func (c *TillsCallback) CallbackReference() string {
+	return c.Transaction.Reference
+}
+
+// PaymentLinkCallback notifies a payment made through a Jenga payment link.
+type PaymentLinkCallback struct {
+	CallbackType   string `json:"callbackType"`
+	PaymentLinkRef string `json:"paymentLinkRef"`
+	ExternalRef    string `json:"externalRef"`
+	Customer       struct {
+		Name         string `json:"name"`
+		MobileNumber string `json:"mobileNumber"`
+		Email        string `json:"email"`
+	} `json:"customer"`
+	Transaction struct {
+		Date          string `json:"date"`
+		Reference     string `json:"reference"`
+		PaymentMode   string `json:"paymentMode"`
+		Amount        Amount `json:"amount"`
+		Currency      string `json:"currency"`
+		ServiceCharge Amount `json:"serviceCharge"`
+		Status        string `json:"status"`
+	} `json:"transaction"`
+}
+
+func (c *PaymentLinkCallback) CallbackReference() string {
+	return c.Transaction.Reference
+}
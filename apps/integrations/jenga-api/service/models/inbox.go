@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/pitabwire/frame"
+	"gorm.io/datatypes"
+)
+
+// Processing states of a CallbackInbox entry.
+const (
+	InboxStateReceived   = "received"
+	InboxStateProcessing = "processing"
+	InboxStateProcessed  = "processed"
+	InboxStateFailed     = "failed"
+)
+
+// CallbackInbox keeps the raw callback exactly as Jenga delivered it so that processing
+// can happen asynchronously, be retried and be replayed after fixes.
+type CallbackInbox struct {
+	frame.BaseModel
+	Kind          string            `gorm:"type:varchar(50);uniqueIndex:idx_callback_inbox_kind_reference"`
+	Reference     string            `gorm:"type:varchar(100);uniqueIndex:idx_callback_inbox_kind_reference"`
+	Path          string            `gorm:"type:varchar(255)"`
+	Headers       datatypes.JSONMap `gorm:"type:jsonb"`
+	Body          string            `gorm:"type:text"`
+	ReceivedAt    time.Time
+	State         string `gorm:"type:varchar(20);index"`
+	Attempts      int
+	LastError     string `gorm:"type:text"`
+	NextAttemptAt *time.Time
+	ProcessedAt   *time.Time
+}
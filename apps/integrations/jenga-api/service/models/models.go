@@ -65,6 +65,8 @@ type Payment struct {
 type Biller struct {
 	BillerCode  string `json:"billerCode"`
 	CountryCode string `json:"countryCode"`
+	Name        string `json:"name,omitempty"`
+	Category    string `json:"category,omitempty"`
 }
 
 type Bill struct {
@@ -105,11 +107,46 @@ type BalanceResponse struct {
 	} `json:"data"`
 }
 
-// FetchBillersRequest represents the request structure for fetching billers
-// It can be extended with additional fields if needed
-
+// FetchBillersRequest represents the request structure for fetching billers.
+// The fields are sent as query parameters when paging through the catalogue.
 type FetchBillersRequest struct {
-	// Add fields if necessary
+	Page     int    `json:"page"`
+	PerPage  int    `json:"per_page"`
+	Category string `json:"category,omitempty"`
+}
+
+// BillersResponse represents the response structure for the billers catalogue.
+type BillersResponse struct {
+	Status  bool   `json:"status"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Billers    []Biller `json:"billers"`
+		PageNumber int      `json:"pageNumber"`
+		TotalPages int      `json:"totalPages"`
+	} `json:"data"`
+}
+
+// BillValidationRequest represents the request structure for validating a bill reference with the biller.
+type BillValidationRequest struct {
+	BillerCode        string `json:"billerCode"`
+	CustomerRefNumber string `json:"customerRefNumber"`
+	Amount            string `json:"amount"`
+	AmountCurrency    string `json:"amountCurrency"`
+}
+
+// BillValidationResponse represents the response structure for a bill validation.
+type BillValidationResponse struct {
+	Status  bool   `json:"status"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		CustomerName      string `json:"customerName"`
+		CustomerRefNumber string `json:"customerRefNumber"`
+		BillerCode        string `json:"billerCode"`
+		Amount            string `json:"amount"`
+		AmountCurrency    string `json:"amountCurrency"`
+	} `json:"data"`
 }
 
 // TillsPayRequest represents the request structure for the tills/pay endpoint.
@@ -128,6 +165,12 @@ type TillsPayResponse struct {
 	Message       string `json:"message"`
 }
 
+// TillsPayJob carries a till payment together with the payment id it is tracked under.
+type TillsPayJob struct {
+	ID      string          `json:"id"`
+	Request TillsPayRequest `json:"request"`
+}
+
 type TillsPayMerchant struct {
 	Till string `json:"till"`
 }
@@ -204,18 +247,18 @@ type PaymentLinkCustomer struct {
 }
 
 type PaymentLinkDetails struct {
-	ExpiryDate      string  `json:"expiryDate"`
-	SaleDate        string  `json:"saleDate"`
-	PaymentLinkType string  `json:"paymentLinkType"`
-	SaleType        string  `json:"saleType"`
-	Name            string  `json:"name"`
-	Description     string  `json:"description"`
-	ExternalRef     string  `json:"externalRef"`
-	PaymentLinkRef  string  `json:"paymentLinkRef,omitempty"`
-	RedirectURL     string  `json:"redirectURL,omitempty"`
-	AmountOption    string  `json:"amountOption"`
-	Amount          float64 `json:"amount"`
-	Currency        string  `json:"currency"`
+	ExpiryDate      string `json:"expiryDate"`
+	SaleDate        string `json:"saleDate"`
+	PaymentLinkType string `json:"paymentLinkType"`
+	SaleType        string `json:"saleType"`
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	ExternalRef     string `json:"externalRef"`
+	PaymentLinkRef  string `json:"paymentLinkRef,omitempty"`
+	RedirectURL     string `json:"redirectURL,omitempty"`
+	AmountOption    string `json:"amountOption"`
+	Amount          Amount `json:"amount"`
+	Currency        string `json:"currency"`
 }
 
 type PaymentLinkRequest struct {
@@ -236,8 +279,174 @@ type PaymentLinkResponseData struct {
 	DateCreated    int64  `json:"dateCreated"`
 	PaymentLinkRef string `json:"paymentLinkRef"`
 	ExternalRef    string `json:"externalRef"`
+	URL            string `json:"url,omitempty"`
 	Status         struct {
 		Code string `json:"code"`
 		Name string `json:"name"`
 	} `json:"status"`
 }
+
+// PaymentLinkChange is published by the payment service when a link changes after creation.
+type PaymentLinkChange struct {
+	Action      string       `json:"action"`
+	PaymentLink *PaymentLink `json:"paymentLink"`
+}
+
+// Payment link change actions.
+const (
+	PaymentLinkActionUpdate     = "update"
+	PaymentLinkActionDeactivate = "deactivate"
+)
+
+// PaymentLinkUpdateRequest represents the request body for updating a Jenga payment link.
+type PaymentLinkUpdateRequest struct {
+	PaymentLinkRef string  `json:"paymentLinkRef"`
+	ExpiryDate     string  `json:"expiryDate,omitempty"`
+	Name           string  `json:"name,omitempty"`
+	Description    string  `json:"description,omitempty"`
+	RedirectURL    string  `json:"redirectURL,omitempty"`
+	Amount         *Amount `json:"amount,omitempty"`
+	Status         string  `json:"status,omitempty"`
+}
+
+// PaymentLinkPaymentsResponse lists the payments made against a Jenga payment link.
+type PaymentLinkPaymentsResponse struct {
+	Status  bool                  `json:"status"`
+	Code    int                   `json:"code"`
+	Message string                `json:"message"`
+	Data    []PaymentLinkCallback `json:"data"`
+}
+
+// AccountInquiryResponse represents the response structure for a Jenga account inquiry.
+type AccountInquiryResponse struct {
+	Status  bool   `json:"status"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Account struct {
+			BranchCode string `json:"branchCode"`
+			Number     string `json:"number"`
+			Currency   string `json:"currency"`
+			Status     string `json:"status"`
+		} `json:"account"`
+		Customer []struct {
+			Name string `json:"name"`
+			ID   string `json:"id"`
+			Type string `json:"type"`
+		} `json:"customer"`
+	} `json:"data"`
+}
+
+// MobileWalletLookupRequest represents the request structure for a mobile wallet name lookup.
+type MobileWalletLookupRequest struct {
+	CountryCode  string `json:"countryCode"`
+	MobileNumber string `json:"mobileNumber"`
+	Wallet       string `json:"wallet"`
+}
+
+// MobileWalletLookupResponse represents the response structure for a mobile wallet name lookup.
+type MobileWalletLookupResponse struct {
+	Status  bool   `json:"status"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Name         string `json:"name"`
+		MobileNumber string `json:"mobileNumber"`
+		Wallet       string `json:"wallet"`
+	} `json:"data"`
+}
+
+// KYCLookupRequest is the body accepted by the beneficiary lookup endpoint.
+type KYCLookupRequest struct {
+	Type          string `json:"type"`
+	CountryCode   string `json:"countryCode"`
+	AccountNumber string `json:"accountNumber,omitempty"`
+	MobileNumber  string `json:"mobileNumber,omitempty"`
+	Wallet        string `json:"wallet,omitempty"`
+}
+
+// KYCLookupResponse is returned by the beneficiary lookup endpoint.
+type KYCLookupResponse struct {
+	Status    string `json:"status"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Reference string `json:"reference"`
+	Message   string `json:"message,omitempty"`
+}
+
+// TransactionStatusResponse represents the response structure for a Jenga transaction status query.
+type TransactionStatusResponse struct {
+	Status  bool   `json:"status"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		TransactionReference string `json:"transactionReference"`
+		TelcoReference       string `json:"telcoReference"`
+		State                string `json:"state"`
+		StateDescription     string `json:"stateDescription"`
+		Amount               Amount `json:"amount"`
+		Charge               Amount `json:"charge"`
+		Currency             string `json:"currency"`
+		PostedDateTime       string `json:"postedDateTime"`
+	} `json:"data"`
+}
+
+// Payout is the outbound payment message the payment service publishes once a payment has
+// been released, screened and routed to Jenga.
+type Payout struct {
+	frame.BaseModel
+	RecipientProfileID string              `gorm:"type:varchar(250)"`
+	RecipientContactID string              `gorm:"type:varchar(50)"`
+	RecipientName      string              `gorm:"type:varchar(250)"`
+	RecipientMSISDN    string              `gorm:"type:varchar(20)"`
+	Amount             decimal.NullDecimal `gorm:"type:numeric"                          json:"amount"`
+	ReferenceID        string              `gorm:"type:varchar(50)"`
+	RouteID            string              `gorm:"type:varchar(50)"`
+	Currency           string              `gorm:"type:varchar(10)"`
+	PaymentType        string              `gorm:"type:varchar(50)"`
+	OutBound           bool
+	Extra              datatypes.JSONMap `gorm:"index:,type:gin;option:jsonb_path_ops" json:"extra"`
+}
+
+// SendMoneyRequest represents the request structure for sending money to a mobile wallet.
+type SendMoneyRequest struct {
+	Source      SendMoneySource      `json:"source"`
+	Destination SendMoneyDestination `json:"destination"`
+	Transfer    SendMoneyTransfer    `json:"transfer"`
+}
+
+type SendMoneySource struct {
+	CountryCode   string `json:"countryCode"`
+	Name          string `json:"name"`
+	AccountNumber string `json:"accountNumber"`
+}
+
+type SendMoneyDestination struct {
+	Type         string `json:"type"`
+	CountryCode  string `json:"countryCode"`
+	Name         string `json:"name"`
+	MobileNumber string `json:"mobileNumber"`
+	WalletName   string `json:"walletName"`
+}
+
+type SendMoneyTransfer struct {
+	Type         string `json:"type"`
+	Amount       string `json:"amount"`
+	CurrencyCode string `json:"currencyCode"`
+	Reference    string `json:"reference"`
+	Date         string `json:"date"`
+	Description  string `json:"description"`
+	CallbackURL  string `json:"callbackUrl,omitempty"`
+}
+
+// SendMoneyResponse represents the response structure for a send money request.
+type SendMoneyResponse struct {
+	Status    bool   `json:"status"`
+	Code      int    `json:"code"`
+	Message   string `json:"message"`
+	Reference string `json:"reference"`
+	Data      struct {
+		TransactionID string `json:"transactionId"`
+		Status        string `json:"status"`
+	} `json:"data"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/pitabwire/frame"
+)
+
+// Progress of a PaymentJob.
+const (
+	// PaymentJobStateNew jobs have not registered their payment with the payment service yet.
+	PaymentJobStateNew = "new"
+	// PaymentJobStateRegistered jobs have a payment but have not asked Jenga to pay.
+	PaymentJobStateRegistered = "registered"
+	// PaymentJobStateSubmitted jobs were sent to Jenga without a definite answer, they are
+	// resolved by querying Jenga and are never sent again.
+	PaymentJobStateSubmitted = "submitted"
+	PaymentJobStatePaid      = "paid"
+	PaymentJobStateFailed    = "failed"
+)
+
+// PaymentJob records how far a payment made through Jenga got, so that a redelivered job
+// carries on from there instead of paying a second time.
+type PaymentJob struct {
+	frame.BaseModel
+	JobID          string `gorm:"type:varchar(50);uniqueIndex"`
+	Kind           string `gorm:"type:varchar(20)"`
+	PaymentID      string `gorm:"type:varchar(50)"`
+	TransactionRef string `gorm:"type:varchar(100)"`
+	State          string `gorm:"type:varchar(20);index"`
+	TransactionID  string `gorm:"type:varchar(100)"`
+	LastError      string `gorm:"type:text"`
+	SubmittedAt    *time.Time
+}
+
+// Settled reports whether the job reached its final outcome.
+func (job *PaymentJob) Settled() bool {
+	return job.State == PaymentJobStatePaid || job.State == PaymentJobStateFailed
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"github.com/pitabwire/frame"
+	"gorm.io/datatypes"
+)
+
+// CallbackRejection records a callback that failed authentication so it can be reviewed later.
+type CallbackRejection struct {
+	frame.BaseModel
+	Path     string            `gorm:"type:varchar(255)"`
+	RemoteIP string            `gorm:"type:varchar(64);index"`
+	Reason   string            `gorm:"type:varchar(100);index"`
+	Headers  datatypes.JSONMap `gorm:"type:jsonb"`
+	// Body is an excerpt of the request body, the hash and size describe all of it.
+	Body       string `gorm:"type:text"`
+	BodySHA256 string `gorm:"type:varchar(64);index"`
+	BodySize   int
+	// Suppressed counts the rejections before this one that were not recorded.
+	Suppressed int
+}
@@ -0,0 +1,236 @@
+// Package paymentjob tracks payments made through Jenga from their registration with the
+// payment service to their outcome. Progress is recorded before Jenga is asked to pay, so a
+// redelivered job carries on from where it got to and a payment Jenga gave no definite
+// answer for is queried, never paid a second time.
+package paymentjob
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	commonv1 "github.com/antinvestor/apis/go/common/v1"
+	paymentV1 "github.com/antinvestor/apis/go/payment/v1"
+	"github.com/antinvestor/jenga-api/service/coreapi"
+	"github.com/antinvestor/jenga-api/service/events/events_stk"
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/antinvestor/jenga-api/service/repository"
+	"github.com/pitabwire/frame"
+)
+
+const (
+	entityTypePayment = "payment"
+
+	defaultStatusQueryDelay = 2 * time.Minute
+	statusQueryBatchSize    = 100
+)
+
+// Tracker records the progress of the payments of one kind and reports their status to the
+// payment service.
+type Tracker struct {
+	Service       *frame.Service
+	Client        coreapi.JengaApiClient
+	PaymentClient *paymentV1.PaymentClient
+	// Kind names the payments tracked, it is reported as the payment type of their statuses.
+	Kind string
+	// StatusQueryDelay is how long an unanswered payment is left before Jenga is queried for it.
+	StatusQueryDelay time.Duration
+	// Jobs stores the progress of each payment, the service datastore is used when it is nil.
+	Jobs repository.PaymentJobRepository
+}
+
+// Start records a new job, or returns the progress of a job that was seen before.
+func (t *Tracker) Start(ctx context.Context, jobID, transactionRef string) (*models.PaymentJob, error) {
+	progress := &models.PaymentJob{
+		JobID:          jobID,
+		Kind:           t.Kind,
+		TransactionRef: transactionRef,
+		State:          models.PaymentJobStateNew,
+	}
+	jobs := t.jobs(ctx)
+	if _, err := jobs.Create(ctx, progress); err != nil {
+		return nil, fmt.Errorf("record %s job: %w", t.Kind, err)
+	}
+	return jobs.GetByJobID(ctx, jobID)
+}
+
+// Registered records the id the payment service tracks the job's payment under.
+func (t *Tracker) Registered(ctx context.Context, progress *models.PaymentJob, paymentID string) error {
+	progress.PaymentID = paymentID
+	progress.State = models.PaymentJobStateRegistered
+	return t.jobs(ctx).Save(ctx, progress)
+}
+
+// Submit records that Jenga is about to be asked to pay, from here on the payment is never
+// sent again.
+func (t *Tracker) Submit(ctx context.Context, progress *models.PaymentJob) error {
+	submittedAt := time.Now()
+	progress.State = models.PaymentJobStateSubmitted
+	progress.SubmittedAt = &submittedAt
+	return t.jobs(ctx).Save(ctx, progress)
+}
+
+// Unconfirmed records a request that may have reached Jenga without an answer, its outcome
+// is queried instead of paying again.
+func (t *Tracker) Unconfirmed(ctx context.Context, progress *models.PaymentJob, err error) {
+	logger := t.Service.Log(ctx).WithField("kind", t.Kind).WithField("jobId", progress.JobID)
+	logger.WithError(err).Warn("payment outcome is unknown, it will be queried")
+
+	progress.LastError = err.Error()
+	if saveErr := t.jobs(ctx).Save(ctx, progress); saveErr != nil {
+		logger.WithError(saveErr).Warn("failed to record payment error")
+	}
+	if statusErr := t.UpdateStatus(ctx, progress.PaymentID, commonv1.STATUS_IN_PROCESS, map[string]string{
+		"message": "Awaiting confirmation from Jenga",
+		"error":   err.Error(),
+	}); statusErr != nil {
+		logger.WithError(statusErr).Warn("failed to report unconfirmed payment")
+	}
+}
+
+// Paid reports the payment successful and records it paid. A payment whose outcome could not
+// be reported is left submitted, its outcome is reported once it has been queried.
+func (t *Tracker) Paid(
+	ctx context.Context,
+	progress *models.PaymentJob,
+	transactionID string,
+	extras map[string]string,
+) error {
+	if err := t.UpdateStatus(ctx, progress.PaymentID, commonv1.STATUS_SUCCESSFUL, extras); err != nil {
+		return err
+	}
+
+	progress.State = models.PaymentJobStatePaid
+	progress.TransactionID = transactionID
+	return t.jobs(ctx).Save(ctx, progress)
+}
+
+// Fail fails the payment. A failure that could not be reported leaves the job as it was, so
+// a redelivery reports it again.
+func (t *Tracker) Fail(ctx context.Context, progress *models.PaymentJob, err error) error {
+	logger := t.Service.Log(ctx).WithField("kind", t.Kind).WithField("jobId", progress.JobID)
+	if updateErr := t.UpdateStatus(ctx, progress.PaymentID, commonv1.STATUS_FAILED, map[string]string{
+		"error": err.Error(),
+	}); updateErr != nil {
+		logger.WithError(updateErr).Error("failed to update payment status")
+		return err
+	}
+
+	progress.State = models.PaymentJobStateFailed
+	progress.LastError = err.Error()
+	if saveErr := t.jobs(ctx).Save(ctx, progress); saveErr != nil {
+		logger.WithError(saveErr).Error("failed to record failed payment")
+	}
+	return err
+}
+
+// Resolve queries Jenga for a submitted payment and reports its outcome once it is final.
+func (t *Tracker) Resolve(ctx context.Context, progress *models.PaymentJob) error {
+	logger := t.Service.Log(ctx).WithField("kind", t.Kind).
+		WithField("jobId", progress.JobID).
+		WithField("transactionRef", progress.TransactionRef)
+
+	token, err := t.Client.GenerateBearerToken()
+	if err != nil {
+		return fmt.Errorf("generate bearer token: %w", err)
+	}
+	response, err := t.Client.QueryTransactionStatus(progress.TransactionRef, token.AccessToken)
+	if err != nil {
+		return fmt.Errorf("query transaction status: %w", err)
+	}
+
+	status, resolution := events_stk.ResolveTransactionState(response)
+	if resolution == "" {
+		logger.WithField("state", response.Data.State).Debug("payment is still pending")
+		return nil
+	}
+
+	if err = t.UpdateStatus(ctx, progress.PaymentID, status, map[string]string{
+		"message":        response.Message,
+		"transaction_id": response.Data.TransactionReference,
+		"jenga_status":   response.Data.State,
+		"resolution":     resolution,
+		"resolved_by":    "status_query",
+	}); err != nil {
+		return err
+	}
+
+	progress.State = models.PaymentJobStatePaid
+	if status != commonv1.STATUS_SUCCESSFUL {
+		progress.State = models.PaymentJobStateFailed
+		progress.LastError = response.Data.StateDescription
+	}
+	progress.TransactionID = response.Data.TransactionReference
+	logger.WithField("state", progress.State).Info("payment resolved from transaction query")
+	return t.jobs(ctx).Save(ctx, progress)
+}
+
+// RunStatusQueries periodically queries Jenga for payments it gave no definite answer for.
+// It blocks until the context is cancelled and is meant to run as a background consumer.
+func (t *Tracker) RunStatusQueries(ctx context.Context) error {
+	ticker := time.NewTicker(t.statusQueryDelay())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			t.QueryUnconfirmed(ctx)
+		}
+	}
+}
+
+// QueryUnconfirmed resolves the payments that have waited for Jenga longer than the status
+// query delay.
+func (t *Tracker) QueryUnconfirmed(ctx context.Context) {
+	logger := t.Service.Log(ctx).WithField("type", t.Kind+".status.query")
+
+	submitted, err := t.jobs(ctx).ListSubmitted(ctx, t.Kind,
+		time.Now().Add(-t.statusQueryDelay()), statusQueryBatchSize)
+	if err != nil {
+		logger.WithError(err).Warn("could not list unconfirmed payments")
+		return
+	}
+	for _, progress := range submitted {
+		if err = t.Resolve(ctx, progress); err != nil {
+			logger.WithError(err).WithField("jobId", progress.JobID).Warn("could not resolve payment")
+		}
+	}
+}
+
+// UpdateStatus reports the status of a tracked payment to the payment service.
+func (t *Tracker) UpdateStatus(
+	ctx context.Context,
+	paymentID string,
+	status commonv1.STATUS,
+	extras map[string]string,
+) error {
+	extras["entity_type"] = entityTypePayment
+	extras["payment_type"] = t.Kind
+
+	_, err := t.PaymentClient.StatusUpdate(ctx, &commonv1.StatusUpdateRequest{
+		Id:     paymentID,
+		State:  commonv1.STATE_ACTIVE,
+		Status: status,
+		Extras: extras,
+	})
+	if err != nil {
+		return fmt.Errorf("payment client status update: %w", err)
+	}
+	return nil
+}
+
+func (t *Tracker) jobs(ctx context.Context) repository.PaymentJobRepository {
+	if t.Jobs != nil {
+		return t.Jobs
+	}
+	return repository.NewPaymentJobRepository(ctx, t.Service)
+}
+
+func (t *Tracker) statusQueryDelay() time.Duration {
+	if t.StatusQueryDelay > 0 {
+		return t.StatusQueryDelay
+	}
+	return defaultStatusQueryDelay
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/pitabwire/frame"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProcessingLeaseExpired is recorded against entries whose processing never completed.
+const ProcessingLeaseExpired = "processing lease expired"
+
+type CallbackInboxRepository interface {
+	GetByID(ctx context.Context, id string) (*models.CallbackInbox, error)
+	// Create stores a new entry and reports false when the callback was already received.
+	Create(ctx context.Context, entry *models.CallbackInbox) (bool, error)
+	Save(ctx context.Context, entry *models.CallbackInbox) error
+	// Claim marks an entry as processing until leaseUntil and counts the attempt. It reports
+	// false when the entry is processed, or is being processed under a lease that has not expired.
+	Claim(ctx context.Context, id string, now, leaseUntil time.Time) (bool, error)
+	// Reschedule moves the next attempt of a due entry to at, entries whose processing lease
+	// expired are marked failed so they can be claimed again. It reports false when the entry
+	// stopped being due, for instance because another worker claimed it.
+	Reschedule(ctx context.Context, id string, now, at time.Time) (bool, error)
+	// Requeue resets an entry in one of the given states for processing from scratch. It
+	// reports false when the entry is in another state or is being processed under a lease.
+	Requeue(ctx context.Context, id string, states []string, now time.Time) (bool, error)
+	ListDue(ctx context.Context, now time.Time, maxAttempts int, limit int) ([]*models.CallbackInbox, error)
+	ListByState(ctx context.Context, state string, limit int) ([]*models.CallbackInbox, error)
+}
+
+type callbackInboxRepository struct {
+	abstractRepository
+}
+
+func NewCallbackInboxRepository(_ context.Context, service *frame.Service) CallbackInboxRepository {
+	return &callbackInboxRepository{abstractRepository{service: service}}
+}
+
+func (repo *callbackInboxRepository) GetByID(ctx context.Context, id string) (*models.CallbackInbox, error) {
+	entry := models.CallbackInbox{}
+	err := repo.readDB(ctx).First(&entry, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (repo *callbackInboxRepository) Create(ctx context.Context, entry *models.CallbackInbox) (bool, error) {
+	if entry.GetID() == "" {
+		entry.GenID(ctx)
+	}
+	result := repo.writeDB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(entry)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (repo *callbackInboxRepository) Save(ctx context.Context, entry *models.CallbackInbox) error {
+	return repo.writeDB(ctx).Save(entry).Error
+}
+
+func (repo *callbackInboxRepository) Claim(ctx context.Context, id string, now, leaseUntil time.Time) (bool, error) {
+	result := repo.writeDB(ctx).Model(&models.CallbackInbox{}).
+		Where("id = ?", id).
+		Where("state IN ? OR (state = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?))",
+			[]string{models.InboxStateReceived, models.InboxStateFailed}, models.InboxStateProcessing, now).
+		Updates(map[string]any{
+			"state":           models.InboxStateProcessing,
+			"attempts":        gorm.Expr("attempts + 1"),
+			"next_attempt_at": leaseUntil,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (repo *callbackInboxRepository) Reschedule(ctx context.Context, id string, now, at time.Time) (bool, error) {
+	result := repo.writeDB(ctx).Model(&models.CallbackInbox{}).
+		Where("id = ? AND state <> ?", id, models.InboxStateProcessed).
+		Where("next_attempt_at IS NULL OR next_attempt_at <= ?", now).
+		Updates(map[string]any{
+			"state": gorm.Expr("CASE WHEN state = ? THEN ? ELSE state END",
+				models.InboxStateProcessing, models.InboxStateFailed),
+			"last_error": gorm.Expr("CASE WHEN state = ? THEN ? ELSE last_error END",
+				models.InboxStateProcessing, ProcessingLeaseExpired),
+			"next_attempt_at": at,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (repo *callbackInboxRepository) Requeue(
+	ctx context.Context,
+	id string,
+	states []string,
+	now time.Time,
+) (bool, error) {
+	result := repo.writeDB(ctx).Model(&models.CallbackInbox{}).
+		Where("id = ? AND state IN ?", id, states).
+		Where("state <> ? OR next_attempt_at IS NULL OR next_attempt_at <= ?", models.InboxStateProcessing, now).
+		Updates(map[string]any{
+			"state":           models.InboxStateReceived,
+			"attempts":        0,
+			"last_error":      "",
+			"next_attempt_at": nil,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListDue returns failed entries whose retry backoff has elapsed, entries that were received
+// but never picked up and entries whose processing lease expired, for instance after a crash.
+func (repo *callbackInboxRepository) ListDue(
+	ctx context.Context,
+	now time.Time,
+	maxAttempts int,
+	limit int,
+) ([]*models.CallbackInbox, error) {
+	var entries []*models.CallbackInbox
+	err := repo.readDB(ctx).
+		Where("(state IN ? AND attempts < ?) OR state = ?",
+			[]string{models.InboxStateReceived, models.InboxStateFailed}, maxAttempts, models.InboxStateProcessing).
+		Where("next_attempt_at IS NULL OR next_attempt_at <= ?", now).
+		Order("received_at").
+		Limit(limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (repo *callbackInboxRepository) ListByState(
+	ctx context.Context,
+	state string,
+	limit int,
+) ([]*models.CallbackInbox, error) {
+	var entries []*models.CallbackInbox
+	err := repo.readDB(ctx).Where("state = ?", state).Order("received_at").Limit(limit).Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
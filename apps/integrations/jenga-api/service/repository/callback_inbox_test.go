@@ -0,0 +1,72 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/antinvestor/jenga-api/service/repository"
+	"github.com/antinvestor/jenga-api/service/repository/repositorytest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallbackInboxLeases(t *testing.T) {
+	ctx, service := repositorytest.NewService(t)
+	inboxRepo := repository.NewCallbackInboxRepository(ctx, service)
+
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+	entries := map[string]*models.CallbackInbox{
+		"received":  {State: models.InboxStateReceived},
+		"failed":    {State: models.InboxStateFailed, Attempts: 1, NextAttemptAt: &past},
+		"backoff":   {State: models.InboxStateFailed, Attempts: 1, NextAttemptAt: &future},
+		"exhausted": {State: models.InboxStateFailed, Attempts: 5, NextAttemptAt: &past},
+		"abandoned": {State: models.InboxStateProcessing, Attempts: 1, NextAttemptAt: &past},
+		"leased":    {State: models.InboxStateProcessing, Attempts: 1, NextAttemptAt: &future},
+		"processed": {State: models.InboxStateProcessed, Attempts: 1},
+	}
+	for reference, entry := range entries {
+		entry.Kind = "test"
+		entry.Reference = reference
+		entry.ReceivedAt = now
+		created, err := inboxRepo.Create(ctx, entry)
+		require.NoError(t, err)
+		require.True(t, created)
+	}
+
+	due, err := inboxRepo.ListDue(ctx, now, 5, 100)
+	require.NoError(t, err)
+	var references []string
+	for _, entry := range due {
+		references = append(references, entry.Reference)
+	}
+	assert.ElementsMatch(t, []string{"received", "failed", "abandoned"}, references)
+
+	for reference, wantClaimed := range map[string]bool{
+		"received": true, "failed": true, "abandoned": true, "leased": false, "processed": false,
+	} {
+		claimed, claimErr := inboxRepo.Claim(ctx, entries[reference].GetID(), now, future)
+		require.NoError(t, claimErr)
+		assert.Equal(t, wantClaimed, claimed, reference)
+	}
+	claimed, err := inboxRepo.GetByID(ctx, entries["abandoned"].GetID())
+	require.NoError(t, err)
+	assert.Equal(t, models.InboxStateProcessing, claimed.State)
+	assert.Equal(t, 2, claimed.Attempts)
+
+	// Once its lease runs out the claimed entry is released for another attempt
+	later := future.Add(time.Second)
+	rescheduled, err := inboxRepo.Reschedule(ctx, entries["abandoned"].GetID(), later, later.Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, rescheduled)
+	released, err := inboxRepo.GetByID(ctx, entries["abandoned"].GetID())
+	require.NoError(t, err)
+	assert.Equal(t, models.InboxStateFailed, released.State)
+	assert.Equal(t, repository.ProcessingLeaseExpired, released.LastError)
+
+	requeued, err := inboxRepo.Requeue(ctx, entries["processed"].GetID(),
+		[]string{models.InboxStateFailed, models.InboxStateReceived, models.InboxStateProcessing}, now)
+	require.NoError(t, err)
+	assert.False(t, requeued, "a processed callback was requeued")
+}
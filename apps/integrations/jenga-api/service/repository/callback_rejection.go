@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/pitabwire/frame"
+)
+
+type CallbackRejectionRepository interface {
+	Save(ctx context.Context, rejection *models.CallbackRejection) error
+	ListRecent(ctx context.Context, limit int) ([]*models.CallbackRejection, error)
+}
+
+type callbackRejectionRepository struct {
+	abstractRepository
+}
+
+func NewCallbackRejectionRepository(_ context.Context, service *frame.Service) CallbackRejectionRepository {
+	return &callbackRejectionRepository{abstractRepository{service: service}}
+}
+
+func (repo *callbackRejectionRepository) Save(ctx context.Context, rejection *models.CallbackRejection) error {
+	if rejection.GetID() == "" {
+		rejection.GenID(ctx)
+	}
+	return repo.writeDB(ctx).Create(rejection).Error
+}
+
+func (repo *callbackRejectionRepository) ListRecent(
+	ctx context.Context,
+	limit int,
+) ([]*models.CallbackRejection, error) {
+	var rejections []*models.CallbackRejection
+	err := repo.readDB(ctx).Order("created_at DESC").Limit(limit).Find(&rejections).Error
+	if err != nil {
+		return nil, err
+	}
+	return rejections, nil
+}
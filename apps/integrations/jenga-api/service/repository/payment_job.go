@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/pitabwire/frame"
+	"gorm.io/gorm/clause"
+)
+
+type PaymentJobRepository interface {
+	GetByJobID(ctx context.Context, jobID string) (*models.PaymentJob, error)
+	// Create stores a new job and reports false when one was already stored for its job id.
+	Create(ctx context.Context, job *models.PaymentJob) (bool, error)
+	Save(ctx context.Context, job *models.PaymentJob) error
+	// ListSubmitted returns jobs of a kind still waiting for Jenga that were submitted before the given time.
+	ListSubmitted(ctx context.Context, kind string, before time.Time, limit int) ([]*models.PaymentJob, error)
+}
+
+type paymentJobRepository struct {
+	abstractRepository
+}
+
+func NewPaymentJobRepository(_ context.Context, service *frame.Service) PaymentJobRepository {
+	return &paymentJobRepository{abstractRepository{service: service}}
+}
+
+func (repo *paymentJobRepository) GetByJobID(ctx context.Context, jobID string) (*models.PaymentJob, error) {
+	job := models.PaymentJob{}
+	// Read from the primary, a redelivered job must see the progress just recorded
+	err := repo.writeDB(ctx).First(&job, "job_id = ?", jobID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (repo *paymentJobRepository) Create(ctx context.Context, job *models.PaymentJob) (bool, error) {
+	if job.GetID() == "" {
+		job.GenID(ctx)
+	}
+	result := repo.writeDB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(job)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (repo *paymentJobRepository) Save(ctx context.Context, job *models.PaymentJob) error {
+	return repo.writeDB(ctx).Save(job).Error
+}
+
+func (repo *paymentJobRepository) ListSubmitted(
+	ctx context.Context,
+	kind string,
+	before time.Time,
+	limit int,
+) ([]*models.PaymentJob, error) {
+	var jobs []*models.PaymentJob
+	err := repo.readDB(ctx).
+		Where("kind = ? AND state = ? AND submitted_at <= ?", kind, models.PaymentJobStateSubmitted, before).
+		Order("submitted_at").
+		Limit(limit).
+		Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
@@ -0,0 +1,165 @@
+// Package repositorytest provides repositories for tests, kept in memory or in a throwaway
+// database.
+package repositorytest
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/antinvestor/jenga-api/service/repository"
+	"gorm.io/gorm"
+)
+
+// CallbackInbox keeps inbox entries in memory and applies the same conditions as the
+// datastore repository.
+type CallbackInbox struct {
+	mu      sync.Mutex
+	entries map[string]*models.CallbackInbox
+}
+
+// NewCallbackInbox returns an empty in-memory inbox.
+func NewCallbackInbox() *CallbackInbox {
+	return &CallbackInbox{entries: make(map[string]*models.CallbackInbox)}
+}
+
+var _ repository.CallbackInboxRepository = (*CallbackInbox)(nil)
+
+func (inbox *CallbackInbox) GetByID(_ context.Context, id string) (*models.CallbackInbox, error) {
+	inbox.mu.Lock()
+	defer inbox.mu.Unlock()
+	entry, ok := inbox.entries[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return clone(entry), nil
+}
+
+func (inbox *CallbackInbox) Create(ctx context.Context, entry *models.CallbackInbox) (bool, error) {
+	inbox.mu.Lock()
+	defer inbox.mu.Unlock()
+	for _, existing := range inbox.entries {
+		if existing.Kind == entry.Kind && existing.Reference == entry.Reference {
+			return false, nil
+		}
+	}
+	if entry.GetID() == "" {
+		entry.GenID(ctx)
+	}
+	inbox.entries[entry.GetID()] = clone(entry)
+	return true, nil
+}
+
+func (inbox *CallbackInbox) Save(_ context.Context, entry *models.CallbackInbox) error {
+	inbox.mu.Lock()
+	defer inbox.mu.Unlock()
+	inbox.entries[entry.GetID()] = clone(entry)
+	return nil
+}
+
+func (inbox *CallbackInbox) Claim(_ context.Context, id string, now, leaseUntil time.Time) (bool, error) {
+	inbox.mu.Lock()
+	defer inbox.mu.Unlock()
+	entry, ok := inbox.entries[id]
+	if !ok {
+		return false, nil
+	}
+	switch entry.State {
+	case models.InboxStateReceived, models.InboxStateFailed:
+	case models.InboxStateProcessing:
+		if !due(entry, now) {
+			return false, nil
+		}
+	default:
+		return false, nil
+	}
+	entry.State = models.InboxStateProcessing
+	entry.Attempts++
+	entry.NextAttemptAt = &leaseUntil
+	return true, nil
+}
+
+func (inbox *CallbackInbox) Reschedule(_ context.Context, id string, now, at time.Time) (bool, error) {
+	inbox.mu.Lock()
+	defer inbox.mu.Unlock()
+	entry, ok := inbox.entries[id]
+	if !ok || entry.State == models.InboxStateProcessed || !due(entry, now) {
+		return false, nil
+	}
+	if entry.State == models.InboxStateProcessing {
+		entry.State = models.InboxStateFailed
+		entry.LastError = repository.ProcessingLeaseExpired
+	}
+	entry.NextAttemptAt = &at
+	return true, nil
+}
+
+func (inbox *CallbackInbox) Requeue(_ context.Context, id string, states []string, now time.Time) (bool, error) {
+	inbox.mu.Lock()
+	defer inbox.mu.Unlock()
+	entry, ok := inbox.entries[id]
+	if !ok || !slices.Contains(states, entry.State) {
+		return false, nil
+	}
+	if entry.State == models.InboxStateProcessing && !due(entry, now) {
+		return false, nil
+	}
+	entry.State = models.InboxStateReceived
+	entry.Attempts = 0
+	entry.LastError = ""
+	entry.NextAttemptAt = nil
+	return true, nil
+}
+
+func (inbox *CallbackInbox) ListDue(
+	_ context.Context,
+	now time.Time,
+	maxAttempts int,
+	limit int,
+) ([]*models.CallbackInbox, error) {
+	return inbox.list(limit, func(entry *models.CallbackInbox) bool {
+		switch entry.State {
+		case models.InboxStateReceived, models.InboxStateFailed:
+			return entry.Attempts < maxAttempts && due(entry, now)
+		case models.InboxStateProcessing:
+			return due(entry, now)
+		}
+		return false
+	}), nil
+}
+
+func (inbox *CallbackInbox) ListByState(_ context.Context, state string, limit int) ([]*models.CallbackInbox, error) {
+	return inbox.list(limit, func(entry *models.CallbackInbox) bool {
+		return entry.State == state
+	}), nil
+}
+
+func (inbox *CallbackInbox) list(limit int, match func(*models.CallbackInbox) bool) []*models.CallbackInbox {
+	inbox.mu.Lock()
+	defer inbox.mu.Unlock()
+	var entries []*models.CallbackInbox
+	for _, entry := range inbox.entries {
+		if match(entry) {
+			entries = append(entries, clone(entry))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ReceivedAt.Before(entries[j].ReceivedAt)
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+func due(entry *models.CallbackInbox, now time.Time) bool {
+	return entry.NextAttemptAt == nil || !entry.NextAttemptAt.After(now)
+}
+
+func clone(entry *models.CallbackInbox) *models.CallbackInbox {
+	copied := *entry
+	return &copied
+}
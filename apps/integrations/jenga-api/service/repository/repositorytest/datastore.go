@@ -0,0 +1,64 @@
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/antinvestor/jenga-api/config"
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/pitabwire/frame"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// NewService starts a throwaway postgres database holding the service's tables and returns
+// a service using it. Tests are skipped where no container runtime is available.
+func NewService(t *testing.T) (context.Context, *frame.Service) {
+	t.Helper()
+	ctx := context.Background()
+
+	postgres, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "postgres:latest",
+			ExposedPorts: []string{"5432/tcp"},
+			Env: map[string]string{
+				"POSTGRES_USER":     "ant",
+				"POSTGRES_PASSWORD": "secret",
+				"POSTGRES_DB":       "service_jenga",
+			},
+			WaitingFor: wait.ForListeningPort("5432/tcp").WithStartupTimeout(5 * time.Minute),
+		},
+		Started: true,
+	})
+	if err != nil {
+		t.Skipf("could not start postgres: %v", err)
+	}
+	t.Cleanup(func() { _ = postgres.Terminate(ctx) })
+
+	host, err := postgres.Host(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := postgres.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbURL := fmt.Sprintf("postgres://ant:secret@%s/service_jenga?sslmode=disable",
+		net.JoinHostPort(host, port.Port()))
+
+	cfg, err := frame.ConfigFromEnv[config.JengaConfig]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, service := frame.NewService(t.Name(),
+		frame.WithDatastoreConnection(dbURL, false), frame.WithConfig(&cfg), frame.WithNoopDriver())
+	t.Cleanup(func() { service.Stop(ctx) })
+
+	if err = service.DB(ctx, false).AutoMigrate(&models.CallbackRejection{}, &models.CallbackInbox{}, &models.PaymentJob{}); err != nil {
+		t.Fatal(err)
+	}
+	return ctx, service
+}
@@ -0,0 +1,81 @@
+package repositorytest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/antinvestor/jenga-api/service/models"
+	"github.com/antinvestor/jenga-api/service/repository"
+	"gorm.io/gorm"
+)
+
+// PaymentJobs keeps payment jobs in memory.
+type PaymentJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*models.PaymentJob
+}
+
+// NewPaymentJobs returns an empty in-memory payment job store.
+func NewPaymentJobs() *PaymentJobs {
+	return &PaymentJobs{jobs: make(map[string]*models.PaymentJob)}
+}
+
+var _ repository.PaymentJobRepository = (*PaymentJobs)(nil)
+
+func (store *PaymentJobs) GetByJobID(_ context.Context, jobID string) (*models.PaymentJob, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	job, ok := store.jobs[jobID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (store *PaymentJobs) Create(ctx context.Context, job *models.PaymentJob) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if _, ok := store.jobs[job.JobID]; ok {
+		return false, nil
+	}
+	if job.GetID() == "" {
+		job.GenID(ctx)
+	}
+	copied := *job
+	store.jobs[job.JobID] = &copied
+	return true, nil
+}
+
+func (store *PaymentJobs) Save(_ context.Context, job *models.PaymentJob) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	copied := *job
+	store.jobs[job.JobID] = &copied
+	return nil
+}
+
+func (store *PaymentJobs) ListSubmitted(
+	_ context.Context,
+	kind string,
+	before time.Time,
+	limit int,
+) ([]*models.PaymentJob, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	var jobs []*models.PaymentJob
+	for _, job := range store.jobs {
+		if job.Kind == kind && job.State == models.PaymentJobStateSubmitted &&
+			job.SubmittedAt != nil && !job.SubmittedAt.After(before) {
+			copied := *job
+			jobs = append(jobs, &copied)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].SubmittedAt.Before(*jobs[j].SubmittedAt) })
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
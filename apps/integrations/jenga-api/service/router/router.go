@@ -1,16 +1,61 @@
 package router
 
 import (
+	"net/http"
+
 	handlers "github.com/antinvestor/jenga-api/service/handler"
 	"github.com/gorilla/mux"
 )
 
-func NewRouter(js *handlers.JobServer) *mux.Router {
+// NewRouter builds the service routes, callbackAuth guards the endpoints Jenga calls back on
+// while serviceAuth guards the endpoints other services and operators call. Without serviceAuth
+// those endpoints refuse every request, they move money, expose account holders and balances,
+// and replay stored callbacks.
+func NewRouter(js *handlers.JobServer, callbackAuth, serviceAuth mux.MiddlewareFunc) *mux.Router {
+	if callbackAuth == nil {
+		callbackAuth = passThrough
+	}
+	if serviceAuth == nil {
+		serviceAuth = denyAll
+	}
+
 	router := mux.NewRouter().StrictSlash(true)
 	// Health check endpoint
 	router.HandleFunc("/health", handlers.HealthHandler).Methods("GET")
-	// Callback endpoint
-	router.HandleFunc("/receivepayments", js.HandleStkCallback).Methods("POST")
-	router.HandleFunc("/payments/tills-pay", js.InitiateTillsPay).Methods("POST")
+	// Callback endpoints, one per Jenga callback family
+	callbacks := router.PathPrefix("/callbacks").Subrouter()
+	callbacks.Use(callbackAuth)
+	callbacks.HandleFunc("/stk", js.HandleStkCallback).Methods("POST")
+	callbacks.HandleFunc("/ipn", js.HandleIPNCallback).Methods("POST")
+	callbacks.HandleFunc("/tills", js.HandleTillsCallback).Methods("POST")
+	callbacks.HandleFunc("/payment-link", js.HandlePaymentLinkCallback).Methods("POST")
+	// Kept for STK callbacks already configured against the original URL
+	router.Handle("/receivepayments", callbackAuth(http.HandlerFunc(js.HandleStkCallback))).Methods("POST")
+	// Endpoints called by other services
+	services := router.NewRoute().Subrouter()
+	services.Use(serviceAuth)
+	services.HandleFunc("/payments/tills-pay", js.InitiateTillsPay).Methods("POST")
+	// Bill payments
+	services.HandleFunc("/billers", js.ListBillers).Methods("GET")
+	services.HandleFunc("/bills/validate", js.ValidateBill).Methods("POST")
+	services.HandleFunc("/bills/pay", js.PayBill).Methods("POST")
+	// Beneficiary (KYC) lookups
+	services.HandleFunc("/kyc/lookup", js.LookupBeneficiary).Methods("POST")
+	services.HandleFunc("/accounts/balance", js.AccountBalance).Methods("GET")
+	// Callback inbox administration
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.Use(serviceAuth)
+	admin.HandleFunc("/callbacks", js.ListCallbacks).Methods("GET")
+	admin.HandleFunc("/callbacks/{id}/replay", js.ReplayCallback).Methods("POST")
 	return router
 }
+
+func passThrough(next http.Handler) http.Handler {
+	return next
+}
+
+func denyAll(http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	})
+}
@@ -0,0 +1,63 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	handlers "github.com/antinvestor/jenga-api/service/handler"
+	"github.com/antinvestor/jenga-api/service/router"
+	"github.com/pitabwire/frame"
+	"github.com/stretchr/testify/assert"
+)
+
+// bearerAuth lets through the requests carrying the service token.
+func bearerAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer service-token" {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestServiceEndpointsRequireAuthentication(t *testing.T) {
+	ctx, service := frame.NewService("jenga_router_test")
+	t.Cleanup(func() { service.Stop(ctx) })
+	js := &handlers.JobServer{Service: service}
+
+	endpoints := []struct {
+		method string
+		path   string
+	}{
+		{method: http.MethodPost, path: "/payments/tills-pay"},
+		{method: http.MethodGet, path: "/billers"},
+		{method: http.MethodPost, path: "/bills/validate"},
+		{method: http.MethodPost, path: "/bills/pay"},
+		{method: http.MethodPost, path: "/kyc/lookup"},
+		{method: http.MethodGet, path: "/accounts/balance"},
+	}
+
+	serve := func(serviceAuth func(http.Handler) http.Handler, method, path, token string) int {
+		request := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		router.NewRouter(js, nil, serviceAuth).ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	for _, endpoint := range endpoints {
+		t.Run(endpoint.path, func(t *testing.T) {
+			// Without service authentication configured every request is refused
+			assert.Equal(t, http.StatusForbidden, serve(nil, endpoint.method, endpoint.path, "service-token"))
+			assert.Equal(t, http.StatusUnauthorized, serve(bearerAuth, endpoint.method, endpoint.path, ""))
+			assert.NotContains(t, []int{http.StatusUnauthorized, http.StatusForbidden},
+				serve(bearerAuth, endpoint.method, endpoint.path, "service-token"))
+		})
+	}
+
+	assert.Equal(t, http.StatusOK, serve(nil, http.MethodGet, "/health", ""))
+}